@@ -22,7 +22,6 @@ import (
 	"io"
 	"math/big"
 	"reflect"
-	"strings"
 
 	"github.com/EarthDollar/go-earthdollar/common"
 )
@@ -77,210 +76,159 @@ func (abi ABI) Pack(name string, args ...interface{}) ([]byte, error) {
 	return append(method.Id(), arguments...), nil
 }
 
-// toGoSliceType parses the input and casts it to the proper slice defined by the ABI
-// argument in T.
-func toGoSlice(i int, t Argument, output []byte) (interface{}, error) {
-	index := i * 32
-	// The slice must, at very least be large enough for the index+32 which is exactly the size required
-	// for the [offset in output, size of offset].
+// readOffset reads the offset or length word at index of the output, making
+// sure it doesn't exceed the size of the output.
+func readOffset(output []byte, index int) (int, error) {
 	if index+32 > len(output) {
-		return nil, fmt.Errorf("abi: cannot marshal in to go slice: insufficient size output %d require %d", len(output), index+32)
+		return 0, fmt.Errorf("abi: cannot marshal in to go type: length insufficient %d require %d", len(output), index+32)
 	}
-	elem := t.Type.Elem
-
-	// first we need to create a slice of the type
-	var refSlice reflect.Value
-	switch elem.T {
-	case IntTy, UintTy, BoolTy:
-		// create a new reference slice matching the element type
-		switch t.Type.Kind {
-		case reflect.Bool:
-			refSlice = reflect.ValueOf([]bool(nil))
-		case reflect.Uint8:
-			refSlice = reflect.ValueOf([]uint8(nil))
-		case reflect.Uint16:
-			refSlice = reflect.ValueOf([]uint16(nil))
-		case reflect.Uint32:
-			refSlice = reflect.ValueOf([]uint32(nil))
-		case reflect.Uint64:
-			refSlice = reflect.ValueOf([]uint64(nil))
-		case reflect.Int8:
-			refSlice = reflect.ValueOf([]int8(nil))
-		case reflect.Int16:
-			refSlice = reflect.ValueOf([]int16(nil))
-		case reflect.Int32:
-			refSlice = reflect.ValueOf([]int32(nil))
-		case reflect.Int64:
-			refSlice = reflect.ValueOf([]int64(nil))
-		default:
-			refSlice = reflect.ValueOf([]*big.Int(nil))
-		}
-	case AddressTy: // address must be of slice Address
-		refSlice = reflect.ValueOf([]common.Address(nil))
-	case HashTy: // hash must be of slice hash
-		refSlice = reflect.ValueOf([]common.Hash(nil))
-	case FixedBytesTy:
-		refSlice = reflect.ValueOf([][]byte(nil))
-	default: // no other types are supported
-		return nil, fmt.Errorf("abi: unsupported slice type %v", elem.T)
+	num := new(big.Int).SetBytes(output[index : index+32])
+	if num.BitLen() > 31 || int(num.Int64()) > len(output) {
+		return 0, fmt.Errorf("abi: cannot marshal in to go type: offset %v would go over slice boundary (len=%d)", num, len(output))
 	}
+	return int(num.Int64()), nil
+}
 
-	var slice []byte
-	var size int
-	var offset int
-	if t.Type.IsSlice {
-
-		// get the offset which determines the start of this array ...
-		offset = int(common.BytesToBig(output[index : index+32]).Uint64())
-		if offset+32 > len(output) {
-			return nil, fmt.Errorf("abi: cannot marshal in to go slice: offset %d would go over slice boundary (len=%d)", len(output), offset+32)
-		}
-
-		slice = output[offset:]
-		// ... starting with the size of the array in elements ...
-		size = int(common.BytesToBig(slice[:32]).Uint64())
-		slice = slice[32:]
-		// ... and make sure that we've at the very least the amount of bytes
-		// available in the buffer.
-		if size*32 > len(slice) {
-			return nil, fmt.Errorf("abi: cannot marshal in to go slice: insufficient size output %d require %d", len(output), offset+32+size*32)
-		}
-
-		// reslice to match the required size
-		slice = slice[:(size * 32)]
-	} else if t.Type.IsArray {
-		//get the number of elements in the array
-		size = t.Type.SliceSize
-
-		//check to make sure array size matches up
-		if index+32*size > len(output) {
-			return nil, fmt.Errorf("abi: cannot marshal in to go array: offset %d would go over slice boundary (len=%d)", len(output), index+32*size)
-		}
-		//slice is there for a fixed amount of times
-		slice = output[index : index+size*32]
+// readInteger converts an output word to the integer type specified by the ABI.
+func readInteger(t Type, word []byte) interface{} {
+	num := new(big.Int).SetBytes(word)
+	if t.T == IntTy {
+		num = common.S256(num)
 	}
+	switch t.Kind {
+	case reflect.Uint8:
+		return uint8(num.Uint64())
+	case reflect.Uint16:
+		return uint16(num.Uint64())
+	case reflect.Uint32:
+		return uint32(num.Uint64())
+	case reflect.Uint64:
+		return num.Uint64()
+	case reflect.Int8:
+		return int8(num.Int64())
+	case reflect.Int16:
+		return int16(num.Int64())
+	case reflect.Int32:
+		return int32(num.Int64())
+	case reflect.Int64:
+		return num.Int64()
+	}
+	return num
+}
 
+// toGoSlice parses size consecutive elements of the list type t from output
+// into a Go slice of the proper element type.
+func toGoSlice(t Type, output []byte, size int) (interface{}, error) {
+	var (
+		slice    = reflect.MakeSlice(t.goType(), size, size)
+		elemSize = getTypeSize(*t.Elem)
+	)
 	for i := 0; i < size; i++ {
-		var (
-			inter        interface{}             // interface type
-			returnOutput = slice[i*32 : i*32+32] // the return output
-		)
-
-		// set inter to the correct type (cast)
-		switch elem.T {
-		case IntTy, UintTy:
-			bigNum := common.BytesToBig(returnOutput)
-			switch t.Type.Kind {
-			case reflect.Uint8:
-				inter = uint8(bigNum.Uint64())
-			case reflect.Uint16:
-				inter = uint16(bigNum.Uint64())
-			case reflect.Uint32:
-				inter = uint32(bigNum.Uint64())
-			case reflect.Uint64:
-				inter = bigNum.Uint64()
-			case reflect.Int8:
-				inter = int8(bigNum.Int64())
-			case reflect.Int16:
-				inter = int16(bigNum.Int64())
-			case reflect.Int32:
-				inter = int32(bigNum.Int64())
-			case reflect.Int64:
-				inter = bigNum.Int64()
-			default:
-				inter = common.BytesToBig(returnOutput)
-			}
-		case BoolTy:
-			inter = common.BytesToBig(returnOutput).Uint64() > 0
-		case AddressTy:
-			inter = common.BytesToAddress(returnOutput)
-		case HashTy:
-			inter = common.BytesToHash(returnOutput)
-		case FixedBytesTy:
-			inter = returnOutput
+		elem, err := toGoType(*t.Elem, output, i*elemSize)
+		if err != nil {
+			return nil, err
 		}
-		// append the item to our reflect slice
-		refSlice = reflect.Append(refSlice, reflect.ValueOf(inter))
+		slice.Index(i).Set(reflect.ValueOf(elem))
 	}
-
-	// return the interface
-	return refSlice.Interface(), nil
+	return slice.Interface(), nil
 }
 
-// toGoType parses the input and casts it to the proper type defined by the ABI
-// argument in T.
-func toGoType(i int, t Argument, output []byte) (interface{}, error) {
-	// we need to treat slices differently
-	if (t.Type.IsSlice || t.Type.IsArray) && t.Type.T != BytesTy && t.Type.T != StringTy && t.Type.T != FixedBytesTy && t.Type.T != FunctionTy {
-		return toGoSlice(i, t, output)
-	}
-
-	index := i * 32
+// toGoType parses the value of ABI type t located at index of the output and
+// casts it to the proper Go type. Dynamic values are referenced by an offset
+// relative to the start of output.
+func toGoType(t Type, output []byte, index int) (interface{}, error) {
 	if index+32 > len(output) {
 		return nil, fmt.Errorf("abi: cannot marshal in to go type: length insufficient %d require %d", len(output), index+32)
 	}
+	switch {
+	case t.isList():
+		start, size := index, t.SliceSize
+		if isDynamicType(t) {
+			offset, err := readOffset(output, index)
+			if err != nil {
+				return nil, err
+			}
+			start = offset
+		}
+		if t.IsSlice {
+			length, err := readOffset(output, start)
+			if err != nil {
+				return nil, err
+			}
+			start, size = start+32, length
+		}
+		return toGoSlice(t, output[start:], size)
 
-	// Parse the given index output and check whether we need to read
-	// a different offset and length based on the type (i.e. string, bytes)
-	var returnOutput []byte
-	switch t.Type.T {
-	case StringTy, BytesTy: // variable arrays are written at the end of the return bytes
-		// parse offset from which we should start reading
-		offset := int(common.BytesToBig(output[index : index+32]).Uint64())
-		if offset+32 > len(output) {
-			return nil, fmt.Errorf("abi: cannot marshal in to go type: length insufficient %d require %d", len(output), offset+32)
+	case t.T == TupleTy:
+		if isDynamicType(t) {
+			offset, err := readOffset(output, index)
+			if err != nil {
+				return nil, err
+			}
+			output, index = output[offset:], 0
+		}
+		tuple := reflect.New(t.Type).Elem()
+		for i, elem := range t.TupleElems {
+			field, err := toGoType(*elem, output, index)
+			if err != nil {
+				return nil, err
+			}
+			tuple.Field(i).Set(reflect.ValueOf(field))
+			index += getTypeSize(*elem)
+		}
+		return tuple.Interface(), nil
+
+	case t.T == StringTy || t.T == BytesTy:
+		// variable arrays are written at the end of the return bytes
+		offset, err := readOffset(output, index)
+		if err != nil {
+			return nil, err
+		}
+		size, err := readOffset(output, offset)
+		if err != nil {
+			return nil, err
 		}
-		// parse the size up until we should be reading
-		size := int(common.BytesToBig(output[offset : offset+32]).Uint64())
 		if offset+32+size > len(output) {
 			return nil, fmt.Errorf("abi: cannot marshal in to go type: length insufficient %d require %d", len(output), offset+32+size)
 		}
-
-		// get the bytes for this return value
-		returnOutput = output[offset+32 : offset+32+size]
-	default:
-		returnOutput = output[index : index+32]
+		if t.T == StringTy {
+			return string(output[offset+32 : offset+32+size]), nil
+		}
+		return output[offset+32 : offset+32+size], nil
 	}
 
-	// convert the bytes to whatever is specified by the ABI.
-	switch t.Type.T {
+	// convert the word to whatever is specified by the ABI.
+	word := output[index : index+32]
+	switch t.T {
 	case IntTy, UintTy:
-		bigNum := common.BytesToBig(returnOutput)
-
-		// If the type is a integer convert to the integer type
-		// specified by the ABI.
-		switch t.Type.Kind {
-		case reflect.Uint8:
-			return uint8(bigNum.Uint64()), nil
-		case reflect.Uint16:
-			return uint16(bigNum.Uint64()), nil
-		case reflect.Uint32:
-			return uint32(bigNum.Uint64()), nil
-		case reflect.Uint64:
-			return uint64(bigNum.Uint64()), nil
-		case reflect.Int8:
-			return int8(bigNum.Int64()), nil
-		case reflect.Int16:
-			return int16(bigNum.Int64()), nil
-		case reflect.Int32:
-			return int32(bigNum.Int64()), nil
-		case reflect.Int64:
-			return int64(bigNum.Int64()), nil
-		case reflect.Ptr:
-			return bigNum, nil
-		}
+		return readInteger(t, word), nil
 	case BoolTy:
-		return common.BytesToBig(returnOutput).Uint64() > 0, nil
+		return new(big.Int).SetBytes(word).Sign() > 0, nil
 	case AddressTy:
-		return common.BytesToAddress(returnOutput), nil
+		return common.BytesToAddress(word), nil
 	case HashTy:
-		return common.BytesToHash(returnOutput), nil
-	case BytesTy, FixedBytesTy, FunctionTy:
-		return returnOutput, nil
-	case StringTy:
-		return string(returnOutput), nil
+		return common.BytesToHash(word), nil
+	case FixedBytesTy, FunctionTy:
+		return word, nil
 	}
-	return nil, fmt.Errorf("abi: unknown type %v", t.Type.T)
+	return nil, fmt.Errorf("abi: unknown type %v", t.T)
+}
+
+// readArguments parses all arguments from the output, in order.
+func readArguments(args []Argument, output []byte) ([]interface{}, error) {
+	var (
+		values = make([]interface{}, len(args))
+		index  = 0
+	)
+	for i, arg := range args {
+		value, err := toGoType(arg.Type, output, index)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+		index += getTypeSize(arg.Type)
+	}
+	return values, nil
 }
 
 // these variable are used to determine certain types during type assertion for
@@ -292,96 +240,101 @@ var (
 	r_byte       = reflect.TypeOf(byte(0))
 )
 
-// Unpack output in v according to the abi specification
+// Unpack output in v according to the abi specification. The name is looked up
+// among the methods first, and the non-indexed inputs of the event of the same
+// name are used otherwise.
 func (abi ABI) Unpack(v interface{}, name string, output []byte) error {
-	var method = abi.Methods[name]
-
 	if len(output) == 0 {
 		return fmt.Errorf("abi: unmarshalling empty output")
 	}
+	if method, ok := abi.Methods[name]; ok {
+		return unpackArguments(v, method.Outputs, output)
+	}
+	if event, ok := abi.Events[name]; ok {
+		return unpackArguments(v, event.nonIndexed(), output)
+	}
+	return fmt.Errorf("abi: could not locate named method or event: %s", name)
+}
 
+// unpackArguments unpacks the output according to the arguments into v, which
+// must be a pointer to a struct or an []interface{} in case of multiple values.
+func unpackArguments(v interface{}, args []Argument, output []byte) error {
 	// make sure the passed value is a pointer
 	valueOf := reflect.ValueOf(v)
 	if reflect.Ptr != valueOf.Kind() {
 		return fmt.Errorf("abi: Unpack(non-pointer %T)", v)
 	}
-
+	if len(args) == 0 {
+		return fmt.Errorf("abi: no values to unmarshal")
+	}
+	values, err := readArguments(args, output)
+	if err != nil {
+		return err
+	}
 	var (
 		value = valueOf.Elem()
 		typ   = value.Type()
 	)
-
-	if len(method.Outputs) > 1 {
-		switch value.Kind() {
-		// struct will match named return values to the struct's field
-		// names
-		case reflect.Struct:
-			for i := 0; i < len(method.Outputs); i++ {
-				marshalledValue, err := toGoType(i, method.Outputs[i], output)
-				if err != nil {
-					return err
-				}
-				reflectValue := reflect.ValueOf(marshalledValue)
-
-				for j := 0; j < typ.NumField(); j++ {
-					field := typ.Field(j)
-					// TODO read tags: `abi:"fieldName"`
-					if field.Name == strings.ToUpper(method.Outputs[i].Name[:1])+method.Outputs[i].Name[1:] {
-						if err := set(value.Field(j), reflectValue, method.Outputs[i]); err != nil {
-							return err
-						}
-					}
-				}
-			}
-		case reflect.Slice:
-			if !value.Type().AssignableTo(r_interSlice) {
-				return fmt.Errorf("abi: cannot marshal tuple in to slice %T (only []interface{} is supported)", v)
+	// a single value is assigned directly, unless it's a field of a struct
+	if len(args) == 1 && (value.Kind() != reflect.Struct || (args[0].Type.T == TupleTy && !args[0].Type.isList())) {
+		return set(value, reflect.ValueOf(values[0]), args[0])
+	}
+	switch value.Kind() {
+	// struct will match named return values to the struct's field
+	// names
+	case reflect.Struct:
+		for i, arg := range args {
+			if err := setField(value, arg, values[i]); err != nil {
+				return err
 			}
+		}
+	case reflect.Slice:
+		if !value.Type().AssignableTo(r_interSlice) {
+			return fmt.Errorf("abi: cannot marshal tuple in to slice %T (only []interface{} is supported)", v)
+		}
 
-			// if the slice already contains values, set those instead of the interface slice itself.
-			if value.Len() > 0 {
-				if len(method.Outputs) > value.Len() {
-					return fmt.Errorf("abi: cannot marshal in to slices of unequal size (require: %v, got: %v)", len(method.Outputs), value.Len())
-				}
-
-				for i := 0; i < len(method.Outputs); i++ {
-					marshalledValue, err := toGoType(i, method.Outputs[i], output)
-					if err != nil {
-						return err
-					}
-					reflectValue := reflect.ValueOf(marshalledValue)
-					if err := set(value.Index(i).Elem(), reflectValue, method.Outputs[i]); err != nil {
-						return err
-					}
-				}
-				return nil
+		// if the slice already contains values, set those instead of the interface slice itself.
+		if value.Len() > 0 {
+			if len(args) > value.Len() {
+				return fmt.Errorf("abi: cannot marshal in to slices of unequal size (require: %v, got: %v)", len(args), value.Len())
 			}
-
-			// create a new slice and start appending the unmarshalled
-			// values to the new interface slice.
-			z := reflect.MakeSlice(typ, 0, len(method.Outputs))
-			for i := 0; i < len(method.Outputs); i++ {
-				marshalledValue, err := toGoType(i, method.Outputs[i], output)
-				if err != nil {
+			for i, arg := range args {
+				if err := set(value.Index(i).Elem(), reflect.ValueOf(values[i]), arg); err != nil {
 					return err
 				}
-				z = reflect.Append(z, reflect.ValueOf(marshalledValue))
 			}
-			value.Set(z)
-		default:
-			return fmt.Errorf("abi: cannot unmarshal tuple in to %v", typ)
+			return nil
 		}
 
-	} else {
-		marshalledValue, err := toGoType(0, method.Outputs[0], output)
-		if err != nil {
-			return err
-		}
-		if err := set(value, reflect.ValueOf(marshalledValue), method.Outputs[0]); err != nil {
-			return err
+		// create a new slice and start appending the unmarshalled
+		// values to the new interface slice.
+		z := reflect.MakeSlice(typ, 0, len(args))
+		for _, value := range values {
+			z = reflect.Append(z, reflect.ValueOf(value))
 		}
+		value.Set(z)
+	default:
+		return fmt.Errorf("abi: cannot unmarshal tuple in to %v", typ)
 	}
+	return nil
+}
 
+// setField assigns the value of an argument to the field of the struct named
+// after it, either by an `abi:"name"` tag or the camel cased argument name.
+// Arguments without a matching field are skipped.
+func setField(value reflect.Value, arg Argument, v interface{}) error {
+	typ := value.Type()
+	for j := 0; j < typ.NumField(); j++ {
+		field := typ.Field(j)
+		if tag := field.Tag.Get("abi"); tag != "" {
+			if tag != arg.Name {
+				continue
+			}
+		} else if arg.Name == "" || field.Name != ToCamelCase(arg.Name) {
+			continue
+		}
+		return set(value.Field(j), reflect.ValueOf(v), arg)
+	}
 	return nil
 }
 
@@ -1239,3 +1239,109 @@ func TestUnmarshal(t *testing.T) {
 		t.Fatal("expected error:", err)
 	}
 }
+
+// Tests that nested dynamic arrays are packed and unpacked according to the
+// example of the ABI specification.
+func TestNestedDynamicArrays(t *testing.T) {
+	const definition = `[
+	{ "type" : "function", "name" : "g", "inputs" : [ { "name" : "a", "type" : "uint256[][]" }, { "name" : "b", "type" : "string[]" } ],
+	  "outputs" : [ { "name" : "a", "type" : "uint256[][]" }, { "name" : "b", "type" : "string[]" } ] }]`
+	abi, err := JSON(strings.NewReader(definition))
+	if err != nil {
+		t.Fatal(err)
+	}
+	nums := [][]*big.Int{{big.NewInt(1), big.NewInt(2)}, {big.NewInt(3)}}
+	strs := []string{"one", "two", "three"}
+
+	packed, err := abi.Pack("g", nums, strs)
+	if err != nil {
+		t.Fatalf("failed to pack: %v", err)
+	}
+	want := common.Hex2Bytes("2289b18c" +
+		"0000000000000000000000000000000000000000000000000000000000000040" +
+		"0000000000000000000000000000000000000000000000000000000000000140" +
+		"0000000000000000000000000000000000000000000000000000000000000002" +
+		"0000000000000000000000000000000000000000000000000000000000000040" +
+		"00000000000000000000000000000000000000000000000000000000000000a0" +
+		"0000000000000000000000000000000000000000000000000000000000000002" +
+		"0000000000000000000000000000000000000000000000000000000000000001" +
+		"0000000000000000000000000000000000000000000000000000000000000002" +
+		"0000000000000000000000000000000000000000000000000000000000000001" +
+		"0000000000000000000000000000000000000000000000000000000000000003" +
+		"0000000000000000000000000000000000000000000000000000000000000003" +
+		"0000000000000000000000000000000000000000000000000000000000000060" +
+		"00000000000000000000000000000000000000000000000000000000000000a0" +
+		"00000000000000000000000000000000000000000000000000000000000000e0" +
+		"0000000000000000000000000000000000000000000000000000000000000003" +
+		"6f6e650000000000000000000000000000000000000000000000000000000000" +
+		"0000000000000000000000000000000000000000000000000000000000000003" +
+		"74776f0000000000000000000000000000000000000000000000000000000000" +
+		"0000000000000000000000000000000000000000000000000000000000000005" +
+		"7468726565000000000000000000000000000000000000000000000000000000")
+	if !bytes.Equal(packed, want) {
+		t.Fatalf("packed output mismatch:\n  have %x\n  want %x", packed, want)
+	}
+	var out struct {
+		A [][]*big.Int
+		B []string
+	}
+	if err := abi.Unpack(&out, "g", packed[4:]); err != nil {
+		t.Fatalf("failed to unpack: %v", err)
+	}
+	if fmt.Sprint(out.A) != fmt.Sprint(nums) || !reflect.DeepEqual(out.B, strs) {
+		t.Fatalf("unpacked output mismatch: have %v %v, want %v %v", out.A, out.B, nums, strs)
+	}
+}
+
+// Tests that tuples, including dynamic ones and arrays of them, survive a pack
+// and unpack round trip and are assignable to user defined structs.
+func TestTupleRoundTrip(t *testing.T) {
+	const definition = `[
+	{ "type" : "function", "name" : "f", "inputs" : [
+		{ "name" : "s", "type" : "tuple", "components" : [ { "name" : "id", "type" : "uint64" }, { "name" : "owner_addr", "type" : "address" }, { "name" : "label", "type" : "string" } ] },
+		{ "name" : "ps", "type" : "tuple[2]", "components" : [ { "name" : "x", "type" : "int256" }, { "name" : "y", "type" : "int256" } ] },
+		{ "name" : "n", "type" : "uint256" } ],
+	  "outputs" : [
+		{ "name" : "s", "type" : "tuple", "components" : [ { "name" : "id", "type" : "uint64" }, { "name" : "owner_addr", "type" : "address" }, { "name" : "label", "type" : "string" } ] },
+		{ "name" : "ps", "type" : "tuple[2]", "components" : [ { "name" : "x", "type" : "int256" }, { "name" : "y", "type" : "int256" } ] },
+		{ "name" : "n", "type" : "uint256" } ] }]`
+	abi, err := JSON(strings.NewReader(definition))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sig := abi.Methods["f"].Sig(); sig != "f((uint64,address,string),(int256,int256)[2],uint256)" {
+		t.Fatalf("signature mismatch: have %s", sig)
+	}
+	type item struct {
+		Id        uint64
+		OwnerAddr common.Address
+		Label     string
+	}
+	type point struct {
+		X, Y *big.Int
+	}
+	var (
+		s  = item{Id: 7, OwnerAddr: common.HexToAddress("0x0102"), Label: "earthdollar"}
+		ps = [2]point{{big.NewInt(-1), big.NewInt(2)}, {big.NewInt(3), big.NewInt(-4)}}
+		n  = big.NewInt(42)
+	)
+	packed, err := abi.Pack("f", s, ps, n)
+	if err != nil {
+		t.Fatalf("failed to pack: %v", err)
+	}
+	// head: offset of s, 4 words of ps, n; the tail holds the dynamic tuple
+	if have := common.BytesToBig(packed[4:36]).Int64(); have != 6*32 {
+		t.Fatalf("dynamic tuple offset mismatch: have %d, want %d", have, 6*32)
+	}
+	var out struct {
+		S  item
+		Ps [2]point
+		N  *big.Int
+	}
+	if err := abi.Unpack(&out, "f", packed[4:]); err != nil {
+		t.Fatalf("failed to unpack: %v", err)
+	}
+	if !reflect.DeepEqual(out.S, s) || fmt.Sprint(out.Ps) != fmt.Sprint(ps) || out.N.Cmp(n) != 0 {
+		t.Fatalf("unpacked output mismatch: have %+v, want %+v %v %v", out, s, ps, n)
+	}
+}
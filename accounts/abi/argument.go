@@ -31,16 +31,17 @@ type Argument struct {
 
 func (a *Argument) UnmarshalJSON(data []byte) error {
 	var extarg struct {
-		Name    string
-		Type    string
-		Indexed bool
+		Name       string
+		Type       string
+		Indexed    bool
+		Components []Argument
 	}
 	err := json.Unmarshal(data, &extarg)
 	if err != nil {
 		return fmt.Errorf("argument json err: %v", err)
 	}
 
-	a.Type, err = NewType(extarg.Type)
+	a.Type, err = newType(extarg.Type, extarg.Components)
 	if err != nil {
 		return err
 	}
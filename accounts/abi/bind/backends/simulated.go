@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"math/big"
 	"sync"
+	"time"
 
 	"github.com/EarthDollar/go-earthdollar"
 	"github.com/EarthDollar/go-earthdollar/accounts/abi/bind"
@@ -29,29 +30,50 @@ import (
 	"github.com/EarthDollar/go-earthdollar/core/state"
 	"github.com/EarthDollar/go-earthdollar/core/types"
 	"github.com/EarthDollar/go-earthdollar/core/vm"
+	"github.com/EarthDollar/go-earthdollar/eth/filters"
 	"github.com/EarthDollar/go-earthdollar/ethdb"
 	"github.com/EarthDollar/go-earthdollar/event"
 	"github.com/EarthDollar/go-earthdollar/params"
+	"github.com/EarthDollar/go-earthdollar/rpc"
 	"golang.org/x/net/context"
 )
 
 // Default chain configuration which sets homestead phase at block 0 (i.e. no frontier)
 var chainConfig = &params.ChainConfig{HomesteadBlock: big.NewInt(0), EIP150Block: new(big.Int), EIP158Block: new(big.Int)}
 
-// This nil assignment ensures compile time that SimulatedBackend implements bind.ContractBackend.
-var _ bind.ContractBackend = (*SimulatedBackend)(nil)
+// These nil assignments ensure compile time that SimulatedBackend implements
+// bind.ContractBackend and the same chain access interfaces as edclient.Client.
+var (
+	_ bind.ContractBackend           = (*SimulatedBackend)(nil)
+	_ ethereum.ChainReader           = (*SimulatedBackend)(nil)
+	_ ethereum.TransactionReader     = (*SimulatedBackend)(nil)
+	_ ethereum.ChainStateReader      = (*SimulatedBackend)(nil)
+	_ ethereum.ChainSyncReader       = (*SimulatedBackend)(nil)
+	_ ethereum.ContractCaller        = (*SimulatedBackend)(nil)
+	_ ethereum.GasEstimator          = (*SimulatedBackend)(nil)
+	_ ethereum.GasPricer             = (*SimulatedBackend)(nil)
+	_ ethereum.LogFilterer           = (*SimulatedBackend)(nil)
+	_ ethereum.PendingStateReader    = (*SimulatedBackend)(nil)
+	_ ethereum.PendingContractCaller = (*SimulatedBackend)(nil)
+)
 
-var errBlockNumberUnsupported = errors.New("SimulatedBackend cannot access blocks other than the latest block")
+var (
+	errBlockNumberUnsupported = errors.New("SimulatedBackend cannot access blocks beyond the latest block")
+	errBlockTimeOutOfRange    = errors.New("pending block time must be after its parent")
+)
 
 // SimulatedBackend implements bind.ContractBackend, simulating a blockchain in
 // the background. Its main purpose is to allow easily testing contract bindings.
 type SimulatedBackend struct {
-	database   ethdb.Database   // In memory database to store our testing data
-	blockchain *core.BlockChain // Ethereum blockchain to handle the consensus
+	database   ethdb.Database       // In memory database to store our testing data
+	blockchain *core.BlockChain     // Ethereum blockchain to handle the consensus
+	mux        *event.TypeMux       // Event mux the blockchain posts its chain events to
+	events     *filters.EventSystem // Event system for filtering log events live
 
 	mu           sync.Mutex
 	pendingBlock *types.Block   // Currently pending block that will be imported on request
 	pendingState *state.StateDB // Currently pending state that will be the active on on request
+	timeOffset   int64          // Seconds the pending block's time is shifted by
 
 	config *params.ChainConfig
 }
@@ -61,8 +83,10 @@ type SimulatedBackend struct {
 func NewSimulatedBackend(accounts ...core.GenesisAccount) *SimulatedBackend {
 	database, _ := ethdb.NewMemDatabase()
 	core.WriteGenesisBlockForTesting(database, accounts...)
-	blockchain, _ := core.NewBlockChain(database, chainConfig, new(core.FakePow), new(event.TypeMux), vm.Config{})
-	backend := &SimulatedBackend{database: database, blockchain: blockchain}
+	mux := new(event.TypeMux)
+	blockchain, _ := core.NewBlockChain(database, chainConfig, new(core.FakePow), mux, vm.Config{})
+	backend := &SimulatedBackend{database: database, blockchain: blockchain, mux: mux}
+	backend.events = filters.NewEventSystem(mux, &filterBackend{database, blockchain, mux}, false)
 	backend.rollback()
 	return backend
 }
@@ -88,20 +112,74 @@ func (b *SimulatedBackend) Rollback() {
 }
 
 func (b *SimulatedBackend) rollback() {
-	blocks, _ := core.GenerateChain(chainConfig, b.blockchain.CurrentBlock(), b.database, 1, func(int, *core.BlockGen) {})
+	b.timeOffset = 0
+	b.regenerate(nil)
+}
+
+// regenerate rebuilds the pending block on top of the current head out of the
+// given transactions, applying the configured time offset.
+func (b *SimulatedBackend) regenerate(txs types.Transactions) {
+	blocks, _ := core.GenerateChain(chainConfig, b.blockchain.CurrentBlock(), b.database, 1, func(number int, block *core.BlockGen) {
+		for _, tx := range txs {
+			block.AddTx(tx)
+		}
+		if b.timeOffset != 0 {
+			block.OffsetTime(b.timeOffset)
+		}
+	})
 	b.pendingBlock = blocks[0]
 	b.pendingState, _ = state.New(b.pendingBlock.Root(), b.database)
 }
 
+// AdjustTime shifts the timestamp of the pending block by the given duration,
+// keeping all pending transactions. The adjustment accumulates until the next
+// Commit or Rollback, but may not move the block before its parent.
+func (b *SimulatedBackend) AdjustTime(adjustment time.Duration) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	offset := b.timeOffset + int64(adjustment/time.Second)
+	if b.pendingBlock.Time().Int64()-b.timeOffset+offset <= b.blockchain.CurrentBlock().Time().Int64() {
+		return errBlockTimeOutOfRange
+	}
+	b.timeOffset = offset
+	b.regenerate(b.pendingBlock.Transactions())
+	return nil
+}
+
+// blockByNumber retrieves a canonical block, or the current head if number is nil.
+func (b *SimulatedBackend) blockByNumber(number *big.Int) (*types.Block, error) {
+	if number == nil {
+		return b.blockchain.CurrentBlock(), nil
+	}
+	if number.Cmp(b.blockchain.CurrentBlock().Number()) > 0 {
+		return nil, errBlockNumberUnsupported
+	}
+	if block := b.blockchain.GetBlockByNumber(number.Uint64()); block != nil {
+		return block, nil
+	}
+	return nil, ethereum.NotFound
+}
+
+// stateByNumber retrieves the state at a canonical block, or at the current
+// head if number is nil.
+func (b *SimulatedBackend) stateByNumber(number *big.Int) (*state.StateDB, error) {
+	block, err := b.blockByNumber(number)
+	if err != nil {
+		return nil, err
+	}
+	return b.blockchain.StateAt(block.Root())
+}
+
 // CodeAt returns the code associated with a certain account in the blockchain.
 func (b *SimulatedBackend) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
-	if blockNumber != nil && blockNumber.Cmp(b.blockchain.CurrentBlock().Number()) != 0 {
-		return nil, errBlockNumberUnsupported
+	statedb, err := b.stateByNumber(blockNumber)
+	if err != nil {
+		return nil, err
 	}
-	statedb, _ := b.blockchain.State()
 	return statedb.GetCode(contract), nil
 }
 
@@ -110,10 +188,10 @@ func (b *SimulatedBackend) BalanceAt(ctx context.Context, contract common.Addres
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
-	if blockNumber != nil && blockNumber.Cmp(b.blockchain.CurrentBlock().Number()) != 0 {
-		return nil, errBlockNumberUnsupported
+	statedb, err := b.stateByNumber(blockNumber)
+	if err != nil {
+		return nil, err
 	}
-	statedb, _ := b.blockchain.State()
 	return statedb.GetBalance(contract), nil
 }
 
@@ -122,10 +200,10 @@ func (b *SimulatedBackend) NonceAt(ctx context.Context, contract common.Address,
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
-	if blockNumber != nil && blockNumber.Cmp(b.blockchain.CurrentBlock().Number()) != 0 {
-		return 0, errBlockNumberUnsupported
+	statedb, err := b.stateByNumber(blockNumber)
+	if err != nil {
+		return 0, err
 	}
-	statedb, _ := b.blockchain.State()
 	return statedb.GetNonce(contract), nil
 }
 
@@ -134,19 +212,129 @@ func (b *SimulatedBackend) StorageAt(ctx context.Context, contract common.Addres
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
-	if blockNumber != nil && blockNumber.Cmp(b.blockchain.CurrentBlock().Number()) != 0 {
-		return nil, errBlockNumberUnsupported
+	statedb, err := b.stateByNumber(blockNumber)
+	if err != nil {
+		return nil, err
 	}
-	statedb, _ := b.blockchain.State()
 	val := statedb.GetState(contract, key)
 	return val[:], nil
 }
 
+// BlockByHash retrieves a block from the simulated chain by hash.
+func (b *SimulatedBackend) BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error) {
+	if block := b.blockchain.GetBlockByHash(hash); block != nil {
+		return block, nil
+	}
+	return nil, ethereum.NotFound
+}
+
+// BlockByNumber retrieves a canonical block from the simulated chain. A nil
+// number selects the latest committed block.
+func (b *SimulatedBackend) BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.blockByNumber(number)
+}
+
+// HeaderByHash retrieves a block header from the simulated chain by hash.
+func (b *SimulatedBackend) HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error) {
+	if header := b.blockchain.GetHeaderByHash(hash); header != nil {
+		return header, nil
+	}
+	return nil, ethereum.NotFound
+}
+
+// HeaderByNumber retrieves a canonical block header from the simulated chain.
+// A nil number selects the latest committed block.
+func (b *SimulatedBackend) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	block, err := b.blockByNumber(number)
+	if err != nil {
+		return nil, err
+	}
+	return block.Header(), nil
+}
+
+// TransactionCount returns the number of transactions in the given block.
+func (b *SimulatedBackend) TransactionCount(ctx context.Context, blockHash common.Hash) (uint, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if blockHash == b.pendingBlock.Hash() {
+		return uint(b.pendingBlock.Transactions().Len()), nil
+	}
+	block := b.blockchain.GetBlockByHash(blockHash)
+	if block == nil {
+		return 0, ethereum.NotFound
+	}
+	return uint(block.Transactions().Len()), nil
+}
+
+// TransactionInBlock returns the transaction at the given index in a block.
+func (b *SimulatedBackend) TransactionInBlock(ctx context.Context, blockHash common.Hash, index uint) (*types.Transaction, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	block := b.pendingBlock
+	if blockHash != block.Hash() {
+		if block = b.blockchain.GetBlockByHash(blockHash); block == nil {
+			return nil, ethereum.NotFound
+		}
+	}
+	txs := block.Transactions()
+	if index >= uint(len(txs)) {
+		return nil, ethereum.NotFound
+	}
+	return txs[index], nil
+}
+
+// TransactionByHash returns the transaction with the given hash, either from
+// the pending block or from the committed chain.
+func (b *SimulatedBackend) TransactionByHash(ctx context.Context, txHash common.Hash) (*types.Transaction, bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if tx := b.pendingBlock.Transaction(txHash); tx != nil {
+		return tx, true, nil
+	}
+	if tx, _, _, _ := core.GetTransaction(b.database, txHash); tx != nil {
+		return tx, false, nil
+	}
+	return nil, false, ethereum.NotFound
+}
+
 // TransactionReceipt returns the receipt of a transaction.
 func (b *SimulatedBackend) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
 	return core.GetReceipt(b.database, txHash), nil
 }
 
+// SyncProgress implements ChainSyncReader. The simulated chain is never
+// syncing, so nil is always returned.
+func (b *SimulatedBackend) SyncProgress(ctx context.Context) (*ethereum.SyncProgress, error) {
+	return nil, nil
+}
+
+// PendingBalanceAt returns the wei balance of an account in the pending state.
+func (b *SimulatedBackend) PendingBalanceAt(ctx context.Context, account common.Address) (*big.Int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.pendingState.GetBalance(account), nil
+}
+
+// PendingStorageAt returns the value of key in the storage of an account in
+// the pending state.
+func (b *SimulatedBackend) PendingStorageAt(ctx context.Context, account common.Address, key common.Hash) ([]byte, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	val := b.pendingState.GetState(account, key)
+	return val[:], nil
+}
+
 // PendingCodeAt returns the code associated with an account in the pending state.
 func (b *SimulatedBackend) PendingCodeAt(ctx context.Context, contract common.Address) ([]byte, error) {
 	b.mu.Lock()
@@ -155,19 +343,28 @@ func (b *SimulatedBackend) PendingCodeAt(ctx context.Context, contract common.Ad
 	return b.pendingState.GetCode(contract), nil
 }
 
+// PendingTransactionCount returns the number of transactions in the pending block.
+func (b *SimulatedBackend) PendingTransactionCount(ctx context.Context) (uint, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return uint(b.pendingBlock.Transactions().Len()), nil
+}
+
 // CallContract executes a contract call.
 func (b *SimulatedBackend) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
-	if blockNumber != nil && blockNumber.Cmp(b.blockchain.CurrentBlock().Number()) != 0 {
-		return nil, errBlockNumberUnsupported
+	block, err := b.blockByNumber(blockNumber)
+	if err != nil {
+		return nil, err
 	}
-	state, err := b.blockchain.State()
+	state, err := b.blockchain.StateAt(block.Root())
 	if err != nil {
 		return nil, err
 	}
-	rval, _, err := b.callContract(ctx, call, b.blockchain.CurrentBlock(), state)
+	rval, _, err := b.callContract(ctx, call, block, state)
 	return rval, err
 }
 
@@ -250,17 +447,84 @@ func (b *SimulatedBackend) SendTransaction(ctx context.Context, tx *types.Transa
 		panic(fmt.Errorf("invalid transaction nonce: got %d, want %d", tx.Nonce(), nonce))
 	}
 
-	blocks, _ := core.GenerateChain(chainConfig, b.blockchain.CurrentBlock(), b.database, 1, func(number int, block *core.BlockGen) {
-		for _, tx := range b.pendingBlock.Transactions() {
-			block.AddTx(tx)
-		}
-		block.AddTx(tx)
-	})
-	b.pendingBlock = blocks[0]
-	b.pendingState, _ = state.New(b.pendingBlock.Root(), b.database)
+	b.regenerate(append(b.pendingBlock.Transactions(), tx))
 	return nil
 }
 
+// FilterLogs executes a one-off log filter query against the committed chain.
+// A nil FromBlock selects the genesis block, a nil ToBlock the latest block.
+func (b *SimulatedBackend) FilterLogs(ctx context.Context, query ethereum.FilterQuery) ([]types.Log, error) {
+	from, to := int64(0), int64(-1)
+	if query.FromBlock != nil {
+		from = query.FromBlock.Int64()
+	}
+	if query.ToBlock != nil {
+		to = query.ToBlock.Int64()
+	}
+	filter := filters.New(&filterBackend{b.database, b.blockchain, b.mux}, false)
+	filter.SetBeginBlock(from)
+	filter.SetEndBlock(to)
+	filter.SetAddresses(query.Addresses)
+	filter.SetTopics(query.Topics)
+
+	logs, err := filter.Find(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]types.Log, len(logs))
+	for i, log := range logs {
+		res[i] = *log
+	}
+	return res, nil
+}
+
+// SubscribeFilterLogs creates a background log filtering operation, streaming
+// the matching logs of newly committed blocks into ch.
+func (b *SimulatedBackend) SubscribeFilterLogs(ctx context.Context, query ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+	sink := make(chan []*types.Log)
+	sub, err := b.events.SubscribeLogs(filters.FilterCriteria(query), sink)
+	if err != nil {
+		return nil, err
+	}
+	return newForwardSub(sub, func(quit <-chan struct{}) {
+		for {
+			select {
+			case logs := <-sink:
+				for _, log := range logs {
+					select {
+					case ch <- *log:
+					case <-quit:
+						return
+					}
+				}
+			case <-quit:
+				return
+			}
+		}
+	}), nil
+}
+
+// SubscribeNewHead streams the headers of newly committed blocks into ch.
+func (b *SimulatedBackend) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
+	sink := make(chan *types.Header)
+	sub := b.events.SubscribeNewHeads(sink)
+
+	return newForwardSub(sub, func(quit <-chan struct{}) {
+		for {
+			select {
+			case head := <-sink:
+				select {
+				case ch <- head:
+				case <-quit:
+					return
+				}
+			case <-quit:
+				return
+			}
+		}
+	}), nil
+}
+
 // callmsg implements core.Message to allow passing it as a transaction simulator.
 type callmsg struct {
 	ethereum.CallMsg
@@ -274,3 +538,95 @@ func (m callmsg) GasPrice() *big.Int   { return m.CallMsg.GasPrice }
 func (m callmsg) Gas() *big.Int        { return m.CallMsg.Gas }
 func (m callmsg) Value() *big.Int      { return m.CallMsg.Value }
 func (m callmsg) Data() []byte         { return m.CallMsg.Data }
+
+// forwardSub is a subscription to the event system, the events of which are
+// relayed to a user supplied channel by a background goroutine.
+type forwardSub struct {
+	sub  *filters.Subscription
+	quit chan struct{}
+	done chan struct{}
+	err  chan error
+	once sync.Once
+}
+
+// newForwardSub starts relaying the events of sub using the given loop, which
+// must return when quit is closed.
+func newForwardSub(sub *filters.Subscription, loop func(quit <-chan struct{})) *forwardSub {
+	s := &forwardSub{
+		sub:  sub,
+		quit: make(chan struct{}),
+		done: make(chan struct{}),
+		err:  make(chan error, 1),
+	}
+	go func() {
+		defer close(s.done)
+		loop(s.quit)
+	}()
+	return s
+}
+
+// Err implements ethereum.Subscription. The channel is closed on Unsubscribe.
+func (s *forwardSub) Err() <-chan error {
+	return s.err
+}
+
+// Unsubscribe implements ethereum.Subscription, stopping event delivery.
+func (s *forwardSub) Unsubscribe() {
+	s.once.Do(func() {
+		close(s.quit)
+		<-s.done
+		s.sub.Unsubscribe()
+		close(s.err)
+	})
+}
+
+// filterBackend implements filters.Backend on top of the simulated chain.
+type filterBackend struct {
+	db  ethdb.Database
+	bc  *core.BlockChain
+	mux *event.TypeMux
+}
+
+func (fb *filterBackend) ChainDb() ethdb.Database  { return fb.db }
+func (fb *filterBackend) EventMux() *event.TypeMux { return fb.mux }
+
+func (fb *filterBackend) HeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Header, error) {
+	if number == rpc.LatestBlockNumber || number == rpc.PendingBlockNumber {
+		return fb.bc.CurrentHeader(), nil
+	}
+	return fb.bc.GetHeaderByNumber(uint64(number)), nil
+}
+
+func (fb *filterBackend) GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error) {
+	return core.GetBlockReceipts(fb.db, hash, core.GetBlockNumber(fb.db, hash)), nil
+}
+
+// SubscribeTxsPreEvent never fires, the simulated chain has no transaction pool.
+func (fb *filterBackend) SubscribeTxsPreEvent(ch chan<- core.TxsPreEvent) event.Subscription {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		<-quit
+		return nil
+	})
+}
+
+func (fb *filterBackend) SubscribeChainEvent(ch chan<- core.ChainEvent) event.Subscription {
+	return fb.bc.SubscribeChainEvent(ch)
+}
+
+func (fb *filterBackend) SubscribeRemovedLogsEvent(ch chan<- core.RemovedLogsEvent) event.Subscription {
+	return fb.bc.SubscribeRemovedLogsEvent(ch)
+}
+
+func (fb *filterBackend) SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscription {
+	return fb.bc.SubscribeLogsEvent(ch)
+}
+
+// BloomStatus reports no indexed sections, the simulated chain is short enough
+// to be filtered block by block.
+func (fb *filterBackend) BloomStatus() (uint64, uint64) {
+	return params.BloomBitsBlocks, 0
+}
+
+func (fb *filterBackend) GetBloomBits(ctx context.Context, bit uint, section uint64) ([]byte, error) {
+	return nil, errors.New("bloombits index not available")
+}
@@ -0,0 +1,187 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package backends
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/EarthDollar/go-earthdollar"
+	"github.com/EarthDollar/go-earthdollar/common"
+	"github.com/EarthDollar/go-earthdollar/core"
+	"github.com/EarthDollar/go-earthdollar/core/types"
+	"github.com/EarthDollar/go-earthdollar/crypto"
+	"golang.org/x/net/context"
+)
+
+var (
+	testKey, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	testAddr    = crypto.PubkeyToAddress(testKey.PublicKey)
+	testBalance = big.NewInt(10000000000)
+)
+
+// Contract creation code emitting a single LOG0 from its constructor.
+var logCode = common.FromHex("60006000a0")
+
+func newTestBackend() *SimulatedBackend {
+	return NewSimulatedBackend(core.GenesisAccount{Address: testAddr, Balance: testBalance})
+}
+
+func signTx(t *testing.T, tx *types.Transaction) *types.Transaction {
+	signed, err := types.SignTx(tx, types.HomesteadSigner{}, testKey)
+	if err != nil {
+		t.Fatalf("failed to sign transaction: %v", err)
+	}
+	return signed
+}
+
+// Tests that committed blocks and their states remain accessible by number and
+// hash, and that transactions move from the pending block into the chain.
+func TestSimulatedChainAccess(t *testing.T) {
+	sim := newTestBackend()
+	ctx := context.Background()
+
+	recipient := common.HexToAddress("0x0102030405060708090a0b0c0d0e0f1011121314")
+	tx := signTx(t, types.NewTransaction(0, recipient, big.NewInt(1000), big.NewInt(21000), big.NewInt(1), nil))
+	if err := sim.SendTransaction(ctx, tx); err != nil {
+		t.Fatalf("failed to send transaction: %v", err)
+	}
+	if _, pending, err := sim.TransactionByHash(ctx, tx.Hash()); err != nil || !pending {
+		t.Fatalf("pending transaction lookup mismatch: pending %v, err %v", pending, err)
+	}
+	if count, _ := sim.PendingTransactionCount(ctx); count != 1 {
+		t.Fatalf("pending transaction count mismatch: have %d, want 1", count)
+	}
+	if balance, _ := sim.PendingBalanceAt(ctx, recipient); balance.Cmp(big.NewInt(1000)) != 0 {
+		t.Fatalf("pending balance mismatch: have %v, want 1000", balance)
+	}
+	sim.Commit()
+
+	if _, pending, err := sim.TransactionByHash(ctx, tx.Hash()); err != nil || pending {
+		t.Fatalf("committed transaction lookup mismatch: pending %v, err %v", pending, err)
+	}
+	head, err := sim.HeaderByNumber(ctx, nil)
+	if err != nil || head.Number.Uint64() != 1 {
+		t.Fatalf("head mismatch: have %v, err %v", head, err)
+	}
+	block, err := sim.BlockByHash(ctx, head.Hash())
+	if err != nil {
+		t.Fatalf("failed to retrieve head block: %v", err)
+	}
+	if count, _ := sim.TransactionCount(ctx, block.Hash()); count != 1 {
+		t.Fatalf("transaction count mismatch: have %d, want 1", count)
+	}
+	if have, _ := sim.TransactionInBlock(ctx, block.Hash(), 0); have == nil || have.Hash() != tx.Hash() {
+		t.Fatalf("transaction in block mismatch: have %v, want %x", have, tx.Hash())
+	}
+	if _, err := sim.TransactionInBlock(ctx, block.Hash(), 1); err != ethereum.NotFound {
+		t.Fatalf("out of range transaction error mismatch: have %v, want %v", err, ethereum.NotFound)
+	}
+	// Historical state must be accessible, future state rejected
+	if balance, _ := sim.BalanceAt(ctx, recipient, big.NewInt(0)); balance.Sign() != 0 {
+		t.Fatalf("genesis balance mismatch: have %v, want 0", balance)
+	}
+	if balance, _ := sim.BalanceAt(ctx, recipient, big.NewInt(1)); balance.Cmp(big.NewInt(1000)) != 0 {
+		t.Fatalf("block 1 balance mismatch: have %v, want 1000", balance)
+	}
+	if _, err := sim.BalanceAt(ctx, recipient, big.NewInt(2)); err != errBlockNumberUnsupported {
+		t.Fatalf("future balance error mismatch: have %v, want %v", err, errBlockNumberUnsupported)
+	}
+}
+
+// Tests that the pending block's time can be moved forward, surviving further
+// transactions, but never before its parent.
+func TestSimulatedAdjustTime(t *testing.T) {
+	sim := newTestBackend()
+	ctx := context.Background()
+
+	parent := sim.blockchain.CurrentBlock().Time().Int64()
+	if err := sim.AdjustTime(time.Hour); err != nil {
+		t.Fatalf("failed to adjust time: %v", err)
+	}
+	tx := signTx(t, types.NewTransaction(0, common.Address{1}, big.NewInt(1), big.NewInt(21000), big.NewInt(1), nil))
+	if err := sim.SendTransaction(ctx, tx); err != nil {
+		t.Fatalf("failed to send transaction: %v", err)
+	}
+	if err := sim.AdjustTime(-2 * time.Hour); err != errBlockTimeOutOfRange {
+		t.Fatalf("backwards adjustment error mismatch: have %v, want %v", err, errBlockTimeOutOfRange)
+	}
+	sim.Commit()
+
+	head, _ := sim.HeaderByNumber(ctx, nil)
+	if have := head.Time.Int64() - parent; have < 3600 {
+		t.Fatalf("block time offset mismatch: have %ds, want at least 3600s", have)
+	}
+	if count, _ := sim.TransactionCount(ctx, head.Hash()); count != 1 {
+		t.Fatalf("transaction count mismatch: have %d, want 1", count)
+	}
+}
+
+// Tests that logs can be filtered from the committed chain, and that both new
+// heads and logs are streamed to subscribers.
+func TestSimulatedLogs(t *testing.T) {
+	sim := newTestBackend()
+	ctx := context.Background()
+
+	heads := make(chan *types.Header, 1)
+	headSub, err := sim.SubscribeNewHead(ctx, heads)
+	if err != nil {
+		t.Fatalf("failed to subscribe to new heads: %v", err)
+	}
+	defer headSub.Unsubscribe()
+
+	logs := make(chan types.Log, 1)
+	logSub, err := sim.SubscribeFilterLogs(ctx, ethereum.FilterQuery{}, logs)
+	if err != nil {
+		t.Fatalf("failed to subscribe to logs: %v", err)
+	}
+	defer logSub.Unsubscribe()
+
+	tx := signTx(t, types.NewContractCreation(0, new(big.Int), big.NewInt(100000), big.NewInt(1), logCode))
+	sim.SendTransaction(ctx, tx)
+	sim.Commit()
+
+	select {
+	case head := <-heads:
+		if head.Number.Uint64() != 1 {
+			t.Fatalf("new head number mismatch: have %v, want 1", head.Number)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("new head timeout")
+	}
+	select {
+	case log := <-logs:
+		if log.TxHash != tx.Hash() {
+			t.Fatalf("streamed log transaction mismatch: have %x, want %x", log.TxHash, tx.Hash())
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("log event timeout")
+	}
+	receipt, _ := sim.TransactionReceipt(ctx, tx.Hash())
+	found, err := sim.FilterLogs(ctx, ethereum.FilterQuery{Addresses: []common.Address{receipt.ContractAddress}})
+	if err != nil {
+		t.Fatalf("failed to filter logs: %v", err)
+	}
+	if len(found) != 1 || found[0].BlockNumber != 1 {
+		t.Fatalf("filtered logs mismatch: have %v, want 1 log in block 1", found)
+	}
+	found, _ = sim.FilterLogs(ctx, ethereum.FilterQuery{Addresses: []common.Address{{1}}})
+	if len(found) != 0 {
+		t.Fatalf("filtered logs of unrelated address: have %v, want none", found)
+	}
+}
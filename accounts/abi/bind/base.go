@@ -144,6 +144,15 @@ func (c *BoundContract) Call(opts *CallOpts, result interface{}, method string,
 	return c.abi.Unpack(result, method, output)
 }
 
+// UnpackLog unpacks a log emitted by the contract for the named event into the
+// struct out, decoding both the indexed topics and the data.
+func (c *BoundContract) UnpackLog(out interface{}, event string, log types.Log) error {
+	if log.Address != c.address {
+		return fmt.Errorf("log emitted by %x, not the bound contract %x", log.Address, c.address)
+	}
+	return c.abi.UnpackLog(out, event, log)
+}
+
 // Transact invokes the (paid) contract method with params as input values.
 func (c *BoundContract) Transact(opts *TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
 	// Otherwise pack up the parameters and invoke the contract
@@ -146,6 +146,10 @@ func bindTypeGo(kind abi.Type) string {
 	stringKind := kind.String()
 
 	switch {
+	case kind.T == abi.TupleTy && kind.Type != nil:
+		// tuples map to anonymous structs, keeping any array dimension
+		return stringKind[strings.LastIndex(stringKind, ")")+1:] + kind.Type.String()
+
 	case strings.HasPrefix(stringKind, "address"):
 		parts := regexp.MustCompile(`address(\[[0-9]*\])?`).FindStringSubmatch(stringKind)
 		if len(parts) != 2 {
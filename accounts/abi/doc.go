@@ -23,4 +23,8 @@
 // as unsigned slice to signed slice. Bit size type casting is also
 // handled. ints with a bit size of 32 will be properly cast to int256,
 // etc.
+//
+// Tuples (structs) and arbitrarily nested dynamic arrays are supported in both
+// directions, tuples being unpacked into Go structs. Logs emitted by events can
+// be decoded with UnpackLog, and filter topics constructed with MakeTopics.
 package abi
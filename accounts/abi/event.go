@@ -21,6 +21,7 @@ import (
 	"strings"
 
 	"github.com/EarthDollar/go-earthdollar/common"
+	"github.com/EarthDollar/go-earthdollar/core/types"
 	"github.com/EarthDollar/go-earthdollar/crypto"
 )
 
@@ -44,3 +45,51 @@ func (e Event) Id() common.Hash {
 	}
 	return common.BytesToHash(crypto.Keccak256([]byte(fmt.Sprintf("%v(%v)", e.Name, strings.Join(types, ",")))))
 }
+
+// nonIndexed returns the inputs of the event which are stored in the data of
+// its logs rather than in the topics.
+func (e Event) nonIndexed() []Argument {
+	var args []Argument
+	for _, input := range e.Inputs {
+		if !input.Indexed {
+			args = append(args, input)
+		}
+	}
+	return args
+}
+
+// indexed returns the inputs of the event which are stored in the topics of
+// its logs.
+func (e Event) indexed() []Argument {
+	var args []Argument
+	for _, input := range e.Inputs {
+		if input.Indexed {
+			args = append(args, input)
+		}
+	}
+	return args
+}
+
+// UnpackLog unpacks a log emitted by the named event into the struct v, both
+// the indexed inputs out of the topics and the remaining ones out of the data.
+// Indexed inputs of dynamic types can only be retrieved as their Keccak256 hash
+// and must be assigned to common.Hash fields.
+func (abi ABI) UnpackLog(v interface{}, name string, log types.Log) error {
+	event, ok := abi.Events[name]
+	if !ok {
+		return fmt.Errorf("abi: event '%s' not found", name)
+	}
+	topics := log.Topics
+	if !event.Anonymous {
+		if len(topics) == 0 || topics[0] != event.Id() {
+			return fmt.Errorf("abi: log is not an '%s' event", name)
+		}
+		topics = topics[1:]
+	}
+	if args := event.nonIndexed(); len(args) > 0 {
+		if err := unpackArguments(v, args, log.Data); err != nil {
+			return err
+		}
+	}
+	return ParseTopics(v, event.indexed(), topics)
+}
@@ -17,10 +17,13 @@
 package abi
 
 import (
+	"math/big"
+	"reflect"
 	"strings"
 	"testing"
 
 	"github.com/EarthDollar/go-earthdollar/common"
+	"github.com/EarthDollar/go-earthdollar/core/types"
 	"github.com/EarthDollar/go-earthdollar/crypto"
 )
 
@@ -54,3 +57,87 @@ func TestEventId(t *testing.T) {
 		}
 	}
 }
+
+const transferEvent = `[
+	{ "type" : "event", "name" : "Transfer", "inputs" : [
+		{ "name" : "from", "type" : "address", "indexed" : true },
+		{ "name" : "memo", "type" : "string", "indexed" : true },
+		{ "name" : "value", "type" : "uint256" },
+		{ "name" : "note", "type" : "string" } ] }]`
+
+// Tests that event logs are decoded out of both their topics and data.
+func TestUnpackLog(t *testing.T) {
+	abi, err := JSON(strings.NewReader(transferEvent))
+	if err != nil {
+		t.Fatal(err)
+	}
+	event := abi.Events["Transfer"]
+	from := common.HexToAddress("0x0102030405060708090a0b0c0d0e0f1011121314")
+
+	topics, err := MakeTopics([]interface{}{event.Id()}, []interface{}{from}, []interface{}{"gift"})
+	if err != nil {
+		t.Fatalf("failed to make topics: %v", err)
+	}
+	data, err := packSequence(
+		[]*Type{&event.Inputs[2].Type, &event.Inputs[3].Type},
+		[]reflect.Value{reflect.ValueOf(big.NewInt(1000)), reflect.ValueOf("happy birthday")},
+	)
+	if err != nil {
+		t.Fatalf("failed to pack data: %v", err)
+	}
+	log := types.Log{Topics: []common.Hash{topics[0][0], topics[1][0], topics[2][0]}, Data: data}
+
+	var transfer struct {
+		From  common.Address
+		Memo  common.Hash
+		Value *big.Int
+		Text  string `abi:"note"`
+	}
+	if err := abi.UnpackLog(&transfer, "Transfer", log); err != nil {
+		t.Fatalf("failed to unpack log: %v", err)
+	}
+	if transfer.From != from {
+		t.Errorf("indexed address mismatch: have %x, want %x", transfer.From, from)
+	}
+	if want := crypto.Keccak256Hash([]byte("gift")); transfer.Memo != want {
+		t.Errorf("indexed string hash mismatch: have %x, want %x", transfer.Memo, want)
+	}
+	if transfer.Value.Cmp(big.NewInt(1000)) != 0 || transfer.Text != "happy birthday" {
+		t.Errorf("data mismatch: have %v %q", transfer.Value, transfer.Text)
+	}
+	// Logs of other events must be rejected
+	log.Topics[0] = crypto.Keccak256Hash([]byte("Approval(address,string,uint256,string)"))
+	if err := abi.UnpackLog(&transfer, "Transfer", log); err == nil {
+		t.Errorf("log of a different event unpacked")
+	}
+}
+
+// Tests that filter values are converted to the topics the EVM emits.
+func TestMakeTopics(t *testing.T) {
+	topics, err := MakeTopics(
+		[]interface{}{big.NewInt(-1), uint8(5), true},
+		[]interface{}{[4]byte{1, 2, 3, 4}, []byte("data")},
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("failed to make topics: %v", err)
+	}
+	want := [][]common.Hash{
+		{
+			common.HexToHash("0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff"),
+			common.HexToHash("0x05"),
+			common.HexToHash("0x01"),
+		},
+		{
+			common.HexToHash("0x0102030400000000000000000000000000000000000000000000000000000000"),
+			crypto.Keccak256Hash([]byte("data")),
+		},
+		nil,
+	}
+	if !reflect.DeepEqual(topics, want) {
+		t.Errorf("topics mismatch:\n  have %x\n  want %x", topics, want)
+	}
+	if _, err := MakeTopics([]interface{}{struct{}{}}); err == nil {
+		t.Errorf("unsupported topic type accepted")
+	}
+}
@@ -56,9 +56,9 @@ var (
 	big_ts   = reflect.TypeOf([]*big.Int(nil))
 )
 
-// U256 converts a big Int into a 256bit EVM number.
+// U256 converts a big Int into a 256bit EVM number, leaving n untouched.
 func U256(n *big.Int) []byte {
-	return common.LeftPadBytes(common.U256(n).Bytes(), 32)
+	return common.LeftPadBytes(common.U256(new(big.Int).Set(n)).Bytes(), 32)
 }
 
 // packNum packs the given number (using the reflect value) and will cast it to appropriate number representation
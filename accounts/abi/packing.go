@@ -63,3 +63,27 @@ func packElement(t Type, reflectValue reflect.Value) []byte {
 	}
 	panic("abi: fatal error")
 }
+
+// packSequence packs the given values as an ABI tuple of the given types: the
+// static values and the offsets of the dynamic ones make up the head, which is
+// followed by the encodings of all the dynamic values.
+func packSequence(types []*Type, values []reflect.Value) ([]byte, error) {
+	headSize := 0
+	for _, t := range types {
+		headSize += getTypeSize(*t)
+	}
+	var head, tail []byte
+	for i, t := range types {
+		packed, err := t.pack(values[i])
+		if err != nil {
+			return nil, err
+		}
+		if isDynamicType(*t) {
+			head = append(head, packNum(reflect.ValueOf(headSize+len(tail)))...)
+			tail = append(tail, packed...)
+		} else {
+			head = append(head, packed...)
+		}
+	}
+	return append(head, tail...), nil
+}
@@ -81,13 +81,100 @@ func set(dst, src reflect.Value, output Argument) error {
 		if dst.Len() < output.Type.SliceSize {
 			return fmt.Errorf("abi: cannot unmarshal src (len=%d) in to dst (len=%d)", output.Type.SliceSize, dst.Len())
 		}
-		reflect.Copy(dst, src)
+		if dstType.Elem() == srcType.Elem() {
+			reflect.Copy(dst, src)
+			break
+		}
+		for i := 0; i < src.Len() && i < dst.Len(); i++ {
+			if err := set(dst.Index(i), src.Index(i), output); err != nil {
+				return err
+			}
+		}
+	case dstType.Kind() == reflect.Slice && srcType.Kind() == reflect.Slice:
+		// element types differ (e.g. user defined structs for tuples), set one by one
+		slice := reflect.MakeSlice(dstType, src.Len(), src.Len())
+		for i := 0; i < src.Len(); i++ {
+			if err := set(slice.Index(i), src.Index(i), output); err != nil {
+				return err
+			}
+		}
+		dst.Set(slice)
+	case dstType.Kind() == reflect.Struct && srcType.Kind() == reflect.Struct:
+		for i := 0; i < srcType.NumField(); i++ {
+			field, err := tupleField(dst, srcType.Field(i).Name, srcType.Field(i).Tag.Get("abi"))
+			if err != nil {
+				return err
+			}
+			if err := set(field, src.Field(i), output); err != nil {
+				return err
+			}
+		}
 	case dstType.Kind() == reflect.Interface:
 		dst.Set(src)
 	case dstType.Kind() == reflect.Ptr:
+		if dst.IsNil() {
+			dst.Set(reflect.New(dstType.Elem()))
+		}
 		return set(dst.Elem(), src, output)
 	default:
 		return fmt.Errorf("abi: cannot unmarshal %v in to %v", src.Type(), dst.Type())
 	}
 	return nil
 }
+
+// goType returns the Go type values of the ABI type are unpacked into.
+func (t Type) goType() reflect.Type {
+	switch {
+	case t.isList():
+		return reflect.SliceOf(t.Elem.goType())
+	case t.T == IntTy || t.T == UintTy:
+		switch t.Kind {
+		case reflect.Uint8:
+			return uint8_t
+		case reflect.Uint16:
+			return uint16_t
+		case reflect.Uint32:
+			return uint32_t
+		case reflect.Uint64:
+			return uint64_t
+		case reflect.Int8:
+			return int8_t
+		case reflect.Int16:
+			return int16_t
+		case reflect.Int32:
+			return int32_t
+		case reflect.Int64:
+			return int64_t
+		}
+		return reflect.PtrTo(big_t)
+	case t.T == BoolTy:
+		return reflect.TypeOf(false)
+	case t.T == AddressTy:
+		return address_t
+	case t.T == HashTy:
+		return hash_t
+	case t.T == StringTy:
+		return reflect.TypeOf("")
+	case t.T == TupleTy:
+		return t.Type
+	}
+	return byte_ts
+}
+
+// tupleField looks up the field of a struct value a tuple component is stored
+// in, matching either the field name or its `abi:"name"` tag.
+func tupleField(v reflect.Value, name, raw string) (reflect.Value, error) {
+	if v.Kind() != reflect.Struct {
+		return reflect.Value{}, fmt.Errorf("abi: cannot use %v as tuple", v.Type())
+	}
+	typ := v.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		if tag := typ.Field(i).Tag.Get("abi"); tag != "" && tag == raw {
+			return v.Field(i), nil
+		}
+	}
+	if field := v.FieldByName(name); field.IsValid() {
+		return field, nil
+	}
+	return reflect.Value{}, fmt.Errorf("abi: field %s not found in %v", name, typ)
+}
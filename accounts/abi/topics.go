@@ -0,0 +1,97 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package abi
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+
+	"github.com/EarthDollar/go-earthdollar/common"
+	"github.com/EarthDollar/go-earthdollar/crypto"
+)
+
+// MakeTopics converts filter query values into the topics of an event log
+// filter. Each rule lists the alternatives accepted at its topic position,
+// an empty rule matching any topic. Values of dynamic types (strings and byte
+// slices) are converted into their Keccak256 hash, as done by the EVM.
+func MakeTopics(query ...[]interface{}) ([][]common.Hash, error) {
+	topics := make([][]common.Hash, len(query))
+	for i, filter := range query {
+		for _, rule := range filter {
+			var topic common.Hash
+
+			switch rule := rule.(type) {
+			case common.Hash:
+				copy(topic[:], rule[:])
+			case common.Address:
+				copy(topic[common.HashLength-common.AddressLength:], rule[:])
+			case *big.Int:
+				copy(topic[:], U256(rule))
+			case bool:
+				if rule {
+					topic[common.HashLength-1] = 1
+				}
+			case int8, int16, int32, int64, uint8, uint16, uint32, uint64:
+				copy(topic[:], packNum(reflect.ValueOf(rule)))
+			case string:
+				topic = crypto.Keccak256Hash([]byte(rule))
+			case []byte:
+				topic = crypto.Keccak256Hash(rule)
+			default:
+				// fixed size byte arrays are stored left aligned
+				val := reflect.ValueOf(rule)
+				if val.Kind() != reflect.Array || val.Type().Elem().Kind() != reflect.Uint8 || val.Len() > common.HashLength {
+					return nil, fmt.Errorf("abi: unsupported topic type %T", rule)
+				}
+				reflect.Copy(reflect.ValueOf(topic[:val.Len()]), val)
+			}
+			topics[i] = append(topics[i], topic)
+		}
+	}
+	return topics, nil
+}
+
+// ParseTopics unpacks the topics of a log into the fields of the struct v named
+// after the indexed event arguments. The topics are expected without the event
+// signature, in the order of the arguments.
+func ParseTopics(v interface{}, args []Argument, topics []common.Hash) error {
+	if len(args) != len(topics) {
+		return fmt.Errorf("abi: topic/field count mismatch: %d for %d", len(topics), len(args))
+	}
+	value := reflect.ValueOf(v)
+	if value.Kind() != reflect.Ptr || value.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("abi: cannot parse topics into %T (only struct pointers are supported)", v)
+	}
+	value = value.Elem()
+
+	for i, arg := range args {
+		// Only values fitting into a single word are stored verbatim, all
+		// others are replaced by their hash.
+		var parsed interface{} = topics[i]
+		if !isDynamicType(arg.Type) && !arg.Type.isList() && arg.Type.T != TupleTy {
+			var err error
+			if parsed, err = toGoType(arg.Type, topics[i][:], 0); err != nil {
+				return err
+			}
+		}
+		if err := setField(value, arg, parsed); err != nil {
+			return err
+		}
+	}
+	return nil
+}
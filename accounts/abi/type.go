@@ -21,6 +21,7 @@ import (
 	"reflect"
 	"regexp"
 	"strconv"
+	"strings"
 )
 
 const (
@@ -35,6 +36,7 @@ const (
 	HashTy
 	FixedpointTy
 	FunctionTy
+	TupleTy
 )
 
 // Type is the reflection of the supported argument type
@@ -49,48 +51,62 @@ type Type struct {
 	Size int
 	T    byte // Our own type checking
 
+	TupleElems    []*Type  // Types of the tuple components, in order
+	TupleRawNames []string // Names of the tuple components as given in the ABI
+
 	stringKind string // holds the unparsed string for deriving signatures
 }
 
-var (
-	// fullTypeRegex parses the abi types
-	//
-	// Types can be in the format of:
-	//
-	// 	Input  = Type [ "[" [ Number ] "]" ] Name .
-	// 	Type   = [ "u" ] "int" [ Number ] [ x ] [ Number ].
-	//
-	// Examples:
-	//
-	//      string     int       uint       fixed
-	//      string32   int8      uint8      uint[]
-	//      address    int256    uint256    fixed128x128[2]
-	fullTypeRegex = regexp.MustCompile(`([a-zA-Z0-9]+)(\[([0-9]*)\])?`)
-	// typeRegex parses the abi sub types
-	typeRegex = regexp.MustCompile("([a-zA-Z]+)(([0-9]+)(x([0-9]+))?)?")
-)
+// typeRegex parses the abi sub types, the optional array dimensions having
+// been stripped off already.
+//
+// Types can be in the format of:
+//
+// 	Input  = Type { "[" [ Number ] "]" } Name .
+// 	Type   = [ "u" ] "int" [ Number ] [ x ] [ Number ] | "tuple" .
+//
+// Examples:
+//
+//      string     int       uint       fixed
+//      string32   int8      uint8      uint[]
+//      address    int256    uint256    fixed128x128[2]
+//      tuple      tuple[]   uint8[][2] bytes32[2][]
+var typeRegex = regexp.MustCompile("([a-zA-Z]+)(([0-9]+)(x([0-9]+))?)?")
 
 // NewType creates a new reflection type of abi type given in t.
 func NewType(t string) (typ Type, err error) {
-	res := fullTypeRegex.FindAllStringSubmatch(t, -1)[0]
-	// check if type is slice and parse type.
-	switch {
-	case res[3] != "":
-		// err is ignored. Already checked for number through the regexp
-		typ.SliceSize, _ = strconv.Atoi(res[3])
-		typ.IsArray = true
-	case res[2] != "":
-		typ.IsSlice, typ.SliceSize = true, -1
-	case res[0] == "":
+	return newType(t, nil)
+}
+
+// newType creates a new reflection type of abi type given in t. The components
+// describe the fields of tuple types and are ignored for all other types.
+func newType(t string, components []Argument) (typ Type, err error) {
+	if t == "" || strings.Count(t, "[") != strings.Count(t, "]") {
 		return Type{}, fmt.Errorf("abi: type parse error: %s", t)
 	}
-	if typ.IsArray || typ.IsSlice {
-		sliceType, err := NewType(res[1])
+	// check if type is slice and parse type. Nested arrays are parsed from
+	// their outermost (last) dimension inwards.
+	base := t
+	if i := strings.LastIndex(t, "["); i != -1 {
+		if !strings.HasSuffix(t, "]") {
+			return Type{}, fmt.Errorf("abi: type parse error: %s", t)
+		}
+		if size := t[i+1 : len(t)-1]; size != "" {
+			if typ.SliceSize, err = strconv.Atoi(size); err != nil || typ.SliceSize < 0 {
+				return Type{}, fmt.Errorf("abi: invalid array size: %s", t)
+			}
+			typ.IsArray = true
+		} else {
+			typ.IsSlice, typ.SliceSize = true, -1
+		}
+		base = t[:i]
+
+		sliceType, err := newType(base, components)
 		if err != nil {
 			return Type{}, err
 		}
 		typ.Elem = &sliceType
-		typ.stringKind = sliceType.stringKind + t[len(res[1]):]
+		typ.stringKind = sliceType.stringKind + t[i:]
 		// Although we know that this is an array, we cannot return
 		// as we don't know the type of the element, however, if it
 		// is still an array, then don't determine the type.
@@ -100,7 +116,11 @@ func NewType(t string) (typ Type, err error) {
 	}
 
 	// parse the type and size of the abi-type.
-	parsedType := typeRegex.FindAllStringSubmatch(res[1], -1)[0]
+	matches := typeRegex.FindAllStringSubmatch(base, -1)
+	if len(matches) == 0 {
+		return Type{}, fmt.Errorf("abi: type parse error: %s", t)
+	}
+	parsedType := matches[0]
 	// varSize is the size of the variable
 	var varSize int
 	if len(parsedType[3]) > 0 {
@@ -165,6 +185,37 @@ func NewType(t string) (typ Type, err error) {
 		typ.IsArray = true
 		typ.T = FunctionTy
 		typ.SliceSize = 24
+	case "tuple":
+		if len(components) == 0 {
+			return Type{}, fmt.Errorf("abi: tuple type without components: %s", t)
+		}
+		var (
+			fields = make([]reflect.StructField, len(components))
+			kinds  = make([]string, len(components))
+			seen   = make(map[string]bool)
+		)
+		for i, c := range components {
+			name := ToCamelCase(c.Name)
+			if name == "" {
+				name = fmt.Sprintf("Field%d", i)
+			}
+			if seen[name] {
+				return Type{}, fmt.Errorf("abi: duplicate tuple field %s", name)
+			}
+			seen[name] = true
+
+			elem := c.Type
+			fields[i] = reflect.StructField{Name: name, Type: elem.goType(), Tag: reflect.StructTag(fmt.Sprintf(`abi:"%s"`, c.Name))}
+			kinds[i] = elem.String()
+			typ.TupleElems = append(typ.TupleElems, &elem)
+			typ.TupleRawNames = append(typ.TupleRawNames, c.Name)
+		}
+		typ.Kind = reflect.Struct
+		typ.Type = reflect.StructOf(fields)
+		typ.T = TupleTy
+		if !(typ.IsArray || typ.IsSlice) {
+			typ.stringKind = "(" + strings.Join(kinds, ",") + ")"
+		}
 	default:
 		return Type{}, fmt.Errorf("unsupported arg type: %s", t)
 	}
@@ -185,28 +236,87 @@ func (t Type) pack(v reflect.Value) ([]byte, error) {
 		return nil, err
 	}
 
-	if (t.IsSlice || t.IsArray) && t.T != BytesTy && t.T != FixedBytesTy && t.T != FunctionTy {
-		var packed []byte
+	switch {
+	case t.isList():
+		types := make([]*Type, v.Len())
+		values := make([]reflect.Value, v.Len())
+		for i := range values {
+			types[i], values[i] = t.Elem, v.Index(i)
+		}
+		packed, err := packSequence(types, values)
+		if err != nil {
+			return nil, err
+		}
+		if t.IsSlice {
+			return append(packNum(reflect.ValueOf(v.Len())), packed...), nil
+		}
+		return packed, nil
 
-		for i := 0; i < v.Len(); i++ {
-			val, err := t.Elem.pack(v.Index(i))
+	case t.T == TupleTy:
+		values := make([]reflect.Value, len(t.TupleElems))
+		for i := range values {
+			field, err := tupleField(v, t.Type.Field(i).Name, t.TupleRawNames[i])
 			if err != nil {
 				return nil, err
 			}
-			packed = append(packed, val...)
+			values[i] = field
 		}
-		if t.IsSlice {
-			return packBytesSlice(packed, v.Len()), nil
-		} else if t.IsArray {
-			return packed, nil
+		return packSequence(t.TupleElems, values)
+	}
+	return packElement(t, v), nil
+}
+
+// isList returns whether the type is an array or slice of other ABI values,
+// as opposed to a byte array or string which is packed as a single element.
+func (t Type) isList() bool {
+	return (t.IsSlice || t.IsArray) && t.T != BytesTy && t.T != FixedBytesTy && t.T != FunctionTy
+}
+
+// isDynamicType returns whether the encoding of the type is of variable size,
+// requiring it to be stored in the tail of its enclosing tuple.
+func isDynamicType(t Type) bool {
+	switch {
+	case t.T == StringTy && !t.isList(), t.T == BytesTy:
+		return true
+	case t.isList():
+		return t.IsSlice || isDynamicType(*t.Elem)
+	case t.T == TupleTy:
+		for _, elem := range t.TupleElems {
+			if isDynamicType(*elem) {
+				return true
+			}
 		}
 	}
+	return false
+}
 
-	return packElement(t, v), nil
+// getTypeSize returns the number of bytes the type occupies in the head of its
+// enclosing tuple. Dynamic types are referenced by a single 32 byte offset.
+func getTypeSize(t Type) int {
+	if isDynamicType(t) {
+		return 32
+	}
+	switch {
+	case t.isList():
+		return t.SliceSize * getTypeSize(*t.Elem)
+	case t.T == TupleTy:
+		size := 0
+		for _, elem := range t.TupleElems {
+			size += getTypeSize(*elem)
+		}
+		return size
+	}
+	return 32
 }
 
-// requireLengthPrefix returns whether the type requires any sort of length
-// prefixing.
-func (t Type) requiresLengthPrefix() bool {
-	return t.T != FixedBytesTy && (t.T == StringTy || t.T == BytesTy || t.IsSlice)
+// ToCamelCase converts an under-score separated ABI identifier into an exported
+// Go one, e.g. "_from_addr" becomes "FromAddr".
+func ToCamelCase(input string) string {
+	parts := strings.Split(input, "_")
+	for i, part := range parts {
+		if len(part) > 0 {
+			parts[i] = strings.ToUpper(part[:1]) + part[1:]
+		}
+	}
+	return strings.Join(parts, "")
 }
@@ -76,3 +76,40 @@ func TestTypeRegexp(t *testing.T) {
 		}
 	}
 }
+
+// Tests that nested arrays and tuples are parsed into the proper types.
+func TestNestedTypes(t *testing.T) {
+	typ, err := NewType("uint8[][2]")
+	if err != nil {
+		t.Fatalf("failed to parse nested array: %v", err)
+	}
+	if !typ.IsArray || typ.SliceSize != 2 || !typ.Elem.IsSlice || typ.Elem.Elem.Kind != reflect.Uint8 {
+		t.Errorf("nested array mismatch: have %+v", typeWithoutStringer(typ))
+	}
+	if typ.String() != "uint8[][2]" {
+		t.Errorf("nested array string mismatch: have %s", typ)
+	}
+	if _, err := NewType("tuple"); err == nil {
+		t.Errorf("tuple without components accepted")
+	}
+	for _, blob := range []string{"", "uint[", "uint]", "uint[x]"} {
+		if _, err := NewType(blob); err == nil {
+			t.Errorf("invalid type %q accepted", blob)
+		}
+	}
+	a, _ := NewType("uint256")
+	b, _ := NewType("string")
+	tuple, err := newType("tuple[]", []Argument{{Name: "amount", Type: a}, {Name: "_memo", Type: b}})
+	if err != nil {
+		t.Fatalf("failed to parse tuple: %v", err)
+	}
+	if tuple.String() != "(uint256,string)[]" {
+		t.Errorf("tuple string mismatch: have %s", tuple)
+	}
+	if name := tuple.Type.Field(1).Name; name != "Memo" {
+		t.Errorf("tuple field name mismatch: have %s, want Memo", name)
+	}
+	if !isDynamicType(tuple) || !isDynamicType(*tuple.Elem) {
+		t.Errorf("tuple with string component not dynamic")
+	}
+}
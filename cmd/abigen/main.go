@@ -33,9 +33,11 @@ var (
 	binFlag = flag.String("bin", "", "Path to the Ethereum contract bytecode (generate deploy method)")
 	typFlag = flag.String("type", "", "Struct name for the binding (default = package name)")
 
-	solFlag  = flag.String("sol", "", "Path to the Ethereum contract Solidity source to build and bind")
-	solcFlag = flag.String("solc", "solc", "Solidity compiler to use if source builds are requested")
-	excFlag  = flag.String("exc", "", "Comma separated types to exclude from binding")
+	solFlag   = flag.String("sol", "", "Path to the Ethereum contract Solidity source to build and bind")
+	solcFlag  = flag.String("solc", "solc", "Solidity compiler to use if source builds are requested")
+	vyFlag    = flag.String("vy", "", "Path to the Ethereum contract Vyper source to build and bind")
+	vyperFlag = flag.String("vyper", "vyper", "Vyper compiler to use if source builds are requested")
+	excFlag   = flag.String("exc", "", "Comma separated types to exclude from binding")
 
 	pkgFlag  = flag.String("pkg", "", "Package name to generate the binding into")
 	outFlag  = flag.String("out", "", "Output file for the generated binding (default = stdout)")
@@ -46,11 +48,14 @@ func main() {
 	// Parse and ensure all needed inputs are specified
 	flag.Parse()
 
-	if *abiFlag == "" && *solFlag == "" {
-		fmt.Printf("No contract ABI (--abi) or Solidity source (--sol) specified\n")
+	if *abiFlag == "" && *solFlag == "" && *vyFlag == "" {
+		fmt.Printf("No contract ABI (--abi), Solidity source (--sol) or Vyper source (--vy) specified\n")
 		os.Exit(-1)
-	} else if (*abiFlag != "" || *binFlag != "" || *typFlag != "") && *solFlag != "" {
-		fmt.Printf("Contract ABI (--abi), bytecode (--bin) and type (--type) flags are mutually exclusive with the Solidity source (--sol) flag\n")
+	} else if (*abiFlag != "" || *binFlag != "" || *typFlag != "") && (*solFlag != "" || *vyFlag != "") {
+		fmt.Printf("Contract ABI (--abi), bytecode (--bin) and type (--type) flags are mutually exclusive with the Solidity (--sol) and Vyper (--vy) source flags\n")
+		os.Exit(-1)
+	} else if *solFlag != "" && *vyFlag != "" {
+		fmt.Printf("Solidity (--sol) and Vyper (--vy) source flags are mutually exclusive\n")
 		os.Exit(-1)
 	}
 	if *pkgFlag == "" {
@@ -69,21 +74,25 @@ func main() {
 		fmt.Printf("Unsupported destination language \"%s\" (--lang)\n", *langFlag)
 		os.Exit(-1)
 	}
-	// If the entire solidity code was specified, build and bind based on that
+	// If the entire solidity or vyper code was specified, build and bind based on that
 	var (
 		abis  []string
 		bins  []string
 		types []string
 	)
-	if *solFlag != "" {
+	if *solFlag != "" || *vyFlag != "" {
 		// Generate the list of types to exclude from binding
 		exclude := make(map[string]bool)
 		for _, kind := range strings.Split(*excFlag, ",") {
 			exclude[strings.ToLower(kind)] = true
 		}
-		contracts, err := compiler.CompileSolidity(*solcFlag, *solFlag)
+		source, path, lang := *solFlag, *solcFlag, compiler.LangSolidity
+		if *vyFlag != "" {
+			source, path, lang = *vyFlag, *vyperFlag, compiler.LangVyper
+		}
+		contracts, err := compiler.Compile(lang, path, source)
 		if err != nil {
-			fmt.Printf("Failed to build Solidity contract: %v\n", err)
+			fmt.Printf("Failed to build %s contract: %v\n", lang, err)
 			os.Exit(-1)
 		}
 		// Gather all non-excluded contract for binding
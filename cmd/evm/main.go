@@ -119,7 +119,14 @@ func run(ctx *cli.Context) error {
 	statedb, _ := state.New(common.Hash{}, db)
 	sender := statedb.CreateAccount(common.StringToAddress("sender"))
 
-	logger := vm.NewStructLogger(nil)
+	var (
+		logger *vm.StructLogger
+		tracer vm.Tracer
+	)
+	if ctx.GlobalBool(DebugFlag.Name) {
+		logger = vm.NewStructLogger(nil)
+		tracer = logger
+	}
 
 	tstart := time.Now()
 
@@ -160,7 +167,7 @@ func run(ctx *cli.Context) error {
 			GasPrice: common.Big(ctx.GlobalString(PriceFlag.Name)),
 			Value:    common.Big(ctx.GlobalString(ValueFlag.Name)),
 			EVMConfig: vm.Config{
-				Tracer:             logger,
+				Tracer:             tracer,
 				DisableGasMetering: ctx.GlobalBool(DisableGasMeteringFlag.Name),
 			},
 		})
@@ -175,7 +182,7 @@ func run(ctx *cli.Context) error {
 			GasPrice: common.Big(ctx.GlobalString(PriceFlag.Name)),
 			Value:    common.Big(ctx.GlobalString(ValueFlag.Name)),
 			EVMConfig: vm.Config{
-				Tracer:             logger,
+				Tracer:             tracer,
 				DisableGasMetering: ctx.GlobalBool(DisableGasMeteringFlag.Name),
 			},
 		})
@@ -186,7 +193,9 @@ func run(ctx *cli.Context) error {
 		statedb.Commit(true)
 		fmt.Println(string(statedb.Dump()))
 	}
-	vm.StdErrFormat(logger.StructLogs())
+	if logger != nil {
+		vm.StdErrFormat(logger.StructLogs())
+	}
 
 	if ctx.GlobalBool(SysStatFlag.Name) {
 		var mem goruntime.MemStats
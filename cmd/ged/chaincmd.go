@@ -73,6 +73,60 @@ Requires a first argument of the file to write to.
 Optional second and third arguments control the first and
 last block to write. In this mode, the file will be appended
 if already existing.
+`,
+	}
+	importPreimagesCommand = cli.Command{
+		Action:    importPreimages,
+		Name:      "import-preimages",
+		Usage:     "Import the trie key preimages from an RLP stream",
+		ArgsUsage: "<datafile>",
+		Category:  "BLOCKCHAIN COMMANDS",
+		Description: `
+The import-preimages command imports hash preimages (the addresses and storage
+slots the state trie keys are derived from) exported by export-preimages into
+the chain database. Files ending in .gz are decompressed transparently.
+`,
+	}
+	exportPreimagesCommand = cli.Command{
+		Action:    exportPreimages,
+		Name:      "export-preimages",
+		Usage:     "Export the trie key preimages into an RLP stream",
+		ArgsUsage: "<dumpfile>",
+		Category:  "BLOCKCHAIN COMMANDS",
+		Description: `
+The export-preimages command exports all the hash preimages recorded in the chain
+database (see --vmdebug and --triepreimages) into a file, allowing other nodes
+to resolve addresses and storage slots without re-executing the chain. Files
+ending in .gz are gzip compressed.
+`,
+	}
+	importSnapshotCommand = cli.Command{
+		Action:    importSnapshot,
+		Name:      "import-snapshot",
+		Usage:     "Bootstrap an empty chain from a state snapshot",
+		ArgsUsage: "<datafile>",
+		Category:  "BLOCKCHAIN COMMANDS",
+		Description: `
+The import-snapshot command initialises an empty chain database from a state
+snapshot created by export-snapshot, making the snapshot block the head of the
+chain. The node then syncs onwards from that block without downloading (or
+executing) any of the blocks preceding it. The imported state is verified
+against the state root of the snapshot block. Files ending in .gz are
+decompressed transparently.
+`,
+	}
+	exportSnapshotCommand = cli.Command{
+		Action:    exportSnapshot,
+		Name:      "export-snapshot",
+		Usage:     "Export the complete state at a block into a snapshot file",
+		ArgsUsage: "<dumpfile> [<blockHash|blockNum>]",
+		Category:  "BLOCKCHAIN COMMANDS",
+		Description: `
+The export-snapshot command writes all the accounts, storage and code of the
+state at the given block (defaulting to the current head) into a compact binary
+file, together with the genesis and the most recent blocks of the chain. The
+file can be used to provision new nodes via import-snapshot. Files ending in
+.gz are gzip compressed.
 `,
 	}
 	upgradedbCommand = cli.Command{
@@ -89,10 +143,17 @@ TODO: Please write this
 		Action:    removeDB,
 		Name:      "removedb",
 		Usage:     "Remove blockchain and state databases",
-		ArgsUsage: " ",
+		ArgsUsage: "[<database>...]",
 		Category:  "BLOCKCHAIN COMMANDS",
 		Description: `
-TODO: Please write this
+The removedb command deletes the given databases of the node (all of them if
+none is given), asking for confirmation of each. The available databases are:
+
+    chaindata       full node blockchain and state database
+    lightchaindata  light client blockchain database
+    nodes           peer discovery database
+
+The keystore, the node key and the static/trusted node lists are never removed.
 `,
 	}
 	dumpCommand = cli.Command{
@@ -231,30 +292,168 @@ func exportChain(ctx *cli.Context) error {
 	return nil
 }
 
-func removeDB(ctx *cli.Context) error {
+// importPreimages imports the hash preimages of an exported RLP stream into the
+// chain database.
+func importPreimages(ctx *cli.Context) error {
+	if len(ctx.Args()) != 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
 	stack := utils.MakeNode(ctx, clientIdentifier, gitCommit)
-	dbdir := stack.ResolvePath(utils.ChainDbName(ctx))
-	if !common.FileExist(dbdir) {
-		fmt.Println(dbdir, "does not exist")
-		return nil
+	db := utils.MakeChainDatabase(ctx, stack)
+	defer db.Close()
+
+	start := time.Now()
+	if err := utils.ImportPreimages(db, ctx.Args().First()); err != nil {
+		utils.Fatalf("Import error: %v", err)
+	}
+	fmt.Printf("Import done in %v\n", time.Since(start))
+	return nil
+}
+
+// exportPreimages dumps all the hash preimages of the chain database into an RLP
+// stream.
+func exportPreimages(ctx *cli.Context) error {
+	if len(ctx.Args()) != 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+	stack := utils.MakeNode(ctx, clientIdentifier, gitCommit)
+	db := utils.MakeChainDatabase(ctx, stack)
+	defer db.Close()
+
+	ldb, ok := db.(*ethdb.LDBDatabase)
+	if !ok {
+		utils.Fatalf("Preimage export requires a LevelDB database")
+	}
+	start := time.Now()
+	if err := utils.ExportPreimages(ldb, ctx.Args().First()); err != nil {
+		utils.Fatalf("Export error: %v", err)
+	}
+	fmt.Printf("Export done in %v\n", time.Since(start))
+	return nil
+}
+
+// importSnapshot initialises an empty chain database from a state snapshot.
+func importSnapshot(ctx *cli.Context) error {
+	if len(ctx.Args()) != 1 {
+		utils.Fatalf("This command requires an argument.")
 	}
+	stack := utils.MakeNode(ctx, clientIdentifier, gitCommit)
+	db := utils.MakeChainDatabase(ctx, stack)
+	defer db.Close()
 
-	fmt.Println(dbdir)
-	confirm, err := console.Stdin.PromptConfirm("Remove this database?")
-	switch {
-	case err != nil:
-		utils.Fatalf("%v", err)
-	case !confirm:
-		fmt.Println("Operation aborted")
-	default:
-		fmt.Println("Removing...")
-		start := time.Now()
-		os.RemoveAll(dbdir)
-		fmt.Printf("Removed in %v\n", time.Since(start))
+	start := time.Now()
+	block, err := utils.ImportSnapshot(db, ctx.Args().First())
+	if err != nil {
+		utils.Fatalf("Import error: %v", err)
 	}
+	fmt.Printf("Imported state of block #%d [%x…] in %v\n", block.NumberU64(), block.Hash().Bytes()[:4], time.Since(start))
 	return nil
 }
 
+// exportSnapshot writes the complete state at a block into a snapshot file.
+func exportSnapshot(ctx *cli.Context) error {
+	if len(ctx.Args()) < 1 || len(ctx.Args()) > 2 {
+		utils.Fatalf("This command requires one or two arguments.")
+	}
+	stack := makeFullNode(ctx)
+	chain, chainDb := utils.MakeChain(ctx, stack)
+	defer chainDb.Close()
+
+	block := chain.CurrentBlock()
+	if len(ctx.Args()) == 2 {
+		if arg := ctx.Args().Get(1); hashish(arg) {
+			block = chain.GetBlockByHash(common.HexToHash(arg))
+		} else {
+			num, _ := strconv.ParseUint(arg, 10, 64)
+			block = chain.GetBlockByNumber(num)
+		}
+		if block == nil {
+			utils.Fatalf("Export error: block %s not found", ctx.Args().Get(1))
+		}
+	}
+	start := time.Now()
+	if err := utils.ExportSnapshot(chain, block, ctx.Args().First()); err != nil {
+		utils.Fatalf("Export error: %v", err)
+	}
+	fmt.Printf("Export done in %v\n", time.Since(start))
+	return nil
+}
+
+// removableDatabase is a database within the instance directory that removedb
+// may delete in order to resync from scratch.
+type removableDatabase struct {
+	name string
+	desc string
+}
+
+var removableDatabases = []removableDatabase{
+	{"chaindata", "Full node chain database"},
+	{"lightchaindata", "Light client chain database"},
+	{"nodes", "Peer discovery database"},
+}
+
+func removeDB(ctx *cli.Context) error {
+	// Select the databases to remove, defaulting to all of them
+	selected := removableDatabases
+	if ctx.NArg() > 0 {
+		selected = nil
+		for _, name := range ctx.Args() {
+			var found bool
+			for _, db := range removableDatabases {
+				if db.name == name {
+					selected, found = append(selected, db), true
+					break
+				}
+			}
+			if !found {
+				utils.Fatalf("Unknown database %q (known: chaindata, lightchaindata, nodes)", name)
+			}
+		}
+	}
+	stack := utils.MakeNode(ctx, clientIdentifier, gitCommit)
+	for _, db := range selected {
+		dbdir := stack.ResolvePath(db.name)
+		if !common.FileExist(dbdir) {
+			fmt.Println(db.desc, "not found:", dbdir)
+			continue
+		}
+		size, err := dirSize(dbdir)
+		if err != nil {
+			utils.Fatalf("Failed to measure %s: %v", dbdir, err)
+		}
+		fmt.Printf("%s (%v): %s\n", db.desc, size, dbdir)
+		confirm, err := console.Stdin.PromptConfirm("Remove this database?")
+		switch {
+		case err != nil:
+			utils.Fatalf("%v", err)
+		case !confirm:
+			fmt.Println("Operation aborted")
+		default:
+			start := time.Now()
+			if err := os.RemoveAll(dbdir); err != nil {
+				utils.Fatalf("Failed to remove %s: %v", dbdir, err)
+			}
+			fmt.Printf("Removed %v in %v\n", size, time.Since(start))
+		}
+	}
+	return nil
+}
+
+// dirSize returns the total size of the files within a directory.
+func dirSize(dir string) (common.StorageSize, error) {
+	var size int64
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return common.StorageSize(size), err
+}
+
 func upgradeDB(ctx *cli.Context) error {
 	glog.Infoln("Upgrading blockchain database")
 
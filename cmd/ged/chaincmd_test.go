@@ -0,0 +1,62 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/EarthDollar/go-earthdollar/common"
+)
+
+// Tests that removedb only deletes the selected and confirmed databases, leaving
+// the keystore and the node key alone.
+func TestRemoveDB(t *testing.T) {
+	datadir := tmpdir(t)
+	defer os.RemoveAll(datadir)
+
+	files := []string{
+		filepath.Join("ged", "chaindata", "000001.log"),
+		filepath.Join("ged", "nodes", "000001.log"),
+		filepath.Join("ged", "nodekey"),
+		filepath.Join("keystore", "UTC--2016-03-22T12-57-55.920751759Z--7ef5a6135f1fd6a02593eedc869c6d41d934aef8"),
+	}
+	for _, file := range files {
+		path := filepath.Join(datadir, file)
+		os.MkdirAll(filepath.Dir(path), 0700)
+		if err := ioutil.WriteFile(path, []byte("0123456789"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ged := runGeth(t, "--datadir", datadir, "removedb", "nodes", "chaindata", "lightchaindata")
+	ged.setTemplateFunc("path", func(name string) string { return filepath.Join(datadir, "ged", name) })
+	ged.expect(`
+Peer discovery database (10.00 B): {{path "nodes"}}
+Remove this database? [y/N] {{.InputLine "n"}}Operation aborted
+Full node chain database (10.00 B): {{path "chaindata"}}
+Remove this database? [y/N] {{.InputLine "y"}}`)
+	ged.expectRegexp(`Removed 10.00 B in .+\nLight client chain database not found: .+\n`)
+	ged.expectExit()
+
+	for i, file := range files {
+		if exists := common.FileExist(filepath.Join(datadir, file)); exists != (i != 0) {
+			t.Errorf("%s: existence mismatch: have %v, want %v", file, exists, i != 0)
+		}
+	}
+}
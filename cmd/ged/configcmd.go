@@ -0,0 +1,114 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+
+	"github.com/EarthDollar/go-earthdollar/cmd/utils"
+	"github.com/EarthDollar/go-earthdollar/common"
+	"github.com/EarthDollar/go-earthdollar/core"
+	"github.com/EarthDollar/go-earthdollar/eth"
+	"github.com/EarthDollar/go-earthdollar/node"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	dumpConfigFormatFlag = cli.StringFlag{
+		Name:  "format",
+		Value: "toml",
+		Usage: "Output format of the configuration (toml or json)",
+	}
+	dumpConfigCommand = cli.Command{
+		Action:    dumpConfig,
+		Name:      "dumpconfig",
+		Usage:     "Show the effective configuration values",
+		ArgsUsage: "[<filename>]",
+		Category:  "MISCELLANEOUS COMMANDS",
+		Description: `
+The dumpconfig command resolves the configuration the node would run with given
+the other command line flags (network presets, computed defaults, bootstrap
+nodes, genesis block) and writes it to the given file or the standard output.
+
+The node key is not included in the output.
+`,
+		Flags: []cli.Flag{
+			dumpConfigFormatFlag,
+		},
+	}
+)
+
+// gedConfig is the effective configuration of all the services of a ged node.
+type gedConfig struct {
+	Whisper  bool             `json:"whisper"`
+	EthStats string           `json:"ethstats,omitempty"`
+	Node     *node.ConfigDump `json:"node"`
+	Eth      *eth.ConfigDump  `json:"eth"`
+}
+
+// dumpConfig resolves the configuration of the node as assembled from the
+// command line flags and writes it out in the requested format.
+func dumpConfig(ctx *cli.Context) error {
+	format := ctx.String(dumpConfigFormatFlag.Name)
+	if format != "toml" && format != "json" {
+		utils.Fatalf("Unknown configuration format %q (want toml or json)", format)
+	}
+	nodeConf := utils.MakeNodeConfig(ctx, clientIdentifier, gitCommit)
+	stack, err := node.New(nodeConf)
+	if err != nil {
+		utils.Fatalf("Failed to create the protocol stack: %v", err)
+	}
+	ethConf, err := utils.MakeEthConfig(ctx, stack, makeDefaultExtra()).Dump()
+	if err != nil {
+		utils.Fatalf("Failed to resolve the Ethereum configuration: %v", err)
+	}
+	// An already initialized chain keeps its genesis regardless of the flags
+	db := utils.MakeChainDatabase(ctx, stack)
+	if hash := core.GetCanonicalHash(db, 0); hash != (common.Hash{}) {
+		ethConf.GenesisHash = hash
+	}
+	db.Close()
+
+	config := &gedConfig{
+		Whisper:  ctx.GlobalBool(utils.WhisperEnabledFlag.Name) || (!ctx.GlobalIsSet(utils.WhisperEnabledFlag.Name) && utils.MakeNetwork(ctx).Ephemeral),
+		EthStats: ctx.GlobalString(utils.EthStatsURLFlag.Name),
+		Node:     nodeConf.Dump(),
+		Eth:      ethConf,
+	}
+	var out io.Writer = os.Stdout
+	if ctx.NArg() > 0 {
+		file, err := os.Create(ctx.Args().First())
+		if err != nil {
+			utils.Fatalf("Failed to create the configuration file: %v", err)
+		}
+		defer file.Close()
+		out = file
+	}
+	switch format {
+	case "json":
+		blob, err := json.MarshalIndent(config, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = out.Write(append(blob, '\n'))
+		return err
+	default:
+		return utils.EncodeTOML(out, config)
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/EarthDollar/go-earthdollar/cmd/utils"
+	"github.com/EarthDollar/go-earthdollar/core"
+	"github.com/EarthDollar/go-earthdollar/ethdb"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	dbCommand = cli.Command{
+		Name:      "db",
+		Usage:     "Low level database operations",
+		ArgsUsage: "",
+		Category:  "BLOCKCHAIN COMMANDS",
+		Subcommands: []cli.Command{
+			{
+				Action:    inspectDB,
+				Name:      "inspect",
+				Usage:     "Inspect the key-space of the chain database",
+				ArgsUsage: " ",
+				Description: `
+The inspect command iterates over the entire chain database and prints the number
+and total size of the entries of each category (headers, bodies, receipts, trie
+nodes, bloom bits, metadata, ...).
+
+Block data without a corresponding header and keys not belonging to any known
+category are reported separately, which helps verifying the database before and
+after migrations. The node must not be running while inspecting its database.
+`,
+			},
+		},
+	}
+)
+
+// inspectDB iterates over the chain database and prints its key-space breakdown.
+func inspectDB(ctx *cli.Context) error {
+	stack := utils.MakeNode(ctx, clientIdentifier, gitCommit)
+	db := utils.MakeChainDatabase(ctx, stack)
+	defer db.Close()
+
+	ldb, ok := db.(*ethdb.LDBDatabase)
+	if !ok {
+		utils.Fatalf("Database inspection requires a LevelDB database")
+	}
+	start := time.Now()
+	ins, err := core.InspectDatabase(ldb)
+	if err != nil {
+		utils.Fatalf("Failed to inspect the database: %v", err)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "CATEGORY\tITEMS\tSIZE")
+	for _, stat := range ins.Stats() {
+		if stat.Count > 0 {
+			fmt.Fprintf(w, "%s\t%d\t%v\n", stat.Name, stat.Count, stat.Size)
+		}
+	}
+	total := ins.Total()
+	fmt.Fprintf(w, "%s\t%d\t%v\n", total.Name, total.Count, total.Size)
+	w.Flush()
+
+	if dangling := ins.Dangling(); dangling > 0 {
+		fmt.Printf("\nDangling entries (referencing missing headers): %d\n", dangling)
+		fmt.Printf("  total difficulties:  %d\n", ins.DanglingTds)
+		fmt.Printf("  bodies:              %d\n", ins.DanglingBodies)
+		fmt.Printf("  receipts:            %d\n", ins.DanglingReceipts)
+		fmt.Printf("  canonical hashes:    %d\n", ins.DanglingCanonical)
+	}
+	if len(ins.UnknownKeys) > 0 {
+		fmt.Printf("\nUnknown keys (showing %d of %d):\n", len(ins.UnknownKeys), ins.Unknown.Count)
+		for _, key := range ins.UnknownKeys {
+			fmt.Printf("  %x\n", key)
+		}
+	}
+	fmt.Printf("\nInspected in %v\n", time.Since(start))
+	return nil
+}
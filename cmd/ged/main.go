@@ -63,9 +63,15 @@ func init() {
 		initCommand,
 		importCommand,
 		exportCommand,
+		importPreimagesCommand,
+		exportPreimagesCommand,
+		importSnapshotCommand,
+		exportSnapshotCommand,
 		upgradedbCommand,
 		removedbCommand,
 		dumpCommand,
+		// See dbcmd.go:
+		dbCommand,
 		// See monitorcmd.go:
 		monitorCommand,
 		// See accountcmd.go:
@@ -75,6 +81,8 @@ func init() {
 		consoleCommand,
 		attachCommand,
 		javascriptCommand,
+		// See configcmd.go:
+		dumpConfigCommand,
 		// See misccmd.go:
 		makedagCommand,
 		versionCommand,
@@ -86,6 +94,7 @@ func init() {
 		utils.UnlockedAccountFlag,
 		utils.PasswordFileFlag,
 		utils.BootnodesFlag,
+		utils.DNSDiscoveryFlag,
 		utils.DataDirFlag,
 		utils.KeyStoreDirFlag,
 		utils.FastSyncFlag,
@@ -93,13 +102,24 @@ func init() {
 		utils.LightServFlag,
 		utils.LightPeersFlag,
 		utils.LightKDFFlag,
+		utils.ShutdownTimeoutFlag,
 		utils.CacheFlag,
+		utils.CacheDatabaseFlag,
+		utils.CacheTrieFlag,
+		utils.CacheSnapshotFlag,
 		utils.TrieCacheGenFlag,
+		utils.TrieFlushFlag,
+		utils.TxLookupLimitFlag,
 		utils.JSpathFlag,
 		utils.ListenPortFlag,
 		utils.MaxPeersFlag,
 		utils.MaxPendingPeersFlag,
+		utils.MaxMessageSizeFlag,
+		utils.MaxMessageRateFlag,
+		utils.MaxDownloadFlag,
+		utils.MaxUploadFlag,
 		utils.EtherbaseFlag,
+		utils.RotatingEtherbasesFlag,
 		utils.GasPriceFlag,
 		utils.MinerThreadsFlag,
 		utils.MiningEnabledFlag,
@@ -109,6 +129,8 @@ func init() {
 		utils.NoDiscoverFlag,
 		utils.DiscoveryV5Flag,
 		utils.NetrestrictFlag,
+		utils.ProxyFlag,
+		utils.ProxyNoDiscoverFlag,
 		utils.NodeKeyFileFlag,
 		utils.NodeKeyHexFlag,
 		utils.RPCEnabledFlag,
@@ -123,21 +145,51 @@ func init() {
 		utils.IPCDisabledFlag,
 		utils.IPCApiFlag,
 		utils.IPCPathFlag,
+		utils.RPCRateLimitFlag,
+		utils.RPCRateBurstFlag,
+		utils.RPCQuotaFlag,
+		utils.RPCBatchLimitFlag,
+		utils.RPCResponseSizeLimitFlag,
+		utils.RPCConcurrencyLimitFlag,
+		utils.AuthRPCEnabledFlag,
+		utils.AuthRPCListenAddrFlag,
+		utils.AuthRPCPortFlag,
+		utils.AuthRPCApiFlag,
+		utils.JWTSecretFlag,
+		utils.RPCAuditLogFlag,
 		utils.ExecFlag,
 		utils.PreloadJSFlag,
 		utils.WhisperEnabledFlag,
+		utils.WhisperMaxMessageSizeFlag,
+		utils.WhisperMinPOWFlag,
+		utils.WhisperLightModeFlag,
+		utils.WhisperMailServerFlag,
+		utils.WhisperMailPasswordFlag,
+		utils.NetworkFlag,
 		utils.DevModeFlag,
 		utils.TestNetFlag,
 		utils.VMForceJitFlag,
 		utils.VMJitCacheFlag,
 		utils.VMEnableJitFlag,
 		utils.VMEnableDebugFlag,
+		utils.VMParallelFlag,
+		utils.TriePreimagesFlag,
 		utils.NetworkIdFlag,
 		utils.RPCCORSDomainFlag,
+		utils.ReadyMaxHeadAgeFlag,
+		utils.LogsMaxRangeFlag,
+		utils.LogsMaxResultsFlag,
+		utils.FilterTimeoutFlag,
 		utils.EthStatsURLFlag,
 		utils.MetricsEnabledFlag,
+		utils.MetricsInfluxDBFlag,
+		utils.MetricsGraphiteFlag,
+		utils.MetricsIntervalFlag,
 		utils.FakePoWFlag,
 		utils.SolcPathFlag,
+		utils.NoReleaseCheckFlag,
+		utils.ReleaseManifestFlag,
+		utils.ReleaseSignerFlag,
 		utils.GpoMinGasPriceFlag,
 		utils.GpoMaxGasPriceFlag,
 		utils.GpoFullBlockRatioFlag,
@@ -191,58 +243,75 @@ func ged(ctx *cli.Context) error {
 }
 
 func makeFullNode(ctx *cli.Context) *node.Node {
-	// Create the default extradata and construct the base node
-	var clientInfo = struct {
-		Version   uint
-		Name      string
-		GoVersion string
-		Os        string
-	}{uint(params.VersionMajor<<16 | params.VersionMinor<<8 | params.VersionPatch), clientIdentifier, runtime.Version(), runtime.GOOS}
-	extra, err := rlp.EncodeToBytes(clientInfo)
-	if err != nil {
-		glog.V(logger.Warn).Infoln("error setting canonical miner information:", err)
-	}
-	if uint64(len(extra)) > params.MaximumExtraDataSize.Uint64() {
-		glog.V(logger.Warn).Infoln("error setting canonical miner information: extra exceeds", params.MaximumExtraDataSize)
-		glog.V(logger.Debug).Infof("extra: %x\n", extra)
-		extra = nil
-	}
 	stack := utils.MakeNode(ctx, clientIdentifier, gitCommit)
-	utils.RegisterEthService(ctx, stack, extra)
+	utils.RegisterEthService(ctx, stack, makeDefaultExtra())
 
-	// Whisper must be explicitly enabled, but is auto-enabled in --dev mode.
+	// Whisper must be explicitly enabled, but is auto-enabled on ephemeral networks (e.g. --dev).
 	shhEnabled := ctx.GlobalBool(utils.WhisperEnabledFlag.Name)
-	shhAutoEnabled := !ctx.GlobalIsSet(utils.WhisperEnabledFlag.Name) && ctx.GlobalIsSet(utils.DevModeFlag.Name)
+	shhAutoEnabled := !ctx.GlobalIsSet(utils.WhisperEnabledFlag.Name) && utils.MakeNetwork(ctx).Ephemeral
 	if shhEnabled || shhAutoEnabled {
-		utils.RegisterShhService(stack)
+		utils.RegisterShhService(ctx, stack)
 	}
 	// Add the Ethereum Stats daemon if requested
 	if url := ctx.GlobalString(utils.EthStatsURLFlag.Name); url != "" {
 		utils.RegisterEthStatsService(stack, url)
 	}
-	// Add the release oracle service so it boots along with node.
-	if err := stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
+	// Add the release checker service so it boots along with node, unless disabled
+	if !ctx.GlobalBool(utils.NoReleaseCheckFlag.Name) {
 		config := release.Config{
-			Oracle: relOracle,
-			Major:  uint32(params.VersionMajor),
-			Minor:  uint32(params.VersionMinor),
-			Patch:  uint32(params.VersionPatch),
+			Oracle:   relOracle,
+			Manifest: ctx.GlobalString(utils.ReleaseManifestFlag.Name),
+			Major:    uint32(params.VersionMajor),
+			Minor:    uint32(params.VersionMinor),
+			Patch:    uint32(params.VersionPatch),
+		}
+		if config.Manifest != "" {
+			signer := ctx.GlobalString(utils.ReleaseSignerFlag.Name)
+			if !common.IsHexAddress(signer) {
+				utils.Fatalf("A valid --%s is required to verify the release manifest", utils.ReleaseSignerFlag.Name)
+			}
+			config.Signer = common.HexToAddress(signer)
 		}
 		commit, _ := hex.DecodeString(gitCommit)
 		copy(config.Commit[:], commit)
-		return release.NewReleaseService(ctx, config)
-	}); err != nil {
-		utils.Fatalf("Failed to register the Geth release oracle service: %v", err)
+
+		if err := stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
+			return release.NewReleaseService(ctx, config)
+		}); err != nil {
+			utils.Fatalf("Failed to register the Geth release oracle service: %v", err)
+		}
 	}
 	return stack
 }
 
+// makeDefaultExtra creates the default extra-data of the mined blocks, carrying
+// the version of the client and the platform it runs on.
+func makeDefaultExtra() []byte {
+	var clientInfo = struct {
+		Version   uint
+		Name      string
+		GoVersion string
+		Os        string
+	}{uint(params.VersionMajor<<16 | params.VersionMinor<<8 | params.VersionPatch), clientIdentifier, runtime.Version(), runtime.GOOS}
+	extra, err := rlp.EncodeToBytes(clientInfo)
+	if err != nil {
+		glog.V(logger.Warn).Infoln("error setting canonical miner information:", err)
+	}
+	if uint64(len(extra)) > params.MaximumExtraDataSize.Uint64() {
+		glog.V(logger.Warn).Infoln("error setting canonical miner information: extra exceeds", params.MaximumExtraDataSize)
+		glog.V(logger.Debug).Infof("extra: %x\n", extra)
+		extra = nil
+	}
+	return extra
+}
+
 // startNode boots up the system node and all registered protocols, after which
 // it unlocks any requested accounts, and starts the RPC/IPC interfaces and the
 // miner.
 func startNode(ctx *cli.Context, stack *node.Node) {
 	// Start up the node itself
 	utils.StartNode(stack)
+	utils.StartMetricsReporters(ctx, clientIdentifier)
 
 	// Unlock any account specifically requested
 	accman := stack.AccountManager()
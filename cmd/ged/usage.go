@@ -67,6 +67,7 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.DataDirFlag,
 			utils.KeyStoreDirFlag,
 			utils.NetworkIdFlag,
+			utils.NetworkFlag,
 			utils.TestNetFlag,
 			utils.DevModeFlag,
 			utils.IdentityFlag,
@@ -75,13 +76,19 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.LightServFlag,
 			utils.LightPeersFlag,
 			utils.LightKDFFlag,
+			utils.ShutdownTimeoutFlag,
 		},
 	},
 	{
 		Name: "PERFORMANCE TUNING",
 		Flags: []cli.Flag{
 			utils.CacheFlag,
+			utils.CacheDatabaseFlag,
+			utils.CacheTrieFlag,
+			utils.CacheSnapshotFlag,
 			utils.TrieCacheGenFlag,
+			utils.TrieFlushFlag,
+			utils.TxLookupLimitFlag,
 		},
 	},
 	{
@@ -106,7 +113,23 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.IPCDisabledFlag,
 			utils.IPCApiFlag,
 			utils.IPCPathFlag,
+			utils.RPCRateLimitFlag,
+			utils.RPCRateBurstFlag,
+			utils.RPCQuotaFlag,
+			utils.RPCBatchLimitFlag,
+			utils.RPCResponseSizeLimitFlag,
+			utils.RPCConcurrencyLimitFlag,
+			utils.AuthRPCEnabledFlag,
+			utils.AuthRPCListenAddrFlag,
+			utils.AuthRPCPortFlag,
+			utils.AuthRPCApiFlag,
+			utils.JWTSecretFlag,
+			utils.RPCAuditLogFlag,
 			utils.RPCCORSDomainFlag,
+			utils.ReadyMaxHeadAgeFlag,
+			utils.LogsMaxRangeFlag,
+			utils.LogsMaxResultsFlag,
+			utils.FilterTimeoutFlag,
 			utils.JSpathFlag,
 			utils.ExecFlag,
 			utils.PreloadJSFlag,
@@ -116,12 +139,20 @@ var AppHelpFlagGroups = []flagGroup{
 		Name: "NETWORKING",
 		Flags: []cli.Flag{
 			utils.BootnodesFlag,
+			utils.DNSDiscoveryFlag,
 			utils.ListenPortFlag,
 			utils.MaxPeersFlag,
 			utils.MaxPendingPeersFlag,
+			utils.MaxMessageSizeFlag,
+			utils.MaxMessageRateFlag,
+			utils.MaxDownloadFlag,
+			utils.MaxUploadFlag,
 			utils.NATFlag,
 			utils.NoDiscoverFlag,
 			utils.DiscoveryV5Flag,
+			utils.NetrestrictFlag,
+			utils.ProxyFlag,
+			utils.ProxyNoDiscoverFlag,
 			utils.NodeKeyFileFlag,
 			utils.NodeKeyHexFlag,
 		},
@@ -133,6 +164,7 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.MinerThreadsFlag,
 			utils.AutoDAGFlag,
 			utils.EtherbaseFlag,
+			utils.RotatingEtherbasesFlag,
 			utils.TargetGasLimitFlag,
 			utils.GasPriceFlag,
 			utils.ExtraDataFlag,
@@ -156,6 +188,8 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.VMForceJitFlag,
 			utils.VMJitCacheFlag,
 			utils.VMEnableDebugFlag,
+			utils.VMParallelFlag,
+			utils.TriePreimagesFlag,
 		},
 	},
 	{
@@ -163,6 +197,9 @@ var AppHelpFlagGroups = []flagGroup{
 		Flags: append([]cli.Flag{
 			utils.EthStatsURLFlag,
 			utils.MetricsEnabledFlag,
+			utils.MetricsInfluxDBFlag,
+			utils.MetricsGraphiteFlag,
+			utils.MetricsIntervalFlag,
 			utils.FakePoWFlag,
 		}, debug.Flags...),
 	},
@@ -170,12 +207,20 @@ var AppHelpFlagGroups = []flagGroup{
 		Name: "EXPERIMENTAL",
 		Flags: []cli.Flag{
 			utils.WhisperEnabledFlag,
+			utils.WhisperMaxMessageSizeFlag,
+			utils.WhisperMinPOWFlag,
+			utils.WhisperLightModeFlag,
+			utils.WhisperMailServerFlag,
+			utils.WhisperMailPasswordFlag,
 		},
 	},
 	{
 		Name: "MISCELLANEOUS",
 		Flags: []cli.Flag{
 			utils.SolcPathFlag,
+			utils.NoReleaseCheckFlag,
+			utils.ReleaseManifestFlag,
+			utils.ReleaseSignerFlag,
 		},
 	},
 }
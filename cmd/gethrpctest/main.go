@@ -30,7 +30,7 @@ import (
 	"github.com/EarthDollar/go-earthdollar/node"
 	"github.com/EarthDollar/go-earthdollar/params"
 	"github.com/EarthDollar/go-earthdollar/tests"
-	whisper "github.com/EarthDollar/go-earthdollar/whisper/whisperv2"
+	whisper "github.com/EarthDollar/go-earthdollar/whisper/whisperv5"
 )
 
 const defaultTestKey = "b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291"
@@ -127,7 +127,7 @@ func MakeSystemNode(privkey string, test *tests.BlockTest) (*node.Node, error) {
 		return nil, err
 	}
 	// Initialize and register the Whisper protocol
-	if err := stack.Register(func(*node.ServiceContext) (node.Service, error) { return whisper.New(), nil }); err != nil {
+	if err := stack.Register(func(*node.ServiceContext) (node.Service, error) { return whisper.New(nil), nil }); err != nil {
 		return nil, err
 	}
 	return stack, nil
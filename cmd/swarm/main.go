@@ -30,7 +30,7 @@ import (
 	"github.com/EarthDollar/go-earthdollar/common"
 	"github.com/EarthDollar/go-earthdollar/console"
 	"github.com/EarthDollar/go-earthdollar/crypto"
-	"github.com/EarthDollar/go-earthdollar/ethclient"
+	"github.com/EarthDollar/go-earthdollar/edclient"
 	"github.com/EarthDollar/go-earthdollar/internal/debug"
 	"github.com/EarthDollar/go-earthdollar/logger"
 	"github.com/EarthDollar/go-earthdollar/logger/glog"
@@ -264,9 +264,9 @@ func registerBzzService(ctx *cli.Context, stack *node.Node) {
 	cors := ctx.GlobalString(CorsStringFlag.Name)
 
 	boot := func(ctx *node.ServiceContext) (node.Service, error) {
-		var client *ethclient.Client
+		var client *edclient.Client
 		if len(ethapi) > 0 {
-			client, err = ethclient.Dial(ethapi)
+			client, err = edclient.Dial(ethapi)
 			if err != nil {
 				utils.Fatalf("Can't connect: %v", err)
 			}
@@ -18,6 +18,7 @@
 package utils
 
 import (
+	"bufio"
 	"compress/gzip"
 	"fmt"
 	"io"
@@ -26,30 +27,24 @@ import (
 	"regexp"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/EarthDollar/go-earthdollar/common"
 	"github.com/EarthDollar/go-earthdollar/core"
 	"github.com/EarthDollar/go-earthdollar/core/types"
+	"github.com/EarthDollar/go-earthdollar/ethdb"
 	"github.com/EarthDollar/go-earthdollar/internal/debug"
 	"github.com/EarthDollar/go-earthdollar/logger"
 	"github.com/EarthDollar/go-earthdollar/logger/glog"
 	"github.com/EarthDollar/go-earthdollar/node"
-	"github.com/EarthDollar/go-earthdollar/rlp"
 )
 
 const (
-	importBatchSize = 2500
+	importBatchSize   = 2500
+	importLogInterval = 8 * time.Second // Interval between two import progress reports
+	exportLogInterval = 8 * time.Second // Interval between two export progress reports
 )
 
-func openLogFile(Datadir string, filename string) *os.File {
-	path := common.AbsolutePath(Datadir, filename)
-	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
-	if err != nil {
-		panic(fmt.Sprintf("error opening log file '%s': %v", filename, err))
-	}
-	return file
-}
-
 // Fatalf formats a message to standard error and exits the program.
 // The message is also printed to standard output if standard error
 // is redirected to a different file.
@@ -119,14 +114,6 @@ func ImportChain(chain *core.BlockChain, fn string) error {
 		}
 		close(stop)
 	}()
-	checkInterrupt := func() bool {
-		select {
-		case <-stop:
-			return true
-		default:
-			return false
-		}
-	}
 
 	glog.Infoln("Importing blockchain ", fn)
 	fh, err := os.Open(fn)
@@ -135,71 +122,45 @@ func ImportChain(chain *core.BlockChain, fn string) error {
 	}
 	defer fh.Close()
 
+	config := core.ImportConfig{
+		BatchSize:   importBatchSize,
+		LogInterval: importLogInterval,
+		Stop:        stop,
+	}
+	if info, err := fh.Stat(); err == nil {
+		config.Size = info.Size()
+	}
 	var reader io.Reader = fh
 	if strings.HasSuffix(fn, ".gz") {
+		// Track the position in the compressed file for the progress reports
+		config.Position = func() int64 {
+			pos, _ := fh.Seek(0, io.SeekCurrent)
+			return pos
+		}
 		if reader, err = gzip.NewReader(reader); err != nil {
 			return err
 		}
 	}
-
-	stream := rlp.NewStream(reader, 0)
-
 	// Run actual the import.
-	blocks := make(types.Blocks, importBatchSize)
-	n := 0
-	for batch := 0; ; batch++ {
-		// Load a batch of RLP blocks.
-		if checkInterrupt() {
-			return fmt.Errorf("interrupted")
-		}
-		i := 0
-		for ; i < importBatchSize; i++ {
-			var b types.Block
-			if err := stream.Decode(&b); err == io.EOF {
-				break
-			} else if err != nil {
-				return fmt.Errorf("at block %d: %v", n, err)
-			}
-			// don't import first block
-			if b.NumberU64() == 0 {
-				i--
-				continue
-			}
-			blocks[i] = &b
-			n++
-		}
-		if i == 0 {
-			break
-		}
-		// Import the batch.
-		if checkInterrupt() {
-			return fmt.Errorf("interrupted")
-		}
-		if hasAllBlocks(chain, blocks[:i]) {
-			glog.Infof("skipping batch %d, all blocks present [%x / %x]",
-				batch, blocks[0].Hash().Bytes()[:4], blocks[i-1].Hash().Bytes()[:4])
-			continue
-		}
+	_, err = chain.ImportChain(reader, config)
+	return err
+}
 
-		if _, err := chain.InsertChain(blocks[:i]); err != nil {
-			return fmt.Errorf("invalid block %d: %v", n, err)
-		}
-	}
-	return nil
+func ExportChain(blockchain *core.BlockChain, fn string) error {
+	return exportChain(blockchain, fn, os.O_TRUNC, 0, blockchain.CurrentBlock().NumberU64())
 }
 
-func hasAllBlocks(chain *core.BlockChain, bs []*types.Block) bool {
-	for _, b := range bs {
-		if !chain.HasBlock(b.Hash()) {
-			return false
-		}
-	}
-	return true
+// ExportAppendChain appends the given range of blocks to a file. Files ending
+// in .gz receive an additional gzip member, which readers transparently join,
+// allowing incremental backups of the chain.
+func ExportAppendChain(blockchain *core.BlockChain, fn string, first uint64, last uint64) error {
+	return exportChain(blockchain, fn, os.O_APPEND, first, last)
 }
 
-func ExportChain(blockchain *core.BlockChain, fn string) error {
+func exportChain(blockchain *core.BlockChain, fn string, mode int, first uint64, last uint64) error {
 	glog.Infoln("Exporting blockchain to ", fn)
-	fh, err := os.OpenFile(fn, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.ModePerm)
+	// TODO verify mode perms
+	fh, err := os.OpenFile(fn, os.O_CREATE|os.O_WRONLY|mode, os.ModePerm)
 	if err != nil {
 		return err
 	}
@@ -211,18 +172,40 @@ func ExportChain(blockchain *core.BlockChain, fn string) error {
 		defer writer.(*gzip.Writer).Close()
 	}
 
-	if err := blockchain.Export(writer); err != nil {
+	if err := blockchain.ExportRange(writer, first, last, core.NewExportLogger(exportLogInterval)); err != nil {
 		return err
 	}
 	glog.Infoln("Exported blockchain to ", fn)
-
 	return nil
 }
 
-func ExportAppendChain(blockchain *core.BlockChain, fn string, first uint64, last uint64) error {
-	glog.Infoln("Exporting blockchain to ", fn)
-	// TODO verify mode perms
-	fh, err := os.OpenFile(fn, os.O_CREATE|os.O_APPEND|os.O_WRONLY, os.ModePerm)
+// ImportPreimages imports a batch of exported hash preimages into the database,
+// transparently decompressing files ending in .gz.
+func ImportPreimages(db ethdb.Database, fn string) error {
+	glog.Infoln("Importing preimages from", fn)
+
+	fh, err := os.Open(fn)
+	if err != nil {
+		return err
+	}
+	defer fh.Close()
+
+	var reader io.Reader = fh
+	if strings.HasSuffix(fn, ".gz") {
+		if reader, err = gzip.NewReader(reader); err != nil {
+			return err
+		}
+	}
+	_, err = core.ImportPreimages(db, reader, importLogInterval)
+	return err
+}
+
+// ExportPreimages exports all known hash preimages into the specified file,
+// gzip compressing them if the file name ends in .gz.
+func ExportPreimages(db *ethdb.LDBDatabase, fn string) error {
+	glog.Infoln("Exporting preimages to", fn)
+
+	fh, err := os.OpenFile(fn, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.ModePerm)
 	if err != nil {
 		return err
 	}
@@ -233,10 +216,61 @@ func ExportAppendChain(blockchain *core.BlockChain, fn string, first uint64, las
 		writer = gzip.NewWriter(writer)
 		defer writer.(*gzip.Writer).Close()
 	}
+	if _, err := core.ExportPreimages(db, writer, exportLogInterval); err != nil {
+		return err
+	}
+	glog.Infoln("Exported preimages to", fn)
+	return nil
+}
+
+// ImportSnapshot bootstraps an empty chain database from a state snapshot file,
+// transparently decompressing files ending in .gz.
+func ImportSnapshot(db ethdb.Database, fn string) (*types.Block, error) {
+	glog.Infoln("Importing state snapshot from", fn)
+
+	fh, err := os.Open(fn)
+	if err != nil {
+		return nil, err
+	}
+	defer fh.Close()
 
-	if err := blockchain.ExportN(writer, first, last); err != nil {
+	var reader io.Reader = bufio.NewReader(fh)
+	if strings.HasSuffix(fn, ".gz") {
+		if reader, err = gzip.NewReader(reader); err != nil {
+			return nil, err
+		}
+	}
+	block, _, err := core.ImportSnapshot(db, reader, importLogInterval)
+	return block, err
+}
+
+// ExportSnapshot exports the complete state at the given block into the
+// specified file, gzip compressing it if the file name ends in .gz.
+func ExportSnapshot(blockchain *core.BlockChain, block *types.Block, fn string) error {
+	glog.Infoln("Exporting state snapshot to", fn)
+
+	fh, err := os.OpenFile(fn, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.ModePerm)
+	if err != nil {
 		return err
 	}
-	glog.Infoln("Exported blockchain to ", fn)
+	defer fh.Close()
+
+	buffer := bufio.NewWriter(fh)
+	var writer io.Writer = buffer
+	if strings.HasSuffix(fn, ".gz") {
+		writer = gzip.NewWriter(writer)
+	}
+	if _, err := core.ExportSnapshot(blockchain, block, writer, exportLogInterval); err != nil {
+		return err
+	}
+	if gz, ok := writer.(*gzip.Writer); ok {
+		if err := gz.Close(); err != nil {
+			return err
+		}
+	}
+	if err := buffer.Flush(); err != nil {
+		return err
+	}
+	glog.Infoln("Exported state snapshot to", fn)
 	return nil
 }
@@ -19,6 +19,7 @@ package utils
 
 import (
 	"crypto/ecdsa"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"math/big"
@@ -27,6 +28,7 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/ethereum/ethash"
 	"github.com/EarthDollar/go-earthdollar/accounts"
@@ -36,6 +38,7 @@ import (
 	"github.com/EarthDollar/go-earthdollar/core/vm"
 	"github.com/EarthDollar/go-earthdollar/crypto"
 	"github.com/EarthDollar/go-earthdollar/eth"
+	"github.com/EarthDollar/go-earthdollar/eth/filters"
 	"github.com/EarthDollar/go-earthdollar/ethdb"
 	"github.com/EarthDollar/go-earthdollar/ethstats"
 	"github.com/EarthDollar/go-earthdollar/event"
@@ -43,6 +46,7 @@ import (
 	"github.com/EarthDollar/go-earthdollar/logger"
 	"github.com/EarthDollar/go-earthdollar/logger/glog"
 	"github.com/EarthDollar/go-earthdollar/metrics"
+	"github.com/EarthDollar/go-earthdollar/miner"
 	"github.com/EarthDollar/go-earthdollar/node"
 	"github.com/EarthDollar/go-earthdollar/p2p/discover"
 	"github.com/EarthDollar/go-earthdollar/p2p/discv5"
@@ -51,7 +55,8 @@ import (
 	"github.com/EarthDollar/go-earthdollar/params"
 	"github.com/EarthDollar/go-earthdollar/pow"
 	"github.com/EarthDollar/go-earthdollar/rpc"
-	whisper "github.com/EarthDollar/go-earthdollar/whisper/whisperv2"
+	"github.com/EarthDollar/go-earthdollar/whisper/mailserver"
+	whisper "github.com/EarthDollar/go-earthdollar/whisper/whisperv5"
 	"gopkg.in/urfave/cli.v1"
 )
 
@@ -116,16 +121,21 @@ var (
 	}
 	NetworkIdFlag = cli.IntFlag{
 		Name:  "networkid",
-		Usage: "Network identifier (integer, 1=Frontier, 2=Morden (disused), 3=Ropsten)",
+		Usage: "Network identifier (integer, 88=Earthdollar, 3=Testnet)",
 		Value: eth.NetworkId,
 	}
+	NetworkFlag = cli.StringFlag{
+		Name:  "network",
+		Usage: "Built-in network to join (" + strings.Join(eth.NetworkNames(), ", ") + ")",
+		Value: "mainnet",
+	}
 	TestNetFlag = cli.BoolFlag{
 		Name:  "testnet",
-		Usage: "Ropsten network: pre-configured test network",
+		Usage: "Ropsten network: pre-configured test network (alias for --network testnet)",
 	}
 	DevModeFlag = cli.BoolFlag{
 		Name:  "dev",
-		Usage: "Developer mode: pre-configured private network with several debugging flags",
+		Usage: "Developer mode: pre-configured private network with several debugging flags (alias for --network dev)",
 	}
 	IdentityFlag = cli.StringFlag{
 		Name:  "identity",
@@ -158,16 +168,46 @@ var (
 		Name:  "lightkdf",
 		Usage: "Reduce key-derivation RAM & CPU usage at some expense of KDF strength",
 	}
+	ShutdownTimeoutFlag = cli.DurationFlag{
+		Name:  "shutdowntimeout",
+		Usage: "Maximum time allowed for a graceful shutdown before reporting the stuck subsystems",
+		Value: eth.DefaultShutdownTimeout,
+	}
 	// Performance tuning settings
 	CacheFlag = cli.IntFlag{
 		Name:  "cache",
-		Usage: "Megabytes of memory allocated to internal caching (min 16MB / database forced)",
-		Value: 128,
+		Usage: "Megabytes of memory allocated to internal caching (split between database, trie and snapshots)",
+		Value: 256,
+	}
+	CacheDatabaseFlag = cli.IntFlag{
+		Name:  "cache-database",
+		Usage: "Percentage of the cache allowance dedicated to the database block cache",
+		Value: eth.DefaultCacheRatios.Database,
+	}
+	CacheTrieFlag = cli.IntFlag{
+		Name:  "cache-trie",
+		Usage: "Percentage of the cache allowance dedicated to trie node caching",
+		Value: eth.DefaultCacheRatios.Trie,
+	}
+	CacheSnapshotFlag = cli.IntFlag{
+		Name:  "cache-snapshot",
+		Usage: "Percentage of the cache allowance dedicated to recent state snapshots",
+		Value: eth.DefaultCacheRatios.Snapshot,
 	}
 	TrieCacheGenFlag = cli.IntFlag{
 		Name:  "trie-cache-gens",
 		Usage: "Number of trie node generations to keep in memory",
-		Value: int(state.MaxTrieCacheGen),
+		Value: int(state.DefaultTrieCacheGen),
+	}
+	TrieFlushFlag = cli.IntFlag{
+		Name:  "trie-flush-interval",
+		Usage: "Number of blocks to keep state tries in memory before flushing, garbage collecting the intermediate ones (0 = archive mode)",
+		Value: 0,
+	}
+	TxLookupLimitFlag = cli.IntFlag{
+		Name:  "txlookuplimit",
+		Usage: "Number of recent blocks to maintain the transaction hash lookup index for (0 = entire chain)",
+		Value: 0,
 	}
 	// Miner settings
 	MiningEnabledFlag = cli.BoolFlag{
@@ -193,6 +233,10 @@ var (
 		Usage: "Public address for block mining rewards (default = first account created)",
 		Value: "0",
 	}
+	RotatingEtherbasesFlag = cli.StringFlag{
+		Name:  "rotatingetherbases",
+		Usage: "Comma separated list of address:weight pairs to rotate the etherbase of the mined blocks among",
+	}
 	GasPriceFlag = cli.StringFlag{
 		Name:  "gasprice",
 		Usage: "Minimal gas price to accept for mining a transactions",
@@ -231,6 +275,14 @@ var (
 		Name:  "vmdebug",
 		Usage: "Record information useful for VM and contract debugging",
 	}
+	VMParallelFlag = cli.BoolFlag{
+		Name:  "parallelexec",
+		Usage: "Execute block transactions speculatively in parallel (experimental)",
+	}
+	TriePreimagesFlag = cli.BoolFlag{
+		Name:  "triepreimages",
+		Usage: "Record the preimages of all accessed state trie keys (for debugging and state dumps)",
+	}
 	// Logging and debug settings
 	EthStatsURLFlag = cli.StringFlag{
 		Name:  "ethstats",
@@ -240,6 +292,19 @@ var (
 		Name:  metrics.MetricsEnabledFlag,
 		Usage: "Enable metrics collection and reporting",
 	}
+	MetricsInfluxDBFlag = cli.StringFlag{
+		Name:  "metricsinfluxdb",
+		Usage: "InfluxDB write endpoint to push metrics to (e.g. http://localhost:8086/write?db=ged)",
+	}
+	MetricsGraphiteFlag = cli.StringFlag{
+		Name:  "metricsgraphite",
+		Usage: "Graphite (carbon plaintext) address to push metrics to (e.g. localhost:2003)",
+	}
+	MetricsIntervalFlag = cli.DurationFlag{
+		Name:  "metricsinterval",
+		Usage: "Interval between pushes to the metrics reporters",
+		Value: 10 * time.Second,
+	}
 	FakePoWFlag = cli.BoolFlag{
 		Name:  "fakepow",
 		Usage: "Disables proof-of-work verification",
@@ -276,8 +341,8 @@ var (
 	}
 	IPCApiFlag = cli.StringFlag{
 		Name:  "ipcapi",
-		Usage: "APIs offered over the IPC-RPC interface",
-		Value: rpc.DefaultIPCApis,
+		Usage: "APIs offered over the IPC-RPC interface (default = all)",
+		Value: "",
 	}
 	IPCPathFlag = DirectoryFlag{
 		Name:  "ipcpath",
@@ -308,6 +373,78 @@ var (
 		Usage: "Origins from which to accept websockets requests",
 		Value: "",
 	}
+	ReadyMaxHeadAgeFlag = cli.DurationFlag{
+		Name:  "readymaxage",
+		Usage: "Maximum head block age for the /ready HTTP probe to succeed (0 = no limit)",
+	}
+	LogsMaxRangeFlag = cli.IntFlag{
+		Name:  "logsmaxrange",
+		Usage: "Maximum number of blocks a single log query may span (0 = no limit)",
+		Value: int(filters.DefaultConfig.MaxBlockRange),
+	}
+	LogsMaxResultsFlag = cli.IntFlag{
+		Name:  "logsmaxresults",
+		Usage: "Maximum number of logs returned by a single log query (0 = no limit)",
+		Value: filters.DefaultConfig.MaxLogs,
+	}
+	FilterTimeoutFlag = cli.DurationFlag{
+		Name:  "filtertimeout",
+		Usage: "Inactivity period after which installed filters are removed if not polled",
+		Value: filters.DefaultConfig.Timeout,
+	}
+	RPCRateLimitFlag = cli.Float64Flag{
+		Name:  "rpcratelimit",
+		Usage: "Calls per second a single client may make over HTTP-RPC and WS-RPC (0 = unlimited)",
+	}
+	RPCRateBurstFlag = cli.IntFlag{
+		Name:  "rpcrateburst",
+		Usage: "Calls a single client may burst over HTTP-RPC and WS-RPC (0 = one second worth)",
+	}
+	RPCQuotaFlag = cli.StringFlag{
+		Name:  "rpcquota",
+		Usage: "Comma separated per client rate limits as <ip or API key>=<rate>[:<burst>] (0 rate = unlimited)",
+	}
+	RPCBatchLimitFlag = cli.IntFlag{
+		Name:  "rpcbatchlimit",
+		Usage: "Maximum number of requests in a HTTP-RPC or WS-RPC batch (0 = unlimited)",
+		Value: node.DefaultRPCBatchLimit,
+	}
+	RPCResponseSizeLimitFlag = cli.IntFlag{
+		Name:  "rpcresponselimit",
+		Usage: "Maximum size in bytes of a HTTP-RPC or WS-RPC (batch) response (0 = unlimited)",
+		Value: node.DefaultRPCResponseSizeLimit,
+	}
+	RPCConcurrencyLimitFlag = cli.IntFlag{
+		Name:  "rpcconcurrency",
+		Usage: "Maximum number of concurrently executed HTTP-RPC and WS-RPC requests each (0 = unlimited)",
+	}
+	AuthRPCEnabledFlag = cli.BoolFlag{
+		Name:  "authrpc",
+		Usage: "Enable the JWT authenticated HTTP-RPC server",
+	}
+	AuthRPCListenAddrFlag = cli.StringFlag{
+		Name:  "authrpcaddr",
+		Usage: "Authenticated HTTP-RPC server listening interface",
+		Value: node.DefaultAuthHost,
+	}
+	AuthRPCPortFlag = cli.IntFlag{
+		Name:  "authrpcport",
+		Usage: "Authenticated HTTP-RPC server listening port",
+		Value: node.DefaultAuthPort,
+	}
+	AuthRPCApiFlag = cli.StringFlag{
+		Name:  "authrpcapi",
+		Usage: "APIs offered over the authenticated HTTP-RPC interface (default = all)",
+		Value: "",
+	}
+	JWTSecretFlag = cli.StringFlag{
+		Name:  "jwtsecret",
+		Usage: "File containing the hex encoded JWT secret of the authenticated HTTP-RPC server (created if missing)",
+	}
+	RPCAuditLogFlag = cli.StringFlag{
+		Name:  "auditlog",
+		Usage: "File in which to record signing, unlock, transaction and admin RPC calls",
+	}
 	ExecFlag = cli.StringFlag{
 		Name:  "exec",
 		Usage: "Execute JavaScript statement (only in combination with console/attach)",
@@ -328,6 +465,26 @@ var (
 		Usage: "Maximum number of pending connection attempts (defaults used if set to 0)",
 		Value: 0,
 	}
+	MaxMessageSizeFlag = cli.IntFlag{
+		Name:  "maxmsgsize",
+		Usage: "Maximum size in bytes of a single message accepted from a peer (defaults used if set to 0)",
+		Value: 0,
+	}
+	MaxMessageRateFlag = cli.IntFlag{
+		Name:  "maxmsgrate",
+		Usage: "Maximum average number of messages per second accepted from a peer (defaults used if set to 0)",
+		Value: 0,
+	}
+	MaxDownloadFlag = cli.IntFlag{
+		Name:  "maxdownload",
+		Usage: "Maximum chain data download bandwidth in KB/s (unlimited if set to 0)",
+		Value: 0,
+	}
+	MaxUploadFlag = cli.IntFlag{
+		Name:  "maxupload",
+		Usage: "Maximum chain data serving bandwidth in KB/s (unlimited if set to 0)",
+		Value: 0,
+	}
 	ListenPortFlag = cli.IntFlag{
 		Name:  "port",
 		Usage: "Network listening port",
@@ -338,6 +495,10 @@ var (
 		Usage: "Comma separated enode URLs for P2P discovery bootstrap",
 		Value: "",
 	}
+	DNSDiscoveryFlag = cli.StringFlag{
+		Name:  "dnsdiscovery",
+		Usage: "Comma separated DNS node tree URLs (enrtree://<key>@<domain>) for P2P discovery bootstrap",
+	}
 	NodeKeyFileFlag = cli.StringFlag{
 		Name:  "nodekey",
 		Usage: "P2P node key file",
@@ -363,11 +524,41 @@ var (
 		Name:  "netrestrict",
 		Usage: "Restricts network communication to the given IP networks (CIDR masks)",
 	}
+	ProxyFlag = cli.StringFlag{
+		Name:  "proxy",
+		Usage: "Routes outbound peer connections through a SOCKS5 proxy ([user:password@]host:port, e.g. Tor)",
+	}
+	ProxyNoDiscoverFlag = cli.BoolFlag{
+		Name:  "proxynodiscover",
+		Usage: "Disables UDP peer discovery (which is not proxied) when using --proxy",
+	}
 
 	WhisperEnabledFlag = cli.BoolFlag{
 		Name:  "shh",
 		Usage: "Enable Whisper",
 	}
+	WhisperMaxMessageSizeFlag = cli.IntFlag{
+		Name:  "shhmaxmsgsize",
+		Usage: "Maximum size of the Whisper messages accepted (bytes)",
+		Value: int(whisper.DefaultMaxMessageSize),
+	}
+	WhisperMinPOWFlag = cli.Float64Flag{
+		Name:  "shhpow",
+		Usage: "Minimum proof of work of the Whisper messages accepted",
+		Value: whisper.DefaultMinimumPoW,
+	}
+	WhisperLightModeFlag = cli.BoolFlag{
+		Name:  "shhlight",
+		Usage: "Run Whisper in light mode (no relaying, only messages matching the local filters, low PoW)",
+	}
+	WhisperMailServerFlag = cli.BoolFlag{
+		Name:  "shhmailserver",
+		Usage: "Archive Whisper messages and deliver them to clients on request",
+	}
+	WhisperMailPasswordFlag = cli.StringFlag{
+		Name:  "shhmailpassword",
+		Usage: "Password file of the Whisper mail server, shared with its clients",
+	}
 
 	// ATM the url is left to the user and deployment to
 	JSpathFlag = cli.StringFlag{
@@ -380,6 +571,18 @@ var (
 		Usage: "Solidity compiler command to be used",
 		Value: "solc",
 	}
+	NoReleaseCheckFlag = cli.BoolFlag{
+		Name:  "noreleasecheck",
+		Usage: "Disables the periodic check for new client releases",
+	}
+	ReleaseManifestFlag = cli.StringFlag{
+		Name:  "releasemanifest",
+		Usage: "URL of a signed release manifest to check for new releases (instead of the release oracle)",
+	}
+	ReleaseSignerFlag = cli.StringFlag{
+		Name:  "releasesigner",
+		Usage: "Address of the key the release manifest must be signed with",
+	}
 
 	// Gas price oracle settings
 	GpoMinGasPriceFlag = cli.StringFlag{
@@ -414,14 +617,37 @@ var (
 	}
 )
 
+// MakeNetwork retrieves the built-in network preset selected by the command line
+// flags, terminating if it's unknown or several networks were requested.
+func MakeNetwork(ctx *cli.Context) *eth.Network {
+	name, selected := ctx.GlobalString(NetworkFlag.Name), 0
+	if ctx.GlobalIsSet(NetworkFlag.Name) {
+		selected++
+	}
+	if ctx.GlobalBool(TestNetFlag.Name) {
+		name, selected = "testnet", selected+1
+	}
+	if ctx.GlobalBool(DevModeFlag.Name) {
+		name, selected = "dev", selected+1
+	}
+	if selected > 1 {
+		Fatalf("The %v flags are mutually exclusive", []cli.Flag{NetworkFlag, TestNetFlag, DevModeFlag})
+	}
+	network, err := eth.LookupNetwork(name)
+	if err != nil {
+		Fatalf("Option %q: %v", NetworkFlag.Name, err)
+	}
+	return network
+}
+
 // MakeDataDir retrieves the currently requested data directory, terminating
-// if none (or the empty string) is specified. If the node is starting a testnet,
-// the a subdirectory of the specified datadir will be used.
+// if none (or the empty string) is specified. If the selected network keeps its
+// data separately, a subdirectory of the specified datadir will be used.
 func MakeDataDir(ctx *cli.Context) string {
 	if path := ctx.GlobalString(DataDirFlag.Name); path != "" {
 		// TODO: choose a different location outside of the regular datadir.
-		if ctx.GlobalBool(TestNetFlag.Name) {
-			return filepath.Join(path, "testnet")
+		if subdir := MakeNetwork(ctx).DataSubdir; subdir != "" {
+			return filepath.Join(path, subdir)
 		}
 		return path
 	}
@@ -481,11 +707,9 @@ func makeNodeUserIdent(ctx *cli.Context) string {
 // MakeBootstrapNodes creates a list of bootstrap nodes from the command line
 // flags, reverting to pre-configured ones if none have been specified.
 func MakeBootstrapNodes(ctx *cli.Context) []*discover.Node {
-	urls := params.MainnetBootnodes
+	urls := MakeNetwork(ctx).Bootnodes
 	if ctx.GlobalIsSet(BootnodesFlag.Name) {
 		urls = strings.Split(ctx.GlobalString(BootnodesFlag.Name), ",")
-	} else if ctx.GlobalBool(TestNetFlag.Name) {
-		urls = params.TestnetBootnodes
 	}
 
 	bootnodes := make([]*discover.Node, 0, len(urls))
@@ -520,6 +744,18 @@ func MakeBootstrapNodesV5(ctx *cli.Context) []*discv5.Node {
 	return bootnodes
 }
 
+// MakeDNSDiscoveryURLs creates the list of DNS node tree URLs from the command
+// line flags.
+func MakeDNSDiscoveryURLs(ctx *cli.Context) []string {
+	var urls []string
+	for _, url := range strings.Split(ctx.GlobalString(DNSDiscoveryFlag.Name), ",") {
+		if url = strings.TrimSpace(url); url != "" {
+			urls = append(urls, url)
+		}
+	}
+	return urls
+}
+
 // MakeListenAddress creates a TCP listening address string from set command
 // line flags.
 func MakeListenAddress(ctx *cli.Context) string {
@@ -551,6 +787,15 @@ func MakeRPCModules(input string) []string {
 	return result
 }
 
+// MakeIPCModules returns the API modules to expose over IPC, or nil if all the
+// available modules should be exposed.
+func MakeIPCModules(ctx *cli.Context) []string {
+	if modules := ctx.GlobalString(IPCApiFlag.Name); modules != "" {
+		return MakeRPCModules(modules)
+	}
+	return nil
+}
+
 // MakeHTTPRpcHost creates the HTTP RPC listener interface string from the set
 // command line flags, returning empty if the HTTP endpoint is disabled.
 func MakeHTTPRpcHost(ctx *cli.Context) string {
@@ -569,6 +814,53 @@ func MakeWSRpcHost(ctx *cli.Context) string {
 	return ctx.GlobalString(WSListenAddrFlag.Name)
 }
 
+// MakeRPCQuotas parses the per client RPC rate limits from the command line flags.
+func MakeRPCQuotas(ctx *cli.Context) map[string]rpc.RateQuota {
+	quotas := make(map[string]rpc.RateQuota)
+	for _, entry := range strings.Split(ctx.GlobalString(RPCQuotaFlag.Name), ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			Fatalf("Option %s: invalid quota %q", RPCQuotaFlag.Name, entry)
+		}
+		var (
+			quota rpc.RateQuota
+			err   error
+		)
+		limits := strings.SplitN(parts[1], ":", 2)
+		if quota.Rate, err = strconv.ParseFloat(limits[0], 64); err != nil {
+			Fatalf("Option %s: invalid rate in %q: %v", RPCQuotaFlag.Name, entry, err)
+		}
+		if len(limits) == 2 {
+			if quota.Burst, err = strconv.Atoi(limits[1]); err != nil {
+				Fatalf("Option %s: invalid burst in %q: %v", RPCQuotaFlag.Name, entry, err)
+			}
+		}
+		quotas[parts[0]] = quota
+	}
+	return quotas
+}
+
+// MakeAuthRpcHost creates the authenticated HTTP RPC listener interface string
+// from the set command line flags, returning empty if the endpoint is disabled.
+func MakeAuthRpcHost(ctx *cli.Context) string {
+	if !ctx.GlobalBool(AuthRPCEnabledFlag.Name) {
+		return ""
+	}
+	return ctx.GlobalString(AuthRPCListenAddrFlag.Name)
+}
+
+// MakeAuthModules returns the API modules to expose over the authenticated HTTP
+// RPC endpoint, or nil if all the available modules should be exposed.
+func MakeAuthModules(ctx *cli.Context) []string {
+	if modules := ctx.GlobalString(AuthRPCApiFlag.Name); modules != "" {
+		return MakeRPCModules(modules)
+	}
+	return nil
+}
+
 // MakeDatabaseHandles raises out the number of allowed file handles per process
 // for Geth and returns half of the allowance to assign to the database.
 func MakeDatabaseHandles() int {
@@ -585,6 +877,16 @@ func MakeDatabaseHandles() int {
 	return limit / 2 // Leave half for networking and other stuff
 }
 
+// MakeCacheRatios creates the distribution of the cache budget from the
+// command line flags.
+func MakeCacheRatios(ctx *cli.Context) eth.CacheRatios {
+	return eth.CacheRatios{
+		Database: ctx.GlobalInt(CacheDatabaseFlag.Name),
+		Trie:     ctx.GlobalInt(CacheTrieFlag.Name),
+		Snapshot: ctx.GlobalInt(CacheSnapshotFlag.Name),
+	}
+}
+
 // MakeAddress converts an account specified directly as a hex encoded string or
 // a key index in the key store to an internal account representation.
 func MakeAddress(accman *accounts.Manager, account string) (accounts.Account, error) {
@@ -620,6 +922,32 @@ func MakeEtherbase(accman *accounts.Manager, ctx *cli.Context) common.Address {
 	return account.Address
 }
 
+// MakeRotatingEtherbases retrieves the weighted etherbases to rotate the mined
+// blocks among from the command line flags, where addresses may also be account
+// indexes.
+func MakeRotatingEtherbases(accman *accounts.Manager, ctx *cli.Context) []miner.Payout {
+	if !ctx.GlobalIsSet(RotatingEtherbasesFlag.Name) {
+		return nil
+	}
+	var payouts []miner.Payout
+	for _, entry := range strings.Split(ctx.GlobalString(RotatingEtherbasesFlag.Name), ",") {
+		parts := strings.Split(strings.TrimSpace(entry), ":")
+		if len(parts) != 2 {
+			Fatalf("Option %q: invalid entry %q, want address:weight", RotatingEtherbasesFlag.Name, entry)
+		}
+		account, err := MakeAddress(accman, parts[0])
+		if err != nil {
+			Fatalf("Option %q: %v", RotatingEtherbasesFlag.Name, err)
+		}
+		weight, err := strconv.ParseUint(parts[1], 10, 64)
+		if err != nil || weight == 0 {
+			Fatalf("Option %q: invalid weight %q", RotatingEtherbasesFlag.Name, parts[1])
+		}
+		payouts = append(payouts, miner.Payout{Address: account.Address, Weight: weight})
+	}
+	return payouts
+}
+
 // MakeMinerExtra resolves extradata for the miner from the set command line flags
 // or returns a default one composed on the client, runtime and OS metadata.
 func MakeMinerExtra(extra []byte, ctx *cli.Context) []byte {
@@ -649,43 +977,67 @@ func MakePasswordList(ctx *cli.Context) []string {
 
 // MakeNode configures a node with no services from command line flags.
 func MakeNode(ctx *cli.Context, name, gitCommit string) *node.Node {
+	stack, err := node.New(MakeNodeConfig(ctx, name, gitCommit))
+	if err != nil {
+		Fatalf("Failed to create the protocol stack: %v", err)
+	}
+	return stack
+}
+
+// MakeNodeConfig assembles the configuration of a node from command line flags.
+func MakeNodeConfig(ctx *cli.Context, name, gitCommit string) *node.Config {
 	vsn := params.Version
 	if gitCommit != "" {
 		vsn += "-" + gitCommit[:8]
 	}
 
 	config := &node.Config{
-		DataDir:           MakeDataDir(ctx),
-		KeyStoreDir:       ctx.GlobalString(KeyStoreDirFlag.Name),
-		UseLightweightKDF: ctx.GlobalBool(LightKDFFlag.Name),
-		PrivateKey:        MakeNodeKey(ctx),
-		Name:              name,
-		Version:           vsn,
-		UserIdent:         makeNodeUserIdent(ctx),
-		NoDiscovery:       ctx.GlobalBool(NoDiscoverFlag.Name) || ctx.GlobalBool(LightModeFlag.Name),
-		DiscoveryV5:       ctx.GlobalBool(DiscoveryV5Flag.Name) || ctx.GlobalBool(LightModeFlag.Name) || ctx.GlobalInt(LightServFlag.Name) > 0,
-		DiscoveryV5Addr:   MakeDiscoveryV5Address(ctx),
-		BootstrapNodes:    MakeBootstrapNodes(ctx),
-		BootstrapNodesV5:  MakeBootstrapNodesV5(ctx),
-		ListenAddr:        MakeListenAddress(ctx),
-		NAT:               MakeNAT(ctx),
-		MaxPeers:          ctx.GlobalInt(MaxPeersFlag.Name),
-		MaxPendingPeers:   ctx.GlobalInt(MaxPendingPeersFlag.Name),
-		IPCPath:           MakeIPCPath(ctx),
-		HTTPHost:          MakeHTTPRpcHost(ctx),
-		HTTPPort:          ctx.GlobalInt(RPCPortFlag.Name),
-		HTTPCors:          ctx.GlobalString(RPCCORSDomainFlag.Name),
-		HTTPModules:       MakeRPCModules(ctx.GlobalString(RPCApiFlag.Name)),
-		WSHost:            MakeWSRpcHost(ctx),
-		WSPort:            ctx.GlobalInt(WSPortFlag.Name),
-		WSOrigins:         ctx.GlobalString(WSAllowedOriginsFlag.Name),
-		WSModules:         MakeRPCModules(ctx.GlobalString(WSApiFlag.Name)),
-	}
-	if ctx.GlobalBool(DevModeFlag.Name) {
+		DataDir:              MakeDataDir(ctx),
+		KeyStoreDir:          ctx.GlobalString(KeyStoreDirFlag.Name),
+		UseLightweightKDF:    ctx.GlobalBool(LightKDFFlag.Name),
+		PrivateKey:           MakeNodeKey(ctx),
+		Name:                 name,
+		Version:              vsn,
+		UserIdent:            makeNodeUserIdent(ctx),
+		NoDiscovery:          ctx.GlobalBool(NoDiscoverFlag.Name) || ctx.GlobalBool(LightModeFlag.Name),
+		DiscoveryV5:          ctx.GlobalBool(DiscoveryV5Flag.Name) || ctx.GlobalBool(LightModeFlag.Name) || ctx.GlobalInt(LightServFlag.Name) > 0,
+		DiscoveryV5Addr:      MakeDiscoveryV5Address(ctx),
+		BootstrapNodes:       MakeBootstrapNodes(ctx),
+		BootstrapNodesV5:     MakeBootstrapNodesV5(ctx),
+		DNSDiscovery:         MakeDNSDiscoveryURLs(ctx),
+		ListenAddr:           MakeListenAddress(ctx),
+		NAT:                  MakeNAT(ctx),
+		MaxPeers:             ctx.GlobalInt(MaxPeersFlag.Name),
+		MaxPendingPeers:      ctx.GlobalInt(MaxPendingPeersFlag.Name),
+		MaxMessageSize:       uint32(ctx.GlobalInt(MaxMessageSizeFlag.Name)),
+		MaxMessageRate:       ctx.GlobalInt(MaxMessageRateFlag.Name),
+		IPCPath:              MakeIPCPath(ctx),
+		IPCModules:           MakeIPCModules(ctx),
+		HTTPHost:             MakeHTTPRpcHost(ctx),
+		HTTPPort:             ctx.GlobalInt(RPCPortFlag.Name),
+		HTTPCors:             ctx.GlobalString(RPCCORSDomainFlag.Name),
+		HTTPModules:          MakeRPCModules(ctx.GlobalString(RPCApiFlag.Name)),
+		WSHost:               MakeWSRpcHost(ctx),
+		WSPort:               ctx.GlobalInt(WSPortFlag.Name),
+		WSOrigins:            ctx.GlobalString(WSAllowedOriginsFlag.Name),
+		WSModules:            MakeRPCModules(ctx.GlobalString(WSApiFlag.Name)),
+		RPCRateLimit:         ctx.GlobalFloat64(RPCRateLimitFlag.Name),
+		RPCRateBurst:         ctx.GlobalInt(RPCRateBurstFlag.Name),
+		RPCQuotas:            MakeRPCQuotas(ctx),
+		RPCBatchLimit:        ctx.GlobalInt(RPCBatchLimitFlag.Name),
+		RPCResponseSizeLimit: ctx.GlobalInt(RPCResponseSizeLimitFlag.Name),
+		RPCConcurrencyLimit:  ctx.GlobalInt(RPCConcurrencyLimitFlag.Name),
+		AuthHost:             MakeAuthRpcHost(ctx),
+		AuthPort:             ctx.GlobalInt(AuthRPCPortFlag.Name),
+		AuthModules:          MakeAuthModules(ctx),
+		JWTSecret:            ctx.GlobalString(JWTSecretFlag.Name),
+		AuditLog:             ctx.GlobalString(RPCAuditLogFlag.Name),
+	}
+	if network := MakeNetwork(ctx); network.Ephemeral {
 		if !ctx.GlobalIsSet(DataDirFlag.Name) {
-			config.DataDir = filepath.Join(os.TempDir(), "/ethereum_dev_mode")
+			config.DataDir = filepath.Join(os.TempDir(), "/ethereum_"+network.Name+"_mode")
 		}
-		// --dev mode does not need p2p networking.
+		// Ephemeral networks do not need p2p networking.
 		config.MaxPeers = 0
 		config.ListenAddr = ":0"
 	}
@@ -696,39 +1048,72 @@ func MakeNode(ctx *cli.Context, name, gitCommit string) *node.Node {
 		}
 		config.NetRestrict = list
 	}
-
-	stack, err := node.New(config)
-	if err != nil {
-		Fatalf("Failed to create the protocol stack: %v", err)
+	if proxy := ctx.GlobalString(ProxyFlag.Name); proxy != "" {
+		dialer, err := netutil.ParseSOCKS5(proxy)
+		if err != nil {
+			Fatalf("Option %q: %v", ProxyFlag.Name, err)
+		}
+		config.Proxy = dialer
+		if ctx.GlobalBool(ProxyNoDiscoverFlag.Name) {
+			config.NoDiscovery, config.DiscoveryV5 = true, false
+		}
 	}
-	return stack
+	return config
 }
 
 // RegisterEthService configures eth.Ethereum from command line flags and adds it to the
 // given node.
 func RegisterEthService(ctx *cli.Context, stack *node.Node, extra []byte) {
-	// Avoid conflicting network flags
-	networks, netFlags := 0, []cli.BoolFlag{DevModeFlag, TestNetFlag}
-	for _, flag := range netFlags {
-		if ctx.GlobalBool(flag.Name) {
-			networks++
+	ethConf := MakeEthConfig(ctx, stack, extra)
+
+	if ethConf.LightMode {
+		if err := stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
+			return les.New(ctx, ethConf)
+		}); err != nil {
+			Fatalf("Failed to register the Ethereum light node service: %v", err)
+		}
+	} else {
+		if err := stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
+			return eth.New(ctx, ethConf)
+		}); err != nil {
+			Fatalf("Failed to register the Ethereum full node service: %v", err)
+		}
+		if ethConf.LightServ > 0 {
+			if err := stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
+				var fullNode *eth.Ethereum
+				if err := ctx.Service(&fullNode); err != nil {
+					return nil, err
+				}
+				return les.NewLesServer(fullNode, ethConf)
+			}); err != nil {
+				Fatalf("Failed to register the Ethereum light server service: %v", err)
+			}
 		}
 	}
-	if networks > 1 {
-		Fatalf("The %v flags are mutually exclusive", netFlags)
-	}
+}
+
+// MakeEthConfig assembles the configuration of the Ethereum service from command
+// line flags. The chain database of the node is consulted for the chain config.
+func MakeEthConfig(ctx *cli.Context, stack *node.Node, extra []byte) *eth.Config {
+	network := MakeNetwork(ctx)
 
 	ethConf := &eth.Config{
+		Network:                 network.Name,
 		Etherbase:               MakeEtherbase(stack.AccountManager(), ctx),
+		RotatingEtherbases:      MakeRotatingEtherbases(stack.AccountManager(), ctx),
 		ChainConfig:             MakeChainConfig(ctx, stack),
 		FastSync:                ctx.GlobalBool(FastSyncFlag.Name),
 		LightMode:               ctx.GlobalBool(LightModeFlag.Name),
 		LightServ:               ctx.GlobalInt(LightServFlag.Name),
 		LightPeers:              ctx.GlobalInt(LightPeersFlag.Name),
 		MaxPeers:                ctx.GlobalInt(MaxPeersFlag.Name),
-		DatabaseCache:           ctx.GlobalInt(CacheFlag.Name),
+		MaxDownloadRate:         ctx.GlobalInt(MaxDownloadFlag.Name) * 1024,
+		MaxUploadRate:           ctx.GlobalInt(MaxUploadFlag.Name) * 1024,
+		CacheSize:               ctx.GlobalInt(CacheFlag.Name),
+		CacheRatios:             MakeCacheRatios(ctx),
+		StateFlushInterval:      uint64(ctx.GlobalInt(TrieFlushFlag.Name)),
+		TxLookupLimit:           uint64(ctx.GlobalInt(TxLookupLimitFlag.Name)),
 		DatabaseHandles:         MakeDatabaseHandles(),
-		NetworkId:               ctx.GlobalInt(NetworkIdFlag.Name),
 		MinerThreads:            ctx.GlobalInt(MinerThreadsFlag.Name),
 		ExtraData:               MakeMinerExtra(extra, ctx),
 		DocRoot:                 ctx.GlobalString(DocRootFlag.Name),
@@ -742,52 +1127,92 @@ func RegisterEthService(ctx *cli.Context, stack *node.Node, extra []byte) {
 		SolcPath:                ctx.GlobalString(SolcPathFlag.Name),
 		AutoDAG:                 ctx.GlobalBool(AutoDAGFlag.Name) || ctx.GlobalBool(MiningEnabledFlag.Name),
 		EnablePreimageRecording: ctx.GlobalBool(VMEnableDebugFlag.Name),
+		ParallelExecution:       ctx.GlobalBool(VMParallelFlag.Name),
+		TriePreimages:           ctx.GlobalBool(TriePreimagesFlag.Name),
+		ReadyMaxHeadAge:         ctx.GlobalDuration(ReadyMaxHeadAgeFlag.Name),
+		ShutdownTimeout:         ctx.GlobalDuration(ShutdownTimeoutFlag.Name),
+		FilterConfig: filters.Config{
+			MaxBlockRange: uint64(ctx.GlobalInt(LogsMaxRangeFlag.Name)),
+			MaxLogs:       ctx.GlobalInt(LogsMaxResultsFlag.Name),
+			Timeout:       ctx.GlobalDuration(FilterTimeoutFlag.Name),
+		},
 	}
 
-	// Override any default configs in dev mode or the test net
-	switch {
-	case ctx.GlobalBool(TestNetFlag.Name):
-		if !ctx.GlobalIsSet(NetworkIdFlag.Name) {
-			ethConf.NetworkId = 3
-		}
-		ethConf.Genesis = core.DefaultTestnetGenesisBlock()
-
-	case ctx.GlobalBool(DevModeFlag.Name):
-		ethConf.Genesis = core.DevGenesisBlock()
-		if !ctx.GlobalIsSet(GasPriceFlag.Name) {
-			ethConf.GasPrice = new(big.Int)
-		}
-		ethConf.PowTest = true
+	// Leave the network id to the preset unless explicitly requested
+	if ctx.GlobalIsSet(NetworkIdFlag.Name) {
+		ethConf.NetworkId = ctx.GlobalInt(NetworkIdFlag.Name)
+	}
+	// Transactions are free on throwaway networks unless configured otherwise
+	if network.Ephemeral && !ctx.GlobalIsSet(GasPriceFlag.Name) {
+		ethConf.GasPrice = new(big.Int)
 	}
 	// Override any global options pertaining to the Ethereum protocol
-	if gen := ctx.GlobalInt(TrieCacheGenFlag.Name); gen > 0 {
-		state.MaxTrieCacheGen = uint16(gen)
+	if ctx.GlobalIsSet(TrieCacheGenFlag.Name) {
+		ethConf.TrieCacheGens = uint16(ctx.GlobalInt(TrieCacheGenFlag.Name))
 	}
+	return ethConf
+}
 
-	if ethConf.LightMode {
-		if err := stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
-			return les.New(ctx, ethConf)
-		}); err != nil {
-			Fatalf("Failed to register the Ethereum light node service: %v", err)
-		}
-	} else {
-		if err := stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
-			fullNode, err := eth.New(ctx, ethConf)
-			if fullNode != nil && ethConf.LightServ > 0 {
-				ls, _ := les.NewLesServer(fullNode, ethConf)
-				fullNode.AddLesServer(ls)
-			}
-			return fullNode, err
-		}); err != nil {
-			Fatalf("Failed to register the Ethereum full node service: %v", err)
+// RegisterShhService configures Whisper and adds it to the given node.
+func RegisterShhService(ctx *cli.Context, stack *node.Node) {
+	config := &whisper.Config{
+		MaxMessageSize:     uint32(ctx.GlobalInt(WhisperMaxMessageSizeFlag.Name)),
+		MinimumAcceptedPOW: ctx.GlobalFloat64(WhisperMinPOWFlag.Name),
+		LightMode:          ctx.GlobalBool(WhisperLightModeFlag.Name),
+	}
+	if config.MaxMessageSize == 0 || config.MaxMessageSize > whisper.MaxMessageSize {
+		Fatalf("Invalid Whisper message size limit %d (max %d)", config.MaxMessageSize, whisper.MaxMessageSize)
+	}
+	if config.MinimumAcceptedPOW < 0 {
+		Fatalf("Invalid Whisper PoW requirement %v", config.MinimumAcceptedPOW)
+	}
+	if err := stack.Register(func(*node.ServiceContext) (node.Service, error) { return whisper.New(config), nil }); err != nil {
+		Fatalf("Failed to register the Whisper service: %v", err)
+	}
+	if ctx.GlobalBool(WhisperMailServerFlag.Name) {
+		if config.LightMode {
+			Fatalf("Whisper mail server cannot run in light mode")
 		}
+		registerMailServer(ctx, stack, config.MinimumAcceptedPOW)
 	}
 }
 
-// RegisterShhService configures Whisper and adds it to the given node.
-func RegisterShhService(stack *node.Node) {
-	if err := stack.Register(func(*node.ServiceContext) (node.Service, error) { return whisper.New(), nil }); err != nil {
-		Fatalf("Failed to register the Whisper service: %v", err)
+// registerMailServer configures the Whisper mail server and adds it to the given
+// node. The envelopes are archived in a dedicated database in the data directory.
+func registerMailServer(ctx *cli.Context, stack *node.Node, pow float64) {
+	path := ctx.GlobalString(WhisperMailPasswordFlag.Name)
+	if path == "" {
+		Fatalf("Whisper mail server requires a password file (--%s)", WhisperMailPasswordFlag.Name)
+	}
+	text, err := ioutil.ReadFile(path)
+	if err != nil {
+		Fatalf("Failed to read Whisper mail server password file: %v", err)
+	}
+	password := strings.TrimRight(strings.Split(string(text), "\n")[0], "\r")
+
+	if err := stack.Register(func(sctx *node.ServiceContext) (node.Service, error) {
+		var shh *whisper.Whisper
+		if err := sctx.Service(&shh); err != nil {
+			return nil, err
+		}
+		db, err := sctx.OpenDatabase("shhmail", 16, 16)
+		if err != nil {
+			return nil, err
+		}
+		ldb, ok := db.(*ethdb.LDBDatabase)
+		if !ok {
+			db.Close()
+			return nil, errors.New("whisper mail server requires a data directory")
+		}
+		server, err := mailserver.New(shh, ldb, password, pow)
+		if err != nil {
+			ldb.Close()
+			return nil, err
+		}
+		shh.RegisterServer(server)
+		return server, nil
+	}); err != nil {
+		Fatalf("Failed to register the Whisper mail server: %v", err)
 	}
 }
 
@@ -808,6 +1233,40 @@ func RegisterEthStatsService(stack *node.Node, url string) {
 	}
 }
 
+// StartMetricsReporters launches the InfluxDB and Graphite push reporters if
+// they were requested on the command line, tagging all series with the node
+// name and the network id.
+func StartMetricsReporters(ctx *cli.Context, name string) {
+	influx, graphite := ctx.GlobalString(MetricsInfluxDBFlag.Name), ctx.GlobalString(MetricsGraphiteFlag.Name)
+	if influx == "" && graphite == "" {
+		return
+	}
+	if !metrics.Enabled {
+		glog.V(logger.Warn).Infof("Metrics reporting requested without --%s, nothing will be reported", MetricsEnabledFlag.Name)
+		return
+	}
+	if identity := makeNodeUserIdent(ctx); identity != "" {
+		name += "/" + identity
+	}
+	networkId := MakeNetwork(ctx).NetworkId
+	if ctx.GlobalIsSet(NetworkIdFlag.Name) {
+		networkId = ctx.GlobalInt(NetworkIdFlag.Name)
+	}
+	tags := map[string]string{"node": name, "network": strconv.Itoa(networkId)}
+	interval := ctx.GlobalDuration(MetricsIntervalFlag.Name)
+	if interval <= 0 {
+		Fatalf("Option %q: interval must be positive, have %v", MetricsIntervalFlag.Name, interval)
+	}
+	if influx != "" {
+		glog.V(logger.Info).Infof("Reporting metrics to InfluxDB at %s every %v", influx, interval)
+		go metrics.ReportInfluxDB(influx, interval, tags)
+	}
+	if graphite != "" {
+		glog.V(logger.Info).Infof("Reporting metrics to Graphite at %s every %v", graphite, interval)
+		go metrics.ReportGraphite(graphite, interval, "ged", tags)
+	}
+}
+
 // SetupNetwork configures the system for either the main net or some test network.
 func SetupNetwork(ctx *cli.Context) {
 	params.TargetGasLimit = common.String2Big(ctx.GlobalString(TargetGasLimitFlag.Name))
@@ -843,31 +1302,15 @@ func MakeChainConfigFromDb(ctx *cli.Context, db ethdb.Database) *params.ChainCon
 		config.ChainId = new(big.Int)
 	}
 	// Check whether we are allowed to set default config params or not:
-	//  - If no genesis is set, we're running either mainnet or testnet (private nets use `geth init`)
-	//  - If a genesis is already set, ensure we have a configuration for it (mainnet or testnet)
+	//  - If no genesis is set, we're running a built-in network (private nets use `geth init`)
+	//  - If a genesis is already set, ensure it's the one of the selected network
+	network := MakeNetwork(ctx)
 	defaults := genesis == nil ||
-		(genesis.Hash() == params.MainNetGenesisHash && !ctx.GlobalBool(TestNetFlag.Name)) ||
-		(genesis.Hash() == params.TestNetGenesisHash && ctx.GlobalBool(TestNetFlag.Name))
+		(network.GenesisHash != (common.Hash{}) && genesis.Hash() == network.GenesisHash)
 
 	if defaults {
-		if ctx.GlobalBool(TestNetFlag.Name) {
-			config = params.TestnetChainConfig
-		} else {
-			// Homestead fork
-			config.HomesteadBlock = params.MainNetHomesteadBlock
-			// DAO fork
-			config.DAOForkBlock = params.MainNetDAOForkBlock
-			config.DAOForkSupport = true
-
-			// DoS reprice fork
-			config.EIP150Block = params.MainNetHomesteadGasRepriceBlock
-			config.EIP150Hash = params.MainNetHomesteadGasRepriceHash
-
-			// DoS state cleanup fork
-			config.EIP155Block = params.MainNetSpuriousDragon
-			config.EIP158Block = params.MainNetSpuriousDragon
-			config.ChainId = params.MainNetChainID
-		}
+		config = new(params.ChainConfig)
+		*config = *network.ChainConfig
 	}
 	return config
 }
@@ -883,7 +1326,7 @@ func ChainDbName(ctx *cli.Context) string {
 // MakeChainDatabase open an LevelDB using the flags passed to the client and will hard crash if it fails.
 func MakeChainDatabase(ctx *cli.Context, stack *node.Node) ethdb.Database {
 	var (
-		cache   = ctx.GlobalInt(CacheFlag.Name)
+		cache   = MakeCacheRatios(ctx).Split(ctx.GlobalInt(CacheFlag.Name)).Database
 		handles = MakeDatabaseHandles()
 		name    = ChainDbName(ctx)
 	)
@@ -900,9 +1343,8 @@ func MakeChain(ctx *cli.Context, stack *node.Node) (chain *core.BlockChain, chai
 	var err error
 	chainDb = MakeChainDatabase(ctx, stack)
 
-	if ctx.GlobalBool(TestNetFlag.Name) {
-		_, err := core.WriteTestNetGenesisBlock(chainDb)
-		if err != nil {
+	if genesis := MakeNetwork(ctx).Genesis; genesis != "" {
+		if _, err := core.WriteGenesisBlock(chainDb, strings.NewReader(genesis)); err != nil {
 			glog.Fatalln(err)
 		}
 	}
@@ -913,10 +1355,13 @@ func MakeChain(ctx *cli.Context, stack *node.Node) (chain *core.BlockChain, chai
 	if !ctx.GlobalBool(FakePoWFlag.Name) {
 		pow = ethash.New()
 	}
-	chain, err = core.NewBlockChain(chainDb, chainConfig, pow, new(event.TypeMux), vm.Config{EnablePreimageRecording: ctx.GlobalBool(VMEnableDebugFlag.Name)})
+	chain, err = core.NewBlockChain(chainDb, chainConfig, pow, new(event.TypeMux), vm.Config{EnablePreimageRecording: ctx.GlobalBool(VMEnableDebugFlag.Name), ParallelExecution: ctx.GlobalBool(VMParallelFlag.Name)})
 	if err != nil {
 		Fatalf("Could not start chainmanager: %v", err)
 	}
+	if ctx.GlobalBool(TriePreimagesFlag.Name) {
+		chain.EnableTriePreimages()
+	}
 	return chain, chainDb
 }
 
@@ -0,0 +1,376 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	bigIntType        = reflect.TypeOf(big.Int{})
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// EncodeTOML writes the TOML representation of a struct (or pointer to one) to
+// the writer. Keys are named after the json tags of the fields so that the TOML
+// and JSON forms of a value match. Types implementing encoding.TextMarshaler are
+// written as strings, types implementing json.Marshaler as the string, number or
+// boolean they marshal into, big integers as numbers and nil values are omitted,
+// TOML having no notion of null.
+func EncodeTOML(w io.Writer, v interface{}) error {
+	val := reflect.ValueOf(v)
+	for val.Kind() == reflect.Ptr || val.Kind() == reflect.Interface {
+		val = val.Elem()
+	}
+	if val.Kind() != reflect.Struct {
+		return errors.New("toml: top level value must be a struct")
+	}
+	enc := &tomlEncoder{w: bufio.NewWriter(w)}
+	if err := enc.table(nil, val); err != nil {
+		return err
+	}
+	return enc.w.Flush()
+}
+
+// tomlEncoder writes a TOML document, tracking whether anything was emitted yet
+// to separate the tables by empty lines.
+type tomlEncoder struct {
+	w       *bufio.Writer
+	written bool
+}
+
+// tomlField is a single key/value pair of a table.
+type tomlField struct {
+	key string
+	val reflect.Value
+}
+
+// table writes the scalar keys of a struct or map first, followed by all the
+// nested tables and arrays of tables, as keys after a table header belong to
+// that table.
+func (enc *tomlEncoder) table(path []string, v reflect.Value) error {
+	fields, err := tomlFields(v)
+	if err != nil {
+		return err
+	}
+	for _, field := range fields {
+		if isTOMLTable(field.val.Type()) || isTOMLTableArray(field.val.Type()) {
+			continue
+		}
+		value, err := tomlValue(field.val)
+		if err != nil {
+			return fmt.Errorf("toml: key %q: %v", strings.Join(append(path, field.key), "."), err)
+		}
+		fmt.Fprintf(enc.w, "%s = %s\n", tomlKey(field.key), value)
+		enc.written = true
+	}
+	for _, field := range fields {
+		sub := append(append([]string{}, path...), field.key)
+		switch {
+		case isTOMLTable(field.val.Type()):
+			enc.header("[" + tomlPath(sub) + "]")
+			if err := enc.table(sub, field.val); err != nil {
+				return err
+			}
+		case isTOMLTableArray(field.val.Type()):
+			for i := 0; i < field.val.Len(); i++ {
+				elem := indirect(field.val.Index(i))
+				if !elem.IsValid() {
+					return fmt.Errorf("toml: key %q: nil array element", tomlPath(sub))
+				}
+				enc.header("[[" + tomlPath(sub) + "]]")
+				if err := enc.table(sub, elem); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// header starts a new table, separating it from any preceding content.
+func (enc *tomlEncoder) header(header string) {
+	if enc.written {
+		enc.w.WriteString("\n")
+	}
+	enc.w.WriteString(header + "\n")
+	enc.written = true
+}
+
+// tomlFields returns the keys of a struct or string keyed map to write, with
+// all pointers dereferenced and nil values dropped. Map keys are sorted.
+func tomlFields(v reflect.Value) ([]tomlField, error) {
+	var fields []tomlField
+	switch v.Kind() {
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if field.PkgPath != "" {
+				continue
+			}
+			name, opts := field.Name, ""
+			if tag := field.Tag.Get("json"); tag != "" {
+				if tag == "-" {
+					continue
+				}
+				if idx := strings.Index(tag, ","); idx >= 0 {
+					tag, opts = tag[:idx], tag[idx:]
+				}
+				if tag != "" {
+					name = tag
+				}
+			}
+			value := v.Field(i)
+			if strings.Contains(opts, ",omitempty") && isEmptyValue(value) {
+				continue
+			}
+			if value = indirect(value); value.IsValid() {
+				fields = append(fields, tomlField{name, value})
+			}
+		}
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("toml: unsupported map key type %v", v.Type().Key())
+		}
+		keys := make([]string, 0, v.Len())
+		for _, key := range v.MapKeys() {
+			keys = append(keys, key.String())
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if value := indirect(v.MapIndex(reflect.ValueOf(key).Convert(v.Type().Key()))); value.IsValid() {
+				fields = append(fields, tomlField{key, value})
+			}
+		}
+	default:
+		return nil, fmt.Errorf("toml: unsupported table type %v", v.Type())
+	}
+	return fields, nil
+}
+
+// tomlValue formats a scalar value or an array of scalars.
+func tomlValue(v reflect.Value) (string, error) {
+	if v.Type() == bigIntType {
+		if !v.CanAddr() {
+			copied := reflect.New(bigIntType).Elem()
+			copied.Set(v)
+			v = copied
+		}
+		n := v.Addr().Interface().(*big.Int)
+		if !n.IsInt64() {
+			return tomlString(n.String()), nil
+		}
+		return n.String(), nil
+	}
+	if m, ok := marshaler(v, textMarshalerType); ok {
+		text, err := m.(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return "", err
+		}
+		return tomlString(string(text)), nil
+	}
+	if m, ok := marshaler(v, jsonMarshalerType); ok {
+		blob, err := m.(json.Marshaler).MarshalJSON()
+		if err != nil {
+			return "", err
+		}
+		var value interface{}
+		if err := json.Unmarshal(blob, &value); err != nil {
+			return "", err
+		}
+		switch value := value.(type) {
+		case string:
+			return tomlString(value), nil
+		case bool, float64:
+			return string(blob), nil
+		}
+		return "", fmt.Errorf("unsupported JSON value %s", blob)
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		f := strconv.FormatFloat(v.Float(), 'f', -1, 64)
+		if !strings.ContainsAny(f, ".eEnN") {
+			f += ".0"
+		}
+		return f, nil
+	case reflect.String:
+		return tomlString(v.String()), nil
+	case reflect.Slice, reflect.Array:
+		items := make([]string, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			elem := indirect(v.Index(i))
+			if !elem.IsValid() {
+				return "", errors.New("nil array element")
+			}
+			item, err := tomlValue(elem)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, item)
+		}
+		return "[" + strings.Join(items, ", ") + "]", nil
+	}
+	return "", fmt.Errorf("unsupported type %v", v.Type())
+}
+
+// marshaler returns the value (or its address) as the given marshaler interface
+// if it implements it.
+func marshaler(v reflect.Value, iface reflect.Type) (interface{}, bool) {
+	if v.Type().Implements(iface) {
+		return v.Interface(), true
+	}
+	if v.CanAddr() && v.Addr().Type().Implements(iface) {
+		return v.Addr().Interface(), true
+	}
+	return nil, false
+}
+
+// isTOMLScalar reports whether values of the type marshal themselves into a
+// single value.
+func isTOMLScalar(typ reflect.Type) bool {
+	if typ == bigIntType {
+		return true
+	}
+	for _, iface := range []reflect.Type{textMarshalerType, jsonMarshalerType} {
+		if typ.Implements(iface) || reflect.PtrTo(typ).Implements(iface) {
+			return true
+		}
+	}
+	return false
+}
+
+// isTOMLTable reports whether values of the type are written as tables.
+func isTOMLTable(typ reflect.Type) bool {
+	if isTOMLScalar(typ) {
+		return false
+	}
+	return typ.Kind() == reflect.Struct || typ.Kind() == reflect.Map
+}
+
+// isTOMLTableArray reports whether values of the type are written as arrays of
+// tables.
+func isTOMLTableArray(typ reflect.Type) bool {
+	if typ.Kind() != reflect.Slice && typ.Kind() != reflect.Array {
+		return false
+	}
+	if isTOMLScalar(typ) {
+		return false
+	}
+	elem := typ.Elem()
+	for elem.Kind() == reflect.Ptr {
+		elem = elem.Elem()
+	}
+	return isTOMLTable(elem)
+}
+
+// tomlPath joins the keys of a nested table into a dotted header.
+func tomlPath(path []string) string {
+	keys := make([]string, len(path))
+	for i, key := range path {
+		keys[i] = tomlKey(key)
+	}
+	return strings.Join(keys, ".")
+}
+
+// tomlKey returns the key as is if it's a valid bare key, quoted otherwise.
+func tomlKey(key string) string {
+	if key == "" {
+		return `""`
+	}
+	for _, c := range key {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-') {
+			return tomlString(key)
+		}
+	}
+	return key
+}
+
+// tomlString quotes a string as a TOML basic string.
+func tomlString(s string) string {
+	var b bytes.Buffer
+	b.WriteByte('"')
+	for _, c := range s {
+		switch {
+		case c == '"' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(c)
+		case c == '\n':
+			b.WriteString(`\n`)
+		case c == '\r':
+			b.WriteString(`\r`)
+		case c == '\t':
+			b.WriteString(`\t`)
+		case c == utf8.RuneError || c < 0x20 || c == 0x7f:
+			fmt.Fprintf(&b, `\u%04X`, c)
+		default:
+			b.WriteRune(c)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// indirect dereferences pointers and interfaces, returning the invalid value
+// for nils.
+func indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// isEmptyValue reports whether the value is omitted by the omitempty option,
+// following the rules of encoding/json.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
@@ -0,0 +1,105 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package utils
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/EarthDollar/go-earthdollar/common"
+)
+
+type tomlTestEntry struct {
+	Name  string `json:"name"`
+	Value int    `json:"value"`
+}
+
+type tomlTestConfig struct {
+	Title   string                   `json:"title"`
+	Enabled bool                     `json:"enabled"`
+	Ratio   float64                  `json:"ratio"`
+	Number  *big.Int                 `json:"number"`
+	Huge    *big.Int                 `json:"huge"`
+	Missing *big.Int                 `json:"missing"`
+	Hash    common.Hash              `json:"hash"`
+	Tags    []string                 `json:"tags"`
+	Empty   string                   `json:"empty,omitempty"`
+	Skipped string                   `json:"-"`
+	Nested  tomlTestEntry            `json:"nested"`
+	Entries []tomlTestEntry          `json:"entries"`
+	Named   map[string]tomlTestEntry `json:"named key"`
+	hidden  int
+}
+
+func TestEncodeTOML(t *testing.T) {
+	huge, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	config := &tomlTestConfig{
+		Title:   "say \"hi\"\n",
+		Enabled: true,
+		Ratio:   2,
+		Number:  big.NewInt(42),
+		Huge:    huge,
+		Hash:    common.HexToHash("0x01"),
+		Tags:    []string{"a", "b"},
+		Skipped: "skipped",
+		Nested:  tomlTestEntry{"nested", 1},
+		Entries: []tomlTestEntry{{"first", 2}, {"second", 3}},
+		Named:   map[string]tomlTestEntry{"z": {"z", 5}, "a": {"a", 4}},
+		hidden:  1,
+	}
+	want := `title = "say \"hi\"\n"
+enabled = true
+ratio = 2.0
+number = 42
+huge = "123456789012345678901234567890"
+hash = "0x0000000000000000000000000000000000000000000000000000000000000001"
+tags = ["a", "b"]
+
+[nested]
+name = "nested"
+value = 1
+
+[[entries]]
+name = "first"
+value = 2
+
+[[entries]]
+name = "second"
+value = 3
+
+["named key"]
+
+["named key".a]
+name = "a"
+value = 4
+
+["named key".z]
+name = "z"
+value = 5
+`
+	var buf bytes.Buffer
+	if err := EncodeTOML(&buf, config); err != nil {
+		t.Fatalf("failed to encode: %v", err)
+	}
+	if buf.String() != want {
+		t.Errorf("encoding mismatch:\nhave:\n%s\nwant:\n%s", buf.String(), want)
+	}
+	if err := EncodeTOML(&buf, 1); err == nil {
+		t.Errorf("non-struct value accepted")
+	}
+}
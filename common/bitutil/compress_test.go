@@ -0,0 +1,113 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package bitutil
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/EarthDollar/go-earthdollar/common/hexutil"
+)
+
+// Tests that data bitset encoding and decoding works and is bijective.
+func TestEncodingCycle(t *testing.T) {
+	tests := []string{
+		// Sparse, dense and all-zero inputs of various lengths
+		"0x000000000000000000",
+		"0xef0400",
+		"0xdf7070533534333636313639343638373532313536346c1bc33339343837313070706336343035336336346c65fefb3930393233383838ac2f65fefb",
+		"0x7b64000000",
+		"0x000034000000000000",
+		"0x0000000000000000000000000000000000000000000000000000000000000000000000000000000000f0000000000000000000",
+		"0x4912385c0e7b64000000",
+		"0x000034000000000000000000000000000000",
+		"0x00",
+		"0x000003e834ff7f0000",
+		"0x0000",
+		"0x0000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000",
+		"0x000f007a00",
+	}
+	for i, tt := range tests {
+		data := hexutil.MustDecode(tt)
+
+		proc, err := bitsetDecodeBytes(bitsetEncodeBytes(data), len(data))
+		if err != nil {
+			t.Errorf("test %d: failed to decompress compressed data: %v", i, err)
+			continue
+		}
+		if !bytes.Equal(data, proc) {
+			t.Errorf("test %d: compress/decompress mismatch: have %x, want %x", i, proc, data)
+		}
+	}
+}
+
+// Tests that data bitset decoding and rencoding works and is bijective.
+func TestDecodingCycle(t *testing.T) {
+	tests := []struct {
+		size  int
+		input string
+		fail  error
+	}{
+		{size: 0, input: "0x"},
+		{size: 1, input: "0x05"},
+		{size: 3, input: "0xa0aabb"},
+		{size: 2, input: "0x01", fail: errMissingData},
+		{size: 2, input: "0x0000", fail: errUnreferencedData},
+		{size: 3, input: "0xdf0000", fail: errZeroContent},
+		{size: 3, input: "0x0100", fail: errExceededTarget},
+	}
+	for i, tt := range tests {
+		data := hexutil.MustDecode(tt.input)
+
+		orig, err := bitsetDecodeBytes(data, tt.size)
+		if err != tt.fail {
+			t.Errorf("test %d: failure mismatch: have %v, want %v", i, err, tt.fail)
+		}
+		if err != nil {
+			continue
+		}
+		if comp := bitsetEncodeBytes(orig); !bytes.Equal(comp, data) {
+			t.Errorf("test %d: decompress/compress mismatch: have %x, want %x", i, comp, data)
+		}
+	}
+}
+
+// Tests that random sparse bit vectors of various densities survive a round
+// trip through the compressor, and that sparse ones actually shrink.
+func TestCompression(t *testing.T) {
+	for _, fill := range []float64{0.001, 0.01, 0.1, 0.5, 1} {
+		data := make([]byte, 512)
+		for i := range data {
+			if rand.Float64() < fill {
+				data[i] = byte(rand.Intn(255) + 1)
+			}
+		}
+		comp := CompressBytes(data)
+		if fill <= 0.1 && len(comp) >= len(data) {
+			t.Errorf("fill %v: data not compressed: %d bytes", fill, len(comp))
+		}
+		decomp, err := DecompressBytes(comp, len(data))
+		if err != nil {
+			t.Errorf("fill %v: failed to decompress: %v", fill, err)
+			continue
+		}
+		if !bytes.Equal(data, decomp) {
+			t.Errorf("fill %v: round trip mismatch", fill)
+		}
+	}
+}
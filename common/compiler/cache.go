@@ -0,0 +1,122 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package compiler
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/EarthDollar/go-earthdollar/crypto"
+	"github.com/EarthDollar/go-earthdollar/ethdb"
+	"github.com/EarthDollar/go-earthdollar/logger"
+	"github.com/EarthDollar/go-earthdollar/logger/glog"
+)
+
+// cachePrefix is the database key prefix of cached compilation results.
+var cachePrefix = []byte("compiler-")
+
+// Cache memoizes compilation results in a database, keyed by the hash of the
+// sources, the compiler path and version, and the compilation options. The
+// compiler still needs to be queried for its version on every call, but the
+// sources are only compiled once.
+type Cache struct {
+	db ethdb.Database
+}
+
+// NewCache creates a compilation cache on top of the given database.
+func NewCache(db ethdb.Database) *Cache {
+	return &Cache{db: db}
+}
+
+// CompileSolidityString is the cached version of the package level function.
+func (c *Cache) CompileSolidityString(solc, source string) (map[string]*Contract, error) {
+	info, err := SolidityVersion(solc)
+	if err != nil {
+		return CompileSolidityString(solc, source)
+	}
+	options, _ := json.Marshal(DefaultOptimizer)
+	key := cacheKey(LangSolidity, info.Path, info.FullVersion, string(options), map[string]string{"": source})
+
+	if contracts := c.get(key); contracts != nil {
+		return contracts, nil
+	}
+	contracts, err := CompileSolidityString(solc, source)
+	if err == nil {
+		c.put(key, contracts)
+	}
+	return contracts, err
+}
+
+// CompileVyperString is the cached version of the package level function.
+func (c *Cache) CompileVyperString(vyper, source string) (map[string]*Contract, error) {
+	info, err := VyperVersion(vyper)
+	if err != nil {
+		return CompileVyperString(vyper, source)
+	}
+	key := cacheKey(LangVyper, info.Path, info.FullVersion, "", map[string]string{"": source})
+
+	if contracts := c.get(key); contracts != nil {
+		return contracts, nil
+	}
+	contracts, err := CompileVyperString(vyper, source)
+	if err == nil {
+		c.put(key, contracts)
+	}
+	return contracts, err
+}
+
+// get retrieves a cached compilation result, or nil if not found.
+func (c *Cache) get(key []byte) map[string]*Contract {
+	blob, err := c.db.Get(key)
+	if err != nil || len(blob) == 0 {
+		return nil
+	}
+	var contracts map[string]*Contract
+	if err := json.Unmarshal(blob, &contracts); err != nil {
+		glog.V(logger.Debug).Infof("Discarding corrupt compiler cache entry %x: %v", key[len(cachePrefix):], err)
+		c.db.Delete(key)
+		return nil
+	}
+	return contracts
+}
+
+// put stores a compilation result in the cache.
+func (c *Cache) put(key []byte, contracts map[string]*Contract) {
+	blob, err := json.Marshal(contracts)
+	if err != nil {
+		return
+	}
+	if err := c.db.Put(key, blob); err != nil {
+		glog.V(logger.Debug).Infof("Failed to cache compilation result: %v", err)
+	}
+}
+
+// cacheKey derives the database key of a compilation from all its inputs.
+func cacheKey(lang, path, version, options string, sources map[string]string) []byte {
+	names := make([]string, 0, len(sources))
+	for name := range sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	blob, _ := json.Marshal([]interface{}{lang, path, version, options, names})
+	data := [][]byte{blob}
+	for _, name := range names {
+		data = append(data, crypto.Keccak256([]byte(sources[name])))
+	}
+	return append(append([]byte{}, cachePrefix...), crypto.Keccak256(data...)...)
+}
@@ -0,0 +1,71 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package compiler
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/EarthDollar/go-earthdollar/ethdb"
+)
+
+func TestCacheKey(t *testing.T) {
+	base := cacheKey(LangSolidity, "/usr/bin/solc", "0.4.11", "{}", map[string]string{"a": "x", "b": "y"})
+	if !bytes.HasPrefix(base, cachePrefix) {
+		t.Fatalf("key %x missing prefix", base)
+	}
+	if key := cacheKey(LangSolidity, "/usr/bin/solc", "0.4.11", "{}", map[string]string{"b": "y", "a": "x"}); !bytes.Equal(key, base) {
+		t.Errorf("key depends on source order")
+	}
+	tests := [][]byte{
+		cacheKey(LangVyper, "/usr/bin/solc", "0.4.11", "{}", map[string]string{"a": "x", "b": "y"}),
+		cacheKey(LangSolidity, "/opt/solc", "0.4.11", "{}", map[string]string{"a": "x", "b": "y"}),
+		cacheKey(LangSolidity, "/usr/bin/solc", "0.4.12", "{}", map[string]string{"a": "x", "b": "y"}),
+		cacheKey(LangSolidity, "/usr/bin/solc", "0.4.11", `{"runs":1}`, map[string]string{"a": "x", "b": "y"}),
+		cacheKey(LangSolidity, "/usr/bin/solc", "0.4.11", "{}", map[string]string{"a": "x", "b": "z"}),
+		cacheKey(LangSolidity, "/usr/bin/solc", "0.4.11", "{}", map[string]string{"a": "x", "c": "y"}),
+	}
+	for i, key := range tests {
+		if bytes.Equal(key, base) {
+			t.Errorf("test %d: key collision with different inputs", i)
+		}
+	}
+}
+
+func TestCacheStorage(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	cache := NewCache(db)
+
+	key := cacheKey(LangSolidity, "solc", "0.4.11", "", map[string]string{"": testSource})
+	if contracts := cache.get(key); contracts != nil {
+		t.Fatalf("unexpected cache hit: %v", contracts)
+	}
+	want := map[string]*Contract{"test": {Code: "0x6060", Info: ContractInfo{Source: testSource, Language: LangSolidity}}}
+	cache.put(key, want)
+	if have := cache.get(key); !reflect.DeepEqual(have, want) {
+		t.Errorf("cached contracts mismatch: have %v, want %v", have, want)
+	}
+	// Corrupt entries should be dropped
+	db.Put(key, []byte("not json"))
+	if contracts := cache.get(key); contracts != nil {
+		t.Errorf("corrupt entry returned: %v", contracts)
+	}
+	if blob, _ := db.Get(key); blob != nil {
+		t.Errorf("corrupt entry not deleted")
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package compiler
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+)
+
+// Source languages supported by the compiler drivers.
+const (
+	LangSolidity = "Solidity"
+	LangVyper    = "Vyper"
+)
+
+// Language guesses the source language of a file from its extension.
+func Language(file string) (string, error) {
+	switch filepath.Ext(file) {
+	case ".sol":
+		return LangSolidity, nil
+	case ".vy":
+		return LangVyper, nil
+	}
+	return "", fmt.Errorf("unknown source language of %s", file)
+}
+
+// Compile builds the given source files with the driver of the given language,
+// using the compiler executable at path (or the default one if empty).
+func Compile(lang, path string, sourcefiles ...string) (map[string]*Contract, error) {
+	switch lang {
+	case LangSolidity:
+		return CompileSolidity(path, sourcefiles...)
+	case LangVyper:
+		return CompileVyper(path, sourcefiles...)
+	}
+	return nil, errors.New("unsupported source language: " + lang)
+}
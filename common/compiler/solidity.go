@@ -14,7 +14,7 @@
 // You should have received a copy of the GNU Lesser General Public License
 // along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
 
-// Package compiler wraps the Solidity compiler executable (solc).
+// Package compiler wraps the Solidity and Vyper compiler executables (solc, vyper).
 package compiler
 
 import (
@@ -25,20 +25,18 @@ import (
 	"io/ioutil"
 	"os/exec"
 	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/EarthDollar/go-earthdollar/common"
 	"github.com/EarthDollar/go-earthdollar/crypto"
 )
 
-var (
-	versionRegexp = regexp.MustCompile(`[0-9]+\.[0-9]+\.[0-9]+`)
-	solcParams    = []string{
-		"--combined-json", "bin,abi,userdoc,devdoc",
-		"--add-std",  // include standard lib contracts
-		"--optimize", // code optimizer switched on
-	}
-)
+var versionRegexp = regexp.MustCompile(`[0-9]+\.[0-9]+\.[0-9]+`)
+
+// DefaultOptimizer is the optimizer configuration contracts are compiled with
+// unless requested otherwise.
+var DefaultOptimizer = Optimizer{Enabled: true, Runs: 200}
 
 type Contract struct {
 	Code string       `json:"code"`
@@ -54,6 +52,7 @@ type ContractInfo struct {
 	AbiDefinition   interface{} `json:"abiDefinition"`
 	UserDoc         interface{} `json:"userDoc"`
 	DeveloperDoc    interface{} `json:"developerDoc"`
+	Metadata        string      `json:"metadata,omitempty"`
 }
 
 // Solidity contains information about the solidity compiler.
@@ -61,10 +60,82 @@ type Solidity struct {
 	Path, Version, FullVersion string
 }
 
-// --combined-output format
-type solcOutput struct {
-	Contracts map[string]struct{ Bin, Abi, Devdoc, Userdoc string }
-	Version   string
+// Optimizer configures the bytecode optimizer of solc.
+type Optimizer struct {
+	Enabled bool `json:"enabled"`
+	Runs    int  `json:"runs"` // Number of expected executions to optimize for
+}
+
+// SourceLocation identifies the part of a source file a diagnostic refers to.
+type SourceLocation struct {
+	File  string `json:"file"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+}
+
+// CompilerMessage is an error or warning reported by the compiler.
+type CompilerMessage struct {
+	SourceLocation   *SourceLocation `json:"sourceLocation,omitempty"`
+	Type             string          `json:"type"`      // e.g. TypeError, ParserError, Warning
+	Component        string          `json:"component"` // e.g. general, ewasm
+	Severity         string          `json:"severity"`  // error or warning
+	Message          string          `json:"message"`
+	FormattedMessage string          `json:"formattedMessage"`
+}
+
+// CompileError is returned if the compiler rejected the sources, carrying all
+// the diagnostics it reported.
+type CompileError struct {
+	Messages []CompilerMessage
+}
+
+func (e *CompileError) Error() string {
+	var errs []string
+	for _, msg := range e.Messages {
+		if msg.Severity != "error" {
+			continue
+		}
+		if msg.FormattedMessage != "" {
+			errs = append(errs, strings.TrimSpace(msg.FormattedMessage))
+		} else {
+			errs = append(errs, msg.Type+": "+msg.Message)
+		}
+	}
+	return "solc: compilation failed\n" + strings.Join(errs, "\n")
+}
+
+// standardInput is the --standard-json input format of solc.
+type standardInput struct {
+	Language string                    `json:"language"`
+	Sources  map[string]standardSource `json:"sources"`
+	Settings standardSettings          `json:"settings"`
+}
+
+type standardSource struct {
+	Content string `json:"content"`
+}
+
+type standardSettings struct {
+	Optimizer       Optimizer                      `json:"optimizer"`
+	OutputSelection map[string]map[string][]string `json:"outputSelection"`
+}
+
+// standardOutput is the --standard-json output format of solc.
+type standardOutput struct {
+	Errors    []CompilerMessage                            `json:"errors"`
+	Contracts map[string]map[string]standardContractOutput `json:"contracts"`
+}
+
+type standardContractOutput struct {
+	Abi      interface{} `json:"abi"`
+	Metadata string      `json:"metadata"`
+	Userdoc  interface{} `json:"userdoc"`
+	Devdoc   interface{} `json:"devdoc"`
+	Evm      struct {
+		Bytecode struct {
+			Object string `json:"object"`
+		} `json:"bytecode"`
+	} `json:"evm"`
 }
 
 // SolidityVersion runs solc and parses its version output.
@@ -91,13 +162,8 @@ func CompileSolidityString(solc, source string) (map[string]*Contract, error) {
 	if len(source) == 0 {
 		return nil, errors.New("solc: empty source string")
 	}
-	if solc == "" {
-		solc = "solc"
-	}
-	args := append(solcParams, "--")
-	cmd := exec.Command(solc, append(args, "-")...)
-	cmd.Stdin = strings.NewReader(source)
-	return runsolc(cmd, source)
+	contracts, _, err := CompileSoliditySources(solc, map[string]string{"<stdin>": source}, DefaultOptimizer)
+	return contracts, err
 }
 
 // CompileSolidity compiles all given Solidity source files.
@@ -105,74 +171,107 @@ func CompileSolidity(solc string, sourcefiles ...string) (map[string]*Contract,
 	if len(sourcefiles) == 0 {
 		return nil, errors.New("solc: no source files")
 	}
-	source, err := slurpFiles(sourcefiles)
-	if err != nil {
-		return nil, err
-	}
-	if solc == "" {
-		solc = "solc"
+	sources := make(map[string]string, len(sourcefiles))
+	for _, file := range sourcefiles {
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		sources[file] = string(content)
 	}
-	args := append(solcParams, "--")
-	cmd := exec.Command(solc, append(args, sourcefiles...)...)
-	return runsolc(cmd, source)
+	contracts, _, err := CompileSoliditySources(solc, sources, DefaultOptimizer)
+	return contracts, err
 }
 
-func runsolc(cmd *exec.Cmd, source string) (map[string]*Contract, error) {
+// CompileSoliditySources compiles the named sources through the standard JSON
+// interface of solc, returning the contracts keyed by name (qualified with the
+// source name if ambiguous) along with any warnings. If the compilation fails,
+// the error is a *CompileError listing all the diagnostics.
+func CompileSoliditySources(solc string, sources map[string]string, optimizer Optimizer) (map[string]*Contract, []CompilerMessage, error) {
+	if len(sources) == 0 {
+		return nil, nil, errors.New("solc: no sources")
+	}
+	info, err := SolidityVersion(solc)
+	if err != nil {
+		return nil, nil, fmt.Errorf("solc: %v", err)
+	}
+	input := standardInput{
+		Language: LangSolidity,
+		Sources:  make(map[string]standardSource, len(sources)),
+		Settings: standardSettings{
+			Optimizer: optimizer,
+			OutputSelection: map[string]map[string][]string{
+				"*": {"*": {"abi", "metadata", "userdoc", "devdoc", "evm.bytecode.object"}},
+			},
+		},
+	}
+	for name, content := range sources {
+		input.Sources[name] = standardSource{Content: content}
+	}
+	blob, err := json.Marshal(input)
+	if err != nil {
+		return nil, nil, err
+	}
 	var stderr, stdout bytes.Buffer
+	cmd := exec.Command(info.Path, "--standard-json")
+	cmd.Stdin = bytes.NewReader(blob)
 	cmd.Stderr = &stderr
 	cmd.Stdout = &stdout
 	if err := cmd.Run(); err != nil {
-		return nil, fmt.Errorf("solc: %v\n%s", err, stderr.Bytes())
+		return nil, nil, fmt.Errorf("solc: %v\n%s", err, stderr.Bytes())
 	}
-	var output solcOutput
-	if err := json.Unmarshal(stdout.Bytes(), &output); err != nil {
-		return nil, err
-	}
-	shortVersion := versionRegexp.FindString(output.Version)
+	options, _ := json.Marshal(input.Settings.Optimizer)
+	return parseStandardOutput(stdout.Bytes(), sources, info.Version, string(options))
+}
 
-	// Compilation succeeded, assemble and return the contracts.
-	contracts := make(map[string]*Contract)
-	for name, info := range output.Contracts {
-		// Parse the individual compilation results.
-		var abi interface{}
-		if err := json.Unmarshal([]byte(info.Abi), &abi); err != nil {
-			return nil, fmt.Errorf("solc: error reading abi definition (%v)", err)
-		}
-		var userdoc interface{}
-		if err := json.Unmarshal([]byte(info.Userdoc), &userdoc); err != nil {
-			return nil, fmt.Errorf("solc: error reading user doc: %v", err)
-		}
-		var devdoc interface{}
-		if err := json.Unmarshal([]byte(info.Devdoc), &devdoc); err != nil {
-			return nil, fmt.Errorf("solc: error reading dev doc: %v", err)
-		}
-		contracts[name] = &Contract{
-			Code: "0x" + info.Bin,
-			Info: ContractInfo{
-				Source:          source,
-				Language:        "Solidity",
-				LanguageVersion: shortVersion,
-				CompilerVersion: shortVersion,
-				CompilerOptions: strings.Join(solcParams, " "),
-				AbiDefinition:   abi,
-				UserDoc:         userdoc,
-				DeveloperDoc:    devdoc,
-			},
+// parseStandardOutput assembles the contracts from the standard JSON output of
+// solc, failing if any error was reported.
+func parseStandardOutput(output []byte, sources map[string]string, version, options string) (map[string]*Contract, []CompilerMessage, error) {
+	var out standardOutput
+	if err := json.Unmarshal(output, &out); err != nil {
+		return nil, nil, fmt.Errorf("solc: error parsing output: %v", err)
+	}
+	var warnings []CompilerMessage
+	for _, msg := range out.Errors {
+		if msg.Severity == "error" {
+			return nil, nil, &CompileError{Messages: out.Errors}
 		}
+		warnings = append(warnings, msg)
 	}
-	return contracts, nil
-}
+	// Sort the sources to qualify ambiguous contract names deterministically
+	names := make([]string, 0, len(sources))
+	for name := range sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
 
-func slurpFiles(files []string) (string, error) {
-	var concat bytes.Buffer
-	for _, file := range files {
-		content, err := ioutil.ReadFile(file)
-		if err != nil {
-			return "", err
+	var source bytes.Buffer
+	for _, name := range names {
+		source.WriteString(sources[name])
+	}
+	contracts := make(map[string]*Contract)
+	for _, file := range names {
+		for name, info := range out.Contracts[file] {
+			if _, exists := contracts[name]; exists {
+				name = file + ":" + name
+			}
+			contracts[name] = &Contract{
+				Code: "0x" + info.Evm.Bytecode.Object,
+				Info: ContractInfo{
+					Source:          source.String(),
+					Language:        LangSolidity,
+					LanguageVersion: version,
+					CompilerVersion: version,
+					CompilerOptions: options,
+					AbiDefinition:   info.Abi,
+					UserDoc:         info.Userdoc,
+					DeveloperDoc:    info.Devdoc,
+					Metadata:        info.Metadata,
+				},
+			}
 		}
-		concat.Write(content)
 	}
-	return concat.String(), nil
+	return contracts, warnings, nil
 }
 
 // SaveInfo serializes info to the given file and returns its Keccak256 hash.
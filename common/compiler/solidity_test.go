@@ -104,3 +104,45 @@ func TestSaveInfo(t *testing.T) {
 		t.Errorf("content hash for info is incorrect. expected %v, got %v", wantHash.Hex(), cinfohash.Hex())
 	}
 }
+
+func TestParseStandardOutput(t *testing.T) {
+	output := `{
+		"errors": [{"type": "Warning", "component": "general", "severity": "warning", "message": "No visibility specified.", "formattedMessage": "test.sol:3:4: Warning: No visibility specified."}],
+		"contracts": {
+			"a.sol": {"test": {"abi": [], "metadata": "{}", "userdoc": {"methods": {}}, "devdoc": {"methods": {}}, "evm": {"bytecode": {"object": "6060"}}}},
+			"b.sol": {"test": {"abi": [], "metadata": "{}", "evm": {"bytecode": {"object": "6061"}}}}
+		}
+	}`
+	sources := map[string]string{"a.sol": "contract test {}", "b.sol": "contract test {}"}
+	contracts, warnings, err := parseStandardOutput([]byte(output), sources, "0.4.11", `{"enabled":true,"runs":200}`)
+	if err != nil {
+		t.Fatalf("failed to parse output: %v", err)
+	}
+	if len(warnings) != 1 || warnings[0].Type != "Warning" {
+		t.Errorf("warnings mismatch: %v", warnings)
+	}
+	if c := contracts["test"]; c == nil || c.Code != "0x6060" || c.Info.Metadata != "{}" {
+		t.Errorf("contract 'test' mismatch: %+v", c)
+	}
+	if c := contracts["b.sol:test"]; c == nil || c.Code != "0x6061" {
+		t.Errorf("contract 'b.sol:test' mismatch: %+v", c)
+	}
+}
+
+func TestParseStandardOutputError(t *testing.T) {
+	output := `{"errors": [
+		{"type": "Warning", "severity": "warning", "message": "Unused variable."},
+		{"sourceLocation": {"file": "test.sol", "start": 1, "end": 9}, "type": "ParserError", "severity": "error", "message": "Expected identifier."}
+	]}`
+	_, _, err := parseStandardOutput([]byte(output), map[string]string{"test.sol": ""}, "0.4.11", "")
+	cerr, ok := err.(*CompileError)
+	if !ok {
+		t.Fatalf("expected compile error, got %v", err)
+	}
+	if len(cerr.Messages) != 2 {
+		t.Errorf("message count mismatch: have %d, want 2", len(cerr.Messages))
+	}
+	if loc := cerr.Messages[1].SourceLocation; loc == nil || loc.File != "test.sol" || loc.End != 9 {
+		t.Errorf("source location mismatch: %+v", loc)
+	}
+}
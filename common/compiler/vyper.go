@@ -0,0 +1,168 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package compiler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Vyper contains information about the vyper compiler.
+type Vyper struct {
+	Path, Version, FullVersion string
+}
+
+// vyperContract is the per contract section of the combined_json output of vyper.
+type vyperContract struct {
+	Abi      interface{} `json:"abi"`
+	Bytecode string      `json:"bytecode"`
+	Userdoc  interface{} `json:"userdoc"`
+	Devdoc   interface{} `json:"devdoc"`
+	Metadata interface{} `json:"metadata"`
+}
+
+// VyperVersion runs vyper and parses its version output.
+func VyperVersion(vyper string) (*Vyper, error) {
+	if vyper == "" {
+		vyper = "vyper"
+	}
+	var out bytes.Buffer
+	cmd := exec.Command(vyper, "--version")
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		return nil, err
+	}
+	v := &Vyper{
+		Path:        cmd.Path,
+		FullVersion: strings.TrimSpace(out.String()),
+		Version:     versionRegexp.FindString(out.String()),
+	}
+	return v, nil
+}
+
+// CompileVyperString builds and returns the contract contained within a source
+// string. As vyper derives contract names from file names, the result is keyed
+// by "contract".
+func CompileVyperString(vyper, source string) (map[string]*Contract, error) {
+	if len(source) == 0 {
+		return nil, errors.New("vyper: empty source string")
+	}
+	dir, err := ioutil.TempDir("", "vyper")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "contract.vy")
+	if err := ioutil.WriteFile(file, []byte(source), 0600); err != nil {
+		return nil, err
+	}
+	return CompileVyper(vyper, file)
+}
+
+// CompileVyper compiles all given Vyper source files, keying every contract by
+// its file name without the extension.
+func CompileVyper(vyper string, sourcefiles ...string) (map[string]*Contract, error) {
+	if len(sourcefiles) == 0 {
+		return nil, errors.New("vyper: no source files")
+	}
+	sources := make(map[string]string, len(sourcefiles))
+	for _, file := range sourcefiles {
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		sources[file] = string(content)
+	}
+	info, err := VyperVersion(vyper)
+	if err != nil {
+		return nil, fmt.Errorf("vyper: %v", err)
+	}
+	var stderr, stdout bytes.Buffer
+	cmd := exec.Command(info.Path, append([]string{"-f", "combined_json"}, sourcefiles...)...)
+	cmd.Stderr = &stderr
+	cmd.Stdout = &stdout
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("vyper: %v\n%s", err, stderr.Bytes())
+	}
+	return parseVyperOutput(stdout.Bytes(), sources, info.Version)
+}
+
+// parseVyperOutput assembles the contracts from the combined_json output of vyper.
+func parseVyperOutput(output []byte, sources map[string]string, version string) (map[string]*Contract, error) {
+	var out map[string]json.RawMessage
+	if err := json.Unmarshal(output, &out); err != nil {
+		return nil, fmt.Errorf("vyper: error parsing output: %v", err)
+	}
+	// Sort the sources to qualify ambiguous contract names deterministically
+	files := make([]string, 0, len(sources))
+	for file := range sources {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	contracts := make(map[string]*Contract)
+	for _, file := range files {
+		blob, ok := out[file]
+		if !ok {
+			return nil, fmt.Errorf("vyper: no output for %s", file)
+		}
+		var info vyperContract
+		if err := json.Unmarshal(blob, &info); err != nil {
+			return nil, fmt.Errorf("vyper: error parsing output of %s: %v", file, err)
+		}
+		name := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+		if _, exists := contracts[name]; exists {
+			name = file + ":" + name
+		}
+		var metadata string
+		if info.Metadata != nil {
+			if s, ok := info.Metadata.(string); ok {
+				metadata = s
+			} else {
+				blob, _ := json.Marshal(info.Metadata)
+				metadata = string(blob)
+			}
+		}
+		code := info.Bytecode
+		if !strings.HasPrefix(code, "0x") {
+			code = "0x" + code
+		}
+		contracts[name] = &Contract{
+			Code: code,
+			Info: ContractInfo{
+				Source:          sources[file],
+				Language:        LangVyper,
+				LanguageVersion: version,
+				CompilerVersion: version,
+				AbiDefinition:   info.Abi,
+				UserDoc:         info.Userdoc,
+				DeveloperDoc:    info.Devdoc,
+				Metadata:        metadata,
+			},
+		}
+	}
+	return contracts, nil
+}
@@ -0,0 +1,86 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package compiler
+
+import (
+	"os/exec"
+	"testing"
+)
+
+const (
+	testVyperSource = `
+@public
+def multiply(a: uint256) -> uint256:
+    return a * 7
+`
+	testVyperOutput = `{
+	"a/test.vy": {"abi": [{"name": "multiply", "type": "function"}], "bytecode": "0x6060", "userdoc": {}, "devdoc": {}},
+	"b/test.vy": {"abi": [], "bytecode": "6061"},
+	"version": "0.1.0b17"
+}`
+)
+
+func skipWithoutVyper(t *testing.T) {
+	if _, err := exec.LookPath("vyper"); err != nil {
+		t.Skip(err)
+	}
+}
+
+func TestVyperCompiler(t *testing.T) {
+	skipWithoutVyper(t)
+
+	contracts, err := CompileVyperString("", testVyperSource)
+	if err != nil {
+		t.Fatalf("error compiling source. result %v: %v", contracts, err)
+	}
+	c, ok := contracts["contract"]
+	if !ok {
+		t.Fatal("info for contract 'contract' not present in result")
+	}
+	if c.Code == "" || c.Code == "0x" {
+		t.Error("empty code")
+	}
+	if c.Info.Language != LangVyper {
+		t.Errorf("language mismatch: have %s, want %s", c.Info.Language, LangVyper)
+	}
+}
+
+func TestParseVyperOutput(t *testing.T) {
+	sources := map[string]string{"a/test.vy": "a", "b/test.vy": "b"}
+	contracts, err := parseVyperOutput([]byte(testVyperOutput), sources, "0.1.0")
+	if err != nil {
+		t.Fatalf("failed to parse output: %v", err)
+	}
+	if c := contracts["test"]; c == nil || c.Code != "0x6060" || c.Info.Source != "a" {
+		t.Errorf("contract 'test' mismatch: %+v", c)
+	}
+	if c := contracts["b/test.vy:test"]; c == nil || c.Code != "0x6061" {
+		t.Errorf("contract 'b/test.vy:test' mismatch: %+v", c)
+	}
+	if _, err := parseVyperOutput([]byte(testVyperOutput), map[string]string{"c.vy": ""}, "0.1.0"); err == nil {
+		t.Error("expected error for missing output")
+	}
+}
+
+func TestLanguage(t *testing.T) {
+	tests := map[string]string{"token.sol": LangSolidity, "dir/token.vy": LangVyper, "token.go": ""}
+	for file, want := range tests {
+		if lang, _ := Language(file); lang != want {
+			t.Errorf("%s: language mismatch: have %q, want %q", file, lang, want)
+		}
+	}
+}
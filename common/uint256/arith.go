@@ -0,0 +1,118 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package uint256
+
+// This file contains portable versions of the double word primitives the
+// arithmetic is built on. They mirror their math/bits counterparts, which are
+// not available on all supported Go versions.
+
+const (
+	mask32 = 1<<32 - 1
+
+	// uintSize is the size of a uint (and thus a big.Word) in bits.
+	uintSize = 32 << (^uint(0) >> 63)
+)
+
+// add64 returns the sum with carry of x, y and carry. The carry input must be
+// 0 or 1, the carry output is guaranteed to be 0 or 1.
+func add64(x, y, carry uint64) (sum, carryOut uint64) {
+	sum = x + y + carry
+	carryOut = ((x & y) | ((x | y) &^ sum)) >> 63
+	return
+}
+
+// sub64 returns the difference of x, y and borrow. The borrow input must be
+// 0 or 1, the borrow output is guaranteed to be 0 or 1.
+func sub64(x, y, borrow uint64) (diff, borrowOut uint64) {
+	diff = x - y - borrow
+	borrowOut = ((^x & y) | (^(x ^ y) & diff)) >> 63
+	return
+}
+
+// mul64 returns the 128-bit product of x and y as its high and low halves.
+func mul64(x, y uint64) (hi, lo uint64) {
+	x0, x1 := x&mask32, x>>32
+	y0, y1 := y&mask32, y>>32
+
+	w0 := x0 * y0
+	t := x1*y0 + w0>>32
+	w1, w2 := t&mask32, t>>32
+	w1 += x0 * y1
+
+	return x1*y1 + w2 + w1>>32, x * y
+}
+
+// div64 returns the quotient and remainder of (hi, lo) divided by y. The
+// quotient must fit into a single word, i.e. y must be greater than hi.
+func div64(hi, lo, y uint64) (quo, rem uint64) {
+	const two32 = 1 << 32
+
+	s := uint(leadingZeros64(y))
+	y <<= s
+
+	yn1, yn0 := y>>32, y&mask32
+	un32 := hi<<s | lo>>(64-s)
+	un10 := lo << s
+	un1, un0 := un10>>32, un10&mask32
+
+	q1 := un32 / yn1
+	rhat := un32 - q1*yn1
+	for q1 >= two32 || q1*yn0 > two32*rhat+un1 {
+		q1--
+		rhat += yn1
+		if rhat >= two32 {
+			break
+		}
+	}
+	un21 := un32*two32 + un1 - q1*y
+
+	q0 := un21 / yn1
+	rhat = un21 - q0*yn1
+	for q0 >= two32 || q0*yn0 > two32*rhat+un0 {
+		q0--
+		rhat += yn1
+		if rhat >= two32 {
+			break
+		}
+	}
+	return q1*two32 + q0, (un21*two32 + un0 - q0*y) >> s
+}
+
+// len64 returns the minimum number of bits required to represent x.
+func len64(x uint64) (n int) {
+	if x >= 1<<32 {
+		x >>= 32
+		n = 32
+	}
+	if x >= 1<<16 {
+		x >>= 16
+		n += 16
+	}
+	if x >= 1<<8 {
+		x >>= 8
+		n += 8
+	}
+	for ; x != 0; x >>= 1 {
+		n++
+	}
+	return n
+}
+
+// leadingZeros64 returns the number of leading zero bits in x.
+func leadingZeros64(x uint64) int {
+	return 64 - len64(x)
+}
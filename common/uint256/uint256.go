@@ -0,0 +1,574 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package uint256 implements fixed-width 256-bit unsigned integers with the
+// wrapping arithmetic semantics of the Ethereum virtual machine.
+//
+// Values live on the stack (or inside other values) and operations never
+// allocate, which makes them considerably cheaper than big.Int for the short
+// lived operands of the interpreter. Signed operations interpret the values
+// as two's complement numbers.
+package uint256
+
+import "math/big"
+
+// Int is a 256-bit unsigned integer, stored as four 64-bit words with the least
+// significant one first. The zero value is ready to use.
+//
+// Like big.Int, operations take the form z.Op(x, y), store the result in z and
+// return it, allowing any of the operands to alias the result.
+type Int [4]uint64
+
+// NewInt allocates a new Int set to v.
+func NewInt(v uint64) *Int {
+	return &Int{v}
+}
+
+// FromBig allocates a new Int set to b modulo 2^256.
+func FromBig(b *big.Int) *Int {
+	return new(Int).SetFromBig(b)
+}
+
+// Clear sets z to 0.
+func (z *Int) Clear() *Int {
+	*z = Int{}
+	return z
+}
+
+// Set sets z to x.
+func (z *Int) Set(x *Int) *Int {
+	*z = *x
+	return z
+}
+
+// SetUint64 sets z to v.
+func (z *Int) SetUint64(v uint64) *Int {
+	*z = Int{v}
+	return z
+}
+
+// SetBytes interprets buf as a big-endian unsigned integer and sets z to it.
+// Only the last 32 bytes are used if buf is longer.
+func (z *Int) SetBytes(buf []byte) *Int {
+	if len(buf) > 32 {
+		buf = buf[len(buf)-32:]
+	}
+	*z = Int{}
+	for i, j := len(buf)-1, uint(0); i >= 0; i, j = i-1, j+1 {
+		z[j/8] |= uint64(buf[i]) << (8 * (j % 8))
+	}
+	return z
+}
+
+// SetFromBig sets z to b modulo 2^256, i.e. negative numbers are converted to
+// their two's complement representation.
+func (z *Int) SetFromBig(b *big.Int) *Int {
+	*z = Int{}
+	words := b.Bits()
+	if uintSize == 64 {
+		for i := 0; i < len(words) && i < 4; i++ {
+			z[i] = uint64(words[i])
+		}
+	} else {
+		for i := 0; i < len(words) && i < 8; i++ {
+			z[i/2] |= uint64(words[i]) << (32 * uint(i%2))
+		}
+	}
+	if b.Sign() < 0 {
+		z.Neg(z)
+	}
+	return z
+}
+
+// ToBig returns z as a new big.Int.
+func (z *Int) ToBig() *big.Int {
+	b := z.Bytes32()
+	return new(big.Int).SetBytes(b[:])
+}
+
+// Bytes32 returns z as a 32 byte big-endian array.
+func (z *Int) Bytes32() (b [32]byte) {
+	for i := 0; i < 4; i++ {
+		w := z[3-i]
+		for j := 0; j < 8; j++ {
+			b[8*i+j] = byte(w >> (56 - 8*uint(j)))
+		}
+	}
+	return b
+}
+
+// Bytes20 returns the low 20 bytes of z as a big-endian array (i.e. the
+// address the value represents).
+func (z *Int) Bytes20() (b [20]byte) {
+	b32 := z.Bytes32()
+	copy(b[:], b32[12:])
+	return b
+}
+
+// Bytes returns the minimal big-endian representation of z.
+func (z *Int) Bytes() []byte {
+	b := z.Bytes32()
+	return b[32-z.ByteLen():]
+}
+
+// Uint64 returns the low 64 bits of z.
+func (z *Int) Uint64() uint64 {
+	return z[0]
+}
+
+// IsUint64 reports whether z can be represented as a uint64.
+func (z *Int) IsUint64() bool {
+	return z[1]|z[2]|z[3] == 0
+}
+
+// IsZero reports whether z is 0.
+func (z *Int) IsZero() bool {
+	return z[0]|z[1]|z[2]|z[3] == 0
+}
+
+// Sign returns -1, 0 or +1 depending on whether z is negative, zero or
+// positive when interpreted as a two's complement number.
+func (z *Int) Sign() int {
+	switch {
+	case z.IsZero():
+		return 0
+	case z[3]&(1<<63) != 0:
+		return -1
+	default:
+		return 1
+	}
+}
+
+// BitLen returns the number of bits required to represent z.
+func (z *Int) BitLen() int {
+	for i := 3; i >= 0; i-- {
+		if z[i] != 0 {
+			return 64*i + len64(z[i])
+		}
+	}
+	return 0
+}
+
+// ByteLen returns the number of bytes required to represent z.
+func (z *Int) ByteLen() int {
+	return (z.BitLen() + 7) / 8
+}
+
+// String returns the decimal representation of z.
+func (z *Int) String() string {
+	return z.ToBig().String()
+}
+
+// Eq reports whether z == x.
+func (z *Int) Eq(x *Int) bool {
+	return *z == *x
+}
+
+// Cmp compares z and x as unsigned numbers and returns -1, 0 or +1.
+func (z *Int) Cmp(x *Int) int {
+	for i := 3; i >= 0; i-- {
+		switch {
+		case z[i] < x[i]:
+			return -1
+		case z[i] > x[i]:
+			return 1
+		}
+	}
+	return 0
+}
+
+// Lt reports whether z < x as unsigned numbers.
+func (z *Int) Lt(x *Int) bool {
+	return z.Cmp(x) < 0
+}
+
+// Gt reports whether z > x as unsigned numbers.
+func (z *Int) Gt(x *Int) bool {
+	return z.Cmp(x) > 0
+}
+
+// Slt reports whether z < x as two's complement numbers.
+func (z *Int) Slt(x *Int) bool {
+	zneg, xneg := z.Sign() < 0, x.Sign() < 0
+	if zneg != xneg {
+		return zneg
+	}
+	return z.Cmp(x) < 0
+}
+
+// Sgt reports whether z > x as two's complement numbers.
+func (z *Int) Sgt(x *Int) bool {
+	return x.Slt(z)
+}
+
+// Add sets z to x + y modulo 2^256.
+func (z *Int) Add(x, y *Int) *Int {
+	var c uint64
+	z[0], c = add64(x[0], y[0], 0)
+	z[1], c = add64(x[1], y[1], c)
+	z[2], c = add64(x[2], y[2], c)
+	z[3], _ = add64(x[3], y[3], c)
+	return z
+}
+
+// Sub sets z to x - y modulo 2^256.
+func (z *Int) Sub(x, y *Int) *Int {
+	var b uint64
+	z[0], b = sub64(x[0], y[0], 0)
+	z[1], b = sub64(x[1], y[1], b)
+	z[2], b = sub64(x[2], y[2], b)
+	z[3], _ = sub64(x[3], y[3], b)
+	return z
+}
+
+// Neg sets z to -x modulo 2^256.
+func (z *Int) Neg(x *Int) *Int {
+	return z.Sub(&Int{}, x)
+}
+
+// Abs sets z to the absolute value of x interpreted as a two's complement
+// number. The absolute value of -2^255 is 2^255.
+func (z *Int) Abs(x *Int) *Int {
+	if x.Sign() < 0 {
+		return z.Neg(x)
+	}
+	return z.Set(x)
+}
+
+// Mul sets z to x * y modulo 2^256.
+func (z *Int) Mul(x, y *Int) *Int {
+	p := umul(x, y)
+	copy(z[:], p[:4])
+	return z
+}
+
+// Div sets z to x / y, or 0 if y is 0.
+func (z *Int) Div(x, y *Int) *Int {
+	if y.IsZero() || x.Lt(y) {
+		return z.Clear()
+	}
+	var quot Int
+	udivrem(quot[:], x[:], y)
+	return z.Set(&quot)
+}
+
+// Mod sets z to x modulo y, or 0 if y is 0.
+func (z *Int) Mod(x, y *Int) *Int {
+	if y.IsZero() {
+		return z.Clear()
+	}
+	if x.Lt(y) {
+		return z.Set(x)
+	}
+	var quot Int
+	rem := udivrem(quot[:], x[:], y)
+	return z.Set(&rem)
+}
+
+// SDiv sets z to x / y interpreting both as two's complement numbers and
+// truncating towards zero, or 0 if y is 0.
+func (z *Int) SDiv(x, y *Int) *Int {
+	if y.IsZero() {
+		return z.Clear()
+	}
+	neg := (x.Sign() < 0) != (y.Sign() < 0)
+
+	var ax, ay Int
+	z.Div(ax.Abs(x), ay.Abs(y))
+	if neg {
+		z.Neg(z)
+	}
+	return z
+}
+
+// SMod sets z to x modulo y interpreting both as two's complement numbers,
+// with the result taking the sign of x, or 0 if y is 0.
+func (z *Int) SMod(x, y *Int) *Int {
+	if y.IsZero() {
+		return z.Clear()
+	}
+	neg := x.Sign() < 0
+
+	var ax, ay Int
+	z.Mod(ax.Abs(x), ay.Abs(y))
+	if neg {
+		z.Neg(z)
+	}
+	return z
+}
+
+// AddMod sets z to (x + y) modulo m without truncating the intermediate sum,
+// or 0 if m is 0.
+func (z *Int) AddMod(x, y, m *Int) *Int {
+	if m.IsZero() {
+		return z.Clear()
+	}
+	var (
+		sum [5]uint64
+		c   uint64
+	)
+	sum[0], c = add64(x[0], y[0], 0)
+	sum[1], c = add64(x[1], y[1], c)
+	sum[2], c = add64(x[2], y[2], c)
+	sum[3], c = add64(x[3], y[3], c)
+	sum[4] = c
+
+	var quot [5]uint64
+	rem := udivrem(quot[:], sum[:], m)
+	return z.Set(&rem)
+}
+
+// MulMod sets z to (x * y) modulo m without truncating the intermediate
+// product, or 0 if m is 0.
+func (z *Int) MulMod(x, y, m *Int) *Int {
+	if m.IsZero() {
+		return z.Clear()
+	}
+	p := umul(x, y)
+
+	var quot [8]uint64
+	rem := udivrem(quot[:], p[:], m)
+	return z.Set(&rem)
+}
+
+// Exp sets z to base^exponent modulo 2^256.
+func (z *Int) Exp(base, exponent *Int) *Int {
+	var (
+		res = Int{1}
+		pow = *base
+		n   = exponent.BitLen()
+	)
+	for i := 0; i < n; i++ {
+		if exponent[i/64]&(1<<uint(i%64)) != 0 {
+			res.Mul(&res, &pow)
+		}
+		pow.Mul(&pow, &pow)
+	}
+	return z.Set(&res)
+}
+
+// SignExtend sets z to x sign extended from the byte at position back (counted
+// from the least significant one). Values of back beyond 30 leave x unchanged.
+func (z *Int) SignExtend(back, x *Int) *Int {
+	if !back.IsUint64() || back[0] > 30 {
+		return z.Set(x)
+	}
+	var (
+		bit  = uint(back[0]*8 + 7)
+		word = bit / 64
+		mask = uint64(1)<<(bit%64) - 1 | uint64(1)<<(bit%64)
+	)
+	z.Set(x)
+	if z[word]&(uint64(1)<<(bit%64)) != 0 {
+		z[word] |= ^mask
+		for i := word + 1; i < 4; i++ {
+			z[i] = ^uint64(0)
+		}
+	} else {
+		z[word] &= mask
+		for i := word + 1; i < 4; i++ {
+			z[i] = 0
+		}
+	}
+	return z
+}
+
+// Not sets z to the bitwise complement of x.
+func (z *Int) Not(x *Int) *Int {
+	z[0], z[1], z[2], z[3] = ^x[0], ^x[1], ^x[2], ^x[3]
+	return z
+}
+
+// And sets z to x & y.
+func (z *Int) And(x, y *Int) *Int {
+	z[0], z[1], z[2], z[3] = x[0]&y[0], x[1]&y[1], x[2]&y[2], x[3]&y[3]
+	return z
+}
+
+// Or sets z to x | y.
+func (z *Int) Or(x, y *Int) *Int {
+	z[0], z[1], z[2], z[3] = x[0]|y[0], x[1]|y[1], x[2]|y[2], x[3]|y[3]
+	return z
+}
+
+// Xor sets z to x ^ y.
+func (z *Int) Xor(x, y *Int) *Int {
+	z[0], z[1], z[2], z[3] = x[0]^y[0], x[1]^y[1], x[2]^y[2], x[3]^y[3]
+	return z
+}
+
+// Byte sets z to the n'th byte of x counted from the most significant one, or
+// 0 if n is beyond 31.
+func (z *Int) Byte(n, x *Int) *Int {
+	if !n.IsUint64() || n[0] > 31 {
+		return z.Clear()
+	}
+	b := x.Bytes32()
+	return z.SetUint64(uint64(b[n[0]]))
+}
+
+// umul returns the full 512-bit product of x and y.
+func umul(x, y *Int) (res [8]uint64) {
+	for i := 0; i < 4; i++ {
+		var carry uint64
+		for j := 0; j < 4; j++ {
+			hi, lo := mul64(x[i], y[j])
+
+			var c uint64
+			lo, c = add64(lo, res[i+j], 0)
+			hi += c
+			lo, c = add64(lo, carry, 0)
+			hi += c
+
+			res[i+j], carry = lo, hi
+		}
+		res[i+4] = carry
+	}
+	return res
+}
+
+// udivrem divides the multi-word number u by the non-zero d, storing the
+// quotient into quot (which must hold at least len(u) words) and returning the
+// remainder. The algorithm is Knuth's algorithm D (TAOCP Vol. 2, 4.3.1).
+func udivrem(quot, u []uint64, d *Int) (rem Int) {
+	var dLen int
+	for i := 3; i >= 0; i-- {
+		if d[i] != 0 {
+			dLen = i + 1
+			break
+		}
+	}
+	var uLen int
+	for i := len(u) - 1; i >= 0; i-- {
+		if u[i] != 0 {
+			uLen = i + 1
+			break
+		}
+	}
+	if uLen < dLen {
+		copy(rem[:], u)
+		return rem
+	}
+	// Normalize the divisor so that its top bit is set, and shift the dividend
+	// by the same amount (Go shifts of 64 or more bits yield zero).
+	shift := uint(leadingZeros64(d[dLen-1]))
+
+	var dn Int
+	for i := dLen - 1; i > 0; i-- {
+		dn[i] = d[i]<<shift | d[i-1]>>(64-shift)
+	}
+	dn[0] = d[0] << shift
+
+	var unStorage [9]uint64
+	un := unStorage[:uLen+1]
+	un[uLen] = u[uLen-1] >> (64 - shift)
+	for i := uLen - 1; i > 0; i-- {
+		un[i] = u[i]<<shift | u[i-1]>>(64-shift)
+	}
+	un[0] = u[0] << shift
+
+	if dLen == 1 {
+		r := udivremBy1(quot, un, dn[0])
+		return Int{r >> shift}
+	}
+	udivremKnuth(quot, un, dn[:dLen])
+
+	for i := 0; i < dLen-1; i++ {
+		rem[i] = un[i]>>shift | un[i+1]<<(64-shift)
+	}
+	rem[dLen-1] = un[dLen-1] >> shift
+	return rem
+}
+
+// udivremBy1 divides u by the normalized single word d, storing the quotient
+// into quot and returning the (normalized) remainder.
+func udivremBy1(quot, u []uint64, d uint64) uint64 {
+	rem := u[len(u)-1]
+	for j := len(u) - 2; j >= 0; j-- {
+		quot[j], rem = div64(rem, u[j], d)
+	}
+	return rem
+}
+
+// udivremKnuth divides u by the normalized multi-word d, storing the quotient
+// into quot and leaving the (normalized) remainder in the low words of u.
+func udivremKnuth(quot, u, d []uint64) {
+	var (
+		dh = d[len(d)-1]
+		dl = d[len(d)-2]
+	)
+	for j := len(u) - len(d) - 1; j >= 0; j-- {
+		var (
+			u2 = u[j+len(d)]
+			u1 = u[j+len(d)-1]
+			u0 = u[j+len(d)-2]
+
+			qhat, rhat uint64
+			overflow   bool
+		)
+		// Estimate the quotient digit from the top words, refining it until
+		// it's at most one too large
+		if u2 >= dh {
+			var c uint64
+			qhat = ^uint64(0)
+			rhat, c = add64(u1, dh, 0)
+			overflow = c != 0
+		} else {
+			qhat, rhat = div64(u2, u1, dh)
+		}
+		for !overflow {
+			ph, pl := mul64(qhat, dl)
+			if ph < rhat || (ph == rhat && pl <= u0) {
+				break
+			}
+			qhat--
+
+			var c uint64
+			rhat, c = add64(rhat, dh, 0)
+			overflow = c != 0
+		}
+		// Multiply and subtract, adding back if the estimate was too large
+		borrow := subMulTo(u[j:j+len(d)], d, qhat)
+		u[j+len(d)] = u2 - borrow
+		if u2 < borrow {
+			qhat--
+			u[j+len(d)] += addTo(u[j:j+len(d)], d)
+		}
+		quot[j] = qhat
+	}
+}
+
+// subMulTo computes x -= y * multiplier, returning the borrow.
+func subMulTo(x, y []uint64, multiplier uint64) uint64 {
+	var borrow uint64
+	for i := 0; i < len(y); i++ {
+		s, c1 := sub64(x[i], borrow, 0)
+		ph, pl := mul64(y[i], multiplier)
+		t, c2 := sub64(s, pl, 0)
+		x[i] = t
+		borrow = ph + c1 + c2
+	}
+	return borrow
+}
+
+// addTo computes x += y, returning the carry.
+func addTo(x, y []uint64) uint64 {
+	var carry uint64
+	for i := 0; i < len(y); i++ {
+		x[i], carry = add64(x[i], y[i], carry)
+	}
+	return carry
+}
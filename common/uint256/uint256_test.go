@@ -0,0 +1,270 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package uint256
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+)
+
+var (
+	tt256   = new(big.Int).Lsh(big.NewInt(1), 256)
+	tt256m1 = new(big.Int).Sub(tt256, big.NewInt(1))
+	tt255   = new(big.Int).Lsh(big.NewInt(1), 255)
+)
+
+// randInt generates a random value, biased towards the edge cases of the
+// arithmetic (short numbers, all ones, sign bit only).
+func randInt(rnd *rand.Rand) *big.Int {
+	switch rnd.Intn(8) {
+	case 0:
+		return new(big.Int)
+	case 1:
+		return big.NewInt(rnd.Int63n(4))
+	case 2:
+		return new(big.Int).Set(tt256m1)
+	case 3:
+		return new(big.Int).Set(tt255)
+	case 4:
+		return new(big.Int).Rand(rnd, new(big.Int).Lsh(big.NewInt(1), uint(rnd.Intn(256)+1)))
+	case 5:
+		// Sparse values with long runs of zero words exercise the divisions
+		v := new(big.Int)
+		for i := 0; i < 4; i++ {
+			if rnd.Intn(2) == 0 {
+				v.SetBit(v, rnd.Intn(256), 1)
+			}
+		}
+		return v
+	default:
+		return new(big.Int).Rand(rnd, tt256)
+	}
+}
+
+// u256 truncates a big number to 256 bits.
+func u256(b *big.Int) *big.Int {
+	return b.And(b, tt256m1)
+}
+
+// s256 interprets a 256-bit number as two's complement.
+func s256(b *big.Int) *big.Int {
+	if b.Cmp(tt255) < 0 {
+		return new(big.Int).Set(b)
+	}
+	return new(big.Int).Sub(b, tt256)
+}
+
+type binaryOp struct {
+	name string
+	fn   func(z, x, y *Int) *Int
+	ref  func(x, y *big.Int) *big.Int
+}
+
+var binaryOps = []binaryOp{
+	{"Add", (*Int).Add, func(x, y *big.Int) *big.Int { return u256(new(big.Int).Add(x, y)) }},
+	{"Sub", (*Int).Sub, func(x, y *big.Int) *big.Int { return u256(new(big.Int).Sub(x, y)) }},
+	{"Mul", (*Int).Mul, func(x, y *big.Int) *big.Int { return u256(new(big.Int).Mul(x, y)) }},
+	{"Div", (*Int).Div, func(x, y *big.Int) *big.Int {
+		if y.Sign() == 0 {
+			return new(big.Int)
+		}
+		return new(big.Int).Div(x, y)
+	}},
+	{"Mod", (*Int).Mod, func(x, y *big.Int) *big.Int {
+		if y.Sign() == 0 {
+			return new(big.Int)
+		}
+		return new(big.Int).Mod(x, y)
+	}},
+	{"SDiv", (*Int).SDiv, func(x, y *big.Int) *big.Int {
+		if y.Sign() == 0 {
+			return new(big.Int)
+		}
+		return u256(new(big.Int).Quo(s256(x), s256(y)))
+	}},
+	{"SMod", (*Int).SMod, func(x, y *big.Int) *big.Int {
+		if y.Sign() == 0 {
+			return new(big.Int)
+		}
+		return u256(new(big.Int).Rem(s256(x), s256(y)))
+	}},
+	{"Exp", (*Int).Exp, func(x, y *big.Int) *big.Int { return new(big.Int).Exp(x, y, tt256) }},
+	{"SignExtend", (*Int).SignExtend, func(back, x *big.Int) *big.Int {
+		if back.Cmp(big.NewInt(31)) >= 0 {
+			return new(big.Int).Set(x)
+		}
+		bit := uint(back.Uint64()*8 + 7)
+		mask := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), bit), big.NewInt(1))
+		if x.Bit(int(bit)) == 1 {
+			return u256(new(big.Int).Or(x, new(big.Int).Not(mask)))
+		}
+		return new(big.Int).And(x, mask)
+	}},
+	{"And", (*Int).And, func(x, y *big.Int) *big.Int { return new(big.Int).And(x, y) }},
+	{"Or", (*Int).Or, func(x, y *big.Int) *big.Int { return new(big.Int).Or(x, y) }},
+	{"Xor", (*Int).Xor, func(x, y *big.Int) *big.Int { return new(big.Int).Xor(x, y) }},
+	{"Byte", (*Int).Byte, func(n, x *big.Int) *big.Int {
+		if n.Cmp(big.NewInt(32)) >= 0 {
+			return new(big.Int)
+		}
+		b := make([]byte, 32)
+		x.FillBytes(b)
+		return big.NewInt(int64(b[n.Uint64()]))
+	}},
+}
+
+// Tests the binary operations against their big.Int counterparts.
+func TestBinaryOps(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for _, op := range binaryOps {
+		for i := 0; i < 20000; i++ {
+			x, y := randInt(rnd), randInt(rnd)
+			if op.name == "SignExtend" || op.name == "Byte" {
+				x = big.NewInt(rnd.Int63n(40))
+			}
+			want := op.ref(x, y)
+			if have := op.fn(new(Int), FromBig(x), FromBig(y)); have.ToBig().Cmp(want) != 0 {
+				t.Fatalf("%s(%#x, %#x): have %#x, want %#x", op.name, x, y, have.ToBig(), want)
+			}
+			// Results must be the same if the operands alias the result
+			z := FromBig(x)
+			if have := op.fn(z, z, FromBig(y)); have.ToBig().Cmp(want) != 0 {
+				t.Fatalf("%s(%#x, %#x) aliased: have %#x, want %#x", op.name, x, y, have.ToBig(), want)
+			}
+		}
+	}
+}
+
+// Tests the modular operations against their big.Int counterparts.
+func TestModularOps(t *testing.T) {
+	rnd := rand.New(rand.NewSource(2))
+	for i := 0; i < 50000; i++ {
+		x, y, m := randInt(rnd), randInt(rnd), randInt(rnd)
+
+		want := new(big.Int)
+		if m.Sign() != 0 {
+			want.Mod(new(big.Int).Add(x, y), m)
+		}
+		if have := new(Int).AddMod(FromBig(x), FromBig(y), FromBig(m)); have.ToBig().Cmp(want) != 0 {
+			t.Fatalf("AddMod(%#x, %#x, %#x): have %#x, want %#x", x, y, m, have.ToBig(), want)
+		}
+		want = new(big.Int)
+		if m.Sign() != 0 {
+			want.Mod(new(big.Int).Mul(x, y), m)
+		}
+		if have := new(Int).MulMod(FromBig(x), FromBig(y), FromBig(m)); have.ToBig().Cmp(want) != 0 {
+			t.Fatalf("MulMod(%#x, %#x, %#x): have %#x, want %#x", x, y, m, have.ToBig(), want)
+		}
+	}
+}
+
+// Tests the comparisons and conversions against their big.Int counterparts.
+func TestComparisonsAndConversions(t *testing.T) {
+	rnd := rand.New(rand.NewSource(3))
+	for i := 0; i < 20000; i++ {
+		x, y := randInt(rnd), randInt(rnd)
+		ux, uy := FromBig(x), FromBig(y)
+
+		if have, want := ux.Cmp(uy), x.Cmp(y); have != want {
+			t.Fatalf("Cmp(%#x, %#x): have %d, want %d", x, y, have, want)
+		}
+		if have, want := ux.Slt(uy), s256(x).Cmp(s256(y)) < 0; have != want {
+			t.Fatalf("Slt(%#x, %#x): have %v, want %v", x, y, have, want)
+		}
+		if have, want := ux.Sgt(uy), s256(x).Cmp(s256(y)) > 0; have != want {
+			t.Fatalf("Sgt(%#x, %#x): have %v, want %v", x, y, have, want)
+		}
+		if have, want := ux.BitLen(), x.BitLen(); have != want {
+			t.Fatalf("BitLen(%#x): have %d, want %d", x, have, want)
+		}
+		if have := new(Int).SetBytes(x.Bytes()); !have.Eq(ux) {
+			t.Fatalf("SetBytes(%#x): have %#x", x, have.ToBig())
+		}
+		if have := new(big.Int).SetBytes(ux.Bytes()); have.Cmp(x) != 0 {
+			t.Fatalf("Bytes(%#x): have %#x", x, have)
+		}
+		if have, want := new(Int).Not(ux).ToBig(), u256(new(big.Int).Not(x)); have.Cmp(want) != 0 {
+			t.Fatalf("Not(%#x): have %#x, want %#x", x, have, want)
+		}
+		// Negative numbers convert to their two's complement
+		if have, want := FromBig(new(big.Int).Neg(x)).ToBig(), u256(new(big.Int).Neg(x)); have.Cmp(want) != 0 {
+			t.Fatalf("FromBig(-%#x): have %#x, want %#x", x, have, want)
+		}
+	}
+	// Oversized inputs are truncated to the low 256 bits
+	huge := new(big.Int).Lsh(tt256m1, 8)
+	if have, want := FromBig(huge).ToBig(), u256(new(big.Int).Set(huge)); have.Cmp(want) != 0 {
+		t.Errorf("FromBig(oversized): have %#x, want %#x", have, want)
+	}
+	if have := new(Int).SetBytes(append([]byte{0xff}, make([]byte, 32)...)); !have.IsZero() {
+		t.Errorf("SetBytes(oversized): have %#x, want 0", have.ToBig())
+	}
+}
+
+// Tests the portable double word primitives against math/big.
+func TestWordPrimitives(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	word := func() uint64 {
+		switch rnd.Intn(4) {
+		case 0:
+			return uint64(rnd.Intn(4))
+		case 1:
+			return ^uint64(rnd.Intn(4))
+		default:
+			return uint64(rnd.Int63())<<1 | uint64(rnd.Intn(2))
+		}
+	}
+	tt64 := new(big.Int).Lsh(big.NewInt(1), 64)
+	double := func(hi, lo uint64) *big.Int {
+		x := new(big.Int).Lsh(new(big.Int).SetUint64(hi), 64)
+		return x.Or(x, new(big.Int).SetUint64(lo))
+	}
+	for i := 0; i < 10000; i++ {
+		x, y, c := word(), word(), uint64(rnd.Intn(2))
+
+		sum, carry := add64(x, y, c)
+		want := new(big.Int).SetUint64(x)
+		want.Add(want, new(big.Int).SetUint64(y)).Add(want, new(big.Int).SetUint64(c))
+		if have := double(carry, sum); have.Cmp(want) != 0 {
+			t.Fatalf("add64(%#x, %#x, %d) = %#x, want %#x", x, y, c, have, want)
+		}
+		diff, borrow := sub64(x, y, c)
+		want = new(big.Int).SetUint64(x)
+		want.Sub(want, new(big.Int).SetUint64(y)).Sub(want, new(big.Int).SetUint64(c))
+		if have := new(big.Int).Sub(new(big.Int).SetUint64(diff), new(big.Int).Mul(new(big.Int).SetUint64(borrow), tt64)); have.Cmp(want) != 0 {
+			t.Fatalf("sub64(%#x, %#x, %d) = %#x, want %#x", x, y, c, have, want)
+		}
+		hi, lo := mul64(x, y)
+		want = new(big.Int).Mul(new(big.Int).SetUint64(x), new(big.Int).SetUint64(y))
+		if have := double(hi, lo); have.Cmp(want) != 0 {
+			t.Fatalf("mul64(%#x, %#x) = %#x, want %#x", x, y, have, want)
+		}
+		if y == 0 {
+			continue
+		}
+		hi = x % y // the quotient must fit into a single word
+		quo, rem := div64(hi, lo, y)
+		wantQuo, wantRem := new(big.Int).QuoRem(double(hi, lo), new(big.Int).SetUint64(y), new(big.Int))
+		if quo != wantQuo.Uint64() || rem != wantRem.Uint64() {
+			t.Fatalf("div64(%#x, %#x, %#x) = %#x, %#x, want %#x, %#x", hi, lo, y, quo, rem, wantQuo, wantRem)
+		}
+		if have, want := len64(x), new(big.Int).SetUint64(x).BitLen(); have != want {
+			t.Fatalf("len64(%#x) = %d, want %d", x, have, want)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package release
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/EarthDollar/go-earthdollar/common"
+	"github.com/EarthDollar/go-earthdollar/common/hexutil"
+	"github.com/EarthDollar/go-earthdollar/crypto"
+)
+
+// maxManifestSize is the maximum size of a release manifest accepted.
+const maxManifestSize = 64 * 1024
+
+// errManifestSigner is returned if a release manifest is not signed by the
+// configured release signer.
+var errManifestSigner = errors.New("release manifest not signed by the release signer")
+
+// manifest is a signed release announcement, allowing new releases to be checked
+// without depending on the chain (e.g. for light clients or before sync).
+type manifest struct {
+	Release   json.RawMessage `json:"release"`   // Announced release, exactly as signed
+	Signature hexutil.Bytes   `json:"signature"` // Signature of the Keccak256 hash of Release
+}
+
+// manifestRelease is the release announced in a manifest.
+type manifestRelease struct {
+	Major  uint32        `json:"major"`
+	Minor  uint32        `json:"minor"`
+	Patch  uint32        `json:"patch"`
+	Commit hexutil.Bytes `json:"commit"`
+	URL    string        `json:"url"` // Download page of the release, optional
+}
+
+// verifyManifest parses a release manifest, checking that it was signed by the
+// given address.
+func verifyManifest(blob []byte, signer common.Address) (Version, error) {
+	var m manifest
+	if err := json.Unmarshal(blob, &m); err != nil {
+		return Version{}, fmt.Errorf("invalid release manifest: %v", err)
+	}
+	if len(m.Release) == 0 || len(m.Signature) != 65 {
+		return Version{}, errors.New("incomplete release manifest")
+	}
+	pubkey, err := crypto.SigToPub(crypto.Keccak256(m.Release), m.Signature)
+	if err != nil {
+		return Version{}, fmt.Errorf("invalid release manifest signature: %v", err)
+	}
+	if crypto.PubkeyToAddress(*pubkey) != signer {
+		return Version{}, errManifestSigner
+	}
+	var release manifestRelease
+	if err := json.Unmarshal(m.Release, &release); err != nil {
+		return Version{}, fmt.Errorf("invalid release in manifest: %v", err)
+	}
+	version := Version{Major: release.Major, Minor: release.Minor, Patch: release.Patch, URL: release.URL}
+	copy(version.Commit[:], release.Commit)
+	return version, nil
+}
+
+// fetchManifest downloads the release manifest at the given URL and verifies it
+// against the release signer.
+func fetchManifest(client *http.Client, url string, signer common.Address) (Version, error) {
+	res, err := client.Get(url)
+	if err != nil {
+		return Version{}, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return Version{}, fmt.Errorf("release manifest unavailable: %s", res.Status)
+	}
+	blob, err := ioutil.ReadAll(http.MaxBytesReader(nil, res.Body, maxManifestSize))
+	if err != nil {
+		return Version{}, err
+	}
+	return verifyManifest(blob, signer)
+}
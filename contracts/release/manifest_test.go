@@ -0,0 +1,117 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package release
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/EarthDollar/go-earthdollar/common/hexutil"
+	"github.com/EarthDollar/go-earthdollar/crypto"
+)
+
+// signManifest creates a release manifest announcing the given release.
+func signManifest(t *testing.T, key *ecdsa.PrivateKey, release manifestRelease) []byte {
+	blob, err := json.Marshal(release)
+	if err != nil {
+		t.Fatalf("failed to encode release: %v", err)
+	}
+	sig, err := crypto.Sign(crypto.Keccak256(blob), key)
+	if err != nil {
+		t.Fatalf("failed to sign release: %v", err)
+	}
+	manifest, err := json.Marshal(manifest{Release: blob, Signature: sig})
+	if err != nil {
+		t.Fatalf("failed to encode manifest: %v", err)
+	}
+	return manifest
+}
+
+// Tests that release manifests are only accepted if signed by the release signer.
+func TestManifestVerification(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	signer := crypto.PubkeyToAddress(key.PublicKey)
+
+	release := manifestRelease{Major: 1, Minor: 6, Patch: 2, Commit: hexutil.Bytes{0xde, 0xad}, URL: "https://example.com"}
+	blob := signManifest(t, key, release)
+
+	version, err := verifyManifest(blob, signer)
+	if err != nil {
+		t.Fatalf("failed to verify manifest: %v", err)
+	}
+	if version.String() != "v1.6.2-dead0000" || version.URL != release.URL {
+		t.Errorf("release mismatch: have %v (%s), want v1.6.2-dead0000 (%s)", version, version.URL, release.URL)
+	}
+	// Manifests signed by anyone else must be rejected
+	other, _ := crypto.GenerateKey()
+	if _, err := verifyManifest(signManifest(t, other, release), signer); err != errManifestSigner {
+		t.Errorf("foreign signature: have error %v, want %v", err, errManifestSigner)
+	}
+	// Tampering with the release must invalidate the signature
+	var m manifest
+	json.Unmarshal(blob, &m)
+	m.Release, _ = json.Marshal(manifestRelease{Major: 9})
+	tampered, _ := json.Marshal(m)
+	if _, err := verifyManifest(tampered, signer); err == nil {
+		t.Errorf("tampered manifest accepted")
+	}
+}
+
+// Tests that the release service reports outdated clients based on a manifest.
+func TestManifestReleaseCheck(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	blob := signManifest(t, key, manifestRelease{Major: 1, Minor: 6, Patch: 0})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(blob)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		major, minor, patch uint32
+		outdated            bool
+	}{
+		{1, 5, 9, true},
+		{0, 9, 9, true},
+		{1, 6, 0, false},
+		{1, 6, 1, false},
+		{2, 0, 0, false},
+	}
+	for i, tt := range tests {
+		config := Config{Manifest: server.URL, Signer: crypto.PubkeyToAddress(key.PublicKey), Major: tt.major, Minor: tt.minor, Patch: tt.patch}
+		service, err := NewReleaseService(nil, config)
+		if err != nil {
+			t.Fatalf("test %d: failed to create release service: %v", i, err)
+		}
+		r := service.(*ReleaseService)
+		r.check()
+
+		status := r.Status()
+		if status.Error != "" {
+			t.Fatalf("test %d: release check failed: %s", i, status.Error)
+		}
+		if status.Outdated != tt.outdated {
+			t.Errorf("test %d: outdated mismatch: have %v, want %v", i, status.Outdated, tt.outdated)
+		}
+		if status.Latest != "v1.6.0-00000000" || status.URL != defaultReleaseURL {
+			t.Errorf("test %d: latest release mismatch: have %s (%s)", i, status.Latest, status.URL)
+		}
+	}
+}
@@ -21,7 +21,9 @@ package release
 
 import (
 	"fmt"
+	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/EarthDollar/go-earthdollar/accounts/abi/bind"
@@ -37,30 +39,92 @@ import (
 	"golang.org/x/net/context"
 )
 
-// Interval to check for new releases
-const releaseRecheckInterval = time.Hour
+const (
+	releaseRecheckInterval = time.Hour        // Interval to check for new releases
+	releaseCheckTimeout    = 10 * time.Second // Maximum time allowed for a single check
+)
+
+// defaultReleaseURL is the page users are pointed to if the release source does
+// not specify where to get the new release from.
+const defaultReleaseURL = "https://github.com/EarthDollar/go-earthdollar/releases"
 
 // Config contains the configurations of the release service.
 type Config struct {
-	Oracle common.Address // Ethereum address of the release oracle
-	Major  uint32         // Major version component of the release
-	Minor  uint32         // Minor version component of the release
-	Patch  uint32         // Patch version component of the release
-	Commit [20]byte       // Git SHA1 commit hash of the release
+	Oracle   common.Address // Ethereum address of the release oracle
+	Manifest string         // URL of a signed release manifest, checked instead of the oracle if set
+	Signer   common.Address // Address of the key the release manifest must be signed with
+	Major    uint32         // Major version component of the release
+	Minor    uint32         // Minor version component of the release
+	Patch    uint32         // Patch version component of the release
+	Commit   [20]byte       // Git SHA1 commit hash of the release
+}
+
+// Version is a client release, either the running one or an announced one.
+type Version struct {
+	Major  uint32
+	Minor  uint32
+	Patch  uint32
+	Commit [20]byte
+	URL    string // Download page of the release, if known
+}
+
+// String implements fmt.Stringer.
+func (v Version) String() string {
+	return fmt.Sprintf("v%d.%d.%d-%x", v.Major, v.Minor, v.Patch, v.Commit[:4])
+}
+
+// newer reports whether the version is a later release than other.
+func (v Version) newer(other Version) bool {
+	if v.Major != other.Major {
+		return v.Major > other.Major
+	}
+	if v.Minor != other.Minor {
+		return v.Minor > other.Minor
+	}
+	return v.Patch > other.Patch
+}
+
+// Status is the outcome of the most recent release check.
+type Status struct {
+	Current  string    `json:"current"`  // Version of the running client
+	Latest   string    `json:"latest"`   // Latest announced release, empty if not yet known
+	Outdated bool      `json:"outdated"` // Whether a newer release than the running one exists
+	URL      string    `json:"url"`      // Download page of the latest release
+	Source   string    `json:"source"`   // Oracle address or manifest URL checked
+	Checked  time.Time `json:"checked"`  // Time of the last successful check
+	Error    string    `json:"error"`    // Failure of the last check, if any
 }
 
-// ReleaseService is a node service that periodically checks the blockchain for
-// newly released versions of the client being run and issues a warning to the
-// user about it.
+// ReleaseService is a node service that periodically checks the blockchain (or
+// a signed release manifest) for newly released versions of the client being
+// run and issues a warning to the user about it.
 type ReleaseService struct {
-	config Config          // Current version to check releases against
-	oracle *ReleaseOracle  // Native binding to the release oracle contract
-	quit   chan chan error // Quit channel to terminate the version checker
+	config  Config          // Current version to check releases against
+	current Version         // Version of the running client
+	oracle  *ReleaseOracle  // Native binding to the release oracle contract (nil if using a manifest)
+	client  *http.Client    // HTTP client to retrieve the release manifest with
+	quit    chan chan error // Quit channel to terminate the version checker
+
+	status Status // Outcome of the last release check
+	lock   sync.RWMutex
 }
 
 // NewReleaseService creates a new service to periodically check for new client
 // releases and notify the user of such.
 func NewReleaseService(ctx *node.ServiceContext, config Config) (node.Service, error) {
+	service := &ReleaseService{
+		config:  config,
+		current: Version{Major: config.Major, Minor: config.Minor, Patch: config.Patch, Commit: config.Commit},
+		quit:    make(chan chan error),
+	}
+	service.status = Status{Current: service.current.String()}
+
+	// Manifests are fetched over HTTP, no chain needed
+	if config.Manifest != "" {
+		service.client = &http.Client{Timeout: releaseCheckTimeout}
+		service.status.Source = config.Manifest
+		return service, nil
+	}
 	// Retrieve the Ethereum service dependency to access the blockchain
 	var apiBackend ethapi.Backend
 	var ethereum *eth.Ethereum
@@ -79,20 +143,26 @@ func NewReleaseService(ctx *node.ServiceContext, config Config) (node.Service, e
 	if err != nil {
 		return nil, err
 	}
-	return &ReleaseService{
-		config: config,
-		oracle: contract,
-		quit:   make(chan chan error),
-	}, nil
+	service.oracle = contract
+	service.status.Source = config.Oracle.Hex()
+	return service, nil
 }
 
 // Protocols returns an empty list of P2P protocols as the release service does
 // not have a networking component.
 func (r *ReleaseService) Protocols() []p2p.Protocol { return nil }
 
-// APIs returns an empty list of RPC descriptors as the release service does not
-// expose any functioanlity to the outside world.
-func (r *ReleaseService) APIs() []rpc.API { return nil }
+// APIs returns the RPC descriptors exposing the outcome of the release checks.
+func (r *ReleaseService) APIs() []rpc.API {
+	return []rpc.API{
+		{
+			Namespace: "release",
+			Version:   "1.0",
+			Service:   NewPublicReleaseAPI(r),
+			Public:    true,
+		},
+	}
+}
 
 // Start spawns the periodic version checker goroutine
 func (r *ReleaseService) Start(server *p2p.Server) error {
@@ -108,6 +178,14 @@ func (r *ReleaseService) Stop() error {
 	return <-errc
 }
 
+// Status returns the outcome of the most recent release check.
+func (r *ReleaseService) Status() Status {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	return r.status
+}
+
 // checker runs indefinitely in the background, periodically checking for new
 // client releases.
 func (r *ReleaseService) checker() {
@@ -121,37 +199,7 @@ func (r *ReleaseService) checker() {
 		case <-timer.C:
 			// Rechedule the timer before continuing
 			timer.Reset(releaseRecheckInterval)
-
-			// Retrieve the current version, and handle missing contracts gracefully
-			ctx, _ := context.WithTimeout(context.Background(), time.Second*5)
-			opts := &bind.CallOpts{Context: ctx}
-			version, err := r.oracle.CurrentVersion(opts)
-			if err != nil {
-				if err == bind.ErrNoCode {
-					glog.V(logger.Debug).Infof("Release oracle not found at %x", r.config.Oracle)
-					continue
-				}
-				glog.V(logger.Error).Infof("Failed to retrieve current release: %v", err)
-				continue
-			}
-			// Version was successfully retrieved, notify if newer than ours
-			if version.Major > r.config.Major ||
-				(version.Major == r.config.Major && version.Minor > r.config.Minor) ||
-				(version.Major == r.config.Major && version.Minor == r.config.Minor && version.Patch > r.config.Patch) {
-
-				warning := fmt.Sprintf("Client v%d.%d.%d-%x seems older than the latest upstream release v%d.%d.%d-%x",
-					r.config.Major, r.config.Minor, r.config.Patch, r.config.Commit[:4], version.Major, version.Minor, version.Patch, version.Commit[:4])
-				howtofix := fmt.Sprintf("Please check https://github.com/EarthDollar/go-earthdollar/releases for new releases")
-				separator := strings.Repeat("-", len(warning))
-
-				glog.V(logger.Warn).Info(separator)
-				glog.V(logger.Warn).Info(warning)
-				glog.V(logger.Warn).Info(howtofix)
-				glog.V(logger.Warn).Info(separator)
-			} else {
-				glog.V(logger.Debug).Infof("Client v%d.%d.%d-%x seems up to date with upstream v%d.%d.%d-%x",
-					r.config.Major, r.config.Minor, r.config.Patch, r.config.Commit[:4], version.Major, version.Minor, version.Patch, version.Commit[:4])
-			}
+			r.check()
 
 		// If termination was requested, return
 		case errc := <-r.quit:
@@ -160,3 +208,74 @@ func (r *ReleaseService) checker() {
 		}
 	}
 }
+
+// latest retrieves the most recent release from the configured source.
+func (r *ReleaseService) latest() (Version, error) {
+	if r.oracle == nil {
+		return fetchManifest(r.client, r.config.Manifest, r.config.Signer)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), releaseCheckTimeout)
+	defer cancel()
+
+	version, err := r.oracle.CurrentVersion(&bind.CallOpts{Context: ctx})
+	if err != nil {
+		return Version{}, err
+	}
+	return Version{Major: version.Major, Minor: version.Minor, Patch: version.Patch, Commit: version.Commit}, nil
+}
+
+// check retrieves the latest release, updating the status and warning the user
+// if the running client is outdated.
+func (r *ReleaseService) check() {
+	version, err := r.latest()
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if err != nil {
+		r.status.Error = err.Error()
+		// Handle missing contracts gracefully
+		if err == bind.ErrNoCode {
+			glog.V(logger.Debug).Infof("Release oracle not found at %x", r.config.Oracle)
+			return
+		}
+		glog.V(logger.Error).Infof("Failed to retrieve current release: %v", err)
+		return
+	}
+	if version.URL == "" {
+		version.URL = defaultReleaseURL
+	}
+	r.status.Latest, r.status.URL = version.String(), version.URL
+	r.status.Outdated = version.newer(r.current)
+	r.status.Checked, r.status.Error = time.Now(), ""
+
+	// Version was successfully retrieved, notify if newer than ours
+	if r.status.Outdated {
+		warning := fmt.Sprintf("Client %v seems older than the latest upstream release %v", r.current, version)
+		howtofix := fmt.Sprintf("Please check %s for new releases", version.URL)
+		separator := strings.Repeat("-", len(warning))
+
+		glog.V(logger.Warn).Info(separator)
+		glog.V(logger.Warn).Info(warning)
+		glog.V(logger.Warn).Info(howtofix)
+		glog.V(logger.Warn).Info(separator)
+	} else {
+		glog.V(logger.Debug).Infof("Client %v seems up to date with upstream %v", r.current, version)
+	}
+}
+
+// PublicReleaseAPI exposes the outcome of the release checks over RPC, allowing
+// monitoring to flag nodes running outdated clients.
+type PublicReleaseAPI struct {
+	service *ReleaseService
+}
+
+// NewPublicReleaseAPI creates a new API for the given release service.
+func NewPublicReleaseAPI(service *ReleaseService) *PublicReleaseAPI {
+	return &PublicReleaseAPI{service: service}
+}
+
+// Status returns the running and the latest known client versions.
+func (api *PublicReleaseAPI) Status() Status {
+	return api.service.Status()
+}
@@ -44,19 +44,32 @@ import (
 	"github.com/EarthDollar/go-earthdollar/rlp"
 	"github.com/EarthDollar/go-earthdollar/trie"
 	"github.com/hashicorp/golang-lru"
+	"gopkg.in/karalabe/cookiejar.v2/collections/prque"
 )
 
 var (
 	blockInsertTimer = metrics.NewTimer("chain/inserts")
 
+	reorgExecuteMeter = metrics.NewMeter("chain/reorg/executes") // Number of chain reorganisations
+	reorgDropMeter    = metrics.NewMeter("chain/reorg/drop")     // Number of blocks dropped from the canonical chain
+	reorgAddMeter     = metrics.NewMeter("chain/reorg/add")      // Number of blocks added to the canonical chain
+	reorgDepthGauge   = metrics.NewGauge("chain/reorg/depth")    // Number of blocks dropped by the last reorganisation
+
+	stateFlushTimer = metrics.NewTimer("chain/state/flushes") // Time spent flushing buffered state to disk
+
 	ErrNoGenesis = errors.New("Genesis not found in chain")
 )
 
 const (
 	bodyCacheLimit      = 256
 	blockCacheLimit     = 256
+	receiptsCacheLimit  = 32
 	maxFutureBlocks     = 256
 	maxTimeFutureBlocks = 30
+	badBlockLimit       = 16
+
+	triesInMemory    = 16                // Number of recent state tries retained in memory on a state flush
+	stateBufferLimit = 256 * 1024 * 1024 // Memory allowance of the state buffer before forcing a flush
 	// must be bumped when consensus algorithm is changed, this forces the upgradedb
 	// command to be run (forces the blocks to be imported again using the new algorithm)
 	BlockChainVersion = 3
@@ -79,10 +92,17 @@ const (
 type BlockChain struct {
 	config *params.ChainConfig // chain & network configuration
 
-	hc           *HeaderChain
-	chainDb      ethdb.Database
-	eventMux     *event.TypeMux
-	genesisBlock *types.Block
+	hc            *HeaderChain
+	chainDb       ethdb.Database
+	stateDb       ethdb.Database // Database of the state tries (chainDb or a node buffer in front of it)
+	eventMux      *event.TypeMux
+	chainFeed     event.Feed
+	chainSideFeed event.Feed
+	chainHeadFeed event.Feed
+	logsFeed      event.Feed
+	rmLogsFeed    event.Feed
+	scope         event.SubscriptionScope
+	genesisBlock  *types.Block
 
 	mu      sync.RWMutex // global mutex for locking chain operations
 	chainmu sync.RWMutex // blockchain insertion lock
@@ -92,11 +112,25 @@ type BlockChain struct {
 	currentBlock     *types.Block // Current head of the block chain
 	currentFastBlock *types.Block // Current head of the fast-sync chain (may be above the block chain!)
 
-	stateCache   *state.StateDB // State database to reuse between imports (contains state cache)
-	bodyCache    *lru.Cache     // Cache for the most recent block bodies
-	bodyRLPCache *lru.Cache     // Cache for the most recent block bodies in RLP encoded format
-	blockCache   *lru.Cache     // Cache for the most recent entire blocks
-	futureBlocks *lru.Cache     // future blocks are blocks added for later processing
+	stateCache    *state.StateDB // State database to reuse between imports (contains state cache)
+	bodyCache     *lru.Cache     // Cache for the most recent block bodies
+	bodyRLPCache  *lru.Cache     // Cache for the most recent block bodies in RLP encoded format
+	blockCache    *lru.Cache     // Cache for the most recent entire blocks
+	receiptsCache *lru.Cache     // Cache for the most recent block receipts
+	futureBlocks  *lru.Cache     // future blocks are blocks added for later processing
+	badBlocks     *lru.Cache     // Cache for the most recently rejected blocks
+
+	stateBuffer   *trie.NodeBuffer // In-memory state trie buffer if state garbage collection is enabled
+	flushInterval uint64           // Number of blocks between flushing the buffered state to disk
+	lastFlush     uint64           // Number of the block whose state was last flushed to disk
+	recentRoots   *prque.Prque     // State roots of the recently written blocks, canonical or not (number sorted)
+	triePreimages bool             // Whether to record the preimages of all accessed state trie keys
+	trieCacheGen  uint16           // Trie cache generation limit of the state cache (0 = default)
+	maxPastTries  int              // Number of past tries retained by the state cache (0 = default)
+
+	txLookupLimit uint64      // Number of recent blocks to keep transactions indexed for (0 = entire chain)
+	txLookupHeads chan uint64 // Notification channel of new heads for the transaction index maintenance
+	supplyHeads   chan uint64 // Notification channel of new heads for the total supply maintenance
 
 	quit    chan struct{} // blockchain quit channel
 	running int32         // running must be called atomically
@@ -104,10 +138,11 @@ type BlockChain struct {
 	procInterrupt int32          // interrupt signaler for block processing
 	wg            sync.WaitGroup // chain processing wait group for shutting down
 
-	pow       pow.PoW
-	processor Processor // block processor interface
-	validator Validator // block and state validator interface
-	vmConfig  vm.Config
+	pow        pow.PoW
+	processor  Processor        // block processor interface
+	prefetcher *statePrefetcher // state trie warmer running alongside the processor
+	validator  Validator        // block and state validator interface
+	vmConfig   vm.Config
 }
 
 // NewBlockChain returns a fully initialised block chain using information
@@ -117,22 +152,28 @@ func NewBlockChain(chainDb ethdb.Database, config *params.ChainConfig, pow pow.P
 	bodyCache, _ := lru.New(bodyCacheLimit)
 	bodyRLPCache, _ := lru.New(bodyCacheLimit)
 	blockCache, _ := lru.New(blockCacheLimit)
+	receiptsCache, _ := lru.New(receiptsCacheLimit)
 	futureBlocks, _ := lru.New(maxFutureBlocks)
+	badBlocks, _ := lru.New(badBlockLimit)
 
 	bc := &BlockChain{
-		config:       config,
-		chainDb:      chainDb,
-		eventMux:     mux,
-		quit:         make(chan struct{}),
-		bodyCache:    bodyCache,
-		bodyRLPCache: bodyRLPCache,
-		blockCache:   blockCache,
-		futureBlocks: futureBlocks,
-		pow:          pow,
-		vmConfig:     vmConfig,
+		config:        config,
+		chainDb:       chainDb,
+		stateDb:       chainDb,
+		eventMux:      mux,
+		quit:          make(chan struct{}),
+		bodyCache:     bodyCache,
+		bodyRLPCache:  bodyRLPCache,
+		blockCache:    blockCache,
+		receiptsCache: receiptsCache,
+		futureBlocks:  futureBlocks,
+		badBlocks:     badBlocks,
+		pow:           pow,
+		vmConfig:      vmConfig,
 	}
 	bc.SetValidator(NewBlockValidator(config, bc, pow))
 	bc.SetProcessor(NewStateProcessor(config, bc))
+	bc.prefetcher = newStatePrefetcher(config, bc.stateDb)
 
 	gv := func() HeaderValidator { return bc.Validator() }
 	var err error
@@ -162,6 +203,13 @@ func NewBlockChain(chainDb ethdb.Database, config *params.ChainConfig, pow pow.P
 			}
 		}
 	}
+	// Fill in any missing total supply entries in the background
+	bc.supplyHeads = make(chan uint64, 1)
+	bc.supplyHeads <- bc.currentBlock.NumberU64()
+
+	bc.wg.Add(1)
+	go bc.totalSupplyLoop()
+
 	// Take ownership of this particular state
 	go bc.update()
 	return bc, nil
@@ -203,8 +251,14 @@ func (self *BlockChain) loadLastState() error {
 			self.currentFastBlock = block
 		}
 	}
+	// Make sure the state of the head block is available, rewinding if corrupted
+	if _, err := state.New(self.currentBlock.Root(), self.stateDb); err != nil {
+		if err := self.repair(); err != nil {
+			return err
+		}
+	}
 	// Initialize a statedb cache to ensure singleton account bloom filter generation
-	statedb, err := state.New(self.currentBlock.Root(), self.chainDb)
+	statedb, err := state.New(self.currentBlock.Root(), self.stateDb)
 	if err != nil {
 		return err
 	}
@@ -223,6 +277,31 @@ func (self *BlockChain) loadLastState() error {
 	return nil
 }
 
+// repair tries to recover from a corrupted head block whose state is missing by
+// rewinding the chain to the most recent block with its state fully available.
+// This method assumes that the chain manager mutex is held.
+func (self *BlockChain) repair() error {
+	block := self.currentBlock
+	for {
+		if _, err := state.New(block.Root(), self.stateDb); err == nil {
+			break
+		}
+		if block.NumberU64() == 0 {
+			return fmt.Errorf("missing genesis state [%x…]", block.Root().Bytes()[:4])
+		}
+		parent := self.GetBlock(block.ParentHash(), block.NumberU64()-1)
+		if parent == nil {
+			return fmt.Errorf("missing block #%d [%x…]", block.NumberU64()-1, block.ParentHash().Bytes()[:4])
+		}
+		block = parent
+	}
+	glog.V(logger.Error).Infof("Head state missing, rewinding chain from #%d [%x…] to #%d [%x…]",
+		self.currentBlock.Number(), self.currentBlock.Hash().Bytes()[:4], block.Number(), block.Hash().Bytes()[:4])
+
+	self.rewind(block.NumberU64())
+	return nil
+}
+
 // SetHead rewinds the local chain to a new head. In the case of headers, everything
 // above the new head will be deleted and the new one set. In the case of blocks
 // though, the head may be further rewound if block bodies are missing (non-archive
@@ -231,15 +310,46 @@ func (bc *BlockChain) SetHead(head uint64) {
 	bc.mu.Lock()
 	defer bc.mu.Unlock()
 
+	bc.rewind(head)
+	bc.loadLastState()
+}
+
+// rewind truncates the chain down to the given head, deleting the canonical
+// markers, bodies, receipts and transaction lookup entries of all blocks above
+// it, together with every MIP bloom bin fully above the new head. Bins spanning
+// the new head are kept, as they can only produce false positives. This method
+// assumes that the chain manager mutex is held.
+func (bc *BlockChain) rewind(head uint64) {
+	height := bc.hc.CurrentHeader().Number.Uint64()
+
 	delFn := func(hash common.Hash, num uint64) {
+		if body := GetBody(bc.chainDb, hash, num); body != nil {
+			for _, tx := range body.Transactions {
+				// Only drop lookups pointing into the rewound block, the same
+				// transaction may be included in the retained chain too
+				if _, blockHash, _, _ := GetTransaction(bc.chainDb, tx.Hash()); blockHash == hash {
+					DeleteTransaction(bc.chainDb, tx.Hash())
+					DeleteReceipt(bc.chainDb, tx.Hash())
+				}
+			}
+		}
 		DeleteBody(bc.chainDb, hash, num)
+		DeleteBlockReceipts(bc.chainDb, hash, num)
+		DeleteTotalSupply(bc.chainDb, hash, num)
 	}
 	bc.hc.SetHead(head, delFn)
 
+	for _, level := range MIPMapLevels {
+		for bin := (head/level + 1) * level; bin <= height; bin += level {
+			DeleteMipmapBloom(bc.chainDb, bin, level)
+		}
+	}
+
 	// Clear out any stale content from the caches
 	bc.bodyCache.Purge()
 	bc.bodyRLPCache.Purge()
 	bc.blockCache.Purge()
+	bc.receiptsCache.Purge()
 	bc.futureBlocks.Purge()
 
 	// Update all computed fields to the new head
@@ -264,7 +374,6 @@ func (bc *BlockChain) SetHead(head uint64) {
 	if err := WriteHeadFastBlockHash(bc.chainDb, bc.currentFastBlock.Hash()); err != nil {
 		glog.Fatalf("failed to reset head fast block hash: %v", err)
 	}
-	bc.loadLastState()
 }
 
 // FastSyncCommitHead sets the current head block to the one defined by the hash
@@ -400,6 +509,31 @@ func (bc *BlockChain) ResetWithGenesisBlock(genesis *types.Block) {
 	bc.currentFastBlock = bc.genesisBlock
 }
 
+// ExportProgressFn is invoked during chain exports after every written block,
+// with the number of blocks exported so far and the total number to export.
+type ExportProgressFn func(exported, total uint64)
+
+// NewExportLogger creates an export progress callback logging the number of
+// blocks exported and the estimated time remaining at most once per interval.
+func NewExportLogger(interval time.Duration) ExportProgressFn {
+	var (
+		start  = time.Now()
+		logged = start
+	)
+	return func(exported, total uint64) {
+		now := time.Now()
+		if exported < total && now.Sub(logged) < interval {
+			return
+		}
+		logged = now
+
+		elapsed := now.Sub(start)
+		eta := time.Duration(float64(elapsed) * float64(total-exported) / float64(exported))
+		glog.V(logger.Info).Infof("exported %d/%d blocks (%.2f%%) in %v, eta %v", exported, total,
+			100*float64(exported)/float64(total), common.PrettyDuration(elapsed), common.PrettyDuration(eta))
+	}
+}
+
 // Export writes the active chain to the given writer.
 func (self *BlockChain) Export(w io.Writer) error {
 	return self.ExportN(w, uint64(0), self.currentBlock.NumberU64())
@@ -407,6 +541,12 @@ func (self *BlockChain) Export(w io.Writer) error {
 
 // ExportN writes a subset of the active chain to the given writer.
 func (self *BlockChain) ExportN(w io.Writer, first uint64, last uint64) error {
+	return self.ExportRange(w, first, last, nil)
+}
+
+// ExportRange writes a subset of the active chain to the given writer, reporting
+// the progress through the optional callback.
+func (self *BlockChain) ExportRange(w io.Writer, first uint64, last uint64, progress ExportProgressFn) error {
 	self.mu.RLock()
 	defer self.mu.RUnlock()
 
@@ -416,6 +556,7 @@ func (self *BlockChain) ExportN(w io.Writer, first uint64, last uint64) error {
 
 	glog.V(logger.Info).Infof("exporting %d blocks...\n", last-first+1)
 
+	total := last - first + 1
 	for nr := first; nr <= last; nr++ {
 		block := self.GetBlockByNumber(nr)
 		if block == nil {
@@ -425,6 +566,9 @@ func (self *BlockChain) ExportN(w io.Writer, first uint64, last uint64) error {
 		if err := block.EncodeRLP(w); err != nil {
 			return err
 		}
+		if progress != nil {
+			progress(nr-first+1, total)
+		}
 	}
 
 	return nil
@@ -448,6 +592,8 @@ func (bc *BlockChain) insert(block *types.Block) {
 		glog.Fatalf("failed to insert head block hash: %v", err)
 	}
 	bc.currentBlock = block
+	bc.notifyTxLookups(block.NumberU64())
+	bc.notifyTotalSupply(block.NumberU64())
 
 	// If the block is better than out head or is on a different chain, force update heads
 	if updateHeads {
@@ -498,12 +644,40 @@ func (self *BlockChain) GetBodyRLP(hash common.Hash) rlp.RawValue {
 	return body
 }
 
+// GetReceiptsByHash retrieves the receipts of all the transactions in a block,
+// caching them if found. The stored receipts already contain all the derived
+// fields, so they are returned as is without any per receipt lookups.
+func (bc *BlockChain) GetReceiptsByHash(hash common.Hash) types.Receipts {
+	// Short circuit if the receipts are already in the cache, retrieve otherwise
+	if cached, ok := bc.receiptsCache.Get(hash); ok {
+		return cached.(types.Receipts)
+	}
+	number := bc.hc.GetBlockNumber(hash)
+	if number == missingNumber {
+		return nil
+	}
+	receipts := GetBlockReceipts(bc.chainDb, hash, number)
+	if receipts == nil {
+		return nil
+	}
+	// Cache the found receipts for next time and return
+	bc.receiptsCache.Add(hash, receipts)
+	return receipts
+}
+
 // HasBlock checks if a block is fully present in the database or not, caching
 // it if present.
 func (bc *BlockChain) HasBlock(hash common.Hash) bool {
 	return bc.GetBlockByHash(hash) != nil
 }
 
+// StateDatabase returns the database holding the state tries, which may be an
+// in-memory buffer in front of the chain database if state garbage collection
+// is enabled.
+func (bc *BlockChain) StateDatabase() ethdb.Database {
+	return bc.stateDb
+}
+
 // HasBlockAndState checks if a block and associated state trie is fully present
 // in the database or not, caching it if present.
 func (bc *BlockChain) HasBlockAndState(hash common.Hash) bool {
@@ -513,7 +687,7 @@ func (bc *BlockChain) HasBlockAndState(hash common.Hash) bool {
 		return false
 	}
 	// Ensure the associated state is also present
-	_, err := state.New(block.Root(), bc.chainDb)
+	_, err := state.New(block.Root(), bc.stateDb)
 	return err == nil
 }
 
@@ -581,11 +755,21 @@ func (bc *BlockChain) Stop() {
 	if !atomic.CompareAndSwapInt32(&bc.running, 0, 1) {
 		return
 	}
+	// Unsubscribe all subscriptions registered from the chain
+	bc.scope.Close()
 	close(bc.quit)
 	atomic.StoreInt32(&bc.procInterrupt, 1)
 
 	bc.wg.Wait()
 
+	// Persist the buffered state of the head block, everything else is lost
+	if bc.stateBuffer != nil {
+		bc.mu.Lock()
+		if _, _, err := state.FlushState(bc.stateBuffer, bc.currentBlock.Root(), nil); err != nil {
+			glog.V(logger.Error).Infof("Failed to flush head state: %v", err)
+		}
+		bc.mu.Unlock()
+	}
 	glog.V(logger.Info).Infoln("Chain manager stopped")
 }
 
@@ -695,6 +879,13 @@ func (self *BlockChain) InsertReceiptChain(blockChain types.Blocks, receiptChain
 	stats := struct{ processed, ignored int32 }{}
 	start := time.Now()
 
+	// Blocks too old to be retained in the transaction lookup index aren't indexed
+	var lookupTail uint64
+	if limit := self.txLookupLimit; limit > 0 {
+		if head := self.hc.CurrentHeader().Number.Uint64(); head >= limit {
+			lookupTail = head - limit + 1
+		}
+	}
 	// Create the block importing task queue and worker functions
 	tasks := make(chan int, len(blockChain))
 	for i := 0; i < len(blockChain) && i < len(receiptChain); i++ {
@@ -746,11 +937,13 @@ func (self *BlockChain) InsertReceiptChain(blockChain types.Blocks, receiptChain
 				glog.Fatal(errs[index])
 				return
 			}
-			if err := WriteTransactions(self.chainDb, block); err != nil {
-				errs[index] = fmt.Errorf("failed to write individual transactions: %v", err)
-				atomic.AddInt32(&failed, 1)
-				glog.Fatal(errs[index])
-				return
+			if block.NumberU64() >= lookupTail {
+				if err := WriteTransactions(self.chainDb, block); err != nil {
+					errs[index] = fmt.Errorf("failed to write individual transactions: %v", err)
+					atomic.AddInt32(&failed, 1)
+					glog.Fatal(errs[index])
+					return
+				}
 			}
 			if err := WriteReceipts(self.chainDb, receipts); err != nil {
 				errs[index] = fmt.Errorf("failed to write individual receipts: %v", err)
@@ -831,7 +1024,12 @@ func (self *BlockChain) WriteBlock(block *types.Block) (status WriteStatus, err
 	if err := WriteBlock(self.chainDb, block); err != nil {
 		glog.Fatalf("failed to write block contents: %v", err)
 	}
+	self.writeTotalSupply(block)
 
+	// Track the state root for retention on flushes, even if the block ends up on a side chain
+	if self.stateBuffer != nil {
+		self.recentRoots.Push(block.Root(), -float32(block.NumberU64()))
+	}
 	// If the total difficulty is higher than our known, add it to the canonical chain
 	// Second clause in the if statement reduces the vulnerability to selfish mining.
 	// Please refer to http://www.cs.cornell.edu/~ie53/publications/btcProcFC.pdf
@@ -844,6 +1042,10 @@ func (self *BlockChain) WriteBlock(block *types.Block) (status WriteStatus, err
 		}
 		self.insert(block) // Insert the block as the new head of the chain
 		status = CanonStatTy
+
+		if err := self.flushState(block); err != nil {
+			glog.Fatalf("failed to flush state: %v", err)
+		}
 	} else {
 		status = SideStatTy
 	}
@@ -853,6 +1055,110 @@ func (self *BlockChain) WriteBlock(block *types.Block) (status WriteStatus, err
 	return
 }
 
+// EnableStateGC switches the chain from archive mode, where the state of every
+// block is persisted, to keeping the recent state tries in memory and flushing
+// them to disk only every interval blocks (or when the buffer grows too large),
+// garbage collecting the intermediate ones. Should the node crash, the chain is
+// rewound on the next startup to the last block with its state flushed.
+//
+// It must be called before any blocks are processed.
+func (bc *BlockChain) EnableStateGC(interval uint64) error {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	if interval == 0 || bc.stateBuffer != nil {
+		return nil
+	}
+	buffer := trie.NewNodeBuffer(bc.chainDb)
+	statedb, err := state.New(bc.currentBlock.Root(), buffer)
+	if err != nil {
+		return err
+	}
+	bc.stateBuffer, bc.stateDb = buffer, buffer
+	bc.flushInterval, bc.lastFlush = interval, bc.currentBlock.NumberU64()
+	bc.recentRoots = prque.New()
+	bc.stateCache = statedb
+	bc.stateCache.RecordTriePreimages(bc.triePreimages)
+	bc.stateCache.SetCacheLimits(bc.trieCacheGen, bc.maxPastTries)
+	bc.prefetcher = newStatePrefetcher(bc.config, buffer)
+
+	return nil
+}
+
+// EnableTriePreimages makes the chain record the preimages of all the account
+// addresses and storage keys accessed while processing blocks, storing them in
+// the preimage table next to the SHA3 preimages of the VM. This allows state
+// dumps and debugging tools to display the keys instead of their hashes.
+//
+// It must be called before any blocks are processed.
+func (bc *BlockChain) EnableTriePreimages() {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	bc.triePreimages = true
+	bc.stateCache.RecordTriePreimages(true)
+}
+
+// SetStateCacheLimits sets the number of generations state trie nodes are kept
+// in memory for and the number of past state tries retained for reorgs. Zero
+// values keep the defaults of the state package. The limits are specific to
+// this chain, allowing multiple chains with differing cache budgets to live in
+// the same process.
+func (bc *BlockChain) SetStateCacheLimits(gens uint16, pastTries int) {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	bc.trieCacheGen, bc.maxPastTries = gens, pastTries
+	bc.stateCache.SetCacheLimits(gens, pastTries)
+}
+
+// flushState persists the buffered state of a new canonical head block if the
+// flush interval elapsed or the buffer grew too large, dropping from memory all
+// state not belonging to the most recent blocks. This method assumes that the
+// chain manager mutex is held.
+func (self *BlockChain) flushState(block *types.Block) error {
+	if self.stateBuffer == nil {
+		return nil
+	}
+	size, _ := self.stateBuffer.Size()
+	if number := block.NumberU64(); number > self.lastFlush && number-self.lastFlush < self.flushInterval && size < stateBufferLimit {
+		return nil
+	}
+	start := time.Now()
+
+	// Retain the state of all recent blocks, side chains included, to support
+	// shallow reorgs onto any fork seen within the last triesInMemory blocks
+	var (
+		retain []common.Hash
+		recent = prque.New()
+	)
+	for !self.recentRoots.Empty() {
+		root, prio := self.recentRoots.Pop()
+		if uint64(-prio)+triesInMemory <= block.NumberU64() {
+			continue
+		}
+		if root != block.Root() {
+			retain = append(retain, root.(common.Hash))
+		}
+		recent.Push(root, prio)
+	}
+	self.recentRoots = recent
+
+	written, dropped, err := state.FlushState(self.stateBuffer, block.Root(), retain)
+	if err != nil {
+		return err
+	}
+	self.lastFlush = block.NumberU64()
+	stateFlushTimer.UpdateSince(start)
+
+	if glog.V(logger.Debug) {
+		size, nodes := self.stateBuffer.Size()
+		glog.Infof("Flushed state of #%d [%x…]: %d entries written, %d dropped, %d retained (%v) in %v",
+			block.Number(), block.Hash().Bytes()[:4], written, dropped, nodes, common.StorageSize(size), common.PrettyDuration(time.Since(start)))
+	}
+	return nil
+}
+
 // InsertChain will attempt to insert the given chain in to the canonical chain or, otherwise, create a fork. It an error is returned
 // it will return the index number of the failing block as well an error describing what went wrong (for possible errors see core/errors.go).
 func (self *BlockChain) InsertChain(chain types.Blocks) (int, error) {
@@ -888,6 +1194,9 @@ func (self *BlockChain) InsertChain(chain types.Blocks) (int, error) {
 	nonceAbort, nonceResults := verifyNoncesFromBlocks(self.pow, chain)
 	defer close(nonceAbort)
 
+	// Recover the transaction senders of the entire chain across all cores
+	recoverSenders(self.config, chain)
+
 	for i, block := range chain {
 		if atomic.LoadInt32(&self.procInterrupt) == 1 {
 			glog.V(logger.Debug).Infoln("Premature abort during block chain processing")
@@ -945,18 +1254,25 @@ func (self *BlockChain) InsertChain(chain types.Blocks) (int, error) {
 		}
 		// Create a new statedb using the parent block and report an
 		// error if it fails.
+		var parentRoot common.Hash
 		switch {
 		case i == 0:
-			err = self.stateCache.Reset(self.GetBlock(block.ParentHash(), block.NumberU64()-1).Root())
+			parentRoot = self.GetBlock(block.ParentHash(), block.NumberU64()-1).Root()
 		default:
-			err = self.stateCache.Reset(chain[i-1].Root())
+			parentRoot = chain[i-1].Root()
 		}
-		if err != nil {
+		if err = self.stateCache.Reset(parentRoot); err != nil {
 			self.reportBlock(block, nil, err)
 			return i, err
 		}
+		// Warm up the state the block will touch while it's being processed
+		var interrupt uint32
+		go self.prefetcher.Prefetch(block, parentRoot, &interrupt)
+
 		// Process block using the parent state as reference point.
 		receipts, logs, usedGas, err := self.processor.Process(block, self.stateCache, self.vmConfig)
+		atomic.StoreUint32(&interrupt, 1)
+		self.prefetcher.Learn(self.stateCache)
 		if err != nil {
 			self.reportBlock(block, receipts, err)
 			return i, err
@@ -989,7 +1305,7 @@ func (self *BlockChain) InsertChain(chain types.Blocks) (int, error) {
 		switch status {
 		case CanonStatTy:
 			if glog.V(logger.Debug) {
-				glog.Infof("inserted block #%d [%x…] in %9v: %3d txs %7v gas %d uncles.", block.Number(), block.Hash().Bytes()[0:4], common.PrettyDuration(time.Since(bstart)), len(block.Transactions()), block.GasUsed(), len(block.Uncles()))
+				glog.Infow("inserted block", "number", block.Number(), "hash", fmt.Sprintf("%x…", block.Hash().Bytes()[0:4]), "elapsed", common.PrettyDuration(time.Since(bstart)), "txs", len(block.Transactions()), "gas", block.GasUsed(), "uncles", len(block.Uncles()))
 			}
 			blockInsertTimer.UpdateSince(bstart)
 			events = append(events, ChainEvent{block, block.Hash(), logs})
@@ -1028,7 +1344,7 @@ func (self *BlockChain) InsertChain(chain types.Blocks) (int, error) {
 		}
 	}
 
-	go self.postChainEvents(events, coalescedLogs)
+	go self.PostChainEvents(events, coalescedLogs)
 
 	return 0, nil
 }
@@ -1192,19 +1508,34 @@ func (self *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 		DeleteReceipt(self.chainDb, tx.Hash())
 		DeleteTransaction(self.chainDb, tx.Hash())
 	}
+	// Report the switch of the canonical chain if any blocks were rolled back
+	if len(oldChain) > 0 {
+		reorgExecuteMeter.Mark(1)
+		reorgDropMeter.Mark(int64(len(oldChain)))
+		reorgAddMeter.Mark(int64(len(newChain)))
+		reorgDepthGauge.Update(int64(len(oldChain)))
+
+		go self.eventMux.Post(ChainReorgEvent{
+			OldChain:   oldChain,
+			NewChain:   newChain,
+			Ancestor:   commonBlock,
+			DroppedTxs: diff,
+			AddedTxs:   types.TxDifference(addedTxs, deletedTxs),
+		})
+	}
 	// Must be posted in a goroutine because of the transaction pool trying
 	// to acquire the chain manager lock
 	if len(diff) > 0 {
 		go self.eventMux.Post(RemovedTransactionEvent{diff})
 	}
 	if len(deletedLogs) > 0 {
-		go self.eventMux.Post(RemovedLogsEvent{deletedLogs})
+		go self.rmLogsFeed.Send(RemovedLogsEvent{deletedLogs})
 	}
 
 	if len(oldChain) > 0 {
 		go func() {
 			for _, block := range oldChain {
-				self.eventMux.Post(ChainSideEvent{Block: block})
+				self.chainSideFeed.Send(ChainSideEvent{Block: block})
 			}
 		}()
 	}
@@ -1212,24 +1543,58 @@ func (self *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 	return nil
 }
 
-// postChainEvents iterates over the events generated by a chain insertion and
-// posts them into the event mux.
-func (self *BlockChain) postChainEvents(events []interface{}, logs []*types.Log) {
+// PostChainEvents iterates over the events generated by a chain insertion and
+// sends them to the dedicated chain, side chain, head and log feeds. Any other
+// event is posted into the event mux.
+func (self *BlockChain) PostChainEvents(events []interface{}, logs []*types.Log) {
 	// post event logs for further processing
-	self.eventMux.Post(logs)
+	if logs != nil {
+		self.logsFeed.Send(logs)
+	}
 	for _, event := range events {
-		if event, ok := event.(ChainEvent); ok {
+		switch ev := event.(type) {
+		case ChainEvent:
+			self.chainFeed.Send(ev)
+
 			// We need some control over the mining operation. Acquiring locks and waiting for the miner to create new block takes too long
 			// and in most cases isn't even necessary.
-			if self.LastBlockHash() == event.Hash {
-				self.eventMux.Post(ChainHeadEvent{event.Block})
+			if self.LastBlockHash() == ev.Hash {
+				self.chainHeadFeed.Send(ChainHeadEvent{ev.Block})
 			}
+		case ChainSideEvent:
+			self.chainSideFeed.Send(ev)
+
+		default:
+			self.eventMux.Post(event)
 		}
-		// Fire the insertion events individually too
-		self.eventMux.Post(event)
 	}
 }
 
+// SubscribeRemovedLogsEvent registers a subscription of RemovedLogsEvent.
+func (self *BlockChain) SubscribeRemovedLogsEvent(ch chan<- RemovedLogsEvent) event.Subscription {
+	return self.scope.Track(self.rmLogsFeed.Subscribe(ch))
+}
+
+// SubscribeChainEvent registers a subscription of ChainEvent.
+func (self *BlockChain) SubscribeChainEvent(ch chan<- ChainEvent) event.Subscription {
+	return self.scope.Track(self.chainFeed.Subscribe(ch))
+}
+
+// SubscribeChainHeadEvent registers a subscription of ChainHeadEvent.
+func (self *BlockChain) SubscribeChainHeadEvent(ch chan<- ChainHeadEvent) event.Subscription {
+	return self.scope.Track(self.chainHeadFeed.Subscribe(ch))
+}
+
+// SubscribeChainSideEvent registers a subscription of ChainSideEvent.
+func (self *BlockChain) SubscribeChainSideEvent(ch chan<- ChainSideEvent) event.Subscription {
+	return self.scope.Track(self.chainSideFeed.Subscribe(ch))
+}
+
+// SubscribeLogsEvent registers a subscription of []*types.Log.
+func (self *BlockChain) SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscription {
+	return self.scope.Track(self.logsFeed.Subscribe(ch))
+}
+
 func (self *BlockChain) update() {
 	futureTimer := time.Tick(5 * time.Second)
 	for {
@@ -1242,8 +1607,43 @@ func (self *BlockChain) update() {
 	}
 }
 
-// reportBlock logs a bad block error.
+// BadBlock is a block rejected by the chain during import, together with the
+// reason of the rejection and the peer it originated from, if known.
+type BadBlock struct {
+	Block *types.Block
+	Err   error
+	Peer  string
+	Time  time.Time
+}
+
+// BadBlocks returns the most recently rejected blocks, newest first.
+func (bc *BlockChain) BadBlocks() []*BadBlock {
+	keys := bc.badBlocks.Keys()
+	blocks := make([]*BadBlock, 0, len(keys))
+	for i := len(keys) - 1; i >= 0; i-- {
+		if bad, ok := bc.badBlocks.Peek(keys[i]); ok {
+			blocks = append(blocks, bad.(*BadBlock))
+		}
+	}
+	return blocks
+}
+
+// SetBadBlockPeer attributes a previously rejected block to the peer it was
+// retrieved from. Unknown blocks are silently ignored.
+func (bc *BlockChain) SetBadBlockPeer(hash common.Hash, peer string) {
+	if bad, ok := bc.badBlocks.Peek(hash); ok {
+		updated := *bad.(*BadBlock)
+		updated.Peer = peer
+		bc.badBlocks.Add(hash, &updated)
+	}
+}
+
+// reportBlock logs a bad block error, caches it for later inspection and
+// notifies any subscribers of the rejection.
 func (bc *BlockChain) reportBlock(block *types.Block, receipts types.Receipts, err error) {
+	bc.badBlocks.Add(block.Hash(), &BadBlock{Block: block, Err: err, Time: time.Now()})
+	go bc.eventMux.Post(BadBlockEvent{Block: block, Err: err})
+
 	if glog.V(logger.Error) {
 		var receiptString string
 		for _, receipt := range receipts {
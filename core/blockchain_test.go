@@ -17,7 +17,9 @@
 package core
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"math/big"
 	"math/rand"
 	"os"
@@ -474,6 +476,7 @@ func chm(genesis *types.Block, db ethdb.Database) *BlockChain {
 	var eventMux event.TypeMux
 	bc := &BlockChain{
 		chainDb:      db,
+		stateDb:      db,
 		genesisBlock: genesis,
 		eventMux:     &eventMux,
 		pow:          FakePow{},
@@ -484,7 +487,10 @@ func chm(genesis *types.Block, db ethdb.Database) *BlockChain {
 	bc.bodyCache, _ = lru.New(100)
 	bc.bodyRLPCache, _ = lru.New(100)
 	bc.blockCache, _ = lru.New(100)
+	bc.receiptsCache, _ = lru.New(100)
 	bc.futureBlocks, _ = lru.New(100)
+	bc.badBlocks, _ = lru.New(100)
+	bc.prefetcher = newStatePrefetcher(testChainConfig(), db)
 	bc.SetValidator(bproc{})
 	bc.SetProcessor(bproc{})
 	bc.ResetWithGenesisBlock(genesis)
@@ -579,6 +585,250 @@ func testBadHashes(t *testing.T, full bool) {
 	}
 }
 
+// Tests that rewinding the chain removes all the canonical markers, receipts and
+// transaction lookup entries above the new head, but retains the ones below.
+func TestSetHeadCleanup(t *testing.T) {
+	var (
+		gendb, _ = ethdb.NewMemDatabase()
+		key, _   = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		address  = crypto.PubkeyToAddress(key.PublicKey)
+		funds    = big.NewInt(1000000000)
+		genesis  = GenesisBlockForTesting(gendb, address, funds)
+		signer   = types.NewEIP155Signer(big.NewInt(1))
+	)
+	blocks, _ := GenerateChain(params.TestChainConfig, genesis, gendb, 8, func(i int, block *BlockGen) {
+		tx, err := types.SignTx(types.NewTransaction(block.TxNonce(address), common.Address{0x00}, big.NewInt(1000), params.TxGas, nil, nil), signer, key)
+		if err != nil {
+			panic(err)
+		}
+		block.AddTx(tx)
+	})
+	db, _ := ethdb.NewMemDatabase()
+	WriteGenesisBlockForTesting(db, GenesisAccount{address, funds})
+	blockchain, _ := NewBlockChain(db, testChainConfig(), FakePow{}, new(event.TypeMux), vm.Config{})
+	defer blockchain.Stop()
+
+	if n, err := blockchain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to process block %d: %v", n, err)
+	}
+	blockchain.SetHead(4)
+
+	if head := blockchain.CurrentBlock().NumberU64(); head != 4 {
+		t.Fatalf("head block mismatch: have #%d, want #4", head)
+	}
+	if head := blockchain.CurrentHeader().Number.Uint64(); head != 4 {
+		t.Fatalf("head header mismatch: have #%d, want #4", head)
+	}
+	for _, block := range blocks {
+		var (
+			retained = block.NumberU64() <= 4
+			txHash   = block.Transactions()[0].Hash()
+		)
+		if have := GetCanonicalHash(db, block.NumberU64()) == block.Hash(); have != retained {
+			t.Errorf("block #%d: canonical marker presence mismatch: have %v, want %v", block.NumberU64(), have, retained)
+		}
+		if tx, _, _, _ := GetTransaction(db, txHash); (tx != nil) != retained {
+			t.Errorf("block #%d: transaction lookup presence mismatch: have %v, want %v", block.NumberU64(), tx != nil, retained)
+		}
+		if receipt := GetReceipt(db, txHash); (receipt != nil) != retained {
+			t.Errorf("block #%d: receipt presence mismatch: have %v, want %v", block.NumberU64(), receipt != nil, retained)
+		}
+		if receipts := GetBlockReceipts(db, block.Hash(), block.NumberU64()); (len(receipts) > 0) != retained {
+			t.Errorf("block #%d: block receipts presence mismatch: have %v, want %v", block.NumberU64(), len(receipts) > 0, retained)
+		}
+	}
+}
+
+// Tests that a chain with a head block missing its state is rewound on startup
+// to the most recent block with a complete state.
+func TestRepairMissingHeadState(t *testing.T) {
+	db, blockchain, err := newCanonical(5, true)
+	if err != nil {
+		t.Fatalf("failed to create pristine chain: %v", err)
+	}
+	blockchain.Stop()
+
+	head := blockchain.CurrentBlock()
+	db.Delete(head.Root().Bytes())
+
+	blockchain, err = NewBlockChain(db, MakeChainConfig(), FakePow{}, new(event.TypeMux), vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to reopen chain: %v", err)
+	}
+	defer blockchain.Stop()
+
+	if number := blockchain.CurrentBlock().NumberU64(); number != head.NumberU64()-1 {
+		t.Errorf("head block mismatch: have #%d, want #%d", number, head.NumberU64()-1)
+	}
+	if hash := GetCanonicalHash(db, head.NumberU64()); hash != (common.Hash{}) {
+		t.Errorf("canonical marker of corrupted head retained: %x", hash)
+	}
+}
+
+// Tests that with state garbage collection enabled, only the state of every few
+// blocks is persisted, the head state being flushed on shutdown, and that a chain
+// which crashed is rewound to the last flushed state.
+func TestStateGarbageCollection(t *testing.T) {
+	gendb, _ := ethdb.NewMemDatabase()
+	genesis := WriteGenesisBlockForTesting(gendb)
+	blocks := makeBlockChain(genesis, 10, gendb, canonicalSeed)
+
+	db, _ := ethdb.NewMemDatabase()
+	WriteGenesisBlockForTesting(db)
+	blockchain, err := NewBlockChain(db, MakeChainConfig(), FakePow{}, new(event.TypeMux), vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	if err := blockchain.EnableStateGC(4); err != nil {
+		t.Fatalf("failed to enable state garbage collection: %v", err)
+	}
+	if n, err := blockchain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert block %d: %v", n, err)
+	}
+	// Only the state of every 4th block should be on disk, the rest in memory
+	for i, block := range blocks {
+		_, err := state.New(block.Root(), db)
+		if flushed := (i+1)%4 == 0; flushed != (err == nil) {
+			t.Errorf("block #%d: state on disk mismatch: have %v, want %v", block.NumberU64(), err == nil, flushed)
+		}
+		if _, err := blockchain.StateAt(block.Root()); err != nil {
+			t.Errorf("block #%d: state unavailable: %v", block.NumberU64(), err)
+		}
+	}
+	// Simulate a crash by reopening the database, the head must be rewound
+	crashed, err := NewBlockChain(db, MakeChainConfig(), FakePow{}, new(event.TypeMux), vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to reopen chain: %v", err)
+	}
+	if head := crashed.CurrentBlock(); head.Hash() != blocks[7].Hash() {
+		t.Errorf("crashed head mismatch: have #%d, want #%d", head.NumberU64(), blocks[7].NumberU64())
+	}
+	crashed.Stop()
+
+	// Stopping the chain must persist the head state
+	blockchain.Stop()
+	if _, err := state.New(blocks[9].Root(), db); err != nil {
+		t.Errorf("head state not flushed on shutdown: %v", err)
+	}
+}
+
+// Tests that the buffered state of side chain blocks survives a state flush,
+// so that a fork seen before the flush can still be extended and reorged onto.
+func TestStateGarbageCollectionFork(t *testing.T) {
+	gendb, _ := ethdb.NewMemDatabase()
+	genesis := WriteGenesisBlockForTesting(gendb)
+	blocks := makeBlockChain(genesis, 12, gendb, canonicalSeed)
+	forks := makeBlockChain(blocks[5], 7, gendb, forkSeed)
+
+	db, _ := ethdb.NewMemDatabase()
+	WriteGenesisBlockForTesting(db)
+	blockchain, err := NewBlockChain(db, MakeChainConfig(), FakePow{}, new(event.TypeMux), vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer blockchain.Stop()
+
+	if err := blockchain.EnableStateGC(4); err != nil {
+		t.Fatalf("failed to enable state garbage collection: %v", err)
+	}
+	// Import the canonical chain and a lighter side chain, then force a flush
+	if n, err := blockchain.InsertChain(blocks[:10]); err != nil {
+		t.Fatalf("failed to insert block %d: %v", n, err)
+	}
+	if n, err := blockchain.InsertChain(forks[:2]); err != nil {
+		t.Fatalf("failed to insert fork block %d: %v", n, err)
+	}
+	if n, err := blockchain.InsertChain(blocks[10:]); err != nil {
+		t.Fatalf("failed to insert block %d: %v", 10+n, err)
+	}
+	if _, err := state.New(blocks[11].Root(), db); err != nil {
+		t.Fatalf("head state not flushed: %v", err)
+	}
+	// Extend the side chain past the canonical difficulty, forcing a reorg
+	if n, err := blockchain.InsertChain(forks[2:]); err != nil {
+		t.Fatalf("failed to extend fork at block %d: %v", 2+n, err)
+	}
+	if head := blockchain.CurrentBlock(); head.Hash() != forks[len(forks)-1].Hash() {
+		t.Errorf("head mismatch: have #%d [%x], want #%d [%x]", head.NumberU64(), head.Hash().Bytes()[:4], forks[len(forks)-1].NumberU64(), forks[len(forks)-1].Hash().Bytes()[:4])
+	}
+}
+
+// Tests that switching the canonical chain to a heavier fork posts a reorg event
+// containing both the dropped and the added blocks.
+func TestChainReorgEvent(t *testing.T) {
+	db, blockchain, err := newCanonical(5, true)
+	if err != nil {
+		t.Fatalf("failed to create pristine chain: %v", err)
+	}
+	defer blockchain.Stop()
+
+	sub := blockchain.eventMux.Subscribe(ChainReorgEvent{})
+	defer sub.Unsubscribe()
+
+	var (
+		oldHead  = blockchain.CurrentBlock()
+		ancestor = blockchain.GetBlockByNumber(2)
+	)
+	if _, err := blockchain.InsertChain(makeBlockChain(ancestor, 5, db, forkSeed)); err != nil {
+		t.Fatalf("failed to insert fork: %v", err)
+	}
+	select {
+	case ev := <-sub.Chan():
+		reorg := ev.Data.(ChainReorgEvent)
+		if reorg.Ancestor.Hash() != ancestor.Hash() {
+			t.Errorf("ancestor mismatch: have #%d [%x], want #%d [%x]", reorg.Ancestor.NumberU64(), reorg.Ancestor.Hash(), ancestor.NumberU64(), ancestor.Hash())
+		}
+		if len(reorg.OldChain) != 3 || reorg.OldChain[0].Hash() != oldHead.Hash() {
+			t.Errorf("old chain mismatch: have %d blocks, want 3 headed by [%x]", len(reorg.OldChain), oldHead.Hash())
+		}
+		if len(reorg.NewChain) == 0 || reorg.NewChain[len(reorg.NewChain)-1].ParentHash() != ancestor.Hash() {
+			t.Errorf("new chain not rooted at the common ancestor")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("reorg event not posted")
+	}
+}
+
+// Tests that rejected blocks are tracked for later inspection, can be attributed
+// to their originating peer and are announced via the event mux.
+func TestBadBlockTracking(t *testing.T) {
+	db, blockchain, err := newCanonical(5, true)
+	if err != nil {
+		t.Fatalf("failed to create pristine chain: %v", err)
+	}
+	defer blockchain.Stop()
+
+	sub := blockchain.eventMux.Subscribe(BadBlockEvent{})
+	defer sub.Unsubscribe()
+
+	blocks := makeBlockChain(blockchain.CurrentBlock(), 3, db, forkSeed)
+	BadHashes[blocks[1].Hash()] = true
+	defer delete(BadHashes, blocks[1].Hash())
+
+	if _, err := blockchain.InsertChain(blocks); !IsBadHashError(err) {
+		t.Fatalf("error mismatch: have %v, want BadHashError", err)
+	}
+	bad := blockchain.BadBlocks()
+	if len(bad) != 1 {
+		t.Fatalf("bad block count mismatch: have %d, want 1", len(bad))
+	}
+	if bad[0].Block.Hash() != blocks[1].Hash() || !IsBadHashError(bad[0].Err) || bad[0].Peer != "" {
+		t.Errorf("bad block mismatch: have #%d [%x] (%v, peer %q), want #%d [%x]", bad[0].Block.NumberU64(), bad[0].Block.Hash(), bad[0].Err, bad[0].Peer, blocks[1].NumberU64(), blocks[1].Hash())
+	}
+	blockchain.SetBadBlockPeer(blocks[1].Hash(), "peer")
+	if peer := blockchain.BadBlocks()[0].Peer; peer != "peer" {
+		t.Errorf("bad block peer mismatch: have %q, want %q", peer, "peer")
+	}
+	select {
+	case ev := <-sub.Chan():
+		if block := ev.Data.(BadBlockEvent).Block; block.Hash() != blocks[1].Hash() {
+			t.Errorf("event block mismatch: have [%x], want [%x]", block.Hash(), blocks[1].Hash())
+		}
+	case <-time.After(time.Second):
+		t.Errorf("bad block event not posted")
+	}
+}
+
 // Tests that bad hashes are detected on boot, and the chain rolled back to a
 // good state prior to the bad hash.
 func TestReorgBadHeaderHashes(t *testing.T) { testReorgBadHashes(t, false) }
@@ -992,7 +1242,8 @@ func TestLogReorgs(t *testing.T) {
 	evmux := &event.TypeMux{}
 	blockchain, _ := NewBlockChain(db, testChainConfig(), FakePow{}, evmux, vm.Config{})
 
-	subs := evmux.Subscribe(RemovedLogsEvent{})
+	rmLogsCh := make(chan RemovedLogsEvent)
+	blockchain.SubscribeRemovedLogsEvent(rmLogsCh)
 	chain, _ := GenerateChain(params.TestChainConfig, genesis, db, 2, func(i int, gen *BlockGen) {
 		if i == 1 {
 			tx, err := types.SignTx(types.NewContractCreation(gen.TxNonce(addr1), new(big.Int), big.NewInt(1000000), new(big.Int), code), signer, key1)
@@ -1011,9 +1262,14 @@ func TestLogReorgs(t *testing.T) {
 		t.Fatalf("failed to insert forked chain: %v", err)
 	}
 
-	ev := <-subs.Chan()
-	if len(ev.Data.(RemovedLogsEvent).Logs) == 0 {
-		t.Error("expected logs")
+	timeout := time.NewTimer(1 * time.Second)
+	select {
+	case ev := <-rmLogsCh:
+		if len(ev.Logs) == 0 {
+			t.Error("expected logs")
+		}
+	case <-timeout.C:
+		t.Fatal("Timeout. No RemovedLogsEvent has been sent.")
 	}
 }
 
@@ -1044,7 +1300,8 @@ func TestReorgSideEvent(t *testing.T) {
 		}
 		gen.AddTx(tx)
 	})
-	subs := evmux.Subscribe(ChainSideEvent{})
+	chainSideCh := make(chan ChainSideEvent, 64)
+	blockchain.SubscribeChainSideEvent(chainSideCh)
 	if _, err := blockchain.InsertChain(replacementBlocks); err != nil {
 		t.Fatalf("failed to insert chain: %v", err)
 	}
@@ -1067,8 +1324,8 @@ func TestReorgSideEvent(t *testing.T) {
 done:
 	for {
 		select {
-		case ev := <-subs.Chan():
-			block := ev.Data.(ChainSideEvent).Block
+		case ev := <-chainSideCh:
+			block := ev.Block
 			if _, ok := expectedSideHashes[block.Hash()]; !ok {
 				t.Errorf("%d: didn't expect %x to be in side chain", i, block.Hash())
 			}
@@ -1088,13 +1345,58 @@ done:
 
 	// make sure no more events are fired
 	select {
-	case e := <-subs.Chan():
+	case e := <-chainSideCh:
 		t.Errorf("unexpected event fired: %v", e)
 	case <-time.After(250 * time.Millisecond):
 	}
 
 }
 
+// Tests that canonical insertions are announced on the chain feed in order, and
+// that only the last one of a batch is announced on the head feed.
+func TestChainFeeds(t *testing.T) {
+	var (
+		db, _   = ethdb.NewMemDatabase()
+		genesis = WriteGenesisBlockForTesting(db)
+	)
+	blockchain, _ := NewBlockChain(db, testChainConfig(), FakePow{}, new(event.TypeMux), vm.Config{})
+	defer blockchain.Stop()
+
+	chainCh := make(chan ChainEvent, 10)
+	blockchain.SubscribeChainEvent(chainCh)
+	headCh := make(chan ChainHeadEvent, 10)
+	blockchain.SubscribeChainHeadEvent(headCh)
+
+	chain, _ := GenerateChain(params.TestChainConfig, genesis, db, 3, func(i int, gen *BlockGen) {})
+	if _, err := blockchain.InsertChain(chain); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	timeout := time.NewTimer(time.Second)
+	for i, block := range chain {
+		select {
+		case ev := <-chainCh:
+			if ev.Hash != block.Hash() {
+				t.Errorf("chain event %d: hash mismatch: have %x, want %x", i, ev.Hash, block.Hash())
+			}
+		case <-timeout.C:
+			t.Fatalf("timeout waiting for chain event %d", i)
+		}
+	}
+	select {
+	case ev := <-headCh:
+		if ev.Block.Hash() != chain[2].Hash() {
+			t.Errorf("head event hash mismatch: have %x, want %x", ev.Block.Hash(), chain[2].Hash())
+		}
+	case <-timeout.C:
+		t.Fatal("timeout waiting for head event")
+	}
+	select {
+	case ev := <-headCh:
+		t.Errorf("unexpected head event for block #%d", ev.Block.NumberU64())
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
 // Tests if the canonical block can be fetched from the database during chain insertion.
 func TestCanonicalBlockRetrieval(t *testing.T) {
 	var (
@@ -1133,6 +1435,84 @@ func TestCanonicalBlockRetrieval(t *testing.T) {
 	}
 }
 
+// Tests that the receipts of a block can be retrieved in bulk, containing all the
+// derived fields, both from the database and from the cache.
+func TestBlockReceiptsRetrieval(t *testing.T) {
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr    = crypto.PubkeyToAddress(key.PublicKey)
+		db, _   = ethdb.NewMemDatabase()
+		genesis = WriteGenesisBlockForTesting(db, GenesisAccount{addr, big.NewInt(1000000000)})
+		signer  = types.HomesteadSigner{}
+	)
+	blockchain, _ := NewBlockChain(db, testChainConfig(), FakePow{}, &event.TypeMux{}, vm.Config{})
+	defer blockchain.Stop()
+
+	chain, _ := GenerateChain(params.TestChainConfig, genesis, db, 3, func(i int, gen *BlockGen) {
+		for j := 0; j < i+1; j++ {
+			tx, _ := types.SignTx(types.NewTransaction(gen.TxNonce(addr), common.Address{0xaa}, big.NewInt(1000), params.TxGas, nil, nil), signer, key)
+			gen.AddTx(tx)
+		}
+	})
+	if _, err := blockchain.InsertChain(chain); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	for _, block := range chain {
+		for round := 0; round < 2; round++ {
+			receipts := blockchain.GetReceiptsByHash(block.Hash())
+			if len(receipts) != len(block.Transactions()) {
+				t.Fatalf("block %d, round %d: receipt count mismatch: have %d, want %d", block.NumberU64(), round, len(receipts), len(block.Transactions()))
+			}
+			for i, receipt := range receipts {
+				if receipt.TxHash != block.Transactions()[i].Hash() {
+					t.Errorf("block %d, round %d, receipt %d: tx hash mismatch: have %x, want %x", block.NumberU64(), round, i, receipt.TxHash, block.Transactions()[i].Hash())
+				}
+				if receipt.GasUsed == nil || receipt.GasUsed.Cmp(params.TxGas) != 0 {
+					t.Errorf("block %d, round %d, receipt %d: gas used mismatch: have %v, want %v", block.NumberU64(), round, i, receipt.GasUsed, params.TxGas)
+				}
+			}
+		}
+	}
+	if receipts := blockchain.GetReceiptsByHash(common.Hash{0x01}); receipts != nil {
+		t.Errorf("unknown block returned receipts: %v", receipts)
+	}
+}
+
+// Tests that the preimages of accessed state trie keys are only stored if trie
+// preimage recording is enabled, even for accounts which are never written.
+func TestTriePreimageRecording(t *testing.T) {
+	for _, enabled := range []bool{false, true} {
+		var (
+			key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+			addr    = crypto.PubkeyToAddress(key.PublicKey)
+			empty   = common.Address{0xee}
+			db, _   = ethdb.NewMemDatabase()
+			genesis = WriteGenesisBlockForTesting(db, GenesisAccount{addr, big.NewInt(1000000000)})
+		)
+		blockchain, _ := NewBlockChain(db, testChainConfig(), FakePow{}, &event.TypeMux{}, vm.Config{})
+		if enabled {
+			blockchain.EnableTriePreimages()
+		}
+		// Send a zero value transfer to an empty account, touching but not creating it
+		chain, _ := GenerateChain(params.TestChainConfig, genesis, db, 1, func(i int, gen *BlockGen) {
+			tx, _ := types.SignTx(types.NewTransaction(gen.TxNonce(addr), empty, new(big.Int), params.TxGas, nil, nil), types.HomesteadSigner{}, key)
+			gen.AddTx(tx)
+		})
+		if _, err := blockchain.InsertChain(chain); err != nil {
+			t.Fatalf("enabled %v: failed to insert chain: %v", enabled, err)
+		}
+		blockchain.Stop()
+
+		preimage, _ := PreimageTable(db).Get(crypto.Keccak256(empty[:]))
+		if enabled && !bytes.Equal(preimage, empty[:]) {
+			t.Errorf("enabled %v: preimage mismatch: have %x, want %x", enabled, preimage, empty)
+		}
+		if !enabled && len(preimage) != 0 {
+			t.Errorf("enabled %v: unexpected preimage stored: %x", enabled, preimage)
+		}
+	}
+}
+
 func TestEIP155Transition(t *testing.T) {
 	// Configure and generate a sample block chain
 	var (
@@ -1295,3 +1675,98 @@ func TestEIP161AccountRemoval(t *testing.T) {
 		t.Error("account should not expect")
 	}
 }
+
+// Tests that exporting a range of the chain writes exactly the requested blocks
+// and reports the progress of every one of them.
+func TestChainExportRange(t *testing.T) {
+	_, blockchain, err := newCanonical(10, true)
+	if err != nil {
+		t.Fatalf("failed to create pristine chain: %v", err)
+	}
+	defer blockchain.Stop()
+
+	var (
+		buf      bytes.Buffer
+		reported []uint64
+	)
+	err = blockchain.ExportRange(&buf, 3, 7, func(exported, total uint64) {
+		if total != 5 {
+			t.Errorf("total mismatch: have %d, want 5", total)
+		}
+		reported = append(reported, exported)
+	})
+	if err != nil {
+		t.Fatalf("failed to export range: %v", err)
+	}
+	if len(reported) != 5 || reported[0] != 1 || reported[4] != 5 {
+		t.Errorf("progress mismatch: have %v, want [1 2 3 4 5]", reported)
+	}
+	stream := rlp.NewStream(&buf, 0)
+	for nr := uint64(3); nr <= 7; nr++ {
+		var block types.Block
+		if err := stream.Decode(&block); err != nil {
+			t.Fatalf("failed to decode block #%d: %v", nr, err)
+		}
+		if block.Hash() != blockchain.GetBlockByNumber(nr).Hash() {
+			t.Errorf("block #%d mismatch", nr)
+		}
+	}
+	if err := stream.Decode(new(types.Block)); err != io.EOF {
+		t.Errorf("trailing data after range: %v", err)
+	}
+	if err := blockchain.ExportRange(&buf, 7, 3, nil); err == nil {
+		t.Errorf("inverted range exported")
+	}
+}
+
+// Tests that a chain exported into an RLP stream can be imported in batches into
+// a pristine chain, and that interrupted imports can be resumed.
+func TestChainImport(t *testing.T) {
+	_, source, err := newCanonical(10, true)
+	if err != nil {
+		t.Fatalf("failed to create source chain: %v", err)
+	}
+	defer source.Stop()
+
+	var buf bytes.Buffer
+	if err := source.Export(&buf); err != nil {
+		t.Fatalf("failed to export chain: %v", err)
+	}
+	export := buf.Bytes()
+
+	_, blockchain, err := newCanonical(0, true)
+	if err != nil {
+		t.Fatalf("failed to create pristine chain: %v", err)
+	}
+	defer blockchain.Stop()
+
+	// Interrupt an import and ensure nothing was inserted
+	stop := make(chan struct{})
+	close(stop)
+	if n, err := blockchain.ImportChain(bytes.NewReader(export), ImportConfig{Stop: stop}); err != ErrImportInterrupted {
+		t.Fatalf("interrupted import error mismatch: have %v, want %v", err, ErrImportInterrupted)
+	} else if n != 0 {
+		t.Errorf("interrupted import count mismatch: have %d, want 0", n)
+	}
+	// Import the chain in multiple batches and check the result
+	n, err := blockchain.ImportChain(bytes.NewReader(export), ImportConfig{BatchSize: 3})
+	if err != nil {
+		t.Fatalf("failed to import chain: %v", err)
+	}
+	if n != 10 {
+		t.Errorf("import count mismatch: have %d, want 10", n)
+	}
+	if head := blockchain.CurrentBlock(); head.Hash() != source.CurrentBlock().Hash() {
+		t.Errorf("head mismatch: have #%d, want #%d", head.NumberU64(), source.CurrentBlock().NumberU64())
+	}
+	// Reimporting the same chain should skip all the batches
+	if n, err := blockchain.ImportChain(bytes.NewReader(export), ImportConfig{BatchSize: 3}); err != nil {
+		t.Fatalf("failed to reimport chain: %v", err)
+	} else if n != 0 {
+		t.Errorf("reimport count mismatch: have %d, want 0", n)
+	}
+	// Corrupt input should be reported
+	if _, err := blockchain.ImportChain(bytes.NewReader(export[:len(export)-1]), ImportConfig{}); err == nil {
+		t.Errorf("truncated input imported")
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package bloombits
+
+import (
+	"fmt"
+
+	"github.com/EarthDollar/go-earthdollar/crypto"
+)
+
+// bloomIndexes represents the bit indexes inside the bloom filter that belong
+// to some key.
+type bloomIndexes [3]uint
+
+// calcBloomIndexes returns the bloom filter bit indexes belonging to the given key.
+func calcBloomIndexes(b []byte) bloomIndexes {
+	b = crypto.Keccak256(b)
+
+	var idxs bloomIndexes
+	for i := 0; i < len(idxs); i++ {
+		idxs[i] = (uint(b[2*i])<<8)&2047 + uint(b[2*i+1])
+	}
+	return idxs
+}
+
+// Matcher is a filter evaluating the bit vectors of sections, finding the blocks
+// whose header blooms may match a filter. The filter consists of a list of groups,
+// all of which have to match (AND), a group matching if any of its keys matches
+// (OR). Empty groups are wildcards matching everything.
+type Matcher struct {
+	sectionSize uint64           // Number of blocks in a section (bit vector length)
+	filters     [][]bloomIndexes // Filter groups with the bloom bits of their keys
+}
+
+// NewMatcher creates a new matcher checking sections of the given size against
+// the filter groups, e.g. the addresses and topics of a log filter.
+func NewMatcher(sectionSize uint64, filters [][][]byte) *Matcher {
+	m := &Matcher{sectionSize: sectionSize}
+	for _, filter := range filters {
+		if len(filter) == 0 {
+			continue
+		}
+		group := make([]bloomIndexes, len(filter))
+		for i, key := range filter {
+			group[i] = calcBloomIndexes(key)
+		}
+		m.filters = append(m.filters, group)
+	}
+	return m
+}
+
+// Bits returns the bloom bits the matcher needs to evaluate a section.
+func (m *Matcher) Bits() []uint {
+	var (
+		bits []uint
+		seen = make(map[uint]bool)
+	)
+	for _, group := range m.filters {
+		for _, idxs := range group {
+			for _, bit := range idxs {
+				if !seen[bit] {
+					seen[bit] = true
+					bits = append(bits, bit)
+				}
+			}
+		}
+	}
+	return bits
+}
+
+// Match evaluates the filter on a single section, returning a bit vector with
+// the bits of the potentially matching blocks set. The bit vectors needed for
+// the evaluation are obtained through the retrieve callback.
+func (m *Matcher) Match(retrieve func(bit uint) ([]byte, error)) ([]byte, error) {
+	size := int(m.sectionSize / 8)
+
+	// Start out with every block matching and narrow down by the filter groups
+	result := make([]byte, size)
+	for i := range result {
+		result[i] = 0xff
+	}
+	vectors := make(map[uint][]byte)
+	for _, group := range m.filters {
+		matches := make([]byte, size)
+		for _, idxs := range group {
+			// Blocks match a key if all three of its bloom bits are set
+			match := make([]byte, size)
+			for i := range match {
+				match[i] = 0xff
+			}
+			for _, bit := range idxs {
+				vector, ok := vectors[bit]
+				if !ok {
+					var err error
+					if vector, err = retrieve(bit); err != nil {
+						return nil, err
+					}
+					if len(vector) != size {
+						return nil, fmt.Errorf("bloom bit %d vector size mismatch: have %d, want %d", bit, len(vector), size)
+					}
+					vectors[bit] = vector
+				}
+				for i := range match {
+					match[i] &= vector[i]
+				}
+			}
+			for i := range matches {
+				matches[i] |= match[i]
+			}
+		}
+		for i := range result {
+			result[i] &= matches[i]
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,105 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package bloombits
+
+import (
+	"bytes"
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/EarthDollar/go-earthdollar/core/types"
+)
+
+// Tests that the matcher finds every block whose bloom contains the filtered
+// keys, and only those, for a few filter combinations.
+func TestMatcher(t *testing.T) {
+	const sectionSize = 256
+
+	// Generate a section of blocks, each including a random set of keys
+	keys := make([][]byte, 16)
+	for i := range keys {
+		keys[i] = []byte{0xaa, byte(i), 0x55}
+	}
+	var (
+		blooms   = make([]types.Bloom, sectionSize)
+		included = make([]map[int]bool, sectionSize)
+	)
+	gen, _ := NewGenerator(sectionSize)
+	for i := range blooms {
+		included[i] = make(map[int]bool)
+		for j := range keys {
+			if rand.Intn(4) == 0 {
+				blooms[i].Add(new(big.Int).SetBytes(keys[j]))
+				included[i][j] = true
+			}
+		}
+		if err := gen.AddBloom(uint(i), blooms[i]); err != nil {
+			t.Fatalf("failed to add bloom %d: %v", i, err)
+		}
+	}
+	retrieve := func(bit uint) ([]byte, error) {
+		return gen.Bitset(bit)
+	}
+	tests := [][][]int{
+		{},                         // Wildcard, everything matches
+		{{0}},                      // Single key
+		{{1, 2, 3}},                // Either of multiple keys
+		{{4}, {5}},                 // All of multiple keys
+		{{6, 7}, {}, {8, 9, 10}},   // Combination with a wildcard position
+		{{11}, {12, 13}, {14, 15}}, // Combination of everything
+	}
+	for i, tt := range tests {
+		filters := make([][][]byte, len(tt))
+		for j, group := range tt {
+			for _, key := range group {
+				filters[j] = append(filters[j], keys[key])
+			}
+		}
+		have, err := NewMatcher(sectionSize, filters).Match(retrieve)
+		if err != nil {
+			t.Fatalf("test %d: failed to match: %v", i, err)
+		}
+		// Calculate the expected matches and verify no false negatives
+		want := make([]byte, sectionSize/8)
+		for block := range blooms {
+			matches := true
+			for _, group := range tt {
+				if len(group) == 0 {
+					continue
+				}
+				found := false
+				for _, key := range group {
+					found = found || included[block][key]
+				}
+				matches = matches && found
+			}
+			if matches {
+				want[block/8] |= 1 << uint(7-block%8)
+			}
+		}
+		for j := range want {
+			if want[j]&^have[j] != 0 {
+				t.Errorf("test %d: missing matches in byte %d: have %08b, want %08b", i, j, have[j], want[j])
+			}
+		}
+		// False positives are allowed, but bloom bits are sparse enough here for none
+		if !bytes.Equal(have, want) {
+			t.Errorf("test %d: match mismatch: have %x, want %x", i, have, want)
+		}
+	}
+}
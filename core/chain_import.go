@@ -0,0 +1,164 @@
+// Copyright 2015 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/EarthDollar/go-earthdollar/common"
+	"github.com/EarthDollar/go-earthdollar/core/types"
+	"github.com/EarthDollar/go-earthdollar/logger"
+	"github.com/EarthDollar/go-earthdollar/logger/glog"
+	"github.com/EarthDollar/go-earthdollar/rlp"
+)
+
+const (
+	defaultImportBatch       = 2500            // Default number of blocks inserted at once during chain imports
+	defaultImportLogInterval = 8 * time.Second // Default interval between two import progress reports
+)
+
+// ErrImportInterrupted is returned by ImportChain if it was aborted through the
+// stop channel of its configuration.
+var ErrImportInterrupted = errors.New("chain import interrupted")
+
+// ImportConfig contains the optional parameters of a chain import. The zero
+// value is a valid configuration.
+type ImportConfig struct {
+	BatchSize   int             // Number of blocks to insert at once (defaults to 2500)
+	LogInterval time.Duration   // Interval between two progress reports (defaults to 8 seconds)
+	Size        int64           // Total size of the input, used to estimate the remaining time (0 = unknown)
+	Position    func() int64    // Number of input bytes consumed so far, if the stream is transformed (e.g. decompressed)
+	Stop        <-chan struct{} // Channel aborting the import before the next batch once closed
+}
+
+// countingReader is an io.Reader tracking the number of bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
+// ImportChain reads an RLP stream of blocks and inserts them into the chain in
+// batches, skipping the genesis block and any batches already fully known. Its
+// progress is logged periodically. The number of blocks imported is returned,
+// together with ErrImportInterrupted if the import was stopped prematurely.
+func (bc *BlockChain) ImportChain(r io.Reader, config ImportConfig) (int, error) {
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaultImportBatch
+	}
+	if config.LogInterval <= 0 {
+		config.LogInterval = defaultImportLogInterval
+	}
+	counter := &countingReader{r: r}
+	if config.Position == nil {
+		config.Position = func() int64 { return counter.n }
+	}
+	stream := rlp.NewStream(counter, 0)
+
+	var (
+		start    = time.Now()
+		logged   = start
+		imported = 0
+		read     = 0
+		blocks   = make(types.Blocks, 0, config.BatchSize)
+	)
+	for batch := 0; ; batch++ {
+		if interrupted(config.Stop) {
+			return imported, ErrImportInterrupted
+		}
+		// Load a batch of blocks from the input stream
+		blocks = blocks[:0]
+		for len(blocks) < config.BatchSize {
+			block := new(types.Block)
+			if err := stream.Decode(block); err == io.EOF {
+				break
+			} else if err != nil {
+				return imported, fmt.Errorf("block %d: failed to parse: %v", read, err)
+			}
+			read++
+			if block.NumberU64() == 0 {
+				continue // Genesis is never imported
+			}
+			blocks = append(blocks, block)
+		}
+		if len(blocks) == 0 {
+			break
+		}
+		// Import the batch, unless all of it is already known
+		if interrupted(config.Stop) {
+			return imported, ErrImportInterrupted
+		}
+		if bc.hasBlocks(blocks) {
+			glog.V(logger.Info).Infof("skipping batch %d, all blocks present [%x / %x]", batch, blocks[0].Hash().Bytes()[:4], blocks[len(blocks)-1].Hash().Bytes()[:4])
+		} else {
+			if index, err := bc.InsertChain(blocks); err != nil {
+				return imported + index, fmt.Errorf("invalid block #%d: %v", blocks[index].NumberU64(), err)
+			}
+			imported += len(blocks)
+		}
+		// Report the progress if enough time passed since the last one
+		if now := time.Now(); now.Sub(logged) >= config.LogInterval {
+			logged = now
+			logImportProgress(imported, blocks[len(blocks)-1], now.Sub(start), config.Position(), config.Size)
+		}
+	}
+	glog.V(logger.Info).Infof("imported %d blocks in %v", imported, common.PrettyDuration(time.Since(start)))
+	return imported, nil
+}
+
+// hasBlocks reports whether all of the given blocks are present in the chain.
+func (bc *BlockChain) hasBlocks(blocks types.Blocks) bool {
+	for _, block := range blocks {
+		if !bc.HasBlock(block.Hash()) {
+			return false
+		}
+	}
+	return true
+}
+
+// logImportProgress reports the state of a running chain import, estimating the
+// remaining time from the portion of the input consumed if its size is known.
+func logImportProgress(imported int, head *types.Block, elapsed time.Duration, position, size int64) {
+	if size <= 0 || position <= 0 {
+		glog.V(logger.Info).Infof("imported %d blocks in %v, at #%d", imported, common.PrettyDuration(elapsed), head.NumberU64())
+		return
+	}
+	eta := time.Duration(0)
+	if position < size {
+		eta = time.Duration(float64(elapsed) * float64(size-position) / float64(position))
+	}
+	glog.V(logger.Info).Infof("imported %d blocks in %v, at #%d (%.2f%%), eta %v", imported, common.PrettyDuration(elapsed),
+		head.NumberU64(), 100*float64(position)/float64(size), common.PrettyDuration(eta))
+}
+
+// interrupted reports whether the given stop channel has been closed.
+func interrupted(stop <-chan struct{}) bool {
+	select {
+	case <-stop:
+		return true
+	default:
+		return false
+	}
+}
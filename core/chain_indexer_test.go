@@ -0,0 +1,132 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/EarthDollar/go-earthdollar/common"
+	"github.com/EarthDollar/go-earthdollar/core/types"
+	"github.com/EarthDollar/go-earthdollar/ethdb"
+)
+
+// testChainIndexBackend implements ChainIndexerBackend, verifying the headers
+// of each section arrive in order and reporting committed sections.
+type testChainIndexBackend struct {
+	t           *testing.T
+	sectionSize uint64
+	section     uint64
+	processed   uint64
+	committed   chan uint64
+}
+
+func (b *testChainIndexBackend) Reset(section uint64) {
+	b.section, b.processed = section, 0
+}
+
+func (b *testChainIndexBackend) Process(header *types.Header) {
+	if want := b.section*b.sectionSize + b.processed; header.Number.Uint64() != want {
+		b.t.Errorf("header number mismatch: have %d, want %d", header.Number, want)
+	}
+	b.processed++
+}
+
+func (b *testChainIndexBackend) Commit() error {
+	if b.processed != b.sectionSize {
+		return fmt.Errorf("section %d incomplete: %d headers processed", b.section, b.processed)
+	}
+	b.committed <- b.section
+	return nil
+}
+
+// writeIndexerTestChain writes a canonical chain of headers on top of the given
+// parent, returning the last one.
+func writeIndexerTestChain(db ethdb.Database, parent *types.Header, count int, seed byte) *types.Header {
+	for i := 0; i < count; i++ {
+		header := &types.Header{
+			ParentHash: parent.Hash(),
+			Number:     new(big.Int).Add(parent.Number, common.Big1),
+			Extra:      []byte{seed},
+		}
+		WriteHeader(db, header)
+		WriteCanonicalHash(db, header.Hash(), header.Number.Uint64())
+		parent = header
+	}
+	return parent
+}
+
+// waitSections waits for the given sections to be committed by the backend.
+func waitSections(t *testing.T, backend *testChainIndexBackend, sections ...uint64) {
+	for _, want := range sections {
+		select {
+		case have := <-backend.committed:
+			if have != want {
+				t.Fatalf("committed section mismatch: have %d, want %d", have, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("section %d not committed", want)
+		}
+	}
+	select {
+	case section := <-backend.committed:
+		t.Fatalf("unexpected section committed: %d", section)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+// Tests that the chain indexer processes sections once enough confirmations
+// arrived, and that reorgs roll back and reprocess the affected sections.
+func TestChainIndexer(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	backend := &testChainIndexBackend{t: t, sectionSize: 8, committed: make(chan uint64, 16)}
+	indexer := NewChainIndexer(db, ethdb.NewTable(db, "test-"), backend, 8, 4, 0, "test")
+	defer indexer.Close()
+
+	genesis := &types.Header{Number: big.NewInt(0)}
+	WriteHeader(db, genesis)
+	WriteCanonicalHash(db, genesis.Hash(), 0)
+
+	// Import headers up to (but excluding) the confirmation of the second section
+	head := writeIndexerTestChain(db, genesis, 18, 0)
+	indexer.newHead(head.Number.Uint64(), false)
+	waitSections(t, backend, 0)
+
+	// Confirm a few more sections at once
+	head = writeIndexerTestChain(db, head, 18, 0)
+	indexer.newHead(head.Number.Uint64(), false)
+	waitSections(t, backend, 1, 2, 3)
+
+	if sections, last, _ := indexer.Sections(); sections != 4 || last != 31 {
+		t.Fatalf("indexed sections mismatch: have %d (until #%d), want 4 (until #31)", sections, last)
+	}
+	// Reorg back into the second section and ensure the sections are rolled back
+	ancestor := GetHeader(db, GetCanonicalHash(db, 12), 12)
+	indexer.newHead(ancestor.Number.Uint64(), true)
+	if sections, _, _ := indexer.Sections(); sections != 1 {
+		t.Fatalf("rolled back sections mismatch: have %d, want 1", sections)
+	}
+	head = writeIndexerTestChain(db, ancestor, 20, 1)
+	indexer.newHead(head.Number.Uint64(), false)
+	waitSections(t, backend, 1, 2)
+
+	if hash := indexer.SectionHead(1); hash != GetCanonicalHash(db, 15) {
+		t.Errorf("section head mismatch after reorg: have %x, want %x", hash, GetCanonicalHash(db, 15))
+	}
+}
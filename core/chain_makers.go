@@ -182,6 +182,7 @@ func (b *BlockGen) OffsetTime(seconds int64) {
 // a similar non-validating proof of work implementation.
 func GenerateChain(config *params.ChainConfig, parent *types.Block, db ethdb.Database, n int, gen func(int, *BlockGen)) ([]*types.Block, []types.Receipts) {
 	blocks, receipts := make(types.Blocks, n), make([]types.Receipts, n)
+	fetcher := &chainMakerFetcher{db: db, parent: parent, blocks: blocks}
 	genblock := func(i int, h *types.Header, statedb *state.StateDB) (*types.Block, types.Receipts) {
 		b := &BlockGen{parent: parent, i: i, chain: blocks, header: h, statedb: statedb, config: config}
 
@@ -204,7 +205,7 @@ func GenerateChain(config *params.ChainConfig, parent *types.Block, db ethdb.Dat
 		if gen != nil {
 			gen(i, b)
 		}
-		AccumulateRewards(statedb, h, b.uncles)
+		AccumulateRewards(config, fetcher, statedb, h, b.uncles)
 		root, err := statedb.Commit(config.IsEIP158(h.Number))
 		if err != nil {
 			panic(fmt.Sprintf("state write error: %v", err))
@@ -226,6 +227,26 @@ func GenerateChain(config *params.ChainConfig, parent *types.Block, db ethdb.Dat
 	return blocks, receipts
 }
 
+// chainMakerFetcher serves the headers of the blocks generated so far, falling
+// back to the database for the ancestors of the generated chain.
+type chainMakerFetcher struct {
+	db     ethdb.Database
+	parent *types.Block   // Parent of the first generated block
+	blocks []*types.Block // Generated blocks, filled in as the chain is built
+}
+
+func (f *chainMakerFetcher) GetHeader(hash common.Hash, number uint64) *types.Header {
+	if base := f.parent.NumberU64(); number > base && number-base <= uint64(len(f.blocks)) {
+		if block := f.blocks[number-base-1]; block != nil && block.Hash() == hash {
+			return block.Header()
+		}
+	}
+	if f.parent.NumberU64() == number && f.parent.Hash() == hash {
+		return f.parent.Header()
+	}
+	return GetHeader(f.db, hash, number)
+}
+
 func makeHeader(config *params.ChainConfig, parent *types.Block, state *state.StateDB) *types.Header {
 	var time *big.Int
 	if parent.Time() == nil {
@@ -0,0 +1,254 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/EarthDollar/go-earthdollar/common"
+	"github.com/EarthDollar/go-earthdollar/ethdb"
+	"github.com/EarthDollar/go-earthdollar/logger"
+	"github.com/EarthDollar/go-earthdollar/logger/glog"
+)
+
+// maxUnknownKeys is the number of unrecognised keys retained by an inspection
+// for reporting purposes.
+const maxUnknownKeys = 16
+
+var (
+	// metadataKeys are the singleton bookkeeping entries of the chain database.
+	metadataKeys = [][]byte{
+		headHeaderKey, headBlockKey, headFastKey, fastPivotKey, txTailKey,
+		[]byte("BlockchainVersion"),
+		[]byte("dbUpgrade_20160530sequentialKeys"),
+		[]byte("dbUpgrade_20170601compactReceipts"),
+		[]byte("setting-mipmap-version"),
+		[]byte("LastChtNumber"),
+		[]byte("TrustedCHT"),
+		[]byte("_requestCostStats"),
+	}
+	// legacyPrefixes are the key prefixes of the pre sequential key schema.
+	legacyPrefixes = [][]byte{oldBlockNumPrefix, oldBlockHashPrefix, oldBlockReceiptsPrefix, oldBlockPrefix}
+
+	chtPrefix = []byte("cht") // chtPrefix + chtNum (uint64 big endian) -> trie root hash (light server)
+)
+
+// DatabaseStat is the number and total size (keys and values) of the entries of
+// a single category of the chain database.
+type DatabaseStat struct {
+	Name  string
+	Count uint64
+	Size  common.StorageSize
+}
+
+func (s *DatabaseStat) add(key, value []byte) {
+	s.Count++
+	s.Size += common.StorageSize(len(key) + len(value))
+}
+
+// DatabaseInspection is the key-space breakdown of a chain database.
+type DatabaseInspection struct {
+	Headers         DatabaseStat
+	Tds             DatabaseStat
+	CanonicalHashes DatabaseStat
+	HashNumbers     DatabaseStat
+	Bodies          DatabaseStat
+	Receipts        DatabaseStat
+	TotalSupplies   DatabaseStat
+	TxLookups       DatabaseStat
+	TxReceipts      DatabaseStat // Receipts stored individually by transaction hash
+	TrieNodes       DatabaseStat // Trie nodes and contract code, both keyed by hash
+	Preimages       DatabaseStat
+	BloomBits       DatabaseStat
+	BloomIndex      DatabaseStat // Bookkeeping of the bloom bits indexer
+	MipmapBlooms    DatabaseStat
+	ChainConfigs    DatabaseStat
+	ChtRoots        DatabaseStat
+	Metadata        DatabaseStat
+	Legacy          DatabaseStat // Entries of the pre sequential key schema
+	Unknown         DatabaseStat
+
+	DanglingTds       uint64 // Total difficulties without a header
+	DanglingBodies    uint64 // Block bodies without a header
+	DanglingReceipts  uint64 // Block receipts without a header
+	DanglingCanonical uint64 // Canonical hash assignments referencing a missing header
+
+	UnknownKeys [][]byte // Sample of the unrecognised keys
+}
+
+// Stats returns all the categories in their reporting order.
+func (ins *DatabaseInspection) Stats() []*DatabaseStat {
+	return []*DatabaseStat{
+		&ins.Headers, &ins.Tds, &ins.CanonicalHashes, &ins.HashNumbers,
+		&ins.Bodies, &ins.Receipts, &ins.TotalSupplies,
+		&ins.TxLookups, &ins.TxReceipts,
+		&ins.TrieNodes, &ins.Preimages,
+		&ins.BloomBits, &ins.BloomIndex, &ins.MipmapBlooms,
+		&ins.ChainConfigs, &ins.ChtRoots, &ins.Metadata,
+		&ins.Legacy, &ins.Unknown,
+	}
+}
+
+// Total returns the number and size of all the entries.
+func (ins *DatabaseInspection) Total() DatabaseStat {
+	total := DatabaseStat{Name: "Total"}
+	for _, stat := range ins.Stats() {
+		total.Count += stat.Count
+		total.Size += stat.Size
+	}
+	return total
+}
+
+// Dangling returns the number of entries referencing missing headers.
+func (ins *DatabaseInspection) Dangling() uint64 {
+	return ins.DanglingTds + ins.DanglingBodies + ins.DanglingReceipts + ins.DanglingCanonical
+}
+
+// InspectDatabase iterates over the entire chain database, categorising every
+// entry by its key and cross checking the block data against the headers.
+func InspectDatabase(db *ethdb.LDBDatabase) (*DatabaseInspection, error) {
+	ins := &DatabaseInspection{
+		Headers:         DatabaseStat{Name: "Headers"},
+		Tds:             DatabaseStat{Name: "Total difficulties"},
+		CanonicalHashes: DatabaseStat{Name: "Canonical hashes"},
+		HashNumbers:     DatabaseStat{Name: "Hash to number mappings"},
+		Bodies:          DatabaseStat{Name: "Bodies"},
+		Receipts:        DatabaseStat{Name: "Receipts"},
+		TotalSupplies:   DatabaseStat{Name: "Total supplies"},
+		TxLookups:       DatabaseStat{Name: "Transaction lookups"},
+		TxReceipts:      DatabaseStat{Name: "Transaction receipts"},
+		TrieNodes:       DatabaseStat{Name: "Trie nodes and code"},
+		Preimages:       DatabaseStat{Name: "Preimages"},
+		BloomBits:       DatabaseStat{Name: "Bloom bits"},
+		BloomIndex:      DatabaseStat{Name: "Bloom bits index"},
+		MipmapBlooms:    DatabaseStat{Name: "Mipmap blooms"},
+		ChainConfigs:    DatabaseStat{Name: "Chain configs"},
+		ChtRoots:        DatabaseStat{Name: "CHT roots"},
+		Metadata:        DatabaseStat{Name: "Metadata"},
+		Legacy:          DatabaseStat{Name: "Legacy entries"},
+		Unknown:         DatabaseStat{Name: "Unknown"},
+	}
+	var (
+		lastHeader []byte // Last header key seen, the td of a block directly follows it
+		hasHeader  = func(number, hash []byte) bool {
+			data, _ := db.Get(append(append(append([]byte{}, headerPrefix...), number...), hash...))
+			return len(data) > 0
+		}
+		start  = time.Now()
+		logged = time.Now()
+	)
+	it := db.NewIterator()
+	defer it.Release()
+
+	for it.Next() {
+		key, value := it.Key(), it.Value()
+		switch {
+		case bytes.HasPrefix(key, headerPrefix) && len(key) == len(headerPrefix)+8+common.HashLength:
+			ins.Headers.add(key, value)
+			lastHeader = append(lastHeader[:0], key...)
+
+		case bytes.HasPrefix(key, headerPrefix) && len(key) == len(headerPrefix)+8+common.HashLength+len(tdSuffix) && bytes.HasSuffix(key, tdSuffix):
+			ins.Tds.add(key, value)
+			if !bytes.Equal(lastHeader, key[:len(key)-len(tdSuffix)]) {
+				ins.DanglingTds++
+			}
+		case bytes.HasPrefix(key, headerPrefix) && len(key) == len(headerPrefix)+8+len(numSuffix) && bytes.HasSuffix(key, numSuffix):
+			ins.CanonicalHashes.add(key, value)
+			if len(value) != common.HashLength || !hasHeader(key[len(headerPrefix):len(headerPrefix)+8], value) {
+				ins.DanglingCanonical++
+			}
+		case bytes.HasPrefix(key, blockHashPrefix) && len(key) == len(blockHashPrefix)+common.HashLength && len(value) == 8:
+			// Transaction lookups have the same key length, tell them apart by the value
+			ins.HashNumbers.add(key, value)
+
+		case bytes.HasPrefix(key, bodyPrefix) && len(key) == len(bodyPrefix)+8+common.HashLength:
+			ins.Bodies.add(key, value)
+			if !hasHeader(key[len(bodyPrefix):len(bodyPrefix)+8], key[len(bodyPrefix)+8:]) {
+				ins.DanglingBodies++
+			}
+		case bytes.HasPrefix(key, blockReceiptsPrefix) && len(key) == len(blockReceiptsPrefix)+8+common.HashLength:
+			ins.Receipts.add(key, value)
+			if !hasHeader(key[len(blockReceiptsPrefix):len(blockReceiptsPrefix)+8], key[len(blockReceiptsPrefix)+8:]) {
+				ins.DanglingReceipts++
+			}
+		case bytes.HasPrefix(key, totalSupplyPrefix) && len(key) == len(totalSupplyPrefix)+8+common.HashLength:
+			ins.TotalSupplies.add(key, value)
+
+		case len(key) == common.HashLength+len(txMetaSuffix) && bytes.HasSuffix(key, txMetaSuffix):
+			ins.TxLookups.add(key, value)
+		case bytes.HasPrefix(key, receiptsPrefix) && len(key) == len(receiptsPrefix)+common.HashLength:
+			ins.TxReceipts.add(key, value)
+
+		case len(key) == common.HashLength:
+			ins.TrieNodes.add(key, value)
+		case bytes.HasPrefix(key, []byte(preimagePrefix)) && len(key) == len(preimagePrefix)+common.HashLength:
+			ins.Preimages.add(key, value)
+
+		case bytes.HasPrefix(key, bloomBitsPrefix) && len(key) == len(bloomBitsPrefix)+2+8+common.HashLength:
+			ins.BloomBits.add(key, value)
+		case bytes.HasPrefix(key, BloomBitsIndexPrefix):
+			ins.BloomIndex.add(key, value)
+		case bytes.HasPrefix(key, mipmapPre):
+			ins.MipmapBlooms.add(key, value)
+
+		case bytes.HasPrefix(key, configPrefix) && len(key) == len(configPrefix)+common.HashLength:
+			ins.ChainConfigs.add(key, value)
+		case bytes.HasPrefix(key, chtPrefix) && len(key) == len(chtPrefix)+8:
+			ins.ChtRoots.add(key, value)
+		case isMetadataKey(key):
+			ins.Metadata.add(key, value)
+		case isLegacyKey(key):
+			ins.Legacy.add(key, value)
+
+		default:
+			ins.Unknown.add(key, value)
+			if len(ins.UnknownKeys) < maxUnknownKeys {
+				ins.UnknownKeys = append(ins.UnknownKeys, common.CopyBytes(key))
+			}
+		}
+		if time.Since(logged) > 8*time.Second {
+			glog.V(logger.Info).Infof("Inspecting database: %d entries in %v", ins.Total().Count, time.Since(start))
+			logged = time.Now()
+		}
+	}
+	if err := it.Error(); err != nil {
+		return nil, err
+	}
+	return ins, nil
+}
+
+// isMetadataKey reports whether the key is one of the singleton bookkeeping
+// entries of the chain database.
+func isMetadataKey(key []byte) bool {
+	for _, meta := range metadataKeys {
+		if bytes.Equal(key, meta) {
+			return true
+		}
+	}
+	return false
+}
+
+// isLegacyKey reports whether the key belongs to the pre sequential key schema.
+func isLegacyKey(key []byte) bool {
+	for _, prefix := range legacyPrefixes {
+		if bytes.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/EarthDollar/go-earthdollar/common"
+	"github.com/EarthDollar/go-earthdollar/core/types"
+	"github.com/EarthDollar/go-earthdollar/ethdb"
+)
+
+// Tests that database inspections categorise the entries and detect block data
+// without a header.
+func TestInspectDatabase(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eth-inspect-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := ethdb.NewLDBDatabase(dir, 0, 0)
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	// Write a complete block and the body and receipts of a missing one
+	header := &types.Header{Number: big.NewInt(1), Extra: []byte("inspect")}
+	hash := header.Hash()
+	WriteHeader(db, header)
+	WriteTd(db, hash, 1, big.NewInt(100))
+	WriteCanonicalHash(db, hash, 1)
+	WriteBody(db, hash, 1, &types.Body{})
+	WriteBlockReceipts(db, hash, 1, nil)
+	WriteHeadBlockHash(db, hash)
+
+	missing := common.HexToHash("0xdeadbeef")
+	WriteBody(db, missing, 2, &types.Body{})
+	WriteBlockReceipts(db, missing, 2, nil)
+	WriteTd(db, missing, 2, big.NewInt(200))
+	WriteCanonicalHash(db, missing, 2)
+
+	db.Put(make([]byte, common.HashLength), []byte{0x80})
+	db.Put([]byte("unknown-key"), []byte{0x01})
+
+	ins, err := InspectDatabase(db)
+	if err != nil {
+		t.Fatalf("failed to inspect database: %v", err)
+	}
+	counts := []struct {
+		stat *DatabaseStat
+		want uint64
+	}{
+		{&ins.Headers, 1}, {&ins.Tds, 2}, {&ins.CanonicalHashes, 2}, {&ins.HashNumbers, 1},
+		{&ins.Bodies, 2}, {&ins.Receipts, 2}, {&ins.TrieNodes, 1}, {&ins.Metadata, 1}, {&ins.Unknown, 1},
+	}
+	for _, count := range counts {
+		if count.stat.Count != count.want {
+			t.Errorf("%s: count mismatch: have %d, want %d", count.stat.Name, count.stat.Count, count.want)
+		}
+	}
+	if total := ins.Total(); total.Count != 13 {
+		t.Errorf("total count mismatch: have %d, want %d", total.Count, 13)
+	}
+	if ins.DanglingTds != 1 || ins.DanglingBodies != 1 || ins.DanglingReceipts != 1 || ins.DanglingCanonical != 1 {
+		t.Errorf("dangling entries mismatch: have %d/%d/%d/%d, want 1/1/1/1",
+			ins.DanglingTds, ins.DanglingBodies, ins.DanglingReceipts, ins.DanglingCanonical)
+	}
+	if len(ins.UnknownKeys) != 1 || string(ins.UnknownKeys[0]) != "unknown-key" {
+		t.Errorf("unknown keys mismatch: have %q", ins.UnknownKeys)
+	}
+}
@@ -27,6 +27,7 @@ import (
 
 	"github.com/EarthDollar/go-earthdollar/common"
 	"github.com/EarthDollar/go-earthdollar/core/types"
+	"github.com/EarthDollar/go-earthdollar/crypto"
 	"github.com/EarthDollar/go-earthdollar/ethdb"
 	"github.com/EarthDollar/go-earthdollar/logger"
 	"github.com/EarthDollar/go-earthdollar/logger/glog"
@@ -39,6 +40,8 @@ var (
 	headHeaderKey = []byte("LastHeader")
 	headBlockKey  = []byte("LastBlock")
 	headFastKey   = []byte("LastFast")
+	fastPivotKey  = []byte("LastFastPivot")
+	txTailKey     = []byte("TxLookupTail")
 
 	headerPrefix        = []byte("h")   // headerPrefix + num (uint64 big endian) + hash -> header
 	tdSuffix            = []byte("t")   // headerPrefix + num (uint64 big endian) + hash + tdSuffix -> td
@@ -46,6 +49,7 @@ var (
 	blockHashPrefix     = []byte("H")   // blockHashPrefix + hash -> num (uint64 big endian)
 	bodyPrefix          = []byte("b")   // bodyPrefix + num (uint64 big endian) + hash -> block body
 	blockReceiptsPrefix = []byte("r")   // blockReceiptsPrefix + num (uint64 big endian) + hash -> block receipts
+	totalSupplyPrefix   = []byte("S")   // totalSupplyPrefix + num (uint64 big endian) + hash -> total supply
 	preimagePrefix      = "secure-key-" // preimagePrefix + hash -> preimage
 
 	txMetaSuffix   = []byte{0x01}
@@ -54,6 +58,9 @@ var (
 	mipmapPre    = []byte("mipmap-log-bloom-")
 	MIPMapLevels = []uint64{1000000, 500000, 100000, 50000, 1000}
 
+	bloomBitsPrefix      = []byte("B")  // bloomBitsPrefix + bit (uint16 big endian) + section (uint64 big endian) + hash -> bloom bits
+	BloomBitsIndexPrefix = []byte("iB") // BloomBitsIndexPrefix is the data table of the bloom bits chain indexer
+
 	configPrefix = []byte("ethereum-config-") // config prefix for the db
 
 	// used by old (non-sequential keys) db, now only used for conversion
@@ -148,6 +155,26 @@ func GetHeadFastBlockHash(db ethdb.Database) common.Hash {
 	return common.BytesToHash(data)
 }
 
+// GetFastSyncPivot retrieves the number of the pivot block of an unfinished fast
+// sync, or 0 if no fast sync is in progress.
+func GetFastSyncPivot(db ethdb.Database) uint64 {
+	data, _ := db.Get(fastPivotKey)
+	if len(data) != 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(data)
+}
+
+// GetTxLookupTail retrieves the number of the oldest block whose transactions are
+// still indexed for hash lookups, or 0 if the entire chain is indexed.
+func GetTxLookupTail(db ethdb.Database) uint64 {
+	data, _ := db.Get(txTailKey)
+	if len(data) != 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(data)
+}
+
 // GetHeaderRLP retrieves a block header in its raw RLP database encoding, or nil
 // if the header's not found.
 func GetHeaderRLP(db ethdb.Database, hash common.Hash, number uint64) rlp.RawValue {
@@ -215,6 +242,21 @@ func GetTd(db ethdb.Database, hash common.Hash, number uint64) *big.Int {
 	return td
 }
 
+// GetTotalSupply retrieves the total amount of ED issued up to and including the
+// block corresponding to the hash, nil if none found.
+func GetTotalSupply(db ethdb.Database, hash common.Hash, number uint64) *big.Int {
+	data, _ := db.Get(append(append(totalSupplyPrefix, encodeBlockNumber(number)...), hash[:]...))
+	if len(data) == 0 {
+		return nil
+	}
+	supply := new(big.Int)
+	if err := rlp.Decode(bytes.NewReader(data), supply); err != nil {
+		glog.V(logger.Error).Infof("invalid block total supply RLP for hash %x: %v", hash, err)
+		return nil
+	}
+	return supply
+}
+
 // GetBlock retrieves an entire block corresponding to the hash, assembling it
 // back from the stored header and body. If either the header or body could not
 // be retrieved nil is returned.
@@ -245,7 +287,7 @@ func GetBlockReceipts(db ethdb.Database, hash common.Hash, number uint64) types.
 			return nil
 		}
 	}
-	storageReceipts := []*types.ReceiptForStorage{}
+	storageReceipts := []*types.CompactReceiptForStorage{}
 	if err := rlp.DecodeBytes(data, &storageReceipts); err != nil {
 		glog.V(logger.Error).Infof("invalid receipt array RLP for hash %x: %v", hash, err)
 		return nil
@@ -254,9 +296,60 @@ func GetBlockReceipts(db ethdb.Database, hash common.Hash, number uint64) types.
 	for i, receipt := range storageReceipts {
 		receipts[i] = (*types.Receipt)(receipt)
 	}
+	// Compactly stored receipts lack the derivable fields, fill them in from the body
+	if len(receipts) > 0 && receipts[0].GasUsed == nil {
+		var txs types.Transactions
+		if body := GetBody(db, hash, number); body != nil {
+			txs = body.Transactions
+		}
+		deriveReceiptFields(hash, number, txs, receipts)
+	}
 	return receipts
 }
 
+// deriveReceiptFields computes the implementation fields of compactly stored
+// receipts from the block they belong to. If the transactions of the block are
+// not available (e.g. the body is not yet retrieved), only the gas used and the
+// log positions are filled in.
+func deriveReceiptFields(hash common.Hash, number uint64, txs types.Transactions, receipts types.Receipts) {
+	if len(txs) != len(receipts) {
+		txs = nil
+	}
+	logIndex := uint(0)
+	for i, receipt := range receipts {
+		// The used gas can be calculated based on previous receipts
+		if i == 0 {
+			receipt.GasUsed = new(big.Int).Set(receipt.CumulativeGasUsed)
+		} else {
+			receipt.GasUsed = new(big.Int).Sub(receipt.CumulativeGasUsed, receipts[i-1].CumulativeGasUsed)
+		}
+		// The transaction hash and contract address need the transaction itself
+		if txs != nil {
+			tx := txs[i]
+			receipt.TxHash = tx.Hash()
+
+			if tx.To() == nil {
+				var signer types.Signer = types.FrontierSigner{}
+				if tx.Protected() {
+					signer = types.NewEIP155Signer(tx.ChainId())
+				}
+				if from, err := types.Sender(signer, tx); err == nil {
+					receipt.ContractAddress = crypto.CreateAddress(from, tx.Nonce())
+				}
+			}
+		}
+		// The derived log fields can simply be set from the block and transaction
+		for _, log := range receipt.Logs {
+			log.BlockNumber = number
+			log.BlockHash = hash
+			log.TxHash = receipt.TxHash
+			log.TxIndex = uint(i)
+			log.Index = logIndex
+			logIndex++
+		}
+	}
+}
+
 // GetTransaction retrieves a specific transaction from the database, along with
 // its added positional metadata.
 func GetTransaction(db ethdb.Database, hash common.Hash) (*types.Transaction, common.Hash, uint64, uint64) {
@@ -332,6 +425,24 @@ func WriteHeadFastBlockHash(db ethdb.Database, hash common.Hash) error {
 	return nil
 }
 
+// WriteFastSyncPivot stores the pivot block number of the running fast sync, so
+// it can be resumed after a restart.
+func WriteFastSyncPivot(db ethdb.Database, number uint64) error {
+	if err := db.Put(fastPivotKey, encodeBlockNumber(number)); err != nil {
+		glog.Fatalf("failed to store fast sync pivot into database: %v", err)
+	}
+	return nil
+}
+
+// WriteTxLookupTail stores the number of the oldest block whose transactions are
+// still indexed for hash lookups.
+func WriteTxLookupTail(db ethdb.Database, number uint64) error {
+	if err := db.Put(txTailKey, encodeBlockNumber(number)); err != nil {
+		glog.Fatalf("failed to store transaction index tail into database: %v", err)
+	}
+	return nil
+}
+
 // WriteHeader serializes a block header into the database.
 func WriteHeader(db ethdb.Database, header *types.Header) error {
 	data, err := rlp.EncodeToBytes(header)
@@ -386,6 +497,21 @@ func WriteTd(db ethdb.Database, hash common.Hash, number uint64, td *big.Int) er
 	return nil
 }
 
+// WriteTotalSupply serializes the total amount of ED issued up to and including
+// a block into the database.
+func WriteTotalSupply(db ethdb.Database, hash common.Hash, number uint64, supply *big.Int) error {
+	data, err := rlp.EncodeToBytes(supply)
+	if err != nil {
+		return err
+	}
+	key := append(append(totalSupplyPrefix, encodeBlockNumber(number)...), hash.Bytes()...)
+	if err := db.Put(key, data); err != nil {
+		glog.Fatalf("failed to store block total supply into database: %v", err)
+	}
+	glog.V(logger.Debug).Infof("stored block total supply [%x…]: %v", hash.Bytes()[:4], supply)
+	return nil
+}
+
 // WriteBlock serializes a block into the database, header and body separately.
 func WriteBlock(db ethdb.Database, block *types.Block) error {
 	// Store the body first to retain database consistency
@@ -401,12 +527,13 @@ func WriteBlock(db ethdb.Database, block *types.Block) error {
 
 // WriteBlockReceipts stores all the transaction receipts belonging to a block
 // as a single receipt slice. This is used during chain reorganisations for
-// rescheduling dropped transactions.
+// rescheduling dropped transactions. The receipts are stored in compact form,
+// any field which can be derived from the block is recomputed on retrieval.
 func WriteBlockReceipts(db ethdb.Database, hash common.Hash, number uint64, receipts types.Receipts) error {
 	// Convert the receipts into their storage form and serialize them
-	storageReceipts := make([]*types.ReceiptForStorage, len(receipts))
+	storageReceipts := make([]*types.CompactReceiptForStorage, len(receipts))
 	for i, receipt := range receipts {
-		storageReceipts[i] = (*types.ReceiptForStorage)(receipt)
+		storageReceipts[i] = (*types.CompactReceiptForStorage)(receipt)
 	}
 	bytes, err := rlp.EncodeToBytes(storageReceipts)
 	if err != nil {
@@ -495,6 +622,11 @@ func WriteReceipts(db ethdb.Database, receipts types.Receipts) error {
 	return nil
 }
 
+// DeleteFastSyncPivot removes the pivot block number of a finished fast sync.
+func DeleteFastSyncPivot(db ethdb.Database) {
+	db.Delete(fastPivotKey)
+}
+
 // DeleteCanonicalHash removes the number to hash canonical mapping.
 func DeleteCanonicalHash(db ethdb.Database, number uint64) {
 	db.Delete(append(append(headerPrefix, encodeBlockNumber(number)...), numSuffix...))
@@ -516,6 +648,11 @@ func DeleteTd(db ethdb.Database, hash common.Hash, number uint64) {
 	db.Delete(append(append(append(headerPrefix, encodeBlockNumber(number)...), hash.Bytes()...), tdSuffix...))
 }
 
+// DeleteTotalSupply removes the total supply associated with a block hash.
+func DeleteTotalSupply(db ethdb.Database, hash common.Hash, number uint64) {
+	db.Delete(append(append(totalSupplyPrefix, encodeBlockNumber(number)...), hash.Bytes()...))
+}
+
 // DeleteBlock removes all block data associated with a hash.
 func DeleteBlock(db ethdb.Database, hash common.Hash, number uint64) {
 	DeleteBlockReceipts(db, hash, number)
@@ -600,6 +737,40 @@ func GetMipmapBloom(db ethdb.Database, number, level uint64) types.Bloom {
 	return types.BytesToBloom(bloomDat)
 }
 
+// DeleteMipmapBloom removes the MIP bloom bin of the given level containing the
+// number.
+func DeleteMipmapBloom(db ethdb.Database, number, level uint64) {
+	mipmapBloomMu.Lock()
+	defer mipmapBloomMu.Unlock()
+
+	db.Delete(mipmapKey(number, level))
+}
+
+// bloomBitsKey = bloomBitsPrefix + bit (uint16 big endian) + section (uint64 big endian) + hash
+func bloomBitsKey(bit uint, section uint64, head common.Hash) []byte {
+	key := append(append([]byte{}, bloomBitsPrefix...), make([]byte, 10)...)
+
+	binary.BigEndian.PutUint16(key[1:], uint16(bit))
+	binary.BigEndian.PutUint64(key[3:], section)
+
+	return append(key, head.Bytes()...)
+}
+
+// GetBloomBits retrieves the compressed bloom bit vector belonging to the given
+// section and bit index, identified by the hash of the section's last block.
+func GetBloomBits(db ethdb.Database, bit uint, section uint64, head common.Hash) ([]byte, error) {
+	return db.Get(bloomBitsKey(bit, section, head))
+}
+
+// WriteBloomBits writes the compressed bloom bit vector belonging to the given
+// section and bit index.
+func WriteBloomBits(db ethdb.Database, bit uint, section uint64, head common.Hash, bits []byte) error {
+	if err := db.Put(bloomBitsKey(bit, section, head), bits); err != nil {
+		glog.Fatalf("failed to store bloom bits: %v", err)
+	}
+	return nil
+}
+
 // PreimageTable returns a Database instance with the key prefix for preimage entries.
 func PreimageTable(db ethdb.Database) ethdb.Database {
 	return ethdb.NewTable(db, preimagePrefix)
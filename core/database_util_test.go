@@ -343,6 +343,25 @@ func TestHeadStorage(t *testing.T) {
 	}
 }
 
+// Tests that the pivot of an unfinished fast sync can be stored and removed.
+func TestFastSyncPivotStorage(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+
+	if pivot := GetFastSyncPivot(db); pivot != 0 {
+		t.Fatalf("Non fast sync pivot returned: %d", pivot)
+	}
+	if err := WriteFastSyncPivot(db, 314); err != nil {
+		t.Fatalf("Failed to write fast sync pivot: %v", err)
+	}
+	if pivot := GetFastSyncPivot(db); pivot != 314 {
+		t.Fatalf("Fast sync pivot mismatch: have %d, want %d", pivot, 314)
+	}
+	DeleteFastSyncPivot(db)
+	if pivot := GetFastSyncPivot(db); pivot != 0 {
+		t.Fatalf("Deleted fast sync pivot returned: %d", pivot)
+	}
+}
+
 // Tests that transactions and associated metadata can be stored and retrieved.
 func TestTransactionStorage(t *testing.T) {
 	db, _ := ethdb.NewMemDatabase()
@@ -471,7 +490,9 @@ func TestBlockReceiptStorage(t *testing.T) {
 		GasUsed:         big.NewInt(222222),
 	}
 	receipts := []*types.Receipt{receipt1, receipt2}
-
+	for _, receipt := range receipts {
+		receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
+	}
 	// Check that no receipt entries are in a pristine database
 	hash := common.BytesToHash([]byte{0x03, 0x14})
 	if rs := GetBlockReceipts(db, hash, 0); len(rs) != 0 {
@@ -500,6 +521,94 @@ func TestBlockReceiptStorage(t *testing.T) {
 	}
 }
 
+// Tests that compactly stored block receipts have their derivable fields filled
+// in from the block body on retrieval, and that receipts stored in the original
+// full format can still be read.
+func TestCompactBlockReceiptStorage(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+
+	key, _ := crypto.GenerateKey()
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	signer := types.NewEIP155Signer(big.NewInt(1))
+
+	tx1, _ := types.SignTx(types.NewContractCreation(0, big.NewInt(0), big.NewInt(100000), big.NewInt(1), []byte{0x60}), signer, key)
+	tx2, _ := types.SignTx(types.NewTransaction(1, common.Address{0x22}, big.NewInt(1), big.NewInt(50000), big.NewInt(1), nil), types.HomesteadSigner{}, key)
+
+	receipt1 := &types.Receipt{
+		PostState:         []byte{0x01},
+		CumulativeGasUsed: big.NewInt(53000),
+		Logs:              []*types.Log{{Address: common.Address{0x11}, Topics: []common.Hash{{0x11}}}},
+		TxHash:            tx1.Hash(),
+		ContractAddress:   crypto.CreateAddress(from, 0),
+		GasUsed:           big.NewInt(53000),
+	}
+	receipt2 := &types.Receipt{
+		PostState:         []byte{0x02},
+		CumulativeGasUsed: big.NewInt(74000),
+		Logs: []*types.Log{
+			{Address: common.Address{0x22}, Data: []byte{0x22}},
+			{Address: common.Address{0x02, 0x22}},
+		},
+		TxHash:  tx2.Hash(),
+		GasUsed: big.NewInt(21000),
+	}
+	receipts := types.Receipts{receipt1, receipt2}
+	for _, receipt := range receipts {
+		receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
+	}
+	block := types.NewBlock(&types.Header{Number: big.NewInt(7)}, types.Transactions{tx1, tx2}, nil, receipts)
+	if err := WriteBody(db, block.Hash(), block.NumberU64(), block.Body()); err != nil {
+		t.Fatalf("failed to write block body: %v", err)
+	}
+	check := func(rs types.Receipts) {
+		if len(rs) != len(receipts) {
+			t.Fatalf("receipt count mismatch: have %d, want %d", len(rs), len(receipts))
+		}
+		if types.DeriveSha(rs) != block.ReceiptHash() {
+			t.Errorf("receipt root mismatch: have %x, want %x", types.DeriveSha(rs), block.ReceiptHash())
+		}
+		logIndex := uint(0)
+		for i, r := range rs {
+			if r.TxHash != receipts[i].TxHash {
+				t.Errorf("receipt %d: tx hash mismatch: have %x, want %x", i, r.TxHash, receipts[i].TxHash)
+			}
+			if r.ContractAddress != receipts[i].ContractAddress {
+				t.Errorf("receipt %d: contract address mismatch: have %x, want %x", i, r.ContractAddress, receipts[i].ContractAddress)
+			}
+			if r.GasUsed.Cmp(receipts[i].GasUsed) != 0 {
+				t.Errorf("receipt %d: gas used mismatch: have %v, want %v", i, r.GasUsed, receipts[i].GasUsed)
+			}
+			for _, log := range r.Logs {
+				if log.BlockHash != block.Hash() || log.BlockNumber != block.NumberU64() || log.TxHash != r.TxHash || log.TxIndex != uint(i) || log.Index != logIndex {
+					t.Errorf("receipt %d: log position mismatch: %+v", i, log)
+				}
+				logIndex++
+			}
+		}
+	}
+	// Store the receipts compactly and ensure all the fields are recovered
+	if err := WriteBlockReceipts(db, block.Hash(), block.NumberU64(), receipts); err != nil {
+		t.Fatalf("failed to write block receipts: %v", err)
+	}
+	compact, _ := db.Get(append(append(blockReceiptsPrefix, encodeBlockNumber(block.NumberU64())...), block.Hash().Bytes()...))
+	check(GetBlockReceipts(db, block.Hash(), block.NumberU64()))
+
+	// Store the receipts in the original full format and ensure they still load
+	legacy := make([]*types.ReceiptForStorage, len(receipts))
+	for i, receipt := range receipts {
+		for j, log := range receipt.Logs {
+			log.BlockHash, log.BlockNumber, log.TxHash, log.TxIndex, log.Index = block.Hash(), block.NumberU64(), receipt.TxHash, uint(i), uint(i+j)
+		}
+		legacy[i] = (*types.ReceiptForStorage)(receipt)
+	}
+	data, _ := rlp.EncodeToBytes(legacy)
+	if len(data) <= len(compact) {
+		t.Errorf("compact encoding not smaller: have %d bytes, full %d bytes", len(compact), len(data))
+	}
+	db.Put(append(append(blockReceiptsPrefix, encodeBlockNumber(block.NumberU64())...), block.Hash().Bytes()...), data)
+	check(GetBlockReceipts(db, block.Hash(), block.NumberU64()))
+}
+
 func TestMipmapBloom(t *testing.T) {
 	db, _ := ethdb.NewMemDatabase()
 
@@ -189,6 +189,21 @@ func IsBadHashError(err error) bool {
 	return ok
 }
 
+// GenesisMismatchError is returned when a genesis block is being written to a
+// database already containing a different one.
+type GenesisMismatchError struct {
+	Stored, New common.Hash
+}
+
+func (e *GenesisMismatchError) Error() string {
+	return fmt.Sprintf("database already contains an incompatible genesis block (have %x, new %x)", e.Stored, e.New)
+}
+
+func IsGenesisMismatchErr(err error) bool {
+	_, ok := err.(*GenesisMismatchError)
+	return ok
+}
+
 type GasLimitErr struct {
 	Have, Want *big.Int
 }
@@ -26,6 +26,10 @@ import (
 // TxPreEvent is posted when a transaction enters the transaction pool.
 type TxPreEvent struct{ Tx *types.Transaction }
 
+// TxsPreEvent is posted when a batch of transactions entered the transaction
+// pool, coalescing the TxPreEvents of a short time window.
+type TxsPreEvent struct{ Txs types.Transactions }
+
 // TxPostEvent is posted when a transaction has been processed.
 type TxPostEvent struct{ Tx *types.Transaction }
 
@@ -73,6 +77,24 @@ type ChainUncleEvent struct {
 
 type ChainHeadEvent struct{ Block *types.Block }
 
+// ChainReorgEvent is posted when the canonical chain switches to a different
+// fork. Both chains are ordered from their head down to (but excluding) the
+// common ancestor. The dropped transactions were included in the old chain but
+// not in the new one, the added transactions vice versa.
+type ChainReorgEvent struct {
+	OldChain   types.Blocks
+	NewChain   types.Blocks
+	Ancestor   *types.Block
+	DroppedTxs types.Transactions
+	AddedTxs   types.Transactions
+}
+
+// BadBlockEvent is posted when a block is rejected during import.
+type BadBlockEvent struct {
+	Block *types.Block
+	Err   error
+}
+
 type GasPriceChanged struct{ Price *big.Int }
 
 // Mining operation events
@@ -36,40 +36,55 @@ import (
 	"github.com/EarthDollar/go-earthdollar/params"
 )
 
+// Genesis specifies the header fields, the initial state and the chain
+// configuration of a genesis block, as found in genesis JSON files.
+type Genesis struct {
+	ChainConfig *params.ChainConfig `json:"config"`
+	Nonce       string
+	Timestamp   string
+	ParentHash  string
+	ExtraData   string
+	GasLimit    string
+	Difficulty  string
+	Mixhash     string
+	Coinbase    string
+	Alloc       map[string]GenesisAlloc
+}
+
+// GenesisAlloc is an account in the state of a genesis block, allowing system
+// contracts to be deployed at genesis.
+type GenesisAlloc struct {
+	Code    string
+	Storage map[string]string
+	Balance string
+	Nonce   string
+}
+
 // WriteGenesisBlock writes the genesis block to the database as block number 0
 func WriteGenesisBlock(chainDb ethdb.Database, reader io.Reader) (*types.Block, error) {
 	contents, err := ioutil.ReadAll(reader)
 	if err != nil {
 		return nil, err
 	}
-
-	var genesis struct {
-		ChainConfig *params.ChainConfig `json:"config"`
-		Nonce       string
-		Timestamp   string
-		ParentHash  string
-		ExtraData   string
-		GasLimit    string
-		Difficulty  string
-		Mixhash     string
-		Coinbase    string
-		Alloc       map[string]struct {
-			Code    string
-			Storage map[string]string
-			Balance string
-		}
-	}
-
-	if err := json.Unmarshal(contents, &genesis); err != nil {
+	genesis := new(Genesis)
+	if err := json.Unmarshal(contents, genesis); err != nil {
 		return nil, err
 	}
+	return WriteGenesis(chainDb, genesis)
+}
 
+// WriteGenesis writes the genesis block described by the given specification to
+// the database as block number 0, along with its state and chain configuration.
+func WriteGenesis(chainDb ethdb.Database, genesis *Genesis) (*types.Block, error) {
 	// creating with empty hash always works
 	statedb, _ := state.New(common.Hash{}, chainDb)
 	for addr, account := range genesis.Alloc {
 		address := common.HexToAddress(addr)
 		statedb.AddBalance(address, common.String2Big(account.Balance))
-		statedb.SetCode(address, common.Hex2Bytes(account.Code))
+		statedb.SetCode(address, common.FromHex(account.Code))
+		if account.Nonce != "" {
+			statedb.SetNonce(address, common.String2Big(account.Nonce).Uint64())
+		}
 		for key, value := range account.Storage {
 			statedb.SetState(address, common.HexToHash(key), common.HexToHash(value))
 		}
@@ -89,6 +104,10 @@ func WriteGenesisBlock(chainDb ethdb.Database, reader io.Reader) (*types.Block,
 		Root:       root,
 	}, nil, nil, nil)
 
+	// Refuse to overwrite the genesis of an existing chain with a different one
+	if stored := GetCanonicalHash(chainDb, 0); stored != (common.Hash{}) && stored != block.Hash() {
+		return nil, &GenesisMismatchError{Stored: stored, New: block.Hash()}
+	}
 	if block := GetBlock(chainDb, block.Hash(), block.NumberU64()); block != nil {
 		glog.V(logger.Info).Infoln("Genesis block already in chain. Writing canonical number")
 		err := WriteCanonicalHash(chainDb, block.Hash(), block.NumberU64())
@@ -107,6 +126,13 @@ func WriteGenesisBlock(chainDb ethdb.Database, reader io.Reader) (*types.Block,
 	if err := WriteBlock(chainDb, block); err != nil {
 		return nil, err
 	}
+	supply := new(big.Int)
+	for _, account := range genesis.Alloc {
+		supply.Add(supply, common.String2Big(account.Balance))
+	}
+	if err := WriteTotalSupply(chainDb, block.Hash(), block.NumberU64(), supply); err != nil {
+		return nil, err
+	}
 	if err := WriteBlockReceipts(chainDb, block.Hash(), block.NumberU64(), nil); err != nil {
 		return nil, err
 	}
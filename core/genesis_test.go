@@ -0,0 +1,101 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/EarthDollar/go-earthdollar/common"
+	"github.com/EarthDollar/go-earthdollar/core/state"
+	"github.com/EarthDollar/go-earthdollar/ethdb"
+	"github.com/EarthDollar/go-earthdollar/params"
+)
+
+// Tests that genesis specifications may pre-deploy contracts, complete with code,
+// storage and nonce, and carry the chain configuration.
+func TestWriteGenesisAlloc(t *testing.T) {
+	spec := `{
+		"config": {"chainId": 1337, "homesteadBlock": 0, "blockReward": 1000},
+		"nonce": "0x42",
+		"difficulty": "0x400",
+		"gasLimit": "0x2fefd8",
+		"alloc": {
+			"0x0000000000000000000000000000000000000100": {
+				"balance": "0x10",
+				"nonce": "3",
+				"code": "0x600160005500",
+				"storage": {"0x01": "0x2a"}
+			},
+			"0000000000000000000000000000000000000200": {"balance": "0x20"}
+		}
+	}`
+	db, _ := ethdb.NewMemDatabase()
+	block, err := WriteGenesisBlock(db, strings.NewReader(spec))
+	if err != nil {
+		t.Fatalf("failed to write genesis: %v", err)
+	}
+	statedb, err := state.New(block.Root(), db)
+	if err != nil {
+		t.Fatalf("failed to open genesis state: %v", err)
+	}
+	contract := common.HexToAddress("0x0100")
+	if code := statedb.GetCode(contract); common.Bytes2Hex(code) != "600160005500" {
+		t.Errorf("code mismatch: have %x, want 600160005500", code)
+	}
+	if nonce := statedb.GetNonce(contract); nonce != 3 {
+		t.Errorf("nonce mismatch: have %d, want 3", nonce)
+	}
+	if value := statedb.GetState(contract, common.HexToHash("0x01")); value != common.HexToHash("0x2a") {
+		t.Errorf("storage mismatch: have %x, want %x", value, common.HexToHash("0x2a"))
+	}
+	if supply := GetTotalSupply(db, block.Hash(), 0); supply == nil || supply.Int64() != 0x30 {
+		t.Errorf("total supply mismatch: have %v, want %v", supply, 0x30)
+	}
+	config, err := GetChainConfig(db, block.Hash())
+	if err != nil {
+		t.Fatalf("failed to retrieve chain config: %v", err)
+	}
+	if config.ChainId.Cmp(big.NewInt(1337)) != 0 || config.BlockReward.Cmp(big.NewInt(1000)) != 0 {
+		t.Errorf("chain config mismatch: have %v, block reward %v", config, config.BlockReward)
+	}
+}
+
+// Tests that writing a genesis block into a database holding a different chain
+// is refused, reporting both genesis hashes.
+func TestWriteGenesisMismatch(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	mainnet, err := WriteDefaultGenesisBlock(db)
+	if err != nil {
+		t.Fatalf("failed to write mainnet genesis: %v", err)
+	}
+	if _, err := WriteDefaultGenesisBlock(db); err != nil {
+		t.Fatalf("failed to rewrite the same genesis: %v", err)
+	}
+	_, err = WriteTestNetGenesisBlock(db)
+	mismatch, ok := err.(*GenesisMismatchError)
+	if !ok {
+		t.Fatalf("error mismatch: have %v, want genesis mismatch", err)
+	}
+	if mismatch.Stored != mainnet.Hash() || mismatch.New != params.TestNetGenesisHash {
+		t.Errorf("mismatch hashes: have %x/%x, want %x/%x", mismatch.Stored, mismatch.New, mainnet.Hash(), params.TestNetGenesisHash)
+	}
+	if hash := GetCanonicalHash(db, 0); hash != mainnet.Hash() {
+		t.Errorf("canonical genesis overwritten: have %x, want %x", hash, mainnet.Hash())
+	}
+}
@@ -44,10 +44,12 @@ const (
 )
 
 // HeaderChain implements the basic block header chain logic that is shared by
-// core.BlockChain and light.LightChain. It is not usable in itself, only as
-// a part of either structure.
-// It is not thread safe either, the encapsulating chain structures should do
-// the necessary mutex locking/unlocking.
+// core.BlockChain and light.LightChain. It can also be used on its own in a
+// header-only mode (see NewHeaderOnlyChain), tracking the total difficulties
+// without ever requiring block bodies.
+// It is not thread safe, the encapsulating chain structures (or the users of
+// a header-only chain) should do the necessary mutex locking/unlocking, apart
+// from InsertHeaders which serialises concurrent insertions itself.
 type HeaderChain struct {
 	config *params.ChainConfig
 
@@ -62,6 +64,7 @@ type HeaderChain struct {
 	numberCache *lru.Cache // Cache for the most recent block numbers
 
 	procInterrupt func() bool
+	insertLock    sync.Mutex // Lock serialising header-only mode insertions
 
 	rand         *mrand.Rand
 	getValidator getHeaderValidatorFn
@@ -72,9 +75,12 @@ type getHeaderValidatorFn func() HeaderValidator
 
 // NewHeaderChain creates a new HeaderChain structure.
 //  getValidator should return the parent's validator
-//  procInterrupt points to the parent's interrupt semaphore
+//  procInterrupt points to the parent's interrupt semaphore (nil if none)
 //  wg points to the parent's shutdown wait group
 func NewHeaderChain(chainDb ethdb.Database, config *params.ChainConfig, getValidator getHeaderValidatorFn, procInterrupt func() bool) (*HeaderChain, error) {
+	if procInterrupt == nil {
+		procInterrupt = func() bool { return false }
+	}
 	headerCache, _ := lru.New(headerCacheLimit)
 	tdCache, _ := lru.New(tdCacheLimit)
 	numberCache, _ := lru.New(numberCacheLimit)
@@ -117,6 +123,39 @@ func NewHeaderChain(chainDb ethdb.Database, config *params.ChainConfig, getValid
 	return hc, nil
 }
 
+// NewHeaderOnlyChain creates a HeaderChain operating without a parent block
+// chain, validating the headers with its own header validator and restoring the
+// last known head header from the database.
+func NewHeaderOnlyChain(chainDb ethdb.Database, config *params.ChainConfig, pow pow.PoW) (*HeaderChain, error) {
+	var validator HeaderValidator
+	hc, err := NewHeaderChain(chainDb, config, func() HeaderValidator { return validator }, nil)
+	if err != nil {
+		return nil, err
+	}
+	validator = NewHeaderValidator(config, hc, pow)
+
+	if head := GetHeadHeaderHash(chainDb); head != (common.Hash{}) {
+		if header := hc.GetHeaderByHash(head); header != nil {
+			hc.currentHeader, hc.currentHeaderHash = header, head
+		}
+	}
+	return hc, nil
+}
+
+// InsertHeaders inserts a batch of headers into a header-only chain, writing
+// each of them directly. It is the counterpart of BlockChain.InsertHeaderChain
+// and LightChain.InsertHeaderChain for chains without a parent structure.
+func (hc *HeaderChain) InsertHeaders(chain []*types.Header, checkFreq int) (int, error) {
+	hc.insertLock.Lock()
+	defer hc.insertLock.Unlock()
+
+	whFunc := func(header *types.Header) error {
+		_, err := hc.WriteHeader(header)
+		return err
+	}
+	return hc.InsertHeaderChain(chain, checkFreq, whFunc)
+}
+
 // GetBlockNumber retrieves the block number belonging to the given hash
 // from the cache or database
 func (hc *HeaderChain) GetBlockNumber(hash common.Hash) uint64 {
@@ -443,6 +482,11 @@ func (hc *HeaderChain) CurrentHeader() *types.Header {
 	return hc.currentHeader
 }
 
+// CurrentTd retrieves the total difficulty of the current head header.
+func (hc *HeaderChain) CurrentTd() *big.Int {
+	return hc.GetTd(hc.currentHeaderHash, hc.currentHeader.Number.Uint64())
+}
+
 // SetCurrentHeader sets the current head header of the canonical chain.
 func (hc *HeaderChain) SetCurrentHeader(head *types.Header) {
 	if err := WriteHeadHeaderHash(hc.chainDb, head.Hash()); err != nil {
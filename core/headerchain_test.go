@@ -0,0 +1,62 @@
+// Copyright 2014 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/EarthDollar/go-earthdollar/ethdb"
+)
+
+// Tests that a header-only chain can import headers without any block bodies,
+// tracking their total difficulty and restoring its head after a restart.
+func TestHeaderOnlyChain(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	genesis, _ := WriteTestNetGenesisBlock(db)
+
+	hc, err := NewHeaderOnlyChain(db, MakeChainConfig(), FakePow{})
+	if err != nil {
+		t.Fatalf("failed to create header chain: %v", err)
+	}
+	headers := makeHeaderChain(genesis.Header(), 10, db, canonicalSeed)
+	if n, err := hc.InsertHeaders(headers, 1); err != nil {
+		t.Fatalf("failed to insert header %d: %v", n, err)
+	}
+	head := headers[len(headers)-1]
+	if current := hc.CurrentHeader(); current.Hash() != head.Hash() {
+		t.Errorf("head header mismatch: have #%d [%x], want #%d [%x]", current.Number, current.Hash(), head.Number, head.Hash())
+	}
+	td := new(big.Int).Set(genesis.Difficulty())
+	for _, header := range headers {
+		td.Add(td, header.Difficulty)
+	}
+	if have := hc.CurrentTd(); have.Cmp(td) != 0 {
+		t.Errorf("total difficulty mismatch: have %v, want %v", have, td)
+	}
+	if body := GetBody(db, head.Hash(), head.Number.Uint64()); body != nil {
+		t.Errorf("block body written in header-only mode")
+	}
+	// Reopen the chain and ensure the head is restored
+	hc, err = NewHeaderOnlyChain(db, MakeChainConfig(), FakePow{})
+	if err != nil {
+		t.Fatalf("failed to reopen header chain: %v", err)
+	}
+	if current := hc.CurrentHeader(); current.Hash() != head.Hash() {
+		t.Errorf("restored head mismatch: have #%d [%x], want #%d [%x]", current.Number, current.Hash(), head.Number, head.Hash())
+	}
+}
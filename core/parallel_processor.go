@@ -0,0 +1,369 @@
+// Copyright 2015 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"runtime"
+	"sync"
+
+	"github.com/EarthDollar/go-earthdollar/common"
+	"github.com/EarthDollar/go-earthdollar/core/state"
+	"github.com/EarthDollar/go-earthdollar/core/types"
+	"github.com/EarthDollar/go-earthdollar/core/vm"
+	"github.com/EarthDollar/go-earthdollar/metrics"
+)
+
+var (
+	parallelHitMeter    = metrics.NewMeter("chain/parallel/hits")    // Speculative executions committed as is
+	parallelReexecMeter = metrics.NewMeter("chain/parallel/reexecs") // Transactions re-executed sequentially
+)
+
+// stateKey identifies a piece of state accessed by a transaction: either all
+// the fields of an account (balance, nonce, code, existence), or a single slot
+// of its storage.
+type stateKey struct {
+	addr    common.Address
+	slot    common.Hash
+	storage bool
+}
+
+// recordingState is a vm.StateDB wrapping a real state database, tracking the
+// read and write sets of a transaction execution and recording all the state
+// modifications, so they can be replayed onto a different state database if the
+// execution turns out to be independent of the transactions preceding it.
+type recordingState struct {
+	inner *state.StateDB
+
+	reads  map[stateKey]struct{}
+	writes map[stateKey]struct{}
+	unsafe bool // Set if the state was accessed in an untrackable way
+
+	ops       []func(vm.StateDB) // State modifications to replay, in order
+	snapshots map[int]int        // Number of recorded modifications at each snapshot
+}
+
+func newRecordingState(inner *state.StateDB) *recordingState {
+	return &recordingState{
+		inner:     inner,
+		reads:     make(map[stateKey]struct{}),
+		writes:    make(map[stateKey]struct{}),
+		snapshots: make(map[int]int),
+	}
+}
+
+func (s *recordingState) read(addr common.Address)  { s.reads[stateKey{addr: addr}] = struct{}{} }
+func (s *recordingState) write(addr common.Address) { s.writes[stateKey{addr: addr}] = struct{}{} }
+
+// record marks the account as written and appends a modification to replay.
+func (s *recordingState) record(addr common.Address, op func(vm.StateDB)) {
+	s.write(addr)
+	s.ops = append(s.ops, op)
+}
+
+// recordBalance appends a balance change to replay. As balance changes commute,
+// zero changes are only considered writes if they may delete an empty account.
+func (s *recordingState) recordBalance(addr common.Address, amount *big.Int, op func(vm.StateDB)) {
+	if amount.Sign() != 0 || s.inner.Empty(addr) {
+		s.write(addr)
+	}
+	s.ops = append(s.ops, op)
+}
+
+// conflicts reports whether the execution read any state written by others.
+func (s *recordingState) conflicts(written map[stateKey]struct{}) bool {
+	if s.unsafe {
+		return true
+	}
+	for key := range s.reads {
+		if _, ok := written[key]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// replay applies all the recorded state modifications onto the given database.
+func (s *recordingState) replay(db vm.StateDB) {
+	for _, op := range s.ops {
+		op(db)
+	}
+}
+
+func (s *recordingState) GetAccount(addr common.Address) vm.Account {
+	s.read(addr)
+	if !s.inner.Exist(addr) {
+		s.record(addr, func(db vm.StateDB) { db.GetAccount(addr) }) // Implicitly creates the account
+	}
+	return &recordingAccount{state: s, addr: addr, inner: s.inner.GetAccount(addr)}
+}
+
+func (s *recordingState) CreateAccount(addr common.Address) vm.Account {
+	s.record(addr, func(db vm.StateDB) { db.CreateAccount(addr) })
+	return &recordingAccount{state: s, addr: addr, inner: s.inner.CreateAccount(addr)}
+}
+
+func (s *recordingState) SubBalance(addr common.Address, amount *big.Int) {
+	amount = new(big.Int).Set(amount)
+	s.recordBalance(addr, amount, func(db vm.StateDB) { db.SubBalance(addr, amount) })
+	s.inner.SubBalance(addr, amount)
+}
+
+func (s *recordingState) AddBalance(addr common.Address, amount *big.Int) {
+	amount = new(big.Int).Set(amount)
+	s.recordBalance(addr, amount, func(db vm.StateDB) { db.AddBalance(addr, amount) })
+	s.inner.AddBalance(addr, amount)
+}
+
+func (s *recordingState) GetBalance(addr common.Address) *big.Int {
+	s.read(addr)
+	return s.inner.GetBalance(addr)
+}
+
+func (s *recordingState) GetNonce(addr common.Address) uint64 {
+	s.read(addr)
+	return s.inner.GetNonce(addr)
+}
+
+func (s *recordingState) SetNonce(addr common.Address, nonce uint64) {
+	s.record(addr, func(db vm.StateDB) { db.SetNonce(addr, nonce) })
+	s.inner.SetNonce(addr, nonce)
+}
+
+func (s *recordingState) GetCodeHash(addr common.Address) common.Hash {
+	s.read(addr)
+	return s.inner.GetCodeHash(addr)
+}
+
+func (s *recordingState) GetCode(addr common.Address) []byte {
+	s.read(addr)
+	return s.inner.GetCode(addr)
+}
+
+func (s *recordingState) SetCode(addr common.Address, code []byte) {
+	s.record(addr, func(db vm.StateDB) { db.SetCode(addr, code) })
+	s.inner.SetCode(addr, code)
+}
+
+func (s *recordingState) GetCodeSize(addr common.Address) int {
+	s.read(addr)
+	return s.inner.GetCodeSize(addr)
+}
+
+func (s *recordingState) AddRefund(gas *big.Int) { s.inner.AddRefund(gas) }
+func (s *recordingState) GetRefund() *big.Int    { return s.inner.GetRefund() }
+
+func (s *recordingState) GetState(addr common.Address, key common.Hash) common.Hash {
+	s.reads[stateKey{addr: addr, slot: key, storage: true}] = struct{}{}
+	return s.inner.GetState(addr, key)
+}
+
+func (s *recordingState) SetState(addr common.Address, key common.Hash, value common.Hash) {
+	s.writes[stateKey{addr: addr, slot: key, storage: true}] = struct{}{}
+	s.ops = append(s.ops, func(db vm.StateDB) { db.SetState(addr, key, value) })
+	s.inner.SetState(addr, key, value)
+}
+
+func (s *recordingState) Suicide(addr common.Address) bool {
+	s.read(addr)
+	s.record(addr, func(db vm.StateDB) { db.Suicide(addr) })
+	return s.inner.Suicide(addr)
+}
+
+func (s *recordingState) HasSuicided(addr common.Address) bool {
+	s.read(addr)
+	return s.inner.HasSuicided(addr)
+}
+
+func (s *recordingState) Exist(addr common.Address) bool {
+	s.read(addr)
+	return s.inner.Exist(addr)
+}
+
+func (s *recordingState) Empty(addr common.Address) bool {
+	s.read(addr)
+	return s.inner.Empty(addr)
+}
+
+func (s *recordingState) Snapshot() int {
+	id := s.inner.Snapshot()
+	s.snapshots[id] = len(s.ops)
+	return id
+}
+
+func (s *recordingState) RevertToSnapshot(id int) {
+	s.inner.RevertToSnapshot(id)
+	s.ops = s.ops[:s.snapshots[id]]
+}
+
+func (s *recordingState) AddLog(log *types.Log) {
+	s.ops = append(s.ops, func(db vm.StateDB) {
+		cpy := *log
+		db.AddLog(&cpy)
+	})
+	s.inner.AddLog(log)
+}
+
+func (s *recordingState) AddPreimage(hash common.Hash, preimage []byte) {
+	s.ops = append(s.ops, func(db vm.StateDB) { db.AddPreimage(hash, preimage) })
+	s.inner.AddPreimage(hash, preimage)
+}
+
+// recordingAccount is a vm.Account tracking its accesses through the owning
+// recording state.
+type recordingAccount struct {
+	state *recordingState
+	addr  common.Address
+	inner vm.Account
+}
+
+func (a *recordingAccount) SubBalance(amount *big.Int) { a.state.SubBalance(a.addr, amount) }
+func (a *recordingAccount) AddBalance(amount *big.Int) { a.state.AddBalance(a.addr, amount) }
+
+func (a *recordingAccount) SetBalance(amount *big.Int) {
+	amount = new(big.Int).Set(amount)
+	a.state.record(a.addr, func(db vm.StateDB) { db.GetAccount(a.addr).SetBalance(amount) })
+	a.inner.SetBalance(amount)
+}
+
+func (a *recordingAccount) SetNonce(nonce uint64) { a.state.SetNonce(a.addr, nonce) }
+
+func (a *recordingAccount) Balance() *big.Int {
+	a.state.read(a.addr)
+	return a.inner.Balance()
+}
+
+func (a *recordingAccount) Address() common.Address { return a.addr }
+func (a *recordingAccount) ReturnGas(gas *big.Int)  { a.inner.ReturnGas(gas) }
+
+func (a *recordingAccount) SetCode(hash common.Hash, code []byte) {
+	a.state.record(a.addr, func(db vm.StateDB) { db.SetCode(a.addr, code) })
+	a.inner.SetCode(hash, code)
+}
+
+func (a *recordingAccount) ForEachStorage(cb func(key, value common.Hash) bool) {
+	a.state.unsafe = true // Storage iteration cannot be tracked slot by slot
+	a.inner.ForEachStorage(cb)
+}
+
+func (a *recordingAccount) Value() *big.Int { return a.inner.Value() }
+
+// speculation is the result of executing a transaction on top of the parent
+// state of its block, independently of all the other transactions.
+type speculation struct {
+	state *recordingState
+	gas   *big.Int // Gas used by the transaction
+	err   error    // Failure of the execution, forcing a sequential re-execution
+}
+
+// processParallel executes the transactions of a block speculatively on top of
+// the parent state concurrently, then commits the results in order onto the
+// given state. Transactions which read any state written by the ones preceding
+// them (or which failed speculatively) are deterministically re-executed in
+// sequence, so the outcome is always identical to a sequential processing.
+func (p *StateProcessor) processParallel(block *types.Block, statedb *state.StateDB, gp *GasPool, usedGas *big.Int, cfg vm.Config) (types.Receipts, []*types.Log, error) {
+	var (
+		header = block.Header()
+		txs    = block.Transactions()
+		specs  = make([]*speculation, len(txs))
+	)
+	// Speculatively run all the transactions across the available cores, unless
+	// the execution cannot be isolated (missing parent state, tracing, JIT)
+	if parent := p.bc.GetBlock(block.ParentHash(), block.NumberU64()-1); parent != nil && cfg.Tracer == nil && !cfg.EnableJit {
+		var (
+			threads = runtime.NumCPU()
+			jobs    = make(chan int, len(txs))
+			pend    sync.WaitGroup
+		)
+		for i := range txs {
+			jobs <- i
+		}
+		close(jobs)
+
+		pend.Add(threads)
+		for i := 0; i < threads; i++ {
+			go func() {
+				defer pend.Done()
+				for index := range jobs {
+					specs[index] = p.speculate(parent.Root(), block, index, cfg)
+				}
+			}()
+		}
+		pend.Wait()
+	}
+	// Commit the speculative results in order, re-executing any conflicts
+	var (
+		receipts types.Receipts
+		logs     []*types.Log
+		written  = make(map[stateKey]struct{})
+	)
+	for i, tx := range txs {
+		statedb.StartRecord(tx.Hash(), block.Hash(), i)
+
+		var (
+			spec = specs[i]
+			msg  types.Message
+			err  error
+		)
+		if msg, err = tx.AsMessage(types.MakeSigner(p.config, header.Number)); err != nil {
+			return nil, nil, err
+		}
+		if spec == nil || spec.err != nil || spec.state.conflicts(written) || gp.SubGas(msg.Gas()) != nil {
+			// Speculation unusable, execute on top of the real state
+			parallelReexecMeter.Mark(1)
+
+			spec = &speculation{state: newRecordingState(statedb)}
+			if spec.gas, err = applyMessage(p.config, p.bc, gp, spec.state, header, msg, cfg); err != nil {
+				return nil, nil, err
+			}
+		} else {
+			// Speculation independent of preceding transactions, replay it
+			parallelHitMeter.Mark(1)
+
+			gp.AddGas(new(big.Int).Sub(msg.Gas(), spec.gas))
+			spec.state.replay(statedb)
+		}
+		for key := range spec.state.writes {
+			written[key] = struct{}{}
+		}
+		usedGas.Add(usedGas, spec.gas)
+		receipt := newReceipt(p.config, statedb, header, tx, msg, usedGas, spec.gas)
+
+		receipts = append(receipts, receipt)
+		logs = append(logs, receipt.Logs...)
+	}
+	return receipts, logs, nil
+}
+
+// speculate executes a single transaction of a block on top of a private copy
+// of the parent state.
+func (p *StateProcessor) speculate(root common.Hash, block *types.Block, index int, cfg vm.Config) *speculation {
+	statedb, err := state.New(root, p.bc.stateDb)
+	if err != nil {
+		return &speculation{err: err}
+	}
+	tx := block.Transactions()[index]
+	statedb.StartRecord(tx.Hash(), block.Hash(), index)
+
+	msg, err := tx.AsMessage(types.MakeSigner(p.config, block.Number()))
+	if err != nil {
+		return &speculation{err: err}
+	}
+	spec := &speculation{state: newRecordingState(statedb)}
+	spec.gas, spec.err = applyMessage(p.config, p.bc, new(GasPool).AddGas(block.GasLimit()), spec.state, block.Header(), msg, cfg)
+	return spec
+}
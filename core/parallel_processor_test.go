@@ -0,0 +1,159 @@
+// Copyright 2015 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/EarthDollar/go-earthdollar/common"
+	"github.com/EarthDollar/go-earthdollar/core/types"
+	"github.com/EarthDollar/go-earthdollar/core/vm"
+	"github.com/EarthDollar/go-earthdollar/crypto"
+	"github.com/EarthDollar/go-earthdollar/ethdb"
+	"github.com/EarthDollar/go-earthdollar/event"
+	"github.com/EarthDollar/go-earthdollar/params"
+)
+
+// counterCode deploys a contract incrementing its first storage slot on every
+// call: PUSH1 0 SLOAD PUSH1 1 ADD PUSH1 0 SSTORE STOP.
+var counterCode = common.FromHex("600a600c600039600a6000f3" + "60005460010160005500")
+
+// makeParallelTestChain generates a chain mixing independent transfers, nonce
+// dependent transactions of single senders and storage conflicts on a shared
+// contract.
+func makeParallelTestChain(t *testing.T) ([]*ecdsa.PrivateKey, []GenesisAccount, []*types.Block) {
+	var (
+		keys     = make([]*ecdsa.PrivateKey, 5)
+		accounts = make([]GenesisAccount, 5)
+		signer   = types.NewEIP155Signer(big.NewInt(1))
+	)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+		accounts[i] = GenesisAccount{crypto.PubkeyToAddress(keys[i].PublicKey), big.NewInt(1000000000)}
+	}
+	gendb, _ := ethdb.NewMemDatabase()
+	genesis := WriteGenesisBlockForTesting(gendb, accounts...)
+	counter := crypto.CreateAddress(accounts[0].Address, 0)
+
+	sign := func(gen *BlockGen, key *ecdsa.PrivateKey, to *common.Address, value int64, data []byte) {
+		var (
+			from  = crypto.PubkeyToAddress(key.PublicKey)
+			raw   *types.Transaction
+			price = big.NewInt(1)
+		)
+		if to == nil {
+			raw = types.NewContractCreation(gen.TxNonce(from), big.NewInt(value), big.NewInt(100000), price, data)
+		} else {
+			raw = types.NewTransaction(gen.TxNonce(from), *to, big.NewInt(value), big.NewInt(100000), price, data)
+		}
+		tx, err := types.SignTx(raw, signer, key)
+		if err != nil {
+			t.Fatalf("failed to sign transaction: %v", err)
+		}
+		gen.AddTx(tx)
+	}
+	blocks, _ := GenerateChain(params.TestChainConfig, genesis, gendb, 4, func(i int, gen *BlockGen) {
+		gen.SetCoinbase(common.Address{0x01})
+		if i == 0 {
+			sign(gen, keys[0], nil, 0, counterCode)
+			return
+		}
+		sign(gen, keys[0], &common.Address{0x10, byte(i)}, 1000, nil) // Independent transfers
+		sign(gen, keys[1], &common.Address{0x11, byte(i)}, 1000, nil)
+		sign(gen, keys[0], &common.Address{0x20}, 1000, nil) // Same sender, dependent nonce
+		sign(gen, keys[2], &counter, 0, nil)                 // Storage conflicts on a shared slot
+		sign(gen, keys[3], &counter, 0, nil)
+		sign(gen, keys[4], &accounts[1].Address, 1000, nil) // Transfer to an active sender
+	})
+	return keys, accounts, blocks
+}
+
+// Tests that importing blocks with speculative parallel execution produces the
+// very same state and receipts as the sequential processing they were generated
+// with.
+func TestParallelExecution(t *testing.T) {
+	_, accounts, blocks := makeParallelTestChain(t)
+
+	db, _ := ethdb.NewMemDatabase()
+	WriteGenesisBlockForTesting(db, accounts...)
+	blockchain, _ := NewBlockChain(db, testChainConfig(), FakePow{}, new(event.TypeMux), vm.Config{ParallelExecution: true})
+	defer blockchain.Stop()
+
+	if n, err := blockchain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to process block %d: %v", n, err)
+	}
+	head := blocks[len(blocks)-1]
+	if blockchain.CurrentBlock().Hash() != head.Hash() {
+		t.Fatalf("head mismatch: have #%d, want #%d", blockchain.CurrentBlock().NumberU64(), head.NumberU64())
+	}
+	statedb, err := blockchain.State()
+	if err != nil {
+		t.Fatalf("failed to retrieve head state: %v", err)
+	}
+	counter := crypto.CreateAddress(accounts[0].Address, 0)
+	if value := statedb.GetState(counter, common.Hash{}); value != common.BigToHash(big.NewInt(6)) {
+		t.Errorf("counter mismatch: have %x, want 6", value)
+	}
+}
+
+// Tests that speculative executions detect the transactions depending on the
+// state written by others.
+func TestSpeculationConflicts(t *testing.T) {
+	_, accounts, blocks := makeParallelTestChain(t)
+
+	db, _ := ethdb.NewMemDatabase()
+	WriteGenesisBlockForTesting(db, accounts...)
+	blockchain, _ := NewBlockChain(db, testChainConfig(), FakePow{}, new(event.TypeMux), vm.Config{})
+	defer blockchain.Stop()
+
+	if n, err := blockchain.InsertChain(blocks[:1]); err != nil {
+		t.Fatalf("failed to process block %d: %v", n, err)
+	}
+	var (
+		processor = blockchain.Processor().(*StateProcessor)
+		block     = blocks[1]
+		specs     = make([]*speculation, len(block.Transactions()))
+	)
+	for i := range specs {
+		specs[i] = processor.speculate(blocks[0].Root(), block, i, vm.Config{})
+		if specs[i].state == nil {
+			t.Fatalf("tx %d: speculation failed: %v", i, specs[i].err)
+		}
+	}
+	// The second transaction of the same sender must fail on top of the parent
+	if specs[2].err == nil {
+		t.Errorf("dependent transaction speculated successfully")
+	}
+	tests := []struct {
+		prev, next int
+		conflict   bool
+	}{
+		{0, 1, false}, // Independent transfers
+		{0, 2, true},  // Same sender
+		{1, 3, false}, // Transfer and contract call
+		{3, 4, true},  // Shared storage slot
+		{3, 5, false}, // Contract call and transfer
+		{1, 5, true},  // Transfer to a previous sender
+	}
+	for i, tt := range tests {
+		if conflict := specs[tt.next].state.conflicts(specs[tt.prev].state.writes); conflict != tt.conflict {
+			t.Errorf("test %d: tx %d after tx %d conflict mismatch: have %v, want %v", i, tt.next, tt.prev, conflict, tt.conflict)
+		}
+	}
+}
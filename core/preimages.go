@@ -0,0 +1,107 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"io"
+	"time"
+
+	"github.com/EarthDollar/go-earthdollar/common"
+	"github.com/EarthDollar/go-earthdollar/crypto"
+	"github.com/EarthDollar/go-earthdollar/ethdb"
+	"github.com/EarthDollar/go-earthdollar/logger"
+	"github.com/EarthDollar/go-earthdollar/logger/glog"
+	"github.com/EarthDollar/go-earthdollar/rlp"
+)
+
+// preimageImportBatch is the number of preimages collected before flushing them
+// into the database during an import.
+const preimageImportBatch = 1024
+
+// ExportPreimages writes all the trie key preimages recorded in the database to
+// the writer as a stream of RLP encoded byte strings, returning the number of
+// preimages exported. The hashes are not exported as they can be recomputed.
+func ExportPreimages(db *ethdb.LDBDatabase, w io.Writer, logInterval time.Duration) (int, error) {
+	var (
+		count  int
+		start  = time.Now()
+		logged = time.Now()
+	)
+	it := db.NewIteratorWithPrefix([]byte(preimagePrefix))
+	defer it.Release()
+
+	for it.Next() {
+		if len(it.Key()) != len(preimagePrefix)+common.HashLength {
+			continue
+		}
+		if err := rlp.Encode(w, it.Value()); err != nil {
+			return count, err
+		}
+		count++
+		if logInterval > 0 && time.Since(logged) > logInterval {
+			glog.V(logger.Info).Infof("Exporting preimages: %d exported in %v", count, time.Since(start))
+			logged = time.Now()
+		}
+	}
+	if err := it.Error(); err != nil {
+		return count, err
+	}
+	glog.V(logger.Info).Infof("Exported %d preimages in %v", count, time.Since(start))
+	return count, nil
+}
+
+// ImportPreimages reads a stream of RLP encoded preimages (as produced by
+// ExportPreimages) and stores them keyed by their hashes, returning the number
+// of preimages read.
+func ImportPreimages(db ethdb.Database, r io.Reader, logInterval time.Duration) (int, error) {
+	var (
+		count     int
+		start     = time.Now()
+		logged    = time.Now()
+		stream    = rlp.NewStream(r, 0)
+		preimages = make(map[common.Hash][]byte)
+	)
+	for {
+		var blob []byte
+		if err := stream.Decode(&blob); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return count, err
+		}
+		preimages[crypto.Keccak256Hash(blob)] = blob
+		count++
+
+		if len(preimages) >= preimageImportBatch {
+			if err := WritePreimages(db, 0, preimages); err != nil {
+				return count, err
+			}
+			preimages = make(map[common.Hash][]byte)
+		}
+		if logInterval > 0 && time.Since(logged) > logInterval {
+			glog.V(logger.Info).Infof("Importing preimages: %d imported in %v", count, time.Since(start))
+			logged = time.Now()
+		}
+	}
+	if len(preimages) > 0 {
+		if err := WritePreimages(db, 0, preimages); err != nil {
+			return count, err
+		}
+	}
+	glog.V(logger.Info).Infof("Imported %d preimages in %v", count, time.Since(start))
+	return count, nil
+}
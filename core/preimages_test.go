@@ -0,0 +1,80 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/EarthDollar/go-earthdollar/common"
+	"github.com/EarthDollar/go-earthdollar/crypto"
+	"github.com/EarthDollar/go-earthdollar/ethdb"
+)
+
+// Tests that preimages exported from one database can be imported into another
+// one, which is then able to resolve all of them by hash.
+func TestPreimageExportImport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eth-preimage-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := ethdb.NewLDBDatabase(dir, 0, 0)
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	preimages := make(map[common.Hash][]byte)
+	for i := 0; i < 2*preimageImportBatch+10; i++ {
+		blob := []byte(fmt.Sprintf("preimage #%d", i))
+		preimages[crypto.Keccak256Hash(blob)] = blob
+	}
+	if err := WritePreimages(db, 0, preimages); err != nil {
+		t.Fatalf("failed to write preimages: %v", err)
+	}
+	// Unrelated entries must not end up in the export
+	db.Put([]byte("secure-key-short"), []byte("junk"))
+	db.Put([]byte("unrelated"), []byte("junk"))
+
+	buf := new(bytes.Buffer)
+	exported, err := ExportPreimages(db, buf, 0)
+	if err != nil {
+		t.Fatalf("failed to export preimages: %v", err)
+	}
+	if exported != len(preimages) {
+		t.Fatalf("exported preimage count mismatch: have %d, want %d", exported, len(preimages))
+	}
+	memdb, _ := ethdb.NewMemDatabase()
+	imported, err := ImportPreimages(memdb, buf, 0)
+	if err != nil {
+		t.Fatalf("failed to import preimages: %v", err)
+	}
+	if imported != len(preimages) {
+		t.Fatalf("imported preimage count mismatch: have %d, want %d", imported, len(preimages))
+	}
+	table := PreimageTable(memdb)
+	for hash, blob := range preimages {
+		if have, err := table.Get(hash[:]); err != nil || !bytes.Equal(have, blob) {
+			t.Errorf("preimage %x mismatch: have %q (%v), want %q", hash, have, err, blob)
+		}
+	}
+}
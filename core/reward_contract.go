@@ -0,0 +1,97 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/EarthDollar/go-earthdollar/accounts/abi"
+	"github.com/EarthDollar/go-earthdollar/common"
+	"github.com/EarthDollar/go-earthdollar/core/state"
+	"github.com/EarthDollar/go-earthdollar/core/types"
+	"github.com/EarthDollar/go-earthdollar/core/vm"
+	"github.com/EarthDollar/go-earthdollar/logger"
+	"github.com/EarthDollar/go-earthdollar/logger/glog"
+	"github.com/EarthDollar/go-earthdollar/params"
+)
+
+// RewardContractABI is the interface the reward distribution contract must
+// implement. It is called with the recipients and amounts of all rewards of a
+// block, having been credited with their sum beforehand.
+const RewardContractABI = `[{"constant":false,"inputs":[{"name":"recipients","type":"address[]"},{"name":"amounts","type":"uint256[]"}],"name":"distribute","outputs":[],"type":"function"}]`
+
+// rewardContractABI is the parsed form of RewardContractABI.
+var rewardContractABI abi.ABI
+
+func init() {
+	parsed, err := abi.JSON(strings.NewReader(RewardContractABI))
+	if err != nil {
+		panic(err)
+	}
+	rewardContractABI = parsed
+}
+
+// systemCaller is the sender of calls made by the protocol itself rather than a
+// transaction, e.g. to the reward distribution contract.
+type systemCaller common.Address
+
+func (c systemCaller) ReturnGas(*big.Int)                                  {}
+func (c systemCaller) Address() common.Address                             { return common.Address(c) }
+func (c systemCaller) Value() *big.Int                                     { return new(big.Int) }
+func (c systemCaller) SetCode(common.Hash, []byte)                         {}
+func (c systemCaller) ForEachStorage(cb func(key, value common.Hash) bool) {}
+
+// distributeRewards credits the sum of the given rewards to the reward contract
+// and invokes it to distribute them. If the contract fails, all its changes are
+// reverted and false is returned, leaving the rewards to be paid directly.
+func distributeRewards(config *params.ChainConfig, chain HeaderFetcher, statedb *state.StateDB, header *types.Header, recipients []common.Address, amounts []*big.Int) bool {
+	input, err := rewardContractABI.Pack("distribute", recipients, amounts)
+	if err != nil {
+		glog.V(logger.Error).Infof("Failed to pack reward distribution: %v", err)
+		return false
+	}
+	total := new(big.Int)
+	for _, amount := range amounts {
+		total.Add(total, amount)
+	}
+	var (
+		contract = *config.RewardContract
+		snapshot = statedb.Snapshot()
+		context  = vm.Context{
+			CanTransfer: CanTransfer,
+			Transfer:    Transfer,
+			GetHash:     GetHashFn(header, chain),
+			Origin:      params.SystemAddress,
+			Coinbase:    header.Coinbase,
+			BlockNumber: new(big.Int).Set(header.Number),
+			Time:        new(big.Int).Set(header.Time),
+			Difficulty:  new(big.Int).Set(header.Difficulty),
+			GasLimit:    new(big.Int).Set(header.GasLimit),
+			GasPrice:    new(big.Int),
+		}
+	)
+	statedb.AddBalance(contract, total)
+
+	evm := vm.NewEVM(context, statedb, config, vm.Config{})
+	if _, err := evm.Call(systemCaller(params.SystemAddress), contract, input, new(big.Int).Set(params.RewardContractGas), new(big.Int)); err != nil {
+		glog.V(logger.Debug).Infof("Reward contract %x failed at block #%v: %v", contract[:4], header.Number, err)
+		statedb.RevertToSnapshot(snapshot)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,57 @@
+// Copyright 2015 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"github.com/EarthDollar/go-earthdollar/core/types"
+	"github.com/EarthDollar/go-earthdollar/crypto"
+	"github.com/EarthDollar/go-earthdollar/params"
+)
+
+// senderCacheSize is the number of recovered transaction signatures to keep
+// around, sized to cover a full transaction pool and a few blocks on top.
+const senderCacheSize = 16384
+
+// senderRecoverer is the signature recovery pipeline shared between the
+// transaction pool and the block import, ensuring that a transaction's sender
+// is never recovered twice, regardless of which path sees it first.
+var senderRecoverer = crypto.NewBatchRecoverer(0, senderCacheSize)
+
+// recoverSenders derives the senders of all the transactions contained in the
+// given blocks concurrently across the available CPU cores. The results are
+// cached in the transaction objects, so the sequential execution afterwards
+// does not need to run the costly signature recovery itself. Invalid signatures
+// are ignored here, they will be reported during execution.
+func recoverSenders(config *params.ChainConfig, blocks types.Blocks) {
+	// Batch up consecutive blocks with the same signing rules
+	var (
+		signer types.Signer
+		txs    types.Transactions
+	)
+	for _, block := range blocks {
+		next := types.MakeSigner(config, block.Number())
+		if signer != nil && !signer.Equal(next) {
+			types.RecoverSenders(senderRecoverer, signer, txs)
+			txs = nil
+		}
+		signer = next
+		txs = append(txs, block.Transactions()...)
+	}
+	if len(txs) > 0 {
+		types.RecoverSenders(senderRecoverer, signer, txs)
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright 2015 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/EarthDollar/go-earthdollar/common"
+	"github.com/EarthDollar/go-earthdollar/core/types"
+	"github.com/EarthDollar/go-earthdollar/crypto"
+	"github.com/EarthDollar/go-earthdollar/params"
+)
+
+// Tests that concurrently recovering the senders of a batch of blocks yields
+// the signing accounts of every transaction.
+func TestRecoverSenders(t *testing.T) {
+	keys := make([]*ecdsa.PrivateKey, 8)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+	}
+	var (
+		signer = types.MakeSigner(params.TestChainConfig, big.NewInt(1))
+		blocks types.Blocks
+	)
+	for i := 0; i < 4; i++ {
+		var txs types.Transactions
+		for j, key := range keys {
+			tx, err := types.SignTx(types.NewTransaction(uint64(i), common.Address{}, big.NewInt(int64(j)), params.TxGas, nil, nil), signer, key)
+			if err != nil {
+				t.Fatalf("failed to sign transaction: %v", err)
+			}
+			txs = append(txs, tx)
+		}
+		blocks = append(blocks, types.NewBlock(&types.Header{Number: big.NewInt(1)}, txs, nil, nil))
+	}
+	recoverSenders(params.TestChainConfig, blocks)
+
+	for i, block := range blocks {
+		for j, tx := range block.Transactions() {
+			from, err := types.Sender(signer, tx)
+			if err != nil {
+				t.Fatalf("block %d, tx %d: failed to retrieve sender: %v", i, j, err)
+			}
+			if want := crypto.PubkeyToAddress(keys[j].PublicKey); from != want {
+				t.Errorf("block %d, tx %d: sender mismatch: have %x, want %x", i, j, from, want)
+			}
+		}
+	}
+}
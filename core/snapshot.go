@@ -0,0 +1,183 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"time"
+
+	"github.com/EarthDollar/go-earthdollar/common"
+	"github.com/EarthDollar/go-earthdollar/core/state"
+	"github.com/EarthDollar/go-earthdollar/core/types"
+	"github.com/EarthDollar/go-earthdollar/ethdb"
+	"github.com/EarthDollar/go-earthdollar/logger"
+	"github.com/EarthDollar/go-earthdollar/logger/glog"
+	"github.com/EarthDollar/go-earthdollar/rlp"
+)
+
+const (
+	// snapshotVersion is the version of the state snapshot file format.
+	snapshotVersion = 1
+
+	// snapshotAncestors is the number of ancestors of the snapshot block included
+	// in a snapshot, as needed by the BLOCKHASH opcode and the uncle validation
+	// of the blocks built on top of it.
+	snapshotAncestors = 256
+)
+
+// errSnapshotChainExists is returned when importing a snapshot into a database
+// which already contains blocks beyond the genesis.
+var errSnapshotChainExists = errors.New("database already contains a chain")
+
+// snapshotHeader is the leading record of a state snapshot, followed by the
+// records of the state itself (see state.ExportState).
+type snapshotHeader struct {
+	Version     uint64
+	Genesis     *types.Block
+	Blocks      []*types.Block // Snapshot block preceded by its most recent ancestors
+	Td          *big.Int       // Total difficulty of the snapshot block
+	TotalSupply *big.Int       `rlp:"nil"` // Total supply at the snapshot block, if known
+}
+
+// ExportSnapshot writes the complete state at the given block, together with the
+// genesis and the most recent ancestors of the block, into w. The resulting file
+// can be used to bootstrap a new node at that block through ImportSnapshot.
+func ExportSnapshot(bc *BlockChain, block *types.Block, w io.Writer, logInterval time.Duration) (*state.SnapshotStats, error) {
+	td := bc.GetTd(block.Hash(), block.NumberU64())
+	if td == nil {
+		return nil, fmt.Errorf("unknown total difficulty of block #%d [%x…]", block.NumberU64(), block.Hash().Bytes()[:4])
+	}
+	if !bc.HasBlockAndState(block.Hash()) {
+		return nil, fmt.Errorf("missing state of block #%d [%x…]", block.NumberU64(), block.Hash().Bytes()[:4])
+	}
+	// Collect the recent ancestors, ordering them oldest first
+	blocks := bc.GetBlocksFromHash(block.Hash(), snapshotAncestors+1)
+	for i, j := 0, len(blocks)-1; i < j; i, j = i+1, j-1 {
+		blocks[i], blocks[j] = blocks[j], blocks[i]
+	}
+	header := snapshotHeader{
+		Version:     snapshotVersion,
+		Genesis:     bc.Genesis(),
+		Blocks:      blocks,
+		Td:          td,
+		TotalSupply: bc.GetTotalSupply(block.Hash(), block.NumberU64()),
+	}
+	if err := rlp.Encode(w, &header); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	stats, err := state.ExportState(bc.StateDatabase(), block.Root(), w, logInterval)
+	if err != nil {
+		return stats, err
+	}
+	glog.V(logger.Info).Infof("Exported state of block #%d [%x…]: %d accounts, %d slots, %d codes in %v", block.NumberU64(), block.Hash().Bytes()[:4], stats.Accounts, stats.Slots, stats.Codes, time.Since(start))
+	return stats, nil
+}
+
+// ImportSnapshot reads a state snapshot (as produced by ExportSnapshot) and
+// writes it into an empty database, setting the snapshot block as the head of
+// the chain. The state is verified against the root of the block before the
+// chain is touched. The new head block is returned.
+func ImportSnapshot(db ethdb.Database, r io.Reader, logInterval time.Duration) (*types.Block, *state.SnapshotStats, error) {
+	stream := rlp.NewStream(r, 0)
+
+	var header snapshotHeader
+	if err := stream.Decode(&header); err != nil {
+		return nil, nil, fmt.Errorf("invalid snapshot header: %v", err)
+	}
+	if header.Version != snapshotVersion {
+		return nil, nil, fmt.Errorf("unsupported snapshot version %d (want %d)", header.Version, snapshotVersion)
+	}
+	if len(header.Blocks) == 0 || header.Genesis == nil || header.Td == nil {
+		return nil, nil, errors.New("incomplete snapshot header")
+	}
+	for i := 1; i < len(header.Blocks); i++ {
+		if header.Blocks[i].ParentHash() != header.Blocks[i-1].Hash() || header.Blocks[i].NumberU64() != header.Blocks[i-1].NumberU64()+1 {
+			return nil, nil, fmt.Errorf("non contiguous snapshot blocks #%d and #%d", header.Blocks[i-1].NumberU64(), header.Blocks[i].NumberU64())
+		}
+	}
+	head := header.Blocks[len(header.Blocks)-1]
+
+	// Refuse to overwrite an existing chain
+	genesis := header.Genesis
+	if stored := GetCanonicalHash(db, 0); stored != (common.Hash{}) && stored != genesis.Hash() {
+		return nil, nil, &GenesisMismatchError{Stored: stored, New: genesis.Hash()}
+	}
+	if hash := GetHeadBlockHash(db); hash != (common.Hash{}) && hash != genesis.Hash() {
+		return nil, nil, errSnapshotChainExists
+	}
+	// Rebuild the state and make sure it's the one the head block commits to
+	start := time.Now()
+	root, stats, err := state.ImportState(db, stream, logInterval)
+	if err != nil {
+		return nil, stats, err
+	}
+	if root != head.Root() {
+		return nil, stats, fmt.Errorf("state root mismatch: have %x, want %x", root, head.Root())
+	}
+	glog.V(logger.Info).Infof("Imported state of block #%d [%x…]: %d accounts, %d slots, %d codes in %v", head.NumberU64(), head.Hash().Bytes()[:4], stats.Accounts, stats.Slots, stats.Codes, time.Since(start))
+
+	// Write the genesis and the recent blocks, deriving the total difficulty of
+	// the ancestors from that of the head
+	if err := WriteTd(db, genesis.Hash(), 0, genesis.Difficulty()); err != nil {
+		return nil, stats, err
+	}
+	if err := WriteBlock(db, genesis); err != nil {
+		return nil, stats, err
+	}
+	if err := WriteCanonicalHash(db, genesis.Hash(), 0); err != nil {
+		return nil, stats, err
+	}
+	td := new(big.Int).Set(header.Td)
+	for i := len(header.Blocks) - 1; i >= 0; i-- {
+		block := header.Blocks[i]
+		if err := WriteTd(db, block.Hash(), block.NumberU64(), td); err != nil {
+			return nil, stats, err
+		}
+		if err := WriteBlock(db, block); err != nil {
+			return nil, stats, err
+		}
+		if err := WriteCanonicalHash(db, block.Hash(), block.NumberU64()); err != nil {
+			return nil, stats, err
+		}
+		if err := WriteTransactions(db, block); err != nil {
+			return nil, stats, err
+		}
+		td = new(big.Int).Sub(td, block.Difficulty())
+	}
+	if err := WriteTxLookupTail(db, header.Blocks[0].NumberU64()); err != nil {
+		return nil, stats, err
+	}
+	if header.TotalSupply != nil {
+		if err := WriteTotalSupply(db, head.Hash(), head.NumberU64(), header.TotalSupply); err != nil {
+			return nil, stats, err
+		}
+	}
+	if err := WriteHeadHeaderHash(db, head.Hash()); err != nil {
+		return nil, stats, err
+	}
+	if err := WriteHeadFastBlockHash(db, head.Hash()); err != nil {
+		return nil, stats, err
+	}
+	if err := WriteHeadBlockHash(db, head.Hash()); err != nil {
+		return nil, stats, err
+	}
+	return head, stats, nil
+}
@@ -0,0 +1,118 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/EarthDollar/go-earthdollar/common"
+	"github.com/EarthDollar/go-earthdollar/core/vm"
+	"github.com/EarthDollar/go-earthdollar/ethdb"
+	"github.com/EarthDollar/go-earthdollar/event"
+	"github.com/EarthDollar/go-earthdollar/params"
+)
+
+// Tests that a state snapshot exported from a chain bootstraps a new node at the
+// snapshot block, which is then able to continue importing the chain.
+func TestSnapshotExportImport(t *testing.T) {
+	var (
+		contract = common.Address{0xcc}
+		slot     = common.Hash{0x01}
+		spec     = &Genesis{
+			ChainConfig: params.TestChainConfig,
+			Nonce:       "0x0",
+			GasLimit:    "0x47e7c4",
+			Difficulty:  "0x20000",
+			Alloc: map[string]GenesisAlloc{
+				common.ToHex(common.Address{0xaa}.Bytes()): {Balance: "1000000000"},
+				common.ToHex(contract.Bytes()): {
+					Balance: "1",
+					Code:    "0x6001600055",
+					Storage: map[string]string{common.ToHex(slot.Bytes()): "0x2a"},
+				},
+			},
+		}
+		gendb, _ = ethdb.NewMemDatabase()
+	)
+	genesis, err := WriteGenesis(gendb, spec)
+	if err != nil {
+		t.Fatalf("failed to write genesis: %v", err)
+	}
+	blocks, _ := GenerateChain(params.TestChainConfig, genesis, gendb, 12, func(i int, block *BlockGen) {
+		block.SetCoinbase(common.Address{byte(i + 1)})
+	})
+	// Import half the chain into a source node and snapshot its head
+	srcdb, _ := ethdb.NewMemDatabase()
+	WriteGenesis(srcdb, spec)
+	src, err := NewBlockChain(srcdb, testChainConfig(), FakePow{}, new(event.TypeMux), vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to create source chain: %v", err)
+	}
+	defer src.Stop()
+	if n, err := src.InsertChain(blocks[:6]); err != nil {
+		t.Fatalf("failed to insert block %d: %v", n, err)
+	}
+	buf := new(bytes.Buffer)
+	stats, err := ExportSnapshot(src, src.CurrentBlock(), buf, 0)
+	if err != nil {
+		t.Fatalf("failed to export snapshot: %v", err)
+	}
+	if stats.Accounts != 8 || stats.Slots != 1 || stats.Codes != 1 {
+		t.Errorf("export stats mismatch: have %+v, want 8 accounts, 1 slot, 1 code", stats)
+	}
+	snapshot := buf.Bytes()
+
+	// Bootstrap a new node from the snapshot and check its state
+	dstdb, _ := ethdb.NewMemDatabase()
+	head, _, err := ImportSnapshot(dstdb, bytes.NewReader(snapshot), 0)
+	if err != nil {
+		t.Fatalf("failed to import snapshot: %v", err)
+	}
+	if head.Hash() != blocks[5].Hash() {
+		t.Fatalf("head mismatch: have #%d [%x…], want #%d [%x…]", head.NumberU64(), head.Hash().Bytes()[:4], blocks[5].NumberU64(), blocks[5].Hash().Bytes()[:4])
+	}
+	dst, err := NewBlockChain(dstdb, testChainConfig(), FakePow{}, new(event.TypeMux), vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to create bootstrapped chain: %v", err)
+	}
+	defer dst.Stop()
+	if dst.CurrentBlock().Hash() != head.Hash() {
+		t.Fatalf("bootstrapped chain head mismatch: have #%d, want #%d", dst.CurrentBlock().NumberU64(), head.NumberU64())
+	}
+	if have, want := dst.GetTd(head.Hash(), head.NumberU64()), src.GetTd(head.Hash(), head.NumberU64()); have.Cmp(want) != 0 {
+		t.Errorf("head total difficulty mismatch: have %v, want %v", have, want)
+	}
+	statedb, err := dst.State()
+	if err != nil {
+		t.Fatalf("failed to open bootstrapped state: %v", err)
+	}
+	if have, want := statedb.GetState(contract, slot), common.HexToHash("0x2a"); have != want {
+		t.Errorf("storage slot mismatch: have %x, want %x", have, want)
+	}
+	if have, want := statedb.GetCode(contract), common.FromHex("0x6001600055"); !bytes.Equal(have, want) {
+		t.Errorf("code mismatch: have %x, want %x", have, want)
+	}
+	// The bootstrapped node must be able to continue the chain
+	if n, err := dst.InsertChain(blocks[6:]); err != nil {
+		t.Fatalf("failed to continue bootstrapped chain at block %d: %v", n, err)
+	}
+	// Snapshots must not overwrite existing chains
+	if _, _, err := ImportSnapshot(srcdb, bytes.NewReader(snapshot), 0); err != errSnapshotChainExists {
+		t.Errorf("import into existing chain: have error %v, want %v", err, errSnapshotChainExists)
+	}
+}
@@ -0,0 +1,38 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"github.com/EarthDollar/go-earthdollar/common"
+	"github.com/EarthDollar/go-earthdollar/rlp"
+	"github.com/EarthDollar/go-earthdollar/trie"
+)
+
+// FlushState persists the state trie rooted at root from a node buffer into its
+// backing database, along with all the storage tries and contract code referenced
+// by the accounts. Any buffered state not needed by the retained state roots is
+// garbage collected. The number of written and dropped entries is returned.
+func FlushState(buffer *trie.NodeBuffer, root common.Hash, retain []common.Hash) (int, int, error) {
+	callback := func(leaf []byte) ([]common.Hash, []common.Hash) {
+		var obj Account
+		if err := rlp.DecodeBytes(leaf, &obj); err != nil {
+			return nil, nil
+		}
+		return []common.Hash{obj.Root}, []common.Hash{common.BytesToHash(obj.CodeHash)}
+	}
+	return buffer.Flush(root, retain, callback)
+}
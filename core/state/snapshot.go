@@ -0,0 +1,231 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math/big"
+	"time"
+
+	"github.com/EarthDollar/go-earthdollar/common"
+	"github.com/EarthDollar/go-earthdollar/crypto"
+	"github.com/EarthDollar/go-earthdollar/ethdb"
+	"github.com/EarthDollar/go-earthdollar/logger"
+	"github.com/EarthDollar/go-earthdollar/logger/glog"
+	"github.com/EarthDollar/go-earthdollar/rlp"
+	"github.com/EarthDollar/go-earthdollar/trie"
+)
+
+// snapshotFlushInterval is the number of trie insertions after which an import
+// commits the pending trie nodes into the database to bound its memory use.
+const snapshotFlushInterval = 100000
+
+// snapshotAccount is the representation of an account in a state snapshot. It
+// is followed in the stream by exactly Slots storage entries of the account.
+type snapshotAccount struct {
+	Hash    common.Hash // Hash of the account address (key in the account trie)
+	Nonce   uint64
+	Balance *big.Int
+	Code    []byte
+	Slots   uint64 // Number of storage slots following the account
+}
+
+// snapshotSlot is the representation of a storage slot in a state snapshot.
+type snapshotSlot struct {
+	Hash  common.Hash // Hash of the slot key (key in the storage trie)
+	Value []byte      // RLP encoded slot value, as stored in the trie
+}
+
+// SnapshotStats summarises the contents of a state snapshot.
+type SnapshotStats struct {
+	Accounts int // Number of accounts in the state
+	Slots    int // Number of storage slots across all accounts
+	Codes    int // Number of accounts with contract code
+}
+
+// ExportState writes the complete state identified by root (accounts, storage
+// and code) to w as a stream of RLP encoded records. Since entries are keyed by
+// their trie hashes, no preimages are needed to export nor to import the state.
+func ExportState(db trie.Database, root common.Hash, w io.Writer, logInterval time.Duration) (*SnapshotStats, error) {
+	tr, err := trie.New(root, db)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		stats  = new(SnapshotStats)
+		start  = time.Now()
+		logged = time.Now()
+	)
+	it := tr.Iterator()
+	for it.Next() {
+		var data Account
+		if err := rlp.DecodeBytes(it.Value, &data); err != nil {
+			return stats, fmt.Errorf("invalid account %x: %v", it.Key, err)
+		}
+		account := snapshotAccount{Hash: common.BytesToHash(it.Key), Nonce: data.Nonce, Balance: data.Balance}
+		if !bytes.Equal(data.CodeHash, emptyCodeHash) {
+			if account.Code, err = db.Get(data.CodeHash); err != nil {
+				return stats, fmt.Errorf("missing code %x of account %x: %v", data.CodeHash, account.Hash, err)
+			}
+			stats.Codes++
+		}
+		storage, err := trie.New(data.Root, db)
+		if err != nil {
+			return stats, err
+		}
+		// The slot count precedes the slots, so the storage is traversed twice
+		// instead of being held in memory.
+		sit := storage.Iterator()
+		for sit.Next() {
+			account.Slots++
+		}
+		if err := sit.Error(); err != nil {
+			return stats, err
+		}
+		if err := rlp.Encode(w, account); err != nil {
+			return stats, err
+		}
+		sit = storage.Iterator()
+		for sit.Next() {
+			if err := rlp.Encode(w, snapshotSlot{common.BytesToHash(sit.Key), sit.Value}); err != nil {
+				return stats, err
+			}
+		}
+		if err := sit.Error(); err != nil {
+			return stats, err
+		}
+		stats.Accounts++
+		stats.Slots += int(account.Slots)
+
+		if logInterval > 0 && time.Since(logged) > logInterval {
+			glog.V(logger.Info).Infof("Exporting state: %d accounts, %d slots in %v", stats.Accounts, stats.Slots, time.Since(start))
+			logged = time.Now()
+		}
+	}
+	if err := it.Error(); err != nil {
+		return stats, err
+	}
+	return stats, nil
+}
+
+// snapshotTrie is a trie being rebuilt from a snapshot, which is periodically
+// flushed into the database to bound the memory used by the import.
+type snapshotTrie struct {
+	*trie.Trie
+	db      ethdb.Database
+	pending int // Number of insertions since the last flush
+}
+
+func newSnapshotTrie(db ethdb.Database) *snapshotTrie {
+	tr, _ := trie.New(common.Hash{}, db)
+	return &snapshotTrie{Trie: tr, db: db}
+}
+
+// commit writes the nodes of the trie into the database, returning its root.
+func (t *snapshotTrie) commit() (common.Hash, error) {
+	batch := t.db.NewBatch()
+	root, err := t.CommitTo(batch)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	// Committed nodes may be unloaded and resolved again, so they must be
+	// present in the database before the trie is used any further.
+	if err := batch.Write(); err != nil {
+		return common.Hash{}, err
+	}
+	t.pending = 0
+	return root, nil
+}
+
+// insert adds an entry to the trie, flushing the pending nodes if needed.
+func (t *snapshotTrie) insert(key, value []byte) error {
+	if err := t.TryUpdate(key, value); err != nil {
+		return err
+	}
+	if t.pending++; t.pending >= snapshotFlushInterval {
+		_, err := t.commit()
+		return err
+	}
+	return nil
+}
+
+// ImportState reads a state snapshot (as produced by ExportState) from the
+// stream, storing all the tries and code into the database and returning the
+// root hash of the rebuilt state. It is up to the caller to check the returned
+// root against the one expected.
+func ImportState(db ethdb.Database, stream *rlp.Stream, logInterval time.Duration) (common.Hash, *SnapshotStats, error) {
+	var (
+		stats    = new(SnapshotStats)
+		start    = time.Now()
+		logged   = time.Now()
+		accounts = newSnapshotTrie(db)
+	)
+	for {
+		var account snapshotAccount
+		if err := stream.Decode(&account); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return common.Hash{}, stats, fmt.Errorf("account #%d: %v", stats.Accounts, err)
+		}
+		// Rebuild the storage trie of the account
+		storage := newSnapshotTrie(db)
+		for i := uint64(0); i < account.Slots; i++ {
+			var slot snapshotSlot
+			if err := stream.Decode(&slot); err != nil {
+				return common.Hash{}, stats, fmt.Errorf("account %x slot #%d: %v", account.Hash, i, err)
+			}
+			if err := storage.insert(slot.Hash[:], slot.Value); err != nil {
+				return common.Hash{}, stats, err
+			}
+		}
+		root, err := storage.commit()
+		if err != nil {
+			return common.Hash{}, stats, err
+		}
+		// Store the code and insert the account itself
+		codeHash := emptyCodeHash
+		if len(account.Code) > 0 {
+			codeHash = crypto.Keccak256(account.Code)
+			if err := db.Put(codeHash, account.Code); err != nil {
+				return common.Hash{}, stats, err
+			}
+			stats.Codes++
+		}
+		blob, err := rlp.EncodeToBytes(Account{Nonce: account.Nonce, Balance: account.Balance, Root: root, CodeHash: codeHash})
+		if err != nil {
+			return common.Hash{}, stats, err
+		}
+		if err := accounts.insert(account.Hash[:], blob); err != nil {
+			return common.Hash{}, stats, err
+		}
+		stats.Accounts++
+		stats.Slots += int(account.Slots)
+
+		if logInterval > 0 && time.Since(logged) > logInterval {
+			glog.V(logger.Info).Infof("Importing state: %d accounts, %d slots in %v", stats.Accounts, stats.Slots, time.Since(start))
+			logged = time.Now()
+		}
+	}
+	root, err := accounts.commit()
+	if err != nil {
+		return common.Hash{}, stats, err
+	}
+	return root, stats, nil
+}
@@ -35,13 +35,14 @@ import (
 	lru "github.com/hashicorp/golang-lru"
 )
 
-// Trie cache generation limit after which to evic trie nodes from memory.
-var MaxTrieCacheGen = uint16(120)
-
 const (
-	// Number of past tries to keep. This value is chosen such that
+	// Default trie cache generation limit after which to evict trie nodes from
+	// memory.
+	DefaultTrieCacheGen = uint16(120)
+
+	// Default number of past tries to keep. The value is chosen such that
 	// reasonable chain reorg depths will hit an existing trie.
-	maxPastTries = 12
+	DefaultPastTries = 12
 
 	// Number of codehash->size associations to keep.
 	codeSizeCacheSize = 100000
@@ -63,6 +64,9 @@ type StateDB struct {
 	pastTries     []*trie.SecureTrie
 	codeSizeCache *lru.Cache
 
+	trieCacheGen uint16 // Trie cache generation limit after which to evict trie nodes from memory
+	maxPastTries int    // Number of past tries to keep for reuse
+
 	// This map holds 'live' objects, which will get modified while processing a state transition.
 	stateObjects      map[common.Address]*StateObject
 	stateObjectsDirty map[common.Address]struct{}
@@ -75,7 +79,8 @@ type StateDB struct {
 	logs         map[common.Hash][]*types.Log
 	logSize      uint
 
-	preimages map[common.Hash][]byte
+	preimages     map[common.Hash][]byte
+	triePreimages bool // Whether to record the preimages of all accessed trie keys
 
 	// Journal of state modifications. This is the backbone of
 	// Snapshot and RevertToSnapshot.
@@ -88,7 +93,7 @@ type StateDB struct {
 
 // Create a new state from a given trie
 func New(root common.Hash, db ethdb.Database) (*StateDB, error) {
-	tr, err := trie.NewSecure(root, db, MaxTrieCacheGen)
+	tr, err := trie.NewSecure(root, db, DefaultTrieCacheGen)
 	if err != nil {
 		return nil, err
 	}
@@ -97,6 +102,8 @@ func New(root common.Hash, db ethdb.Database) (*StateDB, error) {
 		db:                db,
 		trie:              tr,
 		codeSizeCache:     csc,
+		trieCacheGen:      DefaultTrieCacheGen,
+		maxPastTries:      DefaultPastTries,
 		stateObjects:      make(map[common.Address]*StateObject),
 		stateObjectsDirty: make(map[common.Address]struct{}),
 		refund:            new(big.Int),
@@ -119,11 +126,14 @@ func (self *StateDB) New(root common.Hash) (*StateDB, error) {
 		db:                self.db,
 		trie:              tr,
 		codeSizeCache:     self.codeSizeCache,
+		trieCacheGen:      self.trieCacheGen,
+		maxPastTries:      self.maxPastTries,
 		stateObjects:      make(map[common.Address]*StateObject),
 		stateObjectsDirty: make(map[common.Address]struct{}),
 		refund:            new(big.Int),
 		logs:              make(map[common.Hash][]*types.Log),
 		preimages:         make(map[common.Hash][]byte),
+		triePreimages:     self.triePreimages,
 	}, nil
 }
 
@@ -160,14 +170,14 @@ func (self *StateDB) openTrie(root common.Hash) (*trie.SecureTrie, error) {
 			return &tr, nil
 		}
 	}
-	return trie.NewSecure(root, self.db, MaxTrieCacheGen)
+	return trie.NewSecure(root, self.db, self.trieCacheGen)
 }
 
 func (self *StateDB) pushTrie(t *trie.SecureTrie) {
 	self.lock.Lock()
 	defer self.lock.Unlock()
 
-	if len(self.pastTries) >= maxPastTries {
+	if len(self.pastTries) >= self.maxPastTries {
 		copy(self.pastTries, self.pastTries[1:])
 		self.pastTries[len(self.pastTries)-1] = t
 	} else {
@@ -219,6 +229,44 @@ func (self *StateDB) Preimages() map[common.Hash][]byte {
 	return self.preimages
 }
 
+// RecordTriePreimages sets whether the preimages of all the account addresses
+// and storage keys accessed in the state tries are recorded alongside the SHA3
+// preimages seen by the VM.
+func (self *StateDB) RecordTriePreimages(enabled bool) {
+	self.triePreimages = enabled
+}
+
+// SetCacheLimits sets the number of generations trie nodes are kept in memory
+// for and the number of past tries retained for reuse by subsequently derived
+// states. Zero values leave the respective limit unchanged.
+func (self *StateDB) SetCacheLimits(gens uint16, pastTries int) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	if gens > 0 {
+		self.trieCacheGen = gens
+	}
+	if pastTries > 0 {
+		self.maxPastTries = pastTries
+		if len(self.pastTries) > pastTries {
+			self.pastTries = self.pastTries[len(self.pastTries)-pastTries:]
+		}
+	}
+}
+
+// addTriePreimage records the preimage of a trie key about to be accessed, if
+// trie preimage recording is enabled. Contrary to AddPreimage this is not
+// journalled, as the key was accessed even if the changes are reverted.
+func (self *StateDB) addTriePreimage(key []byte) {
+	if !self.triePreimages {
+		return
+	}
+	hash := crypto.Keccak256Hash(key)
+	if _, ok := self.preimages[hash]; !ok {
+		self.preimages[hash] = common.CopyBytes(key)
+	}
+}
+
 func (self *StateDB) AddRefund(gas *big.Int) {
 	self.journal = append(self.journal, refundChange{prev: new(big.Int).Set(self.refund)})
 	self.refund.Add(self.refund, gas)
@@ -302,6 +350,23 @@ func (self *StateDB) GetState(a common.Address, b common.Hash) common.Hash {
 	return common.Hash{}
 }
 
+// AccessedStorage returns the storage slots loaded or modified in each contract
+// since the state was created or last reset.
+func (self *StateDB) AccessedStorage() map[common.Address][]common.Hash {
+	accessed := make(map[common.Address][]common.Hash)
+	for addr, stateObject := range self.stateObjects {
+		if len(stateObject.cachedStorage) == 0 {
+			continue
+		}
+		slots := make([]common.Hash, 0, len(stateObject.cachedStorage))
+		for key := range stateObject.cachedStorage {
+			slots = append(slots, key)
+		}
+		accessed[addr] = slots
+	}
+	return accessed
+}
+
 func (self *StateDB) HasSuicided(addr common.Address) bool {
 	stateObject := self.GetStateObject(addr)
 	if stateObject != nil {
@@ -410,6 +475,7 @@ func (self *StateDB) GetStateObject(addr common.Address) (stateObject *StateObje
 	}
 
 	// Load the object from the database.
+	self.addTriePreimage(addr[:])
 	enc := self.trie.Get(addr[:])
 	if len(enc) == 0 {
 		return nil
@@ -492,12 +558,15 @@ func (self *StateDB) Copy() *StateDB {
 		trie:              self.trie,
 		pastTries:         self.pastTries,
 		codeSizeCache:     self.codeSizeCache,
+		trieCacheGen:      self.trieCacheGen,
+		maxPastTries:      self.maxPastTries,
 		stateObjects:      make(map[common.Address]*StateObject, len(self.stateObjectsDirty)),
 		stateObjectsDirty: make(map[common.Address]struct{}, len(self.stateObjectsDirty)),
 		refund:            new(big.Int).Set(self.refund),
 		logs:              make(map[common.Hash][]*types.Log, len(self.logs)),
 		logSize:           self.logSize,
 		preimages:         make(map[common.Hash][]byte),
+		triePreimages:     self.triePreimages,
 	}
 	// Copy the dirty states, logs, and preimages
 	for addr := range self.stateObjectsDirty {
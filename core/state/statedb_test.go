@@ -30,6 +30,7 @@ import (
 
 	"github.com/EarthDollar/go-earthdollar/common"
 	"github.com/EarthDollar/go-earthdollar/core/types"
+	"github.com/EarthDollar/go-earthdollar/crypto"
 	"github.com/EarthDollar/go-earthdollar/ethdb"
 )
 
@@ -374,3 +375,72 @@ func TestTouchDelete(t *testing.T) {
 		t.Fatal("expected no dirty state object")
 	}
 }
+
+// Tests that the preimages of accessed trie keys are only recorded if enabled,
+// and that they survive resets and copies of the state.
+func TestTriePreimages(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	state, _ := New(common.Hash{}, db)
+
+	addr := common.BytesToAddress([]byte{0x01})
+	state.SetState(addr, common.Hash{0x02}, common.Hash{0x03})
+	root, _ := state.Commit(false)
+
+	var (
+		key     = common.Hash{0x02}
+		missing = common.BytesToAddress([]byte{0x04})
+	)
+	for _, enabled := range []bool{false, true} {
+		state.RecordTriePreimages(enabled)
+		state.Reset(root)
+		state = state.Copy()
+
+		state.GetState(addr, key)
+		state.GetBalance(missing)
+
+		preimages := state.Preimages()
+		for _, preimage := range [][]byte{addr[:], key[:], missing[:]} {
+			have, ok := preimages[crypto.Keccak256Hash(preimage)]
+			if ok != enabled {
+				t.Errorf("enabled %v: preimage %x recorded: %v", enabled, preimage, ok)
+			}
+			if ok && !bytes.Equal(have, preimage) {
+				t.Errorf("enabled %v: preimage mismatch: have %x, want %x", enabled, have, preimage)
+			}
+		}
+	}
+}
+
+// Tests that the cache limits are specific to a state database and are inherited
+// by the states derived from it, allowing independent chains in one process.
+func TestCacheLimits(t *testing.T) {
+	db1, _ := ethdb.NewMemDatabase()
+	db2, _ := ethdb.NewMemDatabase()
+	limited, _ := New(common.Hash{}, db1)
+	limited.SetCacheLimits(16, 2)
+	unlimited, _ := New(common.Hash{}, db2)
+
+	addr := common.BytesToAddress([]byte{0x01})
+	for i := 0; i < 5; i++ {
+		limited.AddBalance(addr, big.NewInt(1))
+		limited.Commit(false)
+		unlimited.AddBalance(addr, big.NewInt(1))
+		unlimited.Commit(false)
+	}
+	if n := len(limited.pastTries); n != 2 {
+		t.Errorf("limited state past tries mismatch: have %d, want %d", n, 2)
+	}
+	if n := len(unlimited.pastTries); n != 5 {
+		t.Errorf("default state past tries mismatch: have %d, want %d", n, 5)
+	}
+	derived, err := limited.New(limited.IntermediateRoot(false))
+	if err != nil {
+		t.Fatalf("failed to derive state: %v", err)
+	}
+	if derived.trieCacheGen != 16 || derived.maxPastTries != 2 {
+		t.Errorf("derived state limits mismatch: have %d/%d, want %d/%d", derived.trieCacheGen, derived.maxPastTries, 16, 2)
+	}
+	if unlimited.trieCacheGen != DefaultTrieCacheGen || unlimited.maxPastTries != DefaultPastTries {
+		t.Errorf("default state limits changed: have %d/%d", unlimited.trieCacheGen, unlimited.maxPastTries)
+	}
+}
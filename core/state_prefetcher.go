@@ -0,0 +1,128 @@
+// Copyright 2015 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"sync/atomic"
+
+	"github.com/EarthDollar/go-earthdollar/common"
+	"github.com/EarthDollar/go-earthdollar/core/state"
+	"github.com/EarthDollar/go-earthdollar/core/types"
+	"github.com/EarthDollar/go-earthdollar/ethdb"
+	"github.com/EarthDollar/go-earthdollar/metrics"
+	"github.com/EarthDollar/go-earthdollar/params"
+	"github.com/hashicorp/golang-lru"
+)
+
+const (
+	prefetchContractLimit = 4096 // Maximum number of contracts to track storage accesses for
+	prefetchSlotLimit     = 32   // Maximum number of storage slots to prefetch per contract
+)
+
+var (
+	prefetchAccountMeter = metrics.NewMeter("chain/prefetch/accounts")
+	prefetchSlotMeter    = metrics.NewMeter("chain/prefetch/slots")
+)
+
+// statePrefetcher warms up the state trie nodes a block is expected to touch,
+// running concurrently with the actual transaction execution. It loads the
+// accounts of the senders and recipients of the transactions, along with the
+// storage slots recently accessed in the recipient contracts, so that by the
+// time the processor gets to them, the trie nodes are already in the database
+// caches instead of still on disk.
+//
+// The prefetcher operates on its own copy of the state, so the results of the
+// lookups themselves are discarded, only the side effect of loading the data
+// is kept.
+type statePrefetcher struct {
+	config *params.ChainConfig // Chain configuration options
+	db     ethdb.Database      // Database to load the state trie nodes from
+	slots  *lru.Cache          // Recently accessed storage slots per contract (address -> []common.Hash)
+}
+
+// newStatePrefetcher creates a prefetcher loading state data from the database.
+func newStatePrefetcher(config *params.ChainConfig, db ethdb.Database) *statePrefetcher {
+	slots, _ := lru.New(prefetchContractLimit)
+	return &statePrefetcher{
+		config: config,
+		db:     db,
+		slots:  slots,
+	}
+}
+
+// Prefetch walks the transactions of the block in order, loading the state of
+// every account and storage slot they are predicted to access on top of the
+// given parent state root. The method returns as soon as the interrupt flag is
+// set, which the caller should do when the block processing has finished.
+func (p *statePrefetcher) Prefetch(block *types.Block, root common.Hash, interrupt *uint32) {
+	statedb, err := state.New(root, p.db)
+	if err != nil {
+		return
+	}
+	var (
+		signer   = types.MakeSigner(p.config, block.Number())
+		accounts int64
+		slots    int64
+	)
+	defer func() {
+		prefetchAccountMeter.Mark(accounts)
+		prefetchSlotMeter.Mark(slots)
+	}()
+
+	statedb.GetBalance(block.Coinbase())
+	accounts++
+
+	for _, tx := range block.Transactions() {
+		if atomic.LoadUint32(interrupt) == 1 {
+			return
+		}
+		// Senders are already cached by the concurrent recovery, so this is cheap
+		if from, err := types.Sender(signer, tx); err == nil {
+			statedb.GetNonce(from)
+			accounts++
+		}
+		to := tx.To()
+		if to == nil {
+			continue
+		}
+		statedb.GetCode(*to)
+		accounts++
+
+		if recent, ok := p.slots.Get(*to); ok {
+			for _, slot := range recent.([]common.Hash) {
+				if atomic.LoadUint32(interrupt) == 1 {
+					return
+				}
+				statedb.GetState(*to, slot)
+				slots++
+			}
+		}
+	}
+}
+
+// Learn records the storage slots accessed while processing a block, so that
+// subsequent prefetches can warm them up for transactions calling into the same
+// contracts. It should be called with the state the block was executed on, prior
+// to it being reset.
+func (p *statePrefetcher) Learn(statedb *state.StateDB) {
+	for addr, slots := range statedb.AccessedStorage() {
+		if len(slots) > prefetchSlotLimit {
+			slots = slots[:prefetchSlotLimit]
+		}
+		p.slots.Add(addr, slots)
+	}
+}
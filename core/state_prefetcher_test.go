@@ -0,0 +1,87 @@
+// Copyright 2015 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"sync/atomic"
+	"testing"
+
+	"github.com/EarthDollar/go-earthdollar/common"
+	"github.com/EarthDollar/go-earthdollar/core/vm"
+	"github.com/EarthDollar/go-earthdollar/crypto"
+	"github.com/EarthDollar/go-earthdollar/ethdb"
+	"github.com/EarthDollar/go-earthdollar/event"
+)
+
+// readCountingDatabase is a memory database counting the lookups made into it.
+type readCountingDatabase struct {
+	*ethdb.MemDatabase
+	reads int32
+}
+
+func (db *readCountingDatabase) Get(key []byte) ([]byte, error) {
+	atomic.AddInt32(&db.reads, 1)
+	return db.MemDatabase.Get(key)
+}
+
+// Tests that the prefetcher learns the storage slots accessed during block
+// processing and loads them, along with the transaction accounts, from the
+// database when prefetching subsequent blocks.
+func TestStatePrefetching(t *testing.T) {
+	_, accounts, blocks := makeParallelTestChain(t)
+
+	db, _ := ethdb.NewMemDatabase()
+	WriteGenesisBlockForTesting(db, accounts...)
+	blockchain, _ := NewBlockChain(db, testChainConfig(), FakePow{}, new(event.TypeMux), vm.Config{})
+	defer blockchain.Stop()
+
+	if n, err := blockchain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to process block %d: %v", n, err)
+	}
+	// Ensure the storage slot of the counter contract was learnt
+	counter := crypto.CreateAddress(accounts[0].Address, 0)
+	recent, ok := blockchain.prefetcher.slots.Get(counter)
+	if !ok {
+		t.Fatalf("counter contract storage accesses not recorded")
+	}
+	if slots := recent.([]common.Hash); len(slots) != 1 || slots[0] != (common.Hash{}) {
+		t.Fatalf("recorded storage slots mismatch: have %x, want [%x]", slots, common.Hash{})
+	}
+	// Prefetch the last block on top of its parent, counting the database reads
+	parent, head := blocks[len(blocks)-2], blocks[len(blocks)-1]
+
+	counting := &readCountingDatabase{MemDatabase: db}
+	prefetcher := newStatePrefetcher(testChainConfig(), counting)
+	prefetcher.slots = blockchain.prefetcher.slots
+
+	var interrupt uint32
+	prefetcher.Prefetch(head, parent.Root(), &interrupt)
+	full := atomic.LoadInt32(&counting.reads)
+	if full == 0 {
+		t.Fatalf("no state loaded during prefetch")
+	}
+	// Ensure an interrupted prefetch stops loading the transaction state
+	atomic.StoreInt32(&counting.reads, 0)
+	atomic.StoreUint32(&interrupt, 1)
+
+	prefetcher = newStatePrefetcher(testChainConfig(), counting)
+	prefetcher.slots = blockchain.prefetcher.slots
+	prefetcher.Prefetch(head, parent.Root(), &interrupt)
+	if partial := atomic.LoadInt32(&counting.reads); partial >= full {
+		t.Fatalf("interrupted prefetch loaded too much: have %d reads, full prefetch %d", partial, full)
+	}
+}
@@ -19,6 +19,7 @@ package core
 import (
 	"math/big"
 
+	"github.com/EarthDollar/go-earthdollar/common"
 	"github.com/EarthDollar/go-earthdollar/core/state"
 	"github.com/EarthDollar/go-earthdollar/core/types"
 	"github.com/EarthDollar/go-earthdollar/core/vm"
@@ -29,8 +30,7 @@ import (
 )
 
 var (
-	big8  = big.NewInt(8)
-	big32 = big.NewInt(32)
+	big100 = big.NewInt(100)
 )
 
 // StateProcessor is a basic Processor, which takes care of transitioning
@@ -67,21 +67,30 @@ func (p *StateProcessor) Process(block *types.Block, statedb *state.StateDB, cfg
 		gp           = new(GasPool).AddGas(block.GasLimit())
 	)
 	// Mutate the the block and state according to any hard-fork specs
-	if p.config.DAOForkSupport && p.config.DAOForkBlock != nil && p.config.DAOForkBlock.Cmp(block.Number()) == 0 {
+	daoBlock := p.config.DAOForkSupport && p.config.DAOForkBlock != nil && p.config.DAOForkBlock.Cmp(block.Number()) == 0
+	if daoBlock {
 		ApplyDAOHardFork(statedb)
 	}
-	// Iterate over and process the individual transactions
-	for i, tx := range block.Transactions() {
-		//fmt.Println("tx:", i)
-		statedb.StartRecord(tx.Hash(), block.Hash(), i)
-		receipt, _, err := ApplyTransaction(p.config, p.bc, gp, statedb, header, tx, totalUsedGas, cfg)
-		if err != nil {
+	// Recover all the senders concurrently, then process the individual transactions
+	recoverSenders(p.config, types.Blocks{block})
+
+	if cfg.ParallelExecution && !daoBlock {
+		if receipts, allLogs, err = p.processParallel(block, statedb, gp, totalUsedGas, cfg); err != nil {
 			return nil, nil, nil, err
 		}
-		receipts = append(receipts, receipt)
-		allLogs = append(allLogs, receipt.Logs...)
+	} else {
+		for i, tx := range block.Transactions() {
+			//fmt.Println("tx:", i)
+			statedb.StartRecord(tx.Hash(), block.Hash(), i)
+			receipt, _, err := ApplyTransaction(p.config, p.bc, gp, statedb, header, tx, totalUsedGas, cfg)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			receipts = append(receipts, receipt)
+			allLogs = append(allLogs, receipt.Logs...)
+		}
 	}
-	AccumulateRewards(statedb, header, block.Uncles())
+	AccumulateRewards(p.config, p.bc, statedb, header, block.Uncles())
 
 	return receipts, allLogs, totalUsedGas, err
 }
@@ -95,6 +104,22 @@ func ApplyTransaction(config *params.ChainConfig, bc *BlockChain, gp *GasPool, s
 	if err != nil {
 		return nil, nil, err
 	}
+	// Apply the transaction to the current state
+	gas, err := applyMessage(config, bc, gp, statedb, header, msg, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Update the state with pending changes
+	usedGas.Add(usedGas, gas)
+	receipt := newReceipt(config, statedb, header, tx, msg, usedGas, gas)
+
+	return receipt, gas, err
+}
+
+// applyMessage runs a transaction message in a new EVM environment on top of
+// the given state, returning the gas used.
+func applyMessage(config *params.ChainConfig, bc *BlockChain, gp *GasPool, statedb vm.StateDB, header *types.Header, msg types.Message, cfg vm.Config) (*big.Int, error) {
 	// Create a new context to be used in the EVM environment
 	context := NewEVMContext(msg, header, bc)
 	// Create a new environment which holds all relevant information
@@ -102,12 +127,11 @@ func ApplyTransaction(config *params.ChainConfig, bc *BlockChain, gp *GasPool, s
 	vmenv := vm.NewEVM(context, statedb, config, cfg)
 	// Apply the transaction to the current state (included in the env)
 	_, gas, err := ApplyMessage(vmenv, msg, gp)
-	if err != nil {
-		return nil, nil, err
-	}
+	return gas, err
+}
 
-	// Update the state with pending changes
-	usedGas.Add(usedGas, gas)
+// newReceipt creates the receipt of a transaction already applied to the state.
+func newReceipt(config *params.ChainConfig, statedb *state.StateDB, header *types.Header, tx *types.Transaction, msg types.Message, usedGas, gas *big.Int) *types.Receipt {
 	// Create a new receipt for the transaction, storing the intermediate root and gas used by the tx
 	// based on the eip phase, we're passing wether the root touch-delete accounts.
 	receipt := types.NewReceipt(statedb.IntermediateRoot(config.IsEIP158(header.Number)).Bytes(), usedGas)
@@ -115,7 +139,7 @@ func ApplyTransaction(config *params.ChainConfig, bc *BlockChain, gp *GasPool, s
 	receipt.GasUsed = new(big.Int).Set(gas)
 	// if the transaction created a contract, store the creation address in the receipt.
 	if msg.To() == nil {
-		receipt.ContractAddress = crypto.CreateAddress(vmenv.Context.Origin, tx.Nonce())
+		receipt.ContractAddress = crypto.CreateAddress(msg.From(), tx.Nonce())
 	}
 
 	// Set the receipt logs and create a bloom for filtering
@@ -124,25 +148,84 @@ func ApplyTransaction(config *params.ChainConfig, bc *BlockChain, gp *GasPool, s
 
 	glog.V(logger.Debug).Infoln(receipt)
 
-	return receipt, gas, err
+	return receipt
 }
 
 // AccumulateRewards credits the coinbase of the given block with the
 // mining reward. The total reward consists of the static block reward
 // and rewards for included uncles. The coinbase of each uncle block is
-// also rewarded.
-func AccumulateRewards(statedb *state.StateDB, header *types.Header, uncles []*types.Header) {
-	reward := new(big.Int).Set(BlockReward)
-	r := new(big.Int)
-	for _, uncle := range uncles {
-		r.Add(uncle.Number, big8)
-		r.Sub(r, header.Number)
-		r.Mul(r, BlockReward)
-		r.Div(r, big8)
-		statedb.AddBalance(uncle.Coinbase, r)
-
-		r.Div(BlockReward, big32)
-		reward.Add(reward, r)
+// also rewarded. After the treasury fork, the configured share of the
+// static block reward is diverted to the treasury addresses instead.
+//
+// After the reward contract fork, the rewards are handed to the reward
+// contract for distribution, falling back to paying them directly if
+// the contract fails.
+func AccumulateRewards(config *params.ChainConfig, chain HeaderFetcher, statedb *state.StateDB, header *types.Header, uncles []*types.Header) {
+	reward, uncleRewards, treasuryRewards := blockRewards(config, header, uncles)
+	if config.IsRewardContract(header.Number) {
+		recipients := []common.Address{header.Coinbase}
+		amounts := []*big.Int{reward}
+		for i, uncle := range uncles {
+			recipients, amounts = append(recipients, uncle.Coinbase), append(amounts, uncleRewards[i])
+		}
+		for i, r := range treasuryRewards {
+			recipients, amounts = append(recipients, config.Treasuries[i].Address), append(amounts, r)
+		}
+		if distributeRewards(config, chain, statedb, header, recipients, amounts) {
+			return
+		}
+	}
+	for i, uncle := range uncles {
+		if uncleRewards[i].Sign() > 0 {
+			statedb.AddBalance(uncle.Coinbase, uncleRewards[i])
+		}
+	}
+	for i, r := range treasuryRewards {
+		statedb.AddBalance(config.Treasuries[i].Address, r)
 	}
 	statedb.AddBalance(header.Coinbase, reward)
 }
+
+// BlockIssuance returns the amount of new ED created by a block, being the sum
+// of the rewards credited by AccumulateRewards.
+func BlockIssuance(config *params.ChainConfig, header *types.Header, uncles []*types.Header) *big.Int {
+	reward, uncleRewards, treasuryRewards := blockRewards(config, header, uncles)
+	for _, r := range uncleRewards {
+		reward.Add(reward, r)
+	}
+	for _, r := range treasuryRewards {
+		reward.Add(reward, r)
+	}
+	return reward
+}
+
+// blockRewards calculates the reward of the coinbase of a block, those of the
+// coinbases of its uncles and those of the treasuries, based on the block reward
+// in effect at the block.
+func blockRewards(config *params.ChainConfig, header *types.Header, uncles []*types.Header) (*big.Int, []*big.Int, []*big.Int) {
+	var (
+		blockReward  = config.BlockRewardAt(header.Number)
+		reward       = new(big.Int).Set(blockReward)
+		uncleRewards = make([]*big.Int, len(uncles))
+	)
+	// Divert the treasury shares of the static reward, never exceeding it
+	var treasuryRewards []*big.Int
+	if config.IsTreasury(header.Number) {
+		treasuryRewards = make([]*big.Int, len(config.Treasuries))
+		for i, treasury := range config.Treasuries {
+			r := new(big.Int).SetUint64(treasury.Percent)
+			r.Mul(r, blockReward)
+			r.Div(r, big100)
+			if r.Cmp(reward) > 0 {
+				r.Set(reward)
+			}
+			treasuryRewards[i] = r
+			reward.Sub(reward, r)
+		}
+	}
+	for i, uncle := range uncles {
+		uncleRewards[i] = config.UncleReward(header.Number, uncle.Number, blockReward)
+		reward.Add(reward, config.UncleInclusionReward(header.Number, blockReward))
+	}
+	return reward, uncleRewards, treasuryRewards
+}
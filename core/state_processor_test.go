@@ -0,0 +1,219 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/EarthDollar/go-earthdollar/common"
+	"github.com/EarthDollar/go-earthdollar/core/state"
+	"github.com/EarthDollar/go-earthdollar/core/types"
+	"github.com/EarthDollar/go-earthdollar/ethdb"
+	"github.com/EarthDollar/go-earthdollar/params"
+)
+
+// Tests that the block reward follows the configured schedule of reward eras.
+func TestBlockRewardEras(t *testing.T) {
+	config := *params.TestChainConfig
+	config.BlockReward = big.NewInt(4e18)
+	config.RewardEras = []params.RewardEra{
+		{Block: big.NewInt(5), Reward: big.NewInt(1e18)},
+		{Block: big.NewInt(3), Reward: big.NewInt(2e18)},
+	}
+	var (
+		db, _   = ethdb.NewMemDatabase()
+		genesis = GenesisBlockForTesting(db, common.Address{0xaa}, big.NewInt(1))
+	)
+	blocks, _ := GenerateChain(&config, genesis, db, 6, func(i int, block *BlockGen) {
+		block.SetCoinbase(common.Address{byte(i + 1)})
+	})
+	want := []int64{4e18, 4e18, 2e18, 2e18, 1e18, 1e18}
+	for i, block := range blocks {
+		statedb, err := state.New(block.Root(), db)
+		if err != nil {
+			t.Fatalf("block #%d: failed to open state: %v", block.NumberU64(), err)
+		}
+		if balance := statedb.GetBalance(block.Coinbase()); balance.Cmp(big.NewInt(want[i])) != 0 {
+			t.Errorf("block #%d: reward mismatch: have %v, want %v", block.NumberU64(), balance, want[i])
+		}
+		if issuance := BlockIssuance(&config, block.Header(), block.Uncles()); issuance.Cmp(big.NewInt(want[i])) != 0 {
+			t.Errorf("block #%d: issuance mismatch: have %v, want %v", block.NumberU64(), issuance, want[i])
+		}
+	}
+}
+
+// Tests that after the treasury fork the configured shares of the block reward
+// are credited to the treasuries instead of the miner.
+func TestTreasuryRewards(t *testing.T) {
+	config := *params.TestChainConfig
+	config.BlockReward = big.NewInt(4e18)
+	config.TreasuryBlock = big.NewInt(3)
+	config.Treasuries = []params.Treasury{
+		{Address: common.Address{0x11}, Percent: 10},
+		{Address: common.Address{0x12}, Percent: 5},
+	}
+	var (
+		db, _   = ethdb.NewMemDatabase()
+		genesis = GenesisBlockForTesting(db, common.Address{0xaa}, big.NewInt(1))
+	)
+	blocks, _ := GenerateChain(&config, genesis, db, 4, func(i int, block *BlockGen) {
+		block.SetCoinbase(common.Address{byte(i + 1)})
+	})
+	want := []int64{4e18, 4e18, 34e17, 34e17}
+	for i, block := range blocks {
+		statedb, err := state.New(block.Root(), db)
+		if err != nil {
+			t.Fatalf("block #%d: failed to open state: %v", block.NumberU64(), err)
+		}
+		if balance := statedb.GetBalance(block.Coinbase()); balance.Cmp(big.NewInt(want[i])) != 0 {
+			t.Errorf("block #%d: miner reward mismatch: have %v, want %v", block.NumberU64(), balance, want[i])
+		}
+		if issuance := BlockIssuance(&config, block.Header(), block.Uncles()); issuance.Cmp(config.BlockReward) != 0 {
+			t.Errorf("block #%d: issuance mismatch: have %v, want %v", block.NumberU64(), issuance, config.BlockReward)
+		}
+	}
+	statedb, _ := state.New(blocks[len(blocks)-1].Root(), db)
+	for i, want := range []int64{8e17, 4e17} {
+		if balance := statedb.GetBalance(config.Treasuries[i].Address); balance.Cmp(big.NewInt(want)) != 0 {
+			t.Errorf("treasury %d: balance mismatch: have %v, want %v", i, balance, want)
+		}
+	}
+}
+
+// Tests that after the reward contract fork the rewards are handed to the reward
+// contract, falling back to paying them directly if the contract fails.
+func TestRewardContract(t *testing.T) {
+	var (
+		recorder = common.Address{0x01, 0x01} // Stores the calldata size and caller
+		failer   = common.Address{0x02, 0x02} // Executes an invalid opcode
+	)
+	config := *params.TestChainConfig
+	config.BlockReward = big.NewInt(4e18)
+	config.RewardContractBlock = big.NewInt(2)
+
+	for i, tt := range []struct {
+		contract common.Address
+		success  bool
+	}{{recorder, true}, {failer, false}} {
+		config.RewardContract = &tt.contract
+
+		db, _ := ethdb.NewMemDatabase()
+		statedb, _ := state.New(common.Hash{}, db)
+		statedb.SetCode(recorder, common.Hex2Bytes("3660005533600155"))
+		statedb.SetCode(failer, common.Hex2Bytes("fe"))
+		root, _ := statedb.Commit(false)
+		genesis := types.NewBlock(&types.Header{Difficulty: params.GenesisDifficulty, GasLimit: params.GenesisGasLimit, Root: root}, nil, nil, nil)
+
+		blocks, _ := GenerateChain(&config, genesis, db, 3, func(i int, block *BlockGen) {
+			block.SetCoinbase(common.Address{byte(i + 1)})
+		})
+		statedb, _ = state.New(blocks[len(blocks)-1].Root(), db)
+
+		contractFunds, minerFunds := new(big.Int), config.BlockReward
+		if tt.success {
+			contractFunds, minerFunds = big.NewInt(8e18), new(big.Int)
+		}
+		if balance := statedb.GetBalance(blocks[0].Coinbase()); balance.Cmp(config.BlockReward) != 0 {
+			t.Errorf("test %d: pre-fork miner balance mismatch: have %v, want %v", i, balance, config.BlockReward)
+		}
+		for _, block := range blocks[1:] {
+			if balance := statedb.GetBalance(block.Coinbase()); balance.Cmp(minerFunds) != 0 {
+				t.Errorf("test %d: block #%d: miner balance mismatch: have %v, want %v", i, block.NumberU64(), balance, minerFunds)
+			}
+		}
+		if balance := statedb.GetBalance(tt.contract); balance.Cmp(contractFunds) != 0 {
+			t.Errorf("test %d: contract balance mismatch: have %v, want %v", i, balance, contractFunds)
+		}
+		if tt.success {
+			// distribute(address[1], uint256[1]): selector, two offsets, two length prefixed items
+			if size := statedb.GetState(recorder, common.Hash{}).Big(); size.Int64() != 4+6*32 {
+				t.Errorf("test %d: calldata size mismatch: have %v, want %v", i, size, 4+6*32)
+			}
+			if caller := common.BytesToAddress(statedb.GetState(recorder, common.BigToHash(big.NewInt(1))).Bytes()); caller != params.SystemAddress {
+				t.Errorf("test %d: caller mismatch: have %x, want %x", i, caller, params.SystemAddress)
+			}
+		}
+	}
+}
+
+// Tests that the reward contract can access the hashes of the recent blocks, also
+// while the chain is being generated.
+func TestRewardContractBlockHash(t *testing.T) {
+	hasher := common.Address{0x03, 0x03} // Stores the hash of the parent block
+
+	config := *params.TestChainConfig
+	config.BlockReward = big.NewInt(4e18)
+	config.RewardContractBlock = big.NewInt(1)
+	config.RewardContract = &hasher
+
+	db, _ := ethdb.NewMemDatabase()
+	statedb, _ := state.New(common.Hash{}, db)
+	statedb.SetCode(hasher, common.Hex2Bytes("6001430340600055"))
+	root, _ := statedb.Commit(false)
+	genesis := types.NewBlock(&types.Header{Difficulty: params.GenesisDifficulty, GasLimit: params.GenesisGasLimit, Root: root}, nil, nil, nil)
+
+	blocks, _ := GenerateChain(&config, genesis, db, 3, nil)
+	for i, block := range blocks {
+		statedb, _ = state.New(block.Root(), db)
+		if have, want := statedb.GetState(hasher, common.Hash{}), block.ParentHash(); have != want {
+			t.Errorf("block #%d: parent hash mismatch: have %x, want %x", i+1, have, want)
+		}
+	}
+}
+
+// Tests that uncles are rewarded according to the configured policy, and not at
+// all after the uncle reward removal fork.
+func TestUncleRewardPolicy(t *testing.T) {
+	config := *params.TestChainConfig
+	config.BlockReward = big.NewInt(32e16)
+	config.UncleRewardDivisor = big.NewInt(16)
+	config.UncleInclusionDivisor = big.NewInt(16)
+	config.NoUncleRewardBlock = big.NewInt(5)
+
+	var (
+		db, _   = ethdb.NewMemDatabase()
+		genesis = GenesisBlockForTesting(db, common.Address{0xaa}, big.NewInt(1))
+	)
+	blocks, _ := GenerateChain(&config, genesis, db, 6, func(i int, block *BlockGen) {
+		block.SetCoinbase(common.Address{byte(i + 1)})
+		if i == 2 || i == 5 {
+			uncle := block.PrevBlock(i - 1).Header()
+			uncle.Coinbase = common.Address{0xf0 + byte(i+1)}
+			block.AddUncle(uncle)
+		}
+	})
+	statedb, _ := state.New(blocks[len(blocks)-1].Root(), db)
+
+	// Block #3 pays its uncle at depth 1 (8-1)/16 and itself 1/16 extra
+	if balance := statedb.GetBalance(common.Address{0xf3}); balance.Cmp(big.NewInt(14e16)) != 0 {
+		t.Errorf("uncle reward mismatch: have %v, want %v", balance, big.NewInt(14e16))
+	}
+	if balance := statedb.GetBalance(blocks[2].Coinbase()); balance.Cmp(big.NewInt(34e16)) != 0 {
+		t.Errorf("uncle inclusion reward mismatch: have %v, want %v", balance, big.NewInt(34e16))
+	}
+	// Block #6 is past the fork, neither the uncle nor its inclusion is rewarded
+	if statedb.Exist(common.Address{0xf6}) {
+		t.Errorf("post-fork uncle credited: balance %v", statedb.GetBalance(common.Address{0xf6}))
+	}
+	if balance := statedb.GetBalance(blocks[5].Coinbase()); balance.Cmp(config.BlockReward) != 0 {
+		t.Errorf("post-fork miner reward mismatch: have %v, want %v", balance, config.BlockReward)
+	}
+	if issuance := BlockIssuance(&config, blocks[5].Header(), blocks[5].Uncles()); issuance.Cmp(config.BlockReward) != 0 {
+		t.Errorf("post-fork issuance mismatch: have %v, want %v", issuance, config.BlockReward)
+	}
+}
@@ -53,6 +53,7 @@ type StateTransition struct {
 	msg           Message
 	gas, gasPrice *big.Int
 	initialGas    *big.Int
+	refundStart   *big.Int // refund counter before the message was applied
 	value         *big.Int
 	data          []byte
 	state         vm.StateDB
@@ -217,6 +218,10 @@ func (self *StateTransition) TransitionDb() (ret []byte, requiredGas, usedGas *b
 	msg := self.msg
 	sender := self.from() // err checked in preCheck
 
+	// Refunds are accounted per transaction, ignore anything the state may
+	// have accumulated before (e.g. when applying several messages in a row).
+	self.refundStart = new(big.Int).Set(self.state.GetRefund())
+
 	homestead := self.env.ChainConfig().IsHomestead(self.env.BlockNumber)
 	contractCreation := MessageCreatesContract(msg)
 	// Pay intrinsic gas
@@ -263,9 +268,11 @@ func (self *StateTransition) refundGas() {
 	remaining := new(big.Int).Mul(self.gas, self.gasPrice)
 	sender.AddBalance(remaining)
 
-	// Apply refund counter, capped to half of the used gas.
-	uhalf := remaining.Div(self.gasUsed(), common.Big2)
-	refund := common.BigMin(uhalf, self.state.GetRefund())
+	// Apply the refund counter of this transaction, capped to the fork
+	// dependent share of the used gas.
+	quotient := self.env.ChainConfig().GasTable(self.env.BlockNumber).RefundQuotient
+	max := remaining.Div(self.gasUsed(), quotient)
+	refund := common.BigMin(max, new(big.Int).Sub(self.state.GetRefund(), self.refundStart))
 	self.gas.Add(self.gas, refund)
 	self.state.AddBalance(sender.Address(), refund.Mul(refund, self.gasPrice))
 
@@ -0,0 +1,113 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/EarthDollar/go-earthdollar/common"
+	"github.com/EarthDollar/go-earthdollar/core/state"
+	"github.com/EarthDollar/go-earthdollar/core/types"
+	"github.com/EarthDollar/go-earthdollar/core/vm"
+	"github.com/EarthDollar/go-earthdollar/ethdb"
+	"github.com/EarthDollar/go-earthdollar/params"
+)
+
+var (
+	refundHomestead = &params.ChainConfig{ChainId: big.NewInt(1), HomesteadBlock: new(big.Int)}
+	refundEIP158    = &params.ChainConfig{ChainId: big.NewInt(1), HomesteadBlock: new(big.Int), EIP150Block: new(big.Int), EIP155Block: new(big.Int), EIP158Block: new(big.Int), EIP140Block: new(big.Int)}
+	refundEIP3529   = &params.ChainConfig{ChainId: big.NewInt(1), HomesteadBlock: new(big.Int), EIP150Block: new(big.Int), EIP155Block: new(big.Int), EIP158Block: new(big.Int), EIP140Block: new(big.Int), EIP3529Block: new(big.Int)}
+)
+
+// Tests that the refunds of storage clears and suicides are accounted per
+// transaction and capped according to the active fork.
+func TestGasRefunds(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *params.ChainConfig
+		code   string   // code of the called contract
+		slots  int      // number of storage slots set before the call
+		refund *big.Int // refund counter left in the state by earlier messages
+		used   int64    // expected gas used by the transaction
+	}{
+		// Clearing a single slot: 26006 gas, refund 15000 capped to 1/2 or 4800
+		{"clear/homestead", refundHomestead, "600060005500", 1, nil, 13003},
+		{"clear/eip158", refundEIP158, "600060005500", 1, nil, 13003},
+		{"clear/eip3529", refundEIP3529, "600060005500", 1, nil, 21206},
+
+		// Clearing three slots: 36018 gas, refund 45000 capped to 1/2 or 1/5
+		{"clear3/homestead", refundHomestead, "60006000556000600155600060025500", 3, nil, 18009},
+		{"clear3/eip3529", refundEIP3529, "60006000556000600155600060025500", 3, nil, 28815},
+
+		// Setting and clearing a fresh slot: 46012 gas, refund 15000 or 4800
+		{"setclear/homestead", refundHomestead, "60016005556000600555", 0, nil, 31012},
+		{"setclear/eip3529", refundEIP3529, "60016005556000600555", 0, nil, 41212},
+
+		// Clearing an empty slot doesn't refund anything: 26006 gas
+		{"clearempty/homestead", refundHomestead, "600060005500", 0, nil, 26006},
+
+		// Suicide: 21002 or 26002 gas, refund 24000 capped to 1/2 or removed
+		{"suicide/homestead", refundHomestead, "33ff", 0, nil, 10501},
+		{"suicide/eip158", refundEIP158, "33ff", 0, nil, 13001},
+		{"suicide/eip3529", refundEIP3529, "33ff", 0, nil, 26002},
+
+		// Reverted clears don't refund: 26012 gas
+		{"revert/eip158", refundEIP158, "600060005560006000fd", 1, nil, 26012},
+		{"revert/eip3529", refundEIP3529, "600060005560006000fd", 1, nil, 26012},
+
+		// Refunds of earlier messages aren't credited to the transaction
+		{"stale/homestead", refundHomestead, "00", 0, big.NewInt(100000), 21000},
+		{"staleclear/homestead", refundHomestead, "600060005500", 1, big.NewInt(100000), 13003},
+		{"staleclear/eip3529", refundEIP3529, "600060005500", 1, big.NewInt(100000), 21206},
+	}
+	for _, tt := range tests {
+		var (
+			db, _    = ethdb.NewMemDatabase()
+			sender   = common.Address{0xaa}
+			contract = common.Address{0xcc}
+		)
+		st, _ := state.New(common.Hash{}, db)
+		st.AddBalance(sender, big.NewInt(1e18))
+		st.SetCode(contract, common.Hex2Bytes(tt.code))
+		for i := 0; i < tt.slots; i++ {
+			st.SetState(contract, common.BigToHash(big.NewInt(int64(i))), common.BigToHash(common.Big1))
+		}
+		root, _ := st.Commit(false)
+		st, _ = state.New(root, db)
+		if tt.refund != nil {
+			st.AddRefund(tt.refund)
+		}
+		var (
+			msg    = types.NewMessage(sender, &contract, 0, new(big.Int), big.NewInt(100000), common.Big1, nil, false)
+			header = &types.Header{Number: common.Big1, Time: common.Big1, GasLimit: params.GenesisGasLimit, Difficulty: common.Big1}
+			env    = vm.NewEVM(NewEVMContext(msg, header, nil), st, tt.config, vm.Config{})
+		)
+		_, used, err := ApplyMessage(env, msg, new(GasPool).AddGas(header.GasLimit))
+		if err != nil {
+			t.Errorf("%s: failed to apply message: %v", tt.name, err)
+			continue
+		}
+		if used.Cmp(big.NewInt(tt.used)) != 0 {
+			t.Errorf("%s: gas used mismatch: have %v, want %d", tt.name, used, tt.used)
+		}
+		want := new(big.Int).Sub(big.NewInt(1e18), big.NewInt(tt.used))
+		if balance := st.GetBalance(sender); balance.Cmp(want) != 0 {
+			t.Errorf("%s: sender balance mismatch: have %v, want %v", tt.name, balance, want)
+		}
+	}
+}
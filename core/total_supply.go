@@ -0,0 +1,152 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+
+	"github.com/EarthDollar/go-earthdollar/common"
+	"github.com/EarthDollar/go-earthdollar/core/state"
+	"github.com/EarthDollar/go-earthdollar/core/types"
+	"github.com/EarthDollar/go-earthdollar/ethdb"
+	"github.com/EarthDollar/go-earthdollar/logger"
+	"github.com/EarthDollar/go-earthdollar/logger/glog"
+	"github.com/EarthDollar/go-earthdollar/rlp"
+	"github.com/EarthDollar/go-earthdollar/trie"
+)
+
+// totalSupplyBatch is the number of blocks above which the backfilling of the
+// total supply is reported to the user.
+const totalSupplyBatch = 1024
+
+// GetTotalSupply retrieves the total amount of ED issued up to and including the
+// given block, or nil if it is not yet known.
+func (bc *BlockChain) GetTotalSupply(hash common.Hash, number uint64) *big.Int {
+	return GetTotalSupply(bc.chainDb, hash, number)
+}
+
+// writeTotalSupply stores the total supply of a block if that of its parent is
+// already known, leaving the rest to the background maintenance otherwise.
+func (bc *BlockChain) writeTotalSupply(block *types.Block) {
+	supply := GetTotalSupply(bc.chainDb, block.ParentHash(), block.NumberU64()-1)
+	if supply == nil {
+		return
+	}
+	supply.Add(supply, BlockIssuance(bc.config, block.Header(), block.Uncles()))
+	if err := WriteTotalSupply(bc.chainDb, block.Hash(), block.NumberU64(), supply); err != nil {
+		glog.Fatalf("failed to write total supply of block #%d: %v", block.NumberU64(), err)
+	}
+}
+
+// notifyTotalSupply schedules the total supply to be filled in up to a new head,
+// superseding any previously scheduled one. This method assumes that the chain
+// manager mutex is held.
+func (bc *BlockChain) notifyTotalSupply(head uint64) {
+	if bc.supplyHeads == nil {
+		return
+	}
+	select {
+	case <-bc.supplyHeads:
+	default:
+	}
+	bc.supplyHeads <- head
+}
+
+// totalSupplyLoop fills in the total supply of canonical blocks imported without
+// it being known (e.g. via fast sync or before supply tracking was introduced)
+// as the chain head progresses.
+func (bc *BlockChain) totalSupplyLoop() {
+	defer bc.wg.Done()
+
+	for {
+		select {
+		case head := <-bc.supplyHeads:
+			bc.updateTotalSupply(head)
+		case <-bc.quit:
+			return
+		}
+	}
+}
+
+// updateTotalSupply computes the missing total supply entries of the canonical
+// chain up to the given head, starting from the most recent block having it. If
+// none does, the supply of the genesis block is summed up from its state.
+func (bc *BlockChain) updateTotalSupply(head uint64) {
+	// Find the most recent canonical block with a known total supply
+	var (
+		number = head
+		supply *big.Int
+	)
+	for {
+		hash := GetCanonicalHash(bc.chainDb, number)
+		if hash == (common.Hash{}) {
+			return
+		}
+		if supply = GetTotalSupply(bc.chainDb, hash, number); supply != nil {
+			break
+		}
+		if number == 0 {
+			var err error
+			if supply, err = genesisSupply(bc.chainDb, bc.genesisBlock.Root()); err != nil {
+				glog.V(logger.Error).Infof("Failed to sum up genesis supply: %v", err)
+				return
+			}
+			if err := WriteTotalSupply(bc.chainDb, hash, 0, supply); err != nil {
+				glog.Fatalf("failed to write genesis supply: %v", err)
+			}
+			break
+		}
+		number--
+	}
+	if head-number > totalSupplyBatch {
+		glog.V(logger.Info).Infof("Computing total supply of blocks #%d-#%d", number+1, head)
+	}
+	// Accumulate the issuance of all subsequent blocks
+	for number < head {
+		select {
+		case <-bc.quit:
+			return
+		default:
+		}
+		number++
+		block := bc.GetBlockByNumber(number)
+		if block == nil {
+			return
+		}
+		supply = new(big.Int).Add(supply, BlockIssuance(bc.config, block.Header(), block.Uncles()))
+		if err := WriteTotalSupply(bc.chainDb, block.Hash(), number, supply); err != nil {
+			glog.Fatalf("failed to write total supply of block #%d: %v", number, err)
+		}
+	}
+}
+
+// genesisSupply sums up the balances of all accounts in the given genesis state.
+func genesisSupply(db ethdb.Database, root common.Hash) (*big.Int, error) {
+	tr, err := trie.NewSecure(root, db, 0)
+	if err != nil {
+		return nil, err
+	}
+	supply := new(big.Int)
+	for it := tr.Iterator(); it.Next(); {
+		var account state.Account
+		if err := rlp.DecodeBytes(it.Value, &account); err != nil {
+			return nil, err
+		}
+		supply.Add(supply, account.Balance)
+	}
+	return supply, nil
+}
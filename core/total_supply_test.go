@@ -0,0 +1,107 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/EarthDollar/go-earthdollar/common"
+	"github.com/EarthDollar/go-earthdollar/core/types"
+	"github.com/EarthDollar/go-earthdollar/core/vm"
+	"github.com/EarthDollar/go-earthdollar/ethdb"
+	"github.com/EarthDollar/go-earthdollar/event"
+	"github.com/EarthDollar/go-earthdollar/params"
+)
+
+// Tests that the total supply of every block is tracked during import, and that
+// it's reconstructed in the background if missing from the database.
+func TestTotalSupply(t *testing.T) {
+	var (
+		gendb, _ = ethdb.NewMemDatabase()
+		address  = common.Address{0xaa}
+		funds    = big.NewInt(1000000000)
+		genesis  = GenesisBlockForTesting(gendb, address, funds)
+	)
+	// Without transactions the supply must equal the sum of all balances
+	blocks, _ := GenerateChain(params.TestChainConfig, genesis, gendb, 8, func(i int, block *BlockGen) {
+		block.SetCoinbase(common.Address{byte(i + 1)})
+		if i == 3 {
+			b1 := block.PrevBlock(1).Header()
+			b1.Coinbase = common.Address{0xf1}
+			block.AddUncle(b1)
+			b2 := block.PrevBlock(2).Header()
+			b2.Coinbase = common.Address{0xf2}
+			block.AddUncle(b2)
+		}
+	})
+	db, _ := ethdb.NewMemDatabase()
+	WriteGenesisBlockForTesting(db, GenesisAccount{address, funds})
+
+	chain, err := NewBlockChain(db, testChainConfig(), FakePow{}, new(event.TypeMux), vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert block %d: %v", n, err)
+	}
+	// check waits until the total supply of all blocks is available and correct.
+	check := func() {
+		all := append(types.Blocks{chain.Genesis()}, blocks...)
+		for start := time.Now(); ; time.Sleep(10 * time.Millisecond) {
+			missing := false
+			for _, block := range all {
+				supply := chain.GetTotalSupply(block.Hash(), block.NumberU64())
+				if supply == nil {
+					missing = true
+					break
+				}
+				want, err := genesisSupply(db, block.Root())
+				if err != nil {
+					t.Fatalf("failed to sum balances of block #%d: %v", block.NumberU64(), err)
+				}
+				if supply.Cmp(want) != 0 {
+					t.Fatalf("block #%d: total supply mismatch: have %v, want %v", block.NumberU64(), supply, want)
+				}
+			}
+			if !missing {
+				return
+			}
+			if time.Since(start) > 5*time.Second {
+				t.Fatalf("total supply not available")
+			}
+		}
+	}
+	check()
+	if supply := chain.GetTotalSupply(chain.Genesis().Hash(), 0); supply.Cmp(funds) != 0 {
+		t.Fatalf("genesis supply mismatch: have %v, want %v", supply, funds)
+	}
+	chain.Stop()
+
+	// Drop all supply entries, they must be restored upon reopening the chain
+	DeleteTotalSupply(db, chain.Genesis().Hash(), 0)
+	for _, block := range blocks {
+		DeleteTotalSupply(db, block.Hash(), block.NumberU64())
+	}
+	chain, err = NewBlockChain(db, testChainConfig(), FakePow{}, new(event.TypeMux), vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to reopen chain: %v", err)
+	}
+	check()
+	chain.Stop()
+}
@@ -0,0 +1,124 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"github.com/EarthDollar/go-earthdollar/logger"
+	"github.com/EarthDollar/go-earthdollar/logger/glog"
+)
+
+// txLookupBatch is the number of blocks to (un)index between persisting the
+// progress of the transaction index maintenance.
+const txLookupBatch = 1024
+
+// SetTxLookupLimit restricts the transaction hash lookup index to the most recent
+// limit canonical blocks, zero meaning the entire chain. The entries of older
+// blocks are removed in the background as the chain progresses, and the missing
+// ones re-added should the limit be raised (or lifted) later.
+//
+// It must be called at most once, before any blocks are processed.
+func (bc *BlockChain) SetTxLookupLimit(limit uint64) {
+	if limit == 0 && GetTxLookupTail(bc.chainDb) == 0 {
+		return // Entire chain indexed, nothing to maintain
+	}
+	bc.txLookupLimit = limit
+	bc.txLookupHeads = make(chan uint64, 1)
+	bc.txLookupHeads <- bc.CurrentBlock().NumberU64()
+
+	bc.wg.Add(1)
+	go bc.txLookupLoop()
+}
+
+// notifyTxLookups schedules the transaction index to be updated to a new head,
+// superseding any previously scheduled one. This method assumes that the chain
+// manager mutex is held.
+func (bc *BlockChain) notifyTxLookups(head uint64) {
+	if bc.txLookupHeads == nil {
+		return
+	}
+	select {
+	case <-bc.txLookupHeads:
+	default:
+	}
+	bc.txLookupHeads <- head
+}
+
+// txLookupLoop maintains the range of blocks indexed for transaction lookups as
+// the chain head progresses.
+func (bc *BlockChain) txLookupLoop() {
+	defer bc.wg.Done()
+
+	tail := GetTxLookupTail(bc.chainDb)
+	for {
+		select {
+		case head := <-bc.txLookupHeads:
+			tail = bc.updateTxLookups(head, tail)
+		case <-bc.quit:
+			return
+		}
+	}
+}
+
+// updateTxLookups moves the transaction index tail towards the oldest block to
+// be indexed with the given head, removing or re-adding the lookup entries of
+// the blocks in between. The new tail is returned, which might fall short of
+// the target if the chain is stopped.
+func (bc *BlockChain) updateTxLookups(head, tail uint64) uint64 {
+	var target uint64
+	if bc.txLookupLimit > 0 && head >= bc.txLookupLimit {
+		target = head - bc.txLookupLimit + 1
+	}
+	if tail == target {
+		return tail
+	}
+	if tail < target && target-tail > txLookupBatch {
+		glog.V(logger.Info).Infof("Unindexing transactions of blocks #%d-#%d", tail, target-1)
+	}
+	if tail > target && tail-target > txLookupBatch {
+		glog.V(logger.Info).Infof("Reindexing transactions of blocks #%d-#%d", target, tail-1)
+	}
+	for processed := 0; tail != target; processed++ {
+		// Persist the progress every now and again, aborting if the chain stops
+		if processed > 0 && processed%txLookupBatch == 0 {
+			WriteTxLookupTail(bc.chainDb, tail)
+		}
+		select {
+		case <-bc.quit:
+			WriteTxLookupTail(bc.chainDb, tail)
+			return tail
+		default:
+		}
+		if tail < target {
+			if block := bc.GetBlockByNumber(tail); block != nil {
+				for _, tx := range block.Transactions() {
+					DeleteTransaction(bc.chainDb, tx.Hash())
+				}
+			}
+			tail++
+		} else {
+			tail--
+			if block := bc.GetBlockByNumber(tail); block != nil {
+				if err := WriteTransactions(bc.chainDb, block); err != nil {
+					glog.Fatalf("failed to reindex transactions of block #%d: %v", tail, err)
+				}
+			}
+		}
+	}
+	WriteTxLookupTail(bc.chainDb, tail)
+	glog.V(logger.Debug).Infof("Transaction index tail moved to #%d", tail)
+	return tail
+}
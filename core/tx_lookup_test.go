@@ -0,0 +1,101 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/EarthDollar/go-earthdollar/common"
+	"github.com/EarthDollar/go-earthdollar/core/types"
+	"github.com/EarthDollar/go-earthdollar/core/vm"
+	"github.com/EarthDollar/go-earthdollar/crypto"
+	"github.com/EarthDollar/go-earthdollar/ethdb"
+	"github.com/EarthDollar/go-earthdollar/event"
+	"github.com/EarthDollar/go-earthdollar/params"
+)
+
+// Tests that the transaction lookup index is restricted to the configured number
+// of recent blocks, and that it's restored when the limit is raised or lifted.
+func TestTxLookupLimit(t *testing.T) {
+	var (
+		gendb, _ = ethdb.NewMemDatabase()
+		key, _   = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		address  = crypto.PubkeyToAddress(key.PublicKey)
+		funds    = big.NewInt(1000000000)
+		genesis  = GenesisBlockForTesting(gendb, address, funds)
+		signer   = types.NewEIP155Signer(big.NewInt(1))
+	)
+	blocks, _ := GenerateChain(params.TestChainConfig, genesis, gendb, 64, func(i int, block *BlockGen) {
+		tx, err := types.SignTx(types.NewTransaction(block.TxNonce(address), common.Address{0x00}, big.NewInt(1000), params.TxGas, nil, nil), signer, key)
+		if err != nil {
+			panic(err)
+		}
+		block.AddTx(tx)
+	})
+	db, _ := ethdb.NewMemDatabase()
+	WriteGenesisBlockForTesting(db, GenesisAccount{address, funds})
+
+	// check waits until the index tail reaches the expected block, and exactly the
+	// transactions from that block onward are indexed.
+	check := func(tail uint64) {
+		var mismatches []uint64
+		for start := time.Now(); time.Since(start) < 5*time.Second; time.Sleep(10 * time.Millisecond) {
+			mismatches = mismatches[:0]
+			for _, block := range blocks {
+				indexed, _, _, _ := GetTransaction(db, block.Transactions()[0].Hash())
+				if (indexed != nil) != (block.NumberU64() >= tail) {
+					mismatches = append(mismatches, block.NumberU64())
+				}
+			}
+			if len(mismatches) == 0 && GetTxLookupTail(db) == tail {
+				return
+			}
+		}
+		t.Fatalf("index mismatch: tail #%d, want #%d, mismatching blocks %v", GetTxLookupTail(db), tail, mismatches)
+	}
+	// Import the chain with a limited index, only the recent blocks must be indexed
+	chain, err := NewBlockChain(db, testChainConfig(), FakePow{}, new(event.TypeMux), vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	chain.SetTxLookupLimit(16)
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert block %d: %v", n, err)
+	}
+	check(49)
+	chain.Stop()
+
+	// Raise the limit, the missing older blocks must be reindexed
+	chain, err = NewBlockChain(db, testChainConfig(), FakePow{}, new(event.TypeMux), vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to reopen chain: %v", err)
+	}
+	chain.SetTxLookupLimit(32)
+	check(33)
+	chain.Stop()
+
+	// Lift the limit, the entire chain must be reindexed
+	chain, err = NewBlockChain(db, testChainConfig(), FakePow{}, new(event.TypeMux), vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to reopen chain: %v", err)
+	}
+	chain.SetTxLookupLimit(0)
+	check(0)
+	chain.Stop()
+}
@@ -48,12 +48,13 @@ var (
 )
 
 var (
-	minPendingPerAccount = uint64(16)    // Min number of guaranteed transaction slots per address
-	maxPendingTotal      = uint64(4096)  // Max limit of pending transactions from all accounts (soft)
-	maxQueuedPerAccount  = uint64(64)    // Max limit of queued transactions per address
-	maxQueuedInTotal     = uint64(1024)  // Max limit of queued transactions from all accounts
-	maxQueuedLifetime    = 3 * time.Hour // Max amount of time transactions from idle accounts are queued
-	evictionInterval     = time.Minute   // Time interval to check for evictable transactions
+	minPendingPerAccount = uint64(16)            // Min number of guaranteed transaction slots per address
+	maxPendingTotal      = uint64(4096)          // Max limit of pending transactions from all accounts (soft)
+	maxQueuedPerAccount  = uint64(64)            // Max limit of queued transactions per address
+	maxQueuedInTotal     = uint64(1024)          // Max limit of queued transactions from all accounts
+	maxQueuedLifetime    = 3 * time.Hour         // Max amount of time transactions from idle accounts are queued
+	evictionInterval     = time.Minute           // Time interval to check for evictable transactions
+	txBatchInterval      = 50 * time.Millisecond // Time window to coalesce new transactions into a single batch event
 )
 
 var (
@@ -75,6 +76,15 @@ var (
 
 type stateFn func() (*state.StateDB, error)
 
+// chainHeadSubscriber is implemented by chains announcing their head changes,
+// used by the pool to track the state it validates transactions against.
+type chainHeadSubscriber interface {
+	SubscribeChainHeadEvent(ch chan<- ChainHeadEvent) event.Subscription
+}
+
+// chainHeadChanSize is the size of the channel listening to head events.
+const chainHeadChanSize = 10
+
 // TxPool contains all currently known transactions. Transactions
 // enter the pool when they are received from the network or submitted
 // locally. They exit the pool when they are included in the blockchain.
@@ -89,7 +99,12 @@ type TxPool struct {
 	gasLimit     func() *big.Int // The current gas limit function callback
 	minGasPrice  *big.Int
 	eventMux     *event.TypeMux
-	events       event.Subscription
+	events       *event.TypeMuxSubscription
+	txFeed       event.Feed
+	txsFeed      event.Feed
+	scope        event.SubscriptionScope
+	chainHeadCh  chan ChainHeadEvent
+	chainHeadSub event.Subscription
 	localTx      *txSet
 	signer       types.Signer
 	mu           sync.RWMutex
@@ -99,13 +114,17 @@ type TxPool struct {
 	all     map[common.Hash]*types.Transaction // All transactions to allow lookups
 	beats   map[common.Address]time.Time       // Last heartbeat from each known account
 
+	batch      types.Transactions // Transactions promoted since the last batch event
+	batchMu    sync.Mutex         // Mutex protecting the batch, separate from the pool lock
+	batchReady chan struct{}      // Notification channel for the first transaction of a batch
+
 	wg   sync.WaitGroup // for shutdown sync
 	quit chan struct{}
 
 	homestead bool
 }
 
-func NewTxPool(config *params.ChainConfig, eventMux *event.TypeMux, currentStateFn stateFn, gasLimitFn func() *big.Int) *TxPool {
+func NewTxPool(config *params.ChainConfig, eventMux *event.TypeMux, chain chainHeadSubscriber, currentStateFn stateFn, gasLimitFn func() *big.Int) *TxPool {
 	pool := &TxPool{
 		config:       config,
 		signer:       types.NewEIP155Signer(config.ChainId),
@@ -119,15 +138,19 @@ func NewTxPool(config *params.ChainConfig, eventMux *event.TypeMux, currentState
 		minGasPrice:  new(big.Int),
 		pendingState: nil,
 		localTx:      newTxSet(),
-		events:       eventMux.Subscribe(ChainHeadEvent{}, GasPriceChanged{}, RemovedTransactionEvent{}),
+		events:       eventMux.Subscribe(GasPriceChanged{}, RemovedTransactionEvent{}),
+		chainHeadCh:  make(chan ChainHeadEvent, chainHeadChanSize),
+		batchReady:   make(chan struct{}, 1),
 		quit:         make(chan struct{}),
 	}
+	pool.chainHeadSub = chain.SubscribeChainHeadEvent(pool.chainHeadCh)
 
 	pool.resetState()
 
-	pool.wg.Add(2)
+	pool.wg.Add(3)
 	go pool.eventLoop()
 	go pool.expirationLoop()
+	go pool.batchLoop()
 
 	return pool
 }
@@ -138,24 +161,34 @@ func (pool *TxPool) eventLoop() {
 	// Track chain events. When a chain events occurs (new chain canon block)
 	// we need to know the new state. The new state will help us determine
 	// the nonces in the managed state
-	for ev := range pool.events.Chan() {
-		switch ev := ev.Data.(type) {
-		case ChainHeadEvent:
+	for {
+		select {
+		case ev := <-pool.chainHeadCh:
 			pool.mu.Lock()
 			if ev.Block != nil {
 				if pool.config.IsHomestead(ev.Block.Number()) {
 					pool.homestead = true
 				}
 			}
-
 			pool.resetState()
 			pool.mu.Unlock()
-		case GasPriceChanged:
-			pool.mu.Lock()
-			pool.minGasPrice = ev.Price
-			pool.mu.Unlock()
-		case RemovedTransactionEvent:
-			pool.AddBatch(ev.Txs)
+
+		case ev, ok := <-pool.events.Chan():
+			if !ok {
+				return
+			}
+			switch ev := ev.Data.(type) {
+			case GasPriceChanged:
+				pool.mu.Lock()
+				pool.minGasPrice = ev.Price
+				pool.mu.Unlock()
+			case RemovedTransactionEvent:
+				pool.AddBatch(ev.Txs)
+			}
+
+		// Be unsubscribed due to system stopped
+		case <-pool.chainHeadSub.Err():
+			return
 		}
 	}
 }
@@ -190,12 +223,28 @@ func (pool *TxPool) resetState() {
 }
 
 func (pool *TxPool) Stop() {
+	// Unsubscribe all subscriptions registered from txpool
+	pool.scope.Close()
+
 	pool.events.Unsubscribe()
+	pool.chainHeadSub.Unsubscribe()
 	close(pool.quit)
 	pool.wg.Wait()
 	glog.V(logger.Info).Infoln("Transaction pool stopped")
 }
 
+// SubscribeTxPreEvent registers a subscription of TxPreEvent and
+// starts sending event to the given channel.
+func (pool *TxPool) SubscribeTxPreEvent(ch chan<- TxPreEvent) event.Subscription {
+	return pool.scope.Track(pool.txFeed.Subscribe(ch))
+}
+
+// SubscribeTxsPreEvent registers a subscription of TxsPreEvent and starts
+// sending the batched transaction events to the given channel.
+func (pool *TxPool) SubscribeTxsPreEvent(ch chan<- TxsPreEvent) event.Subscription {
+	return pool.scope.Track(pool.txsFeed.Subscribe(ch))
+}
+
 func (pool *TxPool) State() *state.ManagedState {
 	pool.mu.RLock()
 	defer pool.mu.RUnlock()
@@ -394,11 +443,14 @@ func (pool *TxPool) promoteTx(addr common.Address, hash common.Hash, tx *types.T
 	// Set the potentially new pending nonce and notify any subsystems of the new tx
 	pool.beats[addr] = time.Now()
 	pool.pendingState.SetNonce(addr, tx.Nonce()+1)
-	go pool.eventMux.Post(TxPreEvent{tx})
+	go pool.txFeed.Send(TxPreEvent{tx})
+	pool.queueBatch(tx)
 }
 
 // Add queues a single transaction in the pool if it is valid.
 func (pool *TxPool) Add(tx *types.Transaction) error {
+	types.RecoverSenders(senderRecoverer, pool.signer, types.Transactions{tx})
+
 	pool.mu.Lock()
 	defer pool.mu.Unlock()
 
@@ -418,6 +470,9 @@ func (pool *TxPool) Add(tx *types.Transaction) error {
 
 // AddBatch attempts to queue a batch of transactions.
 func (pool *TxPool) AddBatch(txs []*types.Transaction) error {
+	// Recover all the senders concurrently before locking the pool
+	types.RecoverSenders(senderRecoverer, pool.signer, txs)
+
 	pool.mu.Lock()
 	defer pool.mu.Unlock()
 
@@ -707,6 +762,48 @@ func (pool *TxPool) expirationLoop() {
 	}
 }
 
+// queueBatch adds a newly promoted transaction to the next batch event, waking
+// up the batch loop if it's the first one.
+func (pool *TxPool) queueBatch(tx *types.Transaction) {
+	pool.batchMu.Lock()
+	pool.batch = append(pool.batch, tx)
+	pool.batchMu.Unlock()
+
+	select {
+	case pool.batchReady <- struct{}{}:
+	default:
+	}
+}
+
+// batchLoop announces the promoted transactions in batches, waiting a short
+// while after the first one for others to arrive, so that subscribers are not
+// woken up for every single transaction under heavy load.
+func (pool *TxPool) batchLoop() {
+	defer pool.wg.Done()
+
+	for {
+		select {
+		case <-pool.batchReady:
+			select {
+			case <-time.After(txBatchInterval):
+			case <-pool.quit:
+				return
+			}
+			pool.batchMu.Lock()
+			txs := pool.batch
+			pool.batch = nil
+			pool.batchMu.Unlock()
+
+			if len(txs) > 0 {
+				pool.txsFeed.Send(TxsPreEvent{txs})
+			}
+
+		case <-pool.quit:
+			return
+		}
+	}
+}
+
 // addressByHeartbeat is an account address tagged with its last activity timestamp.
 type addressByHeartbeat struct {
 	address   common.Address
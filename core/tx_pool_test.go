@@ -36,12 +36,21 @@ func transaction(nonce uint64, gaslimit *big.Int, key *ecdsa.PrivateKey) *types.
 	return tx
 }
 
+// testBlockChain is a stand-in chain announcing head events to the pool.
+type testBlockChain struct {
+	chainHeadFeed event.Feed
+}
+
+func (bc *testBlockChain) SubscribeChainHeadEvent(ch chan<- ChainHeadEvent) event.Subscription {
+	return bc.chainHeadFeed.Subscribe(ch)
+}
+
 func setupTxPool() (*TxPool, *ecdsa.PrivateKey) {
 	db, _ := ethdb.NewMemDatabase()
 	statedb, _ := state.New(common.Hash{}, db)
 
 	key, _ := crypto.GenerateKey()
-	newPool := NewTxPool(testChainConfig(), new(event.TypeMux), func() (*state.StateDB, error) { return statedb, nil }, func() *big.Int { return big.NewInt(1000000) })
+	newPool := NewTxPool(testChainConfig(), new(event.TypeMux), new(testBlockChain), func() (*state.StateDB, error) { return statedb, nil }, func() *big.Int { return big.NewInt(1000000) })
 	newPool.resetState()
 
 	return newPool, key
@@ -90,7 +99,7 @@ func TestStateChangeDuringPoolReset(t *testing.T) {
 
 	gasLimitFunc := func() *big.Int { return big.NewInt(1000000000) }
 
-	txpool := NewTxPool(testChainConfig(), mux, stateFunc, gasLimitFunc)
+	txpool := NewTxPool(testChainConfig(), mux, new(testBlockChain), stateFunc, gasLimitFunc)
 	txpool.resetState()
 
 	nonce := txpool.State().GetNonce(address)
@@ -371,7 +380,8 @@ func TestRemovedTxEvent(t *testing.T) {
 	currentState.AddBalance(from, big.NewInt(1000000000000))
 	pool.resetState()
 	pool.eventMux.Post(RemovedTransactionEvent{types.Transactions{tx}})
-	pool.eventMux.Post(ChainHeadEvent{nil})
+	// Posting blocks until the event loop is done with the previous event
+	pool.eventMux.Post(RemovedTransactionEvent{})
 	if pool.pending[from].Len() != 1 {
 		t.Error("expected 1 pending tx, got", pool.pending[from].Len())
 	}
@@ -563,7 +573,7 @@ func TestTransactionQueueGlobalLimiting(t *testing.T) {
 	db, _ := ethdb.NewMemDatabase()
 	statedb, _ := state.New(common.Hash{}, db)
 
-	pool := NewTxPool(testChainConfig(), new(event.TypeMux), func() (*state.StateDB, error) { return statedb, nil }, func() *big.Int { return big.NewInt(1000000) })
+	pool := NewTxPool(testChainConfig(), new(event.TypeMux), new(testBlockChain), func() (*state.StateDB, error) { return statedb, nil }, func() *big.Int { return big.NewInt(1000000) })
 	pool.resetState()
 
 	// Create a number of test accounts and fund them
@@ -712,7 +722,7 @@ func TestTransactionPendingGlobalLimiting(t *testing.T) {
 	db, _ := ethdb.NewMemDatabase()
 	statedb, _ := state.New(common.Hash{}, db)
 
-	pool := NewTxPool(testChainConfig(), new(event.TypeMux), func() (*state.StateDB, error) { return statedb, nil }, func() *big.Int { return big.NewInt(1000000) })
+	pool := NewTxPool(testChainConfig(), new(event.TypeMux), new(testBlockChain), func() (*state.StateDB, error) { return statedb, nil }, func() *big.Int { return big.NewInt(1000000) })
 	pool.resetState()
 
 	// Create a number of test accounts and fund them
@@ -758,7 +768,7 @@ func TestTransactionPendingMinimumAllowance(t *testing.T) {
 	db, _ := ethdb.NewMemDatabase()
 	statedb, _ := state.New(common.Hash{}, db)
 
-	pool := NewTxPool(testChainConfig(), new(event.TypeMux), func() (*state.StateDB, error) { return statedb, nil }, func() *big.Int { return big.NewInt(1000000) })
+	pool := NewTxPool(testChainConfig(), new(event.TypeMux), new(testBlockChain), func() (*state.StateDB, error) { return statedb, nil }, func() *big.Int { return big.NewInt(1000000) })
 	pool.resetState()
 
 	// Create a number of test accounts and fund them
@@ -882,3 +892,64 @@ func benchmarkPoolBatchInsert(b *testing.B, size int) {
 		pool.AddBatch(batch)
 	}
 }
+
+// Tests that stopping the pool ends all event subscriptions handed out by it,
+// releasing any consumers waiting for events.
+func TestTxPoolStopUnsubscribes(t *testing.T) {
+	pool, _ := setupTxPool()
+
+	subs := make([]event.Subscription, 3)
+	for i := range subs {
+		subs[i] = pool.SubscribeTxPreEvent(make(chan TxPreEvent))
+	}
+	pool.Stop()
+
+	for i, sub := range subs {
+		select {
+		case <-sub.Err():
+		case <-time.After(time.Second):
+			t.Errorf("subscription %d not closed by pool stop", i)
+		}
+	}
+}
+
+// Tests that transactions promoted in quick succession are announced together
+// in a single batch event.
+func TestTxPoolBatchEvents(t *testing.T) {
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	events := make(chan TxsPreEvent, 10)
+	sub := pool.SubscribeTxsPreEvent(events)
+	defer sub.Unsubscribe()
+
+	currentState, _ := pool.currentState()
+	currentState.AddBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000))
+	pool.resetState()
+
+	txs := make(types.Transactions, 5)
+	for i := range txs {
+		txs[i] = transaction(uint64(i), big.NewInt(100000), key)
+	}
+	if err := pool.AddBatch(txs); err != nil {
+		t.Fatalf("failed to add transactions: %v", err)
+	}
+	select {
+	case ev := <-events:
+		if len(ev.Txs) != len(txs) {
+			t.Fatalf("batch size mismatch: have %d, want %d", len(ev.Txs), len(txs))
+		}
+		for i, tx := range ev.Txs {
+			if tx.Hash() != txs[i].Hash() {
+				t.Errorf("tx %d: hash mismatch: have %x, want %x", i, tx.Hash(), txs[i].Hash())
+			}
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no batch event received")
+	}
+	select {
+	case ev := <-events:
+		t.Errorf("unexpected second batch of %d transactions", len(ev.Txs))
+	case <-time.After(2 * txBatchInterval):
+	}
+}
@@ -175,6 +175,55 @@ func (r *ReceiptForStorage) DecodeRLP(s *rlp.Stream) error {
 	return nil
 }
 
+// CompactReceiptForStorage is a wrapper around a Receipt that flattens only the
+// fields which cannot be recomputed from the block the receipt belongs to: the
+// post state, the cumulative gas used and the consensus fields of the logs. The
+// bloom filter is regenerated on decoding, all other implementation fields need
+// to be derived by the caller. Receipts stored in the full ReceiptForStorage
+// format are also accepted when decoding.
+type CompactReceiptForStorage Receipt
+
+// EncodeRLP implements rlp.Encoder, and flattens the non-derivable fields of a
+// receipt into an RLP stream.
+func (r *CompactReceiptForStorage) EncodeRLP(w io.Writer) error {
+	return rlp.Encode(w, []interface{}{r.PostState, r.CumulativeGasUsed, r.Logs})
+}
+
+// DecodeRLP implements rlp.Decoder, and loads a receipt from an RLP stream in
+// either the compact or the full storage format.
+func (r *CompactReceiptForStorage) DecodeRLP(s *rlp.Stream) error {
+	blob, err := s.Raw()
+	if err != nil {
+		return err
+	}
+	content, _, err := rlp.SplitList(blob)
+	if err != nil {
+		return err
+	}
+	fields, err := rlp.CountValues(content)
+	if err != nil {
+		return err
+	}
+	if fields != 3 {
+		return rlp.DecodeBytes(blob, (*ReceiptForStorage)(r))
+	}
+	var receipt struct {
+		PostState         []byte
+		CumulativeGasUsed *big.Int
+		Logs              []*Log
+	}
+	if err := rlp.DecodeBytes(blob, &receipt); err != nil {
+		return err
+	}
+	*r = CompactReceiptForStorage{
+		PostState:         receipt.PostState,
+		CumulativeGasUsed: receipt.CumulativeGasUsed,
+		Bloom:             BytesToBloom(LogsBloom(receipt.Logs).Bytes()),
+		Logs:              receipt.Logs,
+	}
+	return nil
+}
+
 // Receipts is a wrapper around a Receipt array to implement DerivableList.
 type Receipts []*Receipt
 
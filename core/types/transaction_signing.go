@@ -27,7 +27,11 @@ import (
 	"github.com/EarthDollar/go-earthdollar/params"
 )
 
-var ErrInvalidChainId = errors.New("invalid chaid id for signer")
+var (
+	ErrInvalidChainId = errors.New("invalid chaid id for signer")
+
+	errInvalidPubkey = errors.New("invalid public key")
+)
 
 // sigCache is used to cache the derived sender and contains
 // the signer used to derive it.
@@ -91,6 +95,48 @@ func Sender(signer Signer, tx *Transaction) (common.Address, error) {
 	return addr, nil
 }
 
+// RecoverSenders derives the senders of a batch of transactions concurrently
+// using the given recoverer, caching them in the transaction objects. As the
+// recoverer remembers the public keys by transaction hash, transactions seen
+// before (e.g. in the pool before being included in a block) are not recovered
+// again. Invalid signatures are skipped, Sender reports them when called.
+func RecoverSenders(r *crypto.BatchRecoverer, signer Signer, txs Transactions) {
+	sv, ok := signer.(interface {
+		sigValues(tx *Transaction) (common.Hash, []byte, error)
+	})
+	if !ok {
+		for _, tx := range txs {
+			Sender(signer, tx)
+		}
+		return
+	}
+	var (
+		tasks  = make([]*crypto.SigTask, 0, len(txs))
+		owners = make([]*Transaction, 0, len(txs))
+	)
+	for _, tx := range txs {
+		if sc := tx.from.Load(); sc != nil && sc.(sigCache).signer.Equal(signer) {
+			continue
+		}
+		hash, sig, err := sv.sigValues(tx)
+		if err != nil {
+			continue
+		}
+		tasks = append(tasks, &crypto.SigTask{Key: tx.Hash(), Hash: hash[:], Sig: sig})
+		owners = append(owners, tx)
+	}
+	r.Recover(tasks)
+
+	for i, task := range tasks {
+		if task.Err != nil || len(task.Pubkey) == 0 || task.Pubkey[0] != 4 {
+			continue
+		}
+		var addr common.Address
+		copy(addr[:], crypto.Keccak256(task.Pubkey[1:])[12:])
+		owners[i].from.Store(sigCache{signer: signer, from: addr})
+	}
+}
+
 type Signer interface {
 	// Hash returns the rlp encoded hash for signatures
 	Hash(tx *Transaction) common.Hash
@@ -124,36 +170,30 @@ func (s EIP155Signer) Equal(s2 Signer) bool {
 }
 
 func (s EIP155Signer) PublicKey(tx *Transaction) ([]byte, error) {
+	hash, sig, err := s.sigValues(tx)
+	if err != nil {
+		return nil, err
+	}
+	return recoverPlain(hash, sig)
+}
+
+// sigValues validates the signature of the transaction, returning the hash it
+// was created over and the signature in [R || S || V] format.
+func (s EIP155Signer) sigValues(tx *Transaction) (common.Hash, []byte, error) {
 	// if the transaction is not protected fall back to homestead signer
 	if !tx.Protected() {
-		return (HomesteadSigner{}).PublicKey(tx)
+		return (HomesteadSigner{}).sigValues(tx)
 	}
 
 	if tx.ChainId().Cmp(s.chainId) != 0 {
-		return nil, ErrInvalidChainId
+		return common.Hash{}, nil, ErrInvalidChainId
 	}
 
 	V := byte(new(big.Int).Sub(tx.data.V, s.chainIdMul).Uint64() - 35)
 	if !crypto.ValidateSignatureValues(V, tx.data.R, tx.data.S, true) {
-		return nil, ErrInvalidSig
-	}
-	// encode the signature in uncompressed format
-	R, S := tx.data.R.Bytes(), tx.data.S.Bytes()
-	sig := make([]byte, 65)
-	copy(sig[32-len(R):32], R)
-	copy(sig[64-len(S):64], S)
-	sig[64] = V
-
-	// recover the public key from the signature
-	hash := s.Hash(tx)
-	pub, err := crypto.Ecrecover(hash[:], sig)
-	if err != nil {
-		return nil, err
-	}
-	if len(pub) == 0 || pub[0] != 4 {
-		return nil, errors.New("invalid public key")
+		return common.Hash{}, nil, ErrInvalidSig
 	}
-	return pub, nil
+	return s.Hash(tx), encodeSignature(tx.data.R, tx.data.S, V), nil
 }
 
 // WithSignature returns a new transaction with the given signature. This signature
@@ -211,30 +251,24 @@ func (hs HomesteadSigner) WithSignature(tx *Transaction, sig []byte) (*Transacti
 }
 
 func (hs HomesteadSigner) PublicKey(tx *Transaction) ([]byte, error) {
+	hash, sig, err := hs.sigValues(tx)
+	if err != nil {
+		return nil, err
+	}
+	return recoverPlain(hash, sig)
+}
+
+// sigValues validates the signature of the transaction, returning the hash it
+// was created over and the signature in [R || S || V] format.
+func (hs HomesteadSigner) sigValues(tx *Transaction) (common.Hash, []byte, error) {
 	if tx.data.V.BitLen() > 8 {
-		return nil, ErrInvalidSig
+		return common.Hash{}, nil, ErrInvalidSig
 	}
 	V := byte(tx.data.V.Uint64() - 27)
 	if !crypto.ValidateSignatureValues(V, tx.data.R, tx.data.S, true) {
-		return nil, ErrInvalidSig
-	}
-	// encode the snature in uncompressed format
-	r, s := tx.data.R.Bytes(), tx.data.S.Bytes()
-	sig := make([]byte, 65)
-	copy(sig[32-len(r):32], r)
-	copy(sig[64-len(s):64], s)
-	sig[64] = V
-
-	// recover the public key from the snature
-	hash := hs.Hash(tx)
-	pub, err := crypto.Ecrecover(hash[:], sig)
-	if err != nil {
-		return nil, err
-	}
-	if len(pub) == 0 || pub[0] != 4 {
-		return nil, errors.New("invalid public key")
+		return common.Hash{}, nil, ErrInvalidSig
 	}
-	return pub, nil
+	return hs.Hash(tx), encodeSignature(tx.data.R, tx.data.S, V), nil
 }
 
 type FrontierSigner struct{}
@@ -271,29 +305,45 @@ func (fs FrontierSigner) Hash(tx *Transaction) common.Hash {
 }
 
 func (fs FrontierSigner) PublicKey(tx *Transaction) ([]byte, error) {
+	hash, sig, err := fs.sigValues(tx)
+	if err != nil {
+		return nil, err
+	}
+	return recoverPlain(hash, sig)
+}
+
+// sigValues validates the signature of the transaction, returning the hash it
+// was created over and the signature in [R || S || V] format.
+func (fs FrontierSigner) sigValues(tx *Transaction) (common.Hash, []byte, error) {
 	if tx.data.V.BitLen() > 8 {
-		return nil, ErrInvalidSig
+		return common.Hash{}, nil, ErrInvalidSig
 	}
 
 	V := byte(tx.data.V.Uint64() - 27)
 	if !crypto.ValidateSignatureValues(V, tx.data.R, tx.data.S, false) {
-		return nil, ErrInvalidSig
+		return common.Hash{}, nil, ErrInvalidSig
 	}
-	// encode the snature in uncompressed format
-	r, s := tx.data.R.Bytes(), tx.data.S.Bytes()
+	return fs.Hash(tx), encodeSignature(tx.data.R, tx.data.S, V), nil
+}
+
+// encodeSignature assembles the signature values into [R || S || V] format.
+func encodeSignature(R, S *big.Int, V byte) []byte {
+	r, s := R.Bytes(), S.Bytes()
 	sig := make([]byte, 65)
 	copy(sig[32-len(r):32], r)
 	copy(sig[64-len(s):64], s)
 	sig[64] = V
+	return sig
+}
 
-	// recover the public key from the snature
-	hash := fs.Hash(tx)
+// recoverPlain recovers the uncompressed public key of the signer.
+func recoverPlain(hash common.Hash, sig []byte) ([]byte, error) {
 	pub, err := crypto.Ecrecover(hash[:], sig)
 	if err != nil {
 		return nil, err
 	}
 	if len(pub) == 0 || pub[0] != 4 {
-		return nil, errors.New("invalid public key")
+		return nil, errInvalidPubkey
 	}
 	return pub, nil
 }
@@ -136,3 +136,55 @@ func TestChainId(t *testing.T) {
 		t.Error("expected no error")
 	}
 }
+
+// Tests that batch recovering senders caches them in the transactions and that
+// transactions with invalid signatures are still reported by Sender.
+func TestRecoverSenders(t *testing.T) {
+	signer := NewEIP155Signer(big.NewInt(18))
+
+	var (
+		txs   Transactions
+		addrs []common.Address
+	)
+	for i := 0; i < 8; i++ {
+		key, _ := crypto.GenerateKey()
+		tx, err := SignTx(NewTransaction(uint64(i), common.Address{}, new(big.Int), new(big.Int), new(big.Int), nil), signer, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		txs = append(txs, tx)
+		addrs = append(addrs, crypto.PubkeyToAddress(key.PublicKey))
+	}
+	invalid, _ := NewTransaction(0, common.Address{}, new(big.Int), new(big.Int), new(big.Int), nil).WithSignature(signer, make([]byte, 65))
+	txs = append(txs, invalid)
+
+	recoverer := crypto.NewBatchRecoverer(0, 16)
+	RecoverSenders(recoverer, signer, txs)
+
+	for i, tx := range txs[:8] {
+		if tx.from.Load() == nil {
+			t.Errorf("tx %d: sender not cached", i)
+		}
+		if from, err := Sender(signer, tx); err != nil || from != addrs[i] {
+			t.Errorf("tx %d: sender mismatch: have %x (%v), want %x", i, from, err, addrs[i])
+		}
+	}
+	if _, err := Sender(signer, invalid); err == nil {
+		t.Errorf("invalid signature accepted")
+	}
+	// Decoding the same transactions anew should hit the recoverer's cache
+	for i, tx := range txs[:8] {
+		enc, _ := rlp.EncodeToBytes(tx)
+		dec := new(Transaction)
+		if err := rlp.DecodeBytes(enc, dec); err != nil {
+			t.Fatalf("tx %d: failed to decode: %v", i, err)
+		}
+		txs[i] = dec
+	}
+	RecoverSenders(recoverer, signer, txs[:8])
+	for i, tx := range txs[:8] {
+		if sc := tx.from.Load(); sc == nil || sc.(sigCache).from != addrs[i] {
+			t.Errorf("tx %d: sender not restored from cache", i)
+		}
+	}
+}
@@ -17,23 +17,20 @@
 package vm
 
 import (
-	"math/big"
-
 	"github.com/EarthDollar/go-earthdollar/common"
+	"github.com/EarthDollar/go-earthdollar/common/uint256"
 )
 
-var bigMaxUint64 = new(big.Int).SetUint64(^uint64(0))
-
 // destinations stores one map per contract (keyed by hash of code).
 // The maps contain an entry for each location of a JUMPDEST
 // instruction.
 type destinations map[common.Hash]map[uint64]struct{}
 
 // has checks whether code has a JUMPDEST at dest.
-func (d destinations) has(codehash common.Hash, code []byte, dest *big.Int) bool {
+func (d destinations) has(codehash common.Hash, code []byte, dest *uint256.Int) bool {
 	// PC cannot go beyond len(code) and certainly can't be bigger than 64bits.
 	// Don't bother checking for JUMPDEST in that case.
-	if dest.Cmp(bigMaxUint64) > 0 {
+	if !dest.IsUint64() {
 		return false
 	}
 	m, analysed := d[codehash]
@@ -21,6 +21,7 @@ import (
 	"math/big"
 
 	"github.com/EarthDollar/go-earthdollar/common"
+	"github.com/EarthDollar/go-earthdollar/common/uint256"
 	"github.com/EarthDollar/go-earthdollar/params"
 )
 
@@ -46,12 +47,16 @@ var (
 )
 
 // calculates the memory size required for a step
-func calcMemSize(off, l *big.Int) *big.Int {
-	if l.Cmp(common.Big0) == 0 {
+func calcMemSize(off, l *uint256.Int) *big.Int {
+	if l.IsZero() {
 		return common.Big0
 	}
-
-	return new(big.Int).Add(off, l)
+	if off.IsUint64() && l.IsUint64() {
+		if size := off.Uint64() + l.Uint64(); size >= off.Uint64() {
+			return new(big.Int).SetUint64(size)
+		}
+	}
+	return new(big.Int).Add(off.ToBig(), l.ToBig())
 }
 
 // calculates the quadratic gas
@@ -98,12 +103,27 @@ func toValue(val *big.Int) interface{} {
 
 // getData returns a slice from the data based on the start and size and pads
 // up to size with zero's. This function is overflow safe.
-func getData(data []byte, start, size *big.Int) []byte {
-	dlen := big.NewInt(int64(len(data)))
+func getData(data []byte, start, size uint64) []byte {
+	dlen := uint64(len(data))
+
+	s := start
+	if s > dlen {
+		s = dlen
+	}
+	e := s + size
+	if e > dlen || e < s {
+		e = dlen
+	}
+	return common.RightPadBytes(data[s:e], int(size))
+}
 
-	s := common.BigMin(start, dlen)
-	e := common.BigMin(new(big.Int).Add(s, size), dlen)
-	return common.RightPadBytes(data[s.Uint64():e.Uint64()], int(size.Uint64()))
+// getDataAt is getData with a start offset taken from the stack, which may
+// exceed 64 bits (and thus the length of any data).
+func getDataAt(data []byte, start *uint256.Int, size uint64) []byte {
+	if !start.IsUint64() {
+		return getData(data, uint64(len(data)), size)
+	}
+	return getData(data, start.Uint64(), size)
 }
 
 // useGas attempts to subtract the amount of gas and returns whether it was
@@ -0,0 +1,188 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/EarthDollar/go-earthdollar/common"
+	"github.com/EarthDollar/go-earthdollar/crypto/bn256"
+	"github.com/EarthDollar/go-earthdollar/params"
+)
+
+type precompiledTest struct {
+	name     string
+	address  byte
+	input    string
+	expected string
+	gas      int64
+	fails    bool
+}
+
+// g1Gen and g2Gen are the marshalled generators of the bn256 groups.
+var (
+	g1Gen    = common.Bytes2Hex(new(bn256.G1).ScalarBaseMult(big.NewInt(1)).Marshal())
+	g1GenNeg = common.Bytes2Hex(new(bn256.G1).Neg(new(bn256.G1).ScalarBaseMult(big.NewInt(1))).Marshal())
+	g2Gen    = common.Bytes2Hex(new(bn256.G2).ScalarBaseMult(big.NewInt(1)).Marshal())
+)
+
+var precompiledTests = []precompiledTest{
+	{
+		name:     "modexp-eip198-example",
+		address:  5,
+		input:    "0000000000000000000000000000000000000000000000000000000000000001" + "0000000000000000000000000000000000000000000000000000000000000020" + "0000000000000000000000000000000000000000000000000000000000000020" + "03" + "fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2e" + "fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f",
+		expected: "0000000000000000000000000000000000000000000000000000000000000001",
+		gas:      13056,
+	},
+	{
+		name:     "modexp-zero-modulus",
+		address:  5,
+		input:    "0000000000000000000000000000000000000000000000000000000000000001" + "0000000000000000000000000000000000000000000000000000000000000001" + "0000000000000000000000000000000000000000000000000000000000000002" + "03" + "05",
+		expected: "0000",
+		gas:      0,
+	},
+	{
+		name:     "bn256-add-double",
+		address:  6,
+		input:    g1Gen + g1Gen,
+		expected: "030644e72e131a029b85045b68181585d97816a916871ca8d3c208c16d87cfd315ed738c0e0a7c92e7845f96b2ae9c0a68a6a449e3538fc7ff3ebf7a5a18a2c4",
+		gas:      500,
+	},
+	{
+		name:     "bn256-add-infinity",
+		address:  6,
+		input:    "",
+		expected: common.Bytes2Hex(make([]byte, 64)),
+		gas:      500,
+	},
+	{
+		name:    "bn256-add-outside-curve",
+		address: 6,
+		input:   "0000000000000000000000000000000000000000000000000000000000000001" + "0000000000000000000000000000000000000000000000000000000000000001",
+		gas:     500,
+		fails:   true,
+	},
+	{
+		name:     "bn256-scalarmul",
+		address:  7,
+		input:    g1Gen + "00000000000000000000000000000000000000000000000000000000deadbeef",
+		expected: "1fd9bf9c6c9fc892f0b4f856657cd9309f43e2f1cfa3ed4724c40bd74ea1380318ee06de0e49deaf292d55f31fd13e603489f81bfa4ec6f2443ba2274621703f",
+		gas:      40000,
+	},
+	{
+		name:     "bn256-pairing-empty",
+		address:  8,
+		input:    "",
+		expected: "0000000000000000000000000000000000000000000000000000000000000001",
+		gas:      100000,
+	},
+	{
+		name:     "bn256-pairing-single",
+		address:  8,
+		input:    g1Gen + g2Gen,
+		expected: "0000000000000000000000000000000000000000000000000000000000000000",
+		gas:      180000,
+	},
+	{
+		name:     "bn256-pairing-inverse",
+		address:  8,
+		input:    g1Gen + g2Gen + g1GenNeg + g2Gen,
+		expected: "0000000000000000000000000000000000000000000000000000000000000001",
+		gas:      260000,
+	},
+	{
+		name:    "bn256-pairing-bad-size",
+		address: 8,
+		input:   g1Gen,
+		gas:     100000,
+		fails:   true,
+	},
+	{
+		name:     "blake2f-eip152-vector5",
+		address:  9,
+		input:    "0000000c48c9bdf267e6096a3ba7ca8485ae67bb2bf894fe72f36e3cf1361d5f3af54fa5d182e6ad7f520e511f6c3e2b8c68059b6bbd41fbabd9831f79217e1319cde05b61626300000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000300000000000000000000000000000001",
+		expected: "ba80a53f981c4d0d6a2797b69f12f6e94c212f14685ac4b74b12bb6fdbffa2d17d87c5392aab792dc252d5de4533cc9518d38aa8dbf1925ab92386edd4009923",
+		gas:      12,
+	},
+	{
+		name:    "blake2f-bad-length",
+		address: 9,
+		input:   "00",
+		gas:     0,
+		fails:   true,
+	},
+	{
+		name:    "blake2f-bad-flag",
+		address: 9,
+		input:   "0000000c48c9bdf267e6096a3ba7ca8485ae67bb2bf894fe72f36e3cf1361d5f3af54fa5d182e6ad7f520e511f6c3e2b8c68059b6bbd41fbabd9831f79217e1319cde05b61626300000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000300000000000000000000000000000002",
+		gas:     12,
+		fails:   true,
+	},
+}
+
+func TestPrecompiledContracts(t *testing.T) {
+	for _, test := range precompiledTests {
+		p := PrecompiledContractsEIP152[common.BytesToAddress([]byte{test.address})]
+		input := common.Hex2Bytes(test.input)
+
+		if gas := p.RequiredGas(input); gas.Cmp(big.NewInt(test.gas)) != 0 {
+			t.Errorf("%s: gas mismatch: have %v, want %d", test.name, gas, test.gas)
+		}
+		res, err := p.Run(input)
+		if test.fails {
+			if err == nil {
+				t.Errorf("%s: expected error, got none", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if !bytes.Equal(res, common.Hex2Bytes(test.expected)) {
+			t.Errorf("%s: output mismatch: have %x, want %s", test.name, res, test.expected)
+		}
+	}
+}
+
+func TestPrecompiledContractsForks(t *testing.T) {
+	var (
+		modexp = common.BytesToAddress([]byte{5})
+		blake  = common.BytesToAddress([]byte{9})
+		config = &params.ChainConfig{EIP198Block: big.NewInt(10), EIP152Block: big.NewInt(20)}
+	)
+	tests := []struct {
+		number        int64
+		modexp, blake bool
+	}{
+		{0, false, false},
+		{10, true, false},
+		{20, true, true},
+	}
+	for _, test := range tests {
+		env := NewEVM(Context{BlockNumber: big.NewInt(test.number)}, nil, config, Config{})
+		precompiles := env.precompiles()
+		if _, ok := precompiles[modexp]; ok != test.modexp {
+			t.Errorf("block %d: modexp active %v, want %v", test.number, ok, test.modexp)
+		}
+		if _, ok := precompiles[blake]; ok != test.blake {
+			t.Errorf("block %d: blake2f active %v, want %v", test.number, ok, test.blake)
+		}
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"math/big"
 	"sync/atomic"
+	"time"
 
 	"github.com/EarthDollar/go-earthdollar/common"
 	"github.com/EarthDollar/go-earthdollar/crypto"
@@ -127,7 +128,12 @@ func (evm *EVM) Call(caller ContractRef, addr common.Address, input []byte, gas,
 		snapshot = evm.StateDB.Snapshot()
 	)
 	if !evm.StateDB.Exist(addr) {
-		if PrecompiledContracts[addr] == nil && evm.ChainConfig().IsEIP158(evm.BlockNumber) && value.BitLen() == 0 {
+		if evm.precompiles()[addr] == nil && evm.ChainConfig().IsEIP158(evm.BlockNumber) && value.BitLen() == 0 {
+			// Calling a non existing account, don't do anything, but ping the tracer
+			if evm.vmConfig.Tracer != nil && evm.depth == 0 {
+				evm.vmConfig.Tracer.CaptureStart(caller.Address(), addr, false, input, gas, value)
+				evm.vmConfig.Tracer.CaptureEnd(nil, new(big.Int), 0, nil)
+			}
 			caller.ReturnGas(gas)
 			return nil, nil
 		}
@@ -145,13 +151,26 @@ func (evm *EVM) Call(caller ContractRef, addr common.Address, input []byte, gas,
 	contract.SetCallCode(&addr, evm.StateDB.GetCodeHash(addr), evm.StateDB.GetCode(addr))
 	defer contract.Finalise()
 
+	// Capture the tracer start/end events for the outermost call
+	if evm.vmConfig.Tracer != nil && evm.depth == 0 {
+		evm.vmConfig.Tracer.CaptureStart(caller.Address(), addr, false, input, gas, value)
+
+		// The contract shares the gas counter with the caller, so keep a copy
+		// of the initial allowance to calculate the gas used.
+		start, startGas := time.Now(), new(big.Int).Set(gas)
+		defer func() {
+			evm.vmConfig.Tracer.CaptureEnd(ret, startGas.Sub(startGas, contract.Gas), time.Since(start), err)
+		}()
+	}
 	ret, err = evm.interpreter.Run(contract, input)
 	// When an error was returned by the EVM or when setting the creation code
-	// above we revert to the snapshot and consume any gas remaining. Additionally
-	// when we're in homestead this also counts for code storage gas errors.
+	// above we revert to the snapshot and consume any gas remaining, unless the
+	// code explicitly reverted. Additionally when we're in homestead this also
+	// counts for code storage gas errors.
 	if err != nil {
-		contract.UseGas(contract.Gas)
-
+		if err != ErrExecutionReverted {
+			contract.UseGas(contract.Gas)
+		}
 		evm.StateDB.RevertToSnapshot(snapshot)
 	}
 	return ret, err
@@ -195,8 +214,9 @@ func (evm *EVM) CallCode(caller ContractRef, addr common.Address, input []byte,
 
 	ret, err = evm.interpreter.Run(contract, input)
 	if err != nil {
-		contract.UseGas(contract.Gas)
-
+		if err != ErrExecutionReverted {
+			contract.UseGas(contract.Gas)
+		}
 		evm.StateDB.RevertToSnapshot(snapshot)
 	}
 
@@ -234,16 +254,88 @@ func (evm *EVM) DelegateCall(caller ContractRef, addr common.Address, input []by
 
 	ret, err = evm.interpreter.Run(contract, input)
 	if err != nil {
-		contract.UseGas(contract.Gas)
-
+		if err != ErrExecutionReverted {
+			contract.UseGas(contract.Gas)
+		}
 		evm.StateDB.RevertToSnapshot(snapshot)
 	}
 
 	return ret, err
 }
 
+// StaticCall executes the contract associated with the addr with the given input as parameters
+// while disallowing any modifications to the state during the call. Operations attempting such
+// modifications fail with ErrWriteProtection instead of performing them.
+func (evm *EVM) StaticCall(caller ContractRef, addr common.Address, input []byte, gas *big.Int) (ret []byte, err error) {
+	if evm.vmConfig.NoRecursion && evm.depth > 0 {
+		caller.ReturnGas(gas)
+
+		return nil, nil
+	}
+
+	// Depth check execution. Fail if we're trying to execute above the
+	// limit.
+	if evm.depth > int(params.CallCreateDepth.Int64()) {
+		caller.ReturnGas(gas)
+
+		return nil, ErrDepth
+	}
+	// Make sure the read only flag is only set if we aren't read only yet, so
+	// child calls don't lift the restriction when returning.
+	if !evm.interpreter.readOnly {
+		evm.interpreter.readOnly = true
+		defer func() { evm.interpreter.readOnly = false }()
+	}
+
+	var (
+		to       Account
+		snapshot = evm.StateDB.Snapshot()
+	)
+	if !evm.StateDB.Exist(addr) {
+		// Calling a non-existent account without code is a no-op, there is
+		// no value to transfer that would require creating it
+		if evm.precompiles()[addr] == nil {
+			caller.ReturnGas(gas)
+			return nil, nil
+		}
+		to = evm.StateDB.CreateAccount(addr)
+	} else {
+		to = evm.StateDB.GetAccount(addr)
+	}
+
+	// initialise a new contract and set the code that is to be used by the
+	// EVM. The contract is a scoped environment for this execution context
+	// only.
+	contract := NewContract(caller, to, new(big.Int), gas)
+	contract.SetCallCode(&addr, evm.StateDB.GetCodeHash(addr), evm.StateDB.GetCode(addr))
+	defer contract.Finalise()
+
+	ret, err = evm.interpreter.Run(contract, input)
+	if err != nil {
+		if err != ErrExecutionReverted {
+			contract.UseGas(contract.Gas)
+		}
+		evm.StateDB.RevertToSnapshot(snapshot)
+	}
+	return ret, err
+}
+
 // Create creates a new contract using code as deployment code.
 func (evm *EVM) Create(caller ContractRef, code []byte, gas, value *big.Int) (ret []byte, contractAddr common.Address, err error) {
+	contractAddr = crypto.CreateAddress(caller.Address(), evm.StateDB.GetNonce(caller.Address()))
+	return evm.create(caller, code, gas, value, contractAddr)
+}
+
+// Create2 creates a new contract using code as deployment code. The address
+// of the new contract is derived from the caller, the salt and the hash of the
+// deployment code rather than the caller's nonce (EIP-1014).
+func (evm *EVM) Create2(caller ContractRef, code []byte, gas, value *big.Int, salt [32]byte) (ret []byte, contractAddr common.Address, err error) {
+	contractAddr = crypto.CreateAddress2(caller.Address(), salt, crypto.Keccak256(code))
+	return evm.create(caller, code, gas, value, contractAddr)
+}
+
+// create deploys code as a new contract at the given address.
+func (evm *EVM) create(caller ContractRef, code []byte, gas, value *big.Int, contractAddr common.Address) (ret []byte, _ common.Address, err error) {
 	if evm.vmConfig.NoRecursion && evm.depth > 0 {
 		caller.ReturnGas(gas)
 
@@ -268,7 +360,6 @@ func (evm *EVM) Create(caller ContractRef, code []byte, gas, value *big.Int) (re
 	evm.StateDB.SetNonce(caller.Address(), nonce+1)
 
 	snapshot := evm.StateDB.Snapshot()
-	contractAddr = crypto.CreateAddress(caller.Address(), nonce)
 	to := evm.StateDB.CreateAccount(contractAddr)
 	if evm.ChainConfig().IsEIP158(evm.BlockNumber) {
 		evm.StateDB.SetNonce(contractAddr, 1)
@@ -282,6 +373,17 @@ func (evm *EVM) Create(caller ContractRef, code []byte, gas, value *big.Int) (re
 	contract.SetCallCode(&contractAddr, crypto.Keccak256Hash(code), code)
 	defer contract.Finalise()
 
+	// Capture the tracer start/end events for the outermost creation
+	if evm.vmConfig.Tracer != nil && evm.depth == 0 {
+		evm.vmConfig.Tracer.CaptureStart(caller.Address(), contractAddr, true, code, gas, value)
+
+		// The contract shares the gas counter with the caller, so keep a copy
+		// of the initial allowance to calculate the gas used.
+		start, startGas := time.Now(), new(big.Int).Set(gas)
+		defer func() {
+			evm.vmConfig.Tracer.CaptureEnd(ret, startGas.Sub(startGas, contract.Gas), time.Since(start), err)
+		}()
+	}
 	ret, err = evm.interpreter.Run(contract, nil)
 
 	// check whether the max code size has been exceeded
@@ -305,9 +407,15 @@ func (evm *EVM) Create(caller ContractRef, code []byte, gas, value *big.Int) (re
 	// when we're in homestead this also counts for code storage gas errors.
 	if maxCodeSizeExceeded ||
 		(err != nil && (evm.ChainConfig().IsHomestead(evm.BlockNumber) || err != ErrCodeStoreOutOfGas)) {
-		contract.UseGas(contract.Gas)
 		evm.StateDB.RevertToSnapshot(snapshot)
 
+		// An explicit revert hands the remaining gas and the revert data
+		// back to the caller.
+		if err == ErrExecutionReverted {
+			return ret, contractAddr, err
+		}
+		contract.UseGas(contract.Gas)
+
 		// Nothing should be returned when an error is thrown.
 		return nil, contractAddr, err
 	}
@@ -24,4 +24,17 @@ var (
 	ErrDepth               = errors.New("max call depth exceeded")
 	ErrTraceLimitReached   = errors.New("the number of logs reached the specified limit")
 	ErrInsufficientBalance = errors.New("insufficient balance for transfer")
+
+	// ErrExecutionReverted is returned when the code explicitly reverted its
+	// state changes using REVERT. Unlike other errors the remaining gas is
+	// handed back to the caller along with the revert data.
+	ErrExecutionReverted = errors.New("execution reverted")
+
+	// ErrWriteProtection is returned when the code attempts to modify the state
+	// from within a STATICCALL.
+	ErrWriteProtection = errors.New("write protection")
+
+	// ErrReturnDataOutOfBounds is returned when RETURNDATACOPY reads past the
+	// end of the return data buffer.
+	ErrReturnDataOutOfBounds = errors.New("return data out of bounds")
 )
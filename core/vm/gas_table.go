@@ -42,30 +42,38 @@ func constGasFunc(gas *big.Int) gasFunc {
 func gasCalldataCopy(gt params.GasTable, env *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize *big.Int) *big.Int {
 	gas := memoryGasCost(mem, memorySize)
 	gas.Add(gas, GasFastestStep)
-	words := toWordSize(stack.Back(2))
+	words := toWordSize(stack.Back(2).ToBig())
 
-	return gas.Add(gas, words.Mul(words, params.CopyGas))
+	return gas.Add(gas, words.Mul(words, gt.Copy))
+}
+
+func gasReturnDataCopy(gt params.GasTable, env *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize *big.Int) *big.Int {
+	gas := memoryGasCost(mem, memorySize)
+	gas.Add(gas, GasFastestStep)
+	words := toWordSize(stack.Back(2).ToBig())
+
+	return gas.Add(gas, words.Mul(words, gt.Copy))
 }
 
 func gasSStore(gt params.GasTable, env *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize *big.Int) *big.Int {
 	var (
 		y, x = stack.Back(1), stack.Back(0)
-		val  = env.StateDB.GetState(contract.Address(), common.BigToHash(x))
+		val  = env.StateDB.GetState(contract.Address(), common.Hash(x.Bytes32()))
 	)
 	// This checks for 3 scenario's and calculates gas accordingly
 	// 1. From a zero-value address to a non-zero value         (NEW VALUE)
 	// 2. From a non-zero value address to a zero-value address (DELETE)
 	// 3. From a non-zero to a non-zero                         (CHANGE)
-	if common.EmptyHash(val) && !common.EmptyHash(common.BigToHash(y)) {
+	if common.EmptyHash(val) && !y.IsZero() {
 		// 0 => non 0
-		return new(big.Int).Set(params.SstoreSetGas)
-	} else if !common.EmptyHash(val) && common.EmptyHash(common.BigToHash(y)) {
-		env.StateDB.AddRefund(params.SstoreRefundGas)
+		return new(big.Int).Set(gt.SStoreSet)
+	} else if !common.EmptyHash(val) && y.IsZero() {
+		env.StateDB.AddRefund(gt.SStoreRefund)
 
-		return new(big.Int).Set(params.SstoreClearGas)
+		return new(big.Int).Set(gt.SStoreClear)
 	} else {
 		// non 0 => non 0 (or 0 => 0)
-		return new(big.Int).Set(params.SstoreResetGas)
+		return new(big.Int).Set(gt.SStoreReset)
 	}
 }
 
@@ -73,34 +81,34 @@ func makeGasLog(n uint) gasFunc {
 	return func(gt params.GasTable, env *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize *big.Int) *big.Int {
 		mSize := stack.Back(1)
 
-		gas := new(big.Int).Add(memoryGasCost(mem, memorySize), params.LogGas)
-		gas.Add(gas, new(big.Int).Mul(big.NewInt(int64(n)), params.LogTopicGas))
-		gas.Add(gas, new(big.Int).Mul(mSize, params.LogDataGas))
+		gas := new(big.Int).Add(memoryGasCost(mem, memorySize), gt.Log)
+		gas.Add(gas, new(big.Int).Mul(big.NewInt(int64(n)), gt.LogTopic))
+		gas.Add(gas, new(big.Int).Mul(mSize.ToBig(), gt.LogData))
 		return gas
 	}
 }
 
 func gasSha3(gt params.GasTable, env *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize *big.Int) *big.Int {
 	gas := memoryGasCost(mem, memorySize)
-	gas.Add(gas, params.Sha3Gas)
-	words := toWordSize(stack.Back(1))
-	return gas.Add(gas, words.Mul(words, params.Sha3WordGas))
+	gas.Add(gas, gt.Sha3)
+	words := toWordSize(stack.Back(1).ToBig())
+	return gas.Add(gas, words.Mul(words, gt.Sha3Word))
 }
 
 func gasCodeCopy(gt params.GasTable, env *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize *big.Int) *big.Int {
 	gas := memoryGasCost(mem, memorySize)
 	gas.Add(gas, GasFastestStep)
-	words := toWordSize(stack.Back(2))
+	words := toWordSize(stack.Back(2).ToBig())
 
-	return gas.Add(gas, words.Mul(words, params.CopyGas))
+	return gas.Add(gas, words.Mul(words, gt.Copy))
 }
 
 func gasExtCodeCopy(gt params.GasTable, env *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize *big.Int) *big.Int {
 	gas := memoryGasCost(mem, memorySize)
 	gas.Add(gas, gt.ExtcodeCopy)
-	words := toWordSize(stack.Back(3))
+	words := toWordSize(stack.Back(3).ToBig())
 
-	return gas.Add(gas, words.Mul(words, params.CopyGas))
+	return gas.Add(gas, words.Mul(words, gt.Copy))
 }
 
 func gasMLoad(gt params.GasTable, env *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize *big.Int) *big.Int {
@@ -116,7 +124,15 @@ func gasMStore(gt params.GasTable, env *EVM, contract *Contract, stack *Stack, m
 }
 
 func gasCreate(gt params.GasTable, env *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize *big.Int) *big.Int {
-	return new(big.Int).Add(params.CreateGas, memoryGasCost(mem, memorySize))
+	return new(big.Int).Add(gt.Create, memoryGasCost(mem, memorySize))
+}
+
+func gasCreate2(gt params.GasTable, env *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize *big.Int) *big.Int {
+	gas := memoryGasCost(mem, memorySize)
+	gas.Add(gas, gt.Create)
+	// the deployment code is hashed to derive the contract address
+	words := toWordSize(stack.Back(2).ToBig())
+	return gas.Add(gas, words.Mul(words, gt.Sha3Word))
 }
 
 func gasBalance(gt params.GasTable, env *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize *big.Int) *big.Int {
@@ -132,7 +148,7 @@ func gasSLoad(gt params.GasTable, env *EVM, contract *Contract, stack *Stack, me
 }
 
 func gasExp(gt params.GasTable, env *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize *big.Int) *big.Int {
-	expByteLen := int64((stack.data[stack.len()-2].BitLen() + 7) / 8)
+	expByteLen := int64(stack.Back(1).ByteLen())
 	gas := big.NewInt(expByteLen)
 	gas.Mul(gas, gt.ExpByte)
 	return gas.Add(gas, GasSlowStep)
@@ -143,29 +159,29 @@ func gasCall(gt params.GasTable, env *EVM, contract *Contract, stack *Stack, mem
 
 	transfersValue := stack.Back(2).BitLen() > 0
 	var (
-		address = common.BigToAddress(stack.Back(1))
+		address = common.Address(stack.Back(1).Bytes20())
 		eip158  = env.ChainConfig().IsEIP158(env.BlockNumber)
 	)
 	if eip158 {
 		if env.StateDB.Empty(address) && transfersValue {
-			gas.Add(gas, params.CallNewAccountGas)
+			gas.Add(gas, gt.CallNewAccount)
 		}
 	} else if !env.StateDB.Exist(address) {
-		gas.Add(gas, params.CallNewAccountGas)
+		gas.Add(gas, gt.CallNewAccount)
 	}
 	if transfersValue {
-		gas.Add(gas, params.CallValueTransferGas)
+		gas.Add(gas, gt.CallValueTransfer)
 	}
 	gas.Add(gas, memoryGasCost(mem, memorySize))
 
-	cg := callGas(gt, contract.Gas, gas, stack.data[stack.len()-1])
+	cg := callGas(gt, contract.Gas, gas, stack.Back(0).ToBig())
 	// Replace the stack item with the new gas calculation. This means that
 	// either the original item is left on the stack or the item is replaced by:
 	// (availableGas - gas) * 63 / 64
 	// We replace the stack item so that it's available when the opCall instruction is
 	// called. This information is otherwise lost due to the dependency on *current*
 	// available gas.
-	stack.data[stack.len()-1] = cg
+	stack.Back(0).SetFromBig(cg)
 
 	return gas.Add(gas, cg)
 }
@@ -173,18 +189,18 @@ func gasCall(gt params.GasTable, env *EVM, contract *Contract, stack *Stack, mem
 func gasCallCode(gt params.GasTable, env *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize *big.Int) *big.Int {
 	gas := new(big.Int).Set(gt.Calls)
 	if stack.Back(2).BitLen() > 0 {
-		gas.Add(gas, params.CallValueTransferGas)
+		gas.Add(gas, gt.CallValueTransfer)
 	}
 	gas.Add(gas, memoryGasCost(mem, memorySize))
 
-	cg := callGas(gt, contract.Gas, gas, stack.data[stack.len()-1])
+	cg := callGas(gt, contract.Gas, gas, stack.Back(0).ToBig())
 	// Replace the stack item with the new gas calculation. This means that
 	// either the original item is left on the stack or the item is replaced by:
 	// (availableGas - gas) * 63 / 64
 	// We replace the stack item so that it's available when the opCall instruction is
 	// called. This information is otherwise lost due to the dependency on *current*
 	// available gas.
-	stack.data[stack.len()-1] = cg
+	stack.Back(0).SetFromBig(cg)
 
 	return gas.Add(gas, cg)
 }
@@ -193,13 +209,17 @@ func gasReturn(gt params.GasTable, env *EVM, contract *Contract, stack *Stack, m
 	return memoryGasCost(mem, memorySize)
 }
 
+func gasRevert(gt params.GasTable, env *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize *big.Int) *big.Int {
+	return memoryGasCost(mem, memorySize)
+}
+
 func gasSuicide(gt params.GasTable, env *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize *big.Int) *big.Int {
 	gas := new(big.Int)
 	// EIP150 homestead gas reprice fork:
 	if env.ChainConfig().IsEIP150(env.BlockNumber) {
 		gas.Set(gt.Suicide)
 		var (
-			address = common.BigToAddress(stack.Back(0))
+			address = common.Address(stack.Back(0).Bytes20())
 			eip158  = env.ChainConfig().IsEIP158(env.BlockNumber)
 		)
 
@@ -213,8 +233,8 @@ func gasSuicide(gt params.GasTable, env *EVM, contract *Contract, stack *Stack,
 		}
 	}
 
-	if !env.StateDB.HasSuicided(contract.Address()) {
-		env.StateDB.AddRefund(params.SuicideRefundGas)
+	if gt.SuicideRefund.Sign() > 0 && !env.StateDB.HasSuicided(contract.Address()) {
+		env.StateDB.AddRefund(gt.SuicideRefund)
 	}
 	return gas
 }
@@ -222,13 +242,27 @@ func gasSuicide(gt params.GasTable, env *EVM, contract *Contract, stack *Stack,
 func gasDelegateCall(gt params.GasTable, env *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize *big.Int) *big.Int {
 	gas := new(big.Int).Add(gt.Calls, memoryGasCost(mem, memorySize))
 
-	cg := callGas(gt, contract.Gas, gas, stack.data[stack.len()-1])
+	cg := callGas(gt, contract.Gas, gas, stack.Back(0).ToBig())
 	// Replace the stack item with the new gas calculation. This means that
 	// either the original item is left on the stack or the item is replaced by:
 	// (availableGas - gas) * 63 / 64
 	// We replace the stack item so that it's available when the opCall instruction is
 	// called.
-	stack.data[stack.len()-1] = cg
+	stack.Back(0).SetFromBig(cg)
+
+	return gas.Add(gas, cg)
+}
+
+func gasStaticCall(gt params.GasTable, env *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize *big.Int) *big.Int {
+	gas := new(big.Int).Add(gt.Calls, memoryGasCost(mem, memorySize))
+
+	cg := callGas(gt, contract.Gas, gas, stack.Back(0).ToBig())
+	// Replace the stack item with the new gas calculation. This means that
+	// either the original item is left on the stack or the item is replaced by:
+	// (availableGas - gas) * 63 / 64
+	// We replace the stack item so that it's available when the opStaticCall
+	// instruction is called.
+	stack.Back(0).SetFromBig(cg)
 
 	return gas.Add(gas, cg)
 }
@@ -21,278 +21,189 @@ import (
 	"math/big"
 
 	"github.com/EarthDollar/go-earthdollar/common"
-	"github.com/EarthDollar/go-earthdollar/common/math"
+	"github.com/EarthDollar/go-earthdollar/common/uint256"
 	"github.com/EarthDollar/go-earthdollar/core/types"
 	"github.com/EarthDollar/go-earthdollar/crypto"
 	"github.com/EarthDollar/go-earthdollar/params"
 )
 
+// The arithmetic instructions pop their operands and overwrite the new top of
+// the stack with the result, so that no stack item is ever allocated.
+
 func opAdd(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	x, y := stack.pop(), stack.pop()
-	stack.push(U256(x.Add(x, y)))
+	x, y := stack.pop(), stack.peek()
+	y.Add(&x, y)
 	return nil, nil
 }
 
 func opSub(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	x, y := stack.pop(), stack.pop()
-	stack.push(U256(x.Sub(x, y)))
+	x, y := stack.pop(), stack.peek()
+	y.Sub(&x, y)
 	return nil, nil
 }
 
 func opMul(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	x, y := stack.pop(), stack.pop()
-	stack.push(U256(x.Mul(x, y)))
+	x, y := stack.pop(), stack.peek()
+	y.Mul(&x, y)
 	return nil, nil
 }
 
 func opDiv(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	x, y := stack.pop(), stack.pop()
-	if y.Cmp(common.Big0) != 0 {
-		stack.push(U256(x.Div(x, y)))
-	} else {
-		stack.push(new(big.Int))
-	}
+	x, y := stack.pop(), stack.peek()
+	y.Div(&x, y)
 	return nil, nil
 }
 
 func opSdiv(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	x, y := S256(stack.pop()), S256(stack.pop())
-	if y.Cmp(common.Big0) == 0 {
-		stack.push(new(big.Int))
-		return nil, nil
-	} else {
-		n := new(big.Int)
-		if new(big.Int).Mul(x, y).Cmp(common.Big0) < 0 {
-			n.SetInt64(-1)
-		} else {
-			n.SetInt64(1)
-		}
-
-		res := x.Div(x.Abs(x), y.Abs(y))
-		res.Mul(res, n)
-
-		stack.push(U256(res))
-	}
+	x, y := stack.pop(), stack.peek()
+	y.SDiv(&x, y)
 	return nil, nil
 }
 
 func opMod(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	x, y := stack.pop(), stack.pop()
-	if y.Cmp(common.Big0) == 0 {
-		stack.push(new(big.Int))
-	} else {
-		stack.push(U256(x.Mod(x, y)))
-	}
+	x, y := stack.pop(), stack.peek()
+	y.Mod(&x, y)
 	return nil, nil
 }
 
 func opSmod(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	x, y := S256(stack.pop()), S256(stack.pop())
-
-	if y.Cmp(common.Big0) == 0 {
-		stack.push(new(big.Int))
-	} else {
-		n := new(big.Int)
-		if x.Cmp(common.Big0) < 0 {
-			n.SetInt64(-1)
-		} else {
-			n.SetInt64(1)
-		}
-
-		res := x.Mod(x.Abs(x), y.Abs(y))
-		res.Mul(res, n)
-
-		stack.push(U256(res))
-	}
+	x, y := stack.pop(), stack.peek()
+	y.SMod(&x, y)
 	return nil, nil
 }
 
 func opExp(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	base, exponent := stack.pop(), stack.pop()
-	stack.push(math.Exp(base, exponent))
+	base, exponent := stack.pop(), stack.peek()
+	exponent.Exp(&base, exponent)
 	return nil, nil
 }
 
 func opSignExtend(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	back := stack.pop()
-	if back.Cmp(big.NewInt(31)) < 0 {
-		bit := uint(back.Uint64()*8 + 7)
-		num := stack.pop()
-		mask := back.Lsh(common.Big1, bit)
-		mask.Sub(mask, common.Big1)
-		if common.BitTest(num, int(bit)) {
-			num.Or(num, mask.Not(mask))
-		} else {
-			num.And(num, mask)
-		}
-
-		stack.push(U256(num))
-	}
+	back, num := stack.pop(), stack.peek()
+	num.SignExtend(&back, num)
 	return nil, nil
 }
 
 func opNot(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	x := stack.pop()
-	stack.push(U256(x.Not(x)))
+	x := stack.peek()
+	x.Not(x)
 	return nil, nil
 }
 
 func opLt(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	x, y := stack.pop(), stack.pop()
-	if x.Cmp(y) < 0 {
-		stack.push(big.NewInt(1))
-	} else {
-		stack.push(new(big.Int))
-	}
+	x, y := stack.pop(), stack.peek()
+	setBool(y, x.Lt(y))
 	return nil, nil
 }
 
 func opGt(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	x, y := stack.pop(), stack.pop()
-	if x.Cmp(y) > 0 {
-		stack.push(big.NewInt(1))
-	} else {
-		stack.push(new(big.Int))
-	}
+	x, y := stack.pop(), stack.peek()
+	setBool(y, x.Gt(y))
 	return nil, nil
 }
 
 func opSlt(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	x, y := S256(stack.pop()), S256(stack.pop())
-	if x.Cmp(S256(y)) < 0 {
-		stack.push(big.NewInt(1))
-	} else {
-		stack.push(new(big.Int))
-	}
+	x, y := stack.pop(), stack.peek()
+	setBool(y, x.Slt(y))
 	return nil, nil
 }
 
 func opSgt(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	x, y := S256(stack.pop()), S256(stack.pop())
-	if x.Cmp(y) > 0 {
-		stack.push(big.NewInt(1))
-	} else {
-		stack.push(new(big.Int))
-	}
+	x, y := stack.pop(), stack.peek()
+	setBool(y, x.Sgt(y))
 	return nil, nil
 }
 
 func opEq(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	x, y := stack.pop(), stack.pop()
-	if x.Cmp(y) == 0 {
-		stack.push(big.NewInt(1))
-	} else {
-		stack.push(new(big.Int))
-	}
+	x, y := stack.pop(), stack.peek()
+	setBool(y, x.Eq(y))
 	return nil, nil
 }
 
 func opIszero(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	x := stack.pop()
-	if x.Cmp(common.Big0) > 0 {
-		stack.push(new(big.Int))
-	} else {
-		stack.push(big.NewInt(1))
-	}
+	x := stack.peek()
+	setBool(x, x.IsZero())
 	return nil, nil
 }
 
 func opAnd(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	x, y := stack.pop(), stack.pop()
-	stack.push(x.And(x, y))
+	x, y := stack.pop(), stack.peek()
+	y.And(&x, y)
 	return nil, nil
 }
 func opOr(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	x, y := stack.pop(), stack.pop()
-	stack.push(x.Or(x, y))
+	x, y := stack.pop(), stack.peek()
+	y.Or(&x, y)
 	return nil, nil
 }
 func opXor(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	x, y := stack.pop(), stack.pop()
-	stack.push(x.Xor(x, y))
+	x, y := stack.pop(), stack.peek()
+	y.Xor(&x, y)
 	return nil, nil
 }
 func opByte(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	th, val := stack.pop(), stack.pop()
-	if th.Cmp(big.NewInt(32)) < 0 {
-		byte := big.NewInt(int64(common.LeftPadBytes(val.Bytes(), 32)[th.Int64()]))
-		stack.push(byte)
-	} else {
-		stack.push(new(big.Int))
-	}
+	th, val := stack.pop(), stack.peek()
+	val.Byte(&th, val)
 	return nil, nil
 }
 func opAddmod(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	x, y, z := stack.pop(), stack.pop(), stack.pop()
-	if z.Cmp(Zero) > 0 {
-		add := x.Add(x, y)
-		add.Mod(add, z)
-		stack.push(U256(add))
-	} else {
-		stack.push(new(big.Int))
-	}
+	x, y, z := stack.pop(), stack.pop(), stack.peek()
+	z.AddMod(&x, &y, z)
 	return nil, nil
 }
 func opMulmod(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	x, y, z := stack.pop(), stack.pop(), stack.pop()
-	if z.Cmp(Zero) > 0 {
-		mul := x.Mul(x, y)
-		mul.Mod(mul, z)
-		stack.push(U256(mul))
-	} else {
-		stack.push(new(big.Int))
-	}
+	x, y, z := stack.pop(), stack.pop(), stack.peek()
+	z.MulMod(&x, &y, z)
 	return nil, nil
 }
 
 func opSha3(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	offset, size := stack.pop(), stack.pop()
-	data := memory.Get(offset.Int64(), size.Int64())
+	offset, size := stack.pop(), stack.peek()
+	data := memory.Get(int64(offset.Uint64()), int64(size.Uint64()))
 	hash := crypto.Keccak256(data)
 
 	if env.vmConfig.EnablePreimageRecording {
 		env.StateDB.AddPreimage(common.BytesToHash(hash), data)
 	}
 
-	stack.push(common.BytesToBig(hash))
+	size.SetBytes(hash)
 	return nil, nil
 }
 
 func opAddress(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	stack.push(common.Bytes2Big(contract.Address().Bytes()))
+	stack.push(new(uint256.Int).SetBytes(contract.Address().Bytes()))
 	return nil, nil
 }
 
 func opBalance(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	addr := common.BigToAddress(stack.pop())
-	balance := env.StateDB.GetBalance(addr)
-
-	stack.push(new(big.Int).Set(balance))
+	slot := stack.peek()
+	slot.SetFromBig(env.StateDB.GetBalance(common.Address(slot.Bytes20())))
 	return nil, nil
 }
 
 func opOrigin(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	stack.push(env.Origin.Big())
+	stack.push(new(uint256.Int).SetBytes(env.Origin.Bytes()))
 	return nil, nil
 }
 
 func opCaller(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	stack.push(contract.Caller().Big())
+	stack.push(new(uint256.Int).SetBytes(contract.Caller().Bytes()))
 	return nil, nil
 }
 
 func opCallValue(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	stack.push(new(big.Int).Set(contract.value))
+	stack.push(new(uint256.Int).SetFromBig(contract.value))
 	return nil, nil
 }
 
 func opCalldataLoad(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	stack.push(common.Bytes2Big(getData(contract.Input, stack.pop(), common.Big32)))
+	offset := stack.peek()
+	offset.SetBytes(getDataAt(contract.Input, offset, 32))
 	return nil, nil
 }
 
 func opCalldataSize(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	stack.push(big.NewInt(int64(len(contract.Input))))
+	stack.push(new(uint256.Int).SetUint64(uint64(len(contract.Input))))
 	return nil, nil
 }
 
@@ -302,20 +213,52 @@ func opCalldataCopy(pc *uint64, env *EVM, contract *Contract, memory *Memory, st
 		cOff = stack.pop()
 		l    = stack.pop()
 	)
-	memory.Set(mOff.Uint64(), l.Uint64(), getData(contract.Input, cOff, l))
+	memory.Set(mOff.Uint64(), l.Uint64(), getDataAt(contract.Input, &cOff, l.Uint64()))
+	return nil, nil
+}
+
+func opReturnDataSize(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
+	// if not past the return data fork return an error. RETURNDATASIZE is not
+	// supported before EIP-211.
+	if !env.ChainConfig().IsEIP140(env.BlockNumber) {
+		return nil, fmt.Errorf("invalid opcode %x", RETURNDATASIZE)
+	}
+	stack.push(new(uint256.Int).SetUint64(uint64(len(env.interpreter.returnData))))
+	return nil, nil
+}
+
+func opReturnDataCopy(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
+	// if not past the return data fork return an error. RETURNDATACOPY is not
+	// supported before EIP-211.
+	if !env.ChainConfig().IsEIP140(env.BlockNumber) {
+		return nil, fmt.Errorf("invalid opcode %x", RETURNDATACOPY)
+	}
+	var (
+		mOff = stack.pop()
+		dOff = stack.pop()
+		l    = stack.pop()
+	)
+	// Reading past the end of the buffer is an error, as opposed to the zero
+	// padding done by the other copy operations.
+	if !dOff.IsUint64() || !l.IsUint64() {
+		return nil, ErrReturnDataOutOfBounds
+	}
+	start, end := dOff.Uint64(), dOff.Uint64()+l.Uint64()
+	if end < start || end > uint64(len(env.interpreter.returnData)) {
+		return nil, ErrReturnDataOutOfBounds
+	}
+	memory.Set(mOff.Uint64(), l.Uint64(), env.interpreter.returnData[start:end])
 	return nil, nil
 }
 
 func opExtCodeSize(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	addr := common.BigToAddress(stack.pop())
-	l := big.NewInt(int64(env.StateDB.GetCodeSize(addr)))
-	stack.push(l)
+	slot := stack.peek()
+	slot.SetUint64(uint64(env.StateDB.GetCodeSize(common.Address(slot.Bytes20()))))
 	return nil, nil
 }
 
 func opCodeSize(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	l := big.NewInt(int64(len(contract.Code)))
-	stack.push(l)
+	stack.push(new(uint256.Int).SetUint64(uint64(len(contract.Code))))
 	return nil, nil
 }
 
@@ -325,7 +268,7 @@ func opCodeCopy(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack
 		cOff = stack.pop()
 		l    = stack.pop()
 	)
-	codeCopy := getData(contract.Code, cOff, l)
+	codeCopy := getDataAt(contract.Code, &cOff, l.Uint64())
 
 	memory.Set(mOff.Uint64(), l.Uint64(), codeCopy)
 	return nil, nil
@@ -333,56 +276,57 @@ func opCodeCopy(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack
 
 func opExtCodeCopy(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
 	var (
-		addr = common.BigToAddress(stack.pop())
+		a    = stack.pop()
+		addr = common.Address(a.Bytes20())
 		mOff = stack.pop()
 		cOff = stack.pop()
 		l    = stack.pop()
 	)
-	codeCopy := getData(env.StateDB.GetCode(addr), cOff, l)
+	codeCopy := getDataAt(env.StateDB.GetCode(addr), &cOff, l.Uint64())
 
 	memory.Set(mOff.Uint64(), l.Uint64(), codeCopy)
 	return nil, nil
 }
 
 func opGasprice(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	stack.push(new(big.Int).Set(env.GasPrice))
+	stack.push(new(uint256.Int).SetFromBig(env.GasPrice))
 	return nil, nil
 }
 
 func opBlockhash(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	num := stack.pop()
+	num := stack.peek()
 
-	n := new(big.Int).Sub(env.BlockNumber, common.Big257)
-	if num.Cmp(n) > 0 && num.Cmp(env.BlockNumber) < 0 {
-		stack.push(env.GetHash(num.Uint64()).Big())
+	// Only the hashes of the 256 most recent blocks are available
+	if current := env.BlockNumber.Uint64(); num.IsUint64() && num.Uint64() < current && (current < 257 || num.Uint64() > current-257) {
+		num.SetBytes(env.GetHash(num.Uint64()).Bytes())
 	} else {
-		stack.push(new(big.Int))
+		num.Clear()
 	}
 	return nil, nil
 }
 
 func opCoinbase(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	stack.push(env.Coinbase.Big())
+	stack.push(new(uint256.Int).SetBytes(env.Coinbase.Bytes()))
 	return nil, nil
 }
 
 func opTimestamp(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	stack.push(U256(new(big.Int).Set(env.Time)))
+	stack.push(new(uint256.Int).SetFromBig(env.Time))
 	return nil, nil
 }
 
 func opNumber(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	stack.push(U256(new(big.Int).Set(env.BlockNumber)))
+	stack.push(new(uint256.Int).SetFromBig(env.BlockNumber))
 	return nil, nil
 }
 
 func opDifficulty(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	stack.push(U256(new(big.Int).Set(env.Difficulty)))
+	stack.push(new(uint256.Int).SetFromBig(env.Difficulty))
 	return nil, nil
 }
 
 func opGasLimit(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	stack.push(U256(new(big.Int).Set(env.GasLimit)))
+	stack.push(new(uint256.Int).SetFromBig(env.GasLimit))
 	return nil, nil
 }
 
@@ -392,54 +336,53 @@ func opPop(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stac
 }
 
 func opMload(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	offset := stack.pop()
-	val := common.BigD(memory.Get(offset.Int64(), 32))
-	stack.push(val)
+	offset := stack.peek()
+	offset.SetBytes(memory.GetPtr(int64(offset.Uint64()), 32))
 	return nil, nil
 }
 
 func opMstore(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
 	// pop value of the stack
 	mStart, val := stack.pop(), stack.pop()
-	memory.Set(mStart.Uint64(), 32, common.BigToBytes(val, 256))
+	word := val.Bytes32()
+	memory.Set(mStart.Uint64(), 32, word[:])
 	return nil, nil
 }
 
 func opMstore8(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	off, val := stack.pop().Int64(), stack.pop().Int64()
-	memory.store[off] = byte(val & 0xff)
+	off, val := stack.pop(), stack.pop()
+	memory.store[off.Uint64()] = byte(val.Uint64() & 0xff)
 	return nil, nil
 }
 
 func opSload(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	loc := common.BigToHash(stack.pop())
-	val := env.StateDB.GetState(contract.Address(), loc).Big()
-	stack.push(val)
+	loc := stack.peek()
+	val := env.StateDB.GetState(contract.Address(), common.Hash(loc.Bytes32()))
+	loc.SetBytes(val[:])
 	return nil, nil
 }
 
 func opSstore(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	loc := common.BigToHash(stack.pop())
-	val := stack.pop()
-	env.StateDB.SetState(contract.Address(), loc, common.BigToHash(val))
+	loc, val := stack.pop(), stack.pop()
+	env.StateDB.SetState(contract.Address(), common.Hash(loc.Bytes32()), common.Hash(val.Bytes32()))
 	return nil, nil
 }
 
 func opJump(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
 	pos := stack.pop()
-	if !contract.jumpdests.has(contract.CodeHash, contract.Code, pos) {
+	if !contract.jumpdests.has(contract.CodeHash, contract.Code, &pos) {
 		nop := contract.GetOp(pos.Uint64())
-		return nil, fmt.Errorf("invalid jump destination (%v) %v", nop, pos)
+		return nil, fmt.Errorf("invalid jump destination (%v) %v", nop, pos.String())
 	}
 	*pc = pos.Uint64()
 	return nil, nil
 }
 func opJumpi(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
 	pos, cond := stack.pop(), stack.pop()
-	if cond.Cmp(common.BigTrue) >= 0 {
-		if !contract.jumpdests.has(contract.CodeHash, contract.Code, pos) {
+	if !cond.IsZero() {
+		if !contract.jumpdests.has(contract.CodeHash, contract.Code, &pos) {
 			nop := contract.GetOp(pos.Uint64())
-			return nil, fmt.Errorf("invalid jump destination (%v) %v", nop, pos)
+			return nil, fmt.Errorf("invalid jump destination (%v) %v", nop, pos.String())
 		}
 		*pc = pos.Uint64()
 	} else {
@@ -452,17 +395,17 @@ func opJumpdest(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack
 }
 
 func opPc(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	stack.push(new(big.Int).SetUint64(*pc))
+	stack.push(new(uint256.Int).SetUint64(*pc))
 	return nil, nil
 }
 
 func opMsize(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	stack.push(big.NewInt(int64(memory.Len())))
+	stack.push(new(uint256.Int).SetUint64(uint64(memory.Len())))
 	return nil, nil
 }
 
 func opGas(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	stack.push(new(big.Int).Set(contract.Gas))
+	stack.push(new(uint256.Int).SetFromBig(contract.Gas))
 	return nil, nil
 }
 
@@ -470,7 +413,7 @@ func opCreate(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *S
 	var (
 		value        = stack.pop()
 		offset, size = stack.pop(), stack.pop()
-		input        = memory.Get(offset.Int64(), size.Int64())
+		input        = memory.Get(int64(offset.Uint64()), int64(size.Uint64()))
 		gas          = new(big.Int).Set(contract.Gas)
 	)
 	if env.ChainConfig().IsEIP150(env.BlockNumber) {
@@ -479,83 +422,123 @@ func opCreate(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *S
 	}
 
 	contract.UseGas(gas)
-	_, addr, suberr := env.Create(contract, input, gas, value)
+	res, addr, suberr := env.Create(contract, input, gas, value.ToBig())
 	// Push item on the stack based on the returned error. If the ruleset is
 	// homestead we must check for CodeStoreOutOfGasError (homestead only
 	// rule) and treat as an error, if the ruleset is frontier we must
 	// ignore this error and pretend the operation was successful.
 	if env.ChainConfig().IsHomestead(env.BlockNumber) && suberr == ErrCodeStoreOutOfGas {
-		stack.push(new(big.Int))
+		stack.push(new(uint256.Int))
+	} else if suberr != nil && suberr != ErrCodeStoreOutOfGas {
+		stack.push(new(uint256.Int))
+	} else {
+		stack.push(new(uint256.Int).SetBytes(addr.Bytes()))
+	}
+	// Only an explicit revert leaves data for RETURNDATACOPY
+	if suberr == ErrExecutionReverted {
+		return res, nil
+	}
+	return nil, nil
+}
+
+func opCreate2(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
+	// if not past the CREATE2 fork return an error. CREATE2 is not supported
+	// before EIP-1014.
+	if !env.ChainConfig().IsEIP1014(env.BlockNumber) {
+		return nil, fmt.Errorf("invalid opcode %x", CREATE2)
+	}
+	var (
+		value        = stack.pop()
+		offset, size = stack.pop(), stack.pop()
+		salt         = stack.pop()
+		input        = memory.Get(int64(offset.Uint64()), int64(size.Uint64()))
+		gas          = new(big.Int).Set(contract.Gas)
+	)
+	if env.ChainConfig().IsEIP150(env.BlockNumber) {
+		gas.Div(gas, n64)
+		gas = gas.Sub(contract.Gas, gas)
+	}
+
+	contract.UseGas(gas)
+	res, addr, suberr := env.Create2(contract, input, gas, value.ToBig(), salt.Bytes32())
+	// Push item on the stack based on the returned error, following the
+	// same rules as CREATE.
+	if env.ChainConfig().IsHomestead(env.BlockNumber) && suberr == ErrCodeStoreOutOfGas {
+		stack.push(new(uint256.Int))
 	} else if suberr != nil && suberr != ErrCodeStoreOutOfGas {
-		stack.push(new(big.Int))
+		stack.push(new(uint256.Int))
 	} else {
-		stack.push(addr.Big())
+		stack.push(new(uint256.Int).SetBytes(addr.Bytes()))
+	}
+	// Only an explicit revert leaves data for RETURNDATACOPY
+	if suberr == ErrExecutionReverted {
+		return res, nil
 	}
 	return nil, nil
 }
 
 func opCall(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	gas := stack.pop()
+	g := stack.pop()
 	// pop gas and value of the stack.
 	addr, value := stack.pop(), stack.pop()
-	value = U256(value)
 	// pop input size and offset
 	inOffset, inSize := stack.pop(), stack.pop()
 	// pop return size and offset
 	retOffset, retSize := stack.pop(), stack.pop()
 
-	address := common.BigToAddress(addr)
+	address := common.Address(addr.Bytes20())
 
 	// Get the arguments from the memory
-	args := memory.Get(inOffset.Int64(), inSize.Int64())
+	args := memory.Get(int64(inOffset.Uint64()), int64(inSize.Uint64()))
 
-	if len(value.Bytes()) > 0 {
+	gas := g.ToBig()
+	if !value.IsZero() {
 		gas.Add(gas, params.CallStipend)
 	}
 
-	ret, err := env.Call(contract, address, args, gas, value)
+	ret, err := env.Call(contract, address, args, gas, value.ToBig())
 
 	if err != nil {
-		stack.push(new(big.Int))
-
+		stack.push(new(uint256.Int))
 	} else {
-		stack.push(big.NewInt(1))
-
+		stack.push(uint256.NewInt(1))
+	}
+	if err == nil || err == ErrExecutionReverted {
 		memory.Set(retOffset.Uint64(), retSize.Uint64(), ret)
 	}
-	return nil, nil
+	return ret, nil
 }
 
 func opCallCode(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	gas := stack.pop()
+	g := stack.pop()
 	// pop gas and value of the stack.
 	addr, value := stack.pop(), stack.pop()
-	value = U256(value)
 	// pop input size and offset
 	inOffset, inSize := stack.pop(), stack.pop()
 	// pop return size and offset
 	retOffset, retSize := stack.pop(), stack.pop()
 
-	address := common.BigToAddress(addr)
+	address := common.Address(addr.Bytes20())
 
 	// Get the arguments from the memory
-	args := memory.Get(inOffset.Int64(), inSize.Int64())
+	args := memory.Get(int64(inOffset.Uint64()), int64(inSize.Uint64()))
 
-	if len(value.Bytes()) > 0 {
+	gas := g.ToBig()
+	if !value.IsZero() {
 		gas.Add(gas, params.CallStipend)
 	}
 
-	ret, err := env.CallCode(contract, address, args, gas, value)
+	ret, err := env.CallCode(contract, address, args, gas, value.ToBig())
 
 	if err != nil {
-		stack.push(new(big.Int))
-
+		stack.push(new(uint256.Int))
 	} else {
-		stack.push(big.NewInt(1))
-
+		stack.push(uint256.NewInt(1))
+	}
+	if err == nil || err == ErrExecutionReverted {
 		memory.Set(retOffset.Uint64(), retSize.Uint64(), ret)
 	}
-	return nil, nil
+	return ret, nil
 }
 
 func opDelegateCall(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
@@ -567,21 +550,58 @@ func opDelegateCall(pc *uint64, env *EVM, contract *Contract, memory *Memory, st
 
 	gas, to, inOffset, inSize, outOffset, outSize := stack.pop(), stack.pop(), stack.pop(), stack.pop(), stack.pop(), stack.pop()
 
-	toAddr := common.BigToAddress(to)
-	args := memory.Get(inOffset.Int64(), inSize.Int64())
-	ret, err := env.DelegateCall(contract, toAddr, args, gas)
+	toAddr := common.Address(to.Bytes20())
+	args := memory.Get(int64(inOffset.Uint64()), int64(inSize.Uint64()))
+	ret, err := env.DelegateCall(contract, toAddr, args, gas.ToBig())
 	if err != nil {
-		stack.push(new(big.Int))
+		stack.push(new(uint256.Int))
 	} else {
-		stack.push(big.NewInt(1))
+		stack.push(uint256.NewInt(1))
+	}
+	if err == nil || err == ErrExecutionReverted {
 		memory.Set(outOffset.Uint64(), outSize.Uint64(), ret)
 	}
-	return nil, nil
+	return ret, nil
+}
+
+func opStaticCall(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
+	// if not past the STATICCALL fork return an error. STATICCALL is not
+	// supported before EIP-214.
+	if !env.ChainConfig().IsEIP140(env.BlockNumber) {
+		return nil, fmt.Errorf("invalid opcode %x", STATICCALL)
+	}
+
+	gas, to, inOffset, inSize, outOffset, outSize := stack.pop(), stack.pop(), stack.pop(), stack.pop(), stack.pop(), stack.pop()
+
+	toAddr := common.Address(to.Bytes20())
+	args := memory.Get(int64(inOffset.Uint64()), int64(inSize.Uint64()))
+	ret, err := env.StaticCall(contract, toAddr, args, gas.ToBig())
+	if err != nil {
+		stack.push(new(uint256.Int))
+	} else {
+		stack.push(uint256.NewInt(1))
+	}
+	if err == nil || err == ErrExecutionReverted {
+		memory.Set(outOffset.Uint64(), outSize.Uint64(), ret)
+	}
+	return ret, nil
 }
 
 func opReturn(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
 	offset, size := stack.pop(), stack.pop()
-	ret := memory.GetPtr(offset.Int64(), size.Int64())
+	ret := memory.GetPtr(int64(offset.Uint64()), int64(size.Uint64()))
+
+	return ret, nil
+}
+
+func opRevert(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
+	// if not past the REVERT fork return an error. REVERT is not supported
+	// before EIP-140.
+	if !env.ChainConfig().IsEIP140(env.BlockNumber) {
+		return nil, fmt.Errorf("invalid opcode %x", REVERT)
+	}
+	offset, size := stack.pop(), stack.pop()
+	ret := memory.GetPtr(int64(offset.Uint64()), int64(size.Uint64()))
 
 	return ret, nil
 }
@@ -592,7 +612,8 @@ func opStop(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Sta
 
 func opSuicide(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
 	balance := env.StateDB.GetBalance(contract.Address())
-	env.StateDB.AddBalance(common.BigToAddress(stack.pop()), balance)
+	beneficiary := stack.pop()
+	env.StateDB.AddBalance(common.Address(beneficiary.Bytes20()), balance)
 
 	env.StateDB.Suicide(contract.Address())
 
@@ -601,16 +622,26 @@ func opSuicide(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *
 
 // following functions are used by the instruction jump  table
 
+// setBool sets the stack item to 1 if the condition holds or 0 otherwise.
+func setBool(item *uint256.Int, cond bool) {
+	if cond {
+		item.SetUint64(1)
+	} else {
+		item.Clear()
+	}
+}
+
 // make log instruction function
 func makeLog(size int) executionFunc {
 	return func(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
 		topics := make([]common.Hash, size)
 		mStart, mSize := stack.pop(), stack.pop()
 		for i := 0; i < size; i++ {
-			topics[i] = common.BigToHash(stack.pop())
+			topic := stack.pop()
+			topics[i] = common.Hash(topic.Bytes32())
 		}
 
-		d := memory.Get(mStart.Int64(), mSize.Int64())
+		d := memory.Get(int64(mStart.Uint64()), int64(mSize.Uint64()))
 		env.StateDB.AddLog(&types.Log{
 			Address: contract.Address(),
 			Topics:  topics,
@@ -624,10 +655,10 @@ func makeLog(size int) executionFunc {
 }
 
 // make push instruction function
-func makePush(size uint64, bsize *big.Int) executionFunc {
+func makePush(size uint64) executionFunc {
 	return func(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-		byts := getData(contract.Code, new(big.Int).SetUint64(*pc+1), bsize)
-		stack.push(common.Bytes2Big(byts))
+		var item uint256.Int
+		stack.push(item.SetBytes(getData(contract.Code, *pc+1, size)))
 		*pc += size
 		return nil, nil
 	}
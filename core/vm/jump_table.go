@@ -46,6 +46,12 @@ type operation struct {
 	jumps bool
 	// valid is used to check whether the retrieved operation is valid and known
 	valid bool
+	// writes determines whether this a state modifying operation
+	writes bool
+	// reverts determines whether the operation reverts state (implicitly halts)
+	reverts bool
+	// returns determines whether the operation sets the return data content
+	returns bool
 }
 
 var defaultJumpTable = NewJumpTable()
@@ -240,6 +246,19 @@ func NewJumpTable() [256]operation {
 			memorySize:    memoryCalldataCopy,
 			valid:         true,
 		},
+		RETURNDATASIZE: {
+			execute:       opReturnDataSize,
+			gasCost:       constGasFunc(GasQuickStep),
+			validateStack: makeStackFunc(0, 1),
+			valid:         true,
+		},
+		RETURNDATACOPY: {
+			execute:       opReturnDataCopy,
+			gasCost:       gasReturnDataCopy,
+			validateStack: makeStackFunc(3, 0),
+			memorySize:    memoryReturnDataCopy,
+			valid:         true,
+		},
 		CODESIZE: {
 			execute:       opCodeSize,
 			gasCost:       constGasFunc(GasQuickStep),
@@ -347,6 +366,7 @@ func NewJumpTable() [256]operation {
 			gasCost:       gasSStore,
 			validateStack: makeStackFunc(2, 0),
 			valid:         true,
+			writes:        true,
 		},
 		JUMPDEST: {
 			execute:       opJumpdest,
@@ -378,6 +398,8 @@ func NewJumpTable() [256]operation {
 			validateStack: makeStackFunc(3, 1),
 			memorySize:    memoryCreate,
 			valid:         true,
+			writes:        true,
+			returns:       true,
 		},
 		CALL: {
 			execute:       opCall,
@@ -385,6 +407,7 @@ func NewJumpTable() [256]operation {
 			validateStack: makeStackFunc(7, 1),
 			memorySize:    memoryCall,
 			valid:         true,
+			returns:       true,
 		},
 		CALLCODE: {
 			execute:       opCallCode,
@@ -392,6 +415,7 @@ func NewJumpTable() [256]operation {
 			validateStack: makeStackFunc(7, 1),
 			memorySize:    memoryCall,
 			valid:         true,
+			returns:       true,
 		},
 		DELEGATECALL: {
 			execute:       opDelegateCall,
@@ -399,6 +423,24 @@ func NewJumpTable() [256]operation {
 			validateStack: makeStackFunc(6, 1),
 			memorySize:    memoryDelegateCall,
 			valid:         true,
+			returns:       true,
+		},
+		CREATE2: {
+			execute:       opCreate2,
+			gasCost:       gasCreate2,
+			validateStack: makeStackFunc(4, 1),
+			memorySize:    memoryCreate,
+			valid:         true,
+			writes:        true,
+			returns:       true,
+		},
+		STATICCALL: {
+			execute:       opStaticCall,
+			gasCost:       gasStaticCall,
+			validateStack: makeStackFunc(6, 1),
+			memorySize:    memoryStaticCall,
+			valid:         true,
+			returns:       true,
 		},
 		RETURN: {
 			execute:       opReturn,
@@ -408,12 +450,22 @@ func NewJumpTable() [256]operation {
 			halts:         true,
 			valid:         true,
 		},
+		REVERT: {
+			execute:       opRevert,
+			gasCost:       gasRevert,
+			validateStack: makeStackFunc(2, 0),
+			memorySize:    memoryRevert,
+			valid:         true,
+			reverts:       true,
+			returns:       true,
+		},
 		SUICIDE: {
 			execute:       opSuicide,
 			gasCost:       gasSuicide,
 			validateStack: makeStackFunc(1, 0),
 			halts:         true,
 			valid:         true,
+			writes:        true,
 		},
 		JUMP: {
 			execute:       opJump,
@@ -442,6 +494,7 @@ func NewJumpTable() [256]operation {
 			validateStack: makeStackFunc(2, 0),
 			memorySize:    memoryLog,
 			valid:         true,
+			writes:        true,
 		},
 		LOG1: {
 			execute:       makeLog(1),
@@ -449,6 +502,7 @@ func NewJumpTable() [256]operation {
 			validateStack: makeStackFunc(3, 0),
 			memorySize:    memoryLog,
 			valid:         true,
+			writes:        true,
 		},
 		LOG2: {
 			execute:       makeLog(2),
@@ -456,6 +510,7 @@ func NewJumpTable() [256]operation {
 			validateStack: makeStackFunc(4, 0),
 			memorySize:    memoryLog,
 			valid:         true,
+			writes:        true,
 		},
 		LOG3: {
 			execute:       makeLog(3),
@@ -463,6 +518,7 @@ func NewJumpTable() [256]operation {
 			validateStack: makeStackFunc(5, 0),
 			memorySize:    memoryLog,
 			valid:         true,
+			writes:        true,
 		},
 		LOG4: {
 			execute:       makeLog(4),
@@ -470,6 +526,7 @@ func NewJumpTable() [256]operation {
 			validateStack: makeStackFunc(6, 0),
 			memorySize:    memoryLog,
 			valid:         true,
+			writes:        true,
 		},
 		SWAP1: {
 			execute:       makeSwap(1),
@@ -568,193 +625,193 @@ func NewJumpTable() [256]operation {
 			valid:         true,
 		},
 		PUSH1: {
-			execute:       makePush(1, big.NewInt(1)),
+			execute:       makePush(1),
 			gasCost:       gasPush,
 			validateStack: makeStackFunc(0, 1),
 			valid:         true,
 		},
 		PUSH2: {
-			execute:       makePush(2, big.NewInt(2)),
+			execute:       makePush(2),
 			gasCost:       gasPush,
 			validateStack: makeStackFunc(0, 1),
 			valid:         true,
 		},
 		PUSH3: {
-			execute:       makePush(3, big.NewInt(3)),
+			execute:       makePush(3),
 			gasCost:       gasPush,
 			validateStack: makeStackFunc(0, 1),
 			valid:         true,
 		},
 		PUSH4: {
-			execute:       makePush(4, big.NewInt(4)),
+			execute:       makePush(4),
 			gasCost:       gasPush,
 			validateStack: makeStackFunc(0, 1),
 			valid:         true,
 		},
 		PUSH5: {
-			execute:       makePush(5, big.NewInt(5)),
+			execute:       makePush(5),
 			gasCost:       gasPush,
 			validateStack: makeStackFunc(0, 1),
 			valid:         true,
 		},
 		PUSH6: {
-			execute:       makePush(6, big.NewInt(6)),
+			execute:       makePush(6),
 			gasCost:       gasPush,
 			validateStack: makeStackFunc(0, 1),
 			valid:         true,
 		},
 		PUSH7: {
-			execute:       makePush(7, big.NewInt(7)),
+			execute:       makePush(7),
 			gasCost:       gasPush,
 			validateStack: makeStackFunc(0, 1),
 			valid:         true,
 		},
 		PUSH8: {
-			execute:       makePush(8, big.NewInt(8)),
+			execute:       makePush(8),
 			gasCost:       gasPush,
 			validateStack: makeStackFunc(0, 1),
 			valid:         true,
 		},
 		PUSH9: {
-			execute:       makePush(9, big.NewInt(9)),
+			execute:       makePush(9),
 			gasCost:       gasPush,
 			validateStack: makeStackFunc(0, 1),
 			valid:         true,
 		},
 		PUSH10: {
-			execute:       makePush(10, big.NewInt(10)),
+			execute:       makePush(10),
 			gasCost:       gasPush,
 			validateStack: makeStackFunc(0, 1),
 			valid:         true,
 		},
 		PUSH11: {
-			execute:       makePush(11, big.NewInt(11)),
+			execute:       makePush(11),
 			gasCost:       gasPush,
 			validateStack: makeStackFunc(0, 1),
 			valid:         true,
 		},
 		PUSH12: {
-			execute:       makePush(12, big.NewInt(12)),
+			execute:       makePush(12),
 			gasCost:       gasPush,
 			validateStack: makeStackFunc(0, 1),
 			valid:         true,
 		},
 		PUSH13: {
-			execute:       makePush(13, big.NewInt(13)),
+			execute:       makePush(13),
 			gasCost:       gasPush,
 			validateStack: makeStackFunc(0, 1),
 			valid:         true,
 		},
 		PUSH14: {
-			execute:       makePush(14, big.NewInt(14)),
+			execute:       makePush(14),
 			gasCost:       gasPush,
 			validateStack: makeStackFunc(0, 1),
 			valid:         true,
 		},
 		PUSH15: {
-			execute:       makePush(15, big.NewInt(15)),
+			execute:       makePush(15),
 			gasCost:       gasPush,
 			validateStack: makeStackFunc(0, 1),
 			valid:         true,
 		},
 		PUSH16: {
-			execute:       makePush(16, big.NewInt(16)),
+			execute:       makePush(16),
 			gasCost:       gasPush,
 			validateStack: makeStackFunc(0, 1),
 			valid:         true,
 		},
 		PUSH17: {
-			execute:       makePush(17, big.NewInt(17)),
+			execute:       makePush(17),
 			gasCost:       gasPush,
 			validateStack: makeStackFunc(0, 1),
 			valid:         true,
 		},
 		PUSH18: {
-			execute:       makePush(18, big.NewInt(18)),
+			execute:       makePush(18),
 			gasCost:       gasPush,
 			validateStack: makeStackFunc(0, 1),
 			valid:         true,
 		},
 		PUSH19: {
-			execute:       makePush(19, big.NewInt(19)),
+			execute:       makePush(19),
 			gasCost:       gasPush,
 			validateStack: makeStackFunc(0, 1),
 			valid:         true,
 		},
 		PUSH20: {
-			execute:       makePush(20, big.NewInt(20)),
+			execute:       makePush(20),
 			gasCost:       gasPush,
 			validateStack: makeStackFunc(0, 1),
 			valid:         true,
 		},
 		PUSH21: {
-			execute:       makePush(21, big.NewInt(21)),
+			execute:       makePush(21),
 			gasCost:       gasPush,
 			validateStack: makeStackFunc(0, 1),
 			valid:         true,
 		},
 		PUSH22: {
-			execute:       makePush(22, big.NewInt(22)),
+			execute:       makePush(22),
 			gasCost:       gasPush,
 			validateStack: makeStackFunc(0, 1),
 			valid:         true,
 		},
 		PUSH23: {
-			execute:       makePush(23, big.NewInt(23)),
+			execute:       makePush(23),
 			gasCost:       gasPush,
 			validateStack: makeStackFunc(0, 1),
 			valid:         true,
 		},
 		PUSH24: {
-			execute:       makePush(24, big.NewInt(24)),
+			execute:       makePush(24),
 			gasCost:       gasPush,
 			validateStack: makeStackFunc(0, 1),
 			valid:         true,
 		},
 		PUSH25: {
-			execute:       makePush(25, big.NewInt(25)),
+			execute:       makePush(25),
 			gasCost:       gasPush,
 			validateStack: makeStackFunc(0, 1),
 			valid:         true,
 		},
 		PUSH26: {
-			execute:       makePush(26, big.NewInt(26)),
+			execute:       makePush(26),
 			gasCost:       gasPush,
 			validateStack: makeStackFunc(0, 1),
 			valid:         true,
 		},
 		PUSH27: {
-			execute:       makePush(27, big.NewInt(27)),
+			execute:       makePush(27),
 			gasCost:       gasPush,
 			validateStack: makeStackFunc(0, 1),
 			valid:         true,
 		},
 		PUSH28: {
-			execute:       makePush(28, big.NewInt(28)),
+			execute:       makePush(28),
 			gasCost:       gasPush,
 			validateStack: makeStackFunc(0, 1),
 			valid:         true,
 		},
 		PUSH29: {
-			execute:       makePush(29, big.NewInt(29)),
+			execute:       makePush(29),
 			gasCost:       gasPush,
 			validateStack: makeStackFunc(0, 1),
 			valid:         true,
 		},
 		PUSH30: {
-			execute:       makePush(30, big.NewInt(30)),
+			execute:       makePush(30),
 			gasCost:       gasPush,
 			validateStack: makeStackFunc(0, 1),
 			valid:         true,
 		},
 		PUSH31: {
-			execute:       makePush(31, big.NewInt(31)),
+			execute:       makePush(31),
 			gasCost:       gasPush,
 			validateStack: makeStackFunc(0, 1),
 			valid:         true,
 		},
 		PUSH32: {
-			execute:       makePush(32, big.NewInt(32)),
+			execute:       makePush(32),
 			gasCost:       gasPush,
 			validateStack: makeStackFunc(0, 1),
 			valid:         true,
@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"math/big"
 	"os"
+	"time"
 	"unicode"
 
 	"github.com/EarthDollar/go-earthdollar/common"
@@ -60,12 +61,19 @@ type StructLog struct {
 }
 
 // Tracer is used to collect execution traces from an EVM transaction
-// execution. CaptureState is called for each step of the VM with the
-// current VM state.
+// execution. CaptureStart and CaptureEnd are called once at the boundaries of
+// the outermost call or contract creation, CaptureState is called for each
+// step of the VM with the current VM state and CaptureFault is called when the
+// execution of a step (or of an entire call frame) fails.
+//
+// Tracing is enabled by setting Config.Tracer; a nil tracer disables all hooks.
 // Note that reference types are actual VM data structures; make copies
 // if you need to retain them beyond the current call.
 type Tracer interface {
+	CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas, value *big.Int) error
 	CaptureState(env *EVM, pc uint64, op OpCode, gas, cost *big.Int, memory *Memory, stack *Stack, contract *Contract, depth int, err error) error
+	CaptureFault(env *EVM, pc uint64, op OpCode, gas, cost *big.Int, memory *Memory, stack *Stack, contract *Contract, depth int, err error) error
+	CaptureEnd(output []byte, gasUsed *big.Int, t time.Duration, err error) error
 }
 
 // StructLogger is an EVM state logger and implements Tracer.
@@ -78,6 +86,9 @@ type StructLogger struct {
 
 	logs          []StructLog
 	changedValues map[common.Address]Storage
+
+	output []byte
+	err    error
 }
 
 // NewLogger returns a new logger
@@ -91,9 +102,15 @@ func NewStructLogger(cfg *LogConfig) *StructLogger {
 	return logger
 }
 
-// captureState logs a new structured log message and pushes it out to the environment
+// CaptureStart implements the Tracer interface. The struct logger only records
+// execution steps, so the start of the execution is ignored.
+func (l *StructLogger) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas, value *big.Int) error {
+	return nil
+}
+
+// CaptureState logs a new structured log message and pushes it out to the environment
 //
-// captureState also tracks SSTORE ops to track dirty values.
+// CaptureState also tracks SSTORE ops to track dirty values.
 func (l *StructLogger) CaptureState(env *EVM, pc uint64, op OpCode, gas, cost *big.Int, memory *Memory, stack *Stack, contract *Contract, depth int, err error) error {
 	// check if already accumulated the specified number of logs
 	if l.cfg.Limit != 0 && l.cfg.Limit <= len(l.logs) {
@@ -113,8 +130,8 @@ func (l *StructLogger) CaptureState(env *EVM, pc uint64, op OpCode, gas, cost *b
 	switch op {
 	case SSTORE:
 		var (
-			value   = common.BigToHash(stack.data[stack.len()-2])
-			address = common.BigToHash(stack.data[stack.len()-1])
+			value   = common.Hash(stack.Back(1).Bytes32())
+			address = common.Hash(stack.Back(0).Bytes32())
 		)
 		l.changedValues[contract.Address()][address] = value
 	}
@@ -131,7 +148,7 @@ func (l *StructLogger) CaptureState(env *EVM, pc uint64, op OpCode, gas, cost *b
 	if !l.cfg.DisableStack {
 		stck = make([]*big.Int, len(stack.Data()))
 		for i, item := range stack.Data() {
-			stck[i] = new(big.Int).Set(item)
+			stck[i] = item.ToBig()
 		}
 	}
 
@@ -161,11 +178,35 @@ func (l *StructLogger) CaptureState(env *EVM, pc uint64, op OpCode, gas, cost *b
 	return nil
 }
 
+// CaptureFault implements the Tracer interface, logging the failing step along
+// with the error that aborted it.
+func (l *StructLogger) CaptureFault(env *EVM, pc uint64, op OpCode, gas, cost *big.Int, memory *Memory, stack *Stack, contract *Contract, depth int, err error) error {
+	return l.CaptureState(env, pc, op, gas, cost, memory, stack, contract, depth, err)
+}
+
+// CaptureEnd implements the Tracer interface, recording the outcome of the
+// execution.
+func (l *StructLogger) CaptureEnd(output []byte, gasUsed *big.Int, t time.Duration, err error) error {
+	l.output = output
+	l.err = err
+	return nil
+}
+
 // StructLogs returns a list of captured log entries
 func (l *StructLogger) StructLogs() []StructLog {
 	return l.logs
 }
 
+// Output returns the data returned by the traced execution.
+func (l *StructLogger) Output() []byte {
+	return l.output
+}
+
+// Error returns the error the traced execution failed with, if any.
+func (l *StructLogger) Error() error {
+	return l.err
+}
+
 // StdErrFormat formats a slice of StructLogs to human readable format
 func StdErrFormat(logs []StructLog) {
 	fmt.Fprintf(os.Stderr, "VM STAT %d OPs\n", len(logs))
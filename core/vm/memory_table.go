@@ -4,6 +4,7 @@ import (
 	"math/big"
 
 	"github.com/EarthDollar/go-earthdollar/common"
+	"github.com/EarthDollar/go-earthdollar/common/uint256"
 )
 
 func memorySha3(stack *Stack) *big.Int {
@@ -14,6 +15,10 @@ func memoryCalldataCopy(stack *Stack) *big.Int {
 	return calcMemSize(stack.Back(0), stack.Back(2))
 }
 
+func memoryReturnDataCopy(stack *Stack) *big.Int {
+	return calcMemSize(stack.Back(0), stack.Back(2))
+}
+
 func memoryCodeCopy(stack *Stack) *big.Int {
 	return calcMemSize(stack.Back(0), stack.Back(2))
 }
@@ -23,15 +28,15 @@ func memoryExtCodeCopy(stack *Stack) *big.Int {
 }
 
 func memoryMLoad(stack *Stack) *big.Int {
-	return calcMemSize(stack.Back(0), big.NewInt(32))
+	return calcMemSize(stack.Back(0), uint256.NewInt(32))
 }
 
 func memoryMStore8(stack *Stack) *big.Int {
-	return calcMemSize(stack.Back(0), big.NewInt(1))
+	return calcMemSize(stack.Back(0), uint256.NewInt(1))
 }
 
 func memoryMStore(stack *Stack) *big.Int {
-	return calcMemSize(stack.Back(0), big.NewInt(32))
+	return calcMemSize(stack.Back(0), uint256.NewInt(32))
 }
 
 func memoryCreate(stack *Stack) *big.Int {
@@ -58,10 +63,21 @@ func memoryDelegateCall(stack *Stack) *big.Int {
 	return common.BigMax(x, y)
 }
 
+func memoryStaticCall(stack *Stack) *big.Int {
+	x := calcMemSize(stack.Back(4), stack.Back(5))
+	y := calcMemSize(stack.Back(2), stack.Back(3))
+
+	return common.BigMax(x, y)
+}
+
 func memoryReturn(stack *Stack) *big.Int {
 	return calcMemSize(stack.Back(0), stack.Back(1))
 }
 
+func memoryRevert(stack *Stack) *big.Int {
+	return calcMemSize(stack.Back(0), stack.Back(1))
+}
+
 func memoryLog(stack *Stack) *big.Int {
 	mSize, mStart := stack.Back(1), stack.Back(0)
 	return calcMemSize(mStart, mSize)
@@ -59,6 +59,11 @@ func setDefaults(cfg *Config) {
 			EIP150Block:    new(big.Int),
 			EIP155Block:    new(big.Int),
 			EIP158Block:    new(big.Int),
+			EIP140Block:    new(big.Int),
+			EIP1014Block:   new(big.Int),
+			EIP198Block:    new(big.Int),
+			EIP152Block:    new(big.Int),
+			EIP3529Block:   new(big.Int),
 		}
 	}
 
@@ -20,12 +20,15 @@ import (
 	"math/big"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/EarthDollar/go-earthdollar/accounts/abi"
 	"github.com/EarthDollar/go-earthdollar/common"
 	"github.com/EarthDollar/go-earthdollar/core/state"
 	"github.com/EarthDollar/go-earthdollar/core/vm"
+	"github.com/EarthDollar/go-earthdollar/crypto"
 	"github.com/EarthDollar/go-earthdollar/ethdb"
+	"github.com/EarthDollar/go-earthdollar/params"
 )
 
 func TestDefaults(t *testing.T) {
@@ -117,6 +120,171 @@ func TestCall(t *testing.T) {
 	}
 }
 
+func TestCreate2(t *testing.T) {
+	code := []byte{
+		byte(vm.PUSH1), 0x2a, // salt
+		byte(vm.PUSH1), 0, // size
+		byte(vm.PUSH1), 0, // offset
+		byte(vm.PUSH1), 0, // value
+		byte(vm.CREATE2),
+		byte(vm.PUSH1), 0,
+		byte(vm.MSTORE),
+		byte(vm.PUSH1), 32,
+		byte(vm.PUSH1), 0,
+		byte(vm.RETURN),
+	}
+	ret, _, err := Execute(code, nil, nil)
+	if err != nil {
+		t.Fatal("didn't expect error", err)
+	}
+	var salt [32]byte
+	salt[31] = 0x2a
+	want := crypto.CreateAddress2(common.StringToAddress("contract"), salt, crypto.Keccak256(nil))
+	if have := common.BytesToAddress(ret); have != want {
+		t.Errorf("created address mismatch: have %x, want %x", have, want)
+	}
+
+	// Before the fork block the opcode must be rejected
+	cfg := &Config{ChainConfig: &params.ChainConfig{ChainId: big.NewInt(1), HomesteadBlock: new(big.Int)}}
+	if _, _, err := Execute(code, nil, cfg); err == nil {
+		t.Error("expected CREATE2 to fail before the fork block")
+	}
+}
+
+// revertCode stores a value, then reverts with 42 as the revert data.
+var revertCode = []byte{
+	byte(vm.PUSH1), 1,
+	byte(vm.PUSH1), 0,
+	byte(vm.SSTORE),
+	byte(vm.PUSH1), 42,
+	byte(vm.PUSH1), 0,
+	byte(vm.MSTORE),
+	byte(vm.PUSH1), 32,
+	byte(vm.PUSH1), 0,
+	byte(vm.REVERT),
+}
+
+func TestRevert(t *testing.T) {
+	ret, state, err := Execute(revertCode, nil, nil)
+	if err != vm.ErrExecutionReverted {
+		t.Fatalf("error mismatch: have %v, want %v", err, vm.ErrExecutionReverted)
+	}
+	if num := common.BytesToBig(ret); num.Cmp(big.NewInt(42)) != 0 {
+		t.Errorf("revert data mismatch: have %v, want 42", num)
+	}
+	if val := state.GetState(common.StringToAddress("contract"), common.Hash{}); val != (common.Hash{}) {
+		t.Errorf("storage change not reverted: %x", val)
+	}
+
+	// Before the fork block the opcode must be rejected
+	cfg := &Config{ChainConfig: &params.ChainConfig{ChainId: big.NewInt(1), HomesteadBlock: new(big.Int)}}
+	if _, _, err := Execute(revertCode, nil, cfg); err == nil || err == vm.ErrExecutionReverted {
+		t.Errorf("expected REVERT to be invalid before the fork block, got %v", err)
+	}
+}
+
+func TestReturnData(t *testing.T) {
+	// callAndCopy calls a reverting contract and returns n bytes of its revert data
+	callAndCopy := func(n byte) []byte {
+		return []byte{
+			byte(vm.PUSH1), 0, // retSize
+			byte(vm.PUSH1), 0, // retOffset
+			byte(vm.PUSH1), 0, // inSize
+			byte(vm.PUSH1), 0, // inOffset
+			byte(vm.PUSH1), 0, // value
+			byte(vm.PUSH1), 0x0b, // address
+			byte(vm.GAS),
+			byte(vm.CALL),
+			byte(vm.POP),
+			byte(vm.PUSH1), n, // length
+			byte(vm.PUSH1), 0, // data offset
+			byte(vm.PUSH1), 0, // memory offset
+			byte(vm.RETURNDATACOPY),
+			byte(vm.RETURNDATASIZE),
+			byte(vm.PUSH1), 32,
+			byte(vm.MSTORE),
+			byte(vm.PUSH1), 64,
+			byte(vm.PUSH1), 0,
+			byte(vm.RETURN),
+		}
+	}
+	newConfig := func() *Config {
+		db, _ := ethdb.NewMemDatabase()
+		statedb, _ := state.New(common.Hash{}, db)
+		statedb.SetCode(common.HexToAddress("0x0b"), revertCode)
+		return &Config{State: statedb}
+	}
+	ret, _, err := Execute(callAndCopy(32), nil, newConfig())
+	if err != nil {
+		t.Fatal("didn't expect error", err)
+	}
+	if num := common.BytesToBig(ret[:32]); num.Cmp(big.NewInt(42)) != 0 {
+		t.Errorf("return data mismatch: have %v, want 42", num)
+	}
+	if size := common.BytesToBig(ret[32:]); size.Cmp(big.NewInt(32)) != 0 {
+		t.Errorf("return data size mismatch: have %v, want 32", size)
+	}
+	// Copying past the end of the return data must fail
+	if _, _, err := Execute(callAndCopy(33), nil, newConfig()); err != vm.ErrReturnDataOutOfBounds {
+		t.Errorf("error mismatch: have %v, want %v", err, vm.ErrReturnDataOutOfBounds)
+	}
+}
+
+func TestStaticCall(t *testing.T) {
+	// staticCall calls the given address with STATICCALL, returning the
+	// success flag and the first word of the output
+	staticCall := func(addr byte) []byte {
+		return []byte{
+			byte(vm.PUSH1), 32, // outSize
+			byte(vm.PUSH1), 32, // outOffset
+			byte(vm.PUSH1), 0, // inSize
+			byte(vm.PUSH1), 0, // inOffset
+			byte(vm.PUSH1), addr,
+			byte(vm.GAS),
+			byte(vm.STATICCALL),
+			byte(vm.PUSH1), 0,
+			byte(vm.MSTORE),
+			byte(vm.PUSH1), 64,
+			byte(vm.PUSH1), 0,
+			byte(vm.RETURN),
+		}
+	}
+	db, _ := ethdb.NewMemDatabase()
+	statedb, _ := state.New(common.Hash{}, db)
+	statedb.SetCode(common.HexToAddress("0x0b"), revertCode[:5]) // SSTORE only
+	statedb.SetCode(common.HexToAddress("0x0c"), []byte{
+		byte(vm.PUSH1), 42,
+		byte(vm.PUSH1), 0,
+		byte(vm.MSTORE),
+		byte(vm.PUSH1), 32,
+		byte(vm.PUSH1), 0,
+		byte(vm.RETURN),
+	})
+	tests := []struct {
+		addr    byte
+		success int64
+		output  int64
+	}{
+		{0x0b, 0, 0},  // writes are rejected
+		{0x0c, 1, 42}, // reads succeed
+	}
+	for i, tt := range tests {
+		ret, _, err := Execute(staticCall(tt.addr), nil, &Config{State: statedb})
+		if err != nil {
+			t.Fatalf("test %d: didn't expect error: %v", i, err)
+		}
+		if success := common.BytesToBig(ret[:32]); success.Int64() != tt.success {
+			t.Errorf("test %d: success mismatch: have %v, want %v", i, success, tt.success)
+		}
+		if output := common.BytesToBig(ret[32:]); output.Int64() != tt.output {
+			t.Errorf("test %d: output mismatch: have %v, want %v", i, output, tt.output)
+		}
+	}
+	if val := statedb.GetState(common.HexToAddress("0x0b"), common.Hash{}); val != (common.Hash{}) {
+		t.Errorf("storage modified within static call: %x", val)
+	}
+}
+
 func BenchmarkCall(b *testing.B) {
 	var definition = `[{"constant":true,"inputs":[],"name":"seller","outputs":[{"name":"","type":"address"}],"type":"function"},{"constant":false,"inputs":[],"name":"abort","outputs":[],"type":"function"},{"constant":true,"inputs":[],"name":"value","outputs":[{"name":"","type":"uint256"}],"type":"function"},{"constant":false,"inputs":[],"name":"refund","outputs":[],"type":"function"},{"constant":true,"inputs":[],"name":"buyer","outputs":[{"name":"","type":"address"}],"type":"function"},{"constant":false,"inputs":[],"name":"confirmReceived","outputs":[],"type":"function"},{"constant":true,"inputs":[],"name":"state","outputs":[{"name":"","type":"uint8"}],"type":"function"},{"constant":false,"inputs":[],"name":"confirmPurchase","outputs":[],"type":"function"},{"inputs":[],"type":"constructor"},{"anonymous":false,"inputs":[],"name":"Aborted","type":"event"},{"anonymous":false,"inputs":[],"name":"PurchaseConfirmed","type":"event"},{"anonymous":false,"inputs":[],"name":"ItemReceived","type":"event"},{"anonymous":false,"inputs":[],"name":"Refunded","type":"event"}]`
 
@@ -149,3 +317,55 @@ func BenchmarkCall(b *testing.B) {
 		}
 	}
 }
+
+// countingTracer records the number of invocations of each tracer hook.
+type countingTracer struct {
+	starts, states, faults, ends int
+
+	gasUsed *big.Int
+	err     error
+}
+
+func (t *countingTracer) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas, value *big.Int) error {
+	t.starts++
+	return nil
+}
+
+func (t *countingTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost *big.Int, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	t.states++
+	return nil
+}
+
+func (t *countingTracer) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost *big.Int, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	t.faults++
+	return nil
+}
+
+func (t *countingTracer) CaptureEnd(output []byte, gasUsed *big.Int, d time.Duration, err error) error {
+	t.ends++
+	t.gasUsed, t.err = gasUsed, err
+	return nil
+}
+
+func TestTracerHooks(t *testing.T) {
+	tracer := new(countingTracer)
+	cfg := &Config{GasLimit: big.NewInt(100000), EVMConfig: vm.Config{Tracer: tracer}}
+	if _, _, err := Execute(revertCode, nil, cfg); err != vm.ErrExecutionReverted {
+		t.Fatalf("error mismatch: have %v, want %v", err, vm.ErrExecutionReverted)
+	}
+	if tracer.starts != 1 || tracer.ends != 1 {
+		t.Errorf("call boundary hooks mismatch: have %d starts and %d ends, want 1 and 1", tracer.starts, tracer.ends)
+	}
+	if tracer.states != 9 {
+		t.Errorf("step count mismatch: have %d, want 9", tracer.states)
+	}
+	if tracer.faults != 1 {
+		t.Errorf("fault count mismatch: have %d, want 1", tracer.faults)
+	}
+	if tracer.err != vm.ErrExecutionReverted {
+		t.Errorf("end error mismatch: have %v, want %v", tracer.err, vm.ErrExecutionReverted)
+	}
+	if tracer.gasUsed.Sign() <= 0 || tracer.gasUsed.Cmp(cfg.GasLimit) >= 0 {
+		t.Errorf("gas used out of range: %v", tracer.gasUsed)
+	}
+}
@@ -18,35 +18,31 @@ package vm
 
 import (
 	"fmt"
-	"math/big"
+
+	"github.com/EarthDollar/go-earthdollar/common/uint256"
 )
 
-// stack is an object for basic stack operations. Items popped to the stack are
-// expected to be changed and modified. stack does not take care of adding newly
-// initialised objects.
+// stack is an object for basic stack operations. Items are stored by value as
+// fixed-width 256-bit words, so operations can compute their results in place
+// without allocating.
 type Stack struct {
-	data []*big.Int
+	data []uint256.Int
 }
 
 func newstack() *Stack {
-	return &Stack{}
+	return &Stack{data: make([]uint256.Int, 0, 16)}
 }
 
-func (st *Stack) Data() []*big.Int {
+func (st *Stack) Data() []uint256.Int {
 	return st.data
 }
 
-func (st *Stack) push(d *big.Int) {
+func (st *Stack) push(d *uint256.Int) {
 	// NOTE push limit (1024) is checked in baseCheck
-	//stackItem := new(big.Int).Set(d)
-	//st.data = append(st.data, stackItem)
-	st.data = append(st.data, d)
-}
-func (st *Stack) pushN(ds ...*big.Int) {
-	st.data = append(st.data, ds...)
+	st.data = append(st.data, *d)
 }
 
-func (st *Stack) pop() (ret *big.Int) {
+func (st *Stack) pop() (ret uint256.Int) {
 	ret = st.data[len(st.data)-1]
 	st.data = st.data[:len(st.data)-1]
 	return
@@ -61,16 +57,17 @@ func (st *Stack) swap(n int) {
 }
 
 func (st *Stack) dup(n int) {
-	st.push(new(big.Int).Set(st.data[st.len()-n]))
+	st.data = append(st.data, st.data[st.len()-n])
 }
 
-func (st *Stack) peek() *big.Int {
-	return st.data[st.len()-1]
+// peek returns the top item of the stack, which may be modified in place.
+func (st *Stack) peek() *uint256.Int {
+	return &st.data[st.len()-1]
 }
 
 // Back returns the n'th item in stack
-func (st *Stack) Back(n int) *big.Int {
-	return st.data[st.len()-n-1]
+func (st *Stack) Back(n int) *uint256.Int {
+	return &st.data[st.len()-n-1]
 }
 
 func (st *Stack) require(n int) error {
@@ -84,7 +81,7 @@ func (st *Stack) Print() {
 	fmt.Println("### stack ###")
 	if len(st.data) > 0 {
 		for i, val := range st.data {
-			fmt.Printf("%-3d  %v\n", i, val)
+			fmt.Printf("%-3d  %v\n", i, val.String())
 		}
 	} else {
 		fmt.Println("-- empty --")
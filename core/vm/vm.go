@@ -31,13 +31,12 @@ import (
 
 // Config are the configuration options for the Interpreter
 type Config struct {
-	// Debug enabled debugging Interpreter options
-	Debug bool
 	// EnableJit enabled the JIT VM
 	EnableJit bool
 	// ForceJit forces the JIT VM
 	ForceJit bool
-	// Tracer is the op code logger
+	// Tracer is invoked at the boundaries of the outermost call and for
+	// each executed op code. Tracing is disabled if nil.
 	Tracer Tracer
 	// NoRecursion disabled Interpreter call, callcode,
 	// delegate call and create.
@@ -46,6 +45,8 @@ type Config struct {
 	DisableGasMetering bool
 	// Enable recording of SHA3/keccak preimages
 	EnablePreimageRecording bool
+	// Experimental speculative parallel execution of block transactions
+	ParallelExecution bool
 	// JumpTable contains the EVM instruction table. This
 	// may me left uninitialised and will be set the default
 	// table.
@@ -60,6 +61,9 @@ type Interpreter struct {
 	env      *EVM
 	cfg      Config
 	gasTable params.GasTable
+	readOnly bool // Whether to throw on stateful modifications
+
+	returnData []byte // Last CALL's return data for subsequent reuse
 }
 
 // NewInterpreter returns a new instance of the Interpreter.
@@ -83,8 +87,12 @@ func (evm *Interpreter) Run(contract *Contract, input []byte) (ret []byte, err e
 	evm.env.depth++
 	defer func() { evm.env.depth-- }()
 
+	// Reset the previous call's return data. It's unimportant to preserve the old buffer
+	// as every returning call will return new data anyway.
+	evm.returnData = nil
+
 	if contract.CodeAddr != nil {
-		if p := PrecompiledContracts[*contract.CodeAddr]; p != nil {
+		if p := evm.env.precompiles()[*contract.CodeAddr]; p != nil {
 			return RunPrecompiledContract(p, input, contract)
 		}
 	}
@@ -112,8 +120,8 @@ func (evm *Interpreter) Run(contract *Contract, input []byte) (ret []byte, err e
 
 	// User defer pattern to check for an error and, based on the error being nil or not, use all gas and return.
 	defer func() {
-		if err != nil && evm.cfg.Debug {
-			evm.cfg.Tracer.CaptureState(evm.env, pc, op, contract.Gas, cost, mem, stack, contract, evm.env.depth, err)
+		if err != nil && evm.cfg.Tracer != nil {
+			evm.cfg.Tracer.CaptureFault(evm.env, pc, op, contract.Gas, cost, mem, stack, contract, evm.env.depth, err)
 		}
 	}()
 
@@ -146,6 +154,13 @@ func (evm *Interpreter) Run(contract *Contract, input []byte) (ret []byte, err e
 		if err := operation.validateStack(stack); err != nil {
 			return nil, err
 		}
+		// If the operation is valid, enforce the write restrictions of a
+		// static call. Transferring value with CALL counts as a write.
+		if evm.readOnly {
+			if operation.writes || (op == CALL && !stack.Back(2).IsZero()) {
+				return nil, ErrWriteProtection
+			}
+		}
 
 		var memorySize *big.Int
 		// calculate the new memory size and expand the memory to fit
@@ -169,15 +184,22 @@ func (evm *Interpreter) Run(contract *Contract, input []byte) (ret []byte, err e
 			mem.Resize(memorySize.Uint64())
 		}
 
-		if evm.cfg.Debug {
+		if evm.cfg.Tracer != nil {
 			evm.cfg.Tracer.CaptureState(evm.env, pc, op, contract.Gas, cost, mem, stack, contract, evm.env.depth, err)
 		}
 
 		// execute the operation
 		res, err := operation.execute(&pc, evm.env, contract, mem, stack)
+		// if the operation clears the return data (e.g. it has returning data)
+		// set the last return to the result of the operation.
+		if operation.returns {
+			evm.returnData = res
+		}
 		switch {
 		case err != nil:
 			return nil, err
+		case operation.reverts:
+			return res, ErrExecutionReverted
 		case operation.halts:
 			return res, nil
 		case !operation.jumps:
@@ -0,0 +1,123 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package crypto
+
+import (
+	"bytes"
+	"errors"
+	"runtime"
+	"sync"
+
+	"github.com/EarthDollar/go-earthdollar/common"
+	"github.com/hashicorp/golang-lru"
+)
+
+// ErrSigMismatch is returned by a BatchRecoverer if a signature recovers to a
+// different public key than the one it was expected to belong to.
+var ErrSigMismatch = errors.New("signature does not match public key")
+
+// SigTask is a single signature to be recovered (and optionally verified) by a
+// BatchRecoverer.
+type SigTask struct {
+	Key    common.Hash // Cache key uniquely identifying the signature (zero = don't cache)
+	Hash   []byte      // Message hash the signature was created over
+	Sig    []byte      // Signature in [R || S || V] format
+	Expect []byte      // Public key the signature must belong to (nil = recover only)
+
+	Pubkey []byte // Public key recovered from the signature
+	Err    error  // Failure during recovery or verification
+}
+
+// BatchRecoverer recovers the public keys of many signatures concurrently,
+// caching the results keyed by a caller chosen identifier (e.g. a transaction
+// hash), so that the same signature is never recovered twice even if its
+// carrier is decoded multiple times.
+type BatchRecoverer struct {
+	workers int        // Maximum number of concurrent recoveries
+	cache   *lru.Cache // Recovered public keys by task key
+}
+
+// NewBatchRecoverer creates a signature recoverer running at most the given
+// number of concurrent workers (0 = number of CPUs), remembering the results
+// of the last cacheSize signatures.
+func NewBatchRecoverer(workers, cacheSize int) *BatchRecoverer {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	cache, _ := lru.New(cacheSize)
+	return &BatchRecoverer{
+		workers: workers,
+		cache:   cache,
+	}
+}
+
+// Recover fills in the public key or the error of all the tasks, recovering
+// the signatures not found in the cache concurrently. Tasks sharing the same
+// key are only recovered once.
+func (r *BatchRecoverer) Recover(tasks []*SigTask) {
+	// Serve whatever we can from the cache and deduplicate the rest
+	var (
+		pending []*SigTask
+		dups    = make(map[common.Hash][]*SigTask)
+	)
+	for _, task := range tasks {
+		if task.Key == (common.Hash{}) {
+			pending = append(pending, task)
+			continue
+		}
+		if pub, ok := r.cache.Get(task.Key); ok {
+			task.Pubkey, task.Err = pub.([]byte), nil
+			continue
+		}
+		if _, ok := dups[task.Key]; !ok {
+			pending = append(pending, task)
+		}
+		dups[task.Key] = append(dups[task.Key], task)
+	}
+	// Recover the remaining signatures across the worker pool
+	threads := r.workers
+	if threads > len(pending) {
+		threads = len(pending)
+	}
+	var pend sync.WaitGroup
+	pend.Add(threads)
+	for i := 0; i < threads; i++ {
+		go func(id int) {
+			defer pend.Done()
+			for j := id; j < len(pending); j += threads {
+				task := pending[j]
+				task.Pubkey, task.Err = Ecrecover(task.Hash, task.Sig)
+				if task.Err == nil && task.Key != (common.Hash{}) {
+					r.cache.Add(task.Key, task.Pubkey)
+				}
+			}
+		}(i)
+	}
+	pend.Wait()
+
+	// Propagate the results to the duplicates and verify the expected keys
+	for _, group := range dups {
+		for _, task := range group[1:] {
+			task.Pubkey, task.Err = group[0].Pubkey, group[0].Err
+		}
+	}
+	for _, task := range tasks {
+		if task.Err == nil && task.Expect != nil && !bytes.Equal(task.Pubkey, task.Expect) {
+			task.Err = ErrSigMismatch
+		}
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package crypto
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/EarthDollar/go-earthdollar/common"
+)
+
+// Tests that batch recovery yields the signing keys, serves repeated keys from
+// the cache and reports mismatching and invalid signatures.
+func TestBatchRecover(t *testing.T) {
+	recoverer := NewBatchRecoverer(4, 16)
+
+	var (
+		tasks []*SigTask
+		pubs  [][]byte
+	)
+	for i := 0; i < 8; i++ {
+		key, _ := GenerateKey()
+		hash := Keccak256([]byte{byte(i)})
+		sig, err := Sign(hash, key)
+		if err != nil {
+			t.Fatalf("failed to sign message %d: %v", i, err)
+		}
+		pub := FromECDSAPub(&key.PublicKey)
+		tasks = append(tasks, &SigTask{Key: common.BytesToHash(hash), Hash: hash, Sig: sig, Expect: pub})
+		pubs = append(pubs, pub)
+	}
+	// Duplicate a task, flip the expectation of another and add an invalid one
+	dup := *tasks[0]
+	tasks = append(tasks, &dup)
+	tasks[1].Expect = pubs[2]
+	tasks = append(tasks, &SigTask{Hash: tasks[2].Hash, Sig: make([]byte, 65)})
+
+	recoverer.Recover(tasks)
+	for i, task := range tasks[:8] {
+		if !bytes.Equal(task.Pubkey, pubs[i]) {
+			t.Errorf("task %d: pubkey mismatch: have %x, want %x", i, task.Pubkey, pubs[i])
+		}
+		if want := error(nil); i == 1 {
+			if task.Err != ErrSigMismatch {
+				t.Errorf("task %d: error mismatch: have %v, want %v", i, task.Err, ErrSigMismatch)
+			}
+		} else if task.Err != want {
+			t.Errorf("task %d: unexpected error: %v", i, task.Err)
+		}
+	}
+	if !bytes.Equal(tasks[8].Pubkey, pubs[0]) || tasks[8].Err != nil {
+		t.Errorf("duplicate task: have %x (%v), want %x", tasks[8].Pubkey, tasks[8].Err, pubs[0])
+	}
+	if tasks[9].Err == nil {
+		t.Errorf("invalid signature recovered to %x", tasks[9].Pubkey)
+	}
+	if n := recoverer.cache.Len(); n != 8 {
+		t.Errorf("cached signature count mismatch: have %d, want %d", n, 8)
+	}
+	// Corrupt a signature and ensure the cached result is used for its key
+	cached := &SigTask{Key: tasks[3].Key, Hash: tasks[3].Hash, Sig: make([]byte, 65)}
+	recoverer.Recover([]*SigTask{cached})
+	if !bytes.Equal(cached.Pubkey, pubs[3]) || cached.Err != nil {
+		t.Errorf("cached task: have %x (%v), want %x", cached.Pubkey, cached.Err, pubs[3])
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package blake2b
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"testing"
+)
+
+// sum512 hashes data with unkeyed BLAKE2b-512 on top of F.
+func sum512(data []byte) []byte {
+	h := IV
+	h[0] ^= 0x01010040 // digest length 64, no key, fanout and depth 1
+
+	var t [2]uint64
+	for {
+		var (
+			block [128]byte
+			m     [16]uint64
+		)
+		n := copy(block[:], data)
+		data = data[n:]
+		t[0] += uint64(n)
+
+		for i := range m {
+			m[i] = binary.LittleEndian.Uint64(block[i*8:])
+		}
+		final := len(data) == 0
+		F(&h, m, t, final, 12)
+		if final {
+			break
+		}
+	}
+	out := make([]byte, 64)
+	for i, w := range h {
+		binary.LittleEndian.PutUint64(out[i*8:], w)
+	}
+	return out
+}
+
+func TestSum512(t *testing.T) {
+	long := make([]byte, 3*256)
+	for i := range long {
+		long[i] = byte(i)
+	}
+	tests := []struct {
+		data []byte
+		want string
+	}{
+		{nil, "786a02f742015903c6c6fd852552d272912f4740e15847618a86e217f71f5419d25e1031afee585313896444934eb04b903a685b1448b755d56f701afe9be2ce"},
+		{[]byte("abc"), "ba80a53f981c4d0d6a2797b69f12f6e94c212f14685ac4b74b12bb6fdbffa2d17d87c5392aab792dc252d5de4533cc9518d38aa8dbf1925ab92386edd4009923"},
+		{long, "323e97a7a859ee63c9013debb0ca995811e73117a2f574723416e596ebc184e37a59b66d2f597df4a7c1b0d1d41a1a7f28774f46a6864d56c57b9d6c5f7302fb"},
+	}
+	for i, tt := range tests {
+		if have := hex.EncodeToString(sum512(tt.data)); have != tt.want {
+			t.Errorf("test %d: digest mismatch: have %s, want %s", i, have, tt.want)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package bn256
+
+import "math/big"
+
+// G1 is an abstract cyclic group. The zero value is suitable for use as the
+// output of an operation, but cannot be used as an input.
+type G1 struct {
+	p g1
+}
+
+func (e *G1) String() string {
+	return e.p.String()
+}
+
+// ScalarBaseMult sets e to g*k where g is the generator of the group and then
+// returns e.
+func (e *G1) ScalarBaseMult(k *big.Int) *G1 {
+	e.p.ScalarBaseMult(k)
+	return e
+}
+
+// ScalarMult sets e to a*k and then returns e.
+func (e *G1) ScalarMult(a *G1, k *big.Int) *G1 {
+	e.p.ScalarMult(&a.p.G1, k)
+	return e
+}
+
+// Add sets e to a+b and then returns e.
+func (e *G1) Add(a, b *G1) *G1 {
+	e.p.Add(&a.p.G1, &b.p.G1)
+	return e
+}
+
+// Neg sets e to -a and then returns e.
+func (e *G1) Neg(a *G1) *G1 {
+	e.p.Neg(&a.p.G1)
+	return e
+}
+
+// Marshal converts e to a byte slice.
+func (e *G1) Marshal() []byte {
+	return e.p.Marshal()
+}
+
+// Unmarshal sets e to the result of converting the output of Marshal back into
+// a group element and then returns the unconsumed part of m.
+func (e *G1) Unmarshal(m []byte) ([]byte, error) {
+	return e.p.Unmarshal(m)
+}
+
+// G2 is an abstract cyclic group. The zero value is suitable for use as the
+// output of an operation, but cannot be used as an input.
+type G2 struct {
+	p g2
+}
+
+func (e *G2) String() string {
+	return e.p.String()
+}
+
+// ScalarBaseMult sets e to g*k where g is the generator of the group and then
+// returns e.
+func (e *G2) ScalarBaseMult(k *big.Int) *G2 {
+	e.p.ScalarBaseMult(k)
+	return e
+}
+
+// ScalarMult sets e to a*k and then returns e.
+func (e *G2) ScalarMult(a *G2, k *big.Int) *G2 {
+	e.p.ScalarMult(&a.p.G2, k)
+	return e
+}
+
+// Add sets e to a+b and then returns e.
+func (e *G2) Add(a, b *G2) *G2 {
+	e.p.Add(&a.p.G2, &b.p.G2)
+	return e
+}
+
+// Neg sets e to -a and then returns e.
+func (e *G2) Neg(a *G2) *G2 {
+	e.p.Neg(&a.p.G2)
+	return e
+}
+
+// Marshal converts e to a byte slice.
+func (e *G2) Marshal() []byte {
+	return e.p.Marshal()
+}
+
+// Unmarshal sets e to the result of converting the output of Marshal back into
+// a group element and then returns the unconsumed part of m.
+func (e *G2) Unmarshal(m []byte) ([]byte, error) {
+	return e.p.Unmarshal(m)
+}
+
+// GT is an abstract cyclic group. The zero value is suitable for use as the
+// output of an operation, but cannot be used as an input.
+type GT struct {
+	p gt
+}
+
+// ScalarMult sets e to a*k and then returns e.
+func (e *GT) ScalarMult(a *GT, k *big.Int) *GT {
+	e.p.ScalarMult(&a.p.GT, k)
+	return e
+}
+
+// Add sets e to a+b and then returns e.
+func (e *GT) Add(a, b *GT) *GT {
+	e.p.Add(&a.p.GT, &b.p.GT)
+	return e
+}
+
+// Marshal converts e to a byte slice.
+func (e *GT) Marshal() []byte {
+	return e.p.Marshal()
+}
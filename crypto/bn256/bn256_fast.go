@@ -0,0 +1,55 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// +build amd64 arm64
+
+package bn256
+
+import "github.com/EarthDollar/go-earthdollar/crypto/bn256/optimized"
+
+// g1, g2 and gt are the group implementations of the optimized backend.
+type (
+	g1 struct{ optimized.G1 }
+	g2 struct{ optimized.G2 }
+	gt struct{ optimized.GT }
+)
+
+var (
+	// P is the prime over which the base field is formed.
+	P = optimized.P
+
+	// Order is the number of elements in both G1 and G2.
+	Order = optimized.Order
+)
+
+// Pair calculates the optimal ate pairing.
+func Pair(g1 *G1, g2 *G2) *GT {
+	return &GT{gt{*optimized.Pair(&g1.p.G1, &g2.p.G2)}}
+}
+
+// PairingCheck calculates the optimal ate pairing for a set of points,
+// reporting whether the product of the results equals one.
+func PairingCheck(a []*G1, b []*G2) bool {
+	as := make([]*optimized.G1, len(a))
+	for i, p := range a {
+		as[i] = &p.p.G1
+	}
+	bs := make([]*optimized.G2, len(b))
+	for i, p := range b {
+		bs[i] = &p.p.G2
+	}
+	return optimized.PairingCheck(as, bs)
+}
@@ -1,4 +1,4 @@
-// Copyright 2014 The go-ethereum Authors
+// Copyright 2016 The go-ethereum Authors
 // This file is part of the go-ethereum library.
 //
 // The go-ethereum library is free software: you can redistribute it and/or modify
@@ -14,19 +14,24 @@
 // You should have received a copy of the GNU Lesser General Public License
 // along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
 
-/*
-Package whisper implements the Whisper PoC-1.
+package bn256
 
-(https://github.com/ethereum/wiki/wiki/Whisper-PoC-1-Protocol-Spec)
+import (
+	"math/big"
+	"testing"
+)
 
-Whisper combines aspects of both DHTs and datagram messaging systems (e.g. UDP).
-As such it may be likened and compared to both, not dissimilar to the
-matter/energy duality (apologies to physicists for the blatant abuse of a
-fundamental and beautiful natural principle).
+// Tests that whichever implementation is selected computes valid pairings.
+func TestPairingCheck(t *testing.T) {
+	a := big.NewInt(1234567)
 
-Whisper is a pure identity-based messaging system. Whisper provides a low-level
-(non-application-specific) but easily-accessible API without being based upon
-or prejudiced by the low-level hardware attributes and characteristics,
-particularly the notion of singular endpoints.
-*/
-package whisperv2
+	p, q := new(G1).ScalarBaseMult(big.NewInt(1)), new(G2).ScalarBaseMult(big.NewInt(1))
+	ap, aq := new(G1).ScalarBaseMult(a), new(G2).ScalarBaseMult(a)
+
+	if !PairingCheck([]*G1{ap, new(G1).Neg(p)}, []*G2{q, aq}) {
+		t.Error("valid pairing check failed")
+	}
+	if PairingCheck([]*G1{ap, p}, []*G2{q, aq}) {
+		t.Error("invalid pairing check succeeded")
+	}
+}
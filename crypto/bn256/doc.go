@@ -0,0 +1,25 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package bn256 implements the optimal ate pairing over the 256-bit
+// Barreto-Naehrig curve alt_bn128, as used by the elliptic curve precompiled
+// contracts of the EVM (EIP-196 and EIP-197).
+//
+// The package is a thin front for one of two interchangeable implementations
+// sharing the same API: the optimized one (Montgomery arithmetic on 64 bit
+// limbs, assembly backed on amd64) on 64 bit platforms, and the generic one
+// built on math/big everywhere else.
+package bn256
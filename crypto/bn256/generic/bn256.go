@@ -0,0 +1,257 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package generic implements the optimal ate pairing over the 256-bit
+// Barreto-Naehrig curve alt_bn128 in portable Go.
+//
+// The implementation favours simplicity over speed: points are kept in affine
+// coordinates on top of math/big, and it is not constant time. It serves as
+// the fallback on platforms without an optimized implementation and as the
+// reference the optimized one is tested against.
+package generic
+
+import (
+	"errors"
+	"math/big"
+)
+
+var (
+	errNotEnoughData = errors.New("bn256: not enough data")
+	errOutsideField  = errors.New("bn256: coordinate exceeds modulus")
+	errNotOnCurve    = errors.New("bn256: malformed point")
+	errNotInSubgroup = errors.New("bn256: point not in G2")
+)
+
+// G1 is an abstract cyclic group. The zero value is suitable for use as the
+// output of an operation, but cannot be used as an input.
+type G1 struct {
+	p *curvePoint
+}
+
+func (e *G1) String() string {
+	return "bn256.G1" + e.p.String()
+}
+
+// ScalarBaseMult sets e to g*k where g is the generator of the group and
+// then returns e.
+func (e *G1) ScalarBaseMult(k *big.Int) *G1 {
+	e.p = curveGen.mul(k)
+	return e
+}
+
+// ScalarMult sets e to a*k and then returns e.
+func (e *G1) ScalarMult(a *G1, k *big.Int) *G1 {
+	e.p = a.p.mul(k)
+	return e
+}
+
+// Add sets e to a+b and then returns e.
+func (e *G1) Add(a, b *G1) *G1 {
+	e.p = a.p.add(b.p)
+	return e
+}
+
+// Neg sets e to -a and then returns e.
+func (e *G1) Neg(a *G1) *G1 {
+	e.p = a.p.neg()
+	return e
+}
+
+// Marshal converts e to a byte slice of the 32 byte big endian x and y
+// coordinates. The point at infinity is encoded as all zeroes.
+func (e *G1) Marshal() []byte {
+	out := make([]byte, 64)
+	if e.p.inf {
+		return out
+	}
+	putField(out[0:], e.p.x)
+	putField(out[32:], e.p.y)
+	return out
+}
+
+// Unmarshal sets e to the result of converting the output of Marshal back into
+// a group element, returning the unconsumed remainder of m. The point is
+// checked to be on the curve.
+func (e *G1) Unmarshal(m []byte) ([]byte, error) {
+	if len(m) < 64 {
+		return nil, errNotEnoughData
+	}
+	x, err := getField(m[0:])
+	if err != nil {
+		return nil, err
+	}
+	y, err := getField(m[32:])
+	if err != nil {
+		return nil, err
+	}
+	if x.Sign() == 0 && y.Sign() == 0 {
+		e.p = curveInfinity
+		return m[64:], nil
+	}
+	p := &curvePoint{x: x, y: y}
+	if !p.isOnCurve() {
+		return nil, errNotOnCurve
+	}
+	e.p = p
+	return m[64:], nil
+}
+
+// G2 is an abstract cyclic group. The zero value is suitable for use as the
+// output of an operation, but cannot be used as an input.
+type G2 struct {
+	p *twistPoint
+}
+
+func (e *G2) String() string {
+	return "bn256.G2" + e.p.String()
+}
+
+// ScalarBaseMult sets e to g*k where g is the generator of the group and
+// then returns e.
+func (e *G2) ScalarBaseMult(k *big.Int) *G2 {
+	e.p = twistGen.mul(k)
+	return e
+}
+
+// ScalarMult sets e to a*k and then returns e.
+func (e *G2) ScalarMult(a *G2, k *big.Int) *G2 {
+	e.p = a.p.mul(k)
+	return e
+}
+
+// Add sets e to a+b and then returns e.
+func (e *G2) Add(a, b *G2) *G2 {
+	e.p = a.p.add(b.p)
+	return e
+}
+
+// Neg sets e to -a and then returns e.
+func (e *G2) Neg(a *G2) *G2 {
+	e.p = a.p.neg()
+	return e
+}
+
+// Marshal converts e into a byte slice of the 32 byte big endian coordinates,
+// ordered x.im, x.re, y.im, y.re. The point at infinity is encoded as all
+// zeroes.
+func (e *G2) Marshal() []byte {
+	out := make([]byte, 128)
+	if e.p.inf {
+		return out
+	}
+	putField(out[0:], e.p.x.im)
+	putField(out[32:], e.p.x.re)
+	putField(out[64:], e.p.y.im)
+	putField(out[96:], e.p.y.re)
+	return out
+}
+
+// Unmarshal sets e to the result of converting the output of Marshal back into
+// a group element, returning the unconsumed remainder of m. The point is
+// checked to be on the twist and in the subgroup of order r.
+func (e *G2) Unmarshal(m []byte) ([]byte, error) {
+	if len(m) < 128 {
+		return nil, errNotEnoughData
+	}
+	var coords [4]*big.Int
+	for i := range coords {
+		n, err := getField(m[32*i:])
+		if err != nil {
+			return nil, err
+		}
+		coords[i] = n
+	}
+	p := &twistPoint{
+		x: &gfP2{re: coords[1], im: coords[0]},
+		y: &gfP2{re: coords[3], im: coords[2]},
+	}
+	if p.x.isZero() && p.y.isZero() {
+		e.p = twistInfinity
+		return m[128:], nil
+	}
+	if !p.isOnCurve() {
+		return nil, errNotOnCurve
+	}
+	if !p.inSubgroup() {
+		return nil, errNotInSubgroup
+	}
+	e.p = p
+	return m[128:], nil
+}
+
+// GT is an abstract cyclic group. The zero value is suitable for use as the
+// output of an operation, but cannot be used as an input.
+type GT struct {
+	p *gfP12
+}
+
+// ScalarMult sets e to a*k and then returns e.
+func (e *GT) ScalarMult(a *GT, k *big.Int) *GT {
+	e.p = a.p.exp(k)
+	return e
+}
+
+// Add sets e to a+b and then returns e. The group operation of GT is the
+// multiplication in Fp12.
+func (e *GT) Add(a, b *GT) *GT {
+	e.p = a.p.mul(b.p)
+	return e
+}
+
+// Marshal converts e into a byte slice of the twelve 32 byte big endian base
+// field coefficients. Writing e = x·w + y with x and y in Fp6 = Fp2[v]/(v³-ξ),
+// v = w², the order is x.v², x.v, x.1, y.v², y.v, y.1, each as im, re.
+func (e *GT) Marshal() []byte {
+	out := make([]byte, 384)
+	for i, c := range []int{5, 3, 1, 4, 2, 0} {
+		putField(out[64*i:], e.p[c].im)
+		putField(out[64*i+32:], e.p[c].re)
+	}
+	return out
+}
+
+// Pair calculates the optimal ate pairing.
+func Pair(g1 *G1, g2 *G2) *GT {
+	return &GT{optimalAte(g2.p, g1.p)}
+}
+
+// PairingCheck calculates the optimal ate pairing for a set of points,
+// reporting whether the product of the results equals one.
+func PairingCheck(a []*G1, b []*G2) bool {
+	acc := newGFp12One()
+	for i := 0; i < len(a); i++ {
+		if a[i].p.inf || b[i].p.inf {
+			continue
+		}
+		acc = acc.mul(miller(b[i].p, a[i].p))
+	}
+	return finalExponentiation(acc).isOne()
+}
+
+// putField writes n as a 32 byte big endian number into out.
+func putField(out []byte, n *big.Int) {
+	b := n.Bytes()
+	copy(out[32-len(b):32], b)
+}
+
+// getField reads a 32 byte big endian field element from m.
+func getField(m []byte) (*big.Int, error) {
+	n := new(big.Int).SetBytes(m[:32])
+	if n.Cmp(P) >= 0 {
+		return nil, errOutsideField
+	}
+	return n, nil
+}
@@ -0,0 +1,148 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package generic
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math/big"
+	"testing"
+)
+
+func TestParams(t *testing.T) {
+	// p = 36u⁴+36u³+24u²+6u+1 and r = 36u⁴+36u³+18u²+6u+1
+	poly := func(c2 int64) *big.Int {
+		u2 := new(big.Int).Mul(u, u)
+		u3 := new(big.Int).Mul(u2, u)
+		u4 := new(big.Int).Mul(u3, u)
+
+		n := new(big.Int).Mul(big.NewInt(36), u4)
+		n.Add(n, new(big.Int).Mul(big.NewInt(36), u3))
+		n.Add(n, new(big.Int).Mul(big.NewInt(c2), u2))
+		n.Add(n, new(big.Int).Mul(big.NewInt(6), u))
+		return n.Add(n, big.NewInt(1))
+	}
+	if p := poly(24); p.Cmp(P) != 0 {
+		t.Errorf("prime mismatch: have %v, want %v", P, p)
+	}
+	if r := poly(18); r.Cmp(Order) != 0 {
+		t.Errorf("order mismatch: have %v, want %v", Order, r)
+	}
+}
+
+func TestGenerators(t *testing.T) {
+	if !curveGen.isOnCurve() || !curveGen.mul(Order).inf {
+		t.Error("G1 generator not of order r")
+	}
+	if !twistGen.isOnCurve() || !twistGen.inSubgroup() {
+		t.Error("G2 generator not of order r")
+	}
+}
+
+func TestG1Arithmetic(t *testing.T) {
+	tests := []struct {
+		k    int64
+		want string
+	}{
+		{2, "030644e72e131a029b85045b68181585d97816a916871ca8d3c208c16d87cfd315ed738c0e0a7c92e7845f96b2ae9c0a68a6a449e3538fc7ff3ebf7a5a18a2c4"},
+		{0xdeadbeef, "1fd9bf9c6c9fc892f0b4f856657cd9309f43e2f1cfa3ed4724c40bd74ea1380318ee06de0e49deaf292d55f31fd13e603489f81bfa4ec6f2443ba2274621703f"},
+	}
+	for i, tt := range tests {
+		have := new(G1).ScalarBaseMult(big.NewInt(tt.k)).Marshal()
+		if hex.EncodeToString(have) != tt.want {
+			t.Errorf("test %d: point mismatch: have %x, want %s", i, have, tt.want)
+		}
+	}
+	// Adding the negation yields the point at infinity
+	g := new(G1).ScalarBaseMult(big.NewInt(42))
+	if sum := new(G1).Add(g, new(G1).Neg(g)); !sum.p.inf {
+		t.Errorf("g + -g is not infinity: %v", sum)
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	g1 := new(G1).ScalarBaseMult(big.NewInt(12345))
+	g1b := new(G1)
+	if _, err := g1b.Unmarshal(g1.Marshal()); err != nil {
+		t.Fatalf("failed to unmarshal G1: %v", err)
+	}
+	if !bytes.Equal(g1.Marshal(), g1b.Marshal()) {
+		t.Errorf("G1 round trip mismatch: have %v, want %v", g1b, g1)
+	}
+	g2 := new(G2).ScalarBaseMult(big.NewInt(12345))
+	g2b := new(G2)
+	if _, err := g2b.Unmarshal(g2.Marshal()); err != nil {
+		t.Fatalf("failed to unmarshal G2: %v", err)
+	}
+	if !bytes.Equal(g2.Marshal(), g2b.Marshal()) {
+		t.Errorf("G2 round trip mismatch: have %v, want %v", g2b, g2)
+	}
+	// Points off the curve must be rejected
+	bad := g1.Marshal()
+	bad[63] ^= 1
+	if _, err := new(G1).Unmarshal(bad); err != errNotOnCurve {
+		t.Errorf("G1 error mismatch: have %v, want %v", err, errNotOnCurve)
+	}
+	bad = g2.Marshal()
+	bad[127] ^= 1
+	if _, err := new(G2).Unmarshal(bad); err != errNotOnCurve {
+		t.Errorf("G2 error mismatch: have %v, want %v", err, errNotOnCurve)
+	}
+}
+
+func TestBilinearity(t *testing.T) {
+	a, b := big.NewInt(271828), big.NewInt(314159)
+
+	e := Pair(new(G1).ScalarBaseMult(big.NewInt(1)), new(G2).ScalarBaseMult(big.NewInt(1)))
+	if e.p.isOne() {
+		t.Fatal("pairing is degenerate")
+	}
+	if !new(GT).ScalarMult(e, Order).p.isOne() {
+		t.Error("pairing result not of order r")
+	}
+	// e(aP, bQ) = e(P, Q)^(ab)
+	have := Pair(new(G1).ScalarBaseMult(a), new(G2).ScalarBaseMult(b))
+	want := new(GT).ScalarMult(e, new(big.Int).Mul(a, b))
+	if !have.p.equal(want.p) {
+		t.Error("e(aP, bQ) != e(P, Q)^ab")
+	}
+	// e(P+P', Q) = e(P, Q)·e(P', Q)
+	g2 := new(G2).ScalarBaseMult(b)
+	have = Pair(new(G1).ScalarBaseMult(new(big.Int).Add(a, b)), g2)
+	want = new(GT).Add(Pair(new(G1).ScalarBaseMult(a), g2), Pair(new(G1).ScalarBaseMult(b), g2))
+	if !have.p.equal(want.p) {
+		t.Error("e(P+P', Q) != e(P, Q)·e(P', Q)")
+	}
+}
+
+func TestPairingCheck(t *testing.T) {
+	a := big.NewInt(1234567)
+
+	p, q := new(G1).ScalarBaseMult(big.NewInt(1)), new(G2).ScalarBaseMult(big.NewInt(1))
+	ap, aq := new(G1).ScalarBaseMult(a), new(G2).ScalarBaseMult(a)
+
+	// e(aP, Q)·e(-P, aQ) = 1
+	if !PairingCheck([]*G1{ap, new(G1).Neg(p)}, []*G2{q, aq}) {
+		t.Error("valid pairing check failed")
+	}
+	if PairingCheck([]*G1{ap, p}, []*G2{q, aq}) {
+		t.Error("invalid pairing check succeeded")
+	}
+	if !PairingCheck(nil, nil) {
+		t.Error("empty pairing check failed")
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package generic
+
+import "math/big"
+
+func bigFromBase10(s string) *big.Int {
+	n, _ := new(big.Int).SetString(s, 10)
+	return n
+}
+
+// u is the BN parameter that determines the prime: 4965661367192848881.
+var u = bigFromBase10("4965661367192848881")
+
+// P is the prime over which the curves are defined: 36u⁴+36u³+24u²+6u+1.
+var P = bigFromBase10("21888242871839275222246405745257275088696311157297823662689037894645226208583")
+
+// Order is the number of elements in G1, G2 and GT: 36u⁴+36u³+18u²+6u+1.
+var Order = bigFromBase10("21888242871839275222246405745257275088548364400416034343698204186575808495617")
+
+// ateLoopCount is the length of the Miller loop of the optimal ate pairing: 6u+2.
+var ateLoopCount = new(big.Int).Add(new(big.Int).Mul(big.NewInt(6), u), big.NewInt(2))
+
+var (
+	// xi is the non-residue defining the degree twelve extension, Fp12 = Fp2[w]/(w⁶-ξ).
+	xi = &gfP2{re: big.NewInt(9), im: big.NewInt(1)}
+
+	// twistB is the constant of the sextic twist E': y² = x³ + 3/ξ.
+	twistB = newGFp2(big.NewInt(3), new(big.Int)).mul(xi.inv())
+
+	// xiToPMinus1Over3 and xiToPMinus1Over2 map the Frobenius endomorphism
+	// of the untwisted curve onto the twist coordinates.
+	xiToPMinus1Over3 = xi.exp(new(big.Int).Div(new(big.Int).Sub(P, big.NewInt(1)), big.NewInt(3)))
+	xiToPMinus1Over2 = xi.exp(new(big.Int).Div(new(big.Int).Sub(P, big.NewInt(1)), big.NewInt(2)))
+
+	// frobeniusP2Coeffs[i] is ξ^(i(p²-1)/6), the factor by which the p² power
+	// Frobenius map scales the coefficient of wⁱ.
+	frobeniusP2Coeffs = func() (coeffs [6]*gfP2) {
+		pp := new(big.Int).Mul(P, P)
+		step := xi.exp(pp.Div(pp.Sub(pp, big.NewInt(1)), big.NewInt(6)))
+
+		coeffs[0] = newGFp2(big.NewInt(1), new(big.Int))
+		for i := 1; i < len(coeffs); i++ {
+			coeffs[i] = coeffs[i-1].mul(step)
+		}
+		return coeffs
+	}()
+
+	// finalExponent is the hard part of the final exponentiation, (p⁴-p²+1)/r.
+	finalExponent = func() *big.Int {
+		p2 := new(big.Int).Mul(P, P)
+		e := new(big.Int).Mul(p2, p2)
+		e.Sub(e, p2)
+		e.Add(e, big.NewInt(1))
+		return e.Div(e, Order)
+	}()
+)
@@ -0,0 +1,120 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package generic
+
+import "math/big"
+
+// curveB is the constant of the curve E: y² = x³ + 3.
+var curveB = big.NewInt(3)
+
+// curvePoint implements the elliptic curve E over the base field. Points are
+// kept in affine form and are immutable, all operations return a new point.
+type curvePoint struct {
+	x, y *big.Int
+	inf  bool // point at infinity, x and y are meaningless
+}
+
+// curveGen is the generator of G1.
+var curveGen = &curvePoint{x: big.NewInt(1), y: big.NewInt(2)}
+
+var curveInfinity = &curvePoint{inf: true}
+
+func (c *curvePoint) String() string {
+	if c.inf {
+		return "(inf)"
+	}
+	return "(" + c.x.String() + ", " + c.y.String() + ")"
+}
+
+// isOnCurve reports whether c satisfies the curve equation.
+func (c *curvePoint) isOnCurve() bool {
+	if c.inf {
+		return true
+	}
+	lhs := new(big.Int).Mul(c.y, c.y)
+	rhs := new(big.Int).Mul(c.x, c.x)
+	rhs.Mul(rhs, c.x)
+	rhs.Add(rhs, curveB)
+	return lhs.Mod(lhs, P).Cmp(rhs.Mod(rhs, P)) == 0
+}
+
+func (c *curvePoint) neg() *curvePoint {
+	if c.inf {
+		return c
+	}
+	return &curvePoint{x: c.x, y: new(big.Int).Mod(new(big.Int).Neg(c.y), P)}
+}
+
+func (c *curvePoint) double() *curvePoint {
+	if c.inf || c.y.Sign() == 0 {
+		return curveInfinity
+	}
+	// λ = 3x²/2y
+	num := new(big.Int).Mul(c.x, c.x)
+	num.Mul(num, big.NewInt(3))
+	den := new(big.Int).Lsh(c.y, 1)
+	return c.chord(c, num, den)
+}
+
+func (c *curvePoint) add(d *curvePoint) *curvePoint {
+	switch {
+	case c.inf:
+		return d
+	case d.inf:
+		return c
+	case c.x.Cmp(d.x) == 0:
+		if c.y.Cmp(d.y) == 0 {
+			return c.double()
+		}
+		return curveInfinity
+	}
+	// λ = (y₂-y₁)/(x₂-x₁)
+	num := new(big.Int).Sub(d.y, c.y)
+	den := new(big.Int).Sub(d.x, c.x)
+	return c.chord(d, num, den)
+}
+
+// chord returns the third intersection of the line through c and d with slope
+// num/den, reflected over the x axis.
+func (c *curvePoint) chord(d *curvePoint, num, den *big.Int) *curvePoint {
+	lambda := den.ModInverse(den.Mod(den, P), P)
+	lambda.Mul(lambda, num)
+	lambda.Mod(lambda, P)
+
+	x := new(big.Int).Mul(lambda, lambda)
+	x.Sub(x, c.x)
+	x.Sub(x, d.x)
+	x.Mod(x, P)
+
+	y := new(big.Int).Sub(c.x, x)
+	y.Mul(y, lambda)
+	y.Sub(y, c.y)
+	y.Mod(y, P)
+
+	return &curvePoint{x: x, y: y}
+}
+
+func (c *curvePoint) mul(k *big.Int) *curvePoint {
+	res := curveInfinity
+	for i := k.BitLen() - 1; i >= 0; i-- {
+		res = res.double()
+		if k.Bit(i) == 1 {
+			res = res.add(c)
+		}
+	}
+	return res
+}
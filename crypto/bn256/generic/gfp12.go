@@ -0,0 +1,139 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package generic
+
+import "math/big"
+
+// gfP12 implements the field of size p¹² as a degree six extension of Fp2,
+// Fp12 = Fp2[w]/(w⁶-ξ). The subfield Fp6 consists of the elements with only
+// even powers of w.
+type gfP12 [6]*gfP2 // value is the sum of c[i]·wⁱ
+
+func newGFp12One() *gfP12 {
+	e := newGFp12Zero()
+	e[0] = newGFp2(big.NewInt(1), new(big.Int))
+	return e
+}
+
+func newGFp12Zero() *gfP12 {
+	e := new(gfP12)
+	for i := range e {
+		e[i] = newGFp2(new(big.Int), new(big.Int))
+	}
+	return e
+}
+
+func (a *gfP12) isOne() bool {
+	if !a[0].isOne() {
+		return false
+	}
+	for i := 1; i < len(a); i++ {
+		if !a[i].isZero() {
+			return false
+		}
+	}
+	return true
+}
+
+func (a *gfP12) equal(b *gfP12) bool {
+	for i := range a {
+		if !a[i].equal(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (a *gfP12) mul(b *gfP12) *gfP12 {
+	var prod [11]*gfP2
+	for i := range prod {
+		prod[i] = newGFp2(new(big.Int), new(big.Int))
+	}
+	for i := range a {
+		if a[i].isZero() {
+			continue
+		}
+		for j := range b {
+			if b[j].isZero() {
+				continue
+			}
+			prod[i+j] = prod[i+j].add(a[i].mul(b[j]))
+		}
+	}
+	// Reduce using w⁶ = ξ
+	res := new(gfP12)
+	for i := 0; i < 5; i++ {
+		res[i] = prod[i].add(prod[i+6].mul(xi))
+	}
+	res[5] = prod[5]
+	return res
+}
+
+func (a *gfP12) square() *gfP12 {
+	return a.mul(a)
+}
+
+// conj returns a^(p⁶), the conjugate of a over Fp6, which negates the odd
+// powers of w.
+func (a *gfP12) conj() *gfP12 {
+	res := new(gfP12)
+	for i := range a {
+		if i%2 == 0 {
+			res[i] = a[i]
+		} else {
+			res[i] = a[i].neg()
+		}
+	}
+	return res
+}
+
+// frobeniusP2 returns a^(p²).
+func (a *gfP12) frobeniusP2() *gfP12 {
+	res := new(gfP12)
+	for i := range a {
+		res[i] = a[i].mul(frobeniusP2Coeffs[i])
+	}
+	return res
+}
+
+// inv returns the multiplicative inverse of a, which must not be zero.
+func (a *gfP12) inv() *gfP12 {
+	// The norm a·conj(a) lies in Fp6 = Fp2[v]/(v³-ξ) with v = w², which
+	// can be inverted directly.
+	n := a.mul(a.conj())
+	a0, a1, a2 := n[0], n[2], n[4]
+
+	t0 := a0.square().sub(xi.mul(a1.mul(a2)))
+	t1 := xi.mul(a2.square()).sub(a0.mul(a1))
+	t2 := a1.square().sub(a0.mul(a2))
+	d := a0.mul(t0).add(xi.mul(a2.mul(t1).add(a1.mul(t2)))).inv()
+
+	ninv := newGFp12Zero()
+	ninv[0], ninv[2], ninv[4] = t0.mul(d), t1.mul(d), t2.mul(d)
+	return a.conj().mul(ninv)
+}
+
+func (a *gfP12) exp(k *big.Int) *gfP12 {
+	res := newGFp12One()
+	for i := k.BitLen() - 1; i >= 0; i-- {
+		res = res.square()
+		if k.Bit(i) == 1 {
+			res = res.mul(a)
+		}
+	}
+	return res
+}
@@ -0,0 +1,104 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package generic
+
+import "math/big"
+
+// gfP2 implements a field of size p² as a quadratic extension of the base
+// field, Fp2 = Fp[i]/(i²+1). Elements are immutable, all operations return
+// a new element.
+type gfP2 struct {
+	re, im *big.Int // value is re+im·i, both fully reduced
+}
+
+func newGFp2(re, im *big.Int) *gfP2 {
+	return &gfP2{
+		re: new(big.Int).Mod(re, P),
+		im: new(big.Int).Mod(im, P),
+	}
+}
+
+func (a *gfP2) String() string {
+	return "(" + a.re.String() + ", " + a.im.String() + ")"
+}
+
+func (a *gfP2) isZero() bool {
+	return a.re.Sign() == 0 && a.im.Sign() == 0
+}
+
+func (a *gfP2) isOne() bool {
+	return a.re.Cmp(big.NewInt(1)) == 0 && a.im.Sign() == 0
+}
+
+func (a *gfP2) equal(b *gfP2) bool {
+	return a.re.Cmp(b.re) == 0 && a.im.Cmp(b.im) == 0
+}
+
+func (a *gfP2) add(b *gfP2) *gfP2 {
+	return newGFp2(new(big.Int).Add(a.re, b.re), new(big.Int).Add(a.im, b.im))
+}
+
+func (a *gfP2) sub(b *gfP2) *gfP2 {
+	return newGFp2(new(big.Int).Sub(a.re, b.re), new(big.Int).Sub(a.im, b.im))
+}
+
+func (a *gfP2) neg() *gfP2 {
+	return newGFp2(new(big.Int).Neg(a.re), new(big.Int).Neg(a.im))
+}
+
+// conj returns the conjugate of a, which equals a^p.
+func (a *gfP2) conj() *gfP2 {
+	return newGFp2(a.re, new(big.Int).Neg(a.im))
+}
+
+func (a *gfP2) mul(b *gfP2) *gfP2 {
+	// (a0+a1·i)(b0+b1·i) = a0·b0-a1·b1 + (a0·b1+a1·b0)·i
+	re := new(big.Int).Mul(a.re, b.re)
+	re.Sub(re, new(big.Int).Mul(a.im, b.im))
+	im := new(big.Int).Mul(a.re, b.im)
+	im.Add(im, new(big.Int).Mul(a.im, b.re))
+	return newGFp2(re, im)
+}
+
+// mulScalar multiplies a by an element of the base field.
+func (a *gfP2) mulScalar(k *big.Int) *gfP2 {
+	return newGFp2(new(big.Int).Mul(a.re, k), new(big.Int).Mul(a.im, k))
+}
+
+func (a *gfP2) square() *gfP2 {
+	return a.mul(a)
+}
+
+// inv returns the multiplicative inverse of a, which must not be zero.
+func (a *gfP2) inv() *gfP2 {
+	// 1/(a0+a1·i) = (a0-a1·i)/(a0²+a1²)
+	norm := new(big.Int).Mul(a.re, a.re)
+	norm.Add(norm, new(big.Int).Mul(a.im, a.im))
+	norm.ModInverse(norm.Mod(norm, P), P)
+	return a.conj().mulScalar(norm)
+}
+
+func (a *gfP2) exp(k *big.Int) *gfP2 {
+	res := newGFp2(big.NewInt(1), new(big.Int))
+	for i := k.BitLen() - 1; i >= 0; i-- {
+		res = res.square()
+		if k.Bit(i) == 1 {
+			res = res.mul(a)
+		}
+	}
+	return res
+}
@@ -0,0 +1,86 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package generic
+
+import "math/big"
+
+// lineFunction evaluates the line through r with the given slope (on the twist)
+// at the point p of G1. Mapping the twist onto the curve with the untwisting
+// isomorphism (x, y) → (x·w², y·w³), the line becomes
+//
+//	l(p) = p.y - λ·p.x·w + (λ·r.x - r.y)·w³
+//
+// Vertical lines are omitted as they lie in Fp6 and are therefore eliminated
+// by the final exponentiation.
+func lineFunction(r *twistPoint, lambda *gfP2, p *curvePoint) *gfP12 {
+	l := newGFp12Zero()
+	l[0] = newGFp2(p.y, new(big.Int))
+	l[1] = lambda.mulScalar(p.x).neg()
+	l[3] = lambda.mul(r.x).sub(r.y)
+	return l
+}
+
+// millerStep multiplies f by the line through r and q evaluated at p, returning
+// the updated accumulator along with r+q.
+func millerStep(f *gfP12, r, q *twistPoint, p *curvePoint) (*gfP12, *twistPoint) {
+	if r.inf || q.inf {
+		return f, r.add(q)
+	}
+	lambda := r.slope(q)
+	if lambda == nil {
+		return f, twistInfinity
+	}
+	return f.mul(lineFunction(r, lambda, p)), r.chord(q, lambda)
+}
+
+// miller implements the Miller loop of the optimal ate pairing, evaluating
+// f_{6u+2,q}(p)·l_{[6u+2]q,π(q)}(p)·l_{[6u+2]q+π(q),-π²(q)}(p).
+func miller(q *twistPoint, p *curvePoint) *gfP12 {
+	f := newGFp12One()
+	if q.inf || p.inf {
+		return f
+	}
+	r := q
+	for i := ateLoopCount.BitLen() - 2; i >= 0; i-- {
+		f = f.square()
+		f, r = millerStep(f, r, r, p)
+		if ateLoopCount.Bit(i) == 1 {
+			f, r = millerStep(f, r, q, p)
+		}
+	}
+	q1 := q.frobenius()
+	q2 := q1.frobenius().neg()
+
+	f, r = millerStep(f, r, q1, p)
+	f, _ = millerStep(f, r, q2, p)
+	return f
+}
+
+// finalExponentiation computes f^((p¹²-1)/r), mapping the result of the Miller
+// loop onto the unique representative in GT.
+func finalExponentiation(f *gfP12) *gfP12 {
+	// Easy part: f^((p⁶-1)(p²+1))
+	t := f.conj().mul(f.inv())
+	t = t.frobeniusP2().mul(t)
+
+	// Hard part: f^((p⁴-p²+1)/r)
+	return t.exp(finalExponent)
+}
+
+func optimalAte(q *twistPoint, p *curvePoint) *gfP12 {
+	return finalExponentiation(miller(q, p))
+}
@@ -0,0 +1,133 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package generic
+
+import "math/big"
+
+// twistPoint implements the sextic twist E' of the curve, y² = x³ + 3/ξ over
+// Fp2. Its points of order r form G2. Points are kept in affine form and are
+// immutable, all operations return a new point.
+type twistPoint struct {
+	x, y *gfP2
+	inf  bool // point at infinity, x and y are meaningless
+}
+
+// twistGen is the generator of G2.
+var twistGen = &twistPoint{
+	x: newGFp2(
+		bigFromBase10("10857046999023057135944570762232829481370756359578518086990519993285655852781"),
+		bigFromBase10("11559732032986387107991004021392285783925812861821192530917403151452391805634"),
+	),
+	y: newGFp2(
+		bigFromBase10("8495653923123431417604973247489272438418190587263600148770280649306958101930"),
+		bigFromBase10("4082367875863433681332203403145435568316851327593401208105741076214120093531"),
+	),
+}
+
+var twistInfinity = &twistPoint{inf: true}
+
+func (c *twistPoint) String() string {
+	if c.inf {
+		return "(inf)"
+	}
+	return "(" + c.x.String() + ", " + c.y.String() + ")"
+}
+
+// isOnCurve reports whether c satisfies the twist equation.
+func (c *twistPoint) isOnCurve() bool {
+	if c.inf {
+		return true
+	}
+	return c.y.square().equal(c.x.square().mul(c.x).add(twistB))
+}
+
+// inSubgroup reports whether c is of order r, i.e. part of G2. Unlike E, the
+// twist has a cofactor, so being on the curve is not sufficient.
+func (c *twistPoint) inSubgroup() bool {
+	return c.mul(Order).inf
+}
+
+func (c *twistPoint) neg() *twistPoint {
+	if c.inf {
+		return c
+	}
+	return &twistPoint{x: c.x, y: c.y.neg()}
+}
+
+// slope returns the slope of the tangent at c if d equals c, or of the line
+// through c and d otherwise. If the line is vertical, nil is returned.
+func (c *twistPoint) slope(d *twistPoint) *gfP2 {
+	if c.x.equal(d.x) {
+		if !c.y.equal(d.y) || c.y.isZero() {
+			return nil
+		}
+		// λ = 3x²/2y
+		num := c.x.square().mulScalar(big.NewInt(3))
+		return num.mul(c.y.add(c.y).inv())
+	}
+	// λ = (y₂-y₁)/(x₂-x₁)
+	return d.y.sub(c.y).mul(d.x.sub(c.x).inv())
+}
+
+// chord returns the third intersection of the line through c and d with the
+// given slope, reflected over the x axis.
+func (c *twistPoint) chord(d *twistPoint, lambda *gfP2) *twistPoint {
+	x := lambda.square().sub(c.x).sub(d.x)
+	y := lambda.mul(c.x.sub(x)).sub(c.y)
+	return &twistPoint{x: x, y: y}
+}
+
+func (c *twistPoint) add(d *twistPoint) *twistPoint {
+	switch {
+	case c.inf:
+		return d
+	case d.inf:
+		return c
+	}
+	lambda := c.slope(d)
+	if lambda == nil {
+		return twistInfinity
+	}
+	return c.chord(d, lambda)
+}
+
+func (c *twistPoint) double() *twistPoint {
+	return c.add(c)
+}
+
+func (c *twistPoint) mul(k *big.Int) *twistPoint {
+	res := twistInfinity
+	for i := k.BitLen() - 1; i >= 0; i-- {
+		res = res.double()
+		if k.Bit(i) == 1 {
+			res = res.add(c)
+		}
+	}
+	return res
+}
+
+// frobenius returns the image of c under the p-power Frobenius endomorphism of
+// the untwisted curve, mapped back onto the twist.
+func (c *twistPoint) frobenius() *twistPoint {
+	if c.inf {
+		return c
+	}
+	return &twistPoint{
+		x: c.x.conj().mul(xiToPMinus1Over3),
+		y: c.y.conj().mul(xiToPMinus1Over2),
+	}
+}
@@ -0,0 +1,294 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package optimized implements the optimal ate pairing over the 256-bit
+// Barreto-Naehrig curve alt_bn128, tuned for speed.
+//
+// Field elements are kept in Montgomery form on fixed size limbs, points in
+// Jacobian coordinates, and the base field multiplication is implemented in
+// assembly on amd64. The package mirrors the API of the generic (reference)
+// implementation, which it is tested against. It is not constant time.
+package optimized
+
+import (
+	"errors"
+	"math/big"
+)
+
+var (
+	errNotEnoughData = errors.New("bn256: not enough data")
+	errNotOnCurve    = errors.New("bn256: malformed point")
+	errNotInSubgroup = errors.New("bn256: point not in G2")
+)
+
+// G1 is an abstract cyclic group. The zero value is suitable for use as the
+// output of an operation, but cannot be used as an input.
+type G1 struct {
+	p *curvePoint
+}
+
+func (e *G1) String() string {
+	return "bn256.G1" + e.p.String()
+}
+
+// ScalarBaseMult sets e to g*k where g is the generator of the group and
+// then returns e.
+func (e *G1) ScalarBaseMult(k *big.Int) *G1 {
+	if e.p == nil {
+		e.p = &curvePoint{}
+	}
+	e.p.Mul(curveGen, k)
+	return e
+}
+
+// ScalarMult sets e to a*k and then returns e.
+func (e *G1) ScalarMult(a *G1, k *big.Int) *G1 {
+	if e.p == nil {
+		e.p = &curvePoint{}
+	}
+	e.p.Mul(a.p, k)
+	return e
+}
+
+// Add sets e to a+b and then returns e.
+func (e *G1) Add(a, b *G1) *G1 {
+	if e.p == nil {
+		e.p = &curvePoint{}
+	}
+	e.p.Add(a.p, b.p)
+	return e
+}
+
+// Neg sets e to -a and then returns e.
+func (e *G1) Neg(a *G1) *G1 {
+	if e.p == nil {
+		e.p = &curvePoint{}
+	}
+	e.p.Neg(a.p)
+	return e
+}
+
+// Marshal converts e to a byte slice of the 32 byte big endian x and y
+// coordinates. The point at infinity is encoded as all zeroes.
+func (e *G1) Marshal() []byte {
+	out := make([]byte, 64)
+	if e.p == nil || e.p.IsInfinity() {
+		return out
+	}
+	e.p.MakeAffine()
+
+	temp := &gfP{}
+	montDecode(temp, &e.p.x)
+	temp.marshal(out[0:])
+	montDecode(temp, &e.p.y)
+	temp.marshal(out[32:])
+	return out
+}
+
+// Unmarshal sets e to the result of converting the output of Marshal back into
+// a group element, returning the unconsumed remainder of m. The point is
+// checked to be on the curve.
+func (e *G1) Unmarshal(m []byte) ([]byte, error) {
+	if len(m) < 64 {
+		return nil, errNotEnoughData
+	}
+	if e.p == nil {
+		e.p = &curvePoint{}
+	}
+	if err := e.p.x.unmarshal(m[0:]); err != nil {
+		return nil, err
+	}
+	if err := e.p.y.unmarshal(m[32:]); err != nil {
+		return nil, err
+	}
+	if e.p.x.IsZero() && e.p.y.IsZero() {
+		e.p.SetInfinity()
+		return m[64:], nil
+	}
+	montEncode(&e.p.x, &e.p.x)
+	montEncode(&e.p.y, &e.p.y)
+	e.p.z.SetOne()
+	e.p.t.SetOne()
+
+	if !e.p.IsOnCurve() {
+		return nil, errNotOnCurve
+	}
+	return m[64:], nil
+}
+
+// G2 is an abstract cyclic group. The zero value is suitable for use as the
+// output of an operation, but cannot be used as an input.
+type G2 struct {
+	p *twistPoint
+}
+
+func (e *G2) String() string {
+	return "bn256.G2" + e.p.String()
+}
+
+// ScalarBaseMult sets e to g*k where g is the generator of the group and
+// then returns e.
+func (e *G2) ScalarBaseMult(k *big.Int) *G2 {
+	if e.p == nil {
+		e.p = &twistPoint{}
+	}
+	e.p.Mul(twistGen, k)
+	return e
+}
+
+// ScalarMult sets e to a*k and then returns e.
+func (e *G2) ScalarMult(a *G2, k *big.Int) *G2 {
+	if e.p == nil {
+		e.p = &twistPoint{}
+	}
+	e.p.Mul(a.p, k)
+	return e
+}
+
+// Add sets e to a+b and then returns e.
+func (e *G2) Add(a, b *G2) *G2 {
+	if e.p == nil {
+		e.p = &twistPoint{}
+	}
+	e.p.Add(a.p, b.p)
+	return e
+}
+
+// Neg sets e to -a and then returns e.
+func (e *G2) Neg(a *G2) *G2 {
+	if e.p == nil {
+		e.p = &twistPoint{}
+	}
+	e.p.Neg(a.p)
+	return e
+}
+
+// Marshal converts e into a byte slice of the 32 byte big endian coordinates,
+// ordered x.im, x.re, y.im, y.re. The point at infinity is encoded as all
+// zeroes.
+func (e *G2) Marshal() []byte {
+	out := make([]byte, 128)
+	if e.p == nil || e.p.IsInfinity() {
+		return out
+	}
+	e.p.MakeAffine()
+
+	temp := &gfP{}
+	for i, c := range []*gfP{&e.p.x.x, &e.p.x.y, &e.p.y.x, &e.p.y.y} {
+		montDecode(temp, c)
+		temp.marshal(out[32*i:])
+	}
+	return out
+}
+
+// Unmarshal sets e to the result of converting the output of Marshal back into
+// a group element, returning the unconsumed remainder of m. The point is
+// checked to be on the twist and in the subgroup of order r.
+func (e *G2) Unmarshal(m []byte) ([]byte, error) {
+	if len(m) < 128 {
+		return nil, errNotEnoughData
+	}
+	if e.p == nil {
+		e.p = &twistPoint{}
+	}
+	coords := []*gfP{&e.p.x.x, &e.p.x.y, &e.p.y.x, &e.p.y.y}
+	for i, c := range coords {
+		if err := c.unmarshal(m[32*i:]); err != nil {
+			return nil, err
+		}
+	}
+	if e.p.x.IsZero() && e.p.y.IsZero() {
+		e.p.SetInfinity()
+		return m[128:], nil
+	}
+	for _, c := range coords {
+		montEncode(c, c)
+	}
+	e.p.z.SetOne()
+	e.p.t.SetOne()
+
+	if !e.p.IsOnCurve() {
+		return nil, errNotOnCurve
+	}
+	if !e.p.InSubgroup() {
+		return nil, errNotInSubgroup
+	}
+	return m[128:], nil
+}
+
+// GT is an abstract cyclic group. The zero value is suitable for use as the
+// output of an operation, but cannot be used as an input.
+type GT struct {
+	p *gfP12
+}
+
+func (e *GT) String() string {
+	return "bn256.GT" + e.p.String()
+}
+
+// ScalarMult sets e to a*k and then returns e.
+func (e *GT) ScalarMult(a *GT, k *big.Int) *GT {
+	if e.p == nil {
+		e.p = &gfP12{}
+	}
+	e.p.Exp(a.p, k)
+	return e
+}
+
+// Add sets e to a+b and then returns e. The group operation of GT is the
+// multiplication in Fp12.
+func (e *GT) Add(a, b *GT) *GT {
+	if e.p == nil {
+		e.p = &gfP12{}
+	}
+	e.p.Mul(a.p, b.p)
+	return e
+}
+
+// Marshal converts e into a byte slice of the twelve 32 byte big endian base
+// field coefficients. Writing e = x·ω + y with x and y in Fp6 = Fp2[τ]/(τ³-ξ),
+// the order is x.τ², x.τ, x.1, y.τ², y.τ, y.1, each as im, re.
+func (e *GT) Marshal() []byte {
+	out := make([]byte, 384)
+
+	temp := &gfP{}
+	for i, c := range []*gfP{
+		&e.p.x.x.x, &e.p.x.x.y, &e.p.x.y.x, &e.p.x.y.y, &e.p.x.z.x, &e.p.x.z.y,
+		&e.p.y.x.x, &e.p.y.x.y, &e.p.y.y.x, &e.p.y.y.y, &e.p.y.z.x, &e.p.y.z.y,
+	} {
+		montDecode(temp, c)
+		temp.marshal(out[32*i:])
+	}
+	return out
+}
+
+// Pair calculates the optimal ate pairing.
+func Pair(g1 *G1, g2 *G2) *GT {
+	return &GT{optimalAte(g2.p, g1.p)}
+}
+
+// PairingCheck calculates the optimal ate pairing for a set of points,
+// reporting whether the product of the results equals one.
+func PairingCheck(a []*G1, b []*G2) bool {
+	acc := (&gfP12{}).SetOne()
+	for i := 0; i < len(a); i++ {
+		if a[i].p.IsInfinity() || b[i].p.IsInfinity() {
+			continue
+		}
+		acc.Mul(acc, miller(b[i].p, a[i].p))
+	}
+	return finalExponentiation(acc).IsOne()
+}
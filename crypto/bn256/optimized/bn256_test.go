@@ -0,0 +1,226 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package optimized
+
+import (
+	"bytes"
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/EarthDollar/go-earthdollar/crypto/bn256/generic"
+)
+
+func randomGFp(t *testing.T) *gfP {
+	n, err := rand.Int(rand.Reader, P)
+	if err != nil {
+		t.Fatalf("failed to generate field element: %v", err)
+	}
+	return newGFpFromBig(n)
+}
+
+func TestMontgomeryParams(t *testing.T) {
+	R := new(big.Int).Lsh(big.NewInt(1), 256)
+
+	if have := (&gfP{p2[0], p2[1], p2[2], p2[3]}); new(big.Int).SetBytes(marshalRaw(have)).Cmp(P) != 0 {
+		t.Errorf("modulus mismatch: have %x, want %x", marshalRaw(have), P)
+	}
+	want := new(big.Int).ModInverse(P, new(big.Int).Lsh(big.NewInt(1), 64))
+	want.Sub(new(big.Int).Lsh(big.NewInt(1), 64), want)
+	if want.Uint64() != np {
+		t.Errorf("reduction constant mismatch: have %x, want %x", np, want)
+	}
+	if have := new(big.Int).SetBytes(marshalRaw(rN1)); have.Cmp(new(big.Int).Mod(R, P)) != 0 {
+		t.Errorf("R mismatch: have %x", have)
+	}
+	if have := new(big.Int).SetBytes(marshalRaw(r2)); have.Cmp(new(big.Int).Mod(new(big.Int).Mul(R, R), P)) != 0 {
+		t.Errorf("R² mismatch: have %x", have)
+	}
+	// The p² Frobenius factors must lie in the base field
+	for i, n := range []int64{6, 3} {
+		if c := gfP2Exp(xi, pSquaredMinus1Over(n)); !c.x.IsZero() {
+			t.Errorf("factor %d not in base field: %v", i, c)
+		}
+	}
+}
+
+func marshalRaw(e *gfP) []byte {
+	out := make([]byte, 32)
+	e.marshal(out)
+	return out
+}
+
+// Tests that the base field arithmetic matches math/big, and that the assembly
+// multiplication (if any) matches the portable one.
+func TestGFpArithmetic(t *testing.T) {
+	edge := newGFpFromBig(new(big.Int).Sub(P, big.NewInt(1)))
+	for i := 0; i < 1000; i++ {
+		a, b := randomGFp(t), randomGFp(t)
+		if i == 0 {
+			a, b = edge, edge
+		}
+		x, y := a.big(), b.big()
+
+		c := &gfP{}
+		gfpMul(c, a, b)
+		if want := new(big.Int).Mod(new(big.Int).Mul(x, y), P); c.big().Cmp(want) != 0 {
+			t.Fatalf("%v * %v: have %v, want %v", x, y, c, want)
+		}
+		d := &gfP{}
+		gfpMulGeneric(d, a, b)
+		if *c != *d {
+			t.Fatalf("%v * %v: multiplication mismatch: have %v, want %v", x, y, c, d)
+		}
+		gfpAdd(c, a, b)
+		if want := new(big.Int).Mod(new(big.Int).Add(x, y), P); c.big().Cmp(want) != 0 {
+			t.Fatalf("%v + %v: have %v, want %v", x, y, c, want)
+		}
+		gfpSub(c, a, b)
+		if want := new(big.Int).Mod(new(big.Int).Sub(x, y), P); c.big().Cmp(want) != 0 {
+			t.Fatalf("%v - %v: have %v, want %v", x, y, c, want)
+		}
+		c.Invert(a)
+		if want := new(big.Int).ModInverse(x, P); c.big().Cmp(want) != 0 {
+			t.Fatalf("1 / %v: have %v, want %v", x, c, want)
+		}
+	}
+}
+
+// Tests that the group operations produce the same points as the generic
+// implementation.
+func TestGroupsMatchGeneric(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		a, _ := rand.Int(rand.Reader, Order)
+		b, _ := rand.Int(rand.Reader, Order)
+
+		g1a, g1b := new(G1).ScalarBaseMult(a), new(G1).ScalarBaseMult(b)
+		ref1a, ref1b := new(generic.G1).ScalarBaseMult(a), new(generic.G1).ScalarBaseMult(b)
+		if !bytes.Equal(g1a.Marshal(), ref1a.Marshal()) {
+			t.Fatalf("G1 %v: have %x, want %x", a, g1a.Marshal(), ref1a.Marshal())
+		}
+		if have, want := new(G1).Add(g1a, g1b).Marshal(), new(generic.G1).Add(ref1a, ref1b).Marshal(); !bytes.Equal(have, want) {
+			t.Fatalf("G1 sum: have %x, want %x", have, want)
+		}
+		g2a, g2b := new(G2).ScalarBaseMult(a), new(G2).ScalarBaseMult(b)
+		ref2a, ref2b := new(generic.G2).ScalarBaseMult(a), new(generic.G2).ScalarBaseMult(b)
+		if !bytes.Equal(g2a.Marshal(), ref2a.Marshal()) {
+			t.Fatalf("G2 %v: have %x, want %x", a, g2a.Marshal(), ref2a.Marshal())
+		}
+		if have, want := new(G2).Add(g2a, g2b).Marshal(), new(generic.G2).Add(ref2a, ref2b).Marshal(); !bytes.Equal(have, want) {
+			t.Fatalf("G2 sum: have %x, want %x", have, want)
+		}
+		if have, want := Pair(g1a, g2b).Marshal(), generic.Pair(ref1a, ref2b).Marshal(); !bytes.Equal(have, want) {
+			t.Fatalf("pairing mismatch:\nhave %x\nwant %x", have, want)
+		}
+	}
+	// Doubling and cancellation corner cases
+	g := new(G1).ScalarBaseMult(big.NewInt(42))
+	if have, want := new(G1).Add(g, g).Marshal(), new(G1).ScalarBaseMult(big.NewInt(84)).Marshal(); !bytes.Equal(have, want) {
+		t.Errorf("g + g: have %x, want %x", have, want)
+	}
+	if sum := new(G1).Add(g, new(G1).Neg(g)); !sum.p.IsInfinity() {
+		t.Errorf("g + -g is not infinity: %v", sum)
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	g1 := new(G1).ScalarBaseMult(big.NewInt(12345))
+	g1b := new(G1)
+	if _, err := g1b.Unmarshal(g1.Marshal()); err != nil {
+		t.Fatalf("failed to unmarshal G1: %v", err)
+	}
+	if !bytes.Equal(g1.Marshal(), g1b.Marshal()) {
+		t.Errorf("G1 round trip mismatch: have %v, want %v", g1b, g1)
+	}
+	g2 := new(G2).ScalarBaseMult(big.NewInt(12345))
+	g2b := new(G2)
+	if _, err := g2b.Unmarshal(g2.Marshal()); err != nil {
+		t.Fatalf("failed to unmarshal G2: %v", err)
+	}
+	if !bytes.Equal(g2.Marshal(), g2b.Marshal()) {
+		t.Errorf("G2 round trip mismatch: have %v, want %v", g2b, g2)
+	}
+	// Points off the curve or outside the field must be rejected
+	bad := g1.Marshal()
+	bad[63] ^= 1
+	if _, err := new(G1).Unmarshal(bad); err != errNotOnCurve {
+		t.Errorf("G1 error mismatch: have %v, want %v", err, errNotOnCurve)
+	}
+	bad = g2.Marshal()
+	bad[127] ^= 1
+	if _, err := new(G2).Unmarshal(bad); err != errNotOnCurve {
+		t.Errorf("G2 error mismatch: have %v, want %v", err, errNotOnCurve)
+	}
+	copy(bad, P.Bytes())
+	if _, err := new(G1).Unmarshal(bad); err != errOutsideField {
+		t.Errorf("G1 error mismatch: have %v, want %v", err, errOutsideField)
+	}
+}
+
+func TestBilinearity(t *testing.T) {
+	a, b := big.NewInt(271828), big.NewInt(314159)
+
+	e := Pair(new(G1).ScalarBaseMult(big.NewInt(1)), new(G2).ScalarBaseMult(big.NewInt(1)))
+	if e.p.IsOne() {
+		t.Fatal("pairing is degenerate")
+	}
+	if !new(GT).ScalarMult(e, Order).p.IsOne() {
+		t.Error("pairing result not of order r")
+	}
+	// e(aP, bQ) = e(P, Q)^(ab)
+	have := Pair(new(G1).ScalarBaseMult(a), new(G2).ScalarBaseMult(b))
+	want := new(GT).ScalarMult(e, new(big.Int).Mul(a, b))
+	if !have.p.Equal(want.p) {
+		t.Error("e(aP, bQ) != e(P, Q)^ab")
+	}
+}
+
+func TestPairingCheck(t *testing.T) {
+	a := big.NewInt(1234567)
+
+	p, q := new(G1).ScalarBaseMult(big.NewInt(1)), new(G2).ScalarBaseMult(big.NewInt(1))
+	ap, aq := new(G1).ScalarBaseMult(a), new(G2).ScalarBaseMult(a)
+
+	// e(aP, Q)·e(-P, aQ) = 1
+	if !PairingCheck([]*G1{ap, new(G1).Neg(p)}, []*G2{q, aq}) {
+		t.Error("valid pairing check failed")
+	}
+	if PairingCheck([]*G1{ap, p}, []*G2{q, aq}) {
+		t.Error("invalid pairing check succeeded")
+	}
+	if !PairingCheck(nil, nil) {
+		t.Error("empty pairing check failed")
+	}
+}
+
+func BenchmarkPairing(b *testing.B) {
+	p, q := new(G1).ScalarBaseMult(big.NewInt(1)), new(G2).ScalarBaseMult(big.NewInt(1))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		Pair(p, q)
+	}
+}
+
+func BenchmarkPairingGeneric(b *testing.B) {
+	p, q := new(generic.G1).ScalarBaseMult(big.NewInt(1)), new(generic.G2).ScalarBaseMult(big.NewInt(1))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		generic.Pair(p, q)
+	}
+}
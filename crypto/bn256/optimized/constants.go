@@ -0,0 +1,105 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package optimized
+
+import "math/big"
+
+func bigFromBase10(s string) *big.Int {
+	n, _ := new(big.Int).SetString(s, 10)
+	return n
+}
+
+// u is the BN parameter that determines the prime.
+var u = bigFromBase10("4965661367192848881")
+
+// P is a prime over which we form a basic field: 36u⁴+36u³+24u²+6u+1.
+var P = bigFromBase10("21888242871839275222246405745257275088696311157297823662689037894645226208583")
+
+// Order is the number of elements in both G₁ and G₂: 36u⁴+36u³+18u²+6u+1.
+var Order = bigFromBase10("21888242871839275222246405745257275088548364400416034343698204186575808495617")
+
+// sixuPlus2NAF is 6u+2 in non-adjacent form, least significant digit first.
+var sixuPlus2NAF = func() []int8 {
+	n := new(big.Int).Add(new(big.Int).Mul(big.NewInt(6), u), big.NewInt(2))
+
+	var naf []int8
+	for n.Sign() > 0 {
+		digit := int8(0)
+		if n.Bit(0) == 1 {
+			// Pick the digit leaving a multiple of four behind
+			digit = 2 - int8(n.Bits()[0]&3)
+			n.Sub(n, big.NewInt(int64(digit)))
+		}
+		naf = append(naf, digit)
+		n.Rsh(n, 1)
+	}
+	return naf
+}()
+
+var (
+	// xi is the non-residue defining the degree six extension, ξ = i+9.
+	xi = &gfP2{x: *newGFp(1), y: *newGFp(9)}
+
+	// xiToPMinus1Over6 is ξ^((p-1)/6), the p power Frobenius factor of ω.
+	xiToPMinus1Over6 = gfP2Exp(xi, pMinus1Over(6))
+
+	// xiToPMinus1Over3 is ξ^((p-1)/3), the p power Frobenius factor of τ.
+	xiToPMinus1Over3 = gfP2Exp(xi, pMinus1Over(3))
+
+	// xiToPMinus1Over2 is ξ^((p-1)/2), the p power Frobenius factor of ω³.
+	xiToPMinus1Over2 = gfP2Exp(xi, pMinus1Over(2))
+
+	// xiTo2PMinus2Over3 is ξ^((2p-2)/3), the p power Frobenius factor of τ².
+	xiTo2PMinus2Over3 = gfP2Exp(xi, new(big.Int).Lsh(pMinus1Over(3), 1))
+
+	// xiToPSquaredMinus1Over6 is ξ^((p²-1)/6), the p² power Frobenius factor
+	// of ω. It lies in the base field.
+	xiToPSquaredMinus1Over6 = &gfP2Exp(xi, pSquaredMinus1Over(6)).y
+
+	// xiToPSquaredMinus1Over3 is ξ^((p²-1)/3), the p² power Frobenius factor
+	// of τ. It lies in the base field.
+	xiToPSquaredMinus1Over3 = &gfP2Exp(xi, pSquaredMinus1Over(3)).y
+
+	// xiTo2PSquaredMinus2Over3 is ξ^((2p²-2)/3), the p² power Frobenius factor
+	// of τ². It lies in the base field.
+	xiTo2PSquaredMinus2Over3 = &gfP2Exp(xi, new(big.Int).Lsh(pSquaredMinus1Over(3), 1)).y
+)
+
+// pMinus1Over returns (p-1)/n.
+func pMinus1Over(n int64) *big.Int {
+	e := new(big.Int).Sub(P, big.NewInt(1))
+	return e.Div(e, big.NewInt(n))
+}
+
+// pSquaredMinus1Over returns (p²-1)/n.
+func pSquaredMinus1Over(n int64) *big.Int {
+	e := new(big.Int).Mul(P, P)
+	e.Sub(e, big.NewInt(1))
+	return e.Div(e, big.NewInt(n))
+}
+
+// gfP2Exp returns a^k, used to derive the constants above.
+func gfP2Exp(a *gfP2, k *big.Int) *gfP2 {
+	sum := (&gfP2{}).SetOne()
+	for i := k.BitLen() - 1; i >= 0; i-- {
+		sum.Square(sum)
+		if k.Bit(i) == 1 {
+			sum.Mul(sum, a)
+		}
+	}
+	return sum
+}
@@ -0,0 +1,248 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package optimized
+
+import (
+	"errors"
+	"math/big"
+)
+
+// gfP implements the base field Fp. Elements are kept in Montgomery form as
+// four little endian 64 bit limbs, always fully reduced modulo p.
+type gfP [4]uint64
+
+var (
+	// p2 is the field modulus p in limbs.
+	p2 = [4]uint64{0x3c208c16d87cfd47, 0x97816a916871ca8d, 0xb85045b68181585d, 0x30644e72e131a029}
+
+	// np is -p⁻¹ mod 2⁶⁴, the Montgomery reduction constant.
+	np uint64 = 0x87d20782e4866389
+
+	// rN1 is R mod p with R = 2²⁵⁶, i.e. the Montgomery form of one.
+	rN1 = &gfP{0xd35d438dc58f0d9d, 0x0a78eb28f5c70b3d, 0x666ea36f7879462c, 0x0e0a77c19a07df2f}
+
+	// r2 is R² mod p, used to convert into Montgomery form.
+	r2 = &gfP{0xf32cfc5b538afa89, 0xb5e71911d44501fb, 0x47ab1eff0a417ff6, 0x06d89f71cab8351f}
+)
+
+var errOutsideField = errors.New("bn256: coordinate exceeds modulus")
+
+// newGFp returns the Montgomery form of a small integer.
+func newGFp(x int64) *gfP {
+	out := &gfP{}
+	if x >= 0 {
+		out[0] = uint64(x)
+	} else {
+		out[0] = uint64(-x)
+	}
+	montEncode(out, out)
+	if x < 0 {
+		gfpNeg(out, out)
+	}
+	return out
+}
+
+// newGFpFromBig returns the Montgomery form of a number below p.
+func newGFpFromBig(x *big.Int) *gfP {
+	out := &gfP{}
+	words := x.Bits()
+	for i := 0; i < len(words) && i < len(out); i++ {
+		out[i] = uint64(words[i])
+	}
+	montEncode(out, out)
+	return out
+}
+
+func (e *gfP) String() string {
+	return e.big().String()
+}
+
+// big converts e out of Montgomery form into a big integer.
+func (e *gfP) big() *big.Int {
+	dec := &gfP{}
+	montDecode(dec, e)
+
+	var buf [32]byte
+	dec.marshal(buf[:])
+	return new(big.Int).SetBytes(buf[:])
+}
+
+func (e *gfP) Set(f *gfP) *gfP {
+	e[0], e[1], e[2], e[3] = f[0], f[1], f[2], f[3]
+	return e
+}
+
+func (e *gfP) SetZero() *gfP {
+	e[0], e[1], e[2], e[3] = 0, 0, 0, 0
+	return e
+}
+
+func (e *gfP) SetOne() *gfP {
+	return e.Set(rN1)
+}
+
+func (e *gfP) IsZero() bool {
+	return e[0]|e[1]|e[2]|e[3] == 0
+}
+
+func (e *gfP) Equal(f *gfP) bool {
+	return *e == *f
+}
+
+// Invert sets e to f⁻¹ using Fermat's little theorem and returns e.
+func (e *gfP) Invert(f *gfP) *gfP {
+	exp := [4]uint64{p2[0] - 2, p2[1], p2[2], p2[3]}
+
+	sum, power := &gfP{}, &gfP{}
+	sum.Set(rN1)
+	power.Set(f)
+
+	for word := 0; word < 4; word++ {
+		for bit := uint(0); bit < 64; bit++ {
+			if (exp[word]>>bit)&1 == 1 {
+				gfpMul(sum, sum, power)
+			}
+			gfpMul(power, power, power)
+		}
+	}
+	return e.Set(sum)
+}
+
+// marshal writes the raw limbs of e as a 32 byte big endian number into out.
+func (e *gfP) marshal(out []byte) {
+	for w := uint(0); w < 4; w++ {
+		for b := uint(0); b < 8; b++ {
+			out[8*w+b] = byte(e[3-w] >> (56 - 8*b))
+		}
+	}
+}
+
+// unmarshal reads a 32 byte big endian number into the raw limbs of e,
+// failing if it is not below p.
+func (e *gfP) unmarshal(in []byte) error {
+	for w := uint(0); w < 4; w++ {
+		e[3-w] = 0
+		for b := uint(0); b < 8; b++ {
+			e[3-w] += uint64(in[8*w+b]) << (56 - 8*b)
+		}
+	}
+	for i := 3; i >= 0; i-- {
+		if e[i] < p2[i] {
+			return nil
+		}
+		if e[i] > p2[i] {
+			return errOutsideField
+		}
+	}
+	return errOutsideField
+}
+
+// montEncode converts a into Montgomery form.
+func montEncode(c, a *gfP) { gfpMul(c, a, r2) }
+
+// montDecode converts a out of Montgomery form.
+func montDecode(c, a *gfP) { gfpMul(c, a, &gfP{1}) }
+
+// gfpCarry subtracts p from the five limb value (a, head) if it is not below
+// p, leaving a fully reduced result in a.
+func gfpCarry(a *gfP, head uint64) {
+	var b gfP
+	var borrow uint64
+	b[0], borrow = sub64(a[0], p2[0], 0)
+	b[1], borrow = sub64(a[1], p2[1], borrow)
+	b[2], borrow = sub64(a[2], p2[2], borrow)
+	b[3], borrow = sub64(a[3], p2[3], borrow)
+	_, borrow = sub64(head, 0, borrow)
+
+	if borrow == 0 {
+		*a = b
+	}
+}
+
+func gfpNeg(c, a *gfP) {
+	if a.IsZero() {
+		c.SetZero()
+		return
+	}
+	var borrow uint64
+	c[0], borrow = sub64(p2[0], a[0], 0)
+	c[1], borrow = sub64(p2[1], a[1], borrow)
+	c[2], borrow = sub64(p2[2], a[2], borrow)
+	c[3], _ = sub64(p2[3], a[3], borrow)
+}
+
+func gfpAdd(c, a, b *gfP) {
+	var carry uint64
+	c[0], carry = add64(a[0], b[0], 0)
+	c[1], carry = add64(a[1], b[1], carry)
+	c[2], carry = add64(a[2], b[2], carry)
+	c[3], carry = add64(a[3], b[3], carry)
+	gfpCarry(c, carry)
+}
+
+func gfpSub(c, a, b *gfP) {
+	var borrow uint64
+	c[0], borrow = sub64(a[0], b[0], 0)
+	c[1], borrow = sub64(a[1], b[1], borrow)
+	c[2], borrow = sub64(a[2], b[2], borrow)
+	c[3], borrow = sub64(a[3], b[3], borrow)
+
+	if borrow != 0 {
+		var carry uint64
+		c[0], carry = add64(c[0], p2[0], 0)
+		c[1], carry = add64(c[1], p2[1], carry)
+		c[2], carry = add64(c[2], p2[2], carry)
+		c[3], _ = add64(c[3], p2[3], carry)
+	}
+}
+
+// gfpMulGeneric sets c to the Montgomery product a·b·R⁻¹ mod p, interleaving
+// the multiplication with the reduction (CIOS). It is the portable version of
+// gfpMul, which on some platforms is implemented in assembly.
+func gfpMulGeneric(c, a, b *gfP) {
+	var t [6]uint64
+	for i := 0; i < 4; i++ {
+		// t += a·b[i]
+		var carry uint64
+		for j := 0; j < 4; j++ {
+			hi, lo := mul64(a[j], b[i])
+			var c1, c2 uint64
+			lo, c1 = add64(lo, t[j], 0)
+			lo, c2 = add64(lo, carry, 0)
+			t[j], carry = lo, hi+c1+c2
+		}
+		t[4], t[5] = add64(t[4], carry, 0)
+
+		// t = (t + m·p) / 2⁶⁴, with m chosen to clear the lowest limb
+		m := t[0] * np
+		hi, lo := mul64(m, p2[0])
+		_, c1 := add64(lo, t[0], 0)
+		carry = hi + c1
+		for j := 1; j < 4; j++ {
+			hi, lo := mul64(m, p2[j])
+			var c1, c2 uint64
+			lo, c1 = add64(lo, t[j], 0)
+			lo, c2 = add64(lo, carry, 0)
+			t[j-1], carry = lo, hi+c1+c2
+		}
+		var c2 uint64
+		t[3], c2 = add64(t[4], carry, 0)
+		t[4] = t[5] + c2
+	}
+	c[0], c[1], c[2], c[3] = t[0], t[1], t[2], t[3]
+	gfpCarry(c, t[4])
+}
@@ -0,0 +1,115 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// +build amd64,!appengine,!gccgo
+
+#include "textflag.h"
+
+// The Montgomery multiplication keeps the running sum t in R8-R13 (lowest
+// limb first) and the carry between limbs in CX. It is the same algorithm as
+// gfpMulGeneric.
+
+// MULLIMB adds bi·aj plus the carry to tj, leaving the new carry in CX.
+#define MULLIMB(bi, aj, tj) \
+	MOVQ bi, AX; \
+	MULQ aj; \
+	ADDQ CX, AX; \
+	ADCQ $0, DX; \
+	ADDQ AX, tj; \
+	ADCQ $0, DX; \
+	MOVQ DX, CX
+
+// MULROW adds a·bi to t.
+#define MULROW(bi) \
+	XORQ CX, CX; \
+	MULLIMB(bi, 0(SI), R8); \
+	MULLIMB(bi, 8(SI), R9); \
+	MULLIMB(bi, 16(SI), R10); \
+	MULLIMB(bi, 24(SI), R11); \
+	XORQ R13, R13; \
+	ADDQ CX, R12; \
+	ADCQ $0, R13
+
+// REDLIMB adds m·pj plus the carry to tj, storing the result one limb lower
+// in tk and leaving the new carry in CX.
+#define REDLIMB(pj, tj, tk) \
+	MOVQ R14, AX; \
+	MULQ pj; \
+	ADDQ CX, AX; \
+	ADCQ $0, DX; \
+	ADDQ tj, AX; \
+	ADCQ $0, DX; \
+	MOVQ AX, tk; \
+	MOVQ DX, CX
+
+// REDUCE sets t to (t + m·p) / 2⁶⁴ with m = t0·np, clearing the lowest limb.
+#define REDUCE \
+	MOVQ ·np(SB), R14; \
+	IMULQ R8, R14; \
+	MOVQ R14, AX; \
+	MULQ ·p2+0(SB); \
+	ADDQ R8, AX; \
+	ADCQ $0, DX; \
+	MOVQ DX, CX; \
+	REDLIMB(·p2+8(SB), R9, R8); \
+	REDLIMB(·p2+16(SB), R10, R9); \
+	REDLIMB(·p2+24(SB), R11, R10); \
+	ADDQ R12, CX; \
+	ADCQ $0, R13; \
+	MOVQ CX, R11; \
+	MOVQ R13, R12
+
+// func gfpMul(c, a, b *gfP)
+TEXT ·gfpMul(SB), NOSPLIT, $0-24
+	MOVQ a+8(FP), SI
+	MOVQ b+16(FP), BX
+
+	XORQ R8, R8
+	XORQ R9, R9
+	XORQ R10, R10
+	XORQ R11, R11
+	XORQ R12, R12
+
+	MULROW(0(BX))
+	REDUCE
+	MULROW(8(BX))
+	REDUCE
+	MULROW(16(BX))
+	REDUCE
+	MULROW(24(BX))
+	REDUCE
+
+	// Subtract p once if the result is not fully reduced
+	MOVQ R8, AX
+	MOVQ R9, BX
+	MOVQ R10, CX
+	MOVQ R11, DX
+	SUBQ ·p2+0(SB), AX
+	SBBQ ·p2+8(SB), BX
+	SBBQ ·p2+16(SB), CX
+	SBBQ ·p2+24(SB), DX
+	SBBQ $0, R12
+	CMOVQCC AX, R8
+	CMOVQCC BX, R9
+	CMOVQCC CX, R10
+	CMOVQCC DX, R11
+
+	MOVQ c+0(FP), DI
+	MOVQ R8, 0(DI)
+	MOVQ R9, 8(DI)
+	MOVQ R10, 16(DI)
+	MOVQ R11, 24(DI)
+	RET
@@ -1,4 +1,4 @@
-// Copyright 2014 The go-ethereum Authors
+// Copyright 2016 The go-ethereum Authors
 // This file is part of the go-ethereum library.
 //
 // The go-ethereum library is free software: you can redistribute it and/or modify
@@ -14,10 +14,11 @@
 // You should have received a copy of the GNU Lesser General Public License
 // along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
 
-package core
+// +build !amd64 appengine gccgo
 
-import (
-	"math/big"
-)
+package optimized
 
-var BlockReward *big.Int = big.NewInt(5e+18)
+// gfpMul sets c to the Montgomery product a·b·R⁻¹ mod p.
+func gfpMul(c, a, b *gfP) {
+	gfpMulGeneric(c, a, b)
+}
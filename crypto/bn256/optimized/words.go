@@ -0,0 +1,52 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package optimized
+
+// This file contains portable versions of the double word primitives the field
+// arithmetic is built on. They mirror their math/bits counterparts, which are
+// not available on all supported Go versions.
+
+// add64 returns the sum with carry of x, y and carry. The carry input must be
+// 0 or 1, the carry output is guaranteed to be 0 or 1.
+func add64(x, y, carry uint64) (sum, carryOut uint64) {
+	sum = x + y + carry
+	carryOut = ((x & y) | ((x | y) &^ sum)) >> 63
+	return
+}
+
+// sub64 returns the difference of x, y and borrow. The borrow input must be
+// 0 or 1, the borrow output is guaranteed to be 0 or 1.
+func sub64(x, y, borrow uint64) (diff, borrowOut uint64) {
+	diff = x - y - borrow
+	borrowOut = ((^x & y) | (^(x ^ y) & diff)) >> 63
+	return
+}
+
+// mul64 returns the 128-bit product of x and y as its high and low halves.
+func mul64(x, y uint64) (hi, lo uint64) {
+	const mask32 = 1<<32 - 1
+
+	x0, x1 := x&mask32, x>>32
+	y0, y1 := y&mask32, y>>32
+
+	w0 := x0 * y0
+	t := x1*y0 + w0>>32
+	w1, w2 := t&mask32, t>>32
+	w1 += x0 * y1
+
+	return x1*y1 + w2 + w1>>32, x * y
+}
@@ -0,0 +1,137 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package secp256k1 implements signing, public key recovery and the curve
+// arithmetic of the secp256k1 elliptic curve.
+//
+// If the package is built with cgo, the operations are backed by the bitcoin
+// secp256k1 C library, otherwise (or if disabled via UseNative) by a slower
+// pure Go implementation.
+package secp256k1
+
+import (
+	"errors"
+	"math/big"
+	"unsafe"
+)
+
+var (
+	N, _ = new(big.Int).SetString("fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364141", 16)
+	// N / 2 == 57896044618658097711785492504343953926418782139537452191302581570759080747168
+	HalfN, _ = new(big.Int).SetString("7fffffffffffffffffffffffffffffff5d576e7357a4501ddfe92f46681b20a0", 16)
+)
+
+var (
+	ErrInvalidMsgLen       = errors.New("invalid message length, need 32 bytes")
+	ErrInvalidSignatureLen = errors.New("invalid signature length")
+	ErrInvalidRecoveryID   = errors.New("invalid signature recovery id")
+	ErrInvalidKey          = errors.New("invalid private key")
+	ErrSignFailed          = errors.New("signing failed")
+	ErrRecoverFailed       = errors.New("recovery failed")
+)
+
+// backend is an implementation of the curve operations. The inputs are
+// validated for length before any of the methods is invoked.
+type backend interface {
+	// sign creates a recoverable signature of the 32 byte msg.
+	sign(msg []byte, seckey []byte) ([]byte, error)
+
+	// recoverPubkey returns the 65 byte uncompressed public key of the signer.
+	recoverPubkey(msg []byte, sig []byte) ([]byte, error)
+
+	// scalarMult multiplies the 64 byte point by the 32 byte scalar in place,
+	// reporting false if the scalar is zero or overflows the group order.
+	scalarMult(point, scalar []byte) bool
+}
+
+var (
+	nativeImpl backend               // libsecp256k1 backend, nil if built without cgo
+	impl       backend = goBackend{} // backend currently in use
+)
+
+// UseNative selects whether the libsecp256k1 C library or the pure Go
+// implementation is used from now on, returning whether the C library was
+// picked. The C library is only available if the package was built with cgo,
+// and is used by default in that case.
+//
+// UseNative is meant to be called at startup; it is not safe to switch the
+// backend while other goroutines are signing or recovering keys.
+func UseNative(enable bool) bool {
+	if enable && nativeImpl != nil {
+		impl = nativeImpl
+	} else {
+		impl = goBackend{}
+	}
+	return Native()
+}
+
+// Native reports whether the libsecp256k1 C library is in use.
+func Native() bool {
+	return nativeImpl != nil && impl == nativeImpl
+}
+
+// Sign creates a recoverable ECDSA signature.
+// The produced signature is in the 65-byte [R || S || V] format where V is 0 or 1.
+//
+// The caller is responsible for ensuring that msg cannot be chosen
+// directly by an attacker. It is usually preferable to use a cryptographic
+// hash function on any input before handing it to this function.
+func Sign(msg []byte, seckey []byte) ([]byte, error) {
+	if len(msg) != 32 {
+		return nil, ErrInvalidMsgLen
+	}
+	if len(seckey) != 32 {
+		return nil, ErrInvalidKey
+	}
+	return impl.sign(msg, seckey)
+}
+
+// RecoverPubkey returns the the public key of the signer.
+// msg must be the 32-byte hash of the message to be signed.
+// sig must be a 65-byte compact ECDSA signature containing the
+// recovery id as the last element.
+func RecoverPubkey(msg []byte, sig []byte) ([]byte, error) {
+	if len(msg) != 32 {
+		return nil, ErrInvalidMsgLen
+	}
+	if err := checkSignature(sig); err != nil {
+		return nil, err
+	}
+	return impl.recoverPubkey(msg, sig)
+}
+
+func checkSignature(sig []byte) error {
+	if len(sig) != 65 {
+		return ErrInvalidSignatureLen
+	}
+	if sig[64] >= 4 {
+		return ErrInvalidRecoveryID
+	}
+	return nil
+}
+
+// reads num into buf as big-endian bytes.
+func readBits(buf []byte, num *big.Int) {
+	const wordLen = int(unsafe.Sizeof(big.Word(0)))
+	i := len(buf)
+	for _, d := range num.Bits() {
+		for j := 0; j < wordLen && i > 0; j++ {
+			i--
+			buf[i] = byte(d)
+			d >>= 8
+		}
+	}
+}
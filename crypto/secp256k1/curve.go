@@ -35,15 +35,8 @@ import (
 	"crypto/elliptic"
 	"math/big"
 	"sync"
-	"unsafe"
 )
 
-/*
-#include "libsecp256k1/include/secp256k1.h"
-extern int secp256k1_pubkey_scalar_mul(const secp256k1_context* ctx, const unsigned char *point, const unsigned char *scalar);
-*/
-import "C"
-
 // This code is from https://github.com/ThePiachu/GoBit and implements
 // several Koblitz elliptic curves over prime fields.
 //
@@ -228,13 +221,11 @@ func (BitCurve *BitCurve) ScalarMult(Bx, By *big.Int, scalar []byte) (*big.Int,
 	copy(padded[32-len(scalar):], scalar)
 	scalar = padded
 
-	// Do the multiplication in C, updating point.
+	// Do the multiplication in the active backend, updating point.
 	point := make([]byte, 64)
 	readBits(point[:32], Bx)
 	readBits(point[32:], By)
-	pointPtr := (*C.uchar)(unsafe.Pointer(&point[0]))
-	scalarPtr := (*C.uchar)(unsafe.Pointer(&scalar[0]))
-	res := C.secp256k1_pubkey_scalar_mul(context, pointPtr, scalarPtr)
+	ok := impl.scalarMult(point, scalar)
 
 	// Unpack the result and clear temporaries.
 	x := new(big.Int).SetBytes(point[:32])
@@ -245,7 +236,7 @@ func (BitCurve *BitCurve) ScalarMult(Bx, By *big.Int, scalar []byte) (*big.Int,
 	for i := range padded {
 		scalar[i] = 0
 	}
-	if res != 1 {
+	if !ok {
 		return nil, nil
 	}
 	return x, y
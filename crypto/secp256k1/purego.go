@@ -0,0 +1,377 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package secp256k1
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"math/big"
+)
+
+// goBackend implements the curve operations in pure Go. It is considerably
+// slower than the C library, but has no dependency on cgo.
+type goBackend struct{}
+
+// jacobianPoint is a curve point in Jacobian coordinates (x/z², y/z³). The
+// point at infinity is represented by z = 0.
+type jacobianPoint struct {
+	x, y, z *big.Int
+}
+
+func newJacobian(x, y *big.Int) *jacobianPoint {
+	return &jacobianPoint{new(big.Int).Set(x), new(big.Int).Set(y), big.NewInt(1)}
+}
+
+func (p *jacobianPoint) isInfinity() bool {
+	return p.z.Sign() == 0
+}
+
+// affine converts the point back into affine coordinates.
+func (p *jacobianPoint) affine() (x, y *big.Int) {
+	P := S256().P
+	zinv := new(big.Int).ModInverse(p.z, P)
+	zinv2 := new(big.Int).Mul(zinv, zinv)
+
+	x = new(big.Int).Mul(p.x, zinv2)
+	x.Mod(x, P)
+	y = zinv2.Mul(zinv2, zinv)
+	y.Mul(y, p.y)
+	y.Mod(y, P)
+	return x, y
+}
+
+// double returns 2*p.
+func (p *jacobianPoint) double() *jacobianPoint {
+	P := S256().P
+	if p.isInfinity() || p.y.Sign() == 0 {
+		return &jacobianPoint{new(big.Int), new(big.Int), new(big.Int)}
+	}
+	// See http://hyperelliptic.org/EFD/g1p/auto-shortw-jacobian-0.html#doubling-dbl-2009-l
+	a := new(big.Int).Mul(p.x, p.x)
+	b := new(big.Int).Mul(p.y, p.y)
+	b.Mod(b, P)
+	c := new(big.Int).Mul(b, b)
+
+	d := new(big.Int).Add(p.x, b)
+	d.Mul(d, d)
+	d.Sub(d, a)
+	d.Sub(d, c)
+	d.Lsh(d, 1)
+	d.Mod(d, P)
+
+	e := new(big.Int).Mul(big.NewInt(3), a)
+	e.Mod(e, P)
+	f := new(big.Int).Mul(e, e)
+
+	x3 := new(big.Int).Lsh(d, 1)
+	x3.Sub(f, x3)
+	x3.Mod(x3, P)
+
+	y3 := new(big.Int).Sub(d, x3)
+	y3.Mul(y3, e)
+	y3.Sub(y3, c.Lsh(c, 3))
+	y3.Mod(y3, P)
+
+	z3 := new(big.Int).Mul(p.y, p.z)
+	z3.Lsh(z3, 1)
+	z3.Mod(z3, P)
+
+	return &jacobianPoint{x3, y3, z3}
+}
+
+// add returns p+q.
+func (p *jacobianPoint) add(q *jacobianPoint) *jacobianPoint {
+	P := S256().P
+	if p.isInfinity() {
+		return q
+	}
+	if q.isInfinity() {
+		return p
+	}
+	// See http://hyperelliptic.org/EFD/g1p/auto-shortw-jacobian-0.html#addition-add-2007-bl
+	z1z1 := new(big.Int).Mul(p.z, p.z)
+	z1z1.Mod(z1z1, P)
+	z2z2 := new(big.Int).Mul(q.z, q.z)
+	z2z2.Mod(z2z2, P)
+
+	u1 := new(big.Int).Mul(p.x, z2z2)
+	u1.Mod(u1, P)
+	u2 := new(big.Int).Mul(q.x, z1z1)
+	u2.Mod(u2, P)
+
+	s1 := new(big.Int).Mul(p.y, q.z)
+	s1.Mul(s1, z2z2)
+	s1.Mod(s1, P)
+	s2 := new(big.Int).Mul(q.y, p.z)
+	s2.Mul(s2, z1z1)
+	s2.Mod(s2, P)
+
+	h := new(big.Int).Sub(u2, u1)
+	h.Mod(h, P)
+	r := new(big.Int).Sub(s2, s1)
+	r.Mod(r, P)
+	if h.Sign() == 0 {
+		if r.Sign() == 0 {
+			return p.double()
+		}
+		return &jacobianPoint{new(big.Int), new(big.Int), new(big.Int)}
+	}
+	r.Lsh(r, 1)
+
+	i := new(big.Int).Lsh(h, 1)
+	i.Mul(i, i)
+	i.Mod(i, P)
+	j := new(big.Int).Mul(h, i)
+	j.Mod(j, P)
+	v := new(big.Int).Mul(u1, i)
+	v.Mod(v, P)
+
+	x3 := new(big.Int).Mul(r, r)
+	x3.Sub(x3, j)
+	x3.Sub(x3, v)
+	x3.Sub(x3, v)
+	x3.Mod(x3, P)
+
+	y3 := new(big.Int).Sub(v, x3)
+	y3.Mul(y3, r)
+	s1.Mul(s1, j)
+	s1.Lsh(s1, 1)
+	y3.Sub(y3, s1)
+	y3.Mod(y3, P)
+
+	z3 := new(big.Int).Add(p.z, q.z)
+	z3.Mul(z3, z3)
+	z3.Sub(z3, z1z1)
+	z3.Sub(z3, z2z2)
+	z3.Mul(z3, h)
+	z3.Mod(z3, P)
+
+	return &jacobianPoint{x3, y3, z3}
+}
+
+// mulAdd computes k1*p1 + k2*p2 with a joint double-and-add pass.
+func mulAdd(p1 *jacobianPoint, k1 *big.Int, p2 *jacobianPoint, k2 *big.Int) *jacobianPoint {
+	var (
+		sum = p1.add(p2)
+		res = &jacobianPoint{new(big.Int), new(big.Int), new(big.Int)}
+	)
+	bits := k1.BitLen()
+	if k2.BitLen() > bits {
+		bits = k2.BitLen()
+	}
+	for i := bits - 1; i >= 0; i-- {
+		res = res.double()
+		switch b1, b2 := k1.Bit(i), k2.Bit(i); {
+		case b1 == 1 && b2 == 1:
+			res = res.add(sum)
+		case b1 == 1:
+			res = res.add(p1)
+		case b2 == 1:
+			res = res.add(p2)
+		}
+	}
+	return res
+}
+
+// mul computes k*p.
+func mul(p *jacobianPoint, k *big.Int) *jacobianPoint {
+	res := &jacobianPoint{new(big.Int), new(big.Int), new(big.Int)}
+	for i := k.BitLen() - 1; i >= 0; i-- {
+		res = res.double()
+		if k.Bit(i) == 1 {
+			res = res.add(p)
+		}
+	}
+	return res
+}
+
+func (goBackend) sign(msg []byte, seckey []byte) ([]byte, error) {
+	curve := S256()
+
+	d := new(big.Int).SetBytes(seckey)
+	if d.Sign() == 0 || d.Cmp(curve.N) >= 0 {
+		return nil, ErrInvalidKey
+	}
+	e := new(big.Int).SetBytes(msg)
+	e.Mod(e, curve.N)
+
+	nonces := newNonceGenerator(seckey, e)
+	for {
+		k := nonces.next()
+
+		// R = k*G, r = R.x mod N
+		rx, ry := mul(newJacobian(curve.Gx, curve.Gy), k).affine()
+		r := new(big.Int).Mod(rx, curve.N)
+		if r.Sign() == 0 {
+			continue
+		}
+		// s = k⁻¹(e + r*d) mod N
+		s := new(big.Int).Mul(r, d)
+		s.Add(s, e)
+		s.Mul(s, new(big.Int).ModInverse(k, curve.N))
+		s.Mod(s, curve.N)
+		if s.Sign() == 0 {
+			continue
+		}
+		recid := byte(ry.Bit(0))
+		if rx.Cmp(curve.N) >= 0 {
+			recid |= 2
+		}
+		// Only produce lower S values, like the C library
+		if s.Cmp(HalfN) > 0 {
+			s.Sub(curve.N, s)
+			recid ^= 1
+		}
+		sig := make([]byte, 65)
+		readBits(sig[:32], r)
+		readBits(sig[32:64], s)
+		sig[64] = recid
+		return sig, nil
+	}
+}
+
+func (goBackend) recoverPubkey(msg []byte, sig []byte) ([]byte, error) {
+	curve := S256()
+
+	r := new(big.Int).SetBytes(sig[:32])
+	s := new(big.Int).SetBytes(sig[32:64])
+	if r.Sign() == 0 || r.Cmp(curve.N) >= 0 || s.Sign() == 0 || s.Cmp(curve.N) >= 0 {
+		return nil, ErrRecoverFailed
+	}
+	// Reconstruct the point R from its x coordinate and the recovery id
+	x := new(big.Int).Set(r)
+	if sig[64]&2 != 0 {
+		x.Add(x, curve.N)
+		if x.Cmp(curve.P) >= 0 {
+			return nil, ErrRecoverFailed
+		}
+	}
+	y := decompressY(x, sig[64]&1)
+	if y == nil {
+		return nil, ErrRecoverFailed
+	}
+	// Q = r⁻¹(s*R - e*G)
+	e := new(big.Int).SetBytes(msg)
+	e.Mod(e, curve.N)
+
+	rinv := new(big.Int).ModInverse(r, curve.N)
+	u1 := new(big.Int).Mul(e, rinv)
+	u1.Neg(u1)
+	u1.Mod(u1, curve.N)
+	u2 := new(big.Int).Mul(s, rinv)
+	u2.Mod(u2, curve.N)
+
+	q := mulAdd(newJacobian(curve.Gx, curve.Gy), u1, newJacobian(x, y), u2)
+	if q.isInfinity() {
+		return nil, ErrRecoverFailed
+	}
+	qx, qy := q.affine()
+	return curve.Marshal(qx, qy), nil
+}
+
+func (goBackend) scalarMult(point, scalar []byte) bool {
+	curve := S256()
+
+	k := new(big.Int).SetBytes(scalar)
+	if k.Sign() == 0 || k.Cmp(curve.N) >= 0 {
+		return false
+	}
+	p := mul(newJacobian(new(big.Int).SetBytes(point[:32]), new(big.Int).SetBytes(point[32:])), k)
+	if p.isInfinity() {
+		for i := range point {
+			point[i] = 0
+		}
+		return true
+	}
+	x, y := p.affine()
+	for i := range point {
+		point[i] = 0
+	}
+	readBits(point[:32], x)
+	readBits(point[32:], y)
+	return true
+}
+
+// decompressY returns the y coordinate of the curve point with the given x
+// coordinate and parity, or nil if x isn't on the curve.
+func decompressY(x *big.Int, odd byte) *big.Int {
+	curve := S256()
+
+	// y² = x³ + 7
+	y2 := new(big.Int).Mul(x, x)
+	y2.Mul(y2, x)
+	y2.Add(y2, curve.B)
+	y2.Mod(y2, curve.P)
+
+	// P = 3 mod 4, so the square root is y2^((P+1)/4)
+	exp := new(big.Int).Add(curve.P, big.NewInt(1))
+	exp.Rsh(exp, 2)
+	y := new(big.Int).Exp(y2, exp, curve.P)
+	if new(big.Int).Exp(y, big.NewInt(2), curve.P).Cmp(y2) != 0 {
+		return nil
+	}
+	if byte(y.Bit(0)) != odd {
+		y.Sub(curve.P, y)
+	}
+	return y
+}
+
+// nonceGenerator derives the deterministic signing nonces of RFC 6979 with
+// HMAC-SHA256.
+type nonceGenerator struct {
+	k, v []byte
+}
+
+func newNonceGenerator(seckey []byte, e *big.Int) *nonceGenerator {
+	hash := make([]byte, 32)
+	readBits(hash, e)
+
+	g := &nonceGenerator{k: make([]byte, 32), v: make([]byte, 32)}
+	for i := range g.v {
+		g.v[i] = 0x01
+	}
+	g.k = g.mac(g.v, []byte{0x00}, seckey, hash)
+	g.v = g.mac(g.v)
+	g.k = g.mac(g.v, []byte{0x01}, seckey, hash)
+	g.v = g.mac(g.v)
+	return g
+}
+
+// mac computes the HMAC of the concatenated data keyed with the current k.
+func (g *nonceGenerator) mac(data ...[]byte) []byte {
+	h := hmac.New(sha256.New, g.k)
+	for _, d := range data {
+		h.Write(d)
+	}
+	return h.Sum(nil)
+}
+
+// next returns the next nonce candidate in the range [1, N-1].
+func (g *nonceGenerator) next() *big.Int {
+	for {
+		g.v = g.mac(g.v)
+		k := new(big.Int).SetBytes(g.v)
+		if k.Sign() > 0 && k.Cmp(S256().N) < 0 {
+			// Prepare the state for a potential retry
+			g.k = g.mac(g.v, []byte{0x00})
+			g.v = g.mac(g.v)
+			return k
+		}
+		g.k = g.mac(g.v, []byte{0x00})
+		g.v = g.mac(g.v)
+	}
+}
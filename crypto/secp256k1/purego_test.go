@@ -0,0 +1,137 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package secp256k1
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/EarthDollar/go-earthdollar/crypto/randentropy"
+)
+
+// Tests the pure Go signer against a known RFC 6979 test vector.
+func TestGoSignDeterministic(t *testing.T) {
+	seckey := make([]byte, 32)
+	seckey[31] = 1
+	msg := sha256.Sum256([]byte("Satoshi Nakamoto"))
+
+	sig, err := goBackend{}.sign(msg[:], seckey)
+	if err != nil {
+		t.Fatalf("signature error: %v", err)
+	}
+	want := "934b1ea10a4b3c1757e2b0c017d0b6143ce3c9a7e6a4a49860d7a6ab210ee3d82442ce9d2b916064108014783e923ec36b49743e2ffa1c4496f01a512aafd9e501"
+	if hex.EncodeToString(sig) != want {
+		t.Errorf("signature mismatch: have %x, want %s", sig, want)
+	}
+}
+
+func TestGoSignAndRecover(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		pubkey, seckey := generateKeyPair()
+		msg := randentropy.GetEntropyCSPRNG(32)
+
+		sig, err := goBackend{}.sign(msg, seckey)
+		if err != nil {
+			t.Fatalf("signature error: %v", err)
+		}
+		compactSigCheck(t, sig)
+
+		recovered, err := goBackend{}.recoverPubkey(msg, sig)
+		if err != nil {
+			t.Fatalf("recover error: %v", err)
+		}
+		if !bytes.Equal(pubkey, recovered) {
+			t.Fatalf("pubkey mismatch: want: %x have: %x", pubkey, recovered)
+		}
+	}
+}
+
+func TestGoRecoverInvalid(t *testing.T) {
+	msg := randentropy.GetEntropyCSPRNG(32)
+	sig := make([]byte, 65)
+	if _, err := (goBackend{}).recoverPubkey(msg, sig); err != ErrRecoverFailed {
+		t.Errorf("zero signature: got %v, want %v", err, ErrRecoverFailed)
+	}
+	copy(sig, N.Bytes())
+	sig[63] = 1
+	if _, err := (goBackend{}).recoverPubkey(msg, sig); err != ErrRecoverFailed {
+		t.Errorf("overflowing r: got %v, want %v", err, ErrRecoverFailed)
+	}
+}
+
+// Tests that signatures and curve multiplications of the pure Go and the C
+// implementations are interchangeable.
+func TestBackendsCompatible(t *testing.T) {
+	if nativeImpl == nil {
+		t.Skip("built without cgo")
+	}
+	backends := []backend{nativeImpl, goBackend{}}
+	for i := 0; i < 100; i++ {
+		pubkey, seckey := generateKeyPair()
+		msg := randentropy.GetEntropyCSPRNG(32)
+
+		for _, signer := range backends {
+			sig, err := signer.sign(msg, seckey)
+			if err != nil {
+				t.Fatalf("%T: signature error: %v", signer, err)
+			}
+			for _, recoverer := range backends {
+				recovered, err := recoverer.recoverPubkey(msg, sig)
+				if err != nil {
+					t.Fatalf("%T -> %T: recover error: %v", signer, recoverer, err)
+				}
+				if !bytes.Equal(pubkey, recovered) {
+					t.Fatalf("%T -> %T: pubkey mismatch: want: %x have: %x", signer, recoverer, pubkey, recovered)
+				}
+			}
+		}
+		scalar := randentropy.GetEntropyCSPRNG(32)
+		native, pure := append([]byte{}, pubkey[1:]...), append([]byte{}, pubkey[1:]...)
+		if nativeImpl.scalarMult(native, scalar) != (goBackend{}).scalarMult(pure, scalar) {
+			t.Fatalf("scalar multiplication validity mismatch for %x", scalar)
+		}
+		if !bytes.Equal(native, pure) {
+			t.Fatalf("scalar multiplication mismatch: native %x, pure %x", native, pure)
+		}
+	}
+}
+
+func TestUseNative(t *testing.T) {
+	defer UseNative(true)
+
+	if UseNative(false) {
+		t.Fatal("native backend in use after disabling it")
+	}
+	TestSignAndRecover(t)
+
+	if native := UseNative(true); native != (nativeImpl != nil) {
+		t.Fatalf("native backend mismatch: have %v, want %v", native, nativeImpl != nil)
+	}
+}
+
+func BenchmarkGoRecover(b *testing.B) {
+	msg := randentropy.GetEntropyCSPRNG(32)
+	_, seckey := generateKeyPair()
+	sig, _ := Sign(msg, seckey)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		goBackend{}.recoverPubkey(msg, sig)
+	}
+}
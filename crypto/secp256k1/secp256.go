@@ -14,7 +14,8 @@
 // You should have received a copy of the GNU Lesser General Public License
 // along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
 
-// Package secp256k1 wraps the bitcoin secp256k1 C library.
+// +build cgo
+
 package secp256k1
 
 /*
@@ -37,52 +38,27 @@ extern void secp256k1GoPanicError(const char* msg, void* data);
 import "C"
 
 import (
-	"errors"
-	"math/big"
 	"unsafe"
 
 	"github.com/EarthDollar/go-earthdollar/crypto/randentropy"
 )
 
-var (
-	context *C.secp256k1_context
-	N       *big.Int
-	HalfN   *big.Int
-)
+var context *C.secp256k1_context
 
 func init() {
-	N, _ = new(big.Int).SetString("fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364141", 16)
-	// N / 2 == 57896044618658097711785492504343953926418782139537452191302581570759080747168
-	HalfN, _ = new(big.Int).SetString("7fffffffffffffffffffffffffffffff5d576e7357a4501ddfe92f46681b20a0", 16)
-
 	// around 20 ms on a modern CPU.
 	context = C.secp256k1_context_create_sign_verify()
 	C.secp256k1_context_set_illegal_callback(context, C.callbackFunc(C.secp256k1GoPanicIllegal), nil)
 	C.secp256k1_context_set_error_callback(context, C.callbackFunc(C.secp256k1GoPanicError), nil)
+
+	nativeImpl = cgoBackend{}
+	impl = nativeImpl
 }
 
-var (
-	ErrInvalidMsgLen       = errors.New("invalid message length, need 32 bytes")
-	ErrInvalidSignatureLen = errors.New("invalid signature length")
-	ErrInvalidRecoveryID   = errors.New("invalid signature recovery id")
-	ErrInvalidKey          = errors.New("invalid private key")
-	ErrSignFailed          = errors.New("signing failed")
-	ErrRecoverFailed       = errors.New("recovery failed")
-)
+// cgoBackend implements the curve operations using the libsecp256k1 C library.
+type cgoBackend struct{}
 
-// Sign creates a recoverable ECDSA signature.
-// The produced signature is in the 65-byte [R || S || V] format where V is 0 or 1.
-//
-// The caller is responsible for ensuring that msg cannot be chosen
-// directly by an attacker. It is usually preferable to use a cryptographic
-// hash function on any input before handing it to this function.
-func Sign(msg []byte, seckey []byte) ([]byte, error) {
-	if len(msg) != 32 {
-		return nil, ErrInvalidMsgLen
-	}
-	if len(seckey) != 32 {
-		return nil, ErrInvalidKey
-	}
+func (cgoBackend) sign(msg []byte, seckey []byte) ([]byte, error) {
 	seckeydata := (*C.uchar)(unsafe.Pointer(&seckey[0]))
 	if C.secp256k1_ec_seckey_verify(context, seckeydata) != 1 {
 		return nil, ErrInvalidKey
@@ -109,18 +85,7 @@ func Sign(msg []byte, seckey []byte) ([]byte, error) {
 	return sig, nil
 }
 
-// RecoverPubkey returns the the public key of the signer.
-// msg must be the 32-byte hash of the message to be signed.
-// sig must be a 65-byte compact ECDSA signature containing the
-// recovery id as the last element.
-func RecoverPubkey(msg []byte, sig []byte) ([]byte, error) {
-	if len(msg) != 32 {
-		return nil, ErrInvalidMsgLen
-	}
-	if err := checkSignature(sig); err != nil {
-		return nil, err
-	}
-
+func (cgoBackend) recoverPubkey(msg []byte, sig []byte) ([]byte, error) {
 	var (
 		pubkey  = make([]byte, 65)
 		sigdata = (*C.uchar)(unsafe.Pointer(&sig[0]))
@@ -132,25 +97,8 @@ func RecoverPubkey(msg []byte, sig []byte) ([]byte, error) {
 	return pubkey, nil
 }
 
-func checkSignature(sig []byte) error {
-	if len(sig) != 65 {
-		return ErrInvalidSignatureLen
-	}
-	if sig[64] >= 4 {
-		return ErrInvalidRecoveryID
-	}
-	return nil
-}
-
-// reads num into buf as big-endian bytes.
-func readBits(buf []byte, num *big.Int) {
-	const wordLen = int(unsafe.Sizeof(big.Word(0)))
-	i := len(buf)
-	for _, d := range num.Bits() {
-		for j := 0; j < wordLen && i > 0; j++ {
-			i--
-			buf[i] = byte(d)
-			d >>= 8
-		}
-	}
+func (cgoBackend) scalarMult(point, scalar []byte) bool {
+	pointPtr := (*C.uchar)(unsafe.Pointer(&point[0]))
+	scalarPtr := (*C.uchar)(unsafe.Pointer(&scalar[0]))
+	return C.secp256k1_pubkey_scalar_mul(context, pointPtr, scalarPtr) == 1
 }
@@ -0,0 +1,112 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package edclient
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/EarthDollar/go-earthdollar"
+	"github.com/EarthDollar/go-earthdollar/common/hexutil"
+	"github.com/EarthDollar/go-earthdollar/core/types"
+	"github.com/EarthDollar/go-earthdollar/rpc"
+	"golang.org/x/net/context"
+)
+
+// Verify that Client implements the ethereum interfaces.
+var (
+	_ = ethereum.ChainReader(&Client{})
+	_ = ethereum.TransactionReader(&Client{})
+	_ = ethereum.ChainStateReader(&Client{})
+	_ = ethereum.ChainSyncReader(&Client{})
+	_ = ethereum.ContractCaller(&Client{})
+	_ = ethereum.GasEstimator(&Client{})
+	_ = ethereum.GasPricer(&Client{})
+	_ = ethereum.LogFilterer(&Client{})
+	_ = ethereum.PendingStateReader(&Client{})
+	// _ = ethereum.PendingStateEventer(&Client{})
+	_ = ethereum.PendingContractCaller(&Client{})
+)
+
+// FakeEthAPI serves a single block header over the eth namespace.
+type FakeEthAPI struct {
+	head *types.Header
+}
+
+func (api *FakeEthAPI) GetBlockByNumber(number rpc.BlockNumber, fullTx bool) (*types.Header, error) {
+	if number == rpc.LatestBlockNumber || number.Int64() == api.head.Number.Int64() {
+		return api.head, nil
+	}
+	return nil, nil
+}
+
+// FakeEdAPI serves the Earthdollar specific chain information.
+type FakeEdAPI struct{}
+
+func (api *FakeEdAPI) TotalSupply(number rpc.BlockNumber) *hexutil.Big {
+	return (*hexutil.Big)(big.NewInt(1000000 + number.Int64()))
+}
+
+// FakeNetAPI serves the network information.
+type FakeNetAPI struct{}
+
+func (api *FakeNetAPI) Version() string { return "7" }
+
+func newTestClient(t *testing.T, head *types.Header) *Client {
+	server := rpc.NewServer()
+	for name, service := range map[string]interface{}{
+		"eth": &FakeEthAPI{head},
+		"ed":  new(FakeEdAPI),
+		"net": new(FakeNetAPI),
+	} {
+		if err := server.RegisterName(name, service); err != nil {
+			t.Fatalf("failed to register %s service: %v", name, err)
+		}
+	}
+	return NewClient(rpc.DialInProc(server))
+}
+
+func TestClient(t *testing.T) {
+	head := &types.Header{Number: big.NewInt(12), Difficulty: big.NewInt(131072), GasLimit: big.NewInt(4712388), GasUsed: new(big.Int), Time: big.NewInt(1480000000)}
+	client := newTestClient(t, head)
+	ctx := context.Background()
+
+	header, err := client.HeaderByNumber(ctx, big.NewInt(12))
+	if err != nil {
+		t.Fatalf("failed to retrieve header: %v", err)
+	}
+	if header.Hash() != head.Hash() {
+		t.Errorf("header mismatch: have %x, want %x", header.Hash(), head.Hash())
+	}
+	if _, err := client.HeaderByNumber(ctx, big.NewInt(13)); err != ethereum.NotFound {
+		t.Errorf("missing header error mismatch: have %v, want %v", err, ethereum.NotFound)
+	}
+	supply, err := client.TotalSupply(ctx, big.NewInt(12))
+	if err != nil {
+		t.Fatalf("failed to retrieve total supply: %v", err)
+	}
+	if supply.Int64() != 1000012 {
+		t.Errorf("total supply mismatch: have %v, want %v", supply, 1000012)
+	}
+	id, err := client.NetworkID(ctx)
+	if err != nil {
+		t.Fatalf("failed to retrieve network id: %v", err)
+	}
+	if id.Int64() != 7 {
+		t.Errorf("network id mismatch: have %v, want %v", id, 7)
+	}
+}
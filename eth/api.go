@@ -36,6 +36,7 @@ import (
 	"github.com/EarthDollar/go-earthdollar/core/state"
 	"github.com/EarthDollar/go-earthdollar/core/types"
 	"github.com/EarthDollar/go-earthdollar/core/vm"
+	"github.com/EarthDollar/go-earthdollar/ethdb"
 	"github.com/EarthDollar/go-earthdollar/internal/ethapi"
 	"github.com/EarthDollar/go-earthdollar/logger"
 	"github.com/EarthDollar/go-earthdollar/logger/glog"
@@ -48,6 +49,11 @@ import (
 
 const defaultTraceTimeout = 5 * time.Second
 
+const (
+	importLogInterval = 8 * time.Second // Interval between two chain import progress reports
+	exportLogInterval = 8 * time.Second // Interval between two chain export progress reports
+)
+
 // PublicEthereumAPI provides an API to access Ethereum full node-related
 // information.
 type PublicEthereumAPI struct {
@@ -74,6 +80,36 @@ func (s *PublicEthereumAPI) Hashrate() hexutil.Uint64 {
 	return hexutil.Uint64(s.e.Miner().HashRate())
 }
 
+// PublicEarthdollarAPI provides an API to access Earthdollar specific chain
+// information, such as the amount of ED in existence.
+type PublicEarthdollarAPI struct {
+	e *Ethereum
+}
+
+// NewPublicEarthdollarAPI creates a new Earthdollar protocol API for full nodes.
+func NewPublicEarthdollarAPI(e *Ethereum) *PublicEarthdollarAPI {
+	return &PublicEarthdollarAPI{e}
+}
+
+// TotalSupply returns the total amount of ED issued up to and including the
+// given block, being the genesis allocation and all block and uncle rewards.
+func (api *PublicEarthdollarAPI) TotalSupply(blockNr rpc.BlockNumber) (*hexutil.Big, error) {
+	var block *types.Block
+	if blockNr == rpc.LatestBlockNumber || blockNr == rpc.PendingBlockNumber {
+		block = api.e.BlockChain().CurrentBlock()
+	} else {
+		block = api.e.BlockChain().GetBlockByNumber(uint64(blockNr))
+	}
+	if block == nil {
+		return nil, fmt.Errorf("block #%d not found", blockNr)
+	}
+	supply := api.e.BlockChain().GetTotalSupply(block.Hash(), block.NumberU64())
+	if supply == nil {
+		return nil, fmt.Errorf("total supply of block #%d not yet available", block.NumberU64())
+	}
+	return (*hexutil.Big)(supply), nil
+}
+
 // PublicMinerAPI provides an API to control the miner.
 // It offers only methods that operate on data that pose no security risk when it is publicly accessible.
 type PublicMinerAPI struct {
@@ -175,6 +211,16 @@ func (s *PrivateMinerAPI) SetEtherbase(etherbase common.Address) bool {
 	return true
 }
 
+// SetRotatingEtherbases rotates the etherbase of the mined blocks among several
+// addresses, each receiving the full reward of a share of the blocks
+// proportional to its weight.
+func (s *PrivateMinerAPI) SetRotatingEtherbases(payouts []miner.Payout) (bool, error) {
+	if err := s.e.SetRotatingEtherbases(payouts); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 // StartAutoDAG starts auto DAG generation. This will prevent the DAG generating on epoch change
 // which will cause the node to stop mining during the generation process.
 func (s *PrivateMinerAPI) StartAutoDAG() bool {
@@ -210,6 +256,12 @@ func NewPrivateAdminAPI(eth *Ethereum) *PrivateAdminAPI {
 
 // ExportChain exports the current blockchain into a local file.
 func (api *PrivateAdminAPI) ExportChain(file string) (bool, error) {
+	return api.ExportChainRange(file, 0, api.eth.BlockChain().CurrentBlock().NumberU64())
+}
+
+// ExportChainRange exports the blocks between first and last (inclusive) into a
+// local file, gzip compressing them if the file name ends in .gz.
+func (api *PrivateAdminAPI) ExportChainRange(file string, first, last uint64) (bool, error) {
 	// Make sure we can create the file to export into
 	out, err := os.OpenFile(file, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.ModePerm)
 	if err != nil {
@@ -224,22 +276,12 @@ func (api *PrivateAdminAPI) ExportChain(file string) (bool, error) {
 	}
 
 	// Export the blockchain
-	if err := api.eth.BlockChain().Export(writer); err != nil {
+	if err := api.eth.BlockChain().ExportRange(writer, first, last, core.NewExportLogger(exportLogInterval)); err != nil {
 		return false, err
 	}
 	return true, nil
 }
 
-func hasAllBlocks(chain *core.BlockChain, bs []*types.Block) bool {
-	for _, b := range bs {
-		if !chain.HasBlock(b.Hash()) {
-			return false
-		}
-	}
-
-	return true
-}
-
 // ImportChain imports a blockchain from a local file.
 func (api *PrivateAdminAPI) ImportChain(file string) (bool, error) {
 	// Make sure the can access the file to import
@@ -249,42 +291,69 @@ func (api *PrivateAdminAPI) ImportChain(file string) (bool, error) {
 	}
 	defer in.Close()
 
+	config := core.ImportConfig{LogInterval: importLogInterval}
+	if info, err := in.Stat(); err == nil {
+		config.Size = info.Size()
+	}
 	var reader io.Reader = in
 	if strings.HasSuffix(file, ".gz") {
+		config.Position = func() int64 {
+			pos, _ := in.Seek(0, io.SeekCurrent)
+			return pos
+		}
 		if reader, err = gzip.NewReader(reader); err != nil {
 			return false, err
 		}
 	}
-
 	// Run actual the import in pre-configured batches
-	stream := rlp.NewStream(reader, 0)
-
-	blocks, index := make([]*types.Block, 0, 2500), 0
-	for batch := 0; ; batch++ {
-		// Load a batch of blocks from the input file
-		for len(blocks) < cap(blocks) {
-			block := new(types.Block)
-			if err := stream.Decode(block); err == io.EOF {
-				break
-			} else if err != nil {
-				return false, fmt.Errorf("block %d: failed to parse: %v", index, err)
-			}
-			blocks = append(blocks, block)
-			index++
-		}
-		if len(blocks) == 0 {
-			break
-		}
+	if _, err := api.eth.BlockChain().ImportChain(reader, config); err != nil {
+		return false, err
+	}
+	return true, nil
+}
 
-		if hasAllBlocks(api.eth.BlockChain(), blocks) {
-			blocks = blocks[:0]
-			continue
-		}
-		// Import the batch and reset the buffer
-		if _, err := api.eth.BlockChain().InsertChain(blocks); err != nil {
-			return false, fmt.Errorf("batch %d: failed to insert: %v", batch, err)
+// ExportPreimages exports all the recorded trie key preimages into a local file,
+// gzip compressing them if the file name ends in .gz.
+func (api *PrivateAdminAPI) ExportPreimages(file string) (bool, error) {
+	db, ok := api.eth.ChainDb().(*ethdb.LDBDatabase)
+	if !ok {
+		return false, errors.New("preimage export requires a LevelDB database")
+	}
+	// Make sure we can create the file to export into
+	out, err := os.OpenFile(file, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.ModePerm)
+	if err != nil {
+		return false, err
+	}
+	defer out.Close()
+
+	var writer io.Writer = out
+	if strings.HasSuffix(file, ".gz") {
+		writer = gzip.NewWriter(writer)
+		defer writer.(*gzip.Writer).Close()
+	}
+	if _, err := core.ExportPreimages(db, writer, exportLogInterval); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ImportPreimages imports the trie key preimages of a local file exported by
+// ExportPreimages (or "ged export-preimages") into the chain database.
+func (api *PrivateAdminAPI) ImportPreimages(file string) (bool, error) {
+	in, err := os.Open(file)
+	if err != nil {
+		return false, err
+	}
+	defer in.Close()
+
+	var reader io.Reader = in
+	if strings.HasSuffix(file, ".gz") {
+		if reader, err = gzip.NewReader(reader); err != nil {
+			return false, err
 		}
-		blocks = blocks[:0]
+	}
+	if _, err := core.ImportPreimages(api.eth.ChainDb(), reader, importLogInterval); err != nil {
+		return false, err
 	}
 	return true, nil
 }
@@ -413,7 +482,6 @@ func (api *PrivateDebugAPI) traceBlock(block *types.Block, logConfig *vm.LogConf
 	structLogger := vm.NewStructLogger(logConfig)
 
 	config := vm.Config{
-		Debug:  true,
 		Tracer: structLogger,
 	}
 
@@ -541,7 +609,7 @@ func (api *PrivateDebugAPI) TraceTransaction(ctx context.Context, txHash common.
 			continue
 		}
 
-		vmenv := vm.NewEVM(context, stateDb, api.config, vm.Config{Debug: true, Tracer: tracer})
+		vmenv := vm.NewEVM(context, stateDb, api.config, vm.Config{Tracer: tracer})
 		ret, gas, err := core.ApplyMessage(vmenv, msg, new(core.GasPool).AddGas(tx.Gas()))
 		if err != nil {
 			return nil, fmt.Errorf("tracing failed: %v", err)
@@ -566,3 +634,36 @@ func (api *PrivateDebugAPI) Preimage(ctx context.Context, hash common.Hash) (hex
 	db := core.PreimageTable(api.eth.ChainDb())
 	return db.Get(hash.Bytes())
 }
+
+// BadBlockArgs represents the entries in the list returned when bad blocks are
+// queried.
+type BadBlockArgs struct {
+	Hash   common.Hash    `json:"hash"`
+	Number hexutil.Uint64 `json:"number"`
+	Peer   string         `json:"peer"`
+	Error  string         `json:"error"`
+	Time   time.Time      `json:"time"`
+	RLP    hexutil.Bytes  `json:"rlp"`
+}
+
+// GetBadBlocks returns the most recently rejected blocks, newest first,
+// together with the reason of their rejection and the peer they came from.
+func (api *PrivateDebugAPI) GetBadBlocks(ctx context.Context) ([]*BadBlockArgs, error) {
+	bad := api.eth.BlockChain().BadBlocks()
+	results := make([]*BadBlockArgs, len(bad))
+	for i, block := range bad {
+		blockRlp, err := rlp.EncodeToBytes(block.Block)
+		if err != nil {
+			return nil, err
+		}
+		results[i] = &BadBlockArgs{
+			Hash:   block.Block.Hash(),
+			Number: hexutil.Uint64(block.Block.NumberU64()),
+			Peer:   block.Peer,
+			Error:  block.Err.Error(),
+			Time:   block.Time,
+			RLP:    blockRlp,
+		}
+	}
+	return results, nil
+}
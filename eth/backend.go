@@ -20,6 +20,7 @@ package eth
 import (
 	"errors"
 	"fmt"
+	"math"
 	"math/big"
 	"os"
 	"path/filepath"
@@ -42,12 +43,14 @@ import (
 	"github.com/EarthDollar/go-earthdollar/internal/ethapi"
 	"github.com/EarthDollar/go-earthdollar/logger"
 	"github.com/EarthDollar/go-earthdollar/logger/glog"
+	"github.com/EarthDollar/go-earthdollar/metrics"
 	"github.com/EarthDollar/go-earthdollar/miner"
 	"github.com/EarthDollar/go-earthdollar/node"
 	"github.com/EarthDollar/go-earthdollar/p2p"
 	"github.com/EarthDollar/go-earthdollar/params"
 	"github.com/EarthDollar/go-earthdollar/pow"
 	"github.com/EarthDollar/go-earthdollar/rpc"
+	"golang.org/x/net/context"
 )
 
 const (
@@ -61,11 +64,14 @@ const (
 var (
 	datadirInUseErrnos = map[uint]bool{11: true, 32: true, 35: true}
 	portInUseErrRE     = regexp.MustCompile("address already in use")
+
+	errSyncing = errors.New("chain synchronisation in progress")
 )
 
 type Config struct {
 	ChainConfig *params.ChainConfig // chain configuration
 
+	Network    string // Name of the built-in network preset filling unset options (empty = none)
 	NetworkId  int    // Network ID to use for selecting peers to connect to
 	Genesis    string // Genesis JSON to seed the chain database with
 	FastSync   bool   // Enables the state download based fast synchronisation algorithm
@@ -74,8 +80,15 @@ type Config struct {
 	LightPeers int    // Maximum number of LES client peers
 	MaxPeers   int    // Maximum number of global peers
 
-	SkipBcVersionCheck bool // e.g. blockchain export
-	DatabaseCache      int
+	MaxDownloadRate int // Maximum chain data download bandwidth in bytes per second (0 = unlimited)
+	MaxUploadRate   int // Maximum chain data serving bandwidth in bytes per second (0 = unlimited)
+
+	SkipBcVersionCheck bool        // e.g. blockchain export
+	CacheSize          int         // Total memory budget (MB) shared by all internal caches
+	CacheRatios        CacheRatios // Distribution of the cache budget between the caches
+	TrieCacheGens      uint16      // Explicit trie cache generation limit, overriding the budget (0 = derive)
+	StateFlushInterval uint64      // Number of blocks between flushing in-memory state to disk (0 = archive mode)
+	TxLookupLimit      uint64      // Number of recent blocks to index transactions by hash for (0 = entire chain)
 	DatabaseHandles    int
 
 	DocRoot   string
@@ -85,10 +98,11 @@ type Config struct {
 	PowShared bool
 	ExtraData []byte
 
-	Etherbase    common.Address
-	GasPrice     *big.Int
-	MinerThreads int
-	SolcPath     string
+	Etherbase          common.Address
+	RotatingEtherbases []miner.Payout // Weighted coinbase addresses to rotate the mined blocks among
+	GasPrice           *big.Int
+	MinerThreads       int
+	SolcPath           string
 
 	GpoMinGasPrice          *big.Int
 	GpoMaxGasPrice          *big.Int
@@ -98,30 +112,45 @@ type Config struct {
 	GpobaseCorrectionFactor int
 
 	EnablePreimageRecording bool
+	ParallelExecution       bool // Experimental speculative parallel transaction execution
+	TriePreimages           bool // Record the preimages of all accessed state trie keys
+
+	// ReadyMaxHeadAge is the maximum age of the current head block for the node
+	// to still be considered ready to serve requests. Zero disables the check.
+	ReadyMaxHeadAge time.Duration
+
+	// ShutdownTimeout is the time allowed for the subsystems to terminate before
+	// the stuck ones are reported (0 = DefaultShutdownTimeout).
+	ShutdownTimeout time.Duration
+
+	// FilterConfig contains the limits and timeouts of the filter RPC API.
+	FilterConfig filters.Config
 
 	TestGenesisBlock *types.Block   // Genesis block to seed the chain database with (testing only!)
 	TestGenesisState ethdb.Database // Genesis state to seed the database with (testing only!)
 }
 
+// LesServer is notified by the Ethereum protocol once the chain is synced to
+// start serving light clients. It runs as a separate service registered after
+// the full node in the same stack.
 type LesServer interface {
-	Start(srvr *p2p.Server)
 	Synced()
-	Stop()
-	Protocols() []p2p.Protocol
 }
 
 // Ethereum implements the Ethereum full node service.
 type Ethereum struct {
+	config      *Config
 	chainConfig *params.ChainConfig
 	// Channel for shutting down the service
-	shutdownChan  chan bool // Channel for shutting down the ethereum
-	stopDbUpgrade func()    // stop chain db sequential key upgrade
+	shutdownChan       chan bool // Channel for shutting down the ethereum
+	stopDbUpgrade      func()    // stop chain db sequential key upgrade
+	stopReceiptUpgrade func()    // stop chain db compact receipt upgrade
 	// Handlers
 	txPool          *core.TxPool
 	txMu            sync.Mutex
 	blockchain      *core.BlockChain
+	bloomIndexer    *core.ChainIndexer // Bloom bits index builder following the chain head
 	protocolManager *ProtocolManager
-	lesServer       LesServer
 	// DB interfaces
 	chainDb ethdb.Database // Block chain database
 
@@ -141,16 +170,26 @@ type Ethereum struct {
 
 	netVersionId  int
 	netRPCService *ethapi.PublicNetAPI
+
+	readyMaxHeadAge time.Duration
+	shutdownTimeout time.Duration
+	filterConfig    filters.Config
 }
 
+// AddLesServer registers the light server to notify once the chain is synced.
 func (s *Ethereum) AddLesServer(ls LesServer) {
-	s.lesServer = ls
 	s.protocolManager.lesServer = ls
 }
 
 // New creates a new Ethereum object (including the
 // initialisation of the common Ethereum object)
 func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
+	if err := ApplyNetwork(config); err != nil {
+		return nil, err
+	}
+	if config.NetworkId == 0 {
+		config.NetworkId = NetworkId
+	}
 	chainDb, err := CreateDB(ctx, config, "chaindata")
 	if err != nil {
 		return nil, err
@@ -165,6 +204,7 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 	}
 
 	eth := &Ethereum{
+		config:         config,
 		chainDb:        chainDb,
 		eventMux:       ctx.EventMux,
 		accountManager: ctx.AccountManager,
@@ -176,6 +216,10 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 		MinerThreads:   config.MinerThreads,
 		AutoDAG:        config.AutoDAG,
 		solcPath:       config.SolcPath,
+
+		readyMaxHeadAge: config.ReadyMaxHeadAge,
+		shutdownTimeout: config.ShutdownTimeout,
+		filterConfig:    config.FilterConfig,
 	}
 
 	if err := upgradeChainDatabase(chainDb); err != nil {
@@ -184,6 +228,7 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 	if err := addMipmapBloomBins(chainDb); err != nil {
 		return nil, err
 	}
+	eth.stopReceiptUpgrade = upgradeCompactReceipts(chainDb)
 
 	glog.V(logger.Info).Infof("Protocol Versions: %v, Network Id: %v", ProtocolVersions, config.NetworkId)
 
@@ -205,6 +250,9 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 		}
 		glog.V(logger.Info).Infoln("WARNING: Wrote default ethereum genesis block")
 	}
+	if err := ValidateNetworkId(config.NetworkId, genesis.Hash()); err != nil {
+		return nil, err
+	}
 
 	if config.ChainConfig == nil {
 		return nil, errors.New("missing chain config")
@@ -215,14 +263,73 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 
 	glog.V(logger.Info).Infoln("Chain config:", eth.chainConfig)
 
-	eth.blockchain, err = core.NewBlockChain(chainDb, eth.chainConfig, eth.pow, eth.EventMux(), vm.Config{EnablePreimageRecording: config.EnablePreimageRecording})
+	if err := eth.assemble(); err != nil {
+		return nil, err
+	}
+	return eth, nil
+}
+
+// Resume implements node.ResumableService, reopening the chain database and
+// assembling a fresh chain, transaction pool, protocol manager and miner on
+// the event mux of the new run. The one-off initialisation of New (database
+// migrations, genesis and chain configuration checks) is not repeated, only
+// the background database upgrades interrupted by Stop are continued.
+func (s *Ethereum) Resume(ctx *node.ServiceContext) error {
+	chainDb, err := CreateDB(ctx, s.config, "chaindata")
+	if err != nil {
+		return err
+	}
+	s.chainDb = chainDb
+	s.eventMux = ctx.EventMux
+	s.accountManager = ctx.AccountManager
+	s.shutdownChan = make(chan bool)
+
+	if s.stopDbUpgrade != nil {
+		s.stopDbUpgrade = upgradeSequentialKeys(chainDb)
+	}
+	if s.stopReceiptUpgrade != nil {
+		s.stopReceiptUpgrade = upgradeCompactReceipts(chainDb)
+	}
+	if err := s.assemble(); err != nil {
+		chainDb.Close()
+		return err
+	}
+	return nil
+}
+
+// assemble creates the chain and all the subsystems built on top of it from
+// the configuration, the chain database and the event mux of the service.
+func (eth *Ethereum) assemble() error {
+	var (
+		config  = eth.config
+		chainDb = eth.chainDb
+		err     error
+	)
+	eth.blockchain, err = core.NewBlockChain(chainDb, eth.chainConfig, eth.pow, eth.EventMux(), vm.Config{EnablePreimageRecording: config.EnablePreimageRecording, ParallelExecution: config.ParallelExecution})
 	if err != nil {
 		if err == core.ErrNoGenesis {
-			return nil, fmt.Errorf(`No chain found. Please initialise a new chain using the "init" subcommand.`)
+			return fmt.Errorf(`No chain found. Please initialise a new chain using the "init" subcommand.`)
 		}
-		return nil, err
+		return err
+	}
+	if config.StateFlushInterval > 0 {
+		if config.LightServ > 0 {
+			glog.V(logger.Warn).Infof("Serving light clients requires all recent state on disk, disabling state garbage collection")
+		} else if err := eth.blockchain.EnableStateGC(config.StateFlushInterval); err != nil {
+			return err
+		}
+	}
+	if config.TriePreimages {
+		eth.blockchain.EnableTriePreimages()
 	}
-	newPool := core.NewTxPool(eth.chainConfig, eth.EventMux(), eth.blockchain.State, eth.blockchain.GasLimit)
+	_, gens, tries := cacheAllowances(config)
+	eth.blockchain.SetStateCacheLimits(uint16(gens), tries)
+	eth.blockchain.SetTxLookupLimit(config.TxLookupLimit)
+
+	eth.bloomIndexer = NewBloomIndexer(chainDb, params.BloomBitsBlocks)
+	eth.bloomIndexer.Start(eth.blockchain.CurrentHeader(), eth.blockchain)
+
+	newPool := core.NewTxPool(eth.chainConfig, eth.EventMux(), eth.blockchain, eth.blockchain.State, eth.blockchain.GasLimit)
 	eth.txPool = newPool
 
 	maxPeers := config.MaxPeers
@@ -237,11 +344,18 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 	}
 
 	if eth.protocolManager, err = NewProtocolManager(eth.chainConfig, config.FastSync, config.NetworkId, maxPeers, eth.eventMux, eth.txPool, eth.pow, eth.blockchain, chainDb); err != nil {
-		return nil, err
+		return err
 	}
+	eth.protocolManager.SetBandwidthLimits(config.MaxDownloadRate, config.MaxUploadRate)
 	eth.miner = miner.New(eth, eth.chainConfig, eth.EventMux(), eth.pow)
 	eth.miner.SetGasPrice(config.GasPrice)
 	eth.miner.SetExtra(config.ExtraData)
+	if len(config.RotatingEtherbases) > 0 {
+		if err := eth.miner.SetRotatingEtherbases(config.RotatingEtherbases); err != nil {
+			return err
+		}
+		eth.etherbase = config.RotatingEtherbases[0].Address
+	}
 
 	gpoParams := &gasprice.GpoParams{
 		GpoMinGasPrice:          config.GpoMinGasPrice,
@@ -251,15 +365,16 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 		GpobaseStepUp:           config.GpobaseStepUp,
 		GpobaseCorrectionFactor: config.GpobaseCorrectionFactor,
 	}
-	gpo := gasprice.NewGasPriceOracle(eth.blockchain, chainDb, eth.eventMux, gpoParams)
+	gpo := gasprice.NewGasPriceOracle(eth.blockchain, chainDb, gpoParams)
 	eth.ApiBackend = &EthApiBackend{eth, gpo}
 
-	return eth, nil
+	return nil
 }
 
-// CreateDB creates the chain database.
+// CreateDB creates the chain database, allotting it its share of the cache
+// budget and configuring the remaining in-memory caches.
 func CreateDB(ctx *node.ServiceContext, config *Config, name string) (ethdb.Database, error) {
-	db, err := ctx.OpenDatabase(name, config.DatabaseCache, config.DatabaseHandles)
+	db, err := ctx.OpenDatabase(name, setupCaches(config), config.DatabaseHandles)
 	if db, ok := db.(*ethdb.LDBDatabase); ok {
 		db.Meter("eth/db/chaindata/")
 	}
@@ -271,6 +386,9 @@ func SetupGenesisBlock(chainDb *ethdb.Database, config *Config) error {
 	// Load up any custom genesis block if requested
 	if len(config.Genesis) > 0 {
 		block, err := core.WriteGenesisBlock(*chainDb, strings.NewReader(config.Genesis))
+		if core.IsGenesisMismatchErr(err) {
+			return fmt.Errorf("%v: the data directory holds a different chain, refusing to start", err)
+		}
 		if err != nil {
 			return err
 		}
@@ -289,6 +407,20 @@ func SetupGenesisBlock(chainDb *ethdb.Database, config *Config) error {
 	return nil
 }
 
+// ValidateNetworkId checks that the network id is usable in the protocol
+// handshake and doesn't contradict the network of a well known genesis block.
+func ValidateNetworkId(networkId int, genesis common.Hash) error {
+	if networkId <= 0 || uint64(networkId) > math.MaxUint32 {
+		return fmt.Errorf("invalid network id %d", networkId)
+	}
+	for _, network := range Networks {
+		if network.GenesisHash != (common.Hash{}) && network.GenesisHash == genesis && network.NetworkId != networkId {
+			return fmt.Errorf("network id %d doesn't match the %s genesis %x (want %d)", networkId, network.Name, genesis, network.NetworkId)
+		}
+	}
+	return nil
+}
+
 // CreatePoW creates the required type of PoW instance for an Ethereum service
 func CreatePoW(config *Config) (pow.PoW, error) {
 	switch {
@@ -315,6 +447,11 @@ func (s *Ethereum) APIs() []rpc.API {
 			Version:   "1.0",
 			Service:   NewPublicEthereumAPI(s),
 			Public:    true,
+		}, {
+			Namespace: "ed",
+			Version:   "1.0",
+			Service:   NewPublicEarthdollarAPI(s),
+			Public:    true,
 		}, {
 			Namespace: "eth",
 			Version:   "1.0",
@@ -333,7 +470,7 @@ func (s *Ethereum) APIs() []rpc.API {
 		}, {
 			Namespace: "eth",
 			Version:   "1.0",
-			Service:   filters.NewPublicFilterAPI(s.ApiBackend, false),
+			Service:   filters.NewPublicFilterAPI(s.ApiBackend, false, s.filterConfig),
 			Public:    true,
 		}, {
 			Namespace: "admin",
@@ -379,6 +516,16 @@ func (self *Ethereum) SetEtherbase(etherbase common.Address) {
 	self.miner.SetEtherbase(etherbase)
 }
 
+// SetRotatingEtherbases rotates the etherbase of the mined blocks among several
+// weighted addresses, see miner.SetRotatingEtherbases.
+func (self *Ethereum) SetRotatingEtherbases(payouts []miner.Payout) error {
+	if err := self.miner.SetRotatingEtherbases(payouts); err != nil {
+		return err
+	}
+	self.etherbase = payouts[0].Address
+	return nil
+}
+
 func (s *Ethereum) StartMining(threads int) error {
 	eb, err := s.Etherbase()
 	if err != nil {
@@ -404,15 +551,28 @@ func (s *Ethereum) IsListening() bool                  { return true } // Always
 func (s *Ethereum) EthVersion() int                    { return int(s.protocolManager.SubProtocols[0].Version) }
 func (s *Ethereum) NetVersion() int                    { return s.netVersionId }
 func (s *Ethereum) Downloader() *downloader.Downloader { return s.protocolManager.downloader }
+func (s *Ethereum) ChainConfig() *params.ChainConfig   { return s.chainConfig }
+func (s *Ethereum) NodeInfo() *EthNodeInfo             { return s.protocolManager.NodeInfo() }
+
+// Ready implements node.ReadinessReporter, reporting the node unfit to serve
+// requests while it is synchronising or if its head block is stale.
+func (s *Ethereum) Ready() error {
+	if s.protocolManager.downloader.Synchronising() {
+		return errSyncing
+	}
+	if s.readyMaxHeadAge > 0 {
+		head := s.blockchain.CurrentBlock()
+		if age := time.Since(time.Unix(head.Time().Int64(), 0)); age > s.readyMaxHeadAge {
+			return fmt.Errorf("head block #%d is %v old", head.NumberU64(), common.PrettyDuration(age))
+		}
+	}
+	return nil
+}
 
 // Protocols implements node.Service, returning all the currently configured
 // network protocols to start.
 func (s *Ethereum) Protocols() []p2p.Protocol {
-	if s.lesServer == nil {
-		return s.protocolManager.SubProtocols
-	} else {
-		return append(s.protocolManager.SubProtocols, s.lesServer.Protocols()...)
-	}
+	return s.protocolManager.SubProtocols
 }
 
 // Start implements node.Service, starting all internal goroutines needed by the
@@ -422,33 +582,101 @@ func (s *Ethereum) Start(srvr *p2p.Server) error {
 	if s.AutoDAG {
 		s.StartAutoDAG()
 	}
+	s.protocolManager.rater = srvr
 	s.protocolManager.Start()
-	if s.lesServer != nil {
-		s.lesServer.Start(srvr)
+	if metrics.Enabled {
+		go s.chainMetricsLoop()
 	}
 	return nil
 }
 
+// chainMetricsLoop keeps the chain head and synchronisation gauges up to date,
+// refreshing them on every new head and periodically for the time based ones.
+// The loop terminates when the event mux is stopped.
+func (s *Ethereum) chainMetricsLoop() {
+	sub := s.eventMux.Subscribe(downloader.StartEvent{})
+	defer sub.Unsubscribe()
+
+	heads := make(chan core.ChainHeadEvent, 10)
+	headSub := s.blockchain.SubscribeChainHeadEvent(heads)
+	defer headSub.Unsubscribe()
+
+	ticker := time.NewTicker(chainMetricsRefresh)
+	defer ticker.Stop()
+
+	update := func() {
+		head := s.blockchain.CurrentBlock()
+		progress := s.protocolManager.downloader.Progress()
+
+		highest := progress.HighestBlock
+		if highest < progress.CurrentBlock {
+			highest = progress.CurrentBlock
+		}
+		chainHeadGauge.Update(int64(head.NumberU64()))
+		chainHighestGauge.Update(int64(highest))
+		chainHeadAgeGauge.Update(int64(time.Since(time.Unix(head.Time().Int64(), 0)) / time.Second))
+
+		syncETAGauge.Update(int64(progress.ETA / time.Second))
+	}
+	update()
+	for {
+		select {
+		case _, ok := <-sub.Chan():
+			if !ok {
+				return
+			}
+			update()
+		case <-heads:
+			update()
+		case <-ticker.C:
+			update()
+		}
+	}
+}
+
 // Stop implements node.Service, terminating all internal goroutines used by the
 // Ethereum protocol.
 func (s *Ethereum) Stop() error {
-	if s.stopDbUpgrade != nil {
-		s.stopDbUpgrade()
+	timeout := s.shutdownTimeout
+	if timeout <= 0 {
+		timeout = DefaultShutdownTimeout
 	}
-	s.blockchain.Stop()
-	s.protocolManager.Stop()
-	if s.lesServer != nil {
-		s.lesServer.Stop()
-	}
-	s.txPool.Stop()
-	s.miner.Stop()
-	s.eventMux.Stop()
-
-	s.StopAutoDAG()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
 
-	s.chainDb.Close()
-	close(s.shutdownChan)
+	return s.StopContext(ctx)
+}
 
+// StopContext terminates the Ethereum protocol in dependency order: first the
+// producers of new blocks and transactions, then the consumers of the chain and
+// lastly the chain itself, waiting for any in-flight import to finish. If the
+// context expires before all subsystems stopped, a *ShutdownError reporting the
+// stuck subsystems is returned and the database is only closed once they finish.
+func (s *Ethereum) StopContext(ctx context.Context) error {
+	steps := []shutdownStep{
+		{"database upgrade", func() {
+			if s.stopDbUpgrade != nil {
+				s.stopDbUpgrade()
+			}
+			if s.stopReceiptUpgrade != nil {
+				s.stopReceiptUpgrade()
+			}
+		}},
+		{"miner", s.miner.Stop},
+		{"protocol manager", s.protocolManager.Stop},
+		{"bloom indexer", func() { s.bloomIndexer.Close() }},
+		{"transaction pool", s.txPool.Stop},
+		{"blockchain", s.blockchain.Stop},
+		{"event mux", s.eventMux.Stop},
+		{"dag generator", s.StopAutoDAG},
+	}
+	stuck := runShutdown(ctx, steps, func() {
+		s.chainDb.Close()
+		close(s.shutdownChan)
+	})
+	if len(stuck) > 0 {
+		return &ShutdownError{Stuck: stuck}
+	}
 	return nil
 }
 
@@ -17,14 +17,19 @@
 package eth
 
 import (
+	"encoding/binary"
+	"io/ioutil"
 	"math/big"
+	"os"
 	"testing"
 
 	"github.com/EarthDollar/go-earthdollar/common"
 	"github.com/EarthDollar/go-earthdollar/core"
 	"github.com/EarthDollar/go-earthdollar/core/types"
 	"github.com/EarthDollar/go-earthdollar/ethdb"
+	"github.com/EarthDollar/go-earthdollar/node"
 	"github.com/EarthDollar/go-earthdollar/params"
+	"github.com/EarthDollar/go-earthdollar/rlp"
 )
 
 func TestMipmapUpgrade(t *testing.T) {
@@ -81,3 +86,140 @@ func TestMipmapUpgrade(t *testing.T) {
 		t.Error("setting-mipmap-version not written to database")
 	}
 }
+
+func TestCompactReceiptUpgrade(t *testing.T) {
+	dir, err := ioutil.TempDir("", "receipt-upgrade-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := ethdb.NewLDBDatabase(dir, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	addr := common.BytesToAddress([]byte("jeff"))
+	genesis := core.WriteGenesisBlockForTesting(db)
+	chain, receipts := core.GenerateChain(params.TestChainConfig, genesis, db, 10, func(i int, gen *core.BlockGen) {
+		receipt := types.NewReceipt(nil, big.NewInt(int64(i)))
+		receipt.Logs = []*types.Log{{Address: addr, Data: []byte{byte(i)}}}
+		receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
+		gen.AddUncheckedReceipt(receipt)
+	})
+	// Store all the receipts in the original full format
+	var full [][]byte
+	for i, block := range chain {
+		core.WriteBlock(db, block)
+		legacy := make([]*types.ReceiptForStorage, len(receipts[i]))
+		for j, receipt := range receipts[i] {
+			legacy[j] = (*types.ReceiptForStorage)(receipt)
+		}
+		data, _ := rlp.EncodeToBytes(legacy)
+		db.Put(append(append([]byte("r"), encodeNumber(block.NumberU64())...), block.Hash().Bytes()...), data)
+		full = append(full, data)
+	}
+	// Run the upgrade and ensure all entries shrunk but still decode correctly
+	if err, stopped := upgradeCompactBlockReceipts(db, func() bool { return false }); err != nil || stopped {
+		t.Fatalf("upgrade failed: err %v, stopped %v", err, stopped)
+	}
+	for i, block := range chain {
+		data, _ := db.Get(append(append([]byte("r"), encodeNumber(block.NumberU64())...), block.Hash().Bytes()...))
+		if len(data) >= len(full[i]) {
+			t.Errorf("block %d: receipts not compacted: have %d bytes, full %d bytes", block.NumberU64(), len(data), len(full[i]))
+		}
+		have := core.GetBlockReceipts(db, block.Hash(), block.NumberU64())
+		if types.DeriveSha(have) != block.ReceiptHash() {
+			t.Errorf("block %d: receipt root mismatch", block.NumberU64())
+		}
+	}
+}
+
+func encodeNumber(number uint64) []byte {
+	enc := make([]byte, 8)
+	binary.BigEndian.PutUint64(enc, number)
+	return enc
+}
+
+// Tests that network ids are rejected if unusable or contradicting the network
+// of a well known genesis block.
+func TestValidateNetworkId(t *testing.T) {
+	private := common.HexToHash("0x1234")
+	tests := []struct {
+		networkId int
+		genesis   common.Hash
+		fail      bool
+	}{
+		{NetworkId, params.MainNetGenesisHash, false},
+		{TestNetworkId, params.MainNetGenesisHash, true},
+		{TestNetworkId, params.TestNetGenesisHash, false},
+		{NetworkId, params.TestNetGenesisHash, true},
+		{1337, private, false},
+		{NetworkId, private, false},
+		{0, private, true},
+		{-1, private, true},
+	}
+	for i, tt := range tests {
+		if err := ValidateNetworkId(tt.networkId, tt.genesis); (err != nil) != tt.fail {
+			t.Errorf("test %d: validation mismatch: have %v, want failure %v", i, err, tt.fail)
+		}
+	}
+}
+
+// restartTestEvent is posted to check that the event mux survives restarts.
+type restartTestEvent struct{}
+
+// Tests that a node running the Ethereum service can be stopped and started
+// again, resuming the same instance instead of constructing (and migrating) a
+// new one, with the data of the previous runs still available.
+func TestServiceRestart(t *testing.T) {
+	stack, err := node.New(&node.Config{Name: "test", NoDiscovery: true, MaxPeers: 0})
+	if err != nil {
+		t.Fatalf("failed to create protocol stack: %v", err)
+	}
+	var (
+		config      = &Config{Network: "dev", PowFake: true}
+		constructed int
+		first       *Ethereum
+	)
+	constructor := func(ctx *node.ServiceContext) (node.Service, error) {
+		constructed++
+		return New(ctx, config)
+	}
+	if err := stack.Register(constructor); err != nil {
+		t.Fatalf("failed to register Ethereum service: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := stack.Start(); err != nil {
+			t.Fatalf("run %d: failed to start protocol stack: %v", i, err)
+		}
+		var ethereum *Ethereum
+		if err := stack.Service(&ethereum); err != nil {
+			t.Fatalf("run %d: failed to retrieve Ethereum service: %v", i, err)
+		}
+		if first == nil {
+			first = ethereum
+		} else if ethereum != first {
+			t.Fatalf("run %d: service not resumed, new instance constructed", i)
+		}
+		// Ensure the data of the previous runs survived the restarts
+		for j := 0; j < i; j++ {
+			if _, err := ethereum.ChainDb().Get([]byte{byte(j)}); err != nil {
+				t.Fatalf("run %d: data of run %d lost: %v", i, j, err)
+			}
+		}
+		if err := ethereum.ChainDb().Put([]byte{byte(i)}, []byte{0x01}); err != nil {
+			t.Fatalf("run %d: failed to write data: %v", i, err)
+		}
+		if err := stack.EventMux().Post(restartTestEvent{}); err != nil {
+			t.Fatalf("run %d: event mux unusable: %v", i, err)
+		}
+		if err := stack.Stop(); err != nil {
+			t.Fatalf("run %d: failed to stop protocol stack: %v", i, err)
+		}
+	}
+	if constructed != 1 {
+		t.Errorf("service constructed %d times, want 1", constructed)
+	}
+}
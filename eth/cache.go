@@ -0,0 +1,104 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"github.com/EarthDollar/go-earthdollar/logger"
+	"github.com/EarthDollar/go-earthdollar/logger/glog"
+)
+
+const (
+	// trieGenerationSize is the approximate memory (in MB) retained by a single
+	// generation of cached trie nodes.
+	trieGenerationSize = 1
+
+	// pastTrieSize is the approximate memory (in MB) retained by a single past
+	// state trie snapshot kept around for reorgs.
+	pastTrieSize = 2
+)
+
+// CacheRatios specifies how the total cache budget is distributed between the
+// database block cache, the trie node cache and the state snapshot cache. The
+// values are relative weights, usually given in percent.
+type CacheRatios struct {
+	Database int
+	Trie     int
+	Snapshot int
+}
+
+// DefaultCacheRatios is the cache distribution used if none is configured.
+var DefaultCacheRatios = CacheRatios{Database: 50, Trie: 40, Snapshot: 10}
+
+// CacheSplit is the number of megabytes allotted to each individual cache.
+type CacheSplit struct {
+	Database int
+	Trie     int
+	Snapshot int
+}
+
+// Split distributes a total cache budget (in MB) according to the ratios. If
+// the ratios are all zero (or any is negative), the defaults are used instead.
+func (r CacheRatios) Split(total int) CacheSplit {
+	r = r.effective()
+	sum := r.Database + r.Trie + r.Snapshot
+	return CacheSplit{
+		Database: total * r.Database / sum,
+		Trie:     total * r.Trie / sum,
+		Snapshot: total * r.Snapshot / sum,
+	}
+}
+
+// effective returns the ratios, or the defaults if the ratios are unusable.
+func (r CacheRatios) effective() CacheRatios {
+	if r.Database < 0 || r.Trie < 0 || r.Snapshot < 0 || r.Database+r.Trie+r.Snapshot == 0 {
+		return DefaultCacheRatios
+	}
+	return r
+}
+
+// setupCaches splits the configured cache budget and returns the database
+// allowance in MB. The trie and snapshot allowances are applied to the chain
+// itself once created, see New.
+func setupCaches(config *Config) int {
+	split, gens, tries := cacheAllowances(config)
+
+	glog.V(logger.Info).Infof("Cache budget %dMB: database %dMB, trie %dMB (%d generations), snapshots %dMB (%d past tries)",
+		config.CacheSize, split.Database, split.Trie, gens, split.Snapshot, tries)
+	return split.Database
+}
+
+// cacheAllowances splits the configured cache budget and derives the number of
+// trie cache generations and past tries to retain from it.
+func cacheAllowances(config *Config) (split CacheSplit, gens int, tries int) {
+	split = config.CacheRatios.Split(config.CacheSize)
+
+	gens = split.Trie / trieGenerationSize
+	if config.TrieCacheGens > 0 {
+		gens = int(config.TrieCacheGens)
+	}
+	if gens < 1 {
+		gens = 1
+	}
+	if gens > 0xffff {
+		gens = 0xffff
+	}
+	tries = split.Snapshot / pastTrieSize
+	if tries < 1 {
+		tries = 1
+	}
+	return split, gens, tries
+}
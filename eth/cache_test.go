@@ -0,0 +1,38 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import "testing"
+
+func TestCacheSplit(t *testing.T) {
+	tests := []struct {
+		ratios CacheRatios
+		total  int
+		split  CacheSplit
+	}{
+		{DefaultCacheRatios, 256, CacheSplit{Database: 128, Trie: 102, Snapshot: 25}},
+		{CacheRatios{}, 100, CacheSplit{Database: 50, Trie: 40, Snapshot: 10}},
+		{CacheRatios{Database: -1, Trie: 50}, 100, CacheSplit{Database: 50, Trie: 40, Snapshot: 10}},
+		{CacheRatios{Database: 1, Trie: 1, Snapshot: 2}, 400, CacheSplit{Database: 100, Trie: 100, Snapshot: 200}},
+		{CacheRatios{Database: 100}, 64, CacheSplit{Database: 64}},
+	}
+	for i, tt := range tests {
+		if split := tt.ratios.Split(tt.total); split != tt.split {
+			t.Errorf("test %d: split mismatch: have %+v, want %+v", i, split, tt.split)
+		}
+	}
+}
@@ -33,7 +33,10 @@ import (
 	"github.com/EarthDollar/go-earthdollar/rlp"
 )
 
-var useSequentialKeys = []byte("dbUpgrade_20160530sequentialKeys")
+var (
+	useSequentialKeys  = []byte("dbUpgrade_20160530sequentialKeys")
+	useCompactReceipts = []byte("dbUpgrade_20170601compactReceipts")
+)
 
 // upgradeSequentialKeys checks the chain database version and
 // starts a background process to make upgrades if necessary.
@@ -253,6 +256,109 @@ func upgradeSequentialBlockData(db ethdb.Database, hash []byte) error {
 	return nil
 }
 
+// upgradeCompactReceipts checks whether the block receipts in the chain database
+// were already converted to the compact storage format and if not, starts a
+// background process to rewrite them. Receipts in the old format remain readable
+// until converted, so the upgrade may be interrupted at any time and is resumed
+// on the next startup. Returns a stop function that blocks until the process has
+// been safely stopped.
+func upgradeCompactReceipts(db ethdb.Database) (stopFn func()) {
+	data, _ := db.Get(useCompactReceipts)
+	if len(data) > 0 && data[0] == 42 {
+		return nil // already converted
+	}
+	if data, _ := db.Get([]byte("LastHeader")); len(data) == 0 {
+		db.Put(useCompactReceipts, []byte{42})
+		return nil // empty database, nothing to do
+	}
+	if data, _ := db.Get(useSequentialKeys); len(data) == 0 || data[0] != 42 {
+		return nil // wait for the sequential key upgrade to finish first
+	}
+	if _, ok := db.(*ethdb.LDBDatabase); !ok {
+		return nil // only persistent databases can be iterated
+	}
+	glog.V(logger.Info).Infof("Upgrading chain database to use compact receipts")
+
+	stopChn := make(chan struct{})
+	stoppedChn := make(chan struct{})
+
+	go func() {
+		stopFn := func() bool {
+			select {
+			case <-time.After(time.Microsecond * 100): // make sure other processes don't get starved
+			case <-stopChn:
+				return true
+			}
+			return false
+		}
+
+		err, stopped := upgradeCompactBlockReceipts(db, stopFn)
+		if err == nil && !stopped {
+			glog.V(logger.Info).Infof("Receipt conversion successful")
+			db.Put(useCompactReceipts, []byte{42})
+		}
+		if err != nil {
+			glog.V(logger.Error).Infof("Receipt conversion failed: %v", err)
+		}
+		close(stoppedChn)
+	}()
+
+	return func() {
+		close(stopChn)
+		<-stoppedChn
+	}
+}
+
+// upgradeCompactBlockReceipts reads all block receipts from the database and
+// rewrites the ones still stored in the full format in compact form.
+func upgradeCompactBlockReceipts(db ethdb.Database, stopFn func() bool) (error, bool) {
+	prefix := []byte("r")
+	it := db.(*ethdb.LDBDatabase).NewIterator()
+	defer func() {
+		it.Release()
+	}()
+	it.Seek(prefix)
+	cnt, saved := 0, 0
+	for ; bytes.HasPrefix(it.Key(), prefix); it.Next() {
+		keyPtr := it.Key()
+		if len(keyPtr) != 41 || bytes.HasPrefix(keyPtr, []byte("receipts-")) {
+			continue // not a block receipt entry (e.g. a single transaction receipt)
+		}
+		cnt++
+		if cnt%100000 == 0 {
+			key := common.CopyBytes(keyPtr)
+			it.Release()
+			it = db.(*ethdb.LDBDatabase).NewIterator()
+			it.Seek(key)
+			keyPtr = it.Key()
+			glog.V(logger.Info).Infof("converting %d block receipts...", cnt)
+		}
+		blob := it.Value()
+
+		var receipts []*types.CompactReceiptForStorage
+		if err := rlp.DecodeBytes(blob, &receipts); err != nil {
+			return err, false
+		}
+		compact, err := rlp.EncodeToBytes(receipts)
+		if err != nil {
+			return err, false
+		}
+		if len(compact) < len(blob) {
+			if err := db.Put(common.CopyBytes(keyPtr), compact); err != nil {
+				return err, false
+			}
+			saved += len(blob) - len(compact)
+		}
+		if stopFn() {
+			return nil, true
+		}
+	}
+	if cnt > 0 {
+		glog.V(logger.Info).Infof("converted %d block receipts, saved %d bytes", cnt, saved)
+	}
+	return nil, false
+}
+
 // upgradeChainDatabase ensures that the chain database stores block split into
 // separate header and body entries.
 func upgradeChainDatabase(db ethdb.Database) error {
@@ -0,0 +1,198 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"sync"
+
+	"github.com/EarthDollar/go-earthdollar/accounts"
+	"github.com/EarthDollar/go-earthdollar/common"
+	"github.com/EarthDollar/go-earthdollar/core"
+	"github.com/EarthDollar/go-earthdollar/miner"
+	"github.com/EarthDollar/go-earthdollar/node"
+	"github.com/EarthDollar/go-earthdollar/params"
+)
+
+// DevNetworkId is the network id of single-node developer chains.
+const DevNetworkId = 1337
+
+// DevBalance is the amount of wei developer accounts are funded with by default.
+var DevBalance = new(big.Int).Mul(big.NewInt(1000000), common.Ether)
+
+// DevConfig contains the settings of a single-node developer chain.
+type DevConfig struct {
+	Node     node.Config // Node settings (networking is disabled regardless)
+	Accounts int         // Number of pre-funded developer accounts (0 = 1)
+	Balance  *big.Int    // Initial balance of each developer account (nil = DevBalance)
+}
+
+// DevChain is a running single-node developer chain, sealing a block without
+// any proof-of-work as soon as transactions arrive.
+type DevChain struct {
+	Node     *node.Node
+	Ethereum *Ethereum
+	Accounts []accounts.Account // Pre-funded developer accounts, unlocked with an empty passphrase
+
+	datadir string // Temporary data directory to delete on close, if any
+	quit    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// NewDevChain creates and starts a single-node developer chain: an ephemeral
+// data directory (unless one is configured), a set of funded and unlocked
+// developer accounts, no peer discovery and an instant sealing loop.
+func NewDevChain(config *DevConfig) (*DevChain, error) {
+	if config == nil {
+		config = new(DevConfig)
+	}
+	dev := &DevChain{quit: make(chan struct{})}
+
+	nodeConf := config.Node
+	if nodeConf.DataDir == "" {
+		datadir, err := ioutil.TempDir("", "ed-devchain-")
+		if err != nil {
+			return nil, err
+		}
+		nodeConf.DataDir, dev.datadir = datadir, datadir
+	}
+	nodeConf.NoDiscovery, nodeConf.DiscoveryV5 = true, false
+	nodeConf.MaxPeers, nodeConf.ListenAddr = 0, ":0"
+	nodeConf.UseLightweightKDF = true
+
+	stack, err := node.New(&nodeConf)
+	if err != nil {
+		dev.cleanup()
+		return nil, err
+	}
+	dev.Node = stack
+
+	// Create the developer accounts and fund them in the genesis block
+	count, balance := config.Accounts, config.Balance
+	if count <= 0 {
+		count = 1
+	}
+	if balance == nil {
+		balance = DevBalance
+	}
+	genesis := &core.Genesis{
+		ChainConfig: params.DevChainConfig,
+		GasLimit:    params.GenesisGasLimit.String(),
+		Difficulty:  params.MinimumDifficulty.String(),
+		Alloc:       make(map[string]core.GenesisAlloc),
+	}
+	for i := 0; i < count; i++ {
+		account, err := stack.AccountManager().NewAccount("")
+		if err != nil {
+			dev.cleanup()
+			return nil, err
+		}
+		if err := stack.AccountManager().Unlock(account, ""); err != nil {
+			dev.cleanup()
+			return nil, err
+		}
+		dev.Accounts = append(dev.Accounts, account)
+		genesis.Alloc[account.Address.Hex()] = core.GenesisAlloc{Balance: balance.String()}
+	}
+	blob, err := json.Marshal(genesis)
+	if err != nil {
+		dev.cleanup()
+		return nil, err
+	}
+	ethConf := &Config{
+		ChainConfig:       params.DevChainConfig,
+		Genesis:           string(blob),
+		NetworkId:         DevNetworkId,
+		Etherbase:         dev.Accounts[0].Address,
+		PowFake:           true,
+		GasPrice:          new(big.Int),
+		GpoMinGasPrice:    new(big.Int),
+		GpoMaxGasPrice:    new(big.Int),
+		GpoFullBlockRatio: 80,
+		GpobaseStepDown:   10,
+		GpobaseStepUp:     100,
+	}
+	if err := stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
+		return New(ctx, ethConf)
+	}); err != nil {
+		dev.cleanup()
+		return nil, err
+	}
+	if err := stack.Start(); err != nil {
+		dev.cleanup()
+		return nil, err
+	}
+	if err := stack.Service(&dev.Ethereum); err != nil {
+		stack.Stop()
+		dev.cleanup()
+		return nil, err
+	}
+	// Seal blocks instantly, but only if there's anything to include
+	dev.Ethereum.Miner().Register(miner.NewInstantAgent())
+	if err := dev.Ethereum.StartMining(0); err != nil {
+		stack.Stop()
+		dev.cleanup()
+		return nil, err
+	}
+	dev.wg.Add(1)
+	go dev.loop()
+
+	return dev, nil
+}
+
+// loop recommits the sealing work whenever a new transaction arrives, as the
+// miner doesn't pick up transactions until the next block otherwise.
+func (dev *DevChain) loop() {
+	defer dev.wg.Done()
+
+	txs := make(chan core.TxsPreEvent, 16)
+	sub := dev.Ethereum.TxPool().SubscribeTxsPreEvent(txs)
+	defer sub.Unsubscribe()
+
+	// Transactions may have arrived before we subscribed
+	dev.Ethereum.Miner().Recommit()
+	for {
+		select {
+		case <-txs:
+			dev.Ethereum.Miner().Recommit()
+		case <-sub.Err():
+			return
+		case <-dev.quit:
+			return
+		}
+	}
+}
+
+// Close stops the developer chain, deleting its data directory if ephemeral.
+func (dev *DevChain) Close() error {
+	close(dev.quit)
+	dev.wg.Wait()
+
+	err := dev.Node.Stop()
+	dev.cleanup()
+	return err
+}
+
+// cleanup removes the ephemeral data directory, if one was created.
+func (dev *DevChain) cleanup() {
+	if dev.datadir != "" {
+		os.RemoveAll(dev.datadir)
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"math/big"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/EarthDollar/go-earthdollar/common"
+	"github.com/EarthDollar/go-earthdollar/core/types"
+	"github.com/EarthDollar/go-earthdollar/params"
+)
+
+// Tests that a developer chain funds its accounts and seals transactions without
+// mining empty blocks.
+func TestDevChain(t *testing.T) {
+	dev, err := NewDevChain(&DevConfig{Accounts: 2})
+	if err != nil {
+		t.Fatalf("failed to create developer chain: %v", err)
+	}
+	datadir, closed := dev.datadir, false
+	defer func() {
+		if !closed {
+			dev.Close()
+		}
+	}()
+
+	if len(dev.Accounts) != 2 {
+		t.Fatalf("developer account count mismatch: have %d, want %d", len(dev.Accounts), 2)
+	}
+	statedb, _ := dev.Ethereum.BlockChain().State()
+	for i, account := range dev.Accounts {
+		if balance := statedb.GetBalance(account.Address); balance.Cmp(DevBalance) != 0 {
+			t.Errorf("account %d: balance mismatch: have %v, want %v", i, balance, DevBalance)
+		}
+	}
+	// Nothing to seal, the chain shouldn't progress
+	time.Sleep(100 * time.Millisecond)
+	if head := dev.Ethereum.BlockChain().CurrentBlock().NumberU64(); head != 0 {
+		t.Fatalf("empty block sealed: head #%d", head)
+	}
+	// Send a transfer and wait for it to be sealed
+	recipient, amount := common.HexToAddress("0xdeadbeef"), big.NewInt(1000)
+
+	signer := types.NewEIP155Signer(params.DevChainConfig.ChainId)
+	tx := types.NewTransaction(0, recipient, amount, params.TxGas, new(big.Int), nil)
+	sig, err := dev.Node.AccountManager().Sign(dev.Accounts[0].Address, signer.Hash(tx).Bytes())
+	if err != nil {
+		t.Fatalf("failed to sign transaction: %v", err)
+	}
+	if tx, err = tx.WithSignature(signer, sig); err != nil {
+		t.Fatalf("failed to sign transaction: %v", err)
+	}
+	if err := dev.Ethereum.TxPool().Add(tx); err != nil {
+		t.Fatalf("failed to add transaction: %v", err)
+	}
+	for deadline := time.Now().Add(5 * time.Second); dev.Ethereum.BlockChain().CurrentBlock().NumberU64() == 0; {
+		if time.Now().After(deadline) {
+			t.Fatalf("transaction not sealed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	statedb, _ = dev.Ethereum.BlockChain().State()
+	if balance := statedb.GetBalance(recipient); balance.Cmp(amount) != 0 {
+		t.Errorf("recipient balance mismatch: have %v, want %v", balance, amount)
+	}
+	// Closing the chain should clean up the ephemeral data directory
+	closed = true
+	if err := dev.Close(); err != nil {
+		t.Fatalf("failed to close developer chain: %v", err)
+	}
+	if _, err := os.Stat(datadir); !os.IsNotExist(err) {
+		t.Errorf("data directory not removed: %v", err)
+	}
+}
@@ -30,6 +30,7 @@ import (
 
 	ethereum "github.com/EarthDollar/go-earthdollar"
 	"github.com/EarthDollar/go-earthdollar/common"
+	"github.com/EarthDollar/go-earthdollar/core"
 	"github.com/EarthDollar/go-earthdollar/core/types"
 	"github.com/EarthDollar/go-earthdollar/ethdb"
 	"github.com/EarthDollar/go-earthdollar/event"
@@ -70,6 +71,7 @@ var (
 	fsPivotInterval        = 256        // Number of headers out of which to randomize the pivot point
 	fsMinFullBlocks        = 64         // Number of blocks to retrieve fully even in fast sync
 	fsCriticalTrials       = uint32(32) // Number of times to retry in the cricical section before bailing
+	fsPivotResumeLimit     = 8192       // Maximum age of a persisted pivot to resume fast sync with after a restart
 )
 
 var (
@@ -95,6 +97,8 @@ var (
 	errCancelContentProcessing = errors.New("content processing canceled (requested)")
 	errNoSyncActive            = errors.New("no sync active")
 	errTooOld                  = errors.New("peer doesn't speak recent enough protocol version (need version >= 62)")
+	errCheckpointMissing       = errors.New("remote chain doesn't reach the trusted checkpoint")
+	errCheckpointMismatch      = errors.New("remote chain doesn't contain the trusted checkpoint")
 )
 
 type Downloader struct {
@@ -104,6 +108,9 @@ type Downloader struct {
 	queue *queue   // Scheduler for selecting the hashes to download
 	peers *peerSet // Set of active peers from which download can proceed
 
+	stateDB    ethdb.Database            // Database to persist fast sync progress into
+	checkpoint *params.TrustedCheckpoint // Block remote chains must contain to fast or light sync from
+
 	fsPivotLock  *types.Header // Pivot header on critical section entry (cannot change between retries)
 	fsPivotFails uint32        // Number of subsequent fast sync failures in the critical section
 
@@ -114,6 +121,10 @@ type Downloader struct {
 	syncStatsChainOrigin uint64       // Origin block number where syncing started at
 	syncStatsChainHeight uint64       // Highest block number known when syncing started
 	syncStatsStateDone   uint64       // Number of state trie entries already pulled
+	syncStatsBodyDone    uint64       // Number of block bodies already pulled
+	syncStatsReceiptDone uint64       // Number of block receipt sets already pulled
+	syncStatsCycleStart  time.Time    // Time instance when the current sync cycle started
+	syncStatsCycleBlock  uint64       // Current block number when the current sync cycle started
 	syncStatsLock        sync.RWMutex // Lock protecting the sync stats fields
 
 	// Callbacks
@@ -173,6 +184,7 @@ func New(mode SyncMode, stateDb ethdb.Database, mux *event.TypeMux, hasHeader he
 		mode:             mode,
 		mux:              mux,
 		queue:            newQueue(stateDb),
+		stateDB:          stateDb,
 		peers:            newPeerSet(),
 		rttEstimate:      uint64(rttMaxEstimate),
 		rttConfidence:    uint64(1000000),
@@ -213,29 +225,50 @@ func New(mode SyncMode, stateDb ethdb.Database, mux *event.TypeMux, hasHeader he
 // of processed and the total number of known states are also returned. Otherwise
 // these are zero.
 func (d *Downloader) Progress() ethereum.SyncProgress {
-	// Fetch the pending state count outside of the lock to prevent unforeseen deadlocks
-	pendingStates := uint64(d.queue.PendingNodeData())
-
+	// Fetch the pending counts outside of the lock to prevent unforeseen deadlocks
+	var (
+		pendingStates   = uint64(d.queue.PendingNodeData())
+		pendingBodies   = uint64(d.queue.PendingBlocks())
+		pendingReceipts = uint64(d.queue.PendingReceipts())
+	)
 	// Lock the current stats and return the progress
 	d.syncStatsLock.RLock()
 	defer d.syncStatsLock.RUnlock()
 
-	current := uint64(0)
+	current := d.currentBlock()
+
+	// Estimate the remaining time from the import rate of the current sync cycle
+	var eta time.Duration
+	if d.Synchronising() && current > d.syncStatsCycleBlock && d.syncStatsChainHeight > current {
+		elapsed := time.Since(d.syncStatsCycleStart)
+		eta = time.Duration(float64(elapsed) * float64(d.syncStatsChainHeight-current) / float64(current-d.syncStatsCycleBlock))
+	}
+	return ethereum.SyncProgress{
+		StartingBlock:  d.syncStatsChainOrigin,
+		CurrentBlock:   current,
+		HighestBlock:   d.syncStatsChainHeight,
+		PulledStates:   d.syncStatsStateDone,
+		KnownStates:    d.syncStatsStateDone + pendingStates,
+		PulledBodies:   d.syncStatsBodyDone,
+		KnownBodies:    d.syncStatsBodyDone + pendingBodies,
+		PulledReceipts: d.syncStatsReceiptDone,
+		KnownReceipts:  d.syncStatsReceiptDone + pendingReceipts,
+		ETA:            eta,
+	}
+}
+
+// currentBlock retrieves the number of the local head block relevant for the
+// current sync mode.
+func (d *Downloader) currentBlock() uint64 {
 	switch d.mode {
 	case FullSync:
-		current = d.headBlock().NumberU64()
+		return d.headBlock().NumberU64()
 	case FastSync:
-		current = d.headFastBlock().NumberU64()
+		return d.headFastBlock().NumberU64()
 	case LightSync:
-		current = d.headHeader().Number.Uint64()
-	}
-	return ethereum.SyncProgress{
-		StartingBlock: d.syncStatsChainOrigin,
-		CurrentBlock:  current,
-		HighestBlock:  d.syncStatsChainHeight,
-		PulledStates:  d.syncStatsStateDone,
-		KnownStates:   d.syncStatsStateDone + pendingStates,
+		return d.headHeader().Number.Uint64()
 	}
+	return 0
 }
 
 // Synchronising returns whether the downloader is currently retrieving blocks.
@@ -243,6 +276,15 @@ func (d *Downloader) Synchronising() bool {
 	return atomic.LoadInt32(&d.synchronising) > 0
 }
 
+// SyncPeer returns the identifier of the peer the current (or last) sync cycle
+// was run against.
+func (d *Downloader) SyncPeer() string {
+	d.cancelLock.RLock()
+	defer d.cancelLock.RUnlock()
+
+	return d.cancelPeer
+}
+
 // RegisterPeer injects a new download peer into the set of block source to be
 // used for fetching hashes and blocks from.
 func (d *Downloader) RegisterPeer(id string, version int, currentHead currentHeadRetrievalFn,
@@ -297,7 +339,7 @@ func (d *Downloader) Synchronise(id string, head common.Hash, td *big.Int, mode
 
 	case errTimeout, errBadPeer, errStallingPeer,
 		errEmptyHeaderSet, errPeersUnavailable, errTooOld,
-		errInvalidAncestor, errInvalidChain:
+		errInvalidAncestor, errInvalidChain, errCheckpointMismatch:
 		glog.V(logger.Debug).Infof("Removing peer %v: %v", id, err)
 		d.dropPeer(id)
 
@@ -398,6 +440,9 @@ func (d *Downloader) syncWithPeer(p *peer, hash common.Hash, td *big.Int) (err e
 	if err != nil {
 		return err
 	}
+	if err := d.verifyCheckpoint(p, latest.Number.Uint64()); err != nil {
+		return err
+	}
 	height := latest.Number.Uint64()
 
 	origin, err := d.findAncestor(p, height)
@@ -409,6 +454,7 @@ func (d *Downloader) syncWithPeer(p *peer, hash common.Hash, td *big.Int) (err e
 		d.syncStatsChainOrigin = origin
 	}
 	d.syncStatsChainHeight = height
+	d.syncStatsCycleStart, d.syncStatsCycleBlock = time.Now(), d.currentBlock()
 	d.syncStatsLock.Unlock()
 
 	// Initiate the sync using a concurrent header and content retrieval algorithm
@@ -419,17 +465,27 @@ func (d *Downloader) syncWithPeer(p *peer, hash common.Hash, td *big.Int) (err e
 	case FastSync:
 		// Calculate the new fast/slow sync pivot point
 		if d.fsPivotLock == nil {
-			pivotOffset, err := rand.Int(rand.Reader, big.NewInt(int64(fsPivotInterval)))
-			if err != nil {
-				panic(fmt.Sprintf("Failed to access crypto random source: %v", err))
-			}
-			if height > uint64(fsMinFullBlocks)+pivotOffset.Uint64() {
-				pivot = height - uint64(fsMinFullBlocks) - pivotOffset.Uint64()
+			// If an interrupted fast sync left a recent enough pivot behind, resume with
+			// it as all state entries already downloaded for its root can be reused
+			if stored := core.GetFastSyncPivot(d.stateDB); stored > 0 && stored+uint64(fsMinFullBlocks) <= height && height-stored <= uint64(fsPivotResumeLimit) {
+				glog.V(logger.Debug).Infof("Resuming fast sync with persisted pivot #%d", stored)
+				pivot = stored
+			} else {
+				pivotOffset, err := rand.Int(rand.Reader, big.NewInt(int64(fsPivotInterval)))
+				if err != nil {
+					panic(fmt.Sprintf("Failed to access crypto random source: %v", err))
+				}
+				if height > uint64(fsMinFullBlocks)+pivotOffset.Uint64() {
+					pivot = height - uint64(fsMinFullBlocks) - pivotOffset.Uint64()
+				}
 			}
 		} else {
 			// Pivot point locked in, use this and do not pick a new one!
 			pivot = d.fsPivotLock.Number.Uint64()
 		}
+		if pivot > 0 {
+			core.WriteFastSyncPivot(d.stateDB, pivot)
+		}
 		// If the point is below the origin, move origin back to ensure state download
 		if pivot < origin {
 			if pivot > 0 {
@@ -561,6 +617,62 @@ func (d *Downloader) fetchHeight(p *peer) (*types.Header, error) {
 	}
 }
 
+// SetCheckpoint configures the trusted checkpoint remote chains must contain for
+// the downloader to fast or light sync from them.
+func (d *Downloader) SetCheckpoint(checkpoint *params.TrustedCheckpoint) {
+	d.checkpoint = checkpoint
+}
+
+// verifyCheckpoint ensures that the chain of a remote peer contains the trusted
+// checkpoint before fast or light syncing from it. Full syncs verify every block
+// anyway, as do nodes which already have the checkpoint locally.
+func (d *Downloader) verifyCheckpoint(p *peer, height uint64) error {
+	cp := d.checkpoint
+	if cp == nil || d.mode == FullSync || d.headHeader().Number.Uint64() >= cp.Number {
+		return nil
+	}
+	if height < cp.Number {
+		glog.V(logger.Debug).Infof("%v: remote height %d below checkpoint #%d", p, height, cp.Number)
+		return errCheckpointMissing
+	}
+	glog.V(logger.Debug).Infof("%v: verifying checkpoint #%d [%x…]", p, cp.Number, cp.Hash[:4])
+	go p.getAbsHeaders(cp.Number, 1, 0, false)
+
+	timeout := time.After(d.requestTTL())
+	for {
+		select {
+		case <-d.cancelCh:
+			return errCancelBlockFetch
+
+		case packet := <-d.headerCh:
+			// Discard anything not from the origin peer
+			if packet.PeerId() != p.id {
+				glog.V(logger.Debug).Infof("Received headers from incorrect peer(%s)", packet.PeerId())
+				break
+			}
+			headers := packet.(*headerPack).headers
+			if len(headers) != 1 || headers[0].Number.Uint64() != cp.Number {
+				glog.V(logger.Debug).Infof("%v: invalid checkpoint header response", p)
+				return errBadPeer
+			}
+			if hash := headers[0].Hash(); hash != cp.Hash {
+				glog.V(logger.Debug).Infof("%v: checkpoint mismatch: have [%x…], want [%x…]", p, hash[:4], cp.Hash[:4])
+				return errCheckpointMismatch
+			}
+			return nil
+
+		case <-timeout:
+			glog.V(logger.Debug).Infof("%v: checkpoint header timeout", p)
+			return errTimeout
+
+		case <-d.bodyCh:
+		case <-d.stateCh:
+		case <-d.receiptCh:
+			// Out of bounds delivery, ignore
+		}
+	}
+}
+
 // findAncestor tries to locate the common ancestor link of the local chain and
 // a remote peers blockchain. In the general case when our node was in sync and
 // on the correct chain, checking the top N links should already get us a match.
@@ -754,8 +866,9 @@ func (d *Downloader) fetchHeaders(p *peer, from uint64) error {
 			glog.V(logger.Detail).Infof("%v: fetching %d skeleton headers from #%d", p, MaxHeaderFetch, from)
 			go p.getAbsHeaders(from+uint64(MaxHeaderFetch)-1, MaxSkeletonSize, MaxHeaderFetch-1, false)
 		} else {
-			glog.V(logger.Detail).Infof("%v: fetching %d full headers from #%d", p, MaxHeaderFetch, from)
-			go p.getAbsHeaders(from, MaxHeaderFetch, 0, false)
+			count := p.HeaderCapacity(d.requestRTT())
+			glog.V(logger.Detail).Infof("%v: fetching %d full headers from #%d", p, count, from)
+			go p.getAbsHeaders(from, count, 0, false)
 		}
 	}
 	// Start pulling the header chain skeleton until all is done
@@ -775,6 +888,11 @@ func (d *Downloader) fetchHeaders(p *peer, from uint64) error {
 			headerReqTimer.UpdateSince(request)
 			timeout.Stop()
 
+			// Track the origin's performance to size its future requests
+			if packet.Items() > 0 {
+				p.UpdateHeaderStats(request, packet.Items())
+			}
+
 			// If the skeleton's finished, pull any remaining head headers directly from the origin
 			if packet.Items() == 0 && skeleton {
 				skeleton = false
@@ -883,7 +1001,12 @@ func (d *Downloader) fetchBodies(from uint64) error {
 	var (
 		deliver = func(packet dataPack) (int, error) {
 			pack := packet.(*bodyPack)
-			return d.queue.DeliverBodies(pack.peerId, pack.transactions, pack.uncles)
+			accepted, err := d.queue.DeliverBodies(pack.peerId, pack.transactions, pack.uncles)
+
+			d.syncStatsLock.Lock()
+			d.syncStatsBodyDone += uint64(accepted)
+			d.syncStatsLock.Unlock()
+			return accepted, err
 		}
 		expire   = func() map[string]int { return d.queue.ExpireBodies(d.requestTTL()) }
 		fetch    = func(p *peer, req *fetchRequest) error { return p.FetchBodies(req) }
@@ -907,7 +1030,12 @@ func (d *Downloader) fetchReceipts(from uint64) error {
 	var (
 		deliver = func(packet dataPack) (int, error) {
 			pack := packet.(*receiptPack)
-			return d.queue.DeliverReceipts(pack.peerId, pack.receipts)
+			accepted, err := d.queue.DeliverReceipts(pack.peerId, pack.receipts)
+
+			d.syncStatsLock.Lock()
+			d.syncStatsReceiptDone += uint64(accepted)
+			d.syncStatsLock.Unlock()
+			return accepted, err
 		}
 		expire   = func() map[string]int { return d.queue.ExpireReceipts(d.requestTTL()) }
 		fetch    = func(p *peer, req *fetchRequest) error { return p.FetchReceipts(req) }
@@ -1398,6 +1526,9 @@ func (d *Downloader) processContent() error {
 				if err == nil && blocks[len(blocks)-1].NumberU64() == pivot {
 					glog.V(logger.Debug).Infof("Committing block #%d [%x…] as the new head", blocks[len(blocks)-1].Number(), blocks[len(blocks)-1].Hash().Bytes()[:4])
 					index, err = len(blocks)-1, d.commitHeadBlock(blocks[len(blocks)-1].Hash())
+					if err == nil {
+						core.DeleteFastSyncPivot(d.stateDB)
+					}
 				}
 			default:
 				index, err = d.insertBlocks(blocks)
@@ -1795,3 +1795,128 @@ func testFastCriticalRestarts(t *testing.T, protocol int, progress bool) {
 	// completed using a single mode of operation, whereas fast-then-slow can result
 	// in arbitrary intermediate state that's not cleanly verifiable.
 }
+
+// Tests that header deliveries made outside of the idle tracked retrievals are
+// accounted for in the peer's header capacity.
+func TestHeaderCapacityTracking(t *testing.T) {
+	p := newPeer("peer", 63, nil, nil, nil, nil, nil, nil)
+	if cap := p.HeaderCapacity(time.Second); cap != 2 {
+		t.Fatalf("initial capacity mismatch: have %d, want %d", cap, 2)
+	}
+	for i := 0; i < 10; i++ {
+		p.UpdateHeaderStats(time.Now().Add(-100*time.Millisecond), MaxHeaderFetch)
+	}
+	if cap := p.HeaderCapacity(time.Second); cap != MaxHeaderFetch {
+		t.Fatalf("tracked capacity mismatch: have %d, want %d", cap, MaxHeaderFetch)
+	}
+	p.UpdateHeaderStats(time.Now(), 0)
+	if cap := p.HeaderCapacity(time.Second); cap != 2 {
+		t.Fatalf("failed delivery capacity mismatch: have %d, want %d", cap, 2)
+	}
+}
+
+// Tests that an interrupted fast sync resumes with the pivot block persisted by
+// the previous run, and that the pivot is cleared once the sync completes.
+func TestFastSyncPivotResume63(t *testing.T) { testFastSyncPivotResume(t, 63) }
+func TestFastSyncPivotResume64(t *testing.T) { testFastSyncPivotResume(t, 64) }
+
+func testFastSyncPivotResume(t *testing.T, protocol int) {
+	t.Parallel()
+
+	tester := newTester()
+	defer tester.terminate()
+
+	// Create a large enough blockchain to fast sync on and persist an old pivot
+	targetBlocks := fsMinFullBlocks + 2*fsPivotInterval - 15
+	hashes, headers, blocks, receipts := tester.makeChain(targetBlocks, 0, tester.genesis, nil, false)
+	tester.newPeer("peer", protocol, hashes, headers, blocks, receipts)
+
+	stored := uint64(fsPivotInterval / 2)
+	core.WriteFastSyncPivot(tester.stateDb, stored)
+
+	var pivot uint64
+	tester.downloader.syncInitHook = func(uint64, uint64) {
+		pivot = tester.downloader.queue.FastSyncPivot()
+	}
+	if err := tester.sync("peer", nil, FastSync); err != nil {
+		t.Fatalf("failed to synchronise blocks: %v", err)
+	}
+	if hs := len(tester.ownHeaders); hs != targetBlocks+1 {
+		t.Fatalf("synchronised headers mismatch: have %v, want %v", hs, targetBlocks+1)
+	}
+	if rs := len(tester.ownReceipts); rs != int(stored)+1 {
+		t.Errorf("synchronised receipts mismatch: have %v, want %v", rs, stored+1)
+	}
+	if pivot != stored {
+		t.Errorf("resumed pivot mismatch: have %d, want %d", pivot, stored)
+	}
+	if left := core.GetFastSyncPivot(tester.stateDb); left != 0 {
+		t.Errorf("pivot not cleared after sync: have %d", left)
+	}
+}
+
+// Tests that fast and light syncs are only done with peers whose chain contains
+// the trusted checkpoint.
+func TestCheckpointEnforcement63Fast(t *testing.T)  { testCheckpointEnforcement(t, 63, FastSync) }
+func TestCheckpointEnforcement64Fast(t *testing.T)  { testCheckpointEnforcement(t, 64, FastSync) }
+func TestCheckpointEnforcement64Light(t *testing.T) { testCheckpointEnforcement(t, 64, LightSync) }
+
+func testCheckpointEnforcement(t *testing.T, protocol int, mode SyncMode) {
+	t.Parallel()
+
+	tester := newTester()
+	defer tester.terminate()
+
+	// Create a chain with a checkpoint, a fork of it and a short one not reaching it
+	targetBlocks := blockCacheLimit - 15
+	hashesA, hashesB, headersA, headersB, blocksA, blocksB, receiptsA, receiptsB := tester.makeChainFork(targetBlocks, targetBlocks*3/4, tester.genesis, nil, false)
+	hashesC, headersC, blocksC, receiptsC := tester.makeChain(targetBlocks/4, 0, tester.genesis, nil, false)
+
+	number := uint64(targetBlocks / 2)
+	tester.downloader.SetCheckpoint(&params.TrustedCheckpoint{Number: number, Hash: hashesA[targetBlocks-int(number)]})
+	tester.downloader.dropPeer = func(id string) {} // Retain the peers, the test checks the errors
+
+	tester.newPeer("fork", protocol, hashesB, headersB, blocksB, receiptsB)
+	if err := tester.sync("fork", nil, mode); err != errCheckpointMismatch {
+		t.Fatalf("forked sync error mismatch: have %v, want %v", err, errCheckpointMismatch)
+	}
+	tester.newPeer("short", protocol, hashesC, headersC, blocksC, receiptsC)
+	if err := tester.sync("short", nil, mode); err != errCheckpointMissing {
+		t.Fatalf("short sync error mismatch: have %v, want %v", err, errCheckpointMissing)
+	}
+	tester.newPeer("valid", protocol, hashesA, headersA, blocksA, receiptsA)
+	if err := tester.sync("valid", nil, mode); err != nil {
+		t.Fatalf("failed to synchronise blocks: %v", err)
+	}
+	assertOwnChain(t, tester, targetBlocks+1)
+}
+
+// Tests that the downloaded block bodies and receipts are accounted for in the
+// reported sync progress.
+func TestSyncProgressContent63(t *testing.T) { testSyncProgressContent(t, 63) }
+func TestSyncProgressContent64(t *testing.T) { testSyncProgressContent(t, 64) }
+
+func testSyncProgressContent(t *testing.T, protocol int) {
+	t.Parallel()
+
+	tester := newTester()
+	defer tester.terminate()
+
+	targetBlocks := blockCacheLimit - 15
+	hashes, headers, blocks, receipts := tester.makeChain(targetBlocks, 0, tester.genesis, nil, false)
+	tester.newPeer("peer", protocol, hashes, headers, blocks, receipts)
+
+	if err := tester.sync("peer", nil, FastSync); err != nil {
+		t.Fatalf("failed to synchronise blocks: %v", err)
+	}
+	progress := tester.downloader.Progress()
+	if progress.PulledBodies == 0 || progress.KnownBodies != progress.PulledBodies {
+		t.Errorf("body progress mismatch: pulled %d, known %d", progress.PulledBodies, progress.KnownBodies)
+	}
+	if progress.PulledReceipts == 0 || progress.KnownReceipts != progress.PulledReceipts {
+		t.Errorf("receipt progress mismatch: pulled %d, known %d", progress.PulledReceipts, progress.KnownReceipts)
+	}
+	if progress.ETA != 0 {
+		t.Errorf("finished sync reported ETA %v", progress.ETA)
+	}
+}
@@ -257,6 +257,22 @@ func (p *peer) setIdle(started time.Time, delivered int, throughput *float64, id
 	p.lock.Lock()
 	defer p.lock.Unlock()
 
+	p.measure(started, delivered, throughput)
+}
+
+// UpdateHeaderStats integrates a header delivery made outside of the idle tracked
+// retrievals (i.e. skeleton and chain head fetches from the origin peer) into the
+// peer's estimated throughput and round trip time.
+func (p *peer) UpdateHeaderStats(started time.Time, delivered int) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.measure(started, delivered, &p.headerThroughput)
+}
+
+// measure updates a throughput estimate and the peer's round trip time with the
+// results of a retrieval just finished. The lock must be held.
+func (p *peer) measure(started time.Time, delivered int, throughput *float64) {
 	// If nothing was delivered (hard timeout / unavailable data), reduce throughput to minimum
 	if delivered == 0 {
 		*throughput = 0
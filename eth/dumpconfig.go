@@ -0,0 +1,214 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/EarthDollar/go-earthdollar/common"
+	"github.com/EarthDollar/go-earthdollar/common/hexutil"
+	"github.com/EarthDollar/go-earthdollar/core"
+	"github.com/EarthDollar/go-earthdollar/core/types"
+	"github.com/EarthDollar/go-earthdollar/eth/filters"
+	"github.com/EarthDollar/go-earthdollar/ethdb"
+	"github.com/EarthDollar/go-earthdollar/miner"
+	"github.com/EarthDollar/go-earthdollar/params"
+)
+
+// ConfigDump is the serializable snapshot of the effective configuration of an
+// Ethereum service: the network preset is applied, the omitted options are
+// replaced by the defaults the service would use and the derived values (cache
+// allowances, genesis hash) are computed.
+type ConfigDump struct {
+	Network     string              `json:"network,omitempty"`
+	NetworkId   int                 `json:"networkId"`
+	GenesisHash common.Hash         `json:"genesisHash"`
+	Genesis     string              `json:"genesis,omitempty"`
+	ChainConfig *params.ChainConfig `json:"chainConfig"`
+
+	FastSync        bool `json:"fastSync"`
+	LightMode       bool `json:"lightMode"`
+	LightServ       int  `json:"lightServ"`
+	LightPeers      int  `json:"lightPeers"`
+	MaxPeers        int  `json:"maxPeers"`
+	MaxDownloadRate int  `json:"maxDownloadRate"`
+	MaxUploadRate   int  `json:"maxUploadRate"`
+
+	Cache              CacheDump `json:"cache"`
+	StateFlushInterval uint64    `json:"stateFlushInterval"`
+	TxLookupLimit      uint64    `json:"txLookupLimit"`
+	DatabaseHandles    int       `json:"databaseHandles"`
+
+	DocRoot   string        `json:"docRoot"`
+	AutoDAG   bool          `json:"autoDAG"`
+	PowFake   bool          `json:"powFake"`
+	PowTest   bool          `json:"powTest"`
+	PowShared bool          `json:"powShared"`
+	ExtraData hexutil.Bytes `json:"extraData"`
+
+	Etherbase          common.Address `json:"etherbase"`
+	RotatingEtherbases []miner.Payout `json:"rotatingEtherbases,omitempty"`
+	GasPrice           *big.Int       `json:"gasPrice"`
+	MinerThreads       int            `json:"minerThreads"`
+	SolcPath           string         `json:"solcPath"`
+
+	GpoMinGasPrice          *big.Int `json:"gpoMinGasPrice"`
+	GpoMaxGasPrice          *big.Int `json:"gpoMaxGasPrice"`
+	GpoFullBlockRatio       int      `json:"gpoFullBlockRatio"`
+	GpobaseStepDown         int      `json:"gpobaseStepDown"`
+	GpobaseStepUp           int      `json:"gpobaseStepUp"`
+	GpobaseCorrectionFactor int      `json:"gpobaseCorrectionFactor"`
+
+	EnablePreimageRecording bool   `json:"enablePreimageRecording"`
+	ParallelExecution       bool   `json:"parallelExecution"`
+	TriePreimages           bool   `json:"triePreimages"`
+	ReadyMaxHeadAge         string `json:"readyMaxHeadAge"`
+	ShutdownTimeout         string `json:"shutdownTimeout"`
+
+	Filter FilterDump `json:"filter"`
+}
+
+// CacheDump is the cache budget of a ConfigDump along with its distribution.
+type CacheDump struct {
+	Size          int         `json:"size"`          // Total memory budget in MB
+	Ratios        CacheRatios `json:"ratios"`        // Effective distribution weights
+	DatabaseMB    int         `json:"databaseMB"`    // Allowance of the database block cache
+	TrieMB        int         `json:"trieMB"`        // Allowance of the trie node cache
+	SnapshotMB    int         `json:"snapshotMB"`    // Allowance of the state snapshot cache
+	TrieCacheGens int         `json:"trieCacheGens"` // Trie cache generations retained
+	PastTries     int         `json:"pastTries"`     // Past state tries retained
+}
+
+// FilterDump is the log filtering configuration of a ConfigDump.
+type FilterDump struct {
+	MaxBlockRange uint64 `json:"maxBlockRange"`
+	MaxLogs       int    `json:"maxLogs"`
+	Timeout       string `json:"timeout"`
+}
+
+// Dump resolves the effective configuration the Ethereum service would run with
+// if started with config. The config itself is left untouched.
+func (config *Config) Dump() (*ConfigDump, error) {
+	effective := *config
+	if err := ApplyNetwork(&effective); err != nil {
+		return nil, err
+	}
+	genesis, err := genesisHash(&effective)
+	if err != nil {
+		return nil, err
+	}
+	split, gens, tries := cacheAllowances(&effective)
+
+	timeout := effective.FilterConfig.Timeout
+	if timeout <= 0 {
+		timeout = filters.DefaultConfig.Timeout
+	}
+	shutdown := effective.ShutdownTimeout
+	if shutdown <= 0 {
+		shutdown = DefaultShutdownTimeout
+	}
+	return &ConfigDump{
+		Network:     effective.Network,
+		NetworkId:   effective.NetworkId,
+		GenesisHash: genesis,
+		Genesis:     effective.Genesis,
+		ChainConfig: effective.ChainConfig,
+
+		FastSync:        effective.FastSync,
+		LightMode:       effective.LightMode,
+		LightServ:       effective.LightServ,
+		LightPeers:      effective.LightPeers,
+		MaxPeers:        effective.MaxPeers,
+		MaxDownloadRate: effective.MaxDownloadRate,
+		MaxUploadRate:   effective.MaxUploadRate,
+
+		Cache: CacheDump{
+			Size:          effective.CacheSize,
+			Ratios:        effective.CacheRatios.effective(),
+			DatabaseMB:    split.Database,
+			TrieMB:        split.Trie,
+			SnapshotMB:    split.Snapshot,
+			TrieCacheGens: gens,
+			PastTries:     tries,
+		},
+		StateFlushInterval: effective.StateFlushInterval,
+		TxLookupLimit:      effective.TxLookupLimit,
+		DatabaseHandles:    effective.DatabaseHandles,
+
+		DocRoot:   effective.DocRoot,
+		AutoDAG:   effective.AutoDAG,
+		PowFake:   effective.PowFake,
+		PowTest:   effective.PowTest,
+		PowShared: effective.PowShared,
+		ExtraData: effective.ExtraData,
+
+		Etherbase:          effective.Etherbase,
+		RotatingEtherbases: effective.RotatingEtherbases,
+		GasPrice:           effective.GasPrice,
+		MinerThreads:       effective.MinerThreads,
+		SolcPath:           effective.SolcPath,
+
+		GpoMinGasPrice:          effective.GpoMinGasPrice,
+		GpoMaxGasPrice:          effective.GpoMaxGasPrice,
+		GpoFullBlockRatio:       effective.GpoFullBlockRatio,
+		GpobaseStepDown:         effective.GpobaseStepDown,
+		GpobaseStepUp:           effective.GpobaseStepUp,
+		GpobaseCorrectionFactor: effective.GpobaseCorrectionFactor,
+
+		EnablePreimageRecording: effective.EnablePreimageRecording,
+		ParallelExecution:       effective.ParallelExecution,
+		TriePreimages:           effective.TriePreimages,
+		ReadyMaxHeadAge:         effective.ReadyMaxHeadAge.String(),
+		ShutdownTimeout:         shutdown.String(),
+
+		Filter: FilterDump{
+			MaxBlockRange: effective.FilterConfig.MaxBlockRange,
+			MaxLogs:       effective.FilterConfig.MaxLogs,
+			Timeout:       timeout.String(),
+		},
+	}, nil
+}
+
+// genesisHash returns the hash of the genesis block a fresh chain database would
+// be seeded with by the given (network resolved) config.
+func genesisHash(config *Config) (common.Hash, error) {
+	if config.TestGenesisBlock != nil {
+		return config.TestGenesisBlock.Hash(), nil
+	}
+	if config.Genesis == "" && config.Network != "" {
+		if network, err := LookupNetwork(config.Network); err == nil && network.GenesisHash != (common.Hash{}) {
+			return network.GenesisHash, nil
+		}
+	}
+	db, _ := ethdb.NewMemDatabase()
+	defer db.Close()
+
+	var (
+		block *types.Block
+		err   error
+	)
+	if config.Genesis != "" {
+		block, err = core.WriteGenesisBlock(db, strings.NewReader(config.Genesis))
+	} else {
+		block, err = core.WriteDefaultGenesisBlock(db)
+	}
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return block.Hash(), nil
+}
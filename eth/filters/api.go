@@ -38,6 +38,22 @@ var (
 	deadline = 5 * time.Minute // consider a filter inactive if it has not been polled for within deadline
 )
 
+// Config contains the limits enforced on historical log queries, protecting the
+// node from requests scanning or returning unreasonable amounts of data, and the
+// lifetime of installed filters. Zero limits disable the respective check.
+type Config struct {
+	MaxBlockRange uint64        // Maximum number of blocks a single query (or page) may span
+	MaxLogs       int           // Maximum number of logs returned by a single query (or page)
+	Timeout       time.Duration // Inactivity period after which installed filters are uninstalled (0 = default)
+}
+
+// DefaultConfig contains the default limits for log queries.
+var DefaultConfig = Config{
+	MaxBlockRange: 10000,
+	MaxLogs:       10000,
+	Timeout:       deadline,
+}
+
 // filter is a helper struct that holds meta information over the filter type
 // and associated subscription in the event system.
 type filter struct {
@@ -54,6 +70,7 @@ type filter struct {
 type PublicFilterAPI struct {
 	backend   Backend
 	useMipMap bool
+	config    Config
 	mux       *event.TypeMux
 	quit      chan struct{}
 	chainDb   ethdb.Database
@@ -62,11 +79,16 @@ type PublicFilterAPI struct {
 	filters   map[rpc.ID]*filter
 }
 
-// NewPublicFilterAPI returns a new PublicFilterAPI instance.
-func NewPublicFilterAPI(backend Backend, lightMode bool) *PublicFilterAPI {
+// NewPublicFilterAPI returns a new PublicFilterAPI instance, enforcing the given
+// limits on historical log queries.
+func NewPublicFilterAPI(backend Backend, lightMode bool, config Config) *PublicFilterAPI {
+	if config.Timeout <= 0 {
+		config.Timeout = deadline
+	}
 	api := &PublicFilterAPI{
 		backend:   backend,
 		useMipMap: !lightMode,
+		config:    config,
 		mux:       backend.EventMux(),
 		chainDb:   backend.ChainDb(),
 		events:    NewEventSystem(backend.EventMux(), backend, lightMode),
@@ -78,10 +100,10 @@ func NewPublicFilterAPI(backend Backend, lightMode bool) *PublicFilterAPI {
 	return api
 }
 
-// timeoutLoop runs every 5 minutes and deletes filters that have not been recently used.
-// Tt is started when the api is created.
+// timeoutLoop runs every filter timeout period and deletes filters that have not
+// been recently used. It is started when the api is created.
 func (api *PublicFilterAPI) timeoutLoop() {
-	ticker := time.NewTicker(5 * time.Minute)
+	ticker := time.NewTicker(api.config.Timeout)
 	for {
 		<-ticker.C
 		api.filtersMu.Lock()
@@ -99,20 +121,24 @@ func (api *PublicFilterAPI) timeoutLoop() {
 }
 
 // NewPendingTransactionFilter creates a filter that fetches pending transaction hashes
-// as transactions enter the pending state.
+// as transactions enter the pending state. If criteria are given, only hashes of the
+// transactions matching them are returned.
 //
 // It is part of the filter package because this filter can be used throug the
 // `eth_getFilterChanges` polling method that is also used for log filters.
 //
 // https://github.com/ethereum/wiki/wiki/JSON-RPC#eth_newpendingtransactionfilter
-func (api *PublicFilterAPI) NewPendingTransactionFilter() rpc.ID {
+func (api *PublicFilterAPI) NewPendingTransactionFilter(crit *TransactionCriteria) (rpc.ID, error) {
+	if err := crit.validate(); err != nil {
+		return rpc.ID(""), err
+	}
 	var (
 		pendingTxs   = make(chan common.Hash)
-		pendingTxSub = api.events.SubscribePendingTxEvents(pendingTxs)
+		pendingTxSub = api.events.SubscribePendingTxEvents(crit, pendingTxs)
 	)
 
 	api.filtersMu.Lock()
-	api.filters[pendingTxSub.ID] = &filter{typ: PendingTransactionsSubscription, deadline: time.NewTimer(deadline), hashes: make([]common.Hash, 0), s: pendingTxSub}
+	api.filters[pendingTxSub.ID] = &filter{typ: PendingTransactionsSubscription, deadline: time.NewTimer(api.config.Timeout), hashes: make([]common.Hash, 0), s: pendingTxSub}
 	api.filtersMu.Unlock()
 
 	go func() {
@@ -133,22 +159,26 @@ func (api *PublicFilterAPI) NewPendingTransactionFilter() rpc.ID {
 		}
 	}()
 
-	return pendingTxSub.ID
+	return pendingTxSub.ID, nil
 }
 
 // NewPendingTransactions creates a subscription that is triggered each time a transaction
 // enters the transaction pool and was signed from one of the transactions this nodes manages.
-func (api *PublicFilterAPI) NewPendingTransactions(ctx context.Context) (*rpc.Subscription, error) {
+// If criteria are given, only the transactions matching them are notified.
+func (api *PublicFilterAPI) NewPendingTransactions(ctx context.Context, crit *TransactionCriteria) (*rpc.Subscription, error) {
 	notifier, supported := rpc.NotifierFromContext(ctx)
 	if !supported {
 		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
 	}
+	if err := crit.validate(); err != nil {
+		return nil, err
+	}
 
 	rpcSub := notifier.CreateSubscription()
 
 	go func() {
 		txHashes := make(chan common.Hash)
-		pendingTxSub := api.events.SubscribePendingTxEvents(txHashes)
+		pendingTxSub := api.events.SubscribePendingTxEvents(crit, txHashes)
 
 		for {
 			select {
@@ -178,7 +208,7 @@ func (api *PublicFilterAPI) NewBlockFilter() rpc.ID {
 	)
 
 	api.filtersMu.Lock()
-	api.filters[headerSub.ID] = &filter{typ: BlocksSubscription, deadline: time.NewTimer(deadline), hashes: make([]common.Hash, 0), s: headerSub}
+	api.filters[headerSub.ID] = &filter{typ: BlocksSubscription, deadline: time.NewTimer(api.config.Timeout), hashes: make([]common.Hash, 0), s: headerSub}
 	api.filtersMu.Unlock()
 
 	go func() {
@@ -270,6 +300,29 @@ func (api *PublicFilterAPI) Logs(ctx context.Context, crit FilterCriteria) (*rpc
 	return rpcSub, nil
 }
 
+// TransactionCriteria represents a request to be notified only of the pending
+// transactions matching all of the given conditions. Empty conditions match any
+// transaction.
+type TransactionCriteria struct {
+	From        []common.Address `json:"from"`        // Senders, any of which to match
+	To          []common.Address `json:"to"`          // Recipients, any of which to match
+	MinGasPrice *hexutil.Big     `json:"minGasPrice"` // Minimum gas price to match
+	Selectors   []hexutil.Bytes  `json:"selectors"`   // 4 byte method selectors, any of which to match
+}
+
+// validate checks whether the transaction criteria are well formed.
+func (crit *TransactionCriteria) validate() error {
+	if crit == nil {
+		return nil
+	}
+	for i, selector := range crit.Selectors {
+		if len(selector) != 4 {
+			return fmt.Errorf("invalid method selector at index %d: have %d bytes, want 4", i, len(selector))
+		}
+	}
+	return nil
+}
+
 // FilterCriteria represents a request to create a new filter.
 type FilterCriteria struct {
 	FromBlock *big.Int
@@ -299,7 +352,7 @@ func (api *PublicFilterAPI) NewFilter(crit FilterCriteria) (rpc.ID, error) {
 	}
 
 	api.filtersMu.Lock()
-	api.filters[logsSub.ID] = &filter{typ: LogsSubscription, crit: crit, deadline: time.NewTimer(deadline), logs: make([]*types.Log, 0), s: logsSub}
+	api.filters[logsSub.ID] = &filter{typ: LogsSubscription, crit: crit, deadline: time.NewTimer(api.config.Timeout), logs: make([]*types.Log, 0), s: logsSub}
 	api.filtersMu.Unlock()
 
 	go func() {
@@ -325,23 +378,63 @@ func (api *PublicFilterAPI) NewFilter(crit FilterCriteria) (rpc.ID, error) {
 
 // GetLogs returns logs matching the given argument that are stored within the state.
 //
+// Queries spanning more blocks or matching more logs than the configured limits
+// are rejected, in which case GetLogsPage should be used to retrieve the logs.
+//
 // https://github.com/ethereum/wiki/wiki/JSON-RPC#eth_getlogs
 func (api *PublicFilterAPI) GetLogs(ctx context.Context, crit FilterCriteria) ([]*types.Log, error) {
-	if crit.FromBlock == nil {
-		crit.FromBlock = big.NewInt(rpc.LatestBlockNumber.Int64())
-	}
-	if crit.ToBlock == nil {
-		crit.ToBlock = big.NewInt(rpc.LatestBlockNumber.Int64())
-	}
+	logs, err := api.getLogs(ctx, crit)
+	return returnLogs(logs), err
+}
 
-	filter := New(api.backend, api.useMipMap)
-	filter.SetBeginBlock(crit.FromBlock.Int64())
-	filter.SetEndBlock(crit.ToBlock.Int64())
-	filter.SetAddresses(crit.Addresses)
-	filter.SetTopics(crit.Topics)
+// LogCursor is the position within a log query at which a paginated retrieval
+// should be resumed.
+type LogCursor struct {
+	Block hexutil.Uint64 `json:"block"` // Number of the block to resume from
+	Index hexutil.Uint   `json:"index"` // Number of matching logs in the block already returned
+}
 
-	logs, err := filter.Find(ctx)
-	return returnLogs(logs), err
+// LogsPage is a batch of logs returned by a paginated log query, along with the
+// cursor at which to continue the retrieval.
+type LogsPage struct {
+	Logs   []*types.Log `json:"logs"`
+	Cursor *LogCursor   `json:"cursor"` // Continuation point, nil if the query was exhausted
+}
+
+// GetLogsPage returns a batch of logs matching the given argument, starting at
+// the cursor (or the beginning of the query if nil). Each page spans at most the
+// configured maximum number of blocks and contains at most the maximum number
+// of logs. The returned cursor must be passed to the next call to continue the
+// retrieval; the query is exhausted when no cursor is returned.
+func (api *PublicFilterAPI) GetLogsPage(ctx context.Context, crit FilterCriteria, cursor *LogCursor) (*LogsPage, error) {
+	begin, end, err := api.resolveRange(ctx, crit)
+	if err != nil {
+		return nil, err
+	}
+	if cursor != nil {
+		if uint64(cursor.Block) < begin || uint64(cursor.Block) > end {
+			return nil, errors.New("cursor outside of the query range")
+		}
+		begin = uint64(cursor.Block)
+	} else {
+		cursor = new(LogCursor)
+	}
+	if begin > end {
+		return &LogsPage{Logs: []*types.Log{}}, nil
+	}
+	// Clamp the page to the maximum permitted block range
+	last := end
+	if limit := api.config.MaxBlockRange; limit > 0 && last-begin >= limit {
+		last = begin + limit - 1
+	}
+	logs, next, err := api.collectLogs(ctx, crit, begin, last, int(cursor.Index), api.config.MaxLogs)
+	if err != nil {
+		return nil, err
+	}
+	if next == nil && last < end {
+		next = &LogCursor{Block: hexutil.Uint64(last + 1)}
+	}
+	return &LogsPage{Logs: returnLogs(logs), Cursor: next}, nil
 }
 
 // UninstallFilter removes the filter with the given filter id.
@@ -373,26 +466,88 @@ func (api *PublicFilterAPI) GetFilterLogs(ctx context.Context, id rpc.ID) ([]*ty
 	if !found || f.typ != LogsSubscription {
 		return nil, fmt.Errorf("filter not found")
 	}
+	logs, err := api.getLogs(ctx, f.crit)
+	if err != nil {
+		return nil, err
+	}
+	return returnLogs(logs), nil
+}
 
-	filter := New(api.backend, api.useMipMap)
-	if f.crit.FromBlock != nil {
-		filter.SetBeginBlock(f.crit.FromBlock.Int64())
-	} else {
-		filter.SetBeginBlock(rpc.LatestBlockNumber.Int64())
+// getLogs retrieves all the logs matching the criteria, enforcing the configured
+// query limits.
+func (api *PublicFilterAPI) getLogs(ctx context.Context, crit FilterCriteria) ([]*types.Log, error) {
+	begin, end, err := api.resolveRange(ctx, crit)
+	if err != nil {
+		return nil, err
 	}
-	if f.crit.ToBlock != nil {
-		filter.SetEndBlock(f.crit.ToBlock.Int64())
-	} else {
-		filter.SetEndBlock(rpc.LatestBlockNumber.Int64())
+	if begin > end {
+		return nil, nil
 	}
-	filter.SetAddresses(f.crit.Addresses)
-	filter.SetTopics(f.crit.Topics)
-
-	logs, err := filter.Find(ctx)
+	if limit := api.config.MaxBlockRange; limit > 0 && end-begin >= limit {
+		return nil, fmt.Errorf("query spans more than %d blocks, use eth_getLogsPage", limit)
+	}
+	logs, next, err := api.collectLogs(ctx, crit, begin, end, 0, api.config.MaxLogs)
 	if err != nil {
 		return nil, err
 	}
-	return returnLogs(logs), nil
+	if next != nil {
+		return nil, fmt.Errorf("query returned more than %d results, use eth_getLogsPage", api.config.MaxLogs)
+	}
+	return logs, nil
+}
+
+// resolveRange converts the block range of the filter criteria into absolute
+// block numbers, substituting the current head for the latest (and pending)
+// block.
+func (api *PublicFilterAPI) resolveRange(ctx context.Context, crit FilterCriteria) (uint64, uint64, error) {
+	head, err := api.backend.HeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if head == nil {
+		return 0, 0, err
+	}
+	resolve := func(number *big.Int) uint64 {
+		if number == nil || number.Sign() < 0 {
+			return head.Number.Uint64()
+		}
+		return number.Uint64()
+	}
+	return resolve(crit.FromBlock), resolve(crit.ToBlock), nil
+}
+
+// collectLogs retrieves the logs matching the criteria within the given block
+// range, skipping the first few matches of the first block. If more than limit
+// logs match (zero meaning no limit), the first limit ones are returned along
+// with the cursor pointing to the first omitted one.
+func (api *PublicFilterAPI) collectLogs(ctx context.Context, crit FilterCriteria, begin, end uint64, skip, limit int) ([]*types.Log, *LogCursor, error) {
+	filter := New(api.backend, api.useMipMap)
+	filter.SetBeginBlock(int64(begin))
+	filter.SetEndBlock(int64(end))
+	filter.SetAddresses(crit.Addresses)
+	filter.SetTopics(crit.Topics)
+
+	var logs []*types.Log
+	for {
+		found, err := filter.FindOnce(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(found) == 0 {
+			return logs, nil, nil
+		}
+		// FindOnce moves the filter past the block the logs were found in
+		number, offset := uint64(filter.begin-1), 0
+		if number == begin && skip > 0 {
+			if skip > len(found) {
+				skip = len(found)
+			}
+			found, offset = found[skip:], skip
+		}
+		if limit > 0 && len(logs)+len(found) > limit {
+			taken := limit - len(logs)
+			logs = append(logs, found[:taken]...)
+			return logs, &LogCursor{Block: hexutil.Uint64(number), Index: hexutil.Uint(offset + taken)}, nil
+		}
+		logs = append(logs, found...)
+	}
 }
 
 // GetFilterChanges returns the logs for the filter with the given id since
@@ -412,7 +567,7 @@ func (api *PublicFilterAPI) GetFilterChanges(id rpc.ID) (interface{}, error) {
 			// receive timer value and reset timer
 			<-f.deadline.C
 		}
-		f.deadline.Reset(deadline)
+		f.deadline.Reset(api.config.Timeout)
 
 		switch f.typ {
 		case PendingTransactionsSubscription, BlocksSubscription:
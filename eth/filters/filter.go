@@ -17,6 +17,7 @@
 package filters
 
 import (
+	"bytes"
 	"math"
 	"time"
 
@@ -24,6 +25,7 @@ import (
 
 	"github.com/EarthDollar/go-earthdollar/common"
 	"github.com/EarthDollar/go-earthdollar/core"
+	"github.com/EarthDollar/go-earthdollar/core/bloombits"
 	"github.com/EarthDollar/go-earthdollar/core/types"
 	"github.com/EarthDollar/go-earthdollar/ethdb"
 	"github.com/EarthDollar/go-earthdollar/event"
@@ -36,6 +38,18 @@ type Backend interface {
 	EventMux() *event.TypeMux
 	HeaderByNumber(ctx context.Context, blockNr rpc.BlockNumber) (*types.Header, error)
 	GetReceipts(ctx context.Context, blockHash common.Hash) (types.Receipts, error)
+	SubscribeTxsPreEvent(ch chan<- core.TxsPreEvent) event.Subscription
+	SubscribeChainEvent(ch chan<- core.ChainEvent) event.Subscription
+	SubscribeRemovedLogsEvent(ch chan<- core.RemovedLogsEvent) event.Subscription
+	SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscription
+
+	// BloomStatus returns the number of blocks in a bloombits index section and
+	// the number of sections already indexed.
+	BloomStatus() (uint64, uint64)
+
+	// GetBloomBits retrieves the decompressed bit vector of a bloom bit for all
+	// the blocks of an indexed section.
+	GetBloomBits(ctx context.Context, bit uint, section uint64) ([]byte, error)
 }
 
 // Filter can be used to retrieve and filter logs.
@@ -91,6 +105,9 @@ func (f *Filter) SetTopics(topics [][]common.Hash) {
 // updating the start point of the filter accordingly. If no results are
 // found, a nil slice is returned.
 func (f *Filter) FindOnce(ctx context.Context) ([]*types.Log, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	head, _ := f.backend.HeaderByNumber(ctx, rpc.LatestBlockNumber)
 	if head == nil {
 		return nil, nil
@@ -105,6 +122,19 @@ func (f *Filter) FindOnce(ctx context.Context) ([]*types.Log, error) {
 	if f.end == -1 {
 		endBlockNo = headBlockNumber
 	}
+	// Search the already indexed chain sections through the bloombits index
+	if size, sections := f.backend.BloomStatus(); beginBlockNo < sections*size && beginBlockNo <= endBlockNo {
+		end := sections*size - 1
+		if end > endBlockNo {
+			end = endBlockNo
+		}
+		logs, blockNumber, err := f.indexedLogs(ctx, beginBlockNo, end, size)
+		if len(logs) > 0 || err != nil || end == endBlockNo {
+			f.begin = int64(blockNumber + 1)
+			return logs, err
+		}
+		beginBlockNo = end + 1
+	}
 
 	// if no addresses are present we can't make use of fast search which
 	// uses the mipmap bloom filters to check for fast inclusion and uses
@@ -115,9 +145,9 @@ func (f *Filter) FindOnce(ctx context.Context) ([]*types.Log, error) {
 		return logs, err
 	}
 
-	logs, blockNumber := f.mipFind(beginBlockNo, endBlockNo, 0)
+	logs, blockNumber, err := f.mipFind(ctx, beginBlockNo, endBlockNo, 0)
 	f.begin = int64(blockNumber + 1)
-	return logs, nil
+	return logs, err
 }
 
 // Run filters logs with the current parameters set
@@ -131,7 +161,7 @@ func (f *Filter) Find(ctx context.Context) (logs []*types.Log, err error) {
 	}
 }
 
-func (f *Filter) mipFind(start, end uint64, depth int) (logs []*types.Log, blockNumber uint64) {
+func (f *Filter) mipFind(ctx context.Context, start, end uint64, depth int) (logs []*types.Log, blockNumber uint64, err error) {
 	level := core.MIPMapLevels[depth]
 	// normalise numerator so we can work in level specific batches and
 	// work with the proper range checks
@@ -150,25 +180,31 @@ func (f *Filter) mipFind(start, end uint64, depth int) (logs []*types.Log, block
 				start := uint64(math.Max(float64(num), float64(start)))
 				end := uint64(math.Min(float64(num+level-1), float64(end)))
 				if depth+1 == len(core.MIPMapLevels) {
-					l, blockNumber, _ := f.getLogs(context.Background(), start, end)
-					if len(l) > 0 {
-						return l, blockNumber
+					l, blockNumber, err := f.getLogs(ctx, start, end)
+					if len(l) > 0 || err != nil {
+						return l, blockNumber, err
 					}
 				} else {
-					l, blockNumber := f.mipFind(start, end, depth+1)
-					if len(l) > 0 {
-						return l, blockNumber
+					l, blockNumber, err := f.mipFind(ctx, start, end, depth+1)
+					if len(l) > 0 || err != nil {
+						return l, blockNumber, err
 					}
 				}
 			}
 		}
 	}
 
-	return nil, end
+	return nil, end, nil
 }
 
 func (f *Filter) getLogs(ctx context.Context, start, end uint64) (logs []*types.Log, blockNumber uint64, err error) {
 	for i := start; i <= end; i++ {
+		// Abort the scan if the request was cancelled (e.g. client disconnected)
+		select {
+		case <-ctx.Done():
+			return nil, end, ctx.Err()
+		default:
+		}
 		blockNumber := rpc.BlockNumber(i)
 		header, err := f.backend.HeaderByNumber(ctx, blockNumber)
 		if header == nil || err != nil {
@@ -178,16 +214,9 @@ func (f *Filter) getLogs(ctx context.Context, start, end uint64) (logs []*types.
 		// Use bloom filtering to see if this block is interesting given the
 		// current parameters
 		if f.bloomFilter(header.Bloom) {
-			// Get the logs of the block
-			receipts, err := f.backend.GetReceipts(ctx, header.Hash())
-			if err != nil {
+			if logs, err = f.blockLogs(ctx, header); err != nil {
 				return nil, end, err
 			}
-			var unfiltered []*types.Log
-			for _, receipt := range receipts {
-				unfiltered = append(unfiltered, ([]*types.Log)(receipt.Logs)...)
-			}
-			logs = filterLogs(unfiltered, nil, nil, f.addresses, f.topics)
 			if len(logs) > 0 {
 				return logs, uint64(blockNumber), nil
 			}
@@ -197,6 +226,89 @@ func (f *Filter) getLogs(ctx context.Context, start, end uint64) (logs []*types.
 	return logs, end, nil
 }
 
+// indexedLogs returns the logs of the first block within the given range that
+// matches the filter criteria, using the bloombits index to find the candidate
+// blocks. The entire range must be covered by indexed sections.
+func (f *Filter) indexedLogs(ctx context.Context, start, end, size uint64) ([]*types.Log, uint64, error) {
+	// Assemble the bloom filter groups: any address and any topic per position
+	var filters [][][]byte
+	if len(f.addresses) > 0 {
+		group := make([][]byte, len(f.addresses))
+		for i, address := range f.addresses {
+			group[i] = address.Bytes()
+		}
+		filters = append(filters, group)
+	}
+	for _, topics := range f.topics {
+		// Empty topic lists never match, wildcards leave the position unfiltered
+		if len(topics) == 0 {
+			return nil, end, nil
+		}
+		group := make([][]byte, 0, len(topics))
+		for _, topic := range topics {
+			if topic == (common.Hash{}) {
+				group = nil
+				break
+			}
+			group = append(group, topic.Bytes())
+		}
+		filters = append(filters, group)
+	}
+	matcher := bloombits.NewMatcher(size, filters)
+
+	// Evaluate the sections one by one, checking the logs of the candidate blocks
+	for section := start / size; section <= end/size; section++ {
+		select {
+		case <-ctx.Done():
+			return nil, end, ctx.Err()
+		default:
+		}
+		matches, err := matcher.Match(func(bit uint) ([]byte, error) {
+			return f.backend.GetBloomBits(ctx, bit, section)
+		})
+		if err != nil {
+			return nil, end, err
+		}
+		first, last := section*size, (section+1)*size-1
+		if first < start {
+			first = start
+		}
+		if last > end {
+			last = end
+		}
+		for number := first; number <= last; number++ {
+			if idx := number - section*size; matches[idx/8]&(1<<(7-idx%8)) == 0 {
+				continue
+			}
+			header, err := f.backend.HeaderByNumber(ctx, rpc.BlockNumber(number))
+			if header == nil || err != nil {
+				return nil, end, err
+			}
+			logs, err := f.blockLogs(ctx, header)
+			if err != nil {
+				return nil, end, err
+			}
+			if len(logs) > 0 {
+				return logs, number, nil
+			}
+		}
+	}
+	return nil, end, nil
+}
+
+// blockLogs returns the logs of a single block matching the filter criteria.
+func (f *Filter) blockLogs(ctx context.Context, header *types.Header) ([]*types.Log, error) {
+	receipts, err := f.backend.GetReceipts(ctx, header.Hash())
+	if err != nil {
+		return nil, err
+	}
+	var unfiltered []*types.Log
+	for _, receipt := range receipts {
+		unfiltered = append(unfiltered, ([]*types.Log)(receipt.Logs)...)
+	}
+	return filterLogs(unfiltered, nil, nil, f.addresses, f.topics), nil
+}
+
 func includes(addresses []common.Address, a common.Address) bool {
 	for _, addr := range addresses {
 		if addr == a {
@@ -255,6 +367,49 @@ func (f *Filter) bloomFilter(bloom types.Bloom) bool {
 	return bloomFilter(bloom, f.addresses, f.topics)
 }
 
+// filterTransaction reports whether a pending transaction matches the criteria.
+// The sender is only recovered if the criteria filter on it.
+func filterTransaction(tx *types.Transaction, sender func() common.Address, crit *TransactionCriteria) bool {
+	if crit == nil {
+		return true
+	}
+	if crit.MinGasPrice != nil && tx.GasPrice().Cmp(crit.MinGasPrice.ToInt()) < 0 {
+		return false
+	}
+	if len(crit.To) > 0 {
+		if to := tx.To(); to == nil || !includes(crit.To, *to) {
+			return false
+		}
+	}
+	if len(crit.Selectors) > 0 {
+		data, matched := tx.Data(), false
+		for _, selector := range crit.Selectors {
+			if len(data) >= len(selector) && bytes.Equal(data[:len(selector)], selector) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if len(crit.From) > 0 && !includes(crit.From, sender()) {
+		return false
+	}
+	return true
+}
+
+// txSender recovers the sender of a pooled transaction. Replay protected ones are
+// recovered with the same signer as the pool uses, hitting its sender cache.
+func txSender(tx *types.Transaction) common.Address {
+	var signer types.Signer = types.HomesteadSigner{}
+	if tx.Protected() {
+		signer = types.NewEIP155Signer(tx.ChainId())
+	}
+	from, _ := types.Sender(signer, tx)
+	return from
+}
+
 func bloomFilter(bloom types.Bloom, addresses []common.Address, topics [][]common.Hash) bool {
 	if len(addresses) > 0 {
 		var included bool
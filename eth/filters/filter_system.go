@@ -36,6 +36,17 @@ import (
 // the correct bucket when added.
 type Type byte
 
+const (
+	// txChanSize is the size of channel listening to TxsPreEvent.
+	txChanSize = 256
+	// rmLogsChanSize is the size of channel listening to RemovedLogsEvent.
+	rmLogsChanSize = 10
+	// logsChanSize is the size of channel listening to LogsEvent.
+	logsChanSize = 10
+	// chainEvChanSize is the size of channel listening to ChainEvent.
+	chainEvChanSize = 10
+)
+
 const (
 	// UnknownSubscription indicates an unknown subscription type
 	UnknownSubscription Type = iota
@@ -63,6 +74,7 @@ type subscription struct {
 	typ       Type
 	created   time.Time
 	logsCrit  FilterCriteria
+	txCrit    *TransactionCriteria
 	logs      chan []*types.Log
 	hashes    chan common.Hash
 	headers   chan *types.Header
@@ -74,7 +86,7 @@ type subscription struct {
 // subscription which match the subscription criteria.
 type EventSystem struct {
 	mux       *event.TypeMux
-	sub       event.Subscription
+	sub       *event.TypeMuxSubscription
 	backend   Backend
 	lightMode bool
 	lastHead  *types.Header
@@ -258,11 +270,13 @@ func (es *EventSystem) SubscribeNewHeads(headers chan *types.Header) *Subscripti
 }
 
 // SubscribePendingTxEvents creates a subscription that writes transaction hashes for
-// transactions that enter the transaction pool.
-func (es *EventSystem) SubscribePendingTxEvents(hashes chan common.Hash) *Subscription {
+// transactions that enter the transaction pool and match the given criteria (nil
+// matching all transactions).
+func (es *EventSystem) SubscribePendingTxEvents(crit *TransactionCriteria, hashes chan common.Hash) *Subscription {
 	sub := &subscription{
 		id:        rpc.NewID(),
 		typ:       PendingTransactionsSubscription,
+		txCrit:    crit,
 		created:   time.Now(),
 		logs:      make(chan []*types.Log),
 		hashes:    hashes,
@@ -309,10 +323,23 @@ func (es *EventSystem) broadcast(filters filterIndex, ev *event.Event) {
 				}
 			}
 		}
-	case core.TxPreEvent:
-		for _, f := range filters[PendingTransactionsSubscription] {
-			if ev.Time.After(f.created) {
-				f.hashes <- e.Tx.Hash()
+	case core.TxsPreEvent:
+		for _, tx := range e.Txs {
+			// Recover the sender only if requested, and at most once for all filters
+			var (
+				from    common.Address
+				derived bool
+			)
+			sender := func() common.Address {
+				if !derived {
+					from, derived = txSender(tx), true
+				}
+				return from
+			}
+			for _, f := range filters[PendingTransactionsSubscription] {
+				if ev.Time.After(f.created) && filterTransaction(tx, sender, f.txCrit) {
+					f.hashes <- tx.Hash()
+				}
 			}
 		}
 	case core.ChainEvent:
@@ -391,12 +418,23 @@ func (es *EventSystem) lightFilterLogs(header *types.Header, addresses []common.
 	return nil
 }
 
-// eventLoop (un)installs filters and processes mux events.
+// eventLoop (un)installs filters and processes mux and feed events.
 func (es *EventSystem) eventLoop() {
 	var (
-		index = make(filterIndex)
-		sub   = es.mux.Subscribe(core.PendingLogsEvent{}, core.RemovedLogsEvent{}, []*types.Log{}, core.TxPreEvent{}, core.ChainEvent{})
+		index      = make(filterIndex)
+		sub        = es.mux.Subscribe(core.PendingLogsEvent{})
+		subs       event.SubscriptionScope
+		txCh       = make(chan core.TxsPreEvent, txChanSize)
+		txSub      = subs.Track(es.backend.SubscribeTxsPreEvent(txCh))
+		rmLogsCh   = make(chan core.RemovedLogsEvent, rmLogsChanSize)
+		rmLogsSub  = subs.Track(es.backend.SubscribeRemovedLogsEvent(rmLogsCh))
+		logsCh     = make(chan []*types.Log, logsChanSize)
+		logsSub    = subs.Track(es.backend.SubscribeLogsEvent(logsCh))
+		chainEvCh  = make(chan core.ChainEvent, chainEvChanSize)
+		chainEvSub = subs.Track(es.backend.SubscribeChainEvent(chainEvCh))
 	)
+	// Tear down all feed subscriptions together with the loop
+	defer subs.Close()
 
 	for i := UnknownSubscription; i < LastIndexSubscription; i++ {
 		index[i] = make(map[rpc.ID]*subscription)
@@ -409,6 +447,24 @@ func (es *EventSystem) eventLoop() {
 				return
 			}
 			es.broadcast(index, ev)
+		case ev := <-txCh:
+			es.broadcast(index, &event.Event{Time: time.Now(), Data: ev})
+		case ev := <-rmLogsCh:
+			es.broadcast(index, &event.Event{Time: time.Now(), Data: ev})
+		case ev := <-logsCh:
+			es.broadcast(index, &event.Event{Time: time.Now(), Data: ev})
+		case ev := <-chainEvCh:
+			es.broadcast(index, &event.Event{Time: time.Now(), Data: ev})
+
+		// Stop the loop once the producers shut down
+		case <-txSub.Err():
+			return
+		case <-rmLogsSub.Err():
+			return
+		case <-logsSub.Err():
+			return
+		case <-chainEvSub.Err():
+			return
 		case f := <-es.install:
 			if f.typ == MinedAndPendingLogsSubscription {
 				// the type are logs and pending logs subscriptions
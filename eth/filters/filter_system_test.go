@@ -17,6 +17,7 @@
 package filters
 
 import (
+	"crypto/ecdsa"
 	"math/big"
 	"reflect"
 	"testing"
@@ -25,8 +26,11 @@ import (
 	"golang.org/x/net/context"
 
 	"github.com/EarthDollar/go-earthdollar/common"
+	"github.com/EarthDollar/go-earthdollar/common/bitutil"
+	"github.com/EarthDollar/go-earthdollar/common/hexutil"
 	"github.com/EarthDollar/go-earthdollar/core"
 	"github.com/EarthDollar/go-earthdollar/core/types"
+	"github.com/EarthDollar/go-earthdollar/crypto"
 	"github.com/EarthDollar/go-earthdollar/ethdb"
 	"github.com/EarthDollar/go-earthdollar/event"
 	"github.com/EarthDollar/go-earthdollar/params"
@@ -34,8 +38,15 @@ import (
 )
 
 type testBackend struct {
-	mux *event.TypeMux
-	db  ethdb.Database
+	mux        *event.TypeMux
+	db         ethdb.Database
+	txFeed     event.Feed
+	rmLogsFeed event.Feed
+	logsFeed   event.Feed
+	chainFeed  event.Feed
+
+	sectionSize uint64 // Number of blocks in a bloombits section
+	sections    uint64 // Number of bloombits sections indexed
 }
 
 func (b *testBackend) ChainDb() ethdb.Database {
@@ -64,6 +75,35 @@ func (b *testBackend) GetReceipts(ctx context.Context, blockHash common.Hash) (t
 	return core.GetBlockReceipts(b.db, blockHash, num), nil
 }
 
+func (b *testBackend) SubscribeTxsPreEvent(ch chan<- core.TxsPreEvent) event.Subscription {
+	return b.txFeed.Subscribe(ch)
+}
+
+func (b *testBackend) SubscribeRemovedLogsEvent(ch chan<- core.RemovedLogsEvent) event.Subscription {
+	return b.rmLogsFeed.Subscribe(ch)
+}
+
+func (b *testBackend) SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscription {
+	return b.logsFeed.Subscribe(ch)
+}
+
+func (b *testBackend) SubscribeChainEvent(ch chan<- core.ChainEvent) event.Subscription {
+	return b.chainFeed.Subscribe(ch)
+}
+
+func (b *testBackend) BloomStatus() (uint64, uint64) {
+	return b.sectionSize, b.sections
+}
+
+func (b *testBackend) GetBloomBits(ctx context.Context, bit uint, section uint64) ([]byte, error) {
+	head := core.GetCanonicalHash(b.db, (section+1)*b.sectionSize-1)
+	compressed, err := core.GetBloomBits(b.db, bit, section, head)
+	if err != nil {
+		return nil, err
+	}
+	return bitutil.DecompressBytes(compressed, int(b.sectionSize/8))
+}
+
 // TestBlockSubscription tests if a block subscription returns block hashes for posted chain events.
 // It creates multiple subscriptions:
 // - one at the start and should receive all posted chain events and a second (blockHashes)
@@ -75,8 +115,8 @@ func TestBlockSubscription(t *testing.T) {
 	var (
 		mux     = new(event.TypeMux)
 		db, _   = ethdb.NewMemDatabase()
-		backend = &testBackend{mux, db}
-		api     = NewPublicFilterAPI(backend, false)
+		backend = &testBackend{mux: mux, db: db}
+		api     = NewPublicFilterAPI(backend, false, Config{})
 
 		genesis     = core.WriteGenesisBlockForTesting(db)
 		chain, _    = core.GenerateChain(params.TestChainConfig, genesis, db, 10, func(i int, gen *core.BlockGen) {})
@@ -115,22 +155,22 @@ func TestBlockSubscription(t *testing.T) {
 
 	time.Sleep(1 * time.Second)
 	for _, e := range chainEvents {
-		mux.Post(e)
+		backend.chainFeed.Send(e)
 	}
 
 	<-sub0.Err()
 	<-sub1.Err()
 }
 
-// TestPendingTxFilter tests whether pending tx filters retrieve all pending transactions that are posted to the event mux.
+// TestPendingTxFilter tests whether pending tx filters retrieve all pending transactions that are sent to the tx feed.
 func TestPendingTxFilter(t *testing.T) {
 	t.Parallel()
 
 	var (
 		mux     = new(event.TypeMux)
 		db, _   = ethdb.NewMemDatabase()
-		backend = &testBackend{mux, db}
-		api     = NewPublicFilterAPI(backend, false)
+		backend = &testBackend{mux: mux, db: db}
+		api     = NewPublicFilterAPI(backend, false, Config{})
 
 		transactions = []*types.Transaction{
 			types.NewTransaction(0, common.HexToAddress("0xb794f5ea0ba39494ce83a213fffba74279579268"), new(big.Int), new(big.Int), new(big.Int), nil),
@@ -143,13 +183,10 @@ func TestPendingTxFilter(t *testing.T) {
 		hashes []common.Hash
 	)
 
-	fid0 := api.NewPendingTransactionFilter()
+	fid0, _ := api.NewPendingTransactionFilter(nil)
 
 	time.Sleep(1 * time.Second)
-	for _, tx := range transactions {
-		ev := core.TxPreEvent{Tx: tx}
-		mux.Post(ev)
-	}
+	backend.txFeed.Send(core.TxsPreEvent{Txs: transactions})
 
 	for {
 		results, err := api.GetFilterChanges(fid0)
@@ -173,14 +210,141 @@ func TestPendingTxFilter(t *testing.T) {
 	}
 }
 
+// TestPendingTxFilterCriteria tests that pending transaction filters only return
+// the hashes of the transactions matching their criteria.
+func TestPendingTxFilterCriteria(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mux     = new(event.TypeMux)
+		db, _   = ethdb.NewMemDatabase()
+		backend = &testBackend{mux: mux, db: db}
+		api     = NewPublicFilterAPI(backend, false, Config{})
+
+		key1, _  = crypto.GenerateKey()
+		key2, _  = crypto.GenerateKey()
+		sender1  = crypto.PubkeyToAddress(key1.PublicKey)
+		sender2  = crypto.PubkeyToAddress(key2.PublicKey)
+		to1      = common.HexToAddress("0x1111111111111111111111111111111111111111")
+		to2      = common.HexToAddress("0x2222222222222222222222222222222222222222")
+		transfer = hexutil.Bytes{0xa9, 0x05, 0x9c, 0xbb}
+		approve  = hexutil.Bytes{0x09, 0x5e, 0xa7, 0xb3}
+	)
+	sign := func(tx *types.Transaction, key *ecdsa.PrivateKey) *types.Transaction {
+		signed, err := types.SignTx(tx, types.HomesteadSigner{}, key)
+		if err != nil {
+			t.Fatalf("failed to sign transaction: %v", err)
+		}
+		return signed
+	}
+	transactions := []*types.Transaction{
+		sign(types.NewTransaction(0, to1, new(big.Int), big.NewInt(21000), big.NewInt(1), append(transfer, 0x01)), key1),
+		sign(types.NewTransaction(0, to1, new(big.Int), big.NewInt(21000), big.NewInt(10), nil), key2),
+		sign(types.NewTransaction(1, to2, new(big.Int), big.NewInt(21000), big.NewInt(10), append(approve, 0x02)), key1),
+		sign(types.NewContractCreation(1, new(big.Int), big.NewInt(100000), big.NewInt(5), append(transfer, 0x03)), key2),
+	}
+	tests := []struct {
+		crit *TransactionCriteria
+		want []int
+	}{
+		{nil, []int{0, 1, 2, 3}},
+		{&TransactionCriteria{}, []int{0, 1, 2, 3}},
+		{&TransactionCriteria{From: []common.Address{sender1}}, []int{0, 2}},
+		{&TransactionCriteria{To: []common.Address{to1}}, []int{0, 1}},
+		{&TransactionCriteria{To: []common.Address{to1, to2}}, []int{0, 1, 2}},
+		{&TransactionCriteria{MinGasPrice: (*hexutil.Big)(big.NewInt(5))}, []int{1, 2, 3}},
+		{&TransactionCriteria{Selectors: []hexutil.Bytes{transfer}}, []int{0, 3}},
+		{&TransactionCriteria{Selectors: []hexutil.Bytes{transfer, approve}, To: []common.Address{to1, to2}}, []int{0, 2}},
+		{&TransactionCriteria{From: []common.Address{sender2}, MinGasPrice: (*hexutil.Big)(big.NewInt(6))}, []int{1}},
+		{&TransactionCriteria{From: []common.Address{to1}}, nil},
+	}
+	ids := make([]rpc.ID, len(tests))
+	for i, tt := range tests {
+		id, err := api.NewPendingTransactionFilter(tt.crit)
+		if err != nil {
+			t.Fatalf("test %d: failed to create filter: %v", i, err)
+		}
+		ids[i] = id
+	}
+	if _, err := api.NewPendingTransactionFilter(&TransactionCriteria{Selectors: []hexutil.Bytes{{0x01}}}); err == nil {
+		t.Errorf("filter with invalid selector accepted")
+	}
+	time.Sleep(100 * time.Millisecond)
+	backend.txFeed.Send(core.TxsPreEvent{Txs: transactions})
+	// Collect the filter results until they are all complete (or time out)
+	results := make([][]common.Hash, len(tests))
+	for timeout := time.Now().Add(time.Second); time.Now().Before(timeout); time.Sleep(50 * time.Millisecond) {
+		for i, id := range ids {
+			changes, err := api.GetFilterChanges(id)
+			if err != nil {
+				t.Fatalf("test %d: failed to retrieve filter changes: %v", i, err)
+			}
+			results[i] = append(results[i], changes.([]common.Hash)...)
+		}
+		if len(results[0]) == len(transactions) {
+			break
+		}
+	}
+	time.Sleep(100 * time.Millisecond)
+	for i, id := range ids {
+		changes, _ := api.GetFilterChanges(id)
+		results[i] = append(results[i], changes.([]common.Hash)...)
+
+		var want []common.Hash
+		for _, idx := range tests[i].want {
+			want = append(want, transactions[idx].Hash())
+		}
+		if len(results[i]) != len(want) {
+			t.Errorf("test %d: hash count mismatch: have %d, want %d", i, len(results[i]), len(want))
+			continue
+		}
+		for j := range want {
+			if results[i][j] != want[j] {
+				t.Errorf("test %d, hash %d: mismatch: have %x, want %x", i, j, results[i][j], want[j])
+			}
+		}
+	}
+}
+
+// TestFilterTimeout tests that installed filters which are not polled within the
+// configured timeout are uninstalled, while regularly polled ones are retained.
+func TestFilterTimeout(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mux     = new(event.TypeMux)
+		db, _   = ethdb.NewMemDatabase()
+		backend = &testBackend{mux: mux, db: db}
+		api     = NewPublicFilterAPI(backend, false, Config{Timeout: 100 * time.Millisecond})
+
+		idle      = api.NewBlockFilter()
+		polled, _ = api.NewPendingTransactionFilter(nil)
+	)
+	for i := 0; i < 10; i++ {
+		time.Sleep(50 * time.Millisecond)
+		if _, err := api.GetFilterChanges(polled); err != nil {
+			t.Fatalf("polled filter removed after %d polls: %v", i, err)
+		}
+	}
+	if _, err := api.GetFilterChanges(idle); err == nil {
+		t.Errorf("idle filter not removed after timeout")
+	}
+	if api.UninstallFilter(idle) {
+		t.Errorf("idle filter still installed after timeout")
+	}
+	if !api.UninstallFilter(polled) {
+		t.Errorf("polled filter not installed")
+	}
+}
+
 // TestLogFilterCreation test whether a given filter criteria makes sense.
 // If not it must return an error.
 func TestLogFilterCreation(t *testing.T) {
 	var (
 		mux     = new(event.TypeMux)
 		db, _   = ethdb.NewMemDatabase()
-		backend = &testBackend{mux, db}
-		api     = NewPublicFilterAPI(backend, false)
+		backend = &testBackend{mux: mux, db: db}
+		api     = NewPublicFilterAPI(backend, false, Config{})
 
 		testCases = []struct {
 			crit    FilterCriteria
@@ -224,8 +388,8 @@ func TestInvalidLogFilterCreation(t *testing.T) {
 	var (
 		mux     = new(event.TypeMux)
 		db, _   = ethdb.NewMemDatabase()
-		backend = &testBackend{mux, db}
-		api     = NewPublicFilterAPI(backend, false)
+		backend = &testBackend{mux: mux, db: db}
+		api     = NewPublicFilterAPI(backend, false, Config{})
 	)
 
 	// different situations where log filter creation should fail.
@@ -243,15 +407,15 @@ func TestInvalidLogFilterCreation(t *testing.T) {
 	}
 }
 
-// TestLogFilter tests whether log filters match the correct logs that are posted to the event mux.
+// TestLogFilter tests whether log filters match the correct logs that are sent to the logs feed.
 func TestLogFilter(t *testing.T) {
 	t.Parallel()
 
 	var (
 		mux     = new(event.TypeMux)
 		db, _   = ethdb.NewMemDatabase()
-		backend = &testBackend{mux, db}
-		api     = NewPublicFilterAPI(backend, false)
+		backend = &testBackend{mux: mux, db: db}
+		api     = NewPublicFilterAPI(backend, false, Config{})
 
 		firstAddr      = common.HexToAddress("0x1111111111111111111111111111111111111111")
 		secondAddr     = common.HexToAddress("0x2222222222222222222222222222222222222222")
@@ -312,9 +476,12 @@ func TestLogFilter(t *testing.T) {
 
 	// raise events
 	time.Sleep(1 * time.Second)
-	if err := mux.Post(allLogs); err != nil {
-		t.Fatal(err)
+	if nsend := backend.logsFeed.Send(allLogs); nsend == 0 {
+		t.Fatal("logs feed has no subscribers")
 	}
+	// mined and pending logs arrive through different channels, give the
+	// mined ones a head start to keep the expected ordering
+	time.Sleep(100 * time.Millisecond)
 	if err := mux.Post(core.PendingLogsEvent{Logs: allLogs}); err != nil {
 		t.Fatal(err)
 	}
@@ -358,8 +525,8 @@ func TestPendingLogsSubscription(t *testing.T) {
 	var (
 		mux     = new(event.TypeMux)
 		db, _   = ethdb.NewMemDatabase()
-		backend = &testBackend{mux, db}
-		api     = NewPublicFilterAPI(backend, false)
+		backend = &testBackend{mux: mux, db: db}
+		api     = NewPublicFilterAPI(backend, false, Config{})
 
 		firstAddr      = common.HexToAddress("0x1111111111111111111111111111111111111111")
 		secondAddr     = common.HexToAddress("0x2222222222222222222222222222222222222222")
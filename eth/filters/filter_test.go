@@ -17,6 +17,7 @@
 package filters
 
 import (
+	"bytes"
 	"io/ioutil"
 	"math/big"
 	"os"
@@ -25,7 +26,9 @@ import (
 	"golang.org/x/net/context"
 
 	"github.com/EarthDollar/go-earthdollar/common"
+	"github.com/EarthDollar/go-earthdollar/common/bitutil"
 	"github.com/EarthDollar/go-earthdollar/core"
+	"github.com/EarthDollar/go-earthdollar/core/bloombits"
 	"github.com/EarthDollar/go-earthdollar/core/types"
 	"github.com/EarthDollar/go-earthdollar/crypto"
 	"github.com/EarthDollar/go-earthdollar/ethdb"
@@ -52,7 +55,7 @@ func BenchmarkMipmaps(b *testing.B) {
 	var (
 		db, _   = ethdb.NewLDBDatabase(dir, 0, 0)
 		mux     = new(event.TypeMux)
-		backend = &testBackend{mux, db}
+		backend = &testBackend{mux: mux, db: db}
 		key1, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
 		addr1   = crypto.PubkeyToAddress(key1.PublicKey)
 		addr2   = common.BytesToAddress([]byte("jeff"))
@@ -128,7 +131,7 @@ func TestFilters(t *testing.T) {
 	var (
 		db, _   = ethdb.NewLDBDatabase(dir, 0, 0)
 		mux     = new(event.TypeMux)
-		backend = &testBackend{mux, db}
+		backend = &testBackend{mux: mux, db: db}
 		key1, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
 		addr    = crypto.PubkeyToAddress(key1.PublicKey)
 
@@ -287,3 +290,231 @@ func TestFilters(t *testing.T) {
 		t.Error("expected 0 log, got", len(logs))
 	}
 }
+
+// Tests that log filtering uses the bloombits index for the already indexed
+// sections and falls back to scanning the headers for the remainder.
+func TestIndexedFilters(t *testing.T) {
+	var (
+		db, _   = ethdb.NewMemDatabase()
+		mux     = new(event.TypeMux)
+		backend = &testBackend{mux: mux, db: db, sectionSize: 256}
+		key1, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr    = crypto.PubkeyToAddress(key1.PublicKey)
+
+		hash1 = common.BytesToHash([]byte("topic1"))
+		hash2 = common.BytesToHash([]byte("topic2"))
+		hash3 = common.BytesToHash([]byte("topic3"))
+	)
+	genesis := core.WriteGenesisBlockForTesting(db, core.GenesisAccount{Address: addr, Balance: big.NewInt(1000000)})
+	chain, receipts := core.GenerateChain(params.TestChainConfig, genesis, db, 1000, func(i int, gen *core.BlockGen) {
+		var topic common.Hash
+		switch i {
+		case 1:
+			topic = hash1
+		case 599:
+			topic = hash2
+		case 899:
+			topic = hash3
+		default:
+			return
+		}
+		receipt := types.NewReceipt(nil, new(big.Int))
+		receipt.Logs = []*types.Log{{Address: addr, Topics: []common.Hash{topic}}}
+		gen.AddUncheckedReceipt(receipt)
+	})
+	for i, block := range chain {
+		core.WriteBlock(db, block)
+		if err := core.WriteCanonicalHash(db, block.Hash(), block.NumberU64()); err != nil {
+			t.Fatalf("failed to insert block number: %v", err)
+		}
+		if err := core.WriteHeadBlockHash(db, block.Hash()); err != nil {
+			t.Fatalf("failed to insert block number: %v", err)
+		}
+		if err := core.WriteBlockReceipts(db, block.Hash(), block.NumberU64(), receipts[i]); err != nil {
+			t.Fatal("error writing block receipts:", err)
+		}
+	}
+	// Index the first three sections, leaving block 900 out of the index
+	headers := append([]*types.Header{genesis.Header()}, make([]*types.Header, len(chain))...)
+	for i, block := range chain {
+		headers[i+1] = block.Header()
+	}
+	for section := uint64(0); section < 3; section++ {
+		gen, err := bloombits.NewGenerator(uint(backend.sectionSize))
+		if err != nil {
+			t.Fatalf("failed to create bloom generator: %v", err)
+		}
+		for i := uint64(0); i < backend.sectionSize; i++ {
+			gen.AddBloom(uint(i), headers[section*backend.sectionSize+i].Bloom)
+		}
+		head := headers[(section+1)*backend.sectionSize-1].Hash()
+		for i := 0; i < bloombits.BloomBitLength; i++ {
+			bits, err := gen.Bitset(uint(i))
+			if err != nil {
+				t.Fatalf("failed to retrieve bit %d of section %d: %v", i, section, err)
+			}
+			core.WriteBloomBits(db, uint(i), section, head, bitutil.CompressBytes(bits))
+		}
+	}
+	backend.sections = 3
+
+	tests := []struct {
+		addresses  []common.Address
+		topics     [][]common.Hash
+		begin, end int64
+		want       []common.Hash
+	}{
+		{[]common.Address{addr}, nil, 0, -1, []common.Hash{hash1, hash2, hash3}},
+		{nil, [][]common.Hash{{hash1, hash2, hash3}}, 0, -1, []common.Hash{hash1, hash2, hash3}},
+		{nil, [][]common.Hash{{hash2}}, 0, 700, []common.Hash{hash2}},
+		{nil, [][]common.Hash{{hash1}}, 500, -1, nil},
+		{nil, [][]common.Hash{{hash3}}, 600, -1, []common.Hash{hash3}},
+		{[]common.Address{addr}, [][]common.Hash{{common.Hash{}}}, 10, 800, []common.Hash{hash2}},
+		{nil, [][]common.Hash{{common.BytesToHash([]byte("fail"))}}, 0, -1, nil},
+		{nil, [][]common.Hash{{}}, 0, -1, nil},
+	}
+	for i, tt := range tests {
+		filter := New(backend, true)
+		filter.SetAddresses(tt.addresses)
+		filter.SetTopics(tt.topics)
+		filter.SetBeginBlock(tt.begin)
+		filter.SetEndBlock(tt.end)
+
+		logs, err := filter.Find(context.Background())
+		if err != nil {
+			t.Errorf("test %d: failed to filter logs: %v", i, err)
+			continue
+		}
+		if len(logs) != len(tt.want) {
+			t.Errorf("test %d: log count mismatch: have %d, want %d", i, len(logs), len(tt.want))
+			continue
+		}
+		for j, log := range logs {
+			if log.Topics[0] != tt.want[j] {
+				t.Errorf("test %d, log %d: topic mismatch: have %x, want %x", i, j, log.Topics[0], tt.want[j])
+			}
+		}
+	}
+}
+
+// newLogsTestBackend creates a chain of 100 blocks, every tenth of which contains
+// three logs with the given topic, each tagged with its block number and index.
+func newLogsTestBackend(t *testing.T, topic common.Hash) *testBackend {
+	var (
+		db, _   = ethdb.NewMemDatabase()
+		backend = &testBackend{mux: new(event.TypeMux), db: db}
+		genesis = core.WriteGenesisBlockForTesting(db)
+	)
+	chain, receipts := core.GenerateChain(params.TestChainConfig, genesis, db, 100, func(i int, gen *core.BlockGen) {
+		if (i+1)%10 != 0 {
+			return
+		}
+		receipt := types.NewReceipt(nil, new(big.Int))
+		for j := 0; j < 3; j++ {
+			receipt.Logs = append(receipt.Logs, &types.Log{Topics: []common.Hash{topic}, Data: []byte{byte(i + 1), byte(j)}})
+		}
+		gen.AddUncheckedReceipt(receipt)
+	})
+	for i, block := range chain {
+		core.WriteBlock(db, block)
+		if err := core.WriteCanonicalHash(db, block.Hash(), block.NumberU64()); err != nil {
+			t.Fatalf("failed to insert block number: %v", err)
+		}
+		if err := core.WriteHeadBlockHash(db, block.Hash()); err != nil {
+			t.Fatalf("failed to insert block number: %v", err)
+		}
+		if err := core.WriteBlockReceipts(db, block.Hash(), block.NumberU64(), receipts[i]); err != nil {
+			t.Fatal("error writing block receipts:", err)
+		}
+	}
+	return backend
+}
+
+// Tests that log queries exceeding the configured block range or result limits
+// are rejected, and that cancelled queries are aborted.
+func TestLogQueryLimits(t *testing.T) {
+	topic := common.BytesToHash([]byte("topic"))
+	api := NewPublicFilterAPI(newLogsTestBackend(t, topic), false, Config{MaxBlockRange: 50, MaxLogs: 10})
+
+	tests := []struct {
+		from, to int64
+		logs     int
+		fail     bool
+	}{
+		{0, 29, 6, false},  // within both limits
+		{0, 49, 0, true},   // matches 12 logs
+		{41, 70, 9, false}, // unaligned range
+		{0, 60, 0, true},   // spans 61 blocks
+		{60, -1, 0, true},  // spans 41 blocks, but matches 12 logs
+		{95, -1, 3, false}, // open ended, within limits
+		{80, 10, 0, false}, // empty range
+	}
+	for i, tt := range tests {
+		crit := FilterCriteria{FromBlock: big.NewInt(tt.from), ToBlock: big.NewInt(tt.to), Topics: [][]common.Hash{{topic}}}
+		logs, err := api.GetLogs(context.Background(), crit)
+		if tt.fail {
+			if err == nil {
+				t.Errorf("test %d: expected failure, got %d logs", i, len(logs))
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("test %d: failed to retrieve logs: %v", i, err)
+			continue
+		}
+		if len(logs) != tt.logs {
+			t.Errorf("test %d: log count mismatch: have %d, want %d", i, len(logs), tt.logs)
+		}
+	}
+	// Ensure cancelled queries are aborted instead of being run to completion
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	crit := FilterCriteria{FromBlock: big.NewInt(0), ToBlock: big.NewInt(29), Topics: [][]common.Hash{{topic}}}
+	if _, err := api.GetLogs(ctx, crit); err != context.Canceled {
+		t.Errorf("cancelled query error mismatch: have %v, want %v", err, context.Canceled)
+	}
+}
+
+// Tests that paginated log queries return all the matching logs exactly once,
+// splitting pages within blocks if needed.
+func TestLogQueryPagination(t *testing.T) {
+	topic := common.BytesToHash([]byte("topic"))
+	api := NewPublicFilterAPI(newLogsTestBackend(t, topic), false, Config{MaxBlockRange: 25, MaxLogs: 4})
+
+	var (
+		crit   = FilterCriteria{FromBlock: big.NewInt(5), Topics: [][]common.Hash{{topic}}}
+		cursor *LogCursor
+		logs   []*types.Log
+		pages  int
+	)
+	for {
+		page, err := api.GetLogsPage(context.Background(), crit, cursor)
+		if err != nil {
+			t.Fatalf("page %d: failed to retrieve logs: %v", pages, err)
+		}
+		if len(page.Logs) > 4 {
+			t.Errorf("page %d: too many logs: have %d, want at most 4", pages, len(page.Logs))
+		}
+		logs = append(logs, page.Logs...)
+		if pages++; page.Cursor == nil {
+			break
+		}
+		if pages > 30 {
+			t.Fatalf("pagination didn't terminate")
+		}
+		cursor = page.Cursor
+	}
+	if len(logs) != 30 {
+		t.Fatalf("log count mismatch: have %d, want %d", len(logs), 30)
+	}
+	for i, log := range logs {
+		if want := []byte{byte((i/3 + 1) * 10), byte(i % 3)}; !bytes.Equal(log.Data, want) {
+			t.Errorf("log %d: data mismatch: have %x, want %x", i, log.Data, want)
+		}
+	}
+	// Ensure cursors outside of the query are rejected
+	if _, err := api.GetLogsPage(context.Background(), crit, &LogCursor{Block: 2}); err == nil {
+		t.Errorf("cursor before the query range accepted")
+	}
+}
@@ -0,0 +1,170 @@
+// Copyright 2015 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"math/big"
+	"sort"
+
+	"github.com/EarthDollar/go-earthdollar/common"
+	"github.com/EarthDollar/go-earthdollar/params"
+)
+
+var (
+	// errRemoteStale is returned if a remote fork ID is a subset of our already
+	// applied forks, but the announced next fork block is not on our chain.
+	errRemoteStale = errors.New("remote needs update")
+
+	// errLocalIncompatibleOrStale is returned if a remote fork ID does not match
+	// any local checksum, or if it announces a fork we have already passed.
+	errLocalIncompatibleOrStale = errors.New("local incompatible or needs update")
+)
+
+// forkID is a compact identifier of the chain a node is following, consisting
+// of a checksum over the genesis hash and all passed fork blocks, and the
+// block number of the next scheduled fork (0 if none is known).
+type forkID struct {
+	Hash [4]byte // CRC32 checksum of the genesis hash and passed fork blocks
+	Next uint64  // Block number of the next scheduled fork, or 0 if none
+}
+
+// newForkID calculates the fork ID of a chain with the given configuration and
+// genesis hash, at the given head block number.
+func newForkID(config *params.ChainConfig, genesis common.Hash, head uint64) forkID {
+	hash := crc32.ChecksumIEEE(genesis[:])
+	for _, fork := range gatherForks(config) {
+		if fork > head {
+			return forkID{Hash: checksumToBytes(hash), Next: fork}
+		}
+		hash = checksumUpdate(hash, fork)
+	}
+	return forkID{Hash: checksumToBytes(hash)}
+}
+
+// newForkFilter creates a validator checking remote fork IDs against the local
+// chain configuration at the head block returned by the callback.
+func newForkFilter(config *params.ChainConfig, genesis common.Hash, headfn func() uint64) func(forkID) error {
+	// Pre-calculate the checksums of all fork states, sums[i] being the checksum
+	// with the first i forks applied
+	forks := gatherForks(config)
+	sums := make([][4]byte, len(forks)+1)
+	hash := crc32.ChecksumIEEE(genesis[:])
+	sums[0] = checksumToBytes(hash)
+	for i, fork := range forks {
+		hash = checksumUpdate(hash, fork)
+		sums[i+1] = checksumToBytes(hash)
+	}
+	forks = append(forks, ^uint64(0)) // sentinel, the last fork state never ends
+
+	return func(id forkID) error {
+		head := headfn()
+		for i, fork := range forks {
+			// Skip all the fork states we have already passed
+			if head >= fork {
+				continue
+			}
+			// Found the current fork state, check the remote against it
+			if sums[i] == id.Hash {
+				// Same checksum, reject only if the remote announces a fork we
+				// have already passed without applying it
+				if id.Next > 0 && head >= id.Next {
+					return errLocalIncompatibleOrStale
+				}
+				return nil
+			}
+			// Remote on a past fork state, its next announced fork must match ours
+			for j := 0; j < i; j++ {
+				if sums[j] == id.Hash {
+					if forks[j] != id.Next {
+						return errRemoteStale
+					}
+					return nil
+				}
+			}
+			// Remote on a future fork state, we are simply not synced yet
+			for j := i + 1; j < len(sums); j++ {
+				if sums[j] == id.Hash {
+					return nil
+				}
+			}
+			return errLocalIncompatibleOrStale
+		}
+		return errLocalIncompatibleOrStale
+	}
+}
+
+// gatherForks returns the sorted, deduplicated list of fork block numbers from
+// the chain configuration, omitting forks applied in the genesis block.
+func gatherForks(config *params.ChainConfig) []uint64 {
+	var forks []uint64
+	for _, block := range []*big.Int{
+		config.HomesteadBlock,
+		config.DAOForkBlock,
+		config.EIP150Block,
+		config.EIP155Block,
+		config.EIP158Block,
+		config.EIP140Block,
+		config.EIP1014Block,
+		config.EIP198Block,
+		config.EIP152Block,
+		config.EIP3529Block,
+		config.TreasuryBlock,
+		config.RewardContractBlock,
+		config.NoUncleRewardBlock,
+	} {
+		if block != nil && block.Sign() > 0 {
+			forks = append(forks, block.Uint64())
+		}
+	}
+	for _, era := range config.RewardEras {
+		if era.Block != nil && era.Block.Sign() > 0 {
+			forks = append(forks, era.Block.Uint64())
+		}
+	}
+	sort.Sort(uint64Slice(forks))
+
+	var unique []uint64
+	for i, fork := range forks {
+		if i == 0 || forks[i-1] != fork {
+			unique = append(unique, fork)
+		}
+	}
+	return unique
+}
+
+// checksumUpdate extends a CRC32 checksum with a fork block number.
+func checksumUpdate(hash uint32, fork uint64) uint32 {
+	var blob [8]byte
+	binary.BigEndian.PutUint64(blob[:], fork)
+	return crc32.Update(hash, crc32.IEEETable, blob[:])
+}
+
+// checksumToBytes converts a CRC32 checksum into its big endian byte form.
+func checksumToBytes(hash uint32) [4]byte {
+	var blob [4]byte
+	binary.BigEndian.PutUint32(blob[:], hash)
+	return blob
+}
+
+type uint64Slice []uint64
+
+func (s uint64Slice) Len() int           { return len(s) }
+func (s uint64Slice) Less(i, j int) bool { return s[i] < s[j] }
+func (s uint64Slice) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
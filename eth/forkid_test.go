@@ -0,0 +1,109 @@
+// Copyright 2015 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/EarthDollar/go-earthdollar/common"
+	"github.com/EarthDollar/go-earthdollar/params"
+)
+
+var forkIDTestConfig = &params.ChainConfig{
+	HomesteadBlock: big.NewInt(10),
+	DAOForkBlock:   nil,
+	EIP150Block:    big.NewInt(20),
+	EIP155Block:    big.NewInt(30),
+	EIP158Block:    big.NewInt(30),
+}
+
+var forkIDTestGenesis = common.HexToHash("0x1234")
+
+// Tests that fork IDs change exactly at the configured fork blocks and announce
+// the next upcoming one.
+func TestForkIDCreation(t *testing.T) {
+	if forks := gatherForks(forkIDTestConfig); len(forks) != 3 || forks[0] != 10 || forks[1] != 20 || forks[2] != 30 {
+		t.Fatalf("fork gathering mismatch: have %v, want [10 20 30]", forks)
+	}
+	tests := []struct {
+		head uint64
+		next uint64
+	}{{0, 10}, {9, 10}, {10, 20}, {19, 20}, {20, 30}, {30, 0}, {1000, 0}}
+
+	for i, tt := range tests {
+		id := newForkID(forkIDTestConfig, forkIDTestGenesis, tt.head)
+		if id.Next != tt.next {
+			t.Errorf("test %d: next fork mismatch: have %d, want %d", i, id.Next, tt.next)
+		}
+	}
+	if newForkID(forkIDTestConfig, forkIDTestGenesis, 9).Hash == newForkID(forkIDTestConfig, forkIDTestGenesis, 10).Hash {
+		t.Errorf("fork checksum not updated at fork block")
+	}
+	if newForkID(forkIDTestConfig, forkIDTestGenesis, 10).Hash != newForkID(forkIDTestConfig, forkIDTestGenesis, 19).Hash {
+		t.Errorf("fork checksum changed between forks")
+	}
+	if newForkID(forkIDTestConfig, forkIDTestGenesis, 0).Hash == newForkID(forkIDTestConfig, common.HexToHash("0x5678"), 0).Hash {
+		t.Errorf("fork checksum independent of genesis")
+	}
+	// Scheduled block reward changes split the network just the same
+	eras := *forkIDTestConfig
+	eras.RewardEras = []params.RewardEra{{Block: big.NewInt(40), Reward: big.NewInt(1)}}
+	if forks := gatherForks(&eras); len(forks) != 4 || forks[3] != 40 {
+		t.Fatalf("reward era gathering mismatch: have %v, want [10 20 30 40]", forks)
+	}
+}
+
+// Tests that remote fork IDs are accepted or rejected depending on whether the
+// two chains are compatible.
+func TestForkIDValidation(t *testing.T) {
+	id := func(head uint64) forkID { return newForkID(forkIDTestConfig, forkIDTestGenesis, head) }
+
+	// A chain which never applied the EIP150 fork at block 20
+	stale := &params.ChainConfig{HomesteadBlock: big.NewInt(10)}
+	staleID := func(head uint64) forkID { return newForkID(stale, forkIDTestGenesis, head) }
+
+	tests := []struct {
+		head   uint64
+		remote forkID
+		err    error
+	}{
+		// Both on the same fork state, local and remote in sync
+		{0, id(0), nil},
+		{15, id(15), nil},
+		{1000, id(1000), nil},
+		// Remote behind on a past fork state, but aware of our next fork
+		{25, id(15), nil},
+		// Local behind, remote already on a future fork state
+		{15, id(25), nil},
+		{0, id(1000), nil},
+		// Remote on a past fork state, unaware of the fork we applied since
+		{25, staleID(15), errRemoteStale},
+		// Remote on the same state, but announcing a fork we already passed
+		{15, forkID{Hash: id(15).Hash, Next: 12}, errLocalIncompatibleOrStale},
+		// Remote on a completely different chain
+		{15, forkID{Hash: [4]byte{0xde, 0xad, 0xbe, 0xef}}, errLocalIncompatibleOrStale},
+		{0, newForkID(forkIDTestConfig, common.HexToHash("0x5678"), 0), errLocalIncompatibleOrStale},
+	}
+	for i, tt := range tests {
+		head := tt.head
+		filter := newForkFilter(forkIDTestConfig, forkIDTestGenesis, func() uint64 { return head })
+		if err := filter(tt.remote); err != tt.err {
+			t.Errorf("test %d: validation error mismatch: have %v, want %v", i, err, tt.err)
+		}
+	}
+}
@@ -24,7 +24,6 @@ import (
 	"github.com/EarthDollar/go-earthdollar/core"
 	"github.com/EarthDollar/go-earthdollar/core/types"
 	"github.com/EarthDollar/go-earthdollar/ethdb"
-	"github.com/EarthDollar/go-earthdollar/event"
 	"github.com/EarthDollar/go-earthdollar/logger"
 	"github.com/EarthDollar/go-earthdollar/logger/glog"
 )
@@ -32,6 +31,9 @@ import (
 const (
 	gpoProcessPastBlocks = 100
 
+	// chainEvChanSize is the size of channel listening to ChainEvent.
+	chainEvChanSize = 10
+
 	// for testing
 	gpoDefaultBaseCorrectionFactor = 110
 	gpoDefaultMinGasPrice          = 10000000000000
@@ -55,7 +57,6 @@ type GpoParams struct {
 type GasPriceOracle struct {
 	chain         *core.BlockChain
 	db            ethdb.Database
-	evmux         *event.TypeMux
 	params        *GpoParams
 	initOnce      sync.Once
 	minPrice      *big.Int
@@ -69,7 +70,7 @@ type GasPriceOracle struct {
 }
 
 // NewGasPriceOracle returns a new oracle.
-func NewGasPriceOracle(chain *core.BlockChain, db ethdb.Database, evmux *event.TypeMux, params *GpoParams) *GasPriceOracle {
+func NewGasPriceOracle(chain *core.BlockChain, db ethdb.Database, params *GpoParams) *GasPriceOracle {
 	minprice := params.GpoMinGasPrice
 	if minprice == nil {
 		minprice = big.NewInt(gpoDefaultMinGasPrice)
@@ -81,7 +82,6 @@ func NewGasPriceOracle(chain *core.BlockChain, db ethdb.Database, evmux *event.T
 	return &GasPriceOracle{
 		chain:    chain,
 		db:       db,
-		evmux:    evmux,
 		params:   params,
 		blocks:   make(map[uint64]*blockPriceInfo),
 		minBase:  minbase,
@@ -118,15 +118,16 @@ func (self *GasPriceOracle) processPastBlocks() {
 }
 
 func (self *GasPriceOracle) listenLoop() {
-	events := self.evmux.Subscribe(core.ChainEvent{}, core.ChainSplitEvent{})
-	defer events.Unsubscribe()
-
-	for event := range events.Chan() {
-		switch event := event.Data.(type) {
-		case core.ChainEvent:
-			self.processBlock(event.Block)
-		case core.ChainSplitEvent:
-			self.processBlock(event.Block)
+	chainEvCh := make(chan core.ChainEvent, chainEvChanSize)
+	chainEvSub := self.chain.SubscribeChainEvent(chainEvCh)
+	defer chainEvSub.Unsubscribe()
+
+	for {
+		select {
+		case ev := <-chainEvCh:
+			self.processBlock(ev.Block)
+		case <-chainEvSub.Err():
+			return
 		}
 	}
 }
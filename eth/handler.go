@@ -45,6 +45,10 @@ import (
 const (
 	softResponseLimit = 2 * 1024 * 1024 // Target maximum size of returned blocks, headers or node data.
 	estHeaderRlpSize  = 500             // Approximate size of an RLP encoded block header
+
+	// txChanSize is the size of channel listening to TxPreEvent.
+	// The number is referenced from the size of tx pool.
+	txChanSize = 4096
 )
 
 var (
@@ -70,6 +74,7 @@ type ProtocolManager struct {
 	chaindb     ethdb.Database
 	chainconfig *params.ChainConfig
 	maxPeers    int
+	forkFilter  func(forkID) error // Validator of remote fork IDs against the local chain
 
 	downloader *downloader.Downloader
 	fetcher    *fetcher.Fetcher
@@ -78,8 +83,10 @@ type ProtocolManager struct {
 	SubProtocols []p2p.Protocol
 
 	eventMux      *event.TypeMux
+	txCh          chan core.TxPreEvent
 	txSub         event.Subscription
-	minedBlockSub event.Subscription
+	subs          event.SubscriptionScope // Feed subscriptions closed together on Stop
+	minedBlockSub *event.TypeMuxSubscription
 
 	// channels for fetcher, syncer, txsyncLoop
 	newPeerCh   chan *peer
@@ -88,6 +95,10 @@ type ProtocolManager struct {
 	noMorePeers chan struct{}
 
 	lesServer LesServer
+	rater     peerRater // Reputation tracker of the p2p server, nil if unavailable
+
+	download *bandwidthLimiter // Chain data download bandwidth cap shared by all peers (nil = unlimited)
+	upload   *bandwidthLimiter // Chain data serving bandwidth cap shared by all peers (nil = unlimited)
 
 	// wait group is used for graceful shutdowns during downloading
 	// and processing
@@ -114,6 +125,9 @@ func NewProtocolManager(config *params.ChainConfig, fastSync bool, networkId int
 		txsyncCh:    make(chan *txsync),
 		quitSync:    make(chan struct{}),
 	}
+	manager.forkFilter = newForkFilter(config, blockchain.Genesis().Hash(), func() uint64 {
+		return blockchain.CurrentHeader().Number.Uint64()
+	})
 	// Figure out whether to allow fast sync or not
 	if fastSync && blockchain.CurrentBlock().NumberU64() > 0 {
 		glog.V(logger.Info).Infof("blockchain not empty, fast sync disabled")
@@ -163,8 +177,11 @@ func NewProtocolManager(config *params.ChainConfig, fastSync bool, networkId int
 	// Construct the different synchronisation mechanisms
 	manager.downloader = downloader.New(downloader.FullSync, chaindb, manager.eventMux, blockchain.HasHeader, blockchain.HasBlockAndState, blockchain.GetHeaderByHash,
 		blockchain.GetBlockByHash, blockchain.CurrentHeader, blockchain.CurrentBlock, blockchain.CurrentFastBlock, blockchain.FastSyncCommitHead,
-		blockchain.GetTdByHash, blockchain.InsertHeaderChain, manager.insertChain, blockchain.InsertReceiptChain, blockchain.Rollback,
-		manager.removePeer)
+		blockchain.GetTdByHash, blockchain.InsertHeaderChain, manager.insertSyncChain, blockchain.InsertReceiptChain, blockchain.Rollback,
+		manager.dropSyncPeer)
+	if checkpoint, ok := params.TrustedCheckpoints[blockchain.Genesis().Hash()]; ok {
+		manager.downloader.SetCheckpoint(checkpoint)
+	}
 
 	validator := func(block *types.Block, parent *types.Block) error {
 		return core.ValidateHeader(config, pow, block.Header(), parent.Header(), true, false)
@@ -172,11 +189,11 @@ func NewProtocolManager(config *params.ChainConfig, fastSync bool, networkId int
 	heighter := func() uint64 {
 		return blockchain.CurrentBlock().NumberU64()
 	}
-	inserter := func(blocks types.Blocks) (int, error) {
+	inserter := func(peer string, blocks types.Blocks) (int, error) {
 		manager.setSynced() // Mark initial sync done on any fetcher import
-		return manager.insertChain(blocks)
+		return manager.insertChain(peer, blocks)
 	}
-	manager.fetcher = fetcher.New(blockchain.GetBlockByHash, validator, manager.BroadcastBlock, heighter, inserter, manager.removePeer)
+	manager.fetcher = fetcher.New(blockchain.GetBlockByHash, validator, manager.BroadcastBlock, heighter, inserter, manager.dropBadPeer)
 
 	if blockchain.Genesis().Hash().Hex() == defaultGenesisHash && networkId == 1 {
 		glog.V(logger.Debug).Infoln("Bad Block Reporting is enabled")
@@ -186,8 +203,19 @@ func NewProtocolManager(config *params.ChainConfig, fastSync bool, networkId int
 	return manager, nil
 }
 
-func (pm *ProtocolManager) insertChain(blocks types.Blocks) (i int, err error) {
+// insertSyncChain inserts a batch of blocks retrieved by the downloader,
+// attributing any rejected block to the peer the chain is synced from.
+func (pm *ProtocolManager) insertSyncChain(blocks types.Blocks) (int, error) {
+	return pm.insertChain(pm.downloader.SyncPeer(), blocks)
+}
+
+// insertChain inserts a batch of blocks originating from the given peer into
+// the local chain, recording the origin of any rejected block.
+func (pm *ProtocolManager) insertChain(peer string, blocks types.Blocks) (i int, err error) {
 	i, err = pm.blockchain.InsertChain(blocks)
+	if err != nil && i < len(blocks) {
+		pm.blockchain.SetBadBlockPeer(blocks[i].Hash(), peer)
+	}
 	if pm.badBlockReportingEnabled && core.IsValidationErr(err) && i < len(blocks) {
 		go sendBadBlockReport(blocks[i], err)
 	}
@@ -215,7 +243,8 @@ func (pm *ProtocolManager) removePeer(id string) {
 
 func (pm *ProtocolManager) Start() {
 	// broadcast transactions
-	pm.txSub = pm.eventMux.Subscribe(core.TxPreEvent{})
+	pm.txCh = make(chan core.TxPreEvent, txChanSize)
+	pm.txSub = pm.subs.Track(pm.txpool.SubscribeTxPreEvent(pm.txCh))
 	go pm.txBroadcastLoop()
 	// broadcast mined blocks
 	pm.minedBlockSub = pm.eventMux.Subscribe(core.NewMinedBlockEvent{})
@@ -229,7 +258,7 @@ func (pm *ProtocolManager) Start() {
 func (pm *ProtocolManager) Stop() {
 	glog.V(logger.Info).Infoln("Stopping ethereum protocol handler...")
 
-	pm.txSub.Unsubscribe()         // quits txBroadcastLoop
+	pm.subs.Close()                // quits txBroadcastLoop
 	pm.minedBlockSub.Unsubscribe() // quits blockBroadcastLoop
 
 	// Quit the sync loop.
@@ -252,7 +281,15 @@ func (pm *ProtocolManager) Stop() {
 }
 
 func (pm *ProtocolManager) newPeer(pv int, p *p2p.Peer, rw p2p.MsgReadWriter) *peer {
-	return newPeer(pv, p, newMeteredMsgWriter(rw))
+	return newPeer(pv, p, newMeteredMsgWriter(newThrottledMsgReadWriter(rw, pm.download, pm.upload, pm.quitSync)))
+}
+
+// SetBandwidthLimits caps the number of bytes per second of chain data (blocks,
+// headers, receipts and state) downloaded from and served to all peers. Zero or
+// negative values disable the respective limit. Only peers connecting after the
+// call are affected.
+func (pm *ProtocolManager) SetBandwidthLimits(download, upload int) {
+	pm.download, pm.upload = newBandwidthLimiter(download), newBandwidthLimiter(upload)
 }
 
 // handle is the callback invoked to manage the life cycle of an eth peer. When
@@ -262,12 +299,13 @@ func (pm *ProtocolManager) handle(p *peer) error {
 		return p2p.DiscTooManyPeers
 	}
 
-	glog.V(logger.Debug).Infof("%v: peer connected [%s]", p, p.Name())
+	glog.V(logger.Debug).Infow("peer connected", "peer", p.id, "name", p.Name())
 
 	// Execute the Ethereum handshake
 	td, head, genesis := pm.blockchain.Status()
-	if err := p.Handshake(pm.networkId, td, head, genesis); err != nil {
-		glog.V(logger.Debug).Infof("%v: handshake failed: %v", p, err)
+	fork := newForkID(pm.chainconfig, genesis, pm.blockchain.CurrentHeader().Number.Uint64())
+	if err := p.Handshake(pm.networkId, td, head, genesis, fork, pm.forkFilter); err != nil {
+		glog.V(logger.Debug).Infow("handshake failed", "peer", p.id, "err", err)
 		return err
 	}
 	if rw, ok := p.rw.(*meteredMsgReadWriter); ok {
@@ -298,6 +336,7 @@ func (pm *ProtocolManager) handle(p *peer) error {
 		// Start a timer to disconnect if the peer doesn't reply in time
 		p.forkDrop = time.AfterFunc(daoChallengeTimeout, func() {
 			glog.V(logger.Debug).Infof("%v: timed out DAO fork-check, dropping", p)
+			pm.ratePeer(p.id, reputationForkMismatch, "DAO fork-check timeout")
 			pm.removePeer(p.id)
 		})
 		// Make sure it's cleaned up if the peer dies off
@@ -453,6 +492,7 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 				// Validate the header and either drop the peer or continue
 				if err := core.ValidateDAOHeaderExtraData(pm.chainconfig, headers[0]); err != nil {
 					glog.V(logger.Debug).Infof("%v: verified to be on the other side of the DAO fork, dropping", p)
+					pm.ratePeer(p.id, reputationForkMismatch, "DAO fork mismatch")
 					return err
 				}
 				glog.V(logger.Debug).Infof("%v: verified to be on the same side of the DAO fork", p)
@@ -541,7 +581,7 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 				return errResp(ErrDecode, "msg %v: %v", msg, err)
 			}
 			// Retrieve the requested state entry, stopping if enough was found
-			if entry, err := pm.chaindb.Get(hash.Bytes()); err == nil {
+			if entry, err := pm.blockchain.StateDatabase().Get(hash.Bytes()); err == nil {
 				data = append(data, entry)
 				bytes += len(entry)
 			}
@@ -625,6 +665,9 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 		for _, block := range unknown {
 			pm.fetcher.Notify(p.id, block.Hash, block.Number, time.Now(), p.RequestOneHeader, p.RequestBodies)
 		}
+		if len(unknown) > 0 {
+			pm.ratePeer(p.id, reputationFreshBlock, "fresh block announcement")
+		}
 
 	case msg.Code == NewBlockMsg:
 		// Retrieve and decode the propagated block
@@ -637,6 +680,9 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 
 		// Mark the peer as owning the block and schedule it for import
 		p.MarkBlock(request.Block.Hash())
+		if !pm.blockchain.HasBlock(request.Block.Hash()) {
+			pm.ratePeer(p.id, reputationFreshBlock, "fresh block propagation")
+		}
 		pm.fetcher.Enqueue(p.id, request.Block)
 
 		// Assuming the block is importable by the peer, but possibly not yet done so,
@@ -740,20 +786,27 @@ func (self *ProtocolManager) minedBroadcastLoop() {
 }
 
 func (self *ProtocolManager) txBroadcastLoop() {
-	// automatically stops if unsubscribe
-	for obj := range self.txSub.Chan() {
-		event := obj.Data.(core.TxPreEvent)
-		self.BroadcastTx(event.Tx.Hash(), event.Tx)
+	for {
+		select {
+		case event := <-self.txCh:
+			self.BroadcastTx(event.Tx.Hash(), event.Tx)
+
+		// Err() channel will be closed when unsubscribing.
+		case <-self.txSub.Err():
+			return
+		}
 	}
 }
 
 // EthNodeInfo represents a short summary of the Ethereum sub-protocol metadata known
 // about the host peer.
 type EthNodeInfo struct {
-	Network    int         `json:"network"`    // Ethereum network ID (1=Frontier, 2=Morden, Ropsten=3)
-	Difficulty *big.Int    `json:"difficulty"` // Total difficulty of the host's blockchain
-	Genesis    common.Hash `json:"genesis"`    // SHA3 hash of the host's genesis block
-	Head       common.Hash `json:"head"`       // SHA3 hash of the host's best owned block
+	Network    int                 `json:"network"`    // Ethereum network ID (1=Frontier, 2=Morden, Ropsten=3)
+	Difficulty *big.Int            `json:"difficulty"` // Total difficulty of the host's blockchain
+	Genesis    common.Hash         `json:"genesis"`    // SHA3 hash of the host's genesis block
+	Config     *params.ChainConfig `json:"config"`     // Chain configuration for the fork rules
+	Head       common.Hash         `json:"head"`       // SHA3 hash of the host's best owned block
+	HeadNumber *big.Int            `json:"headNumber"` // Number of the host's best owned block
 }
 
 // NodeInfo retrieves some protocol metadata about the running host node.
@@ -763,6 +816,8 @@ func (self *ProtocolManager) NodeInfo() *EthNodeInfo {
 		Network:    self.networkId,
 		Difficulty: self.blockchain.GetTd(currentBlock.Hash(), currentBlock.NumberU64()),
 		Genesis:    self.blockchain.Genesis().Hash(),
+		Config:     self.chainconfig,
 		Head:       currentBlock.Hash(),
+		HeadNumber: currentBlock.Number(),
 	}
 }
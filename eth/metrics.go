@@ -17,6 +17,8 @@
 package eth
 
 import (
+	"time"
+
 	"github.com/EarthDollar/go-earthdollar/metrics"
 	"github.com/EarthDollar/go-earthdollar/p2p"
 )
@@ -54,8 +56,17 @@ var (
 	miscInTrafficMeter        = metrics.NewMeter("eth/misc/in/traffic")
 	miscOutPacketsMeter       = metrics.NewMeter("eth/misc/out/packets")
 	miscOutTrafficMeter       = metrics.NewMeter("eth/misc/out/traffic")
+
+	chainHeadGauge    = metrics.NewGauge("eth/chain/head")    // Number of the current head block
+	chainHighestGauge = metrics.NewGauge("eth/chain/highest") // Highest block number known from the network
+	chainHeadAgeGauge = metrics.NewGauge("eth/chain/headage") // Seconds elapsed since the head block was mined
+	syncETAGauge      = metrics.NewGauge("eth/sync/eta")      // Estimated seconds until the sync completes
 )
 
+// chainMetricsRefresh is the interval at which the time based chain and sync
+// gauges are recalculated in between chain head events.
+const chainMetricsRefresh = 3 * time.Second
+
 // meteredMsgReadWriter is a wrapper around a p2p.MsgReadWriter, capable of
 // accumulating the above defined metrics based on the data stream contents.
 type meteredMsgReadWriter struct {
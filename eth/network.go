@@ -0,0 +1,109 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/EarthDollar/go-earthdollar/common"
+	"github.com/EarthDollar/go-earthdollar/core"
+	"github.com/EarthDollar/go-earthdollar/params"
+)
+
+// Network is a preset bundling everything needed to join a well known network.
+type Network struct {
+	Name        string              // Name the network is selected by
+	NetworkId   int                 // Network id used in the protocol handshake
+	Genesis     string              // Genesis JSON to seed the chain with (empty = default genesis)
+	GenesisHash common.Hash         // Hash of the genesis block, if fixed
+	ChainConfig *params.ChainConfig // Consensus rules of the network
+	Bootnodes   []string            // Enode URLs of the bootstrap nodes
+	DataSubdir  string              // Subdirectory of the data directory to keep the network's data in
+	Ephemeral   bool                // Throwaway local network: temporary data, no p2p networking, test PoW
+}
+
+// Networks is the registry of built-in network presets.
+var Networks = []*Network{
+	{
+		Name:        "mainnet",
+		NetworkId:   NetworkId,
+		GenesisHash: params.MainNetGenesisHash,
+		ChainConfig: params.MainnetChainConfig,
+		Bootnodes:   params.MainnetBootnodes,
+	},
+	{
+		Name:        "testnet",
+		NetworkId:   TestNetworkId,
+		Genesis:     core.DefaultTestnetGenesisBlock(),
+		GenesisHash: params.TestNetGenesisHash,
+		ChainConfig: params.TestnetChainConfig,
+		Bootnodes:   params.TestnetBootnodes,
+		DataSubdir:  "testnet",
+	},
+	{
+		Name:        "dev",
+		NetworkId:   NetworkId,
+		Genesis:     core.DevGenesisBlock(),
+		ChainConfig: params.MainnetChainConfig,
+		Ephemeral:   true,
+	},
+}
+
+// NetworkNames returns the names of all built-in networks.
+func NetworkNames() []string {
+	names := make([]string, len(Networks))
+	for i, network := range Networks {
+		names[i] = network.Name
+	}
+	return names
+}
+
+// LookupNetwork retrieves a built-in network preset by name.
+func LookupNetwork(name string) (*Network, error) {
+	for _, network := range Networks {
+		if network.Name == name {
+			return network, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown network %q (known: %s)", name, strings.Join(NetworkNames(), ", "))
+}
+
+// ApplyNetwork fills the options left unset in config from the network preset
+// it selects. Configs not selecting any network are left untouched.
+func ApplyNetwork(config *Config) error {
+	if config.Network == "" {
+		return nil
+	}
+	network, err := LookupNetwork(config.Network)
+	if err != nil {
+		return err
+	}
+	if config.NetworkId == 0 {
+		config.NetworkId = network.NetworkId
+	}
+	if config.Genesis == "" {
+		config.Genesis = network.Genesis
+	}
+	if config.ChainConfig == nil {
+		config.ChainConfig = network.ChainConfig
+	}
+	if network.Ephemeral {
+		config.PowTest = true
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/EarthDollar/go-earthdollar/common"
+	"github.com/EarthDollar/go-earthdollar/core"
+	"github.com/EarthDollar/go-earthdollar/ethdb"
+	"github.com/EarthDollar/go-earthdollar/params"
+)
+
+// Tests that the genesis specifications of the built-in networks produce the
+// genesis blocks they are identified by.
+func TestNetworkGenesis(t *testing.T) {
+	for _, network := range Networks {
+		if network.Genesis == "" || network.GenesisHash == (common.Hash{}) {
+			continue
+		}
+		db, _ := ethdb.NewMemDatabase()
+		block, err := core.WriteGenesisBlock(db, strings.NewReader(network.Genesis))
+		if err != nil {
+			t.Fatalf("%s: failed to write genesis: %v", network.Name, err)
+		}
+		if block.Hash() != network.GenesisHash {
+			t.Errorf("%s: genesis hash mismatch: have %x, want %x", network.Name, block.Hash(), network.GenesisHash)
+		}
+		if err := ValidateNetworkId(network.NetworkId, block.Hash()); err != nil {
+			t.Errorf("%s: network id rejected: %v", network.Name, err)
+		}
+	}
+}
+
+// Tests that network presets only fill the options left unset.
+func TestApplyNetwork(t *testing.T) {
+	// Unset options are taken from the preset
+	config := &Config{Network: "testnet"}
+	if err := ApplyNetwork(config); err != nil {
+		t.Fatalf("failed to apply network: %v", err)
+	}
+	if config.NetworkId != TestNetworkId {
+		t.Errorf("network id mismatch: have %d, want %d", config.NetworkId, TestNetworkId)
+	}
+	if config.Genesis != core.DefaultTestnetGenesisBlock() {
+		t.Errorf("genesis not set from preset")
+	}
+	if config.ChainConfig != params.TestnetChainConfig {
+		t.Errorf("chain config not set from preset")
+	}
+	if config.PowTest {
+		t.Errorf("test PoW enabled on non-ephemeral network")
+	}
+	// Explicit options are retained
+	config = &Config{Network: "dev", NetworkId: 1337, ChainConfig: params.TestChainConfig}
+	if err := ApplyNetwork(config); err != nil {
+		t.Fatalf("failed to apply network: %v", err)
+	}
+	if config.NetworkId != 1337 {
+		t.Errorf("network id overridden: have %d, want %d", config.NetworkId, 1337)
+	}
+	if config.ChainConfig != params.TestChainConfig {
+		t.Errorf("chain config overridden")
+	}
+	if !config.PowTest {
+		t.Errorf("test PoW not enabled on ephemeral network")
+	}
+	// Configs without a network are left alone, unknown ones rejected
+	config = new(Config)
+	if err := ApplyNetwork(config); err != nil || config.NetworkId != 0 || config.Genesis != "" {
+		t.Errorf("config without network modified: %v", err)
+	}
+	if err := ApplyNetwork(&Config{Network: "olympic"}); err == nil {
+		t.Errorf("unknown network accepted")
+	}
+}
+
+// Tests that configuration dumps resolve the network preset and the derived
+// values without modifying the original config.
+func TestConfigDump(t *testing.T) {
+	config := &Config{Network: "testnet", CacheSize: 100}
+	dump, err := config.Dump()
+	if err != nil {
+		t.Fatalf("failed to dump config: %v", err)
+	}
+	if config.NetworkId != 0 || config.ChainConfig != nil {
+		t.Errorf("original config modified")
+	}
+	if dump.NetworkId != TestNetworkId {
+		t.Errorf("network id mismatch: have %d, want %d", dump.NetworkId, TestNetworkId)
+	}
+	if dump.GenesisHash != params.TestNetGenesisHash {
+		t.Errorf("genesis hash mismatch: have %x, want %x", dump.GenesisHash, params.TestNetGenesisHash)
+	}
+	if dump.ChainConfig != params.TestnetChainConfig {
+		t.Errorf("chain config not set from preset")
+	}
+	if dump.Cache.Ratios != DefaultCacheRatios {
+		t.Errorf("cache ratios mismatch: have %+v, want %+v", dump.Cache.Ratios, DefaultCacheRatios)
+	}
+	if dump.Cache.DatabaseMB != 50 || dump.Cache.TrieMB != 40 || dump.Cache.SnapshotMB != 10 {
+		t.Errorf("cache split mismatch: have %d/%d/%d, want 50/40/10", dump.Cache.DatabaseMB, dump.Cache.TrieMB, dump.Cache.SnapshotMB)
+	}
+	// Custom genesis specs are hashed
+	dev, _ := LookupNetwork("dev")
+	dump, err = (&Config{Genesis: dev.Genesis}).Dump()
+	if err != nil {
+		t.Fatalf("failed to dump config: %v", err)
+	}
+	db, _ := ethdb.NewMemDatabase()
+	block, _ := core.WriteGenesisBlock(db, strings.NewReader(dev.Genesis))
+	if dump.GenesisHash != block.Hash() {
+		t.Errorf("custom genesis hash mismatch: have %x, want %x", dump.GenesisHash, block.Hash())
+	}
+}
@@ -40,6 +40,29 @@ var testAccount, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6
 func TestStatusMsgErrors62(t *testing.T) { testStatusMsgErrors(t, 62) }
 func TestStatusMsgErrors63(t *testing.T) { testStatusMsgErrors(t, 63) }
 
+// Tests that eth/64 peers announcing an incompatible fork ID are rejected.
+func TestStatusMsgForkIDRejected64(t *testing.T) {
+	pm := newTestProtocolManagerMust(t, false, 0, nil, nil)
+	td, currentBlock, genesis := pm.blockchain.Status()
+	defer pm.Stop()
+
+	p, errc := newTestPeer("peer", eth64, pm, false)
+	defer p.close()
+
+	bad := forkID{Hash: [4]byte{0xde, 0xad, 0xbe, 0xef}}
+	go p2p.Send(p.app, StatusMsg, statusData64{eth64, NetworkId, td, currentBlock, genesis, bad})
+
+	want := errResp(ErrForkIDRejected, "deadbeef/0: %v", errLocalIncompatibleOrStale)
+	select {
+	case err := <-errc:
+		if err == nil || err.Error() != want.Error() {
+			t.Errorf("wrong error: got %v, want %q", err, want)
+		}
+	case <-time.After(2 * time.Second):
+		t.Errorf("protocol did not shut down within 2 seconds")
+	}
+}
+
 func testStatusMsgErrors(t *testing.T, protocol int) {
 	pm := newTestProtocolManagerMust(t, false, 0, nil, nil)
 	td, currentBlock, genesis := pm.blockchain.Status()
@@ -60,7 +83,7 @@ func testStatusMsgErrors(t *testing.T, protocol int) {
 		},
 		{
 			code: StatusMsg, data: statusData{uint32(protocol), 999, td, currentBlock, genesis},
-			wantError: errResp(ErrNetworkIdMismatch, "999 (!= 1)"),
+			wantError: errResp(ErrNetworkIdMismatch, "999 (!= %d)", NetworkId),
 		},
 		{
 			code: StatusMsg, data: statusData{uint32(protocol), NetworkId, td, currentBlock, common.Hash{3}},
@@ -0,0 +1,69 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"time"
+
+	"github.com/EarthDollar/go-earthdollar/p2p/discover"
+)
+
+// Reputation adjustments applied to peers based on their protocol behaviour.
+const (
+	reputationSyncFailure  = -20 // Dropped by the downloader (timeout, stalling, useless or invalid chain)
+	reputationInvalidBlock = -50 // Dropped by the fetcher (invalid announcement or block)
+	reputationForkMismatch = -50 // Failed or timed out the DAO fork challenge
+	reputationFreshBlock   = 1   // Propagated a block we didn't know about yet
+)
+
+// badPeerBanDuration is the time peers feeding us invalid proof-of-work or bad
+// blocks are refused reconnection for.
+const badPeerBanDuration = time.Hour
+
+// peerRater is the reputation tracker and ban list of the networking layer,
+// implemented by p2p.Server.
+type peerRater interface {
+	AdjustPeerReputation(id discover.NodeID, delta int, reason string) int
+	BanNode(id discover.NodeID, duration time.Duration)
+}
+
+// ratePeer adjusts the reputation of a connected peer, if reputation tracking
+// is available.
+func (pm *ProtocolManager) ratePeer(id string, delta int, reason string) {
+	if pm.rater == nil {
+		return
+	}
+	if peer := pm.peers.Peer(id); peer != nil {
+		pm.rater.AdjustPeerReputation(peer.ID(), delta, reason)
+	}
+}
+
+// dropSyncPeer is the downloader callback for peers failing synchronisation.
+func (pm *ProtocolManager) dropSyncPeer(id string) {
+	pm.ratePeer(id, reputationSyncFailure, "synchronisation failure")
+	pm.removePeer(id)
+}
+
+// dropBadPeer is the fetcher callback for peers propagating invalid blocks. As
+// these fail proof-of-work or block validation, the peer is banned outright.
+func (pm *ProtocolManager) dropBadPeer(id string) {
+	pm.ratePeer(id, reputationInvalidBlock, "invalid block propagation")
+	if peer := pm.peers.Peer(id); peer != nil && pm.rater != nil {
+		pm.rater.BanNode(peer.ID(), badPeerBanDuration)
+	}
+	pm.removePeer(id)
+}
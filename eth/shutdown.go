@@ -0,0 +1,82 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/EarthDollar/go-earthdollar/logger"
+	"github.com/EarthDollar/go-earthdollar/logger/glog"
+	"golang.org/x/net/context"
+)
+
+// DefaultShutdownTimeout is the time Stop waits for the subsystems to terminate
+// before giving up and reporting the stuck ones.
+const DefaultShutdownTimeout = 30 * time.Second
+
+// ShutdownError is returned if some subsystems did not terminate before the
+// shutdown deadline. The database is left open until they finish.
+type ShutdownError struct {
+	Stuck []string // Subsystems still running, in stop order (the first blocked the rest)
+}
+
+func (e *ShutdownError) Error() string {
+	return fmt.Sprintf("shutdown deadline exceeded, still running: %s", strings.Join(e.Stuck, ", "))
+}
+
+// shutdownStep is a named subsystem termination.
+type shutdownStep struct {
+	name string
+	stop func()
+}
+
+// runShutdown executes the steps one after the other and then the finish function,
+// until all are done or the context expires. In the latter case the remaining
+// steps and finish are left to run in the background and the names of the steps
+// are returned.
+func runShutdown(ctx context.Context, steps []shutdownStep, finish func()) []string {
+	for i, step := range steps {
+		done := make(chan struct{})
+		go func(stop func()) {
+			stop()
+			close(done)
+		}(step.stop)
+
+		select {
+		case <-done:
+		case <-ctx.Done():
+			rest := steps[i+1:]
+			go func() {
+				<-done
+				for _, step := range rest {
+					step.stop()
+				}
+				finish()
+			}()
+			stuck := make([]string, 0, len(steps)-i)
+			for _, step := range steps[i:] {
+				stuck = append(stuck, step.name)
+			}
+			glog.V(logger.Error).Infof("Shutdown deadline exceeded waiting for %s, still running: %s", step.name, strings.Join(stuck, ", "))
+			return stuck
+		}
+	}
+	finish()
+	return nil
+}
@@ -0,0 +1,87 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// Tests that shutdown steps are executed in order if they finish in time.
+func TestShutdownOrder(t *testing.T) {
+	var order []string
+	step := func(name string) shutdownStep {
+		return shutdownStep{name, func() { order = append(order, name) }}
+	}
+	steps := []shutdownStep{step("miner"), step("protocol manager"), step("blockchain")}
+
+	finish := func() { order = append(order, "database") }
+
+	if stuck := runShutdown(context.Background(), steps, finish); stuck != nil {
+		t.Fatalf("unexpected stuck subsystems: %v", stuck)
+	}
+	if want := []string{"miner", "protocol manager", "blockchain", "database"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("stop order mismatch: have %v, want %v", order, want)
+	}
+}
+
+// Tests that a blocking subsystem is reported along with the ones queued after
+// it once the deadline expires, and that those are still stopped afterwards with
+// the database only being closed after all of them finished.
+func TestShutdownDeadline(t *testing.T) {
+	release := make(chan struct{})
+	stopped := make(chan struct{})
+	closed := make(chan struct{})
+	steps := []shutdownStep{
+		{"miner", func() {}},
+		{"blockchain", func() { <-release }},
+		{"event mux", func() { close(stopped) }},
+	}
+	finish := func() {
+		select {
+		case <-stopped:
+		default:
+			t.Errorf("database closed before the subsystems stopped")
+		}
+		close(closed)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	stuck := runShutdown(ctx, steps, finish)
+	if want := []string{"blockchain", "event mux"}; !reflect.DeepEqual(stuck, want) {
+		t.Fatalf("stuck subsystems mismatch: have %v, want %v", stuck, want)
+	}
+	select {
+	case <-closed:
+		t.Fatalf("database closed while subsystems still running")
+	default:
+	}
+	close(release)
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatalf("database not closed after the subsystems stopped")
+	}
+	err := &ShutdownError{Stuck: stuck}
+	if want := "shutdown deadline exceeded, still running: blockchain, event mux"; err.Error() != want {
+		t.Errorf("error message mismatch: have %q, want %q", err.Error(), want)
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright 2015 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"sync"
+	"time"
+
+	"github.com/EarthDollar/go-earthdollar/p2p"
+)
+
+// bandwidthLimiter is a token bucket shared by all peers, capping the number of
+// bytes per second transferred in one direction. Transfers larger than the
+// available allowance are admitted by going into debt, delaying the next ones.
+type bandwidthLimiter struct {
+	rate float64 // Number of bytes allowed per second
+
+	lock   sync.Mutex
+	tokens float64   // Currently available allowance (negative if in debt)
+	last   time.Time // Time instance of the last allowance refill
+}
+
+// newBandwidthLimiter creates a limiter allowing rate bytes per second, or nil
+// if the rate is not positive (unlimited).
+func newBandwidthLimiter(rate int) *bandwidthLimiter {
+	if rate <= 0 {
+		return nil
+	}
+	return &bandwidthLimiter{rate: float64(rate), tokens: float64(rate), last: time.Now()}
+}
+
+// reserve takes size bytes from the allowance, returning the time to wait until
+// the transfer may proceed.
+func (l *bandwidthLimiter) reserve(size uint32) time.Duration {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	// Refill the allowance, permitting bursts of at most a second's worth
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.rate {
+		l.tokens = l.rate
+	}
+	l.last = now
+
+	l.tokens -= float64(size)
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
+
+// wait blocks until size bytes may be transferred, returning false if the quit
+// channel was closed in the mean time.
+func (l *bandwidthLimiter) wait(size uint32, quit <-chan struct{}) bool {
+	delay := l.reserve(size)
+	if delay == 0 {
+		return true
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-quit:
+		return false
+	}
+}
+
+// throttledMsgReadWriter is a wrapper around a p2p.MsgReadWriter, delaying the
+// chain data transfers (block, header, receipt and state messages) to stay
+// within the configured download and upload bandwidths.
+type throttledMsgReadWriter struct {
+	p2p.MsgReadWriter
+	download *bandwidthLimiter
+	upload   *bandwidthLimiter
+	quit     <-chan struct{}
+}
+
+// newThrottledMsgReadWriter wraps a p2p MsgReadWriter with bandwidth limits. If
+// neither direction is limited, the original object is returned.
+func newThrottledMsgReadWriter(rw p2p.MsgReadWriter, download, upload *bandwidthLimiter, quit <-chan struct{}) p2p.MsgReadWriter {
+	if download == nil && upload == nil {
+		return rw
+	}
+	return &throttledMsgReadWriter{MsgReadWriter: rw, download: download, upload: upload, quit: quit}
+}
+
+func (rw *throttledMsgReadWriter) ReadMsg() (p2p.Msg, error) {
+	msg, err := rw.MsgReadWriter.ReadMsg()
+	if err != nil || rw.download == nil || !isChainDataMsg(msg.Code) {
+		return msg, err
+	}
+	if !rw.download.wait(msg.Size, rw.quit) {
+		msg.Discard()
+		return p2p.Msg{}, p2p.DiscQuitting
+	}
+	return msg, nil
+}
+
+func (rw *throttledMsgReadWriter) WriteMsg(msg p2p.Msg) error {
+	if rw.upload != nil && isChainDataMsg(msg.Code) {
+		if !rw.upload.wait(msg.Size, rw.quit) {
+			return p2p.DiscQuitting
+		}
+	}
+	return rw.MsgReadWriter.WriteMsg(msg)
+}
+
+// isChainDataMsg reports whether a message carries chain data, being subject to
+// the bandwidth limits. Control messages (status, announcements, requests) and
+// transactions are never delayed.
+func isChainDataMsg(code uint64) bool {
+	switch code {
+	case BlockHeadersMsg, BlockBodiesMsg, NewBlockMsg, NodeDataMsg, ReceiptsMsg:
+		return true
+	}
+	return false
+}
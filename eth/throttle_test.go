@@ -0,0 +1,75 @@
+// Copyright 2015 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/EarthDollar/go-earthdollar/p2p"
+)
+
+// Tests that the bandwidth limiter admits a second's worth of burst and delays
+// transfers exceeding it proportionally to the overdraft.
+func TestBandwidthLimiter(t *testing.T) {
+	if l := newBandwidthLimiter(0); l != nil {
+		t.Fatalf("unlimited rate created limiter")
+	}
+	l := newBandwidthLimiter(1000)
+	if delay := l.reserve(1000); delay != 0 {
+		t.Fatalf("burst delayed: %v", delay)
+	}
+	if delay := l.reserve(500); delay < 400*time.Millisecond || delay > 500*time.Millisecond {
+		t.Fatalf("overdraft delay mismatch: have %v, want ~500ms", delay)
+	}
+	quit := make(chan struct{})
+	close(quit)
+	if l.wait(1000, quit) {
+		t.Fatalf("wait succeeded despite quit")
+	}
+}
+
+// Tests that only chain data messages are subject to the bandwidth limits.
+func TestThrottledMsgReadWriter(t *testing.T) {
+	app, net := p2p.MsgPipe()
+	defer app.Close()
+
+	limit := newBandwidthLimiter(1)
+	limit.reserve(1) // Drain the burst allowance, delays are now ~1s per byte
+	rw := newThrottledMsgReadWriter(net, nil, limit, make(chan struct{}))
+
+	done := make(chan error, 1)
+	go func() { done <- p2p.Send(rw, TxMsg, []uint{1, 2, 3}) }()
+	if err := p2p.ExpectMsg(app, TxMsg, []uint{1, 2, 3}); err != nil {
+		t.Fatalf("transaction message not passed through: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("transaction send failed: %v", err)
+	}
+	go p2p.Send(rw, BlockHeadersMsg, []uint{1, 2, 3})
+
+	read := make(chan struct{})
+	go func() {
+		app.ReadMsg()
+		close(read)
+	}()
+	select {
+	case <-read:
+		t.Fatalf("chain data message not throttled")
+	case <-time.After(200 * time.Millisecond):
+	}
+}
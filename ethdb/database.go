@@ -17,10 +17,12 @@
 package ethdb
 
 import (
+	"fmt"
 	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/EarthDollar/go-earthdollar/logger"
@@ -31,12 +33,11 @@ import (
 	"github.com/syndtr/goleveldb/leveldb/filter"
 	"github.com/syndtr/goleveldb/leveldb/iterator"
 	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
 
 	gometrics "github.com/rcrowley/go-metrics"
 )
 
-var OpenFileLimit = 64
-
 // cacheRatio specifies how the total allotted cache is distributed between the
 // various system databases.
 var cacheRatio = map[string]float64{
@@ -65,6 +66,9 @@ type LDBDatabase struct {
 	compReadMeter  gometrics.Meter // Meter for measuring the data read during compaction
 	compWriteMeter gometrics.Meter // Meter for measuring the data written during compaction
 
+	gets   uint64 // Number of lookups, accessed atomically
+	misses uint64 // Number of lookups of missing keys, accessed atomically
+
 	quitLock sync.Mutex      // Mutex protecting the quit channel access
 	quitChan chan chan error // Quit channel to stop the metrics collection before closing the database
 }
@@ -129,8 +133,10 @@ func (self *LDBDatabase) Get(key []byte) ([]byte, error) {
 		defer self.getTimer.UpdateSince(time.Now())
 	}
 	// Retrieve the key and increment the miss counter if not found
+	atomic.AddUint64(&self.gets, 1)
 	dat, err := self.db.Get(key, nil)
 	if err != nil {
+		atomic.AddUint64(&self.misses, 1)
 		if self.missMeter != nil {
 			self.missMeter.Mark(1)
 		}
@@ -158,6 +164,12 @@ func (self *LDBDatabase) NewIterator() iterator.Iterator {
 	return self.db.NewIterator(nil, nil)
 }
 
+// NewIteratorWithPrefix returns an iterator over the subset of the database
+// whose keys start with the given prefix.
+func (self *LDBDatabase) NewIteratorWithPrefix(prefix []byte) iterator.Iterator {
+	return self.db.NewIterator(util.BytesPrefix(prefix), nil)
+}
+
 func (self *LDBDatabase) Close() {
 	// Stop the metrics collection to avoid internal database races
 	self.quitLock.Lock()
@@ -184,6 +196,109 @@ func (self *LDBDatabase) LDB() *leveldb.DB {
 	return self.db
 }
 
+// Property returns a leveldb property of the database. The "leveldb." prefix of
+// the property name is optional.
+func (self *LDBDatabase) Property(name string) (string, error) {
+	if !strings.HasPrefix(name, "leveldb.") {
+		name = "leveldb." + name
+	}
+	return self.db.GetProperty(name)
+}
+
+// Compact flattens the underlying data store for the given key range. In essence,
+// deleted and overwritten versions are discarded, and the data is rearranged to
+// reduce the cost of operations needed to access them.
+func (self *LDBDatabase) Compact(start []byte, limit []byte) error {
+	return self.db.CompactRange(util.Range{Start: start, Limit: limit})
+}
+
+// Stats returns a structured summary of the leveldb engine statistics.
+func (self *LDBDatabase) Stats() (*Stats, error) {
+	stats, err := self.db.GetProperty("leveldb.stats")
+	if err != nil {
+		return nil, err
+	}
+	levels, err := parseCompactionTable(stats)
+	if err != nil {
+		return nil, err
+	}
+	result := &Stats{
+		Levels:    levels,
+		Gets:      atomic.LoadUint64(&self.gets),
+		GetMisses: atomic.LoadUint64(&self.misses),
+	}
+	for name, field := range map[string]*int{
+		"leveldb.cachedblock":  &result.CachedBlocks,
+		"leveldb.openedtables": &result.OpenTables,
+		"leveldb.alivesnaps":   &result.AliveSnapshots,
+		"leveldb.aliveiters":   &result.AliveIterators,
+	} {
+		value, err := self.db.GetProperty(name)
+		if err != nil {
+			return nil, err
+		}
+		if value != "<nil>" {
+			if *field, err = strconv.Atoi(value); err != nil {
+				return nil, fmt.Errorf("invalid %s property %q: %v", name, value, err)
+			}
+		}
+	}
+	if len(levels) > 0 && levels[0].Level == 0 {
+		result.WriteSlowdown = levels[0].Tables >= opt.DefaultWriteL0SlowdownTrigger
+		result.WritePaused = levels[0].Tables >= opt.DefaultWriteL0PauseTrigger
+	}
+	return result, nil
+}
+
+// parseCompactionTable extracts the per level statistics from the leveldb stats
+// property. Levels without any tables are omitted by leveldb.
+//
+// This is how a stats table look like (currently):
+//   Compactions
+//    Level |   Tables   |    Size(MB)   |    Time(sec)  |    Read(MB)   |   Write(MB)
+//   -------+------------+---------------+---------------+---------------+---------------
+//      0   |          0 |       0.00000 |       1.27969 |       0.00000 |      12.31098
+//      1   |         85 |     109.27913 |      28.09293 |     213.92493 |     214.26294
+//      2   |        523 |    1000.37159 |       7.26059 |      66.86342 |      66.77884
+//      3   |        570 |    1113.18458 |       0.00000 |       0.00000 |       0.00000
+func parseCompactionTable(stats string) ([]LevelStats, error) {
+	// Find the compaction table, skip the header
+	lines := strings.Split(stats, "\n")
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) != "Compactions" {
+		lines = lines[1:]
+	}
+	if len(lines) <= 3 {
+		return nil, fmt.Errorf("compaction table not found")
+	}
+	lines = lines[3:]
+
+	// Iterate over all the table rows and parse the entries
+	var levels []LevelStats
+	for _, line := range lines {
+		parts := strings.Split(line, "|")
+		if len(parts) != 6 {
+			break
+		}
+		var (
+			level LevelStats
+			err   error
+		)
+		if level.Level, err = strconv.Atoi(strings.TrimSpace(parts[0])); err != nil {
+			return nil, fmt.Errorf("compaction entry parsing failed: %v", err)
+		}
+		if level.Tables, err = strconv.Atoi(strings.TrimSpace(parts[1])); err != nil {
+			return nil, fmt.Errorf("compaction entry parsing failed: %v", err)
+		}
+		for i, field := range []*float64{&level.SizeMB, &level.CompactTime, &level.CompactRead, &level.CompactWrite} {
+			if *field, err = strconv.ParseFloat(strings.TrimSpace(parts[2+i]), 64); err != nil {
+				return nil, fmt.Errorf("compaction entry parsing failed: %v", err)
+			}
+		}
+		levels = append(levels, level)
+	}
+	return levels, nil
+}
+
 // Meter configures the database metrics collectors and
 func (self *LDBDatabase) Meter(prefix string) {
 	// Short circuit metering if the metrics system is disabled
@@ -211,15 +326,6 @@ func (self *LDBDatabase) Meter(prefix string) {
 
 // meter periodically retrieves internal leveldb counters and reports them to
 // the metrics subsystem.
-//
-// This is how a stats table look like (currently):
-//   Compactions
-//    Level |   Tables   |    Size(MB)   |    Time(sec)  |    Read(MB)   |   Write(MB)
-//   -------+------------+---------------+---------------+---------------+---------------
-//      0   |          0 |       0.00000 |       1.27969 |       0.00000 |      12.31098
-//      1   |         85 |     109.27913 |      28.09293 |     213.92493 |     214.26294
-//      2   |        523 |    1000.37159 |       7.26059 |      66.86342 |      66.77884
-//      3   |        570 |    1113.18458 |       0.00000 |       0.00000 |       0.00000
 func (self *LDBDatabase) meter(refresh time.Duration) {
 	// Create the counters to store current and previous values
 	counters := make([][]float64, 2)
@@ -234,34 +340,19 @@ func (self *LDBDatabase) meter(refresh time.Duration) {
 			glog.V(logger.Error).Infof("failed to read database stats: %v", err)
 			return
 		}
-		// Find the compaction table, skip the header
-		lines := strings.Split(stats, "\n")
-		for len(lines) > 0 && strings.TrimSpace(lines[0]) != "Compactions" {
-			lines = lines[1:]
-		}
-		if len(lines) <= 3 {
-			glog.V(logger.Error).Infof("compaction table not found")
+		levels, err := parseCompactionTable(stats)
+		if err != nil {
+			glog.V(logger.Error).Infof("%v", err)
 			return
 		}
-		lines = lines[3:]
-
 		// Iterate over all the table rows, and accumulate the entries
 		for j := 0; j < len(counters[i%2]); j++ {
 			counters[i%2][j] = 0
 		}
-		for _, line := range lines {
-			parts := strings.Split(line, "|")
-			if len(parts) != 6 {
-				break
-			}
-			for idx, counter := range parts[3:] {
-				if value, err := strconv.ParseFloat(strings.TrimSpace(counter), 64); err != nil {
-					glog.V(logger.Error).Infof("compaction entry parsing failed: %v", err)
-					return
-				} else {
-					counters[i%2][idx] += value
-				}
-			}
+		for _, level := range levels {
+			counters[i%2][0] += level.CompactTime
+			counters[i%2][1] += level.CompactRead
+			counters[i%2][2] += level.CompactWrite
 		}
 		// Update all the requested meters
 		if self.compTimeMeter != nil {
@@ -17,8 +17,10 @@
 package ethdb
 
 import (
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"testing"
 
 	"github.com/EarthDollar/go-earthdollar/common"
 )
@@ -32,3 +34,52 @@ func newDb() *LDBDatabase {
 
 	return db
 }
+
+func TestLDBCompactAndStats(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ethdb-stats-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := NewLDBDatabase(dir, 0, 0)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	for i := 0; i < 256; i++ {
+		if err := db.Put([]byte{byte(i)}, []byte{byte(i)}); err != nil {
+			t.Fatalf("failed to insert item %d: %v", i, err)
+		}
+	}
+	db.Get([]byte{0x00})
+	db.Get([]byte{0x00, 0x00})
+
+	if err := db.Compact([]byte{0x10}, []byte{0x20}); err != nil {
+		t.Fatalf("failed to compact range: %v", err)
+	}
+	if err := db.Compact(nil, nil); err != nil {
+		t.Fatalf("failed to compact database: %v", err)
+	}
+	stats, err := db.Stats()
+	if err != nil {
+		t.Fatalf("failed to retrieve stats: %v", err)
+	}
+	if stats.Gets != 2 || stats.GetMisses != 1 {
+		t.Errorf("lookup counters mismatch: have %d/%d, want 2/1", stats.Gets, stats.GetMisses)
+	}
+	tables := 0
+	for _, level := range stats.Levels {
+		tables += level.Tables
+	}
+	if tables == 0 {
+		t.Errorf("no tables reported after compaction: %+v", stats.Levels)
+	}
+	if stats.WriteSlowdown || stats.WritePaused {
+		t.Errorf("unexpected write stall: slowdown %v, paused %v", stats.WriteSlowdown, stats.WritePaused)
+	}
+	if _, err := db.Property("stats"); err != nil {
+		t.Errorf("failed to retrieve unprefixed property: %v", err)
+	}
+}
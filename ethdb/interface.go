@@ -28,3 +28,44 @@ type Batch interface {
 	Put(key, value []byte) error
 	Write() error
 }
+
+// Compacter is an optional interface of databases supporting the manual
+// compaction of key ranges.
+type Compacter interface {
+	// Compact flattens the underlying data store for the given key range, a nil
+	// start denoting a key before all keys and a nil limit one after all keys.
+	Compact(start []byte, limit []byte) error
+}
+
+// Stater is an optional interface of databases able to report the internals of
+// their storage engine.
+type Stater interface {
+	// Property returns an engine specific property of the database.
+	Property(name string) (string, error)
+
+	// Stats returns a structured summary of the engine statistics.
+	Stats() (*Stats, error)
+}
+
+// LevelStats is the summary of a single level of a log-structured merge tree.
+type LevelStats struct {
+	Level        int     `json:"level"`
+	Tables       int     `json:"tables"`
+	SizeMB       float64 `json:"sizeMB"`
+	CompactTime  float64 `json:"compactTime"`  // Seconds spent on compactions into the level
+	CompactRead  float64 `json:"compactRead"`  // Megabytes read by compactions into the level
+	CompactWrite float64 `json:"compactWrite"` // Megabytes written by compactions into the level
+}
+
+// Stats is a structured summary of the storage engine statistics.
+type Stats struct {
+	Levels         []LevelStats `json:"levels"`
+	CachedBlocks   int          `json:"cachedBlocks"`   // Bytes currently held by the block cache
+	OpenTables     int          `json:"openTables"`     // Number of table files kept open
+	AliveSnapshots int          `json:"aliveSnapshots"` // Number of unreleased snapshots
+	AliveIterators int          `json:"aliveIterators"` // Number of unreleased iterators
+	Gets           uint64       `json:"gets"`           // Number of lookups since the database was opened
+	GetMisses      uint64       `json:"getMisses"`      // Number of lookups of non-existent keys
+	WriteSlowdown  bool         `json:"writeSlowdown"`  // Whether writes are being throttled due to level 0 congestion
+	WritePaused    bool         `json:"writePaused"`    // Whether writes are blocked until level 0 is compacted
+}
@@ -42,9 +42,16 @@ import (
 	"golang.org/x/net/websocket"
 )
 
-// historyUpdateRange is the number of blocks a node should report upon login or
-// history request.
-const historyUpdateRange = 50
+const (
+	// historyUpdateRange is the number of blocks a node should report upon login or
+	// history request.
+	historyUpdateRange = 50
+
+	// txChanSize is the size of channel listening to TxsPreEvent.
+	txChanSize = 256
+	// chainHeadChanSize is the size of channel listening to ChainHeadEvent.
+	chainHeadChanSize = 10
+)
 
 // Service implements an Ethereum netstats reporting daemon that pushes local
 // chain statistics up to a monitoring server.
@@ -61,6 +68,7 @@ type Service struct {
 
 	pongCh chan struct{} // Pong notifications are fed into this channel
 	histCh chan []uint64 // History request block numbers are fed into this channel
+	quit   chan struct{} // Quit channel to terminate the reporting daemon
 }
 
 // New returns a monitoring service ready for stats reporting.
@@ -80,6 +88,7 @@ func New(url string, ethServ *eth.Ethereum, lesServ *les.LightEthereum) (*Servic
 		host:   parts[4],
 		pongCh: make(chan struct{}),
 		histCh: make(chan []uint64, 1),
+		quit:   make(chan struct{}),
 	}, nil
 }
 
@@ -102,6 +111,7 @@ func (s *Service) Start(server *p2p.Server) error {
 
 // Stop implements node.Service, terminating the monitoring and reporting daemon.
 func (s *Service) Stop() error {
+	close(s.quit)
 	glog.V(logger.Info).Infoln("Stats daemon stopped")
 	return nil
 }
@@ -109,19 +119,49 @@ func (s *Service) Stop() error {
 // loop keeps trying to connect to the netstats server, reporting chain events
 // until termination.
 func (s *Service) loop() {
-	// Subscribe tso chain events to execute updates on
-	var emux *event.TypeMux
+	// Subscribe to chain events to execute updates on
+	var (
+		chainHeadCh = make(chan core.ChainHeadEvent, chainHeadChanSize)
+		txEventCh   = make(chan core.TxsPreEvent, txChanSize)
+		headSub     event.Subscription
+		txSub       event.Subscription
+	)
 	if s.eth != nil {
-		emux = s.eth.EventMux()
+		headSub = s.eth.BlockChain().SubscribeChainHeadEvent(chainHeadCh)
+		txSub = s.eth.TxPool().SubscribeTxsPreEvent(txEventCh)
 	} else {
-		emux = s.les.EventMux()
+		headSub = s.les.BlockChain().SubscribeChainHeadEvent(chainHeadCh)
+		txSub = s.les.TxPool().SubscribeTxsPreEvent(txEventCh)
 	}
-	headSub := emux.Subscribe(core.ChainHeadEvent{})
 	defer headSub.Unsubscribe()
-
-	txSub := emux.Subscribe(core.TxPreEvent{})
 	defer txSub.Unsubscribe()
 
+	// Start a goroutine that exhausts the subscriptions to avoid events piling
+	// up (and blocking the producers) while the stats server is unreachable
+	var (
+		headCh = make(chan *types.Block, 1)
+		txCh   = make(chan struct{}, 1)
+	)
+	go func() {
+		for {
+			select {
+			// Notify of chain head events, but drop if too frequent
+			case head := <-chainHeadCh:
+				select {
+				case headCh <- head.Block:
+				default:
+				}
+			// Notify of new transaction events, but drop if too frequent
+			case <-txEventCh:
+				select {
+				case txCh <- struct{}{}:
+				default:
+				}
+			case <-s.quit:
+				return
+			}
+		}
+	}()
 	// Loop reporting until termination
 	for {
 		// Establish a websocket connection to the server and authenticate the node
@@ -170,30 +210,17 @@ func (s *Service) loop() {
 				if err = s.reportHistory(out, list); err != nil {
 					glog.V(logger.Warn).Infof("Block history report failed: %v", err)
 				}
-			case head, ok := <-headSub.Chan():
-				if !ok { // node stopped
-					conn.Close()
-					return
-				}
-				if err = s.reportBlock(out, head.Data.(core.ChainHeadEvent).Block); err != nil {
+			case <-s.quit:
+				conn.Close()
+				return
+			case head := <-headCh:
+				if err = s.reportBlock(out, head); err != nil {
 					glog.V(logger.Warn).Infof("Block stats report failed: %v", err)
 				}
 				if err = s.reportPending(out); err != nil {
 					glog.V(logger.Warn).Infof("Post-block transaction stats report failed: %v", err)
 				}
-			case _, ok := <-txSub.Chan():
-				if !ok { // node stopped
-					conn.Close()
-					return
-				}
-				// Exhaust events to avoid reporting too frequently
-				for exhausted := false; !exhausted; {
-					select {
-					case <-headSub.Chan():
-					default:
-						exhausted = true
-					}
-				}
+			case <-txCh:
 				if err = s.reportPending(out); err != nil {
 					glog.V(logger.Warn).Infof("Transaction stats report failed: %v", err)
 				}
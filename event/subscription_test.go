@@ -0,0 +1,64 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package event
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSubscriptionScope(t *testing.T) {
+	var (
+		feed  Feed
+		scope SubscriptionScope
+		chans = make([]chan int, 3)
+		subs  = make([]Subscription, len(chans))
+	)
+	for i := range chans {
+		chans[i] = make(chan int, 1)
+		subs[i] = scope.Track(feed.Subscribe(chans[i]))
+	}
+	if n := scope.Count(); n != len(chans) {
+		t.Fatalf("tracked subscription count mismatch: have %d, want %d", n, len(chans))
+	}
+	// Unsubscribing a single wrapper should untrack it
+	subs[0].Unsubscribe()
+	if n := scope.Count(); n != len(chans)-1 {
+		t.Fatalf("tracked subscription count mismatch: have %d, want %d", n, len(chans)-1)
+	}
+	if n := feed.Send(1); n != len(chans)-1 {
+		t.Fatalf("delivery count mismatch: have %d, want %d", n, len(chans)-1)
+	}
+	// Closing the scope should end all remaining subscriptions
+	scope.Close()
+	for i, sub := range subs {
+		select {
+		case _, ok := <-sub.Err():
+			if ok {
+				t.Errorf("sub %d: error channel not closed", i)
+			}
+		case <-time.After(time.Second):
+			t.Errorf("sub %d: not unsubscribed by scope close", i)
+		}
+	}
+	if n := feed.Send(2); n != 0 {
+		t.Errorf("delivered to %d closed subscriptions", n)
+	}
+	if sub := scope.Track(feed.Subscribe(make(chan int))); sub != nil {
+		t.Errorf("closed scope tracked new subscription")
+	}
+}
@@ -20,6 +20,7 @@ package ethereum
 import (
 	"errors"
 	"math/big"
+	"time"
 
 	"github.com/EarthDollar/go-earthdollar/common"
 	"github.com/EarthDollar/go-earthdollar/core/types"
@@ -103,6 +104,12 @@ type SyncProgress struct {
 	HighestBlock  uint64 // Highest alleged block number in the chain
 	PulledStates  uint64 // Number of state trie entries already downloaded
 	KnownStates   uint64 // Total number os state trie entries known about
+
+	PulledBodies   uint64        // Number of block bodies already downloaded
+	KnownBodies    uint64        // Total number of block bodies known about
+	PulledReceipts uint64        // Number of block receipt sets already downloaded
+	KnownReceipts  uint64        // Total number of block receipt sets known about
+	ETA            time.Duration // Estimated time until the sync completes (0 if unknown)
 }
 
 // ChainSyncReader wraps access to the node's current sync status. If there's no
@@ -64,6 +64,16 @@ func (*HandlerT) Vmodule(pattern string) error {
 	return glog.GetVModule().Set(pattern)
 }
 
+// GetVerbosity returns the current glog verbosity ceiling.
+func (*HandlerT) GetVerbosity() int {
+	return int(glog.GetVerbosity().Get().(glog.Level))
+}
+
+// GetVmodule returns the currently active glog verbosity pattern.
+func (*HandlerT) GetVmodule() string {
+	return glog.GetVModule().String()
+}
+
 // BacktraceAt sets the glog backtrace location.
 // See package glog for details on pattern syntax.
 func (*HandlerT) BacktraceAt(location string) error {
@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"net/http"
 	_ "net/http/pprof"
+	"os"
 	"runtime"
 
 	"github.com/EarthDollar/go-earthdollar/logger"
@@ -43,6 +44,23 @@ var (
 		Usage: "Request a stack trace at a specific logging statement (e.g. \"block.go:271\")",
 		Value: glog.GetTraceLocation(),
 	}
+	logJSONFlag = cli.BoolFlag{
+		Name:  "logjson",
+		Usage: "Emit log records as line delimited JSON objects",
+	}
+	logDirFlag = cli.StringFlag{
+		Name:  "logdir",
+		Usage: "Write log files into the given directory (in addition to stderr)",
+	}
+	logMaxSizeFlag = cli.IntFlag{
+		Name:  "logmaxsize",
+		Usage: "Maximum size of a log file in megabytes before it is rotated",
+		Value: int(glog.MaxSize / 1024 / 1024),
+	}
+	logMaxAgeFlag = cli.DurationFlag{
+		Name:  "logmaxage",
+		Usage: "Maximum age of a log file before it is rotated (0 = no time based rotation)",
+	}
 	pprofFlag = cli.BoolFlag{
 		Name:  "pprof",
 		Usage: "Enable the pprof HTTP server",
@@ -79,6 +97,7 @@ var (
 // Flags holds all command-line flags required for debugging.
 var Flags = []cli.Flag{
 	verbosityFlag, vmoduleFlag, backtraceAtFlag,
+	logJSONFlag, logDirFlag, logMaxSizeFlag, logMaxAgeFlag,
 	pprofFlag, pprofAddrFlag, pprofPortFlag,
 	memprofilerateFlag, blockprofilerateFlag, cpuprofileFlag, traceFlag,
 }
@@ -89,6 +108,17 @@ func Setup(ctx *cli.Context) error {
 	// logging
 	glog.CopyStandardLogTo("INFO")
 	glog.SetToStderr(true)
+	glog.SetJSON(ctx.GlobalBool(logJSONFlag.Name))
+	if dir := ctx.GlobalString(logDirFlag.Name); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+		glog.SetLogDir(dir)
+		glog.SetToStderr(false)
+		glog.SetAlsoToStderr(true)
+	}
+	glog.MaxSize = uint64(ctx.GlobalInt(logMaxSizeFlag.Name)) * 1024 * 1024
+	glog.MaxAge = ctx.GlobalDuration(logMaxAgeFlag.Name)
 
 	// profiling, tracing
 	runtime.MemProfileRate = ctx.GlobalInt(memprofilerateFlag.Name)
@@ -23,7 +23,6 @@ import (
 	"fmt"
 	"math"
 	"math/big"
-	"strings"
 	"time"
 
 	"github.com/ethereum/ethash"
@@ -40,8 +39,6 @@ import (
 	"github.com/EarthDollar/go-earthdollar/p2p"
 	"github.com/EarthDollar/go-earthdollar/rlp"
 	"github.com/EarthDollar/go-earthdollar/rpc"
-	"github.com/syndtr/goleveldb/leveldb"
-	"github.com/syndtr/goleveldb/leveldb/util"
 	"golang.org/x/net/context"
 )
 
@@ -75,6 +72,11 @@ func (s *PublicEthereumAPI) ProtocolVersion() hexutil.Uint {
 // - highestBlock:  block number of the highest block header this node has received from peers
 // - pulledStates:  number of state entries processed until now
 // - knownStates:   number of known state entries that still need to be pulled
+// - pulledBodies:  number of block bodies downloaded until now
+// - knownBodies:   number of known block bodies, including those yet to be pulled
+// - pulledReceipts: number of block receipt sets downloaded until now
+// - knownReceipts: number of known block receipt sets, including those yet to be pulled
+// - eta:           estimated number of seconds until the sync completes (0 if unknown)
 func (s *PublicEthereumAPI) Syncing() (interface{}, error) {
 	progress := s.b.Downloader().Progress()
 
@@ -84,11 +86,16 @@ func (s *PublicEthereumAPI) Syncing() (interface{}, error) {
 	}
 	// Otherwise gather the block sync stats
 	return map[string]interface{}{
-		"startingBlock": hexutil.Uint64(progress.StartingBlock),
-		"currentBlock":  hexutil.Uint64(progress.CurrentBlock),
-		"highestBlock":  hexutil.Uint64(progress.HighestBlock),
-		"pulledStates":  hexutil.Uint64(progress.PulledStates),
-		"knownStates":   hexutil.Uint64(progress.KnownStates),
+		"startingBlock":  hexutil.Uint64(progress.StartingBlock),
+		"currentBlock":   hexutil.Uint64(progress.CurrentBlock),
+		"highestBlock":   hexutil.Uint64(progress.HighestBlock),
+		"pulledStates":   hexutil.Uint64(progress.PulledStates),
+		"knownStates":    hexutil.Uint64(progress.KnownStates),
+		"pulledBodies":   hexutil.Uint64(progress.PulledBodies),
+		"knownBodies":    hexutil.Uint64(progress.KnownBodies),
+		"pulledReceipts": hexutil.Uint64(progress.PulledReceipts),
+		"knownReceipts":  hexutil.Uint64(progress.KnownReceipts),
+		"eta":            hexutil.Uint64(progress.ETA / time.Second),
 	}, nil
 }
 
@@ -279,7 +286,8 @@ func (s *PrivateAccountAPI) SendTransaction(ctx context.Context, args SendTxArgs
 // safely used to calculate a signature from.
 //
 // The hash is calulcated as
-//   keccak256("\x19Ethereum Signed Message:\n"${message length}${message}).
+//
+//	keccak256("\x19Ethereum Signed Message:\n"${message length}${message}).
 //
 // This gives context to the signed message and prevents signing of transactions.
 func signHash(data []byte) []byte {
@@ -949,13 +957,64 @@ func (s *PublicTransactionPoolAPI) GetTransactionReceipt(txHash common.Hash) (ma
 	if tx.Protected() {
 		signer = types.NewEIP155Signer(tx.ChainId())
 	}
+	return marshalReceipt(receipt, txBlock, blockIndex, index, tx, signer), nil
+}
+
+// GetBlockReceipts returns the receipts of all the transactions included in the
+// block with the given number, in the order of the transactions.
+func (s *PublicTransactionPoolAPI) GetBlockReceipts(ctx context.Context, blockNr rpc.BlockNumber) ([]map[string]interface{}, error) {
+	block, err := s.b.BlockByNumber(ctx, blockNr)
+	if block == nil || blockNr == rpc.PendingBlockNumber {
+		return nil, err
+	}
+	return s.blockReceipts(ctx, block)
+}
+
+// GetBlockReceiptsByHash returns the receipts of all the transactions included
+// in the block with the given hash, in the order of the transactions.
+func (s *PublicTransactionPoolAPI) GetBlockReceiptsByHash(ctx context.Context, blockHash common.Hash) ([]map[string]interface{}, error) {
+	block, err := s.b.GetBlock(ctx, blockHash)
+	if block == nil {
+		return nil, err
+	}
+	return s.blockReceipts(ctx, block)
+}
+
+// blockReceipts retrieves the receipts of a block in a single database lookup
+// and converts them into their RPC representation.
+func (s *PublicTransactionPoolAPI) blockReceipts(ctx context.Context, block *types.Block) ([]map[string]interface{}, error) {
+	receipts, err := s.b.GetReceipts(ctx, block.Hash())
+	if err != nil {
+		return nil, err
+	}
+	txs := block.Transactions()
+	if len(receipts) != len(txs) {
+		glog.V(logger.Debug).Infof("receipts not found for block %x", block.Hash())
+		return nil, nil
+	}
+	// Receipts retrieved from the network only contain the consensus fields
+	if len(receipts) > 0 && receipts[0].TxHash != txs[0].Hash() {
+		core.SetReceiptsData(s.b.ChainConfig(), block, receipts)
+	}
+	signer := types.MakeSigner(s.b.ChainConfig(), block.Number())
+
+	fields := make([]map[string]interface{}, len(receipts))
+	for i, receipt := range receipts {
+		fields[i] = marshalReceipt(receipt, block.Hash(), block.NumberU64(), uint64(i), txs[i], signer)
+	}
+	return fields, nil
+}
+
+// marshalReceipt converts a receipt into the RPC representation used by both the
+// single and the bulk receipt retrieval methods.
+func marshalReceipt(receipt *types.Receipt, blockHash common.Hash, blockNumber uint64, index uint64, tx *types.Transaction, signer types.Signer) map[string]interface{} {
 	from, _ := types.Sender(signer, tx)
 
 	fields := map[string]interface{}{
 		"root":              hexutil.Bytes(receipt.PostState),
-		"blockHash":         txBlock,
-		"blockNumber":       hexutil.Uint64(blockIndex),
-		"transactionHash":   txHash,
+		"blockHash":         blockHash,
+		"blockNumber":       hexutil.Uint64(blockNumber),
+		"transactionHash":   tx.Hash(),
 		"transactionIndex":  hexutil.Uint64(index),
 		"from":              from,
 		"to":                tx.To(),
@@ -972,7 +1031,7 @@ func (s *PublicTransactionPoolAPI) GetTransactionReceipt(txHash common.Hash) (ma
 	if receipt.ContractAddress != (common.Address{}) {
 		fields["contractAddress"] = receipt.ContractAddress
 	}
-	return fields, nil
+	return fields
 }
 
 // sign is a helper function that signs a transaction with the private key of the given address.
@@ -1264,31 +1323,53 @@ func NewPrivateDebugAPI(b Backend) *PrivateDebugAPI {
 
 // ChaindbProperty returns leveldb properties of the chain database.
 func (api *PrivateDebugAPI) ChaindbProperty(property string) (string, error) {
-	ldb, ok := api.b.ChainDb().(interface {
-		LDB() *leveldb.DB
-	})
+	db, ok := api.b.ChainDb().(ethdb.Stater)
 	if !ok {
 		return "", fmt.Errorf("chaindbProperty does not work for memory databases")
 	}
 	if property == "" {
 		property = "leveldb.stats"
-	} else if !strings.HasPrefix(property, "leveldb.") {
-		property = "leveldb." + property
 	}
-	return ldb.LDB().GetProperty(property)
+	return db.Property(property)
+}
+
+// ChaindbStats returns the structured engine statistics of the chain database,
+// such as the per level table sizes, cache usage, lookup misses and write stalls.
+func (api *PrivateDebugAPI) ChaindbStats() (*ethdb.Stats, error) {
+	db, ok := api.b.ChainDb().(ethdb.Stater)
+	if !ok {
+		return nil, fmt.Errorf("chaindbStats does not work for memory databases")
+	}
+	return db.Stats()
 }
 
-func (api *PrivateDebugAPI) ChaindbCompact() error {
-	ldb, ok := api.b.ChainDb().(interface {
-		LDB() *leveldb.DB
-	})
+// ChaindbCompact flattens the chain database. If a key range is given, only
+// that range is compacted (a nil limit meaning the end of the key space),
+// otherwise the entire database is compacted in small chunks to avoid stalling
+// the node for too long.
+func (api *PrivateDebugAPI) ChaindbCompact(start, limit *hexutil.Bytes) error {
+	db, ok := api.b.ChainDb().(ethdb.Compacter)
 	if !ok {
 		return fmt.Errorf("chaindbCompact does not work for memory databases")
 	}
+	if start != nil || limit != nil {
+		var from, to []byte
+		if start != nil {
+			from = *start
+		}
+		if limit != nil {
+			to = *limit
+		}
+		glog.V(logger.Info).Infof("compacting chain DB range 0x%x-0x%x", from, to)
+		if err := db.Compact(from, to); err != nil {
+			glog.Errorf("compaction error: %v", err)
+			return err
+		}
+		return nil
+	}
 	for b := byte(0); b < 255; b++ {
 		glog.V(logger.Info).Infof("compacting chain DB range 0x%0.2X-0x%0.2X", b, b+1)
-		err := ldb.LDB().CompactRange(util.Range{Start: []byte{b}, Limit: []byte{b + 1}})
-		if err != nil {
+		if err := db.Compact([]byte{b}, []byte{b + 1}); err != nil {
 			glog.Errorf("compaction error: %v", err)
 			return err
 		}
@@ -73,7 +73,7 @@ type State interface {
 }
 
 func GetAPIs(apiBackend Backend, solcPath string) []rpc.API {
-	compiler := makeCompilerAPIs(solcPath)
+	compiler := makeCompilerAPIs(solcPath, apiBackend.ChainDb())
 	all := []rpc.API{
 		{
 			Namespace: "eth",
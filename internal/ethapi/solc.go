@@ -20,11 +20,12 @@ import (
 	"sync"
 
 	"github.com/EarthDollar/go-earthdollar/common/compiler"
+	"github.com/EarthDollar/go-earthdollar/ethdb"
 	"github.com/EarthDollar/go-earthdollar/rpc"
 )
 
-func makeCompilerAPIs(solcPath string) []rpc.API {
-	c := &compilerAPI{solc: solcPath}
+func makeCompilerAPIs(solcPath string, db ethdb.Database) []rpc.API {
+	c := &compilerAPI{solc: solcPath, cache: compiler.NewCache(db)}
 	return []rpc.API{
 		{
 			Namespace: "eth",
@@ -42,11 +43,13 @@ func makeCompilerAPIs(solcPath string) []rpc.API {
 }
 
 type compilerAPI struct {
-	// This lock guards the solc path set through the API.
-	// It also ensures that only one solc process is used at
+	// This lock guards the solc and vyper paths set through the API.
+	// It also ensures that only one compiler process is used at
 	// any time.
-	mu   sync.Mutex
-	solc string
+	mu    sync.Mutex
+	solc  string
+	vyper string
+	cache *compiler.Cache
 }
 
 type CompilerAdminAPI compilerAPI
@@ -63,20 +66,43 @@ func (api *CompilerAdminAPI) SetSolc(path string) (string, error) {
 	return info.FullVersion, nil
 }
 
+// SetVyper sets the Vyper compiler path to be used by the node.
+func (api *CompilerAdminAPI) SetVyper(path string) (string, error) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	info, err := compiler.VyperVersion(path)
+	if err != nil {
+		return "", err
+	}
+	api.vyper = path
+	return info.FullVersion, nil
+}
+
 type PublicCompilerAPI compilerAPI
 
 // CompileSolidity compiles the given solidity source.
 func (api *PublicCompilerAPI) CompileSolidity(source string) (map[string]*compiler.Contract, error) {
 	api.mu.Lock()
 	defer api.mu.Unlock()
-	return compiler.CompileSolidityString(api.solc, source)
+	return api.cache.CompileSolidityString(api.solc, source)
+}
+
+// CompileVyper compiles the given vyper source.
+func (api *PublicCompilerAPI) CompileVyper(source string) (map[string]*compiler.Contract, error) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	return api.cache.CompileVyperString(api.vyper, source)
 }
 
 func (api *PublicCompilerAPI) GetCompilers() ([]string, error) {
 	api.mu.Lock()
 	defer api.mu.Unlock()
+	compilers := []string{}
 	if _, err := compiler.SolidityVersion(api.solc); err == nil {
-		return []string{"Solidity"}, nil
+		compilers = append(compilers, compiler.LangSolidity)
+	}
+	if _, err := compiler.VyperVersion(api.vyper); err == nil {
+		compilers = append(compilers, compiler.LangVyper)
 	}
-	return []string{}, nil
+	return compilers, nil
 }
@@ -21,6 +21,7 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"time"
 
 	"github.com/EarthDollar/go-earthdollar/common"
 	"github.com/EarthDollar/go-earthdollar/core/vm"
@@ -105,7 +106,7 @@ type stackWrapper struct {
 
 // peek returns the nth-from-the-top element of the stack.
 func (sw *stackWrapper) peek(idx int) *big.Int {
-	return sw.stack.Data()[len(sw.stack.Data())-idx-1]
+	return sw.stack.Back(idx).ToBig()
 }
 
 // length returns the length of the stack
@@ -177,12 +178,14 @@ type JavascriptTracer struct {
 	stackvalue otto.Value             // JS view of `stack`
 	db         *dbWrapper             // Wrapper around the VM environment
 	dbvalue    otto.Value             // JS view of `db`
+	fault      bool                   // Whether the user-supplied object has a `fault` function
 	err        error                  // Error, if one has occurred
 }
 
 // NewJavascriptTracer instantiates a new JavascriptTracer instance.
 // code specifies a Javascript snippet, which must evaluate to an expression
-// returning an object with 'step' and 'result' functions.
+// returning an object with 'step' and 'result' functions, and optionally a
+// 'fault' function invoked instead of 'step' for failing steps.
 func NewJavascriptTracer(code string) (*JavascriptTracer, error) {
 	vm := otto.New()
 	vm.Interrupt = make(chan func(), 1)
@@ -212,6 +215,11 @@ func NewJavascriptTracer(code string) (*JavascriptTracer, error) {
 		return nil, fmt.Errorf("Trace object must expose a function result()")
 	}
 
+	fault, err := jstracer.Get("fault")
+	if err != nil {
+		return nil, err
+	}
+
 	// Create the persistent log object
 	log := make(map[string]interface{})
 	logvalue, _ := vm.ToValue(log)
@@ -232,6 +240,7 @@ func NewJavascriptTracer(code string) (*JavascriptTracer, error) {
 		stackvalue: stack.toValue(vm),
 		db:         db,
 		dbvalue:    db.toValue(vm),
+		fault:      fault.IsFunction(),
 		err:        nil,
 	}, nil
 }
@@ -277,8 +286,37 @@ func wrapError(context string, err error) error {
 	return fmt.Errorf("%v    in server-side tracer function '%v'", message, context)
 }
 
+// CaptureStart implements the Tracer interface to initialize the tracing operation.
+func (jst *JavascriptTracer) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas, value *big.Int) error {
+	return nil
+}
+
 // CaptureState implements the Tracer interface to trace a single step of VM execution
 func (jst *JavascriptTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost *big.Int, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	jst.capture("step", env, pc, op, gas, cost, memory, stack, contract, depth, err)
+	return nil
+}
+
+// CaptureFault implements the Tracer interface to trace an execution fault. The
+// failing step is passed to 'fault' if the trace object defines it, or to 'step'
+// otherwise.
+func (jst *JavascriptTracer) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost *big.Int, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	method := "step"
+	if jst.fault {
+		method = "fault"
+	}
+	jst.capture(method, env, pc, op, gas, cost, memory, stack, contract, depth, err)
+	return nil
+}
+
+// CaptureEnd implements the Tracer interface, called after the outermost call
+// finishes.
+func (jst *JavascriptTracer) CaptureEnd(output []byte, gasUsed *big.Int, t time.Duration, err error) error {
+	return nil
+}
+
+// capture invokes the given trace object method with the current VM state.
+func (jst *JavascriptTracer) capture(method string, env *vm.EVM, pc uint64, op vm.OpCode, gas, cost *big.Int, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) {
 	if jst.err == nil {
 		jst.memory.memory = memory
 		jst.stack.stack = stack
@@ -296,12 +334,11 @@ func (jst *JavascriptTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode,
 		jst.log["account"] = contract.Address()
 		jst.log["err"] = err
 
-		_, err := jst.callSafely("step", jst.logvalue, jst.dbvalue)
+		_, err := jst.callSafely(method, jst.logvalue, jst.dbvalue)
 		if err != nil {
-			jst.err = wrapError("step", err)
+			jst.err = wrapError(method, err)
 		}
 	}
-	return nil
 }
 
 // GetResult calls the Javascript 'result' function and returns its value, or any accumulated error
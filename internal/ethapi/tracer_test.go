@@ -43,7 +43,7 @@ func (account) SetCode(common.Hash, []byte)                         {}
 func (account) ForEachStorage(cb func(key, value common.Hash) bool) {}
 
 func runTrace(tracer *JavascriptTracer) (interface{}, error) {
-	env := vm.NewEVM(vm.Context{}, nil, params.TestChainConfig, vm.Config{Debug: true, Tracer: tracer})
+	env := vm.NewEVM(vm.Context{}, nil, params.TestChainConfig, vm.Config{Tracer: tracer})
 
 	contract := vm.NewContract(account{}, account{}, big.NewInt(0), big.NewInt(10000))
 	contract.Code = []byte{byte(vm.PUSH1), 0x1, byte(vm.PUSH1), 0x1, 0x0}
@@ -133,7 +133,7 @@ func TestHaltBetweenSteps(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	env := vm.NewEVM(vm.Context{}, nil, params.TestChainConfig, vm.Config{Debug: true, Tracer: tracer})
+	env := vm.NewEVM(vm.Context{}, nil, params.TestChainConfig, vm.Config{Tracer: tracer})
 	contract := vm.NewContract(&account{}, &account{}, big.NewInt(0), big.NewInt(0))
 
 	tracer.CaptureState(env, 0, 0, big.NewInt(0), big.NewInt(0), nil, nil, contract, 0, nil)
@@ -145,3 +145,25 @@ func TestHaltBetweenSteps(t *testing.T) {
 		t.Errorf("Expected timeout error, got %v", err)
 	}
 }
+
+func TestFault(t *testing.T) {
+	tracer, err := NewJavascriptTracer("{steps: 0, faults: [], step: function() { this.steps++; }, fault: function(log) { this.faults.push(log.op.toString()); }, result: function() { return this.steps + ':' + this.faults.join(','); }}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	env := vm.NewEVM(vm.Context{}, nil, params.TestChainConfig, vm.Config{Tracer: tracer})
+
+	contract := vm.NewContract(account{}, account{}, big.NewInt(0), big.NewInt(10000))
+	contract.Code = []byte{byte(vm.PUSH1), 0x1, byte(vm.ADD)}
+
+	if _, err := env.Interpreter().Run(contract, []byte{}); err == nil {
+		t.Fatal("expected stack underflow")
+	}
+	ret, err := tracer.GetResult()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ret != "1:ADD" {
+		t.Errorf("Expected return value to be %q, got %#v", "1:ADD", ret)
+	}
+}
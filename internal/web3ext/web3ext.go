@@ -22,11 +22,13 @@ var Modules = map[string]string{
 	"bzz":        Bzz_JS,
 	"chequebook": Chequebook_JS,
 	"debug":      Debug_JS,
+	"ed":         Ed_JS,
 	"ens":        ENS_JS,
 	"eth":        Eth_JS,
 	"miner":      Miner_JS,
 	"net":        Net_JS,
 	"personal":   Personal_JS,
+	"release":    Release_JS,
 	"rpc":        RPC_JS,
 	"shh":        Shh_JS,
 	"txpool":     TxPool_JS,
@@ -174,17 +176,63 @@ web3._extend({
 			call: 'admin_removePeer',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'setReputation',
+			call: 'admin_setReputation',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'addTrustedPeer',
+			call: 'admin_addTrustedPeer',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'removeTrustedPeer',
+			call: 'admin_removeTrustedPeer',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'reloadPeers',
+			call: 'admin_reloadPeers',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'banPeer',
+			call: 'admin_banPeer',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'unbanPeer',
+			call: 'admin_unbanPeer',
+			params: 1
+		}),
 		new web3._extend.Method({
 			name: 'exportChain',
 			call: 'admin_exportChain',
 			params: 1,
 			inputFormatter: [null]
 		}),
+		new web3._extend.Method({
+			name: 'exportChainRange',
+			call: 'admin_exportChainRange',
+			params: 3,
+			inputFormatter: [null, null, null]
+		}),
 		new web3._extend.Method({
 			name: 'importChain',
 			call: 'admin_importChain',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'exportPreimages',
+			call: 'admin_exportPreimages',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'importPreimages',
+			call: 'admin_importPreimages',
+			params: 1
+		}),
 		new web3._extend.Method({
 			name: 'sleepBlocks',
 			call: 'admin_sleepBlocks',
@@ -195,6 +243,21 @@ web3._extend({
 			call: 'admin_setSolc',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'setVyper',
+			call: 'admin_setVyper',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'startHTTP',
+			call: 'admin_startHTTP',
+			params: 4,
+			inputFormatter: [null, null, null, null]
+		}),
+		new web3._extend.Method({
+			name: 'stopHTTP',
+			call: 'admin_stopHTTP'
+		}),
 		new web3._extend.Method({
 			name: 'startRPC',
 			call: 'admin_startRPC',
@@ -229,6 +292,14 @@ web3._extend({
 		new web3._extend.Property({
 			name: 'datadir',
 			getter: 'admin_datadir'
+		}),
+		new web3._extend.Property({
+			name: 'reputations',
+			getter: 'admin_reputations'
+		}),
+		new web3._extend.Property({
+			name: 'bans',
+			getter: 'admin_bans'
 		})
 	]
 });
@@ -293,6 +364,13 @@ web3._extend({
 		new web3._extend.Method({
 			name: 'chaindbCompact',
 			call: 'debug_chaindbCompact',
+			params: 2,
+			inputFormatter: [null, null]
+		}),
+		new web3._extend.Method({
+			name: 'chaindbStats',
+			call: 'debug_chaindbStats',
+			params: 0
 		}),
 		new web3._extend.Method({
 			name: 'metrics',
@@ -309,6 +387,16 @@ web3._extend({
 			call: 'debug_vmodule',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'getVerbosity',
+			call: 'debug_getVerbosity',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'getVmodule',
+			call: 'debug_getVmodule',
+			params: 0
+		}),
 		new web3._extend.Method({
 			name: 'backtraceAt',
 			call: 'debug_backtraceAt',
@@ -391,6 +479,28 @@ web3._extend({
 			call: 'debug_preimage',
 			params: 1,
 			inputFormatter: [null]
+		}),
+		new web3._extend.Method({
+			name: 'getBadBlocks',
+			call: 'debug_getBadBlocks',
+			params: 0
+		})
+	],
+	properties: []
+});
+`
+
+const Ed_JS = `
+web3._extend({
+	property: 'ed',
+	methods:
+	[
+		new web3._extend.Method({
+			name: 'totalSupply',
+			call: 'ed_totalSupply',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter],
+			outputFormatter: web3._extend.formatters.outputBigNumberFormatter
 		})
 	],
 	properties: []
@@ -438,6 +548,25 @@ web3._extend({
 			},
 			params: 2,
 			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter, web3._extend.utils.toHex]
+		}),
+		new web3._extend.Method({
+			name: 'getLogsPage',
+			call: 'eth_getLogsPage',
+			params: 2,
+			inputFormatter: [null, null]
+		}),
+		new web3._extend.Method({
+			name: 'getBlockReceipts',
+			call: function(args) {
+				return (web3._extend.utils.isString(args[0]) && args[0].indexOf('0x') === 0) ? 'eth_getBlockReceiptsByHash' : 'eth_getBlockReceipts';
+			},
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'compileVyper',
+			call: 'eth_compileVyper',
+			params: 1
 		})
 	],
 	properties:
@@ -479,6 +608,11 @@ web3._extend({
 			params: 1,
 			inputFormatter: [web3._extend.formatters.inputAddressFormatter]
 		}),
+		new web3._extend.Method({
+			name: 'setRotatingEtherbases',
+			call: 'miner_setRotatingEtherbases',
+			params: 1
+		}),
 		new web3._extend.Method({
 			name: 'setExtra',
 			call: 'miner_setExtra',
@@ -567,7 +701,79 @@ web3._extend({
 const Shh_JS = `
 web3._extend({
 	property: 'shh',
-	methods: [],
+	methods:
+	[
+		new web3._extend.Method({
+			name: 'setMaxMessageSize',
+			call: 'shh_setMaxMessageSize',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'setMinimumPoW',
+			call: 'shh_setMinimumPoW',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'requestMail',
+			call: 'shh_requestMail',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'generateSymKey',
+			call: 'shh_generateSymKey',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'addSymKey',
+			call: 'shh_addSymKey',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'getSymKey',
+			call: 'shh_getSymKey',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'hasSymKey',
+			call: 'shh_hasSymKey',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'deleteSymKey',
+			call: 'shh_deleteSymKey',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'addPrivateKey',
+			call: 'shh_addPrivateKey',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'getPrivateKey',
+			call: 'shh_getPrivateKey',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'deleteIdentity',
+			call: 'shh_deleteIdentity',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'markPeerTrusted',
+			call: 'shh_markPeerTrusted',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'getFilterChanges',
+			call: 'shh_getFilterChanges',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'getMessages',
+			call: 'shh_getMessages',
+			params: 1
+		})
+	],
 	properties:
 	[
 		new web3._extend.Property({
@@ -605,3 +811,17 @@ web3._extend({
 	]
 });
 `
+
+const Release_JS = `
+web3._extend({
+	property: 'release',
+	methods: [],
+	properties:
+	[
+		new web3._extend.Property({
+			name: 'status',
+			getter: 'release_status'
+		})
+	]
+});
+`
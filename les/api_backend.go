@@ -17,6 +17,7 @@
 package les
 
 import (
+	"errors"
 	"math/big"
 
 	"github.com/EarthDollar/go-earthdollar/accounts"
@@ -130,6 +131,32 @@ func (b *LesApiBackend) TxPoolContent() (map[common.Address]types.Transactions,
 	return b.eth.txPool.Content()
 }
 
+func (b *LesApiBackend) SubscribeTxsPreEvent(ch chan<- core.TxsPreEvent) event.Subscription {
+	return b.eth.txPool.SubscribeTxsPreEvent(ch)
+}
+
+func (b *LesApiBackend) SubscribeChainEvent(ch chan<- core.ChainEvent) event.Subscription {
+	return b.eth.blockchain.SubscribeChainEvent(ch)
+}
+
+// SubscribeRemovedLogsEvent never fires, the light client filters logs of
+// rolled back headers itself.
+func (b *LesApiBackend) SubscribeRemovedLogsEvent(ch chan<- core.RemovedLogsEvent) event.Subscription {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		<-quit
+		return nil
+	})
+}
+
+// SubscribeLogsEvent never fires, the light client retrieves the logs of new
+// headers on demand.
+func (b *LesApiBackend) SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscription {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		<-quit
+		return nil
+	})
+}
+
 func (b *LesApiBackend) Downloader() *downloader.Downloader {
 	return b.eth.Downloader()
 }
@@ -150,6 +177,15 @@ func (b *LesApiBackend) EventMux() *event.TypeMux {
 	return b.eth.eventMux
 }
 
+// BloomStatus reports an empty bloombits index, light clients don't maintain one.
+func (b *LesApiBackend) BloomStatus() (uint64, uint64) {
+	return params.BloomBitsBlocks, 0
+}
+
+func (b *LesApiBackend) GetBloomBits(ctx context.Context, bit uint, section uint64) ([]byte, error) {
+	return nil, errors.New("bloombits index not available in light mode")
+}
+
 func (b *LesApiBackend) AccountManager() *accounts.Manager {
 	return b.eth.accountManager
 }
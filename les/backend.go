@@ -68,9 +68,16 @@ type LightEthereum struct {
 
 	netVersionId  int
 	netRPCService *ethapi.PublicNetAPI
+	filterConfig  filters.Config
 }
 
 func New(ctx *node.ServiceContext, config *eth.Config) (*LightEthereum, error) {
+	if err := eth.ApplyNetwork(config); err != nil {
+		return nil, err
+	}
+	if config.NetworkId == 0 {
+		config.NetworkId = NetworkId
+	}
 	chainDb, err := eth.CreateDB(ctx, config, "lightchaindata")
 	if err != nil {
 		return nil, err
@@ -83,6 +90,11 @@ func New(ctx *node.ServiceContext, config *eth.Config) (*LightEthereum, error) {
 		return nil, err
 	}
 
+	if genesis := core.GetCanonicalHash(chainDb, 0); genesis != (common.Hash{}) {
+		if err := eth.ValidateNetworkId(config.NetworkId, genesis); err != nil {
+			return nil, err
+		}
+	}
 	odr := NewLesOdr(chainDb)
 	relay := NewLesTxRelay()
 	eth := &LightEthereum{
@@ -95,6 +107,7 @@ func New(ctx *node.ServiceContext, config *eth.Config) (*LightEthereum, error) {
 		shutdownChan:   make(chan bool),
 		netVersionId:   config.NetworkId,
 		solcPath:       config.SolcPath,
+		filterConfig:   config.FilterConfig,
 	}
 
 	if config.ChainConfig == nil {
@@ -109,7 +122,7 @@ func New(ctx *node.ServiceContext, config *eth.Config) (*LightEthereum, error) {
 		return nil, err
 	}
 
-	eth.txPool = light.NewTxPool(eth.chainConfig, eth.eventMux, eth.blockchain, eth.relay)
+	eth.txPool = light.NewTxPool(eth.chainConfig, eth.blockchain, eth.relay)
 	if eth.protocolManager, err = NewProtocolManager(eth.chainConfig, config.LightMode, config.NetworkId, eth.eventMux, eth.pow, eth.blockchain, nil, chainDb, odr, relay); err != nil {
 		return nil, err
 	}
@@ -158,7 +171,7 @@ func (s *LightEthereum) APIs() []rpc.API {
 		}, {
 			Namespace: "eth",
 			Version:   "1.0",
-			Service:   filters.NewPublicFilterAPI(s.ApiBackend, true),
+			Service:   filters.NewPublicFilterAPI(s.ApiBackend, true, s.filterConfig),
 			Public:    true,
 		}, {
 			Namespace: "net",
@@ -85,6 +85,7 @@ type BlockChain interface {
 	GetBlockHashesFromHash(hash common.Hash, max uint64) []common.Hash
 	LastBlockHash() common.Hash
 	Genesis() *types.Block
+	SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription
 }
 
 type txPool interface {
@@ -206,6 +207,9 @@ func NewProtocolManager(chainConfig *params.ChainConfig, lightSync bool, network
 		manager.downloader = downloader.New(downloader.LightSync, chainDb, manager.eventMux, blockchain.HasHeader, nil, blockchain.GetHeaderByHash,
 			nil, blockchain.CurrentHeader, nil, nil, nil, blockchain.GetTdByHash,
 			blockchain.InsertHeaderChain, nil, nil, blockchain.Rollback, removePeer)
+		if checkpoint, ok := params.TrustedCheckpoints[blockchain.Genesis().Hash()]; ok {
+			manager.downloader.SetCheckpoint(checkpoint)
+		}
 	}
 
 	if odr != nil {
@@ -901,10 +905,16 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 
 // NodeInfo retrieves some protocol metadata about the running host node.
 func (self *ProtocolManager) NodeInfo() *eth.EthNodeInfo {
-	return &eth.EthNodeInfo{
+	head := self.blockchain.LastBlockHash()
+	info := &eth.EthNodeInfo{
 		Network:    self.networkId,
-		Difficulty: self.blockchain.GetTdByHash(self.blockchain.LastBlockHash()),
+		Difficulty: self.blockchain.GetTdByHash(head),
 		Genesis:    self.blockchain.Genesis().Hash(),
-		Head:       self.blockchain.LastBlockHash(),
+		Config:     self.chainConfig,
+		Head:       head,
+	}
+	if header := self.blockchain.GetHeaderByHash(head); header != nil {
+		info.HeadNumber = header.Number
 	}
+	return info
 }
@@ -34,6 +34,7 @@ import (
 	"github.com/EarthDollar/go-earthdollar/logger/glog"
 	"github.com/EarthDollar/go-earthdollar/p2p"
 	"github.com/EarthDollar/go-earthdollar/rlp"
+	"github.com/EarthDollar/go-earthdollar/rpc"
 	"github.com/EarthDollar/go-earthdollar/trie"
 )
 
@@ -47,6 +48,9 @@ type LesServer struct {
 	lock            sync.Mutex
 }
 
+// NewLesServer creates a light server on top of a full node. It is meant to be
+// registered as a separate service after the full node, which it subscribes to
+// for being notified once the chain is synced.
 func NewLesServer(eth *eth.Ethereum, config *eth.Config) (*LesServer, error) {
 	pm, err := NewProtocolManager(config.ChainConfig, false, config.NetworkId, eth.EventMux(), eth.Pow(), eth.BlockChain(), eth.TxPool(), eth.ChainDb(), nil, nil)
 	if err != nil {
@@ -63,16 +67,24 @@ func NewLesServer(eth *eth.Ethereum, config *eth.Config) (*LesServer, error) {
 	}
 	srv.fcManager = flowcontrol.NewClientManager(uint64(config.LightServ), 10, 1000000000)
 	srv.fcCostStats = newCostStats(eth.ChainDb())
+
+	eth.AddLesServer(srv)
 	return srv, nil
 }
 
+// Protocols implements node.Service, returning the LES protocols to serve.
 func (s *LesServer) Protocols() []p2p.Protocol {
 	return s.protocolManager.SubProtocols
 }
 
-// Start only starts the actual service if the ETH protocol has already been synced,
-// otherwise it will be started by Synced()
-func (s *LesServer) Start(srvr *p2p.Server) {
+// APIs implements node.Service, the light server exposes no RPC APIs of its own.
+func (s *LesServer) APIs() []rpc.API {
+	return nil
+}
+
+// Start implements node.Service. It only starts the actual service if the ETH
+// protocol has already been synced, otherwise it will be started by Synced()
+func (s *LesServer) Start(srvr *p2p.Server) error {
 	s.lock.Lock()
 	defer s.lock.Unlock()
 
@@ -80,6 +92,7 @@ func (s *LesServer) Start(srvr *p2p.Server) {
 	if s.synced {
 		s.protocolManager.Start(s.srvr)
 	}
+	return nil
 }
 
 // Synced notifies the server that the ETH protocol has been synced and LES service can be started
@@ -93,8 +106,8 @@ func (s *LesServer) Synced() {
 	}
 }
 
-// Stop stops the LES service
-func (s *LesServer) Stop() {
+// Stop implements node.Service, stopping the LES service.
+func (s *LesServer) Stop() error {
 	s.lock.Lock()
 	defer s.lock.Unlock()
 
@@ -105,6 +118,7 @@ func (s *LesServer) Stop() {
 		<-s.protocolManager.noMorePeers
 	}()
 	s.protocolManager.Stop()
+	return nil
 }
 
 type requestCosts struct {
@@ -292,7 +306,8 @@ func (s *requestCostStats) update(msgCode, reqCnt, cost uint64) {
 
 func (pm *ProtocolManager) blockLoop() {
 	pm.wg.Add(1)
-	sub := pm.eventMux.Subscribe(core.ChainHeadEvent{})
+	headCh := make(chan core.ChainHeadEvent, 10)
+	headSub := pm.blockchain.SubscribeChainHeadEvent(headCh)
 	newCht := make(chan struct{}, 10)
 	newCht <- struct{}{}
 	go func() {
@@ -301,10 +316,10 @@ func (pm *ProtocolManager) blockLoop() {
 		lastBroadcastTd := common.Big0
 		for {
 			select {
-			case ev := <-sub.Chan():
+			case ev := <-headCh:
 				peers := pm.peers.AllPeers()
 				if len(peers) > 0 {
-					header := ev.Data.(core.ChainHeadEvent).Block.Header()
+					header := ev.Block.Header()
 					hash := header.Hash()
 					number := header.Number.Uint64()
 					td := core.GetTd(pm.chainDb, hash, number)
@@ -340,7 +355,7 @@ func (pm *ProtocolManager) blockLoop() {
 					}
 				}()
 			case <-pm.quitSync:
-				sub.Unsubscribe()
+				headSub.Unsubscribe()
 				pm.wg.Done()
 				return
 			}
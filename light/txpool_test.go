@@ -105,7 +105,7 @@ func TestTxPool(t *testing.T) {
 	lightchain, _ := NewLightChain(odr, testChainConfig(), pow, evmux)
 	lightchain.SetValidator(bproc{})
 	txPermanent = 50
-	pool := NewTxPool(testChainConfig(), evmux, lightchain, relay)
+	pool := NewTxPool(testChainConfig(), lightchain, relay)
 
 	for ii, block := range gchain {
 		i := ii + 1
@@ -145,6 +145,14 @@ func SetToStderr(toStderr bool) {
 	logging.mu.Unlock()
 }
 
+// SetAlsoToStderr sets whether logs written to files are also copied to
+// standard error.
+func SetAlsoToStderr(alsoToStderr bool) {
+	logging.mu.Lock()
+	logging.alsoToStderr = alsoToStderr
+	logging.mu.Unlock()
+}
+
 // GetTraceLocation returns the global TraceLocation flag.
 func GetTraceLocation() *TraceLocation {
 	return &logging.traceLocation
@@ -289,6 +297,7 @@ type moduleSpec struct {
 // modulePat contains a filter for the -vmodule flag.
 // It holds a verbosity level and a file pattern to match.
 type modulePat struct {
+	source  string // pattern as specified by the user
 	pattern *regexp.Regexp
 	level   Level
 }
@@ -302,7 +311,7 @@ func (m *moduleSpec) String() string {
 		if i > 0 {
 			b.WriteRune(',')
 		}
-		fmt.Fprintf(&b, "%s=%d", f.pattern, f.level)
+		fmt.Fprintf(&b, "%s=%d", f.source, f.level)
 	}
 	return b.String()
 }
@@ -340,7 +349,7 @@ func (m *moduleSpec) Set(value string) error {
 		}
 		// TODO: check syntax of filter?
 		re, _ := compileModulePattern(pattern)
-		filter = append(filter, modulePat{re, Level(v)})
+		filter = append(filter, modulePat{pattern, re, Level(v)})
 	}
 	logging.mu.Lock()
 	defer logging.mu.Unlock()
@@ -510,6 +519,10 @@ type buffer struct {
 	bytes.Buffer
 	tmp  [64]byte // temporary byte array for creating headers.
 	next *buffer
+
+	hdr  int           // length of the text header, skipped in JSON output.
+	time time.Time     // timestamp of the record.
+	ctx  []interface{} // contextual key/value pairs for JSON output.
 }
 
 var logging loggingT
@@ -547,6 +560,7 @@ func (l *loggingT) getBuffer() *buffer {
 	} else {
 		b.next = nil
 		b.Reset()
+		b.hdr, b.ctx = 0, nil
 	}
 	return b
 }
@@ -610,6 +624,7 @@ func (l *loggingT) formatHeader(s severity, file string, line int) *buffer {
 		s = infoLog // for safety.
 	}
 	buf := l.getBuffer()
+	buf.time = now
 
 	// Avoid Fprintf, for speed. The format is so simple that we can do it quickly by hand.
 	// It's worth about 3X. Fprintf is hard.
@@ -635,6 +650,7 @@ func (l *loggingT) formatHeader(s severity, file string, line int) *buffer {
 	buf.tmp[n+1] = ']'
 	buf.tmp[n+2] = ' '
 	buf.Write(buf.tmp[:n+3])
+	buf.hdr = buf.Len()
 	return buf
 }
 
@@ -722,12 +738,19 @@ func (l *loggingT) printWithFileLine(s severity, file string, line int, alsoToSt
 // output writes the data to the log files and releases the buffer.
 func (l *loggingT) output(s severity, buf *buffer, file string, line int, alsoToStderr bool) {
 	l.mu.Lock()
+	var trace []byte
 	if l.traceLocation.isSet() {
 		if l.traceLocation.match(file, line) {
-			buf.Write(stacks(false))
+			trace = stacks(false)
 		}
 	}
-	data := buf.Bytes()
+	var data []byte
+	if jsonEnabled() {
+		data = l.formatJSON(s, buf, file, line, trace)
+	} else {
+		buf.Write(trace)
+		data = buf.Bytes()
+	}
 	if l.toStderr {
 		os.Stderr.Write(data)
 	} else {
@@ -851,9 +874,10 @@ func (l *loggingT) exit(err error) {
 type syncBuffer struct {
 	logger *loggingT
 	*bufio.Writer
-	file   *os.File
-	sev    severity
-	nbytes uint64 // The number of bytes written to this file
+	file    *os.File
+	sev     severity
+	nbytes  uint64    // The number of bytes written to this file
+	created time.Time // The time at which the current file was created
 }
 
 func (sb *syncBuffer) Sync() error {
@@ -861,8 +885,9 @@ func (sb *syncBuffer) Sync() error {
 }
 
 func (sb *syncBuffer) Write(p []byte) (n int, err error) {
-	if sb.nbytes+uint64(len(p)) >= MaxSize {
-		if err := sb.rotateFile(time.Now()); err != nil {
+	now := time.Now()
+	if sb.nbytes+uint64(len(p)) >= MaxSize || (MaxAge > 0 && now.Sub(sb.created) >= MaxAge) {
+		if err := sb.rotateFile(now); err != nil {
 			sb.logger.exit(err)
 		}
 	}
@@ -883,12 +908,18 @@ func (sb *syncBuffer) rotateFile(now time.Time) error {
 	var err error
 	sb.file, _, err = create(severityName[sb.sev], now)
 	sb.nbytes = 0
+	sb.created = now
 	if err != nil {
 		return err
 	}
 
 	sb.Writer = bufio.NewWriterSize(sb.file, bufferSize)
 
+	// JSON logs are consumed by machines, don't break them with a preamble.
+	if jsonEnabled() {
+		return nil
+	}
+
 	// Write header.
 	var buf bytes.Buffer
 	fmt.Fprintf(&buf, "Log file created at: %s\n", now.Format("2006/01/02 15:04:05"))
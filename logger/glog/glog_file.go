@@ -32,6 +32,10 @@ import (
 // MaxSize is the maximum size of a log file in bytes.
 var MaxSize uint64 = 1024 * 1024 * 1800
 
+// MaxAge is the maximum age of a log file before it is rotated. Zero disables
+// time based rotation.
+var MaxAge time.Duration
+
 // logDirs lists the candidate directories for new log files.
 var logDirs []string
 
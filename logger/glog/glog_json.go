@@ -0,0 +1,144 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Structured (JSON) output and contextual fields for logs.
+
+package glog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sync/atomic"
+	"time"
+)
+
+// jsonFormat is non-zero if log records are to be emitted as JSON objects,
+// one per line, instead of the classic glog text format.
+var jsonFormat int32
+
+// SetJSON switches the output format of all log destinations between the
+// glog text format and line delimited JSON objects.
+func SetJSON(enabled bool) {
+	if enabled {
+		atomic.StoreInt32(&jsonFormat, 1)
+	} else {
+		atomic.StoreInt32(&jsonFormat, 0)
+	}
+}
+
+// jsonEnabled reports whether log records are emitted as JSON.
+func jsonEnabled() bool {
+	return atomic.LoadInt32(&jsonFormat) != 0
+}
+
+// Infow is equivalent to the global Infow function, guarded by the value of v.
+// See the documentation of V for usage.
+func (v Verbose) Infow(msg string, ctx ...interface{}) {
+	if v {
+		logging.printw(infoLog, msg, ctx)
+	}
+}
+
+// Infow logs msg to the INFO log together with the contextual key/value pairs
+// in ctx, e.g. glog.Infow("Imported block", "number", 42, "peer", id).
+func Infow(msg string, ctx ...interface{}) {
+	logging.printw(infoLog, msg, ctx)
+}
+
+// Warningw logs msg and the contextual key/value pairs in ctx to the WARNING
+// and INFO logs.
+func Warningw(msg string, ctx ...interface{}) {
+	logging.printw(warningLog, msg, ctx)
+}
+
+// Errorw logs msg and the contextual key/value pairs in ctx to the ERROR,
+// WARNING and INFO logs.
+func Errorw(msg string, ctx ...interface{}) {
+	logging.printw(errorLog, msg, ctx)
+}
+
+// printw writes a message with contextual fields. In text mode the fields are
+// appended to the message as key=value pairs, in JSON mode they become
+// members of the emitted object.
+func (l *loggingT) printw(s severity, msg string, ctx []interface{}) {
+	buf, file, line := l.header(s, 0)
+	buf.WriteString(msg)
+	if len(ctx)%2 != 0 {
+		ctx = append(ctx, nil)
+	}
+	if jsonEnabled() {
+		buf.ctx = ctx
+	} else {
+		for i := 0; i < len(ctx); i += 2 {
+			fmt.Fprintf(buf, " %v=%v", ctx[i], ctx[i+1])
+		}
+	}
+	buf.WriteByte('\n')
+	l.output(s, buf, file, line, false)
+}
+
+// jsonRecord is the shape of a single structured log line.
+type jsonRecord map[string]interface{}
+
+// formatJSON converts a formatted text record into its JSON representation.
+// The glog header is dropped in favour of explicit fields and any contextual
+// fields attached to the buffer are added as additional members.
+func (l *loggingT) formatJSON(s severity, buf *buffer, file string, line int, stack []byte) []byte {
+	msg := bytes.TrimRight(buf.Bytes()[buf.hdr:], "\n")
+
+	rec := jsonRecord{
+		"t":      buf.time.Format(time.RFC3339Nano),
+		"lvl":    severityName[s],
+		"module": path.Dir(file),
+		"caller": fmt.Sprintf("%s:%d", path.Base(file), line),
+		"msg":    string(msg),
+	}
+	for i := 0; i+1 < len(buf.ctx); i += 2 {
+		key := fmt.Sprint(buf.ctx[i])
+		if _, reserved := rec[key]; reserved {
+			key = "ctx." + key
+		}
+		rec[key] = jsonValue(buf.ctx[i+1])
+	}
+	if len(stack) > 0 {
+		rec["stack"] = string(stack)
+	}
+	out, err := json.Marshal(rec)
+	if err != nil {
+		out, _ = json.Marshal(jsonRecord{"t": rec["t"], "lvl": rec["lvl"], "msg": rec["msg"], "error": err.Error()})
+	}
+	return append(out, '\n')
+}
+
+// jsonValue converts a contextual value into something that encodes nicely,
+// preferring the String method of types such as hashes, ids and big numbers.
+func jsonValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case nil, bool, string, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return v
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	default:
+		if _, err := json.Marshal(v); err != nil {
+			return fmt.Sprintf("%+v", v)
+		}
+		return v
+	}
+}
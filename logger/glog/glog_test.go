@@ -18,6 +18,7 @@ package glog
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	stdLog "log"
 	"path/filepath"
@@ -290,6 +291,15 @@ func TestVmoduleOff(t *testing.T) {
 	}
 }
 
+// Test that the active vmodule setting can be read back as it was specified.
+func TestVmoduleString(t *testing.T) {
+	logging.vmodule.Set("eth/downloader=6,p2p/*=5,zero=0")
+	defer logging.vmodule.Set("")
+	if have, want := logging.vmodule.String(), "eth/downloader=6,p2p/*=5"; have != want {
+		t.Errorf("vmodule mismatch: have %q, want %q", have, want)
+	}
+}
+
 var patternTests = []struct{ input, want string }{
 	{"foo/bar/x.go", ".*/foo/bar/x\\.go$"},
 	{"foo/*/x.go", ".*/foo(/.*)?/x\\.go$"},
@@ -392,6 +402,75 @@ func TestRollover(t *testing.T) {
 	}
 }
 
+// Test that contextual fields are rendered as key=value pairs in text mode.
+func TestInfow(t *testing.T) {
+	setFlags()
+	defer logging.swap(logging.newBuffers())
+	Infow("imported", "number", 42, "peer", "abcd")
+	if !contains(infoLog, "imported number=42 peer=abcd\n", t) {
+		t.Errorf("Infow has wrong output: %q", contents(infoLog))
+	}
+}
+
+// Test that JSON mode emits one well formed object per record.
+func TestJSON(t *testing.T) {
+	setFlags()
+	defer logging.swap(logging.newBuffers())
+	SetJSON(true)
+	defer SetJSON(false)
+
+	Infow("imported", "number", 42, "msg", "shadowed")
+	Warning("plain")
+
+	lines := strings.Split(strings.TrimSpace(contents(infoLog)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 records, got %d: %q", len(lines), contents(infoLog))
+	}
+	var rec map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &rec); err != nil {
+		t.Fatalf("record is not valid JSON: %v", err)
+	}
+	if rec["lvl"] != "INFO" || rec["msg"] != "imported" || rec["number"] != float64(42) || rec["ctx.msg"] != "shadowed" {
+		t.Errorf("unexpected record: %v", rec)
+	}
+	if rec["module"] != "logger/glog" {
+		t.Errorf("module mismatch: have %v, want logger/glog", rec["module"])
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &rec); err != nil {
+		t.Fatalf("record is not valid JSON: %v", err)
+	}
+	if rec["lvl"] != "WARNING" || rec["msg"] != "plain" {
+		t.Errorf("unexpected record: %v", rec)
+	}
+}
+
+func TestRolloverAge(t *testing.T) {
+	setFlags()
+	var err error
+	defer func(previous func(error)) { logExitFunc = previous }(logExitFunc)
+	logExitFunc = func(e error) {
+		err = e
+	}
+	defer func(previous time.Duration) { MaxAge = previous }(MaxAge)
+	MaxAge = time.Second
+
+	Info("x") // Be sure we have a file.
+	info, ok := logging.file[infoLog].(*syncBuffer)
+	if !ok {
+		t.Fatal("info wasn't created")
+	}
+	fname0 := info.file.Name()
+	time.Sleep(1100 * time.Millisecond)
+
+	Info("x") // expired, should create a new file
+	if err != nil {
+		t.Fatalf("error after rotation: %v", err)
+	}
+	if fname1 := info.file.Name(); fname0 == fname1 {
+		t.Errorf("info.f.Name did not change: %v", fname0)
+	}
+}
+
 func TestLogBacktraceAt(t *testing.T) {
 	setFlags()
 	defer logging.swap(logging.newBuffers())
@@ -0,0 +1,168 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/EarthDollar/go-earthdollar/logger"
+	"github.com/EarthDollar/go-earthdollar/logger/glog"
+	"github.com/rcrowley/go-metrics"
+)
+
+// reportPercentiles are the percentiles exported for timers and histograms.
+var reportPercentiles = []float64{0.5, 0.95, 0.99}
+
+// field is a single named value of a metric snapshot.
+type field struct {
+	name  string
+	value interface{}
+}
+
+// snapshotFields flattens a metric into its exported values.
+func snapshotFields(metric interface{}) []field {
+	switch m := metric.(type) {
+	case metrics.Counter:
+		return []field{{"count", m.Count()}}
+	case metrics.Gauge:
+		return []field{{"value", m.Value()}}
+	case metrics.GaugeFloat64:
+		return []field{{"value", m.Value()}}
+	case metrics.Meter:
+		s := m.Snapshot()
+		return []field{{"count", s.Count()}, {"m1", s.Rate1()}, {"m5", s.Rate5()}, {"m15", s.Rate15()}, {"mean", s.RateMean()}}
+	case metrics.Histogram:
+		s := m.Snapshot()
+		ps := s.Percentiles(reportPercentiles)
+		return []field{{"count", s.Count()}, {"min", s.Min()}, {"max", s.Max()}, {"mean", s.Mean()}, {"p50", ps[0]}, {"p95", ps[1]}, {"p99", ps[2]}}
+	case metrics.Timer:
+		s := m.Snapshot()
+		ps := s.Percentiles(reportPercentiles)
+		return []field{{"count", s.Count()}, {"min", s.Min()}, {"max", s.Max()}, {"mean", s.Mean()}, {"p50", ps[0]}, {"p95", ps[1]}, {"p99", ps[2]}, {"m1", s.Rate1()}}
+	}
+	return nil
+}
+
+// sortedTags returns the tag keys in a deterministic order.
+func sortedTags(tags map[string]string) []string {
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// influxEscaper escapes measurement names, tag keys and tag values according
+// to the InfluxDB line protocol.
+var influxEscaper = strings.NewReplacer(",", `\,`, " ", `\ `, "=", `\=`)
+
+// writeInflux serializes all metrics of the registry in InfluxDB line protocol.
+func writeInflux(w io.Writer, r metrics.Registry, tags map[string]string, now time.Time) {
+	var tagset bytes.Buffer
+	for _, key := range sortedTags(tags) {
+		fmt.Fprintf(&tagset, ",%s=%s", influxEscaper.Replace(key), influxEscaper.Replace(tags[key]))
+	}
+	r.Each(func(name string, metric interface{}) {
+		fields := snapshotFields(metric)
+		if len(fields) == 0 {
+			return
+		}
+		fmt.Fprintf(w, "%s%s ", influxEscaper.Replace(name), tagset.String())
+		for i, f := range fields {
+			if i > 0 {
+				io.WriteString(w, ",")
+			}
+			switch v := f.value.(type) {
+			case int64:
+				fmt.Fprintf(w, "%s=%di", f.name, v)
+			default:
+				fmt.Fprintf(w, "%s=%v", f.name, v)
+			}
+		}
+		fmt.Fprintf(w, " %d\n", now.UnixNano())
+	})
+}
+
+// writeGraphite serializes all metrics of the registry in the Graphite
+// plaintext protocol, attaching the tags in Graphite's tagged series format.
+func writeGraphite(w io.Writer, r metrics.Registry, prefix string, tags map[string]string, now time.Time) {
+	var tagset bytes.Buffer
+	for _, key := range sortedTags(tags) {
+		fmt.Fprintf(&tagset, ";%s=%s", key, tags[key])
+	}
+	r.Each(func(name string, metric interface{}) {
+		path := strings.Replace(name, "/", ".", -1)
+		if prefix != "" {
+			path = prefix + "." + path
+		}
+		for _, f := range snapshotFields(metric) {
+			fmt.Fprintf(w, "%s.%s%s %v %d\n", path, f.name, tagset.String(), f.value, now.Unix())
+		}
+	})
+}
+
+// ReportInfluxDB periodically pushes the contents of the default metrics
+// registry to an InfluxDB HTTP write endpoint (e.g. http://host:8086/write?db=ged),
+// tagging every series with the given tags. It never returns.
+func ReportInfluxDB(url string, interval time.Duration, tags map[string]string) {
+	client := &http.Client{Timeout: interval}
+	for range time.Tick(interval) {
+		var buf bytes.Buffer
+		writeInflux(&buf, metrics.DefaultRegistry, tags, time.Now())
+
+		resp, err := client.Post(url, "text/plain", &buf)
+		if err != nil {
+			glog.V(logger.Debug).Infof("failed to report metrics to InfluxDB: %v", err)
+			continue
+		}
+		io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode/100 != 2 {
+			glog.V(logger.Debug).Infof("InfluxDB rejected metrics: %s", resp.Status)
+		}
+	}
+}
+
+// ReportGraphite periodically pushes the contents of the default metrics
+// registry to a Graphite (carbon) plaintext endpoint at addr, prefixing every
+// series path with prefix and attaching the tags. It never returns.
+func ReportGraphite(addr string, interval time.Duration, prefix string, tags map[string]string) {
+	for range time.Tick(interval) {
+		conn, err := net.DialTimeout("tcp", addr, interval)
+		if err != nil {
+			glog.V(logger.Debug).Infof("failed to report metrics to Graphite: %v", err)
+			continue
+		}
+		conn.SetWriteDeadline(time.Now().Add(interval))
+
+		var buf bytes.Buffer
+		writeGraphite(&buf, metrics.DefaultRegistry, prefix, tags, time.Now())
+		if _, err := buf.WriteTo(conn); err != nil {
+			glog.V(logger.Debug).Infof("failed to report metrics to Graphite: %v", err)
+		}
+		conn.Close()
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"sync"
+
+	"github.com/EarthDollar/go-earthdollar/common"
+	"github.com/EarthDollar/go-earthdollar/core/types"
+	"github.com/EarthDollar/go-earthdollar/logger"
+	"github.com/EarthDollar/go-earthdollar/logger/glog"
+)
+
+// InstantAgent seals blocks as soon as they contain transactions, without doing
+// any proof-of-work search. It's only usable with a proof-of-work implementation
+// accepting any seal (e.g. core.FakePow), as used by single-node developer chains.
+type InstantAgent struct {
+	mu sync.Mutex
+
+	workCh   chan *Work
+	quit     chan struct{}
+	returnCh chan<- *Result
+}
+
+func NewInstantAgent() *InstantAgent {
+	return &InstantAgent{workCh: make(chan *Work, 1)}
+}
+
+func (self *InstantAgent) Work() chan<- *Work            { return self.workCh }
+func (self *InstantAgent) SetReturnCh(ch chan<- *Result) { self.returnCh = ch }
+func (self *InstantAgent) GetHashRate() int64            { return 0 }
+
+func (self *InstantAgent) Start() {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	if self.quit != nil {
+		return // agent already started
+	}
+	self.quit = make(chan struct{})
+	go self.update(self.quit)
+}
+
+func (self *InstantAgent) Stop() {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	if self.quit != nil {
+		close(self.quit)
+		self.quit = nil
+	}
+}
+
+func (self *InstantAgent) update(quit chan struct{}) {
+	for {
+		select {
+		case work := <-self.workCh:
+			// Empty blocks are not worth sealing, wait for transactions
+			if len(work.Block.Transactions()) == 0 {
+				continue
+			}
+			glog.V(logger.Debug).Infof("instantly sealing block #%d with %d txs", work.Block.Number(), len(work.Block.Transactions()))
+
+			block := work.Block.WithMiningResult(types.EncodeNonce(0), common.Hash{})
+			select {
+			case self.returnCh <- &Result{work, block}:
+			case <-quit:
+				return
+			}
+		case <-quit:
+			return
+		}
+	}
+}
@@ -144,6 +144,14 @@ func (self *Miner) Stop() {
 	atomic.StoreInt32(&self.shouldStart, 0)
 }
 
+// Recommit discards the work currently being mined and assembles a new block on
+// top of the chain head, picking up all the pending transactions.
+func (self *Miner) Recommit() {
+	if self.Mining() {
+		self.worker.commitNewWork()
+	}
+}
+
 func (self *Miner) Register(agent Agent) {
 	if self.Mining() {
 		agent.Start()
@@ -192,7 +200,25 @@ func (self *Miner) PendingBlock() *types.Block {
 	return self.worker.pendingBlock()
 }
 
+// SetEtherbase sets the coinbase of the mined blocks, ending any rotation set up
+// by SetRotatingEtherbases.
 func (self *Miner) SetEtherbase(addr common.Address) {
 	self.coinbase = addr
 	self.worker.setEtherbase(addr)
+	self.worker.setPayouts(nil)
+}
+
+// SetRotatingEtherbases rotates the coinbase of the mined blocks among several
+// addresses by weight. Each block still pays its entire reward to a single
+// address, so the shares only hold on average: a miner finding few blocks may
+// not pay some of the addresses for a long time.
+func (self *Miner) SetRotatingEtherbases(payouts []Payout) error {
+	schedule, err := newPayoutSchedule(payouts)
+	if err != nil {
+		return err
+	}
+	self.coinbase = payouts[0].Address
+	self.worker.setEtherbase(payouts[0].Address)
+	self.worker.setPayouts(schedule)
+	return nil
 }
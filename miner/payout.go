@@ -0,0 +1,87 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"errors"
+
+	"github.com/EarthDollar/go-earthdollar/common"
+)
+
+var (
+	errNoPayouts  = errors.New("no payout addresses")
+	errZeroWeight = errors.New("zero payout weight")
+)
+
+// Payout is a coinbase address together with its share of the mined blocks.
+type Payout struct {
+	Address common.Address `json:"address"`
+	Weight  uint64         `json:"weight"`
+}
+
+// payoutSchedule rotates the coinbase of the mined blocks among several payout
+// addresses, such that over time each receives the share of the block rewards
+// corresponding to its weight. It uses smooth weighted round robin, spreading
+// the blocks of each address as evenly as possible. The reward of any single
+// block is never split, it is paid in full to the selected coinbase.
+//
+// The schedule advances once per block height, so repeatedly recommitting work
+// for the same block keeps the same coinbase.
+type payoutSchedule struct {
+	payouts []Payout
+	credits []int64 // Accumulated credit of each payout address
+	total   int64   // Sum of all weights
+
+	selected bool           // Whether a coinbase was already selected
+	number   uint64         // Block number of the last selection
+	coinbase common.Address // Coinbase selected for the last block number
+}
+
+// newPayoutSchedule creates a schedule splitting the blocks among the payouts.
+func newPayoutSchedule(payouts []Payout) (*payoutSchedule, error) {
+	if len(payouts) == 0 {
+		return nil, errNoPayouts
+	}
+	s := &payoutSchedule{
+		payouts: append([]Payout(nil), payouts...),
+		credits: make([]int64, len(payouts)),
+	}
+	for _, p := range payouts {
+		if p.Weight == 0 {
+			return nil, errZeroWeight
+		}
+		s.total += int64(p.Weight)
+	}
+	return s, nil
+}
+
+// next returns the coinbase to use for the block with the given number.
+func (s *payoutSchedule) next(number uint64) common.Address {
+	if s.selected && number == s.number {
+		return s.coinbase
+	}
+	best := 0
+	for i, p := range s.payouts {
+		s.credits[i] += int64(p.Weight)
+		if s.credits[i] > s.credits[best] {
+			best = i
+		}
+	}
+	s.credits[best] -= s.total
+	s.selected, s.number, s.coinbase = true, number, s.payouts[best].Address
+	return s.coinbase
+}
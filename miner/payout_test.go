@@ -0,0 +1,58 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"testing"
+
+	"github.com/EarthDollar/go-earthdollar/common"
+)
+
+// Tests that the payout schedule splits the blocks proportionally to the weights,
+// spreading them evenly and keeping the coinbase stable within a block height.
+func TestPayoutSchedule(t *testing.T) {
+	var (
+		a = common.Address{0x0a}
+		b = common.Address{0x0b}
+		c = common.Address{0x0c}
+	)
+	schedule, err := newPayoutSchedule([]Payout{{a, 5}, {b, 3}, {c, 2}})
+	if err != nil {
+		t.Fatalf("failed to create schedule: %v", err)
+	}
+	counts := make(map[common.Address]int)
+	for number := uint64(1); number <= 100; number++ {
+		coinbase := schedule.next(number)
+		if again := schedule.next(number); again != coinbase {
+			t.Fatalf("block #%d: coinbase changed on recommit: have %x, want %x", number, again, coinbase)
+		}
+		counts[coinbase]++
+
+		// Any ten consecutive blocks must already be split exactly
+		if number%10 == 0 {
+			if counts[a] != int(number/10)*5 || counts[b] != int(number/10)*3 || counts[c] != int(number/10)*2 {
+				t.Fatalf("block #%d: split mismatch: have %v", number, counts)
+			}
+		}
+	}
+	if _, err := newPayoutSchedule(nil); err != errNoPayouts {
+		t.Errorf("empty schedule error mismatch: have %v, want %v", err, errNoPayouts)
+	}
+	if _, err := newPayoutSchedule([]Payout{{a, 1}, {b, 0}}); err != errZeroWeight {
+		t.Errorf("zero weight error mismatch: have %v, want %v", err, errZeroWeight)
+	}
+}
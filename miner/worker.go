@@ -20,6 +20,7 @@ import (
 	"bytes"
 	"fmt"
 	"math/big"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -42,6 +43,13 @@ import (
 const (
 	resultQueueSize  = 10
 	miningLogAtDepth = 5
+
+	// txChanSize is the size of channel listening to TxsPreEvent.
+	txChanSize = 256
+	// chainHeadChanSize is the size of channel listening to ChainHeadEvent.
+	chainHeadChanSize = 10
+	// chainSideChanSize is the size of channel listening to ChainSideEvent.
+	chainSideChanSize = 10
 )
 
 // Agent can register themself with the worker
@@ -89,9 +97,15 @@ type worker struct {
 	mu sync.Mutex
 
 	// update loop
-	mux    *event.TypeMux
-	events event.Subscription
-	wg     sync.WaitGroup
+	mux          *event.TypeMux
+	txCh         chan core.TxsPreEvent
+	txSub        event.Subscription
+	chainHeadCh  chan core.ChainHeadEvent
+	chainHeadSub event.Subscription
+	chainSideCh  chan core.ChainSideEvent
+	chainSideSub event.Subscription
+	subs         event.SubscriptionScope // Feed subscriptions torn down together with the update loop
+	wg           sync.WaitGroup
 
 	agents map[Agent]struct{}
 	recv   chan *Result
@@ -103,6 +117,7 @@ type worker struct {
 	chainDb ethdb.Database
 
 	coinbase common.Address
+	payouts  *payoutSchedule // Optional rotation of the coinbase among several addresses
 	gasPrice *big.Int
 	extra    []byte
 
@@ -137,11 +152,16 @@ func newWorker(config *params.ChainConfig, coinbase common.Address, eth Backend,
 		possibleUncles: make(map[common.Hash]*types.Block),
 		coinbase:       coinbase,
 		txQueue:        make(map[common.Hash]*types.Transaction),
+		txCh:           make(chan core.TxsPreEvent, txChanSize),
+		chainHeadCh:    make(chan core.ChainHeadEvent, chainHeadChanSize),
+		chainSideCh:    make(chan core.ChainSideEvent, chainSideChanSize),
 		agents:         make(map[Agent]struct{}),
 		unconfirmed:    newUnconfirmedBlocks(eth.BlockChain(), 5),
 		fullValidation: false,
 	}
-	worker.events = worker.mux.Subscribe(core.ChainHeadEvent{}, core.ChainSideEvent{}, core.TxPreEvent{})
+	worker.txSub = worker.subs.Track(eth.TxPool().SubscribeTxsPreEvent(worker.txCh))
+	worker.chainHeadSub = worker.subs.Track(eth.BlockChain().SubscribeChainHeadEvent(worker.chainHeadCh))
+	worker.chainSideSub = worker.subs.Track(eth.BlockChain().SubscribeChainSideEvent(worker.chainSideCh))
 	go worker.update()
 
 	go worker.wait()
@@ -156,6 +176,12 @@ func (self *worker) setEtherbase(addr common.Address) {
 	self.coinbase = addr
 }
 
+func (self *worker) setPayouts(payouts *payoutSchedule) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	self.payouts = payouts
+}
+
 func (self *worker) setExtra(extra []byte) {
 	self.mu.Lock()
 	defer self.mu.Unlock()
@@ -239,22 +265,40 @@ func (self *worker) unregister(agent Agent) {
 }
 
 func (self *worker) update() {
-	for event := range self.events.Chan() {
+	defer self.subs.Close()
+
+	for {
 		// A real event arrived, process interesting content
-		switch ev := event.Data.(type) {
-		case core.ChainHeadEvent:
+		select {
+		case <-self.chainHeadCh:
 			self.commitNewWork()
-		case core.ChainSideEvent:
+
+		case ev := <-self.chainSideCh:
 			self.uncleMu.Lock()
 			self.possibleUncles[ev.Block.Hash()] = ev.Block
 			self.uncleMu.Unlock()
-		case core.TxPreEvent:
-			// Apply transaction to the pending state if we're not mining
+
+		// Any producer was stopped, the node is shutting down
+		case <-self.txSub.Err():
+			return
+		case <-self.chainHeadSub.Err():
+			return
+		case <-self.chainSideSub.Err():
+			return
+
+		case ev := <-self.txCh:
+			// Apply transactions to the pending state if we're not mining
 			if atomic.LoadInt32(&self.mining) == 0 {
 				self.currentMu.Lock()
 
-				acc, _ := types.Sender(self.current.signer, ev.Tx)
-				txs := map[common.Address]types.Transactions{acc: {ev.Tx}}
+				txs := make(map[common.Address]types.Transactions)
+				for _, tx := range ev.Txs {
+					acc, _ := types.Sender(self.current.signer, tx)
+					txs[acc] = append(txs[acc], tx)
+				}
+				for _, accTxs := range txs {
+					sort.Sort(types.TxByNonce(accTxs))
+				}
 				txset := types.NewTransactionsByPriceAndNonce(txs)
 
 				self.current.commitTransactions(self.mux, txset, self.gasPrice, self.chain)
@@ -327,12 +371,12 @@ func (self *worker) wait() {
 				// broadcast before waiting for validation
 				go func(block *types.Block, logs []*types.Log, receipts []*types.Receipt) {
 					self.mux.Post(core.NewMinedBlockEvent{Block: block})
-					self.mux.Post(core.ChainEvent{Block: block, Hash: block.Hash(), Logs: logs})
 
+					var canonLogs []*types.Log
 					if stat == core.CanonStatTy {
-						self.mux.Post(core.ChainHeadEvent{Block: block})
-						self.mux.Post(logs)
+						canonLogs = logs
 					}
+					self.chain.PostChainEvents([]interface{}{core.ChainEvent{Block: block, Hash: block.Hash(), Logs: logs}}, canonLogs)
 					if err := core.WriteBlockReceipts(self.chainDb, block.Hash(), block.NumberU64(), receipts); err != nil {
 						glog.V(logger.Warn).Infoln("error writing block receipts:", err)
 					}
@@ -429,13 +473,17 @@ func (self *worker) commitNewWork() {
 	}
 
 	num := parent.Number()
+	coinbase := self.coinbase
+	if self.payouts != nil {
+		coinbase = self.payouts.next(num.Uint64() + 1)
+	}
 	header := &types.Header{
 		ParentHash: parent.Hash(),
 		Number:     num.Add(num, common.Big1),
 		Difficulty: core.CalcDifficulty(self.config, uint64(tstamp), parent.Time().Uint64(), parent.Number(), parent.Difficulty()),
 		GasLimit:   core.CalcGasLimit(parent),
 		GasUsed:    new(big.Int),
-		Coinbase:   self.coinbase,
+		Coinbase:   coinbase,
 		Extra:      self.extra,
 		Time:       big.NewInt(tstamp),
 	}
@@ -502,7 +550,7 @@ func (self *worker) commitNewWork() {
 
 	if atomic.LoadInt32(&self.mining) == 1 {
 		// commit state root after all state transitions.
-		core.AccumulateRewards(work.state, header, uncles)
+		core.AccumulateRewards(self.config, self.chain, work.state, header, uncles)
 		header.Root = work.state.IntermediateRoot(self.config.IsEIP158(header.Number))
 	}
 
@@ -122,6 +122,37 @@ func (am *AccountManager) SignPassphrase(account *Account, passphrase string, ha
 	return am.manager.SignWithPassphrase(account.account, passphrase, hash)
 }
 
+// SignTx signs the transaction with the unlocked key of the given account. If
+// chainID is non nil, the replay protected EIP155 signature scheme is used.
+func (am *AccountManager) SignTx(account *Account, tx *Transaction, chainID *BigInt) (signed *Transaction, _ error) {
+	signer := txSigner(chainID)
+	sig, err := am.manager.Sign(account.account.Address, signer.Hash(tx.tx).Bytes())
+	if err != nil {
+		return nil, err
+	}
+	rawTx, err := tx.tx.WithSignature(signer, sig)
+	if err != nil {
+		return nil, err
+	}
+	return &Transaction{rawTx}, nil
+}
+
+// SignTxPassphrase signs the transaction if the private key matching the given
+// account can be decrypted with the passphrase. If chainID is non nil, the replay
+// protected EIP155 signature scheme is used.
+func (am *AccountManager) SignTxPassphrase(account *Account, passphrase string, tx *Transaction, chainID *BigInt) (signed *Transaction, _ error) {
+	signer := txSigner(chainID)
+	sig, err := am.manager.SignWithPassphrase(account.account, passphrase, signer.Hash(tx.tx).Bytes())
+	if err != nil {
+		return nil, err
+	}
+	rawTx, err := tx.tx.WithSignature(signer, sig)
+	if err != nil {
+		return nil, err
+	}
+	return &Transaction{rawTx}, nil
+}
+
 // Unlock unlocks the given account indefinitely.
 func (am *AccountManager) Unlock(account *Account, passphrase string) error {
 	return am.manager.TimedUnlock(account.account, passphrase, 0)
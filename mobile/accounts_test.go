@@ -0,0 +1,73 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ged
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+// Tests that transactions can be created, signed with both signature schemes
+// and moved across the language boundary in their RLP form.
+func TestSignTransaction(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mobile-keystore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	am := NewAccountManager(dir, LightScryptN, LightScryptP)
+	account, err := am.NewAccount("secret")
+	if err != nil {
+		t.Fatalf("failed to create account: %v", err)
+	}
+	to, _ := NewAddressFromHex("0x0102030405060708090a0b0c0d0e0f1011121314")
+	tx := NewTransaction(1, to, NewBigInt(1000), NewBigInt(21000), NewBigInt(1), nil)
+
+	for _, chainID := range []*BigInt{nil, NewBigInt(7)} {
+		if _, err := am.SignTxPassphrase(account, "wrong", tx, chainID); err == nil {
+			t.Fatalf("chain %v: signed with invalid passphrase", chainID)
+		}
+		signed, err := am.SignTxPassphrase(account, "secret", tx, chainID)
+		if err != nil {
+			t.Fatalf("chain %v: failed to sign transaction: %v", chainID, err)
+		}
+		blob, err := signed.EncodeRLP()
+		if err != nil {
+			t.Fatalf("chain %v: failed to encode transaction: %v", chainID, err)
+		}
+		decoded, err := NewTransactionFromRLP(blob)
+		if err != nil {
+			t.Fatalf("chain %v: failed to decode transaction: %v", chainID, err)
+		}
+		from, err := decoded.GetFrom()
+		if err != nil {
+			t.Fatalf("chain %v: failed to recover sender: %v", chainID, err)
+		}
+		if from.GetHex() != account.GetAddress().GetHex() {
+			t.Errorf("chain %v: sender mismatch: have %s, want %s", chainID, from.GetHex(), account.GetAddress().GetHex())
+		}
+	}
+	// Signing with an unlocked key must not need the passphrase
+	if err := am.Unlock(account, "secret"); err != nil {
+		t.Fatalf("failed to unlock account: %v", err)
+	}
+	if _, err := am.SignTx(account, tx, NewBigInt(7)); err != nil {
+		t.Errorf("failed to sign with unlocked account: %v", err)
+	}
+}
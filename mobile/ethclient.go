@@ -22,17 +22,17 @@ import (
 	"math/big"
 
 	"github.com/EarthDollar/go-earthdollar/core/types"
-	"github.com/EarthDollar/go-earthdollar/ethclient"
+	"github.com/EarthDollar/go-earthdollar/edclient"
 )
 
 // EthereumClient provides access to the Ethereum APIs.
 type EthereumClient struct {
-	client *ethclient.Client
+	client *edclient.Client
 }
 
 // NewEthereumClient connects a client to the given URL.
 func NewEthereumClient(rawurl string) (client *EthereumClient, _ error) {
-	rawClient, err := ethclient.Dial(rawurl)
+	rawClient, err := edclient.Dial(rawurl)
 	return &EthereumClient{rawClient}, err
 }
 
@@ -303,3 +303,25 @@ func (ec *EthereumClient) EstimateGas(ctx *Context, msg *CallMsg) (gas *BigInt,
 func (ec *EthereumClient) SendTransaction(ctx *Context, tx *Transaction) error {
 	return ec.client.SendTransaction(ctx.context, tx.tx)
 }
+
+// Earthdollar specific
+
+// GetNetworkID returns the network identifier the remote node is running on.
+func (ec *EthereumClient) GetNetworkID(ctx *Context) (id int64, _ error) {
+	rawID, err := ec.client.NetworkID(ctx.context)
+	if err != nil {
+		return 0, err
+	}
+	return rawID.Int64(), nil
+}
+
+// GetTotalSupply returns the total amount of earthdollars in circulation.
+// The block number can be <0, in which case the supply is taken from the latest known block.
+func (ec *EthereumClient) GetTotalSupply(ctx *Context, number int64) (supply *BigInt, _ error) {
+	if number < 0 {
+		rawSupply, err := ec.client.TotalSupply(ctx.context, nil)
+		return &BigInt{rawSupply}, err
+	}
+	rawSupply, err := ec.client.TotalSupply(ctx.context, big.NewInt(number))
+	return &BigInt{rawSupply}, err
+}
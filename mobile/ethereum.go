@@ -21,6 +21,7 @@ package ged
 import (
 	"errors"
 	"math/big"
+	"time"
 
 	ethereum "github.com/EarthDollar/go-earthdollar"
 	"github.com/EarthDollar/go-earthdollar/common"
@@ -84,6 +85,14 @@ func (p *SyncProgress) GetHighestBlock() int64  { return int64(p.progress.Highes
 func (p *SyncProgress) GetPulledStates() int64  { return int64(p.progress.PulledStates) }
 func (p *SyncProgress) GetKnownStates() int64   { return int64(p.progress.KnownStates) }
 
+func (p *SyncProgress) GetPulledBodies() int64   { return int64(p.progress.PulledBodies) }
+func (p *SyncProgress) GetKnownBodies() int64    { return int64(p.progress.KnownBodies) }
+func (p *SyncProgress) GetPulledReceipts() int64 { return int64(p.progress.PulledReceipts) }
+func (p *SyncProgress) GetKnownReceipts() int64  { return int64(p.progress.KnownReceipts) }
+
+// GetETA returns the estimated number of seconds until the sync completes.
+func (p *SyncProgress) GetETA() int64 { return int64(p.progress.ETA / time.Second) }
+
 // Topics is a set of topic lists to filter events with.
 type Topics struct{ topics [][]common.Hash }
 
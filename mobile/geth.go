@@ -25,14 +25,14 @@ import (
 	"path/filepath"
 
 	"github.com/EarthDollar/go-earthdollar/common"
+	"github.com/EarthDollar/go-earthdollar/edclient"
 	"github.com/EarthDollar/go-earthdollar/eth"
-	"github.com/EarthDollar/go-earthdollar/ethclient"
 	"github.com/EarthDollar/go-earthdollar/ethstats"
 	"github.com/EarthDollar/go-earthdollar/les"
 	"github.com/EarthDollar/go-earthdollar/node"
 	"github.com/EarthDollar/go-earthdollar/p2p/nat"
 	"github.com/EarthDollar/go-earthdollar/params"
-	"github.com/EarthDollar/go-earthdollar/whisper/whisperv2"
+	"github.com/EarthDollar/go-earthdollar/whisper/whisperv5"
 )
 
 // NodeConfig represents the collection of configuration values to fine tune the Geth
@@ -62,8 +62,9 @@ type NodeConfig struct {
 	// empty genesis state is equivalent to using the mainnet's state.
 	EthereumGenesis string
 
-	// EthereumDatabaseCache is the system memory in MB to allocate for database caching.
-	// A minimum of 16MB is always reserved.
+	// EthereumDatabaseCache is the system memory in MB to allocate for caching. It
+	// is split between the database, trie nodes and state snapshots, with a
+	// minimum of 16MB always reserved for the database.
 	EthereumDatabaseCache int
 
 	// EthereumNetStats is a netstats connection string to use to report various
@@ -74,6 +75,11 @@ type NodeConfig struct {
 
 	// WhisperEnabled specifies whether the node should run the Whisper protocol.
 	WhisperEnabled bool
+
+	// WhisperLightMode specifies whether Whisper should only receive the messages
+	// matching the local filters, without relaying any and with low PoW on the
+	// outgoing ones, to spare the bandwidth and battery of the device.
+	WhisperLightMode bool
 }
 
 // defaultNodeConfig contains the default node configuration values to use if all
@@ -82,7 +88,7 @@ var defaultNodeConfig = &NodeConfig{
 	BootstrapNodes:        FoundationBootnodes(),
 	MaxPeers:              25,
 	EthereumEnabled:       true,
-	EthereumNetworkID:     1,
+	EthereumNetworkID:     eth.NetworkId,
 	EthereumChainConfig:   MainnetChainConfig(),
 	EthereumDatabaseCache: 16,
 }
@@ -143,7 +149,7 @@ func NewNode(datadir string, config *NodeConfig) (stack *Node, _ error) {
 			},
 			Genesis:                 config.EthereumGenesis,
 			LightMode:               true,
-			DatabaseCache:           config.EthereumDatabaseCache,
+			CacheSize:               config.EthereumDatabaseCache,
 			NetworkId:               config.EthereumNetworkID,
 			GasPrice:                new(big.Int).Mul(big.NewInt(20), common.Shannon),
 			GpoMinGasPrice:          new(big.Int).Mul(big.NewInt(20), common.Shannon),
@@ -172,7 +178,9 @@ func NewNode(datadir string, config *NodeConfig) (stack *Node, _ error) {
 	}
 	// Register the Whisper protocol if requested
 	if config.WhisperEnabled {
-		if err := rawStack.Register(func(*node.ServiceContext) (node.Service, error) { return whisperv2.New(), nil }); err != nil {
+		shhConf := whisperv5.DefaultConfig
+		shhConf.LightMode = config.WhisperLightMode
+		if err := rawStack.Register(func(*node.ServiceContext) (node.Service, error) { return whisperv5.New(&shhConf), nil }); err != nil {
 			return nil, fmt.Errorf("whisper init: %v", err)
 		}
 	}
@@ -196,7 +204,7 @@ func (n *Node) GetEthereumClient() (client *EthereumClient, _ error) {
 	if err != nil {
 		return nil, err
 	}
-	return &EthereumClient{ethclient.NewClient(rpc)}, nil
+	return &EthereumClient{edclient.NewClient(rpc)}, nil
 }
 
 // GetNodeInfo gathers and returns a collection of metadata known about the host.
@@ -24,3 +24,8 @@ import (
 func SetVerbosity(level int) {
 	glog.SetV(level)
 }
+
+// SetVmodule sets the per-module verbosity pattern, e.g. "eth/downloader=6,p2p=5".
+func SetVmodule(pattern string) error {
+	return glog.GetVModule().Set(pattern)
+}
@@ -23,6 +23,7 @@ import (
 	"fmt"
 
 	"github.com/EarthDollar/go-earthdollar/core/types"
+	"github.com/EarthDollar/go-earthdollar/rlp"
 )
 
 // A Nonce is a 64-bit hash which proves (combined with the mix-hash) that
@@ -132,6 +133,39 @@ type Transaction struct {
 	tx *types.Transaction
 }
 
+// NewTransaction creates a new transaction with the given properties. A nil
+// recipient creates a contract creation transaction.
+func NewTransaction(nonce int64, to *Address, amount, gasLimit, gasPrice *BigInt, data []byte) *Transaction {
+	if to == nil {
+		return &Transaction{types.NewContractCreation(uint64(nonce), amount.bigint, gasLimit.bigint, gasPrice.bigint, data)}
+	}
+	return &Transaction{types.NewTransaction(uint64(nonce), to.address, amount.bigint, gasLimit.bigint, gasPrice.bigint, data)}
+}
+
+// NewTransactionFromRLP parses a transaction from its RLP encoding.
+func NewTransactionFromRLP(data []byte) (tx *Transaction, _ error) {
+	rawTx := new(types.Transaction)
+	if err := rlp.DecodeBytes(data, rawTx); err != nil {
+		return nil, err
+	}
+	return &Transaction{rawTx}, nil
+}
+
+// EncodeRLP encodes the transaction into its RLP form, suitable for storing or
+// transferring it to another device.
+func (tx *Transaction) EncodeRLP() (data []byte, _ error) {
+	return rlp.EncodeToBytes(tx.tx)
+}
+
+// txSigner returns the signer to use for the given chain, defaulting to the
+// unprotected homestead scheme if no chain id is given.
+func txSigner(chainID *BigInt) types.Signer {
+	if chainID == nil {
+		return types.HomesteadSigner{}
+	}
+	return types.NewEIP155Signer(chainID.bigint)
+}
+
 func (tx *Transaction) GetData() []byte      { return tx.tx.Data() }
 func (tx *Transaction) GetGas() int64        { return tx.tx.Gas().Int64() }
 func (tx *Transaction) GetGasPrice() *BigInt { return &BigInt{tx.tx.GasPrice()} }
@@ -142,8 +176,14 @@ func (tx *Transaction) GetHash() *Hash    { return &Hash{tx.tx.Hash()} }
 func (tx *Transaction) GetSigHash() *Hash { return &Hash{tx.tx.SigHash(types.HomesteadSigner{})} }
 func (tx *Transaction) GetCost() *BigInt  { return &BigInt{tx.tx.Cost()} }
 
+// GetFrom recovers the sender of the transaction, using the signature scheme
+// the transaction was signed with.
 func (tx *Transaction) GetFrom() (address *Address, _ error) {
-	from, err := types.Sender(types.HomesteadSigner{}, tx.tx)
+	var signer types.Signer = types.HomesteadSigner{}
+	if tx.tx.Protected() {
+		signer = types.NewEIP155Signer(tx.tx.ChainId())
+	}
+	from, err := types.Sender(signer, tx.tx)
 	return &Address{from}, err
 }
 
@@ -18,6 +18,8 @@ package node
 
 import (
 	"fmt"
+	"net"
+	"strconv"
 	"strings"
 	"time"
 
@@ -25,7 +27,9 @@ import (
 	"github.com/EarthDollar/go-earthdollar/crypto"
 	"github.com/EarthDollar/go-earthdollar/p2p"
 	"github.com/EarthDollar/go-earthdollar/p2p/discover"
+	"github.com/EarthDollar/go-earthdollar/rpc"
 	"github.com/rcrowley/go-metrics"
+	"golang.org/x/net/context"
 )
 
 // PrivateAdminAPI is the collection of administrative API methods exposed only
@@ -73,45 +77,227 @@ func (api *PrivateAdminAPI) RemovePeer(url string) (bool, error) {
 	return true, nil
 }
 
-// StartRPC starts the HTTP RPC API server.
-func (api *PrivateAdminAPI) StartRPC(host *string, port *int, cors *string, apis *string) (bool, error) {
+// PeerEvents creates an RPC subscription which receives peer lifecycle events:
+// peers being added or dropped and connections failing their handshakes,
+// together with the reasons.
+func (api *PrivateAdminAPI) PeerEvents(ctx context.Context) (*rpc.Subscription, error) {
+	// Make sure the server is running, fail otherwise
+	server := api.node.Server()
+	if server == nil {
+		return &rpc.Subscription{}, ErrNodeStopped
+	}
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		sub := api.node.EventMux().Subscribe(p2p.PeerEvent{})
+		defer sub.Unsubscribe()
+
+		for {
+			select {
+			case ev, ok := <-sub.Chan():
+				if !ok {
+					return
+				}
+				notifier.Notify(rpcSub.ID, ev.Data)
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+	return rpcSub, nil
+}
+
+// AddTrustedPeer allows a remote node to always connect, even if slots are full.
+// An existing connection to the node is promoted immediately.
+func (api *PrivateAdminAPI) AddTrustedPeer(url string) (bool, error) {
+	// Make sure the server is running, fail otherwise
+	server := api.node.Server()
+	if server == nil {
+		return false, ErrNodeStopped
+	}
+	node, err := discover.ParseNode(url)
+	if err != nil {
+		return false, fmt.Errorf("invalid enode: %v", err)
+	}
+	server.AddTrustedPeer(node)
+	return true, nil
+}
+
+// RemoveTrustedPeer removes a remote node from the trusted peer set, but it
+// does not disconnect it.
+func (api *PrivateAdminAPI) RemoveTrustedPeer(url string) (bool, error) {
+	// Make sure the server is running, fail otherwise
+	server := api.node.Server()
+	if server == nil {
+		return false, ErrNodeStopped
+	}
+	node, err := discover.ParseNode(url)
+	if err != nil {
+		return false, fmt.Errorf("invalid enode: %v", err)
+	}
+	server.RemoveTrustedPeer(node)
+	return true, nil
+}
+
+// ReloadPeers re-reads static-nodes.json and trusted-nodes.json, connecting to
+// newly listed static nodes, dropping removed ones and updating the trusted set.
+func (api *PrivateAdminAPI) ReloadPeers() (bool, error) {
+	if err := api.node.ReloadNodeLists(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Reputations retrieves the behavioural scores of all nodes rated since the
+// node was started, keyed by node id.
+func (api *PrivateAdminAPI) Reputations() (map[string]int, error) {
+	server := api.node.Server()
+	if server == nil {
+		return nil, ErrNodeStopped
+	}
+	scores := make(map[string]int)
+	for id, score := range server.PeerReputations() {
+		scores[id.String()] = score
+	}
+	return scores, nil
+}
+
+// SetReputation overwrites the behavioural score of a node given by its id or
+// enode url, e.g. to lift a ban. The resulting score is returned.
+func (api *PrivateAdminAPI) SetReputation(id string, score int) (int, error) {
+	server := api.node.Server()
+	if server == nil {
+		return 0, ErrNodeStopped
+	}
+	nodeID, err := parseNodeID(id)
+	if err != nil {
+		return 0, err
+	}
+	return server.SetPeerReputation(nodeID, score), nil
+}
+
+// BanPeer refuses connections to and from a remote node for the given number of
+// seconds, disconnecting it if currently connected. The target may be an enode
+// url, a node id or an IP address, the latter banning all nodes behind it. Bans
+// are persisted across restarts.
+func (api *PrivateAdminAPI) BanPeer(target string, seconds uint64) (bool, error) {
+	server := api.node.Server()
+	if server == nil {
+		return false, ErrNodeStopped
+	}
+	duration := time.Duration(seconds) * time.Second
+	if ip := net.ParseIP(target); ip != nil {
+		server.BanIP(ip, duration)
+		return true, nil
+	}
+	id, err := parseNodeID(target)
+	if err != nil {
+		return false, err
+	}
+	server.BanNode(id, duration)
+	return true, nil
+}
+
+// UnbanPeer lifts the ban of a node or IP address, reporting whether one was
+// in effect.
+func (api *PrivateAdminAPI) UnbanPeer(target string) (bool, error) {
+	server := api.node.Server()
+	if server == nil {
+		return false, ErrNodeStopped
+	}
+	if ip := net.ParseIP(target); ip != nil {
+		return server.UnbanIP(ip), nil
+	}
+	id, err := parseNodeID(target)
+	if err != nil {
+		return false, err
+	}
+	return server.UnbanNode(id), nil
+}
+
+// BanInfo describes an active node or IP ban.
+type BanInfo struct {
+	ID    string    `json:"id,omitempty"`
+	IP    string    `json:"ip,omitempty"`
+	Until time.Time `json:"until"`
+}
+
+// Bans retrieves all currently active node and IP bans.
+func (api *PrivateAdminAPI) Bans() ([]BanInfo, error) {
+	server := api.node.Server()
+	if server == nil {
+		return nil, ErrNodeStopped
+	}
+	bans := []BanInfo{}
+	for _, b := range server.Bans() {
+		info := BanInfo{Until: b.Until}
+		if b.IP != nil {
+			info.IP = b.IP.String()
+		} else {
+			info.ID = b.ID.String()
+		}
+		bans = append(bans, info)
+	}
+	return bans, nil
+}
+
+// parseNodeID extracts a node id from either an enode url or a hex id.
+func parseNodeID(s string) (discover.NodeID, error) {
+	if node, err := discover.ParseNode(s); err == nil {
+		return node.ID, nil
+	}
+	id, err := discover.HexID(s)
+	if err != nil {
+		return discover.NodeID{}, fmt.Errorf("invalid node id: %v", err)
+	}
+	return id, nil
+}
+
+// StartHTTP starts the HTTP RPC API server. If the server is already running, it
+// is restarted with the new settings, omitted ones retaining their current value.
+// Should the restart fail, the server keeps running on its previous settings.
+func (api *PrivateAdminAPI) StartHTTP(host *string, port *int, cors *string, apis *string) (bool, error) {
 	api.node.lock.Lock()
 	defer api.node.lock.Unlock()
 
-	if api.node.httpHandler != nil {
-		return false, fmt.Errorf("HTTP RPC already running on %s", api.node.httpEndpoint)
+	if api.node.server == nil {
+		return false, ErrNodeStopped
 	}
-
+	h, p := splitEndpoint(api.node.httpEndpoint, DefaultHTTPHost, api.node.config.HTTPPort)
 	if host == nil {
-		h := DefaultHTTPHost
-		if api.node.config.HTTPHost != "" {
-			h = api.node.config.HTTPHost
-		}
 		host = &h
 	}
 	if port == nil {
-		port = &api.node.config.HTTPPort
+		port = &p
 	}
 	if cors == nil {
-		cors = &api.node.config.HTTPCors
+		cors = &api.node.httpCors
 	}
-
-	modules := api.node.httpWhitelist
+	modules := api.node.httpModules
 	if apis != nil {
-		modules = nil
-		for _, m := range strings.Split(*apis, ",") {
-			modules = append(modules, strings.TrimSpace(m))
-		}
+		modules = splitModules(*apis)
 	}
-
-	if err := api.node.startHTTP(fmt.Sprintf("%s:%d", *host, *port), api.node.rpcAPIs, modules, *cors); err != nil {
+	if err := api.node.restartHTTP(fmt.Sprintf("%s:%d", *host, *port), modules, *cors); err != nil {
 		return false, err
 	}
 	return true, nil
 }
 
-// StopRPC terminates an already running HTTP RPC API endpoint.
-func (api *PrivateAdminAPI) StopRPC() (bool, error) {
+// StartRPC starts the HTTP RPC API server.
+//
+// Deprecated: use StartHTTP instead.
+func (api *PrivateAdminAPI) StartRPC(host *string, port *int, cors *string, apis *string) (bool, error) {
+	return api.StartHTTP(host, port, cors, apis)
+}
+
+// StopHTTP terminates an already running HTTP RPC API endpoint.
+func (api *PrivateAdminAPI) StopHTTP() (bool, error) {
 	api.node.lock.Lock()
 	defer api.node.lock.Unlock()
 
@@ -122,44 +308,44 @@ func (api *PrivateAdminAPI) StopRPC() (bool, error) {
 	return true, nil
 }
 
-// StartWS starts the websocket RPC API server.
+// StopRPC terminates an already running HTTP RPC API endpoint.
+//
+// Deprecated: use StopHTTP instead.
+func (api *PrivateAdminAPI) StopRPC() (bool, error) {
+	return api.StopHTTP()
+}
+
+// StartWS starts the websocket RPC API server. If the server is already running,
+// it is restarted with the new settings, omitted ones retaining their current
+// value. Should the restart fail, the server keeps running on its previous settings.
 func (api *PrivateAdminAPI) StartWS(host *string, port *int, allowedOrigins *string, apis *string) (bool, error) {
 	api.node.lock.Lock()
 	defer api.node.lock.Unlock()
 
-	if api.node.wsHandler != nil {
-		return false, fmt.Errorf("WebSocket RPC already running on %s", api.node.wsEndpoint)
+	if api.node.server == nil {
+		return false, ErrNodeStopped
 	}
-
+	h, p := splitEndpoint(api.node.wsEndpoint, DefaultWSHost, api.node.config.WSPort)
 	if host == nil {
-		h := DefaultWSHost
-		if api.node.config.WSHost != "" {
-			h = api.node.config.WSHost
-		}
 		host = &h
 	}
 	if port == nil {
-		port = &api.node.config.WSPort
+		port = &p
 	}
 	if allowedOrigins == nil {
-		allowedOrigins = &api.node.config.WSOrigins
+		allowedOrigins = &api.node.wsOrigins
 	}
-
-	modules := api.node.config.WSModules
+	modules := api.node.wsModules
 	if apis != nil {
-		modules = nil
-		for _, m := range strings.Split(*apis, ",") {
-			modules = append(modules, strings.TrimSpace(m))
-		}
+		modules = splitModules(*apis)
 	}
-
-	if err := api.node.startWS(fmt.Sprintf("%s:%d", *host, *port), api.node.rpcAPIs, modules, *allowedOrigins); err != nil {
+	if err := api.node.restartWS(fmt.Sprintf("%s:%d", *host, *port), modules, *allowedOrigins); err != nil {
 		return false, err
 	}
 	return true, nil
 }
 
-// StopRPC terminates an already running websocket RPC API endpoint.
+// StopWS terminates an already running websocket RPC API endpoint.
 func (api *PrivateAdminAPI) StopWS() (bool, error) {
 	api.node.lock.Lock()
 	defer api.node.lock.Unlock()
@@ -171,6 +357,26 @@ func (api *PrivateAdminAPI) StopWS() (bool, error) {
 	return true, nil
 }
 
+// splitEndpoint extracts the host and port of an endpoint, falling back to the
+// given defaults if the endpoint is not set.
+func splitEndpoint(endpoint string, host string, port int) (string, int) {
+	if h, p, err := net.SplitHostPort(endpoint); err == nil {
+		if n, err := strconv.Atoi(p); err == nil {
+			return h, n
+		}
+	}
+	return host, port
+}
+
+// splitModules parses a comma separated list of API modules.
+func splitModules(apis string) []string {
+	var modules []string
+	for _, m := range strings.Split(apis, ",") {
+		modules = append(modules, strings.TrimSpace(m))
+	}
+	return modules
+}
+
 // PublicAdminAPI is the collection of administrative API methods exposed over
 // both secure and unsecure RPC channels.
 type PublicAdminAPI struct {
@@ -35,6 +35,7 @@ import (
 	"github.com/EarthDollar/go-earthdollar/p2p/discv5"
 	"github.com/EarthDollar/go-earthdollar/p2p/nat"
 	"github.com/EarthDollar/go-earthdollar/p2p/netutil"
+	"github.com/EarthDollar/go-earthdollar/rpc"
 )
 
 var (
@@ -87,6 +88,11 @@ type Config struct {
 	// relative), then that specific path is enforced. An empty path disables IPC.
 	IPCPath string
 
+	// IPCModules is a list of API modules to expose via the IPC RPC interface.
+	// If the module list is empty, all RPC API endpoints will be exposed, as the
+	// IPC endpoint is only reachable from the local machine.
+	IPCModules []string
+
 	// This field should be a valid secp256k1 private key that will be used for both
 	// remote peer identification as well as network traffic encryption. If no key
 	// is configured, the preset one is loaded from the data dir, generating it if
@@ -115,6 +121,11 @@ type Config struct {
 	// using the V5 discovery protocol.
 	BootstrapNodesV5 []*discv5.Node
 
+	// DNSDiscovery is a list of DNS node tree URLs (enrtree://<key>@<domain>)
+	// from which additional bootstrap nodes are fetched, allowing the network to
+	// recover even if all the configured bootstrap nodes are unreachable.
+	DNSDiscovery []string
+
 	// Network interface address on which the node should listen for inbound peers.
 	ListenAddr string
 
@@ -126,6 +137,10 @@ type Config struct {
 	// peer connections.
 	Dialer *net.Dialer
 
+	// If Proxy is set to a non-nil value, outbound peer connections are routed
+	// through the given SOCKS5 proxy.
+	Proxy *netutil.SOCKS5Dialer
+
 	// If NoDial is true, the node will not dial any peers.
 	NoDial bool
 
@@ -138,6 +153,14 @@ type Config struct {
 	// Zero defaults to preset values.
 	MaxPendingPeers int
 
+	// MaxMessageSize is the maximum payload size of a single message a peer may
+	// send before being disconnected. Zero defaults to preset values.
+	MaxMessageSize uint32
+
+	// MaxMessageRate is the number of messages per second a peer may send on
+	// average before being disconnected. Zero defaults to preset values.
+	MaxMessageRate int
+
 	// HTTPHost is the host interface on which to start the HTTP RPC server. If this
 	// field is empty, no HTTP API endpoint will be started.
 	HTTPHost string
@@ -175,6 +198,57 @@ type Config struct {
 	// If the module list is empty, all RPC API endpoints designated public will be
 	// exposed.
 	WSModules []string
+
+	// RPCRateLimit is the number of calls per second a single client (identified by
+	// its IP address) may make on the HTTP and websocket RPC interfaces, expensive
+	// calls counting multiple times. If the limit is zero, no rate limiting is done.
+	RPCRateLimit float64
+
+	// RPCRateBurst is the number of calls a client may save up for bursts of
+	// requests. If zero, it defaults to a second worth of calls.
+	RPCRateBurst int
+
+	// RPCQuotas overrides the rate limits of individual clients, identified by their
+	// IP address or by the API key presented in the X-API-Key header.
+	RPCQuotas map[string]rpc.RateQuota
+
+	// RPCBatchLimit is the maximum number of requests a single batch may contain
+	// on the HTTP and websocket RPC interfaces. Zero means unlimited.
+	RPCBatchLimit int
+
+	// RPCResponseSizeLimit is the maximum number of bytes a single response (or
+	// batch of responses) may amount to on the HTTP and websocket RPC interfaces.
+	// Zero means unlimited.
+	RPCResponseSizeLimit int
+
+	// RPCConcurrencyLimit is the maximum number of requests the HTTP and websocket
+	// RPC interfaces each execute concurrently. Zero means unlimited.
+	RPCConcurrencyLimit int
+
+	// AuthHost is the host interface on which to start the authenticated HTTP RPC
+	// server. Requests to it must carry an HS256 JWT bearer token signed with the
+	// shared secret. If this field is empty, no authenticated endpoint is started.
+	AuthHost string
+
+	// AuthPort is the TCP port number on which to start the authenticated HTTP RPC
+	// server. The default zero value is valid and will pick a port number randomly.
+	AuthPort int
+
+	// AuthModules is a list of API modules to expose via the authenticated HTTP RPC
+	// interface. If the module list is empty, all RPC API endpoints will be exposed.
+	AuthModules []string
+
+	// JWTSecret is the file containing the hex encoded shared secret used to verify
+	// the tokens of the authenticated endpoint. Relative paths are resolved inside
+	// the instance directory and a missing file is created with a random secret. If
+	// the field is empty, the "jwtsecret" file of the instance directory is used.
+	JWTSecret string
+
+	// AuditLog is the file in which calls to sensitive RPC methods (signing,
+	// unlocking, transaction submission and admin calls) are recorded. Relative
+	// paths are resolved inside the instance directory. If the field is empty, no
+	// audit log is kept.
+	AuditLog string
 }
 
 // IPCEndpoint resolves an IPC endpoint based on a configured value, taking into
@@ -252,6 +326,25 @@ func DefaultWSEndpoint() string {
 	return config.WSEndpoint()
 }
 
+// AuthEndpoint resolves the authenticated HTTP endpoint based on the configured
+// host interface and port parameters.
+func (c *Config) AuthEndpoint() string {
+	if c.AuthHost == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s:%d", c.AuthHost, c.AuthPort)
+}
+
+// jwtSecretPath resolves the file holding the shared secret of the authenticated
+// endpoint, defaulting to one in the instance directory.
+func (c *Config) jwtSecretPath() string {
+	path := c.JWTSecret
+	if path == "" {
+		path = "jwtsecret"
+	}
+	return c.resolvePath(path)
+}
+
 // NodeName returns the devp2p node identifier.
 func (c *Config) NodeName() string {
 	name := c.name()
@@ -29,6 +29,11 @@ const (
 	DefaultHTTPPort  = 8811        // Default TCP port for the HTTP RPC server
 	DefaultWSHost    = "localhost" // Default host interface for the websocket RPC server
 	DefaultWSPort    = 8546        // Default TCP port for the websocket RPC server
+	DefaultAuthHost  = "localhost" // Default host interface for the authenticated HTTP RPC server
+	DefaultAuthPort  = 8551        // Default TCP port for the authenticated HTTP RPC server
+
+	DefaultRPCBatchLimit        = 1000             // Default maximum number of requests in an RPC batch
+	DefaultRPCResponseSizeLimit = 25 * 1024 * 1024 // Default maximum size of an RPC (batch) response
 )
 
 // DefaultDataDir is the default data directory to use for the databases and other
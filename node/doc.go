@@ -19,7 +19,9 @@ Package node sets up multi-protocol Ethereum nodes.
 
 In the model exposed by this package, a node is a collection of services which use shared
 resources to provide RPC APIs. Services can also offer devp2p protocols, which are wired
-up to the devp2p network when the node instance is started.
+up to the devp2p network when the node instance is started. Services are started in the
+order they were registered and stopped in reverse, so a service can build upon the ones
+registered before it, e.g. the LES server on top of the full Ethereum node.
 
 
 Resources Managed By Node
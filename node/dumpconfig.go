@@ -0,0 +1,131 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package node
+
+import (
+	"fmt"
+
+	"github.com/EarthDollar/go-earthdollar/rpc"
+)
+
+// ConfigDump is the serializable snapshot of the effective configuration of a
+// node: endpoints and paths are resolved and the bootstrap nodes are listed by
+// their enode URLs. The node key is never included.
+type ConfigDump struct {
+	Name              string `json:"name"`
+	DataDir           string `json:"dataDir"`
+	KeyStoreDir       string `json:"keyStoreDir,omitempty"`
+	UseLightweightKDF bool   `json:"useLightweightKDF"`
+
+	ListenAddr       string   `json:"listenAddr"`
+	NAT              string   `json:"nat,omitempty"`
+	NoDial           bool     `json:"noDial"`
+	NoDiscovery      bool     `json:"noDiscovery"`
+	DiscoveryV5      bool     `json:"discoveryV5"`
+	DiscoveryV5Addr  string   `json:"discoveryV5Addr,omitempty"`
+	BootstrapNodes   []string `json:"bootstrapNodes"`
+	BootstrapNodesV5 []string `json:"bootstrapNodesV5"`
+	DNSDiscovery     []string `json:"dnsDiscovery,omitempty"`
+	NetRestrict      []string `json:"netRestrict,omitempty"`
+	Proxy            bool     `json:"proxy"`
+	MaxPeers         int      `json:"maxPeers"`
+	MaxPendingPeers  int      `json:"maxPendingPeers"`
+	MaxMessageSize   uint32   `json:"maxMessageSize"`
+	MaxMessageRate   int      `json:"maxMessageRate"`
+
+	IPCEndpoint  string   `json:"ipcEndpoint"`
+	IPCModules   []string `json:"ipcModules"`
+	HTTPEndpoint string   `json:"httpEndpoint"`
+	HTTPCors     string   `json:"httpCors"`
+	HTTPModules  []string `json:"httpModules"`
+	WSEndpoint   string   `json:"wsEndpoint"`
+	WSOrigins    string   `json:"wsOrigins"`
+	WSModules    []string `json:"wsModules"`
+	AuthEndpoint string   `json:"authEndpoint"`
+	AuthModules  []string `json:"authModules"`
+	JWTSecret    string   `json:"jwtSecret,omitempty"`
+	AuditLog     string   `json:"auditLog,omitempty"`
+
+	RPCRateLimit         float64                  `json:"rpcRateLimit"`
+	RPCRateBurst         int                      `json:"rpcRateBurst"`
+	RPCQuotas            map[string]rpc.RateQuota `json:"rpcQuotas,omitempty"`
+	RPCBatchLimit        int                      `json:"rpcBatchLimit"`
+	RPCResponseSizeLimit int                      `json:"rpcResponseSizeLimit"`
+	RPCConcurrencyLimit  int                      `json:"rpcConcurrencyLimit"`
+}
+
+// Dump resolves the effective configuration a node would run with if created
+// from c.
+func (c *Config) Dump() *ConfigDump {
+	dump := &ConfigDump{
+		Name:              c.NodeName(),
+		DataDir:           c.DataDir,
+		KeyStoreDir:       c.KeyStoreDir,
+		UseLightweightKDF: c.UseLightweightKDF,
+
+		ListenAddr:       c.ListenAddr,
+		NoDial:           c.NoDial,
+		NoDiscovery:      c.NoDiscovery,
+		DiscoveryV5:      c.DiscoveryV5,
+		DiscoveryV5Addr:  c.DiscoveryV5Addr,
+		BootstrapNodes:   make([]string, 0, len(c.BootstrapNodes)),
+		BootstrapNodesV5: make([]string, 0, len(c.BootstrapNodesV5)),
+		DNSDiscovery:     c.DNSDiscovery,
+		Proxy:            c.Proxy != nil,
+		MaxPeers:         c.MaxPeers,
+		MaxPendingPeers:  c.MaxPendingPeers,
+		MaxMessageSize:   c.MaxMessageSize,
+		MaxMessageRate:   c.MaxMessageRate,
+
+		IPCEndpoint:  c.IPCEndpoint(),
+		IPCModules:   c.IPCModules,
+		HTTPEndpoint: c.HTTPEndpoint(),
+		HTTPCors:     c.HTTPCors,
+		HTTPModules:  c.HTTPModules,
+		WSEndpoint:   c.WSEndpoint(),
+		WSOrigins:    c.WSOrigins,
+		WSModules:    c.WSModules,
+		AuthEndpoint: c.AuthEndpoint(),
+		AuthModules:  c.AuthModules,
+		AuditLog:     c.AuditLog,
+
+		RPCRateLimit:         c.RPCRateLimit,
+		RPCRateBurst:         c.RPCRateBurst,
+		RPCQuotas:            c.RPCQuotas,
+		RPCBatchLimit:        c.RPCBatchLimit,
+		RPCResponseSizeLimit: c.RPCResponseSizeLimit,
+		RPCConcurrencyLimit:  c.RPCConcurrencyLimit,
+	}
+	if c.NAT != nil {
+		dump.NAT = fmt.Sprint(c.NAT)
+	}
+	for _, n := range c.BootstrapNodes {
+		dump.BootstrapNodes = append(dump.BootstrapNodes, n.String())
+	}
+	for _, n := range c.BootstrapNodesV5 {
+		dump.BootstrapNodesV5 = append(dump.BootstrapNodesV5, n.String())
+	}
+	if c.NetRestrict != nil {
+		for _, n := range *c.NetRestrict {
+			dump.NetRestrict = append(dump.NetRestrict, n.String())
+		}
+	}
+	if dump.AuthEndpoint != "" {
+		dump.JWTSecret = c.jwtSecretPath()
+	}
+	return dump
+}
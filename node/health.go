@@ -0,0 +1,99 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package node
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// ReadinessReporter is an optional interface a Service may implement to signal
+// whether it is currently fit to serve requests (e.g. it is synchronised with
+// the network). It is consulted by the /ready endpoint of the HTTP RPC server.
+type ReadinessReporter interface {
+	// Ready returns nil if the service is able to serve requests, or an error
+	// describing why it isn't.
+	Ready() error
+}
+
+// healthStatus is the JSON response of the health and readiness endpoints.
+type healthStatus struct {
+	Started bool              `json:"started"`
+	Ready   *bool             `json:"ready,omitempty"`
+	Errors  map[string]string `json:"errors,omitempty"`
+}
+
+// healthHandler wraps an HTTP handler, serving the liveness (/health) and
+// readiness (/ready) probes and passing all other requests through.
+func (n *Node) healthHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		switch r.URL.Path {
+		case "/health":
+			status := n.health(false)
+			writeHealth(w, status, status.Started)
+		case "/ready":
+			status := n.health(true)
+			writeHealth(w, status, *status.Ready)
+		default:
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// health assembles the current status of the node, optionally querying all
+// running services for their readiness.
+func (n *Node) health(readiness bool) *healthStatus {
+	n.lock.RLock()
+	defer n.lock.RUnlock()
+
+	status := &healthStatus{Started: n.server != nil}
+	if !readiness {
+		return status
+	}
+	ready := status.Started
+	if !status.Started {
+		status.Errors = map[string]string{"node": ErrNodeStopped.Error()}
+	}
+	for kind, service := range n.services {
+		if reporter, ok := service.(ReadinessReporter); ok {
+			if err := reporter.Ready(); err != nil {
+				if status.Errors == nil {
+					status.Errors = make(map[string]string)
+				}
+				status.Errors[fmt.Sprint(kind)] = err.Error()
+				ready = false
+			}
+		}
+	}
+	status.Ready = &ready
+	return status
+}
+
+// writeHealth serializes a health status into an HTTP response, signalling
+// failures via the status code so probes don't need to parse the body.
+func writeHealth(w http.ResponseWriter, status *healthStatus, ok bool) {
+	w.Header().Set("content-type", "application/json")
+	if !ok {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(status)
+}
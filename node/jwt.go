@@ -0,0 +1,161 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package node
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/EarthDollar/go-earthdollar/common"
+	"github.com/EarthDollar/go-earthdollar/logger"
+	"github.com/EarthDollar/go-earthdollar/logger/glog"
+)
+
+const (
+	jwtSecretLength = 32               // Length of the shared secret in bytes
+	jwtMaxClockSkew = 60 * time.Second // Maximum permitted age (or future age) of a token
+)
+
+var (
+	errMissingToken = errors.New("missing bearer token")
+	errMalformedJWT = errors.New("malformed token")
+	errJWTAlgorithm = errors.New("unsupported signing algorithm")
+	errJWTSignature = errors.New("invalid token signature")
+	errJWTIssuedAt  = errors.New("token issuance time out of range")
+	errJWTExpired   = errors.New("token expired")
+)
+
+// jwtHeader is the JOSE header of a token.
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ,omitempty"`
+}
+
+// jwtClaims is the set of registered claims the authenticated endpoint checks.
+type jwtClaims struct {
+	IssuedAt  *int64 `json:"iat"`
+	ExpiresAt *int64 `json:"exp,omitempty"`
+}
+
+// jwtHandler wraps an HTTP handler, rejecting all requests which don't carry a
+// valid HS256 token signed with the shared secret in their Authorization header.
+func jwtHandler(secret []byte, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "Bearer ") {
+			http.Error(w, errMissingToken.Error(), http.StatusUnauthorized)
+			return
+		}
+		if err := verifyJWT(strings.TrimPrefix(auth, "Bearer "), secret, time.Now()); err != nil {
+			glog.V(logger.Debug).Infof("Rejected authenticated RPC request from %s: %v", r.RemoteAddr, err)
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// verifyJWT checks that the token is signed with the secret using HS256, and that
+// it was issued close enough to now to prevent the replay of captured tokens.
+func verifyJWT(token string, secret []byte, now time.Time) error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return errMalformedJWT
+	}
+	var header jwtHeader
+	if err := decodeJWTSegment(parts[0], &header); err != nil {
+		return err
+	}
+	if header.Alg != "HS256" {
+		return errJWTAlgorithm
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return errMalformedJWT
+	}
+	if !hmac.Equal(sig, signJWT(parts[0]+"."+parts[1], secret)) {
+		return errJWTSignature
+	}
+	var claims jwtClaims
+	if err := decodeJWTSegment(parts[1], &claims); err != nil {
+		return err
+	}
+	if claims.IssuedAt == nil {
+		return errJWTIssuedAt
+	}
+	if skew := now.Sub(time.Unix(*claims.IssuedAt, 0)); skew > jwtMaxClockSkew || skew < -jwtMaxClockSkew {
+		return errJWTIssuedAt
+	}
+	if claims.ExpiresAt != nil && !now.Before(time.Unix(*claims.ExpiresAt, 0)) {
+		return errJWTExpired
+	}
+	return nil
+}
+
+// decodeJWTSegment decodes a base64url encoded JSON segment of a token.
+func decodeJWTSegment(segment string, v interface{}) error {
+	blob, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return errMalformedJWT
+	}
+	if err := json.Unmarshal(blob, v); err != nil {
+		return errMalformedJWT
+	}
+	return nil
+}
+
+// signJWT calculates the HS256 signature of the signing input of a token.
+func signJWT(input string, secret []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(input))
+	return mac.Sum(nil)
+}
+
+// loadJWTSecret reads the hex encoded shared secret from the given file. If the
+// file doesn't exist yet, a new random secret is generated and saved into it, so
+// it can be handed to the clients of the authenticated endpoint.
+func loadJWTSecret(path string) ([]byte, error) {
+	if blob, err := ioutil.ReadFile(path); err == nil {
+		secret := common.FromHex(strings.TrimSpace(string(blob)))
+		if len(secret) != jwtSecretLength {
+			return nil, fmt.Errorf("invalid JWT secret in %s: need %d hex encoded bytes", path, jwtSecretLength)
+		}
+		return secret, nil
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	secret := make([]byte, jwtSecretLength)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(path, []byte(hex.EncodeToString(secret)), 0600); err != nil {
+		return nil, err
+	}
+	glog.V(logger.Info).Infof("Generated JWT secret: %s", path)
+	return secret, nil
+}
@@ -0,0 +1,162 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package node
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/EarthDollar/go-earthdollar/rpc"
+)
+
+// makeJWT assembles a token from raw header and claims, signing it with secret.
+func makeJWT(header, claims string, secret []byte) string {
+	input := base64.RawURLEncoding.EncodeToString([]byte(header)) + "." + base64.RawURLEncoding.EncodeToString([]byte(claims))
+	return input + "." + base64.RawURLEncoding.EncodeToString(signJWT(input, secret))
+}
+
+func TestVerifyJWT(t *testing.T) {
+	var (
+		secret = []byte("0123456789abcdef0123456789abcdef")
+		now    = time.Unix(1500000000, 0)
+		header = `{"alg":"HS256","typ":"JWT"}`
+	)
+	tests := []struct {
+		token string
+		err   error
+	}{
+		{makeJWT(header, `{"iat":1500000000}`, secret), nil},
+		{makeJWT(header, `{"iat":1499999950}`, secret), nil},
+		{makeJWT(header, `{"iat":1500000050,"exp":1500000100}`, secret), nil},
+		{makeJWT(header, `{"iat":1499999900}`, secret), errJWTIssuedAt},
+		{makeJWT(header, `{"iat":1500000100}`, secret), errJWTIssuedAt},
+		{makeJWT(header, `{}`, secret), errJWTIssuedAt},
+		{makeJWT(header, `{"iat":1500000000,"exp":1500000000}`, secret), errJWTExpired},
+		{makeJWT(header, `{"iat":1500000000}`, []byte("other secret")), errJWTSignature},
+		{makeJWT(`{"alg":"none"}`, `{"iat":1500000000}`, secret), errJWTAlgorithm},
+		{makeJWT(`{"alg":"HS512"}`, `{"iat":1500000000}`, secret), errJWTAlgorithm},
+		{makeJWT(header, `not json`, secret), errMalformedJWT},
+		{"a.b", errMalformedJWT},
+		{"", errMalformedJWT},
+	}
+	for i, test := range tests {
+		if err := verifyJWT(test.token, secret, now); err != test.err {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, test.err)
+		}
+	}
+}
+
+func TestLoadJWTSecret(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// A missing secret file should be generated and reloaded afterwards
+	path := filepath.Join(dir, "jwtsecret")
+	secret, err := loadJWTSecret(path)
+	if err != nil {
+		t.Fatalf("failed to generate secret: %v", err)
+	}
+	if len(secret) != jwtSecretLength {
+		t.Fatalf("generated secret length mismatch: have %d, want %d", len(secret), jwtSecretLength)
+	}
+	reloaded, err := loadJWTSecret(path)
+	if err != nil {
+		t.Fatalf("failed to reload secret: %v", err)
+	}
+	if string(reloaded) != string(secret) {
+		t.Fatalf("reloaded secret mismatch: have %x, want %x", reloaded, secret)
+	}
+	// Invalid secrets should be rejected
+	ioutil.WriteFile(path, []byte("0x1234"), 0600)
+	if _, err := loadJWTSecret(path); err == nil {
+		t.Fatalf("short secret accepted")
+	}
+}
+
+// Tests that the authenticated endpoint only serves requests with valid tokens,
+// but exposes non-public APIs to them.
+func TestAuthEndpoint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatalf("failed to create temporary data directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	config := testNodeConfig()
+	config.DataDir = dir
+	config.AuthHost = "127.0.0.1"
+
+	stack, err := New(config)
+	if err != nil {
+		t.Fatalf("failed to create protocol stack: %v", err)
+	}
+	apis := []rpc.API{{Namespace: "private", Version: "1", Service: new(OneMethodApi)}}
+	constructor := func(*ServiceContext) (Service, error) {
+		return &InstrumentedService{apis: apis}, nil
+	}
+	if err := stack.Register(constructor); err != nil {
+		t.Fatalf("failed to register service: %v", err)
+	}
+	if err := stack.Start(); err != nil {
+		t.Fatalf("failed to start protocol stack: %v", err)
+	}
+	defer stack.Stop()
+
+	secret, err := loadJWTSecret(filepath.Join(dir, config.Name, "jwtsecret"))
+	if err != nil {
+		t.Fatalf("failed to load generated secret: %v", err)
+	}
+	call := func(token string) (int, string) {
+		body := `{"jsonrpc":"2.0","id":1,"method":"private_theOneMethod"}`
+		req, _ := http.NewRequest("POST", "http://"+stack.authListener.Addr().String(), strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("failed to send request: %v", err)
+		}
+		defer resp.Body.Close()
+		reply, _ := ioutil.ReadAll(resp.Body)
+		return resp.StatusCode, string(reply)
+	}
+	if code, _ := call(""); code != http.StatusUnauthorized {
+		t.Errorf("unauthenticated request status mismatch: have %d, want %d", code, http.StatusUnauthorized)
+	}
+	claims := fmt.Sprintf(`{"iat":%d}`, time.Now().Unix())
+	if code, _ := call(makeJWT(`{"alg":"HS256"}`, claims, []byte("invalid"))); code != http.StatusUnauthorized {
+		t.Errorf("forged request status mismatch: have %d, want %d", code, http.StatusUnauthorized)
+	}
+	code, reply := call(makeJWT(`{"alg":"HS256"}`, claims, secret))
+	if code != http.StatusOK {
+		t.Fatalf("authenticated request status mismatch: have %d, want %d", code, http.StatusOK)
+	}
+	if strings.Contains(reply, "error") {
+		t.Errorf("authenticated request failed: %s", reply)
+	}
+}
@@ -19,12 +19,14 @@ package node
 import (
 	"errors"
 	"net"
+	"net/http"
 	"os"
 	"path/filepath"
 	"reflect"
 	"strings"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/EarthDollar/go-earthdollar/accounts"
 	"github.com/EarthDollar/go-earthdollar/ethdb"
@@ -35,8 +37,13 @@ import (
 	"github.com/EarthDollar/go-earthdollar/p2p"
 	"github.com/EarthDollar/go-earthdollar/rpc"
 	"github.com/syndtr/goleveldb/leveldb/storage"
+	"golang.org/x/net/context"
 )
 
+// rpcDrainTimeout is the maximum time Stop waits for in-flight RPC requests to
+// finish before terminating the services they use.
+const rpcDrainTimeout = 5 * time.Second
+
 var (
 	ErrDatadirUsed    = errors.New("datadir already used")
 	ErrNodeStopped    = errors.New("node not started")
@@ -49,17 +56,22 @@ var (
 // Node is a container on which services can be registered.
 type Node struct {
 	eventmux *event.TypeMux // Event multiplexer used between the services of a stack
+	muxUsed  bool           // Whether services were already constructed on eventmux
 	config   *Config
 	accman   *accounts.Manager
+	memdbs   *memDatabases // In-memory databases of an ephemeral node, kept across restarts
 
 	ephemeralKeystore string          // if non-empty, the key directory that will be removed by Stop
 	instanceDirLock   storage.Storage // prevents concurrent use of instance directory
 
 	serverConfig p2p.Config
 	server       *p2p.Server // Currently running P2P networking layer
+	nodeLists    *nodeLists  // Static and trusted node lists kept in sync with their files
 
 	serviceFuncs []ServiceConstructor     // Service constructors (in dependency order)
 	services     map[reflect.Type]Service // Currently running services
+	serviceKinds []reflect.Type           // Types of the running services in start order
+	suspended    []ResumableService       // Stopped services to resume on the next start, by constructor index
 
 	rpcAPIs       []rpc.API   // List of APIs currently provided by the node
 	inprocHandler *rpc.Server // In-process RPC request handler to process the API requests
@@ -68,15 +80,27 @@ type Node struct {
 	ipcListener net.Listener // IPC RPC listener socket to serve API requests
 	ipcHandler  *rpc.Server  // IPC RPC request handler to process the API requests
 
-	httpEndpoint  string       // HTTP endpoint (interface + port) to listen at (empty = HTTP disabled)
-	httpWhitelist []string     // HTTP RPC modules to allow through this endpoint
-	httpListener  net.Listener // HTTP RPC listener socket to server API requests
-	httpHandler   *rpc.Server  // HTTP RPC request handler to process the API requests
+	httpEndpoint string       // HTTP endpoint (interface + port) to listen at (empty = HTTP disabled)
+	httpModules  []string     // HTTP RPC modules to allow through this endpoint
+	httpCors     string       // HTTP RPC Cross-Origin Resource Sharing header
+	httpListener net.Listener // HTTP RPC listener socket to server API requests
+	httpHandler  *rpc.Server  // HTTP RPC request handler to process the API requests
 
 	wsEndpoint string       // Websocket endpoint (interface + port) to listen at (empty = websocket disabled)
+	wsModules  []string     // Websocket RPC modules to allow through this endpoint
+	wsOrigins  string       // Websocket RPC origins to accept requests from
 	wsListener net.Listener // Websocket RPC listener socket to server API requests
 	wsHandler  *rpc.Server  // Websocket RPC request handler to process the API requests
 
+	authEndpoint string       // Authenticated HTTP endpoint to listen at (empty = authenticated RPC disabled)
+	authListener net.Listener // Authenticated HTTP RPC listener socket to serve API requests
+	authHandler  *rpc.Server  // Authenticated HTTP RPC request handler to process the API requests
+
+	auditFile *os.File    // File backing the RPC audit log (nil = auditing disabled)
+	auditor   rpc.Auditor // Auditor recording sensitive calls on all RPC endpoints
+
+	limiter *rpc.RateLimiter // Limiter of the client call rates on the public RPC endpoints (nil = unlimited)
+
 	stop chan struct{} // Channel to wait for termination notifications
 	lock sync.RWMutex
 }
@@ -111,6 +135,14 @@ func New(conf *Config) (*Node, error) {
 	if err != nil {
 		return nil, err
 	}
+	// Create the rate limiter shared by the public RPC endpoints
+	var limiter *rpc.RateLimiter
+	if conf.RPCRateLimit > 0 || len(conf.RPCQuotas) > 0 {
+		limiter = rpc.NewRateLimiter(conf.RPCRateLimit, conf.RPCRateBurst)
+		for client, quota := range conf.RPCQuotas {
+			limiter.SetQuota(client, quota)
+		}
+	}
 	// Note: any interaction with Config that would create/touch files
 	// in the data directory or instance directory is delayed until Start.
 	return &Node{
@@ -120,13 +152,23 @@ func New(conf *Config) (*Node, error) {
 		serviceFuncs:      []ServiceConstructor{},
 		ipcEndpoint:       conf.IPCEndpoint(),
 		httpEndpoint:      conf.HTTPEndpoint(),
+		httpModules:       conf.HTTPModules,
+		httpCors:          conf.HTTPCors,
 		wsEndpoint:        conf.WSEndpoint(),
+		wsModules:         conf.WSModules,
+		wsOrigins:         conf.WSOrigins,
+		authEndpoint:      conf.AuthEndpoint(),
+		limiter:           limiter,
 		eventmux:          new(event.TypeMux),
+		memdbs:            newMemDatabases(),
 	}, nil
 }
 
 // Register injects a new service into the node's stack. The service created by
 // the passed constructor must be unique in its type with regard to sibling ones.
+//
+// Services are constructed and started in registration order and stopped in
+// reverse, so a service may look up and build on the ones registered before it.
 func (n *Node) Register(constructor ServiceConstructor) error {
 	n.lock.Lock()
 	defer n.lock.Unlock()
@@ -151,6 +193,12 @@ func (n *Node) Start() error {
 		return err
 	}
 
+	// Services stop the event mux on termination, hand a fresh one to every run
+	if n.muxUsed {
+		n.eventmux = new(event.TypeMux)
+	}
+	n.muxUsed = true
+
 	// Initialize the p2p server. This creates the node key and
 	// discovery databases.
 	n.serverConfig = p2p.Config{
@@ -161,6 +209,7 @@ func (n *Node) Start() error {
 		DiscoveryV5Addr:  n.config.DiscoveryV5Addr,
 		BootstrapNodes:   n.config.BootstrapNodes,
 		BootstrapNodesV5: n.config.BootstrapNodesV5,
+		DNSDiscovery:     n.config.DNSDiscovery,
 		StaticNodes:      n.config.StaticNodes(),
 		TrustedNodes:     n.config.TrusterNodes(),
 		NodeDatabase:     n.config.NodeDB(),
@@ -168,28 +217,44 @@ func (n *Node) Start() error {
 		NetRestrict:      n.config.NetRestrict,
 		NAT:              n.config.NAT,
 		Dialer:           n.config.Dialer,
+		Proxy:            n.config.Proxy,
 		NoDial:           n.config.NoDial,
 		MaxPeers:         n.config.MaxPeers,
 		MaxPendingPeers:  n.config.MaxPendingPeers,
+		MaxMessageSize:   n.config.MaxMessageSize,
+		MaxMessageRate:   n.config.MaxMessageRate,
+		EventMux:         n.eventmux,
 	}
 	running := &p2p.Server{Config: n.serverConfig}
 	glog.V(logger.Info).Infoln("instance:", n.serverConfig.Name)
 
 	// Otherwise copy and specialize the P2P configuration
 	services := make(map[reflect.Type]Service)
-	for _, constructor := range n.serviceFuncs {
+	kinds := make([]reflect.Type, 0, len(n.serviceFuncs))
+	for i, constructor := range n.serviceFuncs {
 		// Create a new context for the particular service
 		ctx := &ServiceContext{
 			config:         n.config,
 			services:       make(map[reflect.Type]Service),
 			EventMux:       n.eventmux,
 			AccountManager: n.accman,
+			memdbs:         n.memdbs,
 		}
 		for kind, s := range services { // copy needed for threaded access
 			ctx.services[kind] = s
 		}
-		// Construct and save the service
-		service, err := constructor(ctx)
+		// Resume the instance of the previous run if possible, construct otherwise
+		var (
+			service Service
+			err     error
+		)
+		if i < len(n.suspended) && n.suspended[i] != nil {
+			resumed := n.suspended[i]
+			n.suspended[i] = nil
+			service, err = resumed, resumed.Resume(ctx)
+		} else {
+			service, err = constructor(ctx)
+		}
 		if err != nil {
 			return err
 		}
@@ -198,10 +263,11 @@ func (n *Node) Start() error {
 			return &DuplicateServiceError{Kind: kind}
 		}
 		services[kind] = service
+		kinds = append(kinds, kind)
 	}
 	// Gather the protocols and start the freshly assembled P2P server
-	for _, service := range services {
-		running.Protocols = append(running.Protocols, service.Protocols()...)
+	for _, kind := range kinds {
+		running.Protocols = append(running.Protocols, services[kind].Protocols()...)
 	}
 	if err := running.Start(); err != nil {
 		if errno, ok := err.(syscall.Errno); ok && datadirInUseErrnos[uint(errno)] {
@@ -210,33 +276,38 @@ func (n *Node) Start() error {
 		return err
 	}
 	// Start each of the services
-	started := []reflect.Type{}
-	for kind, service := range services {
+	for i, kind := range kinds {
 		// Start the next service, stopping all previous upon failure
-		if err := service.Start(running); err != nil {
-			for _, kind := range started {
-				services[kind].Stop()
-			}
+		if err := services[kind].Start(running); err != nil {
+			stopServices(services, kinds[:i])
 			running.Stop()
 
 			return err
 		}
-		// Mark the service started for potential cleanup
-		started = append(started, kind)
 	}
 	// Lastly start the configured RPC interfaces
+	if err := n.openAuditLog(); err != nil {
+		stopServices(services, kinds)
+		running.Stop()
+		return err
+	}
 	if err := n.startRPC(services); err != nil {
-		for _, service := range services {
-			service.Stop()
-		}
+		stopServices(services, kinds)
 		running.Stop()
+		n.closeAuditLog()
 		return err
 	}
 	// Finish initializing the startup
 	n.services = services
+	n.serviceKinds = kinds
 	n.server = running
 	n.stop = make(chan struct{})
 
+	if n.config.DataDir != "" {
+		n.nodeLists = newNodeLists(n.config, running)
+		go n.nodeLists.loop(n.stop)
+	}
+
 	return nil
 }
 
@@ -260,6 +331,56 @@ func (n *Node) openDataDir() error {
 	return nil
 }
 
+// openAuditLog opens the configured RPC audit log for appending.
+func (n *Node) openAuditLog() error {
+	if n.config.AuditLog == "" {
+		return nil
+	}
+	path := n.config.resolvePath(n.config.AuditLog)
+	if path == "" {
+		return errors.New("relative audit log path requires a data directory")
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	n.auditFile = file
+	n.auditor = rpc.NewAuditLog(file)
+	glog.V(logger.Info).Infof("RPC audit log opened: %s", path)
+	return nil
+}
+
+// closeAuditLog closes the RPC audit log, if any.
+func (n *Node) closeAuditLog() {
+	if n.auditFile != nil {
+		n.auditFile.Close()
+		n.auditFile, n.auditor = nil, nil
+	}
+}
+
+// newRPCServer creates an RPC request handler wired up to the node-wide
+// facilities shared by all endpoints.
+func (n *Node) newRPCServer() *rpc.Server {
+	handler := rpc.NewServer()
+	if n.auditor != nil {
+		handler.SetAuditor(n.auditor)
+	}
+	return handler
+}
+
+// newPublicRPCServer creates an RPC request handler for the network endpoints
+// exposed to untrusted clients, additionally limiting their call rates and the
+// resources their requests may consume.
+func (n *Node) newPublicRPCServer() *rpc.Server {
+	handler := n.newRPCServer()
+	if n.limiter != nil {
+		handler.SetRateLimiter(n.limiter)
+	}
+	handler.SetBatchLimits(n.config.RPCBatchLimit, n.config.RPCResponseSizeLimit)
+	handler.SetConcurrencyLimit(n.config.RPCConcurrencyLimit)
+	return handler
+}
+
 // startRPC is a helper method to start all the various RPC endpoint during node
 // startup. It's not meant to be called at any time afterwards as it makes certain
 // assumptions about the state of the node.
@@ -269,11 +390,17 @@ func (n *Node) startRPC(services map[reflect.Type]Service) error {
 	for _, service := range services {
 		apis = append(apis, service.APIs()...)
 	}
+	// Warn about whitelisted modules no service provides, most probably typos
+	for transport, modules := range map[string][]string{"IPC": n.config.IPCModules, "HTTP": n.config.HTTPModules, "WebSocket": n.config.WSModules, "Authenticated HTTP": n.config.AuthModules} {
+		if unknown := unavailableModules(modules, apis); len(unknown) > 0 {
+			glog.V(logger.Warn).Infof("%s whitelists unavailable modules: %v", transport, unknown)
+		}
+	}
 	// Start the various API endpoints, terminating all in case of errors
 	if err := n.startInProc(apis); err != nil {
 		return err
 	}
-	if err := n.startIPC(apis); err != nil {
+	if err := n.startIPC(apis, n.config.IPCModules); err != nil {
 		n.stopInProc()
 		return err
 	}
@@ -288,6 +415,13 @@ func (n *Node) startRPC(services map[reflect.Type]Service) error {
 		n.stopInProc()
 		return err
 	}
+	if err := n.startAuth(n.authEndpoint, apis, n.config.AuthModules); err != nil {
+		n.stopWS()
+		n.stopHTTP()
+		n.stopIPC()
+		n.stopInProc()
+		return err
+	}
 	// All API endpoints started successfully
 	n.rpcAPIs = apis
 	return nil
@@ -296,7 +430,7 @@ func (n *Node) startRPC(services map[reflect.Type]Service) error {
 // startInProc initializes an in-process RPC endpoint.
 func (n *Node) startInProc(apis []rpc.API) error {
 	// Register all the APIs exposed by the services
-	handler := rpc.NewServer()
+	handler := n.newRPCServer()
 	for _, api := range apis {
 		if err := handler.RegisterName(api.Namespace, api.Service); err != nil {
 			return err
@@ -315,19 +449,27 @@ func (n *Node) stopInProc() {
 	}
 }
 
-// startIPC initializes and starts the IPC RPC endpoint.
-func (n *Node) startIPC(apis []rpc.API) error {
+// startIPC initializes and starts the IPC RPC endpoint. Contrary to the network
+// based endpoints, an empty module list exposes all APIs, not only public ones.
+func (n *Node) startIPC(apis []rpc.API, modules []string) error {
 	// Short circuit if the IPC endpoint isn't being exposed
 	if n.ipcEndpoint == "" {
 		return nil
 	}
+	// Generate the whitelist based on the allowed modules
+	whitelist := make(map[string]bool)
+	for _, module := range modules {
+		whitelist[module] = true
+	}
 	// Register all the APIs exposed by the services
-	handler := rpc.NewServer()
+	handler := n.newRPCServer()
 	for _, api := range apis {
-		if err := handler.RegisterName(api.Namespace, api.Service); err != nil {
-			return err
+		if whitelist[api.Namespace] || len(whitelist) == 0 {
+			if err := handler.RegisterName(api.Namespace, api.Service); err != nil {
+				return err
+			}
+			glog.V(logger.Debug).Infof("IPC registered %T under '%s'", api.Service, api.Namespace)
 		}
-		glog.V(logger.Debug).Infof("IPC registered %T under '%s'", api.Service, api.Namespace)
 	}
 	// All APIs registered, start the IPC listener
 	var (
@@ -390,7 +532,7 @@ func (n *Node) startHTTP(endpoint string, apis []rpc.API, modules []string, cors
 		whitelist[module] = true
 	}
 	// Register all the APIs exposed by the services
-	handler := rpc.NewServer()
+	handler := n.newPublicRPCServer()
 	for _, api := range apis {
 		if whitelist[api.Namespace] || (len(whitelist) == 0 && api.Public) {
 			if err := handler.RegisterName(api.Namespace, api.Service); err != nil {
@@ -407,11 +549,15 @@ func (n *Node) startHTTP(endpoint string, apis []rpc.API, modules []string, cors
 	if listener, err = net.Listen("tcp", endpoint); err != nil {
 		return err
 	}
-	go rpc.NewHTTPServer(cors, handler).Serve(listener)
+	server := rpc.NewHTTPServer(cors, handler)
+	server.Handler = n.healthHandler(server.Handler)
+	go server.Serve(listener)
 	glog.V(logger.Info).Infof("HTTP endpoint opened: http://%s", endpoint)
 
 	// All listeners booted successfully
 	n.httpEndpoint = endpoint
+	n.httpModules = modules
+	n.httpCors = cors
 	n.httpListener = listener
 	n.httpHandler = handler
 
@@ -432,6 +578,26 @@ func (n *Node) stopHTTP() {
 	}
 }
 
+// restartHTTP stops the HTTP RPC endpoint if it's running and starts it anew on
+// the given settings. If that fails, the endpoint is restored on its previous
+// settings. The node lock must be held.
+func (n *Node) restartHTTP(endpoint string, modules []string, cors string) error {
+	running := n.httpHandler != nil
+	prevEndpoint, prevModules, prevCors := n.httpEndpoint, n.httpModules, n.httpCors
+	if running {
+		n.stopHTTP()
+	}
+	if err := n.startHTTP(endpoint, n.rpcAPIs, modules, cors); err != nil {
+		if running {
+			if err := n.startHTTP(prevEndpoint, n.rpcAPIs, prevModules, prevCors); err != nil {
+				glog.V(logger.Error).Infof("Failed to restore HTTP endpoint: %v", err)
+			}
+		}
+		return err
+	}
+	return nil
+}
+
 // startWS initializes and starts the websocket RPC endpoint.
 func (n *Node) startWS(endpoint string, apis []rpc.API, modules []string, wsOrigins string) error {
 	// Short circuit if the WS endpoint isn't being exposed
@@ -444,7 +610,7 @@ func (n *Node) startWS(endpoint string, apis []rpc.API, modules []string, wsOrig
 		whitelist[module] = true
 	}
 	// Register all the APIs exposed by the services
-	handler := rpc.NewServer()
+	handler := n.newPublicRPCServer()
 	for _, api := range apis {
 		if whitelist[api.Namespace] || (len(whitelist) == 0 && api.Public) {
 			if err := handler.RegisterName(api.Namespace, api.Service); err != nil {
@@ -466,6 +632,8 @@ func (n *Node) startWS(endpoint string, apis []rpc.API, modules []string, wsOrig
 
 	// All listeners booted successfully
 	n.wsEndpoint = endpoint
+	n.wsModules = modules
+	n.wsOrigins = wsOrigins
 	n.wsListener = listener
 	n.wsHandler = handler
 
@@ -486,6 +654,104 @@ func (n *Node) stopWS() {
 	}
 }
 
+// restartWS stops the websocket RPC endpoint if it's running and starts it anew
+// on the given settings. If that fails, the endpoint is restored on its previous
+// settings. The node lock must be held.
+func (n *Node) restartWS(endpoint string, modules []string, wsOrigins string) error {
+	running := n.wsHandler != nil
+	prevEndpoint, prevModules, prevOrigins := n.wsEndpoint, n.wsModules, n.wsOrigins
+	if running {
+		n.stopWS()
+	}
+	if err := n.startWS(endpoint, n.rpcAPIs, modules, wsOrigins); err != nil {
+		if running {
+			if err := n.startWS(prevEndpoint, n.rpcAPIs, prevModules, prevOrigins); err != nil {
+				glog.V(logger.Error).Infof("Failed to restore WebSocket endpoint: %v", err)
+			}
+		}
+		return err
+	}
+	return nil
+}
+
+// startAuth initializes and starts the JWT authenticated HTTP RPC endpoint. As
+// only holders of the shared secret may access it, an empty module list exposes
+// all APIs, not only public ones.
+func (n *Node) startAuth(endpoint string, apis []rpc.API, modules []string) error {
+	// Short circuit if the authenticated endpoint isn't being exposed
+	if endpoint == "" {
+		return nil
+	}
+	// Load the shared secret the tokens are verified with
+	path := n.config.jwtSecretPath()
+	if path == "" {
+		return errors.New("relative JWT secret path requires a data directory")
+	}
+	secret, err := loadJWTSecret(path)
+	if err != nil {
+		return err
+	}
+	// Generate the whitelist based on the allowed modules
+	whitelist := make(map[string]bool)
+	for _, module := range modules {
+		whitelist[module] = true
+	}
+	// Register all the APIs exposed by the services
+	handler := n.newRPCServer()
+	for _, api := range apis {
+		if whitelist[api.Namespace] || len(whitelist) == 0 {
+			if err := handler.RegisterName(api.Namespace, api.Service); err != nil {
+				return err
+			}
+			glog.V(logger.Debug).Infof("Authenticated HTTP registered %T under '%s'", api.Service, api.Namespace)
+		}
+	}
+	// All APIs registered, start the HTTP listener
+	listener, err := net.Listen("tcp", endpoint)
+	if err != nil {
+		return err
+	}
+	go (&http.Server{Handler: jwtHandler(secret, handler)}).Serve(listener)
+	glog.V(logger.Info).Infof("Authenticated HTTP endpoint opened: http://%s", endpoint)
+
+	// All listeners booted successfully
+	n.authEndpoint = endpoint
+	n.authListener = listener
+	n.authHandler = handler
+
+	return nil
+}
+
+// stopAuth terminates the authenticated HTTP RPC endpoint.
+func (n *Node) stopAuth() {
+	if n.authListener != nil {
+		n.authListener.Close()
+		n.authListener = nil
+
+		glog.V(logger.Info).Infof("Authenticated HTTP endpoint closed: http://%s", n.authEndpoint)
+	}
+	if n.authHandler != nil {
+		n.authHandler.Stop()
+		n.authHandler = nil
+	}
+}
+
+// unavailableModules returns the whitelisted modules which aren't provided by
+// any of the available APIs. The built-in rpc module is always available.
+func unavailableModules(modules []string, apis []rpc.API) []string {
+	available := map[string]bool{"rpc": true}
+	for _, api := range apis {
+		available[api.Namespace] = true
+	}
+	var missing []string
+	for _, module := range modules {
+		if module != "" && !available[module] {
+			missing = append(missing, module)
+		}
+	}
+	return missing
+}
+
 // Stop terminates a running node along with all it's services. In the node was
 // not started, an error is returned.
 func (n *Node) Stop() error {
@@ -498,21 +764,29 @@ func (n *Node) Stop() error {
 	}
 
 	// Terminate the API, services and the p2p server.
+	handlers := []*rpc.Server{n.inprocHandler, n.ipcHandler, n.httpHandler, n.wsHandler, n.authHandler}
+	n.stopAuth()
 	n.stopWS()
 	n.stopHTTP()
 	n.stopIPC()
 	n.rpcAPIs = nil
+	waitRPC(handlers, rpcDrainTimeout)
+	n.closeAuditLog()
 	failure := &StopError{
-		Services: make(map[reflect.Type]error),
+		Services: stopServices(n.services, n.serviceKinds),
 	}
-	for kind, service := range n.services {
-		if err := service.Stop(); err != nil {
-			failure.Services[kind] = err
+	// Keep the cleanly stopped resumable services around for the next start
+	n.suspended = make([]ResumableService, len(n.serviceKinds))
+	for i, kind := range n.serviceKinds {
+		if service, ok := n.services[kind].(ResumableService); ok && failure.Services[kind] == nil {
+			n.suspended[i] = service
 		}
 	}
 	n.server.Stop()
 	n.services = nil
+	n.serviceKinds = nil
 	n.server = nil
+	n.nodeLists = nil
 
 	// Release instance directory lock.
 	if n.instanceDirLock != nil {
@@ -538,6 +812,52 @@ func (n *Node) Stop() error {
 	return nil
 }
 
+// stopServices terminates the given services in reverse start order, returning
+// the errors of those failing to stop cleanly.
+func stopServices(services map[reflect.Type]Service, kinds []reflect.Type) map[reflect.Type]error {
+	failures := make(map[reflect.Type]error)
+	for i := len(kinds) - 1; i >= 0; i-- {
+		if err := services[kinds[i]].Stop(); err != nil {
+			failures[kinds[i]] = err
+		}
+	}
+	return failures
+}
+
+// waitRPC waits for the requests still executing on the given handlers to finish,
+// giving up after the timeout.
+func waitRPC(handlers []*rpc.Server, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	for _, handler := range handlers {
+		if handler == nil {
+			continue
+		}
+		if err := handler.WaitPending(ctx); err != nil {
+			glog.V(logger.Warn).Infof("In-flight RPC requests still running after %v", timeout)
+			return
+		}
+	}
+}
+
+// ReloadNodeLists re-reads the static and trusted node lists from the data
+// directory and applies any changes to the running server. The lists are also
+// reloaded automatically when their files are modified.
+func (n *Node) ReloadNodeLists() error {
+	n.lock.RLock()
+	defer n.lock.RUnlock()
+
+	if n.server == nil {
+		return ErrNodeStopped
+	}
+	if n.nodeLists == nil {
+		return errors.New("node lists require a data directory")
+	}
+	n.nodeLists.reload(true)
+	return nil
+}
+
 // Wait blocks the thread until the node is stopped. If the node is not running
 // at the time of invocation, the method immediately returns.
 func (n *Node) Wait() {
@@ -633,9 +953,18 @@ func (n *Node) WSEndpoint() string {
 	return n.wsEndpoint
 }
 
+// AuthEndpoint retrieves the current authenticated HTTP endpoint used by the
+// protocol stack.
+func (n *Node) AuthEndpoint() string {
+	return n.authEndpoint
+}
+
 // EventMux retrieves the event multiplexer used by all the network services in
 // the current protocol stack.
 func (n *Node) EventMux() *event.TypeMux {
+	n.lock.RLock()
+	defer n.lock.RUnlock()
+
 	return n.eventmux
 }
 
@@ -644,7 +973,7 @@ func (n *Node) EventMux() *event.TypeMux {
 // ephemeral, a memory database is returned.
 func (n *Node) OpenDatabase(name string, cache, handles int) (ethdb.Database, error) {
 	if n.config.DataDir == "" {
-		return ethdb.NewMemDatabase()
+		return n.memdbs.open(name)
 	}
 	return ethdb.NewLDBDatabase(n.config.resolvePath(name), cache, handles)
 }
@@ -19,7 +19,10 @@ package node
 import (
 	"errors"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 	"time"
@@ -232,6 +235,56 @@ func TestServiceRestarts(t *testing.T) {
 	}
 }
 
+// Tests that resumable services are resumed across restarts instead of being
+// constructed anew, unless they failed to stop cleanly.
+func TestServiceResumption(t *testing.T) {
+	stack, err := New(testNodeConfig())
+	if err != nil {
+		t.Fatalf("failed to create protocol stack: %v", err)
+	}
+	var (
+		constructed, resumed, fresh int
+		service                     *ResumingService
+	)
+	if err := stack.Register(func(*ServiceContext) (Service, error) {
+		constructed++
+		service = &ResumingService{resumeHook: func(*ServiceContext) { resumed++ }}
+		return service, nil
+	}); err != nil {
+		t.Fatalf("failed to register resumable service: %v", err)
+	}
+	if err := stack.Register(func(*ServiceContext) (Service, error) {
+		fresh++
+		return new(NoopService), nil
+	}); err != nil {
+		t.Fatalf("failed to register plain service: %v", err)
+	}
+	if err := stack.Start(); err != nil {
+		t.Fatalf("failed to start protocol stack: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := stack.Restart(); err != nil {
+			t.Fatalf("iter %d: failed to restart stack: %v", i, err)
+		}
+	}
+	if constructed != 1 || resumed != 3 || fresh != 4 {
+		t.Fatalf("constructed/resumed/fresh mismatch: have %d/%d/%d, want 1/3/4", constructed, resumed, fresh)
+	}
+	// Fail the termination and ensure the service is constructed anew
+	service.stop = errors.New("stop failure")
+	if err := stack.Stop(); err == nil {
+		t.Fatalf("stop succeeded despite the service failure")
+	}
+	if err := stack.Start(); err != nil {
+		t.Fatalf("failed to start protocol stack: %v", err)
+	}
+	defer stack.Stop()
+
+	if constructed != 2 || resumed != 3 {
+		t.Fatalf("constructed/resumed mismatch: have %d/%d, want 2/3", constructed, resumed)
+	}
+}
+
 // Tests that if a service fails to initialize itself, none of the other services
 // will be allowed to even start.
 func TestServiceConstructionAbortion(t *testing.T) {
@@ -332,6 +385,43 @@ func TestServiceStartupAbortion(t *testing.T) {
 	}
 }
 
+// Tests that services are started in registration order and stopped in reverse.
+func TestServiceOrder(t *testing.T) {
+	stack, err := New(testNodeConfig())
+	if err != nil {
+		t.Fatalf("failed to create protocol stack: %v", err)
+	}
+	var events []string
+	for _, entry := range []struct {
+		id    string
+		maker InstrumentingWrapper
+	}{{"A", InstrumentedServiceMakerA}, {"B", InstrumentedServiceMakerB}, {"C", InstrumentedServiceMakerC}} {
+		id := entry.id // Closure for the constructor
+		constructor := func(*ServiceContext) (Service, error) {
+			return &InstrumentedService{
+				startHook: func(*p2p.Server) { events = append(events, "start "+id) },
+				stopHook:  func() { events = append(events, "stop "+id) },
+			}, nil
+		}
+		if err := stack.Register(entry.maker(constructor)); err != nil {
+			t.Fatalf("service %s: registration failed: %v", id, err)
+		}
+	}
+	for i := 0; i < 10; i++ {
+		events = nil
+		if err := stack.Start(); err != nil {
+			t.Fatalf("iter %d: failed to start protocol stack: %v", i, err)
+		}
+		if err := stack.Stop(); err != nil {
+			t.Fatalf("iter %d: failed to stop protocol stack: %v", i, err)
+		}
+		want := []string{"start A", "start B", "start C", "stop C", "stop B", "stop A"}
+		if !reflect.DeepEqual(events, want) {
+			t.Fatalf("iter %d: lifecycle order mismatch: have %v, want %v", i, events, want)
+		}
+	}
+}
+
 // Tests that even if a registered service fails to shut down cleanly, it does
 // not influece the rest of the shutdown invocations.
 func TestServiceTerminationGuarantee(t *testing.T) {
@@ -573,3 +663,167 @@ func TestAPIGather(t *testing.T) {
 		}
 	}
 }
+
+// Tests that the health and readiness probes reflect the node and service state.
+func TestHealthProbes(t *testing.T) {
+	stack, err := New(testNodeConfig())
+	if err != nil {
+		t.Fatalf("failed to create protocol stack: %v", err)
+	}
+	service := new(ReadyService)
+	if err := stack.Register(func(*ServiceContext) (Service, error) { return service, nil }); err != nil {
+		t.Fatalf("failed to register service: %v", err)
+	}
+	rpcCalled := false
+	handler := stack.healthHandler(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { rpcCalled = true }))
+
+	probe := func(path string) int {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		return rec.Code
+	}
+	if code := probe("/health"); code != http.StatusServiceUnavailable {
+		t.Errorf("stopped node health: have %d, want %d", code, http.StatusServiceUnavailable)
+	}
+	if err := stack.Start(); err != nil {
+		t.Fatalf("failed to start node: %v", err)
+	}
+	defer stack.Stop()
+
+	if code := probe("/health"); code != http.StatusOK {
+		t.Errorf("started node health: have %d, want %d", code, http.StatusOK)
+	}
+	if code := probe("/ready"); code != http.StatusOK {
+		t.Errorf("ready service readiness: have %d, want %d", code, http.StatusOK)
+	}
+	service.ready = errors.New("syncing")
+	if code := probe("/ready"); code != http.StatusServiceUnavailable {
+		t.Errorf("unready service readiness: have %d, want %d", code, http.StatusServiceUnavailable)
+	}
+	if probe("/"); !rpcCalled {
+		t.Errorf("non-probe request not passed through")
+	}
+}
+
+// Tests that the IPC endpoint exposes all APIs by default, but only the
+// whitelisted ones if a module list is configured.
+func TestIPCModuleWhitelist(t *testing.T) {
+	apis := []rpc.API{
+		{Namespace: "public", Version: "1", Service: new(OneMethodApi), Public: true},
+		{Namespace: "private", Version: "1", Service: new(OneMethodApi)},
+	}
+	tests := []struct {
+		modules []string
+		public  bool
+		private bool
+	}{
+		{nil, true, true},
+		{[]string{"private"}, false, true},
+		{[]string{"public", "unknown"}, true, false},
+	}
+	for i, test := range tests {
+		dir, err := ioutil.TempDir("", "")
+		if err != nil {
+			t.Fatalf("failed to create temporary data directory: %v", err)
+		}
+		defer os.RemoveAll(dir)
+
+		config := testNodeConfig()
+		config.IPCPath = filepath.Join(dir, "test.ipc")
+		config.IPCModules = test.modules
+
+		stack, err := New(config)
+		if err != nil {
+			t.Fatalf("test %d: failed to create protocol stack: %v", i, err)
+		}
+		constructor := func(*ServiceContext) (Service, error) {
+			return &InstrumentedService{apis: apis}, nil
+		}
+		if err := stack.Register(constructor); err != nil {
+			t.Fatalf("test %d: failed to register service: %v", i, err)
+		}
+		if err := stack.Start(); err != nil {
+			t.Fatalf("test %d: failed to start protocol stack: %v", i, err)
+		}
+		client, err := rpc.Dial(config.IPCPath)
+		if err != nil {
+			t.Fatalf("test %d: failed to connect to the IPC API server: %v", i, err)
+		}
+		if err := client.Call(nil, "public_theOneMethod"); (err == nil) != test.public {
+			t.Errorf("test %d: public API availability mismatch: have %v, want %v", i, err == nil, test.public)
+		}
+		if err := client.Call(nil, "private_theOneMethod"); (err == nil) != test.private {
+			t.Errorf("test %d: private API availability mismatch: have %v, want %v", i, err == nil, test.private)
+		}
+		client.Close()
+		stack.Stop()
+	}
+}
+
+// Tests that the HTTP endpoint can be started, reconfigured and stopped through
+// the admin API while the node is running.
+func TestAdminHTTPRestart(t *testing.T) {
+	stack, err := New(testNodeConfig())
+	if err != nil {
+		t.Fatalf("failed to create protocol stack: %v", err)
+	}
+	apis := []rpc.API{
+		{Namespace: "single", Version: "1", Service: new(OneMethodApi), Public: true},
+		{Namespace: "private", Version: "1", Service: new(OneMethodApi)},
+	}
+	constructor := func(*ServiceContext) (Service, error) {
+		return &InstrumentedService{apis: apis}, nil
+	}
+	if err := stack.Register(constructor); err != nil {
+		t.Fatalf("failed to register service: %v", err)
+	}
+	if err := stack.Start(); err != nil {
+		t.Fatalf("failed to start protocol stack: %v", err)
+	}
+	defer stack.Stop()
+
+	api := NewPrivateAdminAPI(stack)
+	call := func(method string) error {
+		client, err := rpc.DialHTTP("http://" + stack.httpListener.Addr().String())
+		if err != nil {
+			t.Fatalf("failed to connect to the HTTP API server: %v", err)
+		}
+		defer client.Close()
+		return client.Call(nil, method)
+	}
+	host, port := "127.0.0.1", 0
+	if _, err := api.StartHTTP(&host, &port, nil, nil); err != nil {
+		t.Fatalf("failed to start HTTP endpoint: %v", err)
+	}
+	if err := call("single_theOneMethod"); err != nil {
+		t.Errorf("public API unavailable: %v", err)
+	}
+	if err := call("private_theOneMethod"); err == nil {
+		t.Errorf("private API exposed by default")
+	}
+	// Restarting with a new module list should expose the requested modules
+	modules := "private"
+	if _, err := api.StartHTTP(nil, nil, nil, &modules); err != nil {
+		t.Fatalf("failed to restart HTTP endpoint: %v", err)
+	}
+	if err := call("private_theOneMethod"); err != nil {
+		t.Errorf("whitelisted API unavailable after restart: %v", err)
+	}
+	if err := call("single_theOneMethod"); err == nil {
+		t.Errorf("non-whitelisted API exposed after restart")
+	}
+	// Failing restarts should keep the endpoint running on its previous settings
+	invalid := "invalid host"
+	if _, err := api.StartHTTP(&invalid, nil, nil, nil); err == nil {
+		t.Fatalf("restart on invalid host succeeded")
+	}
+	if err := call("private_theOneMethod"); err != nil {
+		t.Errorf("endpoint not restored after failed restart: %v", err)
+	}
+	if _, err := api.StopHTTP(); err != nil {
+		t.Fatalf("failed to stop HTTP endpoint: %v", err)
+	}
+	if _, err := api.StopHTTP(); err == nil {
+		t.Fatalf("stopping stopped HTTP endpoint succeeded")
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright 2015 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package node
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"github.com/EarthDollar/go-earthdollar/logger"
+	"github.com/EarthDollar/go-earthdollar/logger/glog"
+	"github.com/EarthDollar/go-earthdollar/p2p"
+	"github.com/EarthDollar/go-earthdollar/p2p/discover"
+)
+
+// nodeListRefresh is the interval at which the static and trusted node lists
+// are checked for modifications.
+const nodeListRefresh = 5 * time.Second
+
+// nodeList tracks a node list file within the data directory, together with
+// the nodes last applied from it to the running server.
+type nodeList struct {
+	path   string
+	mod    time.Time
+	nodes  map[discover.NodeID]*discover.Node
+	add    func(*discover.Node)
+	remove func(*discover.Node)
+}
+
+// nodeLists keeps the static and trusted node lists of a running server in
+// sync with their files.
+type nodeLists struct {
+	lock    sync.Mutex
+	config  *Config
+	static  *nodeList
+	trusted *nodeList
+}
+
+// newNodeLists creates the file trackers of the static and trusted node lists
+// for a server started with the given nodes.
+func newNodeLists(config *Config, server *p2p.Server) *nodeLists {
+	newList := func(file string, nodes []*discover.Node, add, remove func(*discover.Node)) *nodeList {
+		l := &nodeList{
+			path:   config.resolvePath(file),
+			nodes:  make(map[discover.NodeID]*discover.Node),
+			add:    add,
+			remove: remove,
+		}
+		if stat, err := os.Stat(l.path); err == nil {
+			l.mod = stat.ModTime()
+		}
+		for _, n := range nodes {
+			l.nodes[n.ID] = n
+		}
+		return l
+	}
+	return &nodeLists{
+		config:  config,
+		static:  newList(datadirStaticNodes, server.StaticNodes, server.AddPeer, server.RemovePeer),
+		trusted: newList(datadirTrustedNodes, server.TrustedNodes, server.AddTrustedPeer, server.RemoveTrustedPeer),
+	}
+}
+
+// loop periodically reloads the node lists whose files were modified, until
+// stop is closed.
+func (ls *nodeLists) loop(stop chan struct{}) {
+	ticker := time.NewTicker(nodeListRefresh)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			ls.reload(false)
+		case <-stop:
+			return
+		}
+	}
+}
+
+// reload re-reads the static and trusted node lists, applying any additions
+// and removals to the running server. Unless forced, only modified files are
+// read.
+func (ls *nodeLists) reload(force bool) {
+	ls.lock.Lock()
+	defer ls.lock.Unlock()
+
+	for _, l := range []*nodeList{ls.static, ls.trusted} {
+		var mod time.Time
+		if stat, err := os.Stat(l.path); err == nil {
+			mod = stat.ModTime()
+		}
+		if !force && mod.Equal(l.mod) {
+			continue
+		}
+		l.mod = mod
+
+		nodes := make(map[discover.NodeID]*discover.Node)
+		for _, n := range ls.config.parsePersistentNodes(l.path) {
+			nodes[n.ID] = n
+		}
+		var added, removed int
+		for id, n := range l.nodes {
+			if _, ok := nodes[id]; !ok {
+				l.remove(n)
+				removed++
+			}
+		}
+		for id, n := range nodes {
+			if _, ok := l.nodes[id]; !ok {
+				l.add(n)
+				added++
+			}
+		}
+		l.nodes = nodes
+		if added > 0 || removed > 0 {
+			glog.V(logger.Info).Infof("Reloaded %s: %d added, %d removed", l.path, added, removed)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright 2015 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package node
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/EarthDollar/go-earthdollar/p2p/discover"
+)
+
+var nodeListTestURLs = []string{
+	"enode://a979fb575495b8d6db44f750317d0f4622bf4c2aa3365d6af7c284339968eef29b69ad0dce72a4d8db5ebb4968de0e3bec910127f134779fbcb0cb6d3331163c@52.16.188.185:30303",
+	"enode://de471bccee3d042261d52e9bff31458daecc406142b401d4cd848f677479f73104b9fdeb090af9583d3391b7f10cb2ba9e26865dd5fca4fcdc0fb1e3b723c786@54.94.239.50:30303",
+	"enode://1118980bf48b0a3640bdba04e0fe78b1add18e1cd99bf22d53daac1fd9972ad650df52176e7c7d89d1114cfef2bc23a2959aa54998a46afcf7d91809f0855082@52.74.57.123:30303",
+}
+
+// Tests that modifications of a node list file are applied as node additions
+// and removals.
+func TestNodeListReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "node-test")
+	if err != nil {
+		t.Fatalf("failed to create temporary data directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	config := &Config{DataDir: dir, Name: "test"}
+	path := config.resolvePath(datadirStaticNodes)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatalf("failed to create instance directory: %v", err)
+	}
+	write := func(urls ...string) {
+		blob, _ := json.Marshal(urls)
+		if err := ioutil.WriteFile(path, []byte(blob), 0600); err != nil {
+			t.Fatalf("failed to write node list: %v", err)
+		}
+	}
+	live := make(map[discover.NodeID]bool)
+	list := &nodeList{
+		path:   path,
+		nodes:  make(map[discover.NodeID]*discover.Node),
+		add:    func(n *discover.Node) { live[n.ID] = true },
+		remove: func(n *discover.Node) { delete(live, n.ID) },
+	}
+	lists := &nodeLists{config: config, static: list, trusted: &nodeList{path: config.resolvePath(datadirTrustedNodes)}}
+
+	check := func(urls ...string) {
+		if len(live) != len(urls) {
+			t.Fatalf("live node count mismatch: have %d, want %d", len(live), len(urls))
+		}
+		for _, url := range urls {
+			if !live[discover.MustParseNode(url).ID] {
+				t.Errorf("node %s not live", url[:20])
+			}
+		}
+	}
+	write(nodeListTestURLs[0], nodeListTestURLs[1])
+	lists.reload(true)
+	check(nodeListTestURLs[0], nodeListTestURLs[1])
+
+	write(nodeListTestURLs[1], nodeListTestURLs[2])
+	lists.reload(true)
+	check(nodeListTestURLs[1], nodeListTestURLs[2])
+
+	os.Remove(path)
+	lists.reload(true)
+	check()
+}
@@ -18,6 +18,7 @@ package node
 
 import (
 	"reflect"
+	"sync"
 
 	"github.com/EarthDollar/go-earthdollar/accounts"
 	"github.com/EarthDollar/go-earthdollar/ethdb"
@@ -34,6 +35,7 @@ type ServiceContext struct {
 	services       map[reflect.Type]Service // Index of the already constructed services
 	EventMux       *event.TypeMux           // Event multiplexer used for decoupled notifications
 	AccountManager *accounts.Manager        // Account manager created by the node.
+	memdbs         *memDatabases            // Databases of an ephemeral node (nil = always fresh)
 }
 
 // OpenDatabase opens an existing database with the given name (or creates one
@@ -41,11 +43,42 @@ type ServiceContext struct {
 // node is an ephemeral one, a memory database is returned.
 func (ctx *ServiceContext) OpenDatabase(name string, cache int, handles int) (ethdb.Database, error) {
 	if ctx.config.DataDir == "" {
-		return ethdb.NewMemDatabase()
+		return ctx.memdbs.open(name)
 	}
 	return ethdb.NewLDBDatabase(ctx.config.resolvePath(name), cache, handles)
 }
 
+// memDatabases retains the in-memory databases of an ephemeral node, so that a
+// restarted service finds the data it left behind, just like on disk.
+type memDatabases struct {
+	lock sync.Mutex
+	dbs  map[string]*ethdb.MemDatabase
+}
+
+func newMemDatabases() *memDatabases {
+	return &memDatabases{dbs: make(map[string]*ethdb.MemDatabase)}
+}
+
+// open returns the memory database with the given name, creating it if needed.
+// A nil set always creates a new database.
+func (m *memDatabases) open(name string) (ethdb.Database, error) {
+	if m == nil {
+		return ethdb.NewMemDatabase()
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if db, ok := m.dbs[name]; ok {
+		return db, nil
+	}
+	db, err := ethdb.NewMemDatabase()
+	if err != nil {
+		return nil, err
+	}
+	m.dbs[name] = db
+	return db, nil
+}
+
 // Service retrieves a currently running service registered of a specific type.
 func (ctx *ServiceContext) Service(service interface{}) error {
 	element := reflect.ValueOf(service).Elem()
@@ -69,7 +102,10 @@ type ServiceConstructor func(ctx *ServiceContext) (Service, error)
 // Start method.
 //
 // • Restart logic is not required as the node will create a fresh instance
-// every time a service is started.
+// every time a service is started, unless the service is a ResumableService. The
+// instance is handed the databases of the previous one (persistent or, for
+// ephemeral nodes, in-memory) and a fresh event mux, and may thus stop the mux it
+// was given along with itself.
 type Service interface {
 	// Protocols retrieves the P2P protocols the service wishes to start.
 	Protocols() []p2p.Protocol
@@ -85,3 +121,15 @@ type Service interface {
 	// are all terminated.
 	Stop() error
 }
+
+// ResumableService is a Service which can be started again after being stopped.
+// Instead of constructing a fresh instance, the node resumes the stopped one on
+// the next start, sparing the service its one-off initialisation.
+type ResumableService interface {
+	Service
+
+	// Resume is called in place of the service constructor, in the same order,
+	// to reacquire the resources released by Stop from the context of the new
+	// run (databases, event mux, etc).
+	Resume(ctx *ServiceContext) error
+}
@@ -88,6 +88,21 @@ func (s *InstrumentedService) Stop() error {
 	return s.stop
 }
 
+// ResumingService is an InstrumentedService which can be resumed after a stop.
+type ResumingService struct {
+	InstrumentedService
+
+	resume     error
+	resumeHook func(*ServiceContext)
+}
+
+func (s *ResumingService) Resume(ctx *ServiceContext) error {
+	if s.resumeHook != nil {
+		s.resumeHook(ctx)
+	}
+	return s.resume
+}
+
 // InstrumentingWrapper is a method to specialize a service constructor returning
 // a generic InstrumentedService into one returning a wrapping specific one.
 type InstrumentingWrapper func(base ServiceConstructor) ServiceConstructor
@@ -133,3 +148,11 @@ func (api *OneMethodApi) TheOneMethod() {
 		api.fun()
 	}
 }
+
+// ReadyService is a NoopService additionally reporting a configurable readiness.
+type ReadyService struct {
+	NoopService
+	ready error
+}
+
+func (s *ReadyService) Ready() error { return s.ready }
@@ -0,0 +1,220 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"github.com/EarthDollar/go-earthdollar/logger"
+	"github.com/EarthDollar/go-earthdollar/logger/glog"
+	"github.com/EarthDollar/go-earthdollar/p2p/discover"
+)
+
+// banStore is implemented by node databases capable of persisting bans across
+// restarts (e.g. the discovery table).
+type banStore interface {
+	Bans() []discover.Ban
+	SetBan(b discover.Ban) error
+	RemoveBan(b discover.Ban) error
+}
+
+// banList tracks the currently active node and IP bans.
+type banList struct {
+	lock  sync.Mutex
+	nodes map[discover.NodeID]time.Time
+	ips   map[string]time.Time
+	store banStore
+}
+
+// newBanList creates a ban list, loading all unexpired bans from the store.
+func newBanList(store banStore) *banList {
+	l := &banList{
+		nodes: make(map[discover.NodeID]time.Time),
+		ips:   make(map[string]time.Time),
+		store: store,
+	}
+	if store != nil {
+		now := time.Now()
+		for _, b := range store.Bans() {
+			if !b.Until.After(now) {
+				store.RemoveBan(b)
+				continue
+			}
+			if b.IP != nil {
+				l.ips[b.IP.String()] = b.Until
+			} else {
+				l.nodes[b.ID] = b.Until
+			}
+		}
+	}
+	return l
+}
+
+// add inserts or updates a ban.
+func (l *banList) add(b discover.Ban) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if b.IP != nil {
+		l.ips[b.IP.String()] = b.Until
+	} else {
+		l.nodes[b.ID] = b.Until
+	}
+	if l.store != nil {
+		if err := l.store.SetBan(b); err != nil {
+			glog.V(logger.Warn).Infof("failed to persist ban: %v", err)
+		}
+	}
+}
+
+// remove lifts a ban, reporting whether it existed.
+func (l *banList) remove(b discover.Ban) bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	var ok bool
+	if b.IP != nil {
+		_, ok = l.ips[b.IP.String()]
+		delete(l.ips, b.IP.String())
+	} else {
+		_, ok = l.nodes[b.ID]
+		delete(l.nodes, b.ID)
+	}
+	if l.store != nil {
+		l.store.RemoveBan(b)
+	}
+	return ok
+}
+
+// banned reports whether the node or the IP address is currently banned.
+func (l *banList) banned(id discover.NodeID, ip net.IP) bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if until, ok := l.nodes[id]; ok && time.Now().Before(until) {
+		return true
+	}
+	return l.ipBanned(ip)
+}
+
+// bannedIP reports whether the IP address is currently banned.
+func (l *banList) bannedIP(ip net.IP) bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	return l.ipBanned(ip)
+}
+
+// ipBanned is the unlocked version of bannedIP.
+func (l *banList) ipBanned(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	until, ok := l.ips[ip.String()]
+	return ok && time.Now().Before(until)
+}
+
+// all returns the currently active bans.
+func (l *banList) all() []discover.Ban {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	var (
+		now  = time.Now()
+		bans []discover.Ban
+	)
+	for id, until := range l.nodes {
+		if now.Before(until) {
+			bans = append(bans, discover.Ban{ID: id, Until: until})
+		}
+	}
+	for ip, until := range l.ips {
+		if now.Before(until) {
+			bans = append(bans, discover.Ban{IP: net.ParseIP(ip), Until: until})
+		}
+	}
+	return bans
+}
+
+// BanNode refuses any connection to or from the given node for the duration,
+// disconnecting it if currently connected. The ban is persisted in the node
+// database if discovery is running.
+func (srv *Server) BanNode(id discover.NodeID, duration time.Duration) {
+	if srv.bans == nil {
+		return
+	}
+	glog.V(logger.Info).Infof("Banning node %x for %v", id[:8], duration)
+	srv.bans.add(discover.Ban{ID: id, Until: time.Now().Add(duration)})
+	srv.dropBanned()
+}
+
+// BanIP refuses any connection to or from the given IP address for the
+// duration, disconnecting all peers currently connected from it.
+func (srv *Server) BanIP(ip net.IP, duration time.Duration) {
+	if srv.bans == nil {
+		return
+	}
+	glog.V(logger.Info).Infof("Banning IP %v for %v", ip, duration)
+	srv.bans.add(discover.Ban{IP: ip, Until: time.Now().Add(duration)})
+	srv.dropBanned()
+}
+
+// UnbanNode lifts the ban of a node, reporting whether it was banned.
+func (srv *Server) UnbanNode(id discover.NodeID) bool {
+	if srv.bans == nil {
+		return false
+	}
+	return srv.bans.remove(discover.Ban{ID: id})
+}
+
+// UnbanIP lifts the ban of an IP address, reporting whether it was banned.
+func (srv *Server) UnbanIP(ip net.IP) bool {
+	if srv.bans == nil {
+		return false
+	}
+	return srv.bans.remove(discover.Ban{IP: ip})
+}
+
+// Bans returns all currently active node and IP bans.
+func (srv *Server) Bans() []discover.Ban {
+	if srv.bans == nil {
+		return nil
+	}
+	return srv.bans.all()
+}
+
+// dropBanned disconnects all connected peers which are banned.
+func (srv *Server) dropBanned() {
+	for _, p := range srv.Peers() {
+		if srv.bans.banned(p.ID(), remoteIP(p.rw)) {
+			p.Disconnect(DiscUselessPeer)
+		}
+	}
+}
+
+// remoteIP returns the IP address of the remote end of a connection.
+func remoteIP(c *conn) net.IP {
+	if c.fd == nil {
+		return nil
+	}
+	if tcp, ok := c.fd.RemoteAddr().(*net.TCPAddr); ok {
+		return tcp.IP
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/EarthDollar/go-earthdollar/p2p/discover"
+)
+
+// memBanStore is an in-memory banStore for testing.
+type memBanStore map[string]discover.Ban
+
+func (s memBanStore) key(b discover.Ban) string {
+	if b.IP != nil {
+		return b.IP.String()
+	}
+	return b.ID.String()
+}
+
+func (s memBanStore) Bans() []discover.Ban {
+	var bans []discover.Ban
+	for _, b := range s {
+		bans = append(bans, b)
+	}
+	return bans
+}
+
+func (s memBanStore) SetBan(b discover.Ban) error {
+	s[s.key(b)] = b
+	return nil
+}
+
+func (s memBanStore) RemoveBan(b discover.Ban) error {
+	delete(s, s.key(b))
+	return nil
+}
+
+func TestBanList(t *testing.T) {
+	store := make(memBanStore)
+	id1, id2, id3 := randomID(), randomID(), randomID()
+	ip1, ip2 := net.ParseIP("10.0.0.1"), net.ParseIP("10.0.0.2")
+
+	// Expired bans must be dropped on load
+	store.SetBan(discover.Ban{ID: id3, Until: time.Now().Add(-time.Minute)})
+
+	list := newBanList(store)
+	if len(store) != 0 {
+		t.Errorf("expired ban not removed from store: %v", store)
+	}
+	list.add(discover.Ban{ID: id1, Until: time.Now().Add(time.Hour)})
+	list.add(discover.Ban{IP: ip1, Until: time.Now().Add(time.Hour)})
+
+	tests := []struct {
+		id     discover.NodeID
+		ip     net.IP
+		banned bool
+	}{
+		{id1, nil, true},
+		{id1, ip2, true},
+		{id2, ip1, true},
+		{id2, ip2, false},
+		{id3, nil, false},
+	}
+	for i, tt := range tests {
+		if banned := list.banned(tt.id, tt.ip); banned != tt.banned {
+			t.Errorf("test %d: ban mismatch: have %v, want %v", i, banned, tt.banned)
+		}
+	}
+	// A fresh list must resume from the persisted bans
+	list = newBanList(store)
+	if !list.banned(id1, nil) || !list.bannedIP(ip1) {
+		t.Errorf("persisted bans not loaded: %v", list.all())
+	}
+	if len(list.all()) != 2 {
+		t.Errorf("ban count mismatch: have %d, want 2", len(list.all()))
+	}
+	// Lifted bans must be removed from the store too
+	if !list.remove(discover.Ban{ID: id1}) {
+		t.Errorf("existing ban not reported as removed")
+	}
+	if list.banned(id1, nil) || len(store) != 1 {
+		t.Errorf("ban not lifted: %v", store)
+	}
+}
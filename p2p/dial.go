@@ -51,6 +51,8 @@ type dialstate struct {
 	maxDynDials int
 	ntab        discoverTable
 	netrestrict *netutil.Netlist
+	reputation  *reputationTracker // optional, prefers well behaving peers
+	bans        *banList           // optional, refuses banned nodes and IPs
 
 	lookupRunning bool
 	dialing       map[discover.NodeID]connFlag
@@ -133,7 +135,15 @@ func (s *dialstate) removeStatic(n *discover.Node) {
 func (s *dialstate) newTasks(nRunning int, peers map[discover.NodeID]*Peer, now time.Time) []task {
 	var newtasks []task
 	addDial := func(flag connFlag, n *discover.Node) bool {
-		if err := s.checkDial(n, peers); err != nil {
+		err := s.checkDial(n, peers)
+		if err == nil && flag&dynDialedConn != 0 {
+			if s.reputation != nil && s.reputation.score(n.ID) <= BanReputation {
+				err = errBannedPeer
+			} else if s.bans != nil && s.bans.banned(n.ID, n.IP) {
+				err = errBannedNode
+			}
+		}
+		if err != nil {
 			glog.V(logger.Debug).Infof("skipping dial candidate %x@%v:%d: %v", n.ID[:8], n.IP, n.TCP, err)
 			return false
 		}
@@ -176,6 +186,9 @@ func (s *dialstate) newTasks(nRunning int, peers map[discover.NodeID]*Peer, now
 	randomCandidates := needDynDials / 2
 	if randomCandidates > 0 {
 		n := s.ntab.ReadRandomNodes(s.randomNodes)
+		if s.reputation != nil {
+			s.reputation.sortByReputation(s.randomNodes[:n])
+		}
 		for i := 0; i < randomCandidates && i < n; i++ {
 			if addDial(dynDialedConn, s.randomNodes[i]) {
 				needDynDials--
@@ -184,6 +197,9 @@ func (s *dialstate) newTasks(nRunning int, peers map[discover.NodeID]*Peer, now
 	}
 	// Create dynamic dials from random lookup results, removing tried
 	// items from the result buffer.
+	if s.reputation != nil {
+		s.reputation.sortByReputation(s.lookupBuf)
+	}
 	i := 0
 	for ; i < len(s.lookupBuf) && needDynDials > 0; i++ {
 		if addDial(dynDialedConn, s.lookupBuf[i]) {
@@ -214,6 +230,8 @@ var (
 	errAlreadyConnected = errors.New("already connected")
 	errRecentlyDialed   = errors.New("recently dialed")
 	errNotWhitelisted   = errors.New("not contained in netrestrict whitelist")
+	errBannedPeer       = errors.New("banned for bad reputation")
+	errBannedNode       = errors.New("node or IP is banned")
 )
 
 func (s *dialstate) checkDial(n *discover.Node, peers map[discover.NodeID]*Peer) error {
@@ -297,7 +315,7 @@ func (t *dialTask) resolve(srv *Server) bool {
 func (t *dialTask) dial(srv *Server, dest *discover.Node) bool {
 	addr := &net.TCPAddr{IP: dest.IP, Port: int(dest.TCP)}
 	glog.V(logger.Debug).Infof("dial tcp %v (%x)\n", addr, dest.ID[:6])
-	fd, err := srv.Dialer.Dial("tcp", addr.String())
+	fd, err := srv.dialTCP(addr.String())
 	if err != nil {
 		glog.V(logger.Detail).Infof("%v", err)
 		return false
@@ -307,6 +325,15 @@ func (t *dialTask) dial(srv *Server, dest *discover.Node) bool {
 	return true
 }
 
+// dialTCP connects to the given address, through the SOCKS5 proxy if one is
+// configured.
+func (srv *Server) dialTCP(addr string) (net.Conn, error) {
+	if srv.Proxy != nil {
+		return srv.Proxy.Dial("tcp", addr)
+	}
+	return srv.Dialer.Dial("tcp", addr)
+}
+
 func (t *dialTask) String() string {
 	return fmt.Sprintf("%v %x %v:%d", t.flags, t.dest.ID[:8], t.dest.IP, t.dest.TCP)
 }
@@ -23,6 +23,7 @@ import (
 	"bytes"
 	"crypto/rand"
 	"encoding/binary"
+	"net"
 	"os"
 	"sync"
 	"time"
@@ -62,6 +63,14 @@ var (
 	nodeDBDiscoverPing      = nodeDBDiscoverRoot + ":lastping"
 	nodeDBDiscoverPong      = nodeDBDiscoverRoot + ":lastpong"
 	nodeDBDiscoverFindFails = nodeDBDiscoverRoot + ":findfail"
+	nodeDBDiscoverRecord    = nodeDBDiscoverRoot + ":enr"
+
+	nodeDBBan       = ":ban"    // Node scoped ban expiration timestamp
+	nodeDBBanPrefix = "ban:ip:" // Prefix of IP scoped ban expiration timestamps
+
+	nodeDBReputationRoot    = ":reputation"
+	nodeDBReputationScore   = nodeDBReputationRoot + ":score"
+	nodeDBReputationUpdated = nodeDBReputationRoot + ":updated"
 )
 
 // newNodeDB creates a new node database for storing and retrieving infos about
@@ -201,10 +210,46 @@ func (db *nodeDB) updateNode(node *Node) error {
 	return db.lvl.Put(makeKey(node.ID, nodeDBDiscoverRoot), blob, nil)
 }
 
-// deleteNode deletes all information/keys associated with a node.
+// record retrieves the latest known signed record of a node.
+func (db *nodeDB) record(id NodeID) *Record {
+	blob, err := db.lvl.Get(makeKey(id, nodeDBDiscoverRecord), nil)
+	if err != nil {
+		return nil
+	}
+	r := new(Record)
+	if err := rlp.DecodeBytes(blob, r); err != nil {
+		glog.V(logger.Warn).Infof("failed to decode node record: %v", err)
+		return nil
+	}
+	return r
+}
+
+// updateRecord stores a signed node record, unless a record with the same or
+// a higher sequence number is already known. It reports whether the record
+// was stored.
+func (db *nodeDB) updateRecord(r *Record) (bool, error) {
+	id, err := r.NodeID()
+	if err != nil {
+		return false, err
+	}
+	if old := db.record(id); old != nil && old.Seq() >= r.Seq() {
+		return false, nil
+	}
+	blob, err := rlp.EncodeToBytes(r)
+	if err != nil {
+		return false, err
+	}
+	return true, db.lvl.Put(makeKey(id, nodeDBDiscoverRecord), blob, nil)
+}
+
+// deleteNode deletes all information/keys associated with a node, except for
+// bans which must outlive the node's presence in the discovery table.
 func (db *nodeDB) deleteNode(id NodeID) error {
 	deleter := db.lvl.NewIterator(util.BytesPrefix(makeKey(id, "")), nil)
 	for deleter.Next() {
+		if _, field := splitKey(deleter.Key()); field == nodeDBBan {
+			continue
+		}
 		if err := db.lvl.Delete(deleter.Key(), nil); err != nil {
 			return err
 		}
@@ -300,6 +345,74 @@ func (db *nodeDB) updateFindFails(id NodeID, fails int) error {
 	return db.storeInt64(makeKey(id, nodeDBDiscoverFindFails), int64(fails))
 }
 
+// reputation retrieves the behavioural score of a remote node along with the
+// time it was last updated.
+func (db *nodeDB) reputation(id NodeID) (int, time.Time) {
+	score := db.fetchInt64(makeKey(id, nodeDBReputationScore))
+	updated := db.fetchInt64(makeKey(id, nodeDBReputationUpdated))
+	return int(score), time.Unix(updated, 0)
+}
+
+// updateReputation stores the behavioural score of a remote node.
+func (db *nodeDB) updateReputation(id NodeID, score int, updated time.Time) error {
+	if err := db.storeInt64(makeKey(id, nodeDBReputationScore), int64(score)); err != nil {
+		return err
+	}
+	return db.storeInt64(makeKey(id, nodeDBReputationUpdated), updated.Unix())
+}
+
+// Ban is a persisted restriction on connecting to a node, identified either by
+// its id or by an IP address.
+type Ban struct {
+	ID    NodeID    `json:"id,omitempty"`
+	IP    net.IP    `json:"ip,omitempty"`
+	Until time.Time `json:"until"`
+}
+
+// banKey returns the database key of a ban.
+func banKey(b Ban) []byte {
+	if b.IP != nil {
+		return []byte(nodeDBBanPrefix + b.IP.String())
+	}
+	return makeKey(b.ID, nodeDBBan)
+}
+
+// bans retrieves all persisted bans, including expired ones.
+func (db *nodeDB) bans() []Ban {
+	var bans []Ban
+
+	it := db.lvl.NewIterator(nil, nil)
+	defer it.Release()
+
+	for it.Next() {
+		key := it.Key()
+		switch {
+		case bytes.HasPrefix(key, []byte(nodeDBBanPrefix)):
+			ip := net.ParseIP(string(key[len(nodeDBBanPrefix):]))
+			if ip == nil {
+				continue
+			}
+			bans = append(bans, Ban{IP: ip, Until: time.Unix(db.fetchInt64(key), 0)})
+
+		case bytes.HasPrefix(key, nodeDBItemPrefix):
+			if id, field := splitKey(key); field == nodeDBBan {
+				bans = append(bans, Ban{ID: id, Until: time.Unix(db.fetchInt64(key), 0)})
+			}
+		}
+	}
+	return bans
+}
+
+// updateBan stores a ban, overwriting any previous one of the same target.
+func (db *nodeDB) updateBan(b Ban) error {
+	return db.storeInt64(banKey(b), b.Until.Unix())
+}
+
+// deleteBan removes a ban.
+func (db *nodeDB) deleteBan(b Ban) error {
+	return db.lvl.Delete(banKey(b), nil)
+}
+
 // querySeeds retrieves random nodes to be used as potential seed nodes
 // for bootstrapping.
 func (db *nodeDB) querySeeds(n int, maxAge time.Duration) []*Node {
@@ -144,6 +144,16 @@ func TestNodeDBFetchStore(t *testing.T) {
 	if stored := db.findFails(node.ID); stored != num {
 		t.Errorf("find-node fails: value mismatch: have %v, want %v", stored, num)
 	}
+	// Check fetch/store operations on a node reputation object
+	if score, updated := db.reputation(node.ID); score != 0 || updated.Unix() != 0 {
+		t.Errorf("reputation: non-existing object: %v, %v", score, updated)
+	}
+	if err := db.updateReputation(node.ID, -num, inst); err != nil {
+		t.Errorf("reputation: failed to update: %v", err)
+	}
+	if score, updated := db.reputation(node.ID); score != -num || updated.Unix() != inst.Unix() {
+		t.Errorf("reputation: value mismatch: have %v, %v, want %v, %v", score, updated, -num, inst)
+	}
 	// Check fetch/store operations on an actual node object
 	if stored := db.node(node.ID); stored != nil {
 		t.Errorf("node: non-existing object: %v", stored)
@@ -378,3 +388,53 @@ func TestNodeDBSelfExpiration(t *testing.T) {
 		t.Errorf("self not evacuated")
 	}
 }
+
+func TestNodeDBBans(t *testing.T) {
+	db, _ := newNodeDB("", Version, NodeID{})
+	defer db.close()
+
+	until := time.Unix(time.Now().Add(time.Hour).Unix(), 0)
+	seed := nodeDBExpirationNodes[len(nodeDBExpirationNodes)-1]
+	nodeBan := Ban{ID: seed.node.ID, Until: until}
+	ipBan := Ban{IP: net.ParseIP("10.0.0.1"), Until: until}
+
+	// Store a node ban alongside an expiring node, and an IP ban
+	if err := db.updateNode(seed.node); err != nil {
+		t.Fatalf("failed to insert node: %v", err)
+	}
+	if err := db.updateLastPong(seed.node.ID, seed.pong); err != nil {
+		t.Fatalf("failed to update pong: %v", err)
+	}
+	for _, b := range []Ban{nodeBan, ipBan} {
+		if err := db.updateBan(b); err != nil {
+			t.Fatalf("failed to store ban %v: %v", b, err)
+		}
+	}
+	// Node expiration must not lift the ban
+	if err := db.expireNodes(); err != nil {
+		t.Fatalf("failed to expire nodes: %v", err)
+	}
+	if node := db.node(seed.node.ID); node != nil {
+		t.Errorf("node not expired")
+	}
+	bans := db.bans()
+	if len(bans) != 2 {
+		t.Fatalf("ban count mismatch: have %d, want 2", len(bans))
+	}
+	for _, b := range bans {
+		if b.IP != nil {
+			if !b.IP.Equal(ipBan.IP) || !b.Until.Equal(until) {
+				t.Errorf("IP ban mismatch: have %v, want %v", b, ipBan)
+			}
+		} else if b.ID != nodeBan.ID || !b.Until.Equal(until) {
+			t.Errorf("node ban mismatch: have %v, want %v", b, nodeBan)
+		}
+	}
+	// Deleted bans must not be returned any more
+	if err := db.deleteBan(ipBan); err != nil {
+		t.Fatalf("failed to delete ban: %v", err)
+	}
+	if bans := db.bans(); len(bans) != 1 || bans[0].ID != nodeBan.ID {
+		t.Errorf("bans mismatch after delete: %v", bans)
+	}
+}
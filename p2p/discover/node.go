@@ -54,6 +54,9 @@ type Node struct {
 	// whether this node is currently being pinged in order to replace
 	// it in a bucket
 	contested bool
+
+	// signed node record the node was created from, if any
+	rec *Record
 }
 
 // NewNode creates a new node. It is mostly meant to be used for
@@ -71,6 +74,12 @@ func NewNode(id NodeID, ip net.IP, udpPort, tcpPort uint16) *Node {
 	}
 }
 
+// Record returns the signed node record the node was created from, or nil if
+// the node was created from an enode URL or a discovery packet.
+func (n *Node) Record() *Record {
+	return n.rec
+}
+
 func (n *Node) addr() *net.UDPAddr {
 	return &net.UDPAddr{IP: n.IP, Port: int(n.UDP)}
 }
@@ -140,7 +149,17 @@ var incompleteNodeURL = regexp.MustCompile("(?i)^(?:enode://)?([0-9a-f]+)$")
 // and UDP discovery port 30301.
 //
 //    enode://<hex node id>@10.3.58.6:20203?discport=30301
+//
+// Signed node records in their textual form ("enr:<base64>") are accepted as
+// well, in which case the record is verified and retained within the node.
 func ParseNode(rawurl string) (*Node, error) {
+	if strings.HasPrefix(rawurl, recordPrefix) {
+		r, err := ParseRecord(rawurl)
+		if err != nil {
+			return nil, fmt.Errorf("invalid node record (%v)", err)
+		}
+		return NewNodeFromRecord(r)
+	}
 	if m := incompleteNodeURL.FindStringSubmatch(rawurl); m != nil {
 		id, err := HexID(m[1])
 		if err != nil {
@@ -0,0 +1,400 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package discover
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/EarthDollar/go-earthdollar/crypto"
+	"github.com/EarthDollar/go-earthdollar/crypto/secp256k1"
+	"github.com/EarthDollar/go-earthdollar/rlp"
+)
+
+// Keys of the predefined node record entries.
+const (
+	RecordKeyID        = "id"        // identity scheme, always "v4"
+	RecordKeySecp256k1 = "secp256k1" // compressed public key
+	RecordKeyIP        = "ip"        // IPv4 or IPv6 address
+	RecordKeyTCP       = "tcp"       // TCP (RLPx) port
+	RecordKeyUDP       = "udp"       // UDP (discovery) port
+)
+
+const (
+	// recordSizeLimit is the maximum encoded size of a node record in bytes.
+	recordSizeLimit = 300
+
+	// recordPrefix is the prefix of the textual form of a node record.
+	recordPrefix = "enr:"
+
+	// identitySchemeV4 is the only supported identity scheme: secp256k1 keys
+	// signing the keccak256 hash of the record content.
+	identitySchemeV4 = "v4"
+)
+
+var (
+	errRecordNotSigned   = errors.New("record is not signed")
+	errRecordInvalidSig  = errors.New("invalid record signature")
+	errRecordTooBig      = errors.New("record bigger than 300 bytes")
+	errRecordNotSorted   = errors.New("record keys not sorted")
+	errRecordDupKey      = errors.New("duplicate record key")
+	errRecordIncomplete  = errors.New("incomplete key/value pair in record")
+	errRecordNoScheme    = errors.New("unknown or missing identity scheme")
+	errRecordKeyNotFound = errors.New("no such record key")
+)
+
+// recordPair is a single key/value entry of a record.
+type recordPair struct {
+	k string
+	v rlp.RawValue
+}
+
+// Record is a signed, versioned node record. Besides the identity of the node
+// and its endpoint, it may carry arbitrary key/value pairs such as supported
+// capabilities. Every modification invalidates the signature, so records
+// have to be re-signed (usually with an increased sequence number) before
+// they can be encoded.
+type Record struct {
+	seq       uint64
+	signature []byte
+	raw       []byte       // RLP encoding of the signed record
+	pairs     []recordPair // sorted by key
+}
+
+// Seq returns the sequence number of the record.
+func (r *Record) Seq() uint64 {
+	return r.seq
+}
+
+// SetSeq updates the sequence number, invalidating the signature.
+func (r *Record) SetSeq(seq uint64) {
+	r.seq = seq
+	r.invalidate()
+}
+
+// Set adds or updates the value of a key, invalidating the signature.
+func (r *Record) Set(key string, value interface{}) error {
+	blob, err := rlp.EncodeToBytes(value)
+	if err != nil {
+		return err
+	}
+	i := sort.Search(len(r.pairs), func(i int) bool { return r.pairs[i].k >= key })
+	if i < len(r.pairs) && r.pairs[i].k == key {
+		r.pairs[i].v = blob
+	} else {
+		r.pairs = append(r.pairs, recordPair{})
+		copy(r.pairs[i+1:], r.pairs[i:])
+		r.pairs[i] = recordPair{k: key, v: blob}
+	}
+	r.invalidate()
+	return nil
+}
+
+// Load decodes the value of a key into value, which must be a pointer.
+func (r *Record) Load(key string, value interface{}) error {
+	i := sort.Search(len(r.pairs), func(i int) bool { return r.pairs[i].k >= key })
+	if i == len(r.pairs) || r.pairs[i].k != key {
+		return errRecordKeyNotFound
+	}
+	if err := rlp.DecodeBytes(r.pairs[i].v, value); err != nil {
+		return fmt.Errorf("invalid record key %q: %v", key, err)
+	}
+	return nil
+}
+
+// Keys returns the keys set in the record, sorted.
+func (r *Record) Keys() []string {
+	keys := make([]string, len(r.pairs))
+	for i, p := range r.pairs {
+		keys[i] = p.k
+	}
+	return keys
+}
+
+// Signed reports whether the record carries a valid signature.
+func (r *Record) Signed() bool {
+	return r.signature != nil
+}
+
+func (r *Record) invalidate() {
+	r.signature, r.raw = nil, nil
+}
+
+// content returns the items covered by the signature.
+func (r *Record) content() []interface{} {
+	list := make([]interface{}, 1, 2*len(r.pairs)+1)
+	list[0] = r.seq
+	for _, p := range r.pairs {
+		list = append(list, p.k, p.v)
+	}
+	return list
+}
+
+// Sign sets the identity scheme and public key entries and signs the record
+// with the given key.
+func (r *Record) Sign(key *ecdsa.PrivateKey) error {
+	r.Set(RecordKeyID, identitySchemeV4)
+	r.Set(RecordKeySecp256k1, compressPubkey(&key.PublicKey))
+
+	blob, err := rlp.EncodeToBytes(r.content())
+	if err != nil {
+		return err
+	}
+	sig, err := crypto.Sign(crypto.Keccak256(blob), key)
+	if err != nil {
+		return err
+	}
+	sig = sig[:64] // drop the recovery id
+	raw, err := rlp.EncodeToBytes(append([]interface{}{sig}, r.content()...))
+	if err != nil {
+		return err
+	}
+	if len(raw) > recordSizeLimit {
+		return errRecordTooBig
+	}
+	r.signature, r.raw = sig, raw
+	return nil
+}
+
+// verifySignature checks the signature against the public key in the record.
+func (r *Record) verifySignature() error {
+	var scheme string
+	if err := r.Load(RecordKeyID, &scheme); err != nil || scheme != identitySchemeV4 {
+		return errRecordNoScheme
+	}
+	pubkey, err := r.pubkey()
+	if err != nil {
+		return err
+	}
+	blob, err := rlp.EncodeToBytes(r.content())
+	if err != nil {
+		return err
+	}
+	hash := crypto.Keccak256(blob)
+	for v := byte(0); v < 2; v++ {
+		recovered, err := crypto.SigToPub(hash, append(append([]byte{}, r.signature...), v))
+		if err == nil && recovered.X.Cmp(pubkey.X) == 0 && recovered.Y.Cmp(pubkey.Y) == 0 {
+			return nil
+		}
+	}
+	return errRecordInvalidSig
+}
+
+// pubkey returns the public key contained in the record.
+func (r *Record) pubkey() (*ecdsa.PublicKey, error) {
+	var compressed []byte
+	if err := r.Load(RecordKeySecp256k1, &compressed); err != nil {
+		return nil, err
+	}
+	return decompressPubkey(compressed)
+}
+
+// NodeID returns the identity of the node the record belongs to.
+func (r *Record) NodeID() (NodeID, error) {
+	pubkey, err := r.pubkey()
+	if err != nil {
+		return NodeID{}, err
+	}
+	return PubkeyID(pubkey), nil
+}
+
+// EncodeRLP implements rlp.Encoder. Only signed records can be encoded.
+func (r *Record) EncodeRLP(w io.Writer) error {
+	if !r.Signed() {
+		return errRecordNotSigned
+	}
+	_, err := w.Write(r.raw)
+	return err
+}
+
+// DecodeRLP implements rlp.Decoder, verifying the signature of the record.
+func (r *Record) DecodeRLP(s *rlp.Stream) error {
+	raw, err := s.Raw()
+	if err != nil {
+		return err
+	}
+	if len(raw) > recordSizeLimit {
+		return errRecordTooBig
+	}
+	var dec Record
+	elems := rlp.NewStream(bytes.NewReader(raw), 0)
+	if _, err := elems.List(); err != nil {
+		return err
+	}
+	if dec.signature, err = elems.Bytes(); err != nil {
+		return err
+	}
+	if dec.seq, err = elems.Uint(); err != nil {
+		return err
+	}
+	for prev := ""; ; {
+		var p recordPair
+		if err := elems.Decode(&p.k); err == rlp.EOL {
+			break
+		} else if err != nil {
+			return err
+		}
+		if p.v, err = elems.Raw(); err == rlp.EOL {
+			return errRecordIncomplete
+		} else if err != nil {
+			return err
+		}
+		if len(dec.pairs) > 0 {
+			if p.k == prev {
+				return errRecordDupKey
+			}
+			if p.k < prev {
+				return errRecordNotSorted
+			}
+		}
+		dec.pairs = append(dec.pairs, p)
+		prev = p.k
+	}
+	if err := dec.verifySignature(); err != nil {
+		return err
+	}
+	dec.raw = raw
+	*r = dec
+	return nil
+}
+
+// String returns the textual form of the record: "enr:" followed by the URL
+// safe base64 encoding of the signed RLP. Unsigned records are rendered as a
+// list of their keys.
+func (r *Record) String() string {
+	if !r.Signed() {
+		return fmt.Sprintf("unsigned record seq=%d keys=%v", r.seq, r.Keys())
+	}
+	return recordPrefix + base64.RawURLEncoding.EncodeToString(r.raw)
+}
+
+// ParseRecord decodes and verifies the textual form of a node record.
+func ParseRecord(s string) (*Record, error) {
+	if !strings.HasPrefix(s, recordPrefix) {
+		return nil, fmt.Errorf("missing %q prefix", recordPrefix)
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(s[len(recordPrefix):])
+	if err != nil {
+		return nil, err
+	}
+	r := new(Record)
+	if err := rlp.DecodeBytes(raw, r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// NewRecord creates a signed record describing the given node, which must be
+// identified by the given private key.
+func NewRecord(key *ecdsa.PrivateKey, seq uint64, ip net.IP, udp, tcp uint16) (*Record, error) {
+	r := &Record{seq: seq}
+	if ip != nil {
+		if ipv4 := ip.To4(); ipv4 != nil {
+			ip = ipv4
+		}
+		r.Set(RecordKeyIP, []byte(ip))
+	}
+	if udp != 0 {
+		r.Set(RecordKeyUDP, udp)
+	}
+	if tcp != 0 {
+		r.Set(RecordKeyTCP, tcp)
+	}
+	if err := r.Sign(key); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// NewNodeFromRecord creates a node from a signed record. The record is kept and
+// can be retrieved via Record.
+func NewNodeFromRecord(r *Record) (*Node, error) {
+	if !r.Signed() {
+		return nil, errRecordNotSigned
+	}
+	id, err := r.NodeID()
+	if err != nil {
+		return nil, err
+	}
+	var (
+		ip       []byte
+		udp, tcp uint16
+	)
+	if err := r.Load(RecordKeyIP, &ip); err != nil && err != errRecordKeyNotFound {
+		return nil, err
+	}
+	if ip != nil && len(ip) != net.IPv4len && len(ip) != net.IPv6len {
+		return nil, fmt.Errorf("invalid record IP length %d", len(ip))
+	}
+	if err := r.Load(RecordKeyUDP, &udp); err != nil && err != errRecordKeyNotFound {
+		return nil, err
+	}
+	if err := r.Load(RecordKeyTCP, &tcp); err != nil && err != errRecordKeyNotFound {
+		return nil, err
+	}
+	if udp == 0 {
+		udp = tcp
+	}
+	n := NewNode(id, net.IP(ip), udp, tcp)
+	n.rec = r
+	return n, nil
+}
+
+// compressPubkey encodes a public key in the 33 byte compressed format.
+func compressPubkey(pubkey *ecdsa.PublicKey) []byte {
+	buf := make([]byte, 33)
+	buf[0] = 2 + byte(pubkey.Y.Bit(0))
+	x := pubkey.X.Bytes()
+	copy(buf[33-len(x):], x)
+	return buf
+}
+
+// decompressPubkey parses a public key in the 33 byte compressed format.
+func decompressPubkey(buf []byte) (*ecdsa.PublicKey, error) {
+	if len(buf) != 33 || (buf[0] != 2 && buf[0] != 3) {
+		return nil, errors.New("invalid compressed public key")
+	}
+	var (
+		curve = secp256k1.S256()
+		p     = curve.Params().P
+		x     = new(big.Int).SetBytes(buf[1:])
+	)
+	if x.Cmp(p) >= 0 {
+		return nil, errors.New("invalid compressed public key")
+	}
+	// y² = x³ + 7, and since p ≡ 3 mod 4, y = (y²)^((p+1)/4)
+	y2 := new(big.Int).Exp(x, big.NewInt(3), p)
+	y2.Add(y2, curve.Params().B).Mod(y2, p)
+	exp := new(big.Int).Add(p, big.NewInt(1))
+	exp.Rsh(exp, 2)
+	y := new(big.Int).Exp(y2, exp, p)
+	if new(big.Int).Exp(y, big.NewInt(2), p).Cmp(y2) != 0 {
+		return nil, errors.New("invalid compressed public key")
+	}
+	if y.Bit(0) != uint(buf[0]-2) {
+		y.Sub(p, y)
+	}
+	return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+}
@@ -0,0 +1,118 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package discover
+
+import (
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/EarthDollar/go-earthdollar/crypto"
+	"github.com/EarthDollar/go-earthdollar/rlp"
+)
+
+func TestPubkeyCompression(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		key, _ := crypto.GenerateKey()
+		pub, err := decompressPubkey(compressPubkey(&key.PublicKey))
+		if err != nil {
+			t.Fatalf("decompression failed: %v", err)
+		}
+		if pub.X.Cmp(key.X) != 0 || pub.Y.Cmp(key.Y) != 0 {
+			t.Fatalf("key mismatch after roundtrip")
+		}
+	}
+}
+
+func TestRecordRoundtrip(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	rec, err := NewRecord(key, 7, net.IP{10, 3, 58, 6}, 30301, 30303)
+	if err != nil {
+		t.Fatalf("failed to create record: %v", err)
+	}
+	if err := rec.Set("eth", uint(63)); err != nil {
+		t.Fatalf("failed to set capability: %v", err)
+	}
+	if rec.Signed() {
+		t.Fatalf("record still signed after modification")
+	}
+	if _, err := rlp.EncodeToBytes(rec); err != errRecordNotSigned {
+		t.Fatalf("unsigned record encoded: %v", err)
+	}
+	if err := rec.Sign(key); err != nil {
+		t.Fatalf("failed to sign record: %v", err)
+	}
+	node, err := ParseNode(rec.String())
+	if err != nil {
+		t.Fatalf("failed to parse record %q: %v", rec.String(), err)
+	}
+	want := NewNode(PubkeyID(&key.PublicKey), net.IP{10, 3, 58, 6}, 30301, 30303)
+	if node.String() != want.String() {
+		t.Errorf("node mismatch: have %v, want %v", node, want)
+	}
+	if node.Record() == nil || node.Record().Seq() != 7 {
+		t.Fatalf("record not retained: %v", node.Record())
+	}
+	var eth uint
+	if err := node.Record().Load("eth", &eth); err != nil || eth != 63 {
+		t.Errorf("capability mismatch: have %d (%v), want 63", eth, err)
+	}
+	if keys := node.Record().Keys(); !reflect.DeepEqual(keys, []string{"eth", "id", "ip", "secp256k1", "tcp", "udp"}) {
+		t.Errorf("key mismatch: %v", keys)
+	}
+}
+
+func TestRecordTampered(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	rec, _ := NewRecord(key, 1, net.IP{10, 0, 0, 1}, 30303, 30303)
+
+	// Re-encode the record with a modified port but the original signature
+	var raw []interface{}
+	if err := rlp.DecodeBytes(rec.raw, &raw); err != nil {
+		t.Fatalf("failed to decode record: %v", err)
+	}
+	port, _ := rlp.EncodeToBytes(uint16(1))
+	for i := 2; i < len(raw); i += 2 {
+		if string(raw[i].([]byte)) == RecordKeyTCP {
+			raw[i+1] = rlp.RawValue(port)
+		}
+	}
+	blob, _ := rlp.EncodeToBytes(raw)
+	if err := rlp.DecodeBytes(blob, new(Record)); err != errRecordInvalidSig {
+		t.Errorf("tampered record: have error %v, want %v", err, errRecordInvalidSig)
+	}
+}
+
+func TestNodeDBRecordUpdate(t *testing.T) {
+	db, _ := newNodeDB("", Version, NodeID{})
+	defer db.close()
+
+	key, _ := crypto.GenerateKey()
+	id := PubkeyID(&key.PublicKey)
+	rec1, _ := NewRecord(key, 1, net.IP{10, 0, 0, 1}, 30303, 30303)
+	rec2, _ := NewRecord(key, 2, net.IP{10, 0, 0, 2}, 30303, 30303)
+
+	if ok, err := db.updateRecord(rec2); !ok || err != nil {
+		t.Fatalf("failed to store record: %v, %v", ok, err)
+	}
+	if ok, _ := db.updateRecord(rec1); ok {
+		t.Errorf("older record replaced newer one")
+	}
+	if stored := db.record(id); stored == nil || stored.Seq() != 2 {
+		t.Errorf("stored record mismatch: %v", stored)
+	}
+}
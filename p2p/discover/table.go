@@ -68,8 +68,9 @@ type Table struct {
 
 	nodeAddedHook func(*Node) // for testing
 
-	net  transport
-	self *Node // metadata of the local node
+	net    transport
+	selfMu sync.RWMutex // protects self, which is replaced if the external IP changes
+	self   *Node        // metadata of the local node
 }
 
 type bondproc struct {
@@ -121,9 +122,63 @@ func newTable(t transport, ourID NodeID, ourAddr *net.UDPAddr, nodeDBPath string
 // Self returns the local node.
 // The returned node should not be modified by the caller.
 func (tab *Table) Self() *Node {
+	tab.selfMu.RLock()
+	defer tab.selfMu.RUnlock()
+
 	return tab.self
 }
 
+// SetExternalIP changes the address the local node is advertised with, e.g.
+// after the NAT gateway reported a new external IP.
+func (tab *Table) SetExternalIP(ip net.IP) {
+	tab.selfMu.Lock()
+	defer tab.selfMu.Unlock()
+
+	self := *tab.self
+	self.IP = ip
+	tab.self = &self
+	if t, ok := tab.net.(*udp); ok {
+		t.ourEndpoint = makeEndpoint(&net.UDPAddr{IP: ip, Port: int(t.ourEndpoint.UDP)}, t.ourEndpoint.TCP)
+	}
+}
+
+// Reputation retrieves the persisted behavioural score of a node together with
+// the time of its last update.
+func (tab *Table) Reputation(id NodeID) (int, time.Time) {
+	return tab.db.reputation(id)
+}
+
+// SetReputation persists the behavioural score of a node.
+func (tab *Table) SetReputation(id NodeID, score int, updated time.Time) error {
+	return tab.db.updateReputation(id, score, updated)
+}
+
+// Bans returns all bans persisted in the node database.
+func (tab *Table) Bans() []Ban {
+	return tab.db.bans()
+}
+
+// SetBan persists a ban in the node database.
+func (tab *Table) SetBan(b Ban) error {
+	return tab.db.updateBan(b)
+}
+
+// RemoveBan deletes a ban from the node database.
+func (tab *Table) RemoveBan(b Ban) error {
+	return tab.db.deleteBan(b)
+}
+
+// Record returns the latest signed record known for a node, or nil.
+func (tab *Table) Record(id NodeID) *Record {
+	return tab.db.record(id)
+}
+
+// UpdateRecord stores a signed node record if it is newer (has a higher
+// sequence number) than the one already known, reporting whether it was.
+func (tab *Table) UpdateRecord(r *Record) (bool, error) {
+	return tab.db.updateRecord(r)
+}
+
 // ReadRandomNodes fills the given slice with random nodes from the
 // table. It will not write the same node more than once. The nodes in
 // the slice are copies and can be modified by the caller.
@@ -193,6 +248,9 @@ func (tab *Table) SetFallbackNodes(nodes []*Node) error {
 	tab.mutex.Lock()
 	tab.nursery = make([]*Node, 0, len(nodes))
 	for _, n := range nodes {
+		if n.rec != nil {
+			tab.db.updateRecord(n.rec)
+		}
 		cpy := *n
 		// Recompute cpy.sha because the node might not have been
 		// created by NewNode or ParseNode.
@@ -246,7 +304,7 @@ func (tab *Table) lookup(targetID NodeID, refreshIfEmpty bool) []*Node {
 	)
 	// don't query further if we hit ourself.
 	// unlikely to happen often in practice.
-	asked[tab.self.ID] = true
+	asked[tab.Self().ID] = true
 
 	for {
 		tab.mutex.Lock()
@@ -398,7 +456,7 @@ func (tab *Table) doRefresh(done chan struct{}) {
 	tab.mutex.Unlock()
 
 	// Finally, do a self lookup to fill up the buckets.
-	tab.lookup(tab.self.ID, false)
+	tab.lookup(tab.Self().ID, false)
 }
 
 // closest returns the n nodes in the table that are closest to the
@@ -458,7 +516,7 @@ func (tab *Table) bondall(nodes []*Node) (result []*Node) {
 // If pinged is true, the remote node has just pinged us and one half
 // of the process can be skipped.
 func (tab *Table) bond(pinged bool, id NodeID, addr *net.UDPAddr, tcpPort uint16) (*Node, error) {
-	if id == tab.self.ID {
+	if id == tab.Self().ID {
 		return nil, errors.New("is self")
 	}
 	// Retrieve a previously known node and any recent findnode failures
@@ -553,7 +611,7 @@ func (tab *Table) ping(id NodeID, addr *net.UDPAddr) error {
 //
 // The caller must not hold tab.mutex.
 func (tab *Table) add(new *Node) {
-	b := tab.buckets[logdist(tab.self.sha, new.sha)]
+	b := tab.buckets[logdist(tab.Self().sha, new.sha)]
 	tab.mutex.Lock()
 	defer tab.mutex.Unlock()
 	if b.bump(new) {
@@ -590,10 +648,10 @@ func (tab *Table) add(new *Node) {
 func (tab *Table) stuff(nodes []*Node) {
 outer:
 	for _, n := range nodes {
-		if n.ID == tab.self.ID {
+		if n.ID == tab.Self().ID {
 			continue // don't add self
 		}
-		bucket := tab.buckets[logdist(tab.self.sha, n.sha)]
+		bucket := tab.buckets[logdist(tab.Self().sha, n.sha)]
 		for i := range bucket.entries {
 			if bucket.entries[i].ID == n.ID {
 				continue outer // already in bucket
@@ -613,7 +671,7 @@ outer:
 func (tab *Table) delete(node *Node) {
 	tab.mutex.Lock()
 	defer tab.mutex.Unlock()
-	bucket := tab.buckets[logdist(tab.self.sha, node.sha)]
+	bucket := tab.buckets[logdist(tab.Self().sha, node.sha)]
 	for i := range bucket.entries {
 		if bucket.entries[i].ID == node.ID {
 			bucket.entries = append(bucket.entries[:i], bucket.entries[i+1:]...)
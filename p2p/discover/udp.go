@@ -242,7 +242,7 @@ func newUDP(priv *ecdsa.PrivateKey, c conn, natm nat.Interface, nodeDBPath strin
 		if !realaddr.IP.IsLoopback() {
 			go nat.Map(natm, udp.closing, "udp", realaddr.Port, realaddr.Port, "ethereum discovery")
 		}
-		// TODO: react to external IP changes over time.
+		// External IP changes are applied later on via SetExternalIP.
 		if ext, err := natm.ExternalIP(); err == nil {
 			realaddr = &net.UDPAddr{IP: ext, Port: realaddr.Port}
 		}
@@ -266,13 +266,21 @@ func (t *udp) close() {
 	// TODO: wait for the loops to end.
 }
 
+// endpoint returns the advertised endpoint of the local node.
+func (t *udp) endpoint() rpcEndpoint {
+	t.selfMu.RLock()
+	defer t.selfMu.RUnlock()
+
+	return t.ourEndpoint
+}
+
 // ping sends a ping message to the given node and waits for a reply.
 func (t *udp) ping(toid NodeID, toaddr *net.UDPAddr) error {
 	// TODO: maybe check for ReplyTo field in callback to measure RTT
 	errc := t.pending(toid, pongPacket, func(interface{}) bool { return true })
 	t.send(toaddr, pingPacket, ping{
 		Version:    Version,
-		From:       t.ourEndpoint,
+		From:       t.endpoint(),
 		To:         makeEndpoint(toaddr, 0), // TODO: maybe use known TCP port from DB
 		Expiration: uint64(time.Now().Add(expiration).Unix()),
 	})
@@ -74,6 +74,20 @@ func newUDPTest(t *testing.T) *udpTest {
 	return test
 }
 
+func TestUDP_setExternalIP(t *testing.T) {
+	test := newUDPTest(t)
+	defer test.table.Close()
+
+	ext := net.IP{33, 44, 55, 66}
+	test.table.SetExternalIP(ext)
+	if ip := test.table.Self().IP; !ip.Equal(ext) {
+		t.Errorf("self IP mismatch: have %v, want %v", ip, ext)
+	}
+	if ep := test.udp.endpoint(); !ep.IP.Equal(ext) || ep.UDP != test.udp.ourEndpoint.UDP {
+		t.Errorf("advertised endpoint mismatch: have %v, want IP %v", ep, ext)
+	}
+}
+
 // handles a packet as if it had been sent to the transport.
 func (test *udpTest) packetIn(wantError error, ptype byte, data packet) error {
 	enc, err := encodePacket(test.remotekey, ptype, data)
@@ -0,0 +1,214 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package dnsdisc
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/EarthDollar/go-earthdollar/crypto"
+	"github.com/EarthDollar/go-earthdollar/logger"
+	"github.com/EarthDollar/go-earthdollar/logger/glog"
+	"github.com/EarthDollar/go-earthdollar/p2p/discover"
+)
+
+const (
+	// maxEntries limits the number of records fetched from a single tree
+	// (including linked trees) to protect against malicious publishers.
+	maxEntries = 10000
+
+	// maxLinkDepth limits how deep links to other trees are followed.
+	maxLinkDepth = 4
+)
+
+// Resolver is a DNS resolver capable of looking up TXT records.
+type Resolver interface {
+	LookupTXT(domain string) ([]string, error)
+}
+
+// netResolver resolves via the system DNS configuration.
+type netResolver struct{}
+
+func (netResolver) LookupTXT(domain string) ([]string, error) {
+	return net.LookupTXT(domain)
+}
+
+// Client retrieves node lists from DNS.
+type Client struct {
+	resolver Resolver
+}
+
+// NewClient creates a DNS discovery client. If resolver is nil, the system
+// resolver is used.
+func NewClient(resolver Resolver) *Client {
+	if resolver == nil {
+		resolver = netResolver{}
+	}
+	return &Client{resolver: resolver}
+}
+
+// SyncTree downloads the tree referenced by url (enrtree://<key>@<domain>),
+// following any links to other trees, and returns all nodes contained.
+func (c *Client) SyncTree(url string) ([]*discover.Node, error) {
+	link, err := parseLink(url)
+	if err != nil {
+		return nil, err
+	}
+	s := &syncer{client: c, visited: make(map[string]bool)}
+	if err := s.syncTree(link, 0); err != nil {
+		return nil, err
+	}
+	return s.nodes, nil
+}
+
+// SyncTrees downloads all given trees and returns the nodes contained in them.
+// Trees failing to resolve are skipped, the error of the last one returned.
+func (c *Client) SyncTrees(urls []string) ([]*discover.Node, error) {
+	var (
+		nodes   []*discover.Node
+		seen    = make(map[discover.NodeID]bool)
+		lastErr error
+	)
+	for _, url := range urls {
+		found, err := c.SyncTree(url)
+		if err != nil {
+			glog.V(logger.Debug).Infof("DNS discovery of %s failed: %v", url, err)
+			lastErr = err
+			continue
+		}
+		for _, n := range found {
+			if !seen[n.ID] {
+				seen[n.ID] = true
+				nodes = append(nodes, n)
+			}
+		}
+	}
+	return nodes, lastErr
+}
+
+// syncer is the state of a single tree synchronisation.
+type syncer struct {
+	client  *Client
+	visited map[string]bool // domains (trees) already synced
+	entries int
+	nodes   []*discover.Node
+}
+
+// syncTree retrieves and verifies the root of a tree and walks both subtrees.
+func (s *syncer) syncTree(link *linkEntry, depth int) error {
+	if s.visited[link.domain] {
+		return nil
+	}
+	s.visited[link.domain] = true
+
+	root, err := s.resolveRoot(link)
+	if err != nil {
+		return err
+	}
+	glog.V(logger.Detail).Infof("DNS discovery: syncing tree %s (seq %d)", link.domain, root.seq)
+
+	var links []*linkEntry
+	if err := s.walk(link.domain, root.eroot, false, &links); err != nil {
+		return err
+	}
+	if depth >= maxLinkDepth {
+		return nil
+	}
+	if err := s.walk(link.domain, root.lroot, true, &links); err != nil {
+		return err
+	}
+	for _, l := range links {
+		if err := s.syncTree(l, depth+1); err != nil {
+			glog.V(logger.Debug).Infof("DNS discovery: linked tree %s failed: %v", l.domain, err)
+		}
+	}
+	return nil
+}
+
+// resolveRoot retrieves the root record of a tree and checks its signature.
+func (s *syncer) resolveRoot(link *linkEntry) (*rootEntry, error) {
+	txts, err := s.client.resolver.LookupTXT(link.domain)
+	if err != nil {
+		return nil, err
+	}
+	for _, txt := range txts {
+		if strings.HasPrefix(txt, rootPrefix) {
+			root, err := parseRoot(txt)
+			if err != nil {
+				return nil, err
+			}
+			if !root.verifySignature(link.pubkey) {
+				return nil, errInvalidSig
+			}
+			return root, nil
+		}
+	}
+	return nil, fmt.Errorf("no root found at %s", link.domain)
+}
+
+// walk resolves the subtree rooted at hash, collecting nodes (or links if
+// the link subtree is being walked).
+func (s *syncer) walk(domain, hash string, linkTree bool, links *[]*linkEntry) error {
+	e, err := s.resolveEntry(domain, hash)
+	if err != nil {
+		return err
+	}
+	switch e := e.(type) {
+	case *branchEntry:
+		for _, child := range e.children {
+			if err := s.walk(domain, child, linkTree, links); err != nil {
+				return err
+			}
+		}
+	case *nodeEntry:
+		if linkTree {
+			return fmt.Errorf("node entry %s in link tree", hash)
+		}
+		s.nodes = append(s.nodes, e.node)
+	case *linkEntry:
+		if !linkTree {
+			return fmt.Errorf("link entry %s in node tree", hash)
+		}
+		*links = append(*links, e)
+	default:
+		return fmt.Errorf("unexpected entry %s: %v", hash, e)
+	}
+	return nil
+}
+
+// resolveEntry retrieves and authenticates a non-root record of a tree.
+func (s *syncer) resolveEntry(domain, hash string) (entry, error) {
+	if s.entries++; s.entries > maxEntries {
+		return nil, fmt.Errorf("too many entries (limit %d)", maxEntries)
+	}
+	txts, err := s.client.resolver.LookupTXT(hash + "." + domain)
+	if err != nil {
+		return nil, err
+	}
+	want, err := b32format.DecodeString(hash)
+	if err != nil {
+		return nil, errInvalidChild
+	}
+	// Large records might be split up into multiple strings
+	txt := strings.Join(txts, "")
+	if h := crypto.Keccak256([]byte(txt)); !bytes.HasPrefix(h, want) {
+		return nil, fmt.Errorf("%s.%s: %v", hash, domain, errHashMismatch)
+	}
+	return parseEntry(txt)
+}
@@ -0,0 +1,121 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package dnsdisc
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/EarthDollar/go-earthdollar/crypto"
+	"github.com/EarthDollar/go-earthdollar/p2p/discover"
+)
+
+// mapResolver is a Resolver serving records from memory.
+type mapResolver map[string]string
+
+func (mr mapResolver) LookupTXT(name string) ([]string, error) {
+	if txt, ok := mr[name]; ok {
+		return []string{txt}, nil
+	}
+	return nil, fmt.Errorf("no such host: %s", name)
+}
+
+func (mr mapResolver) add(records map[string]string) {
+	for name, txt := range records {
+		mr[name] = txt
+	}
+}
+
+func testNodes(n int) []*discover.Node {
+	nodes := make([]*discover.Node, n)
+	for i := range nodes {
+		key, _ := crypto.GenerateKey()
+		nodes[i] = discover.NewNode(discover.PubkeyID(&key.PublicKey), net.IP{10, 0, byte(i >> 8), byte(i)}, 30303, 30303)
+	}
+	return nodes
+}
+
+func makeTestTree(t *testing.T, domain string, nodes []*discover.Node, links []string) (*Tree, string) {
+	tree, err := MakeTree(1, nodes, links)
+	if err != nil {
+		t.Fatalf("failed to make tree: %v", err)
+	}
+	key, _ := crypto.GenerateKey()
+	url, err := tree.Sign(key, domain)
+	if err != nil {
+		t.Fatalf("failed to sign tree: %v", err)
+	}
+	return tree, url
+}
+
+func sortedIDs(nodes []*discover.Node) []string {
+	ids := make([]string, len(nodes))
+	for i, n := range nodes {
+		ids[i] = n.ID.String()
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func TestClientSyncTree(t *testing.T) {
+	var (
+		linkedNodes = testNodes(3)
+		mainNodes   = testNodes(40)
+		resolver    = make(mapResolver)
+	)
+	linked, linkedURL := makeTestTree(t, "linked.example.org", linkedNodes, nil)
+	resolver.add(linked.ToTXT("linked.example.org"))
+	tree, url := makeTestTree(t, "nodes.example.org", mainNodes, []string{linkedURL})
+	resolver.add(tree.ToTXT("nodes.example.org"))
+
+	if have, want := sortedIDs(tree.Nodes()), sortedIDs(mainNodes); !reflect.DeepEqual(have, want) {
+		t.Fatalf("tree node mismatch: have %v, want %v", have, want)
+	}
+	nodes, err := NewClient(resolver).SyncTree(url)
+	if err != nil {
+		t.Fatalf("sync failed: %v", err)
+	}
+	if have, want := sortedIDs(nodes), sortedIDs(append(mainNodes, linkedNodes...)); !reflect.DeepEqual(have, want) {
+		t.Errorf("synced node mismatch: have %v, want %v", have, want)
+	}
+}
+
+func TestClientSyncTreeInvalid(t *testing.T) {
+	resolver := make(mapResolver)
+	tree, url := makeTestTree(t, "nodes.example.org", testNodes(5), nil)
+	resolver.add(tree.ToTXT("nodes.example.org"))
+
+	// A root signed by a different key must be rejected
+	other, _ := crypto.GenerateKey()
+	otherID := discover.PubkeyID(&other.PublicKey)
+	if _, err := NewClient(resolver).SyncTree(fmt.Sprintf("enrtree://%x@nodes.example.org", otherID[:])); err != errInvalidSig {
+		t.Errorf("foreign key: have error %v, want %v", err, errInvalidSig)
+	}
+	// A tampered record must be rejected
+	for name, txt := range resolver {
+		if name != "nodes.example.org" && txt[:len(nodePrefix)] == nodePrefix {
+			resolver[name] = testNodes(1)[0].String()
+			break
+		}
+	}
+	if _, err := NewClient(resolver).SyncTree(url); err == nil {
+		t.Errorf("tampered tree synced without error")
+	}
+}
@@ -0,0 +1,363 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package dnsdisc implements node discovery via signed node lists published in
+// DNS TXT records.
+//
+// A list is published as a merkle tree of TXT records below a domain. The root
+// record (located at the domain itself) is signed by the publisher and commits
+// to the hashes of the node and link subtrees. All other records are stored at
+// the subdomain named by their own hash, which allows clients to authenticate
+// every record they receive:
+//
+//	enrtree-root:v1 e=<node-root> l=<link-root> seq=<n> sig=<signature>
+//	enrtree-branch:<hash>,<hash>,...
+//	enr:<signed node record>
+//	enode://<node-id>@<ip>:<port>
+//	enrtree://<public-key>@<domain>
+//
+// Trees are referenced by URLs of the form enrtree://<public-key>@<domain>,
+// where the public key is the hex encoded key the root must be signed with.
+package dnsdisc
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/base32"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/EarthDollar/go-earthdollar/crypto"
+	"github.com/EarthDollar/go-earthdollar/p2p/discover"
+)
+
+const (
+	rootPrefix   = "enrtree-root:v1"
+	branchPrefix = "enrtree-branch:"
+	linkPrefix   = "enrtree://"
+	nodePrefix   = "enode://"
+	recordPrefix = "enr:"
+
+	// maxChildren is the maximum number of hashes in a single branch record,
+	// chosen such that a branch fits into a TXT record string.
+	maxChildren = 9
+
+	// hashAbbrev is the number of hash bytes used to name a subdomain.
+	hashAbbrev = 16
+)
+
+var (
+	errUnknownEntry = errors.New("unknown entry type")
+	errNoPubkey     = errors.New("missing public key")
+	errBadPubkey    = errors.New("invalid public key")
+	errInvalidSig   = errors.New("invalid root signature")
+	errInvalidChild = errors.New("invalid child hash")
+	errHashMismatch = errors.New("hash mismatch")
+)
+
+// b32format is the encoding of subdomain hashes.
+var b32format = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// b64format is the encoding of root signatures.
+var b64format = base64.RawURLEncoding
+
+// entry is a single record of the tree.
+type entry interface {
+	fmt.Stringer
+}
+
+type (
+	rootEntry struct {
+		eroot string
+		lroot string
+		seq   uint
+		sig   []byte
+	}
+	branchEntry struct {
+		children []string
+	}
+	nodeEntry struct {
+		node *discover.Node
+	}
+	linkEntry struct {
+		str    string
+		domain string
+		pubkey discover.NodeID
+	}
+)
+
+func (e *rootEntry) String() string {
+	return fmt.Sprintf("%s sig=%s", e.content(), b64format.EncodeToString(e.sig))
+}
+
+// content is the signed portion of the root record.
+func (e *rootEntry) content() string {
+	return fmt.Sprintf("%s e=%s l=%s seq=%d", rootPrefix, e.eroot, e.lroot, e.seq)
+}
+
+// sigHash returns the hash the root signature is created over.
+func (e *rootEntry) sigHash() []byte {
+	return crypto.Keccak256([]byte(e.content()))
+}
+
+// verifySignature checks whether the root was signed by the given key.
+func (e *rootEntry) verifySignature(pubkey discover.NodeID) bool {
+	if len(e.sig) != 65 {
+		return false
+	}
+	signer, err := crypto.SigToPub(e.sigHash(), e.sig)
+	if err != nil {
+		return false
+	}
+	return discover.PubkeyID(signer) == pubkey
+}
+
+func (e *branchEntry) String() string {
+	return branchPrefix + strings.Join(e.children, ",")
+}
+
+func (e *nodeEntry) String() string {
+	if rec := e.node.Record(); rec != nil && rec.Signed() {
+		return rec.String()
+	}
+	return e.node.String()
+}
+
+func (e *linkEntry) String() string {
+	return linkPrefix + e.str
+}
+
+// subdomain returns the name under which an entry is published.
+func subdomain(e entry) string {
+	h := crypto.Keccak256([]byte(e.String()))
+	return b32format.EncodeToString(h[:hashAbbrev])
+}
+
+// parseEntry decodes the content of a TXT record.
+func parseEntry(s string) (entry, error) {
+	switch {
+	case strings.HasPrefix(s, rootPrefix):
+		return parseRoot(s)
+	case strings.HasPrefix(s, branchPrefix):
+		return parseBranch(s)
+	case strings.HasPrefix(s, linkPrefix):
+		return parseLink(s)
+	case strings.HasPrefix(s, nodePrefix), strings.HasPrefix(s, recordPrefix):
+		node, err := discover.ParseNode(s)
+		if err != nil {
+			return nil, err
+		}
+		return &nodeEntry{node}, nil
+	default:
+		return nil, errUnknownEntry
+	}
+}
+
+func parseRoot(s string) (*rootEntry, error) {
+	var (
+		e      rootEntry
+		fields = strings.Fields(strings.TrimPrefix(s, rootPrefix))
+		sig    string
+	)
+	for _, field := range fields {
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid root field %q", field)
+		}
+		switch kv[0] {
+		case "e":
+			e.eroot = kv[1]
+		case "l":
+			e.lroot = kv[1]
+		case "seq":
+			seq, err := strconv.ParseUint(kv[1], 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("invalid root sequence number: %v", err)
+			}
+			e.seq = uint(seq)
+		case "sig":
+			sig = kv[1]
+		}
+	}
+	if !isValidHash(e.eroot) || !isValidHash(e.lroot) {
+		return nil, errInvalidChild
+	}
+	var err error
+	if e.sig, err = b64format.DecodeString(sig); err != nil || len(e.sig) != 65 {
+		return nil, errInvalidSig
+	}
+	return &e, nil
+}
+
+func parseBranch(s string) (*branchEntry, error) {
+	s = strings.TrimPrefix(s, branchPrefix)
+	if s == "" {
+		return &branchEntry{}, nil
+	}
+	children := strings.Split(s, ",")
+	for _, c := range children {
+		if !isValidHash(c) {
+			return nil, errInvalidChild
+		}
+	}
+	return &branchEntry{children}, nil
+}
+
+func parseLink(s string) (*linkEntry, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "enrtree" || u.Host == "" {
+		return nil, fmt.Errorf("invalid tree url %q", s)
+	}
+	if u.User == nil {
+		return nil, errNoPubkey
+	}
+	pubkey, err := discover.HexID(u.User.String())
+	if err != nil {
+		return nil, errBadPubkey
+	}
+	return &linkEntry{str: strings.TrimPrefix(s, linkPrefix), domain: u.Host, pubkey: pubkey}, nil
+}
+
+// isValidHash reports whether s is a well formed subdomain hash.
+func isValidHash(s string) bool {
+	dlen := b32format.DecodedLen(len(s))
+	if dlen < 12 || dlen > 32 || strings.ContainsAny(s, "\n\r") {
+		return false
+	}
+	buf := make([]byte, 32)
+	_, err := b32format.Decode(buf, []byte(s))
+	return err == nil
+}
+
+// Tree is a signed node list ready to be published to DNS.
+type Tree struct {
+	root    *rootEntry
+	entries map[string]entry
+}
+
+// MakeTree creates a tree containing the given nodes and links to other trees.
+func MakeTree(seq uint, nodes []*discover.Node, links []string) (*Tree, error) {
+	sorted := make([]*discover.Node, len(nodes))
+	copy(sorted, nodes)
+	sort.Sort(nodesByID(sorted))
+
+	nodeEntries := make([]entry, len(sorted))
+	for i, n := range sorted {
+		if n.Incomplete() {
+			return nil, fmt.Errorf("incomplete node %v", n)
+		}
+		nodeEntries[i] = &nodeEntry{n}
+	}
+	linkEntries := make([]entry, len(links))
+	for i, l := range links {
+		e, err := parseLink(l)
+		if err != nil {
+			return nil, err
+		}
+		linkEntries[i] = e
+	}
+	t := &Tree{entries: make(map[string]entry)}
+	eroot := t.build(nodeEntries)
+	t.entries[subdomain(eroot)] = eroot
+	lroot := t.build(linkEntries)
+	t.entries[subdomain(lroot)] = lroot
+	t.root = &rootEntry{seq: seq, eroot: subdomain(eroot), lroot: subdomain(lroot)}
+	return t, nil
+}
+
+// build assembles the branches above the given leaves, returning the subtree
+// root. All non-root entries are added to the tree.
+func (t *Tree) build(leaves []entry) entry {
+	if len(leaves) == 0 {
+		return &branchEntry{}
+	}
+	if len(leaves) == 1 {
+		return leaves[0]
+	}
+	if len(leaves) <= maxChildren {
+		hashes := make([]string, len(leaves))
+		for i, e := range leaves {
+			hashes[i] = subdomain(e)
+			t.entries[hashes[i]] = e
+		}
+		return &branchEntry{hashes}
+	}
+	var subtrees []entry
+	for len(leaves) > 0 {
+		n := maxChildren
+		if len(leaves) < n {
+			n = len(leaves)
+		}
+		sub := t.build(leaves[:n])
+		leaves = leaves[n:]
+		subtrees = append(subtrees, sub)
+		t.entries[subdomain(sub)] = sub
+	}
+	return t.build(subtrees)
+}
+
+// Sign signs the tree with the given key, returning the URL of the tree when
+// published under domain.
+func (t *Tree) Sign(key *ecdsa.PrivateKey, domain string) (string, error) {
+	sig, err := crypto.Sign(t.root.sigHash(), key)
+	if err != nil {
+		return "", err
+	}
+	t.root.sig = sig
+	id := discover.PubkeyID(&key.PublicKey)
+	return fmt.Sprintf("%s%x@%s", linkPrefix, id[:], domain), nil
+}
+
+// Seq returns the sequence number of the tree.
+func (t *Tree) Seq() uint {
+	return t.root.seq
+}
+
+// Nodes returns all nodes contained in the tree.
+func (t *Tree) Nodes() []*discover.Node {
+	var nodes []*discover.Node
+	for _, e := range t.entries {
+		if ne, ok := e.(*nodeEntry); ok {
+			nodes = append(nodes, ne.node)
+		}
+	}
+	sort.Sort(nodesByID(nodes))
+	return nodes
+}
+
+// ToTXT returns all records of the tree as a map from fully qualified names to
+// TXT contents, ready to be published under domain.
+func (t *Tree) ToTXT(domain string) map[string]string {
+	records := map[string]string{domain: t.root.String()}
+	for hash, e := range t.entries {
+		records[hash+"."+domain] = e.String()
+	}
+	return records
+}
+
+type nodesByID []*discover.Node
+
+func (ns nodesByID) Len() int           { return len(ns) }
+func (ns nodesByID) Swap(i, j int)      { ns[i], ns[j] = ns[j], ns[i] }
+func (ns nodesByID) Less(i, j int) bool { return bytes.Compare(ns[i].ID[:], ns[j].ID[:]) < 0 }
@@ -0,0 +1,68 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import "github.com/EarthDollar/go-earthdollar/p2p/discover"
+
+// PeerEventType is the kind of a peer lifecycle event.
+type PeerEventType string
+
+const (
+	// PeerEventTypeAdd is posted when a peer passed the handshakes and its
+	// protocols are about to be started.
+	PeerEventTypeAdd PeerEventType = "add"
+
+	// PeerEventTypeDrop is posted when a connected peer is removed.
+	PeerEventTypeDrop PeerEventType = "drop"
+
+	// PeerEventTypeHandshakeFailed is posted when a connection is closed
+	// before becoming a peer, e.g. due to failed handshakes or peer limits.
+	PeerEventTypeHandshakeFailed PeerEventType = "handshake failed"
+)
+
+// PeerEvent is posted to the server's event mux whenever a peer is added or
+// dropped, or a connection fails to become a peer.
+type PeerEvent struct {
+	Type          PeerEventType `json:"type"`
+	Peer          string        `json:"peer,omitempty"` // Node id, if already known
+	RemoteAddress string        `json:"remoteAddress"`
+	Inbound       bool          `json:"inbound"`
+	Reason        string        `json:"reason,omitempty"` // Drop or failure reason
+}
+
+// postEvent publishes a peer event if an event mux is configured.
+func (srv *Server) postEvent(ev PeerEvent) {
+	if srv.EventMux != nil {
+		srv.EventMux.Post(ev)
+	}
+}
+
+// connEvent assembles a peer event describing the given connection.
+func connEvent(typ PeerEventType, c *conn, reason error) PeerEvent {
+	ev := PeerEvent{
+		Type:          typ,
+		RemoteAddress: c.fd.RemoteAddr().String(),
+		Inbound:       c.is(inboundConn),
+	}
+	if (c.id != discover.NodeID{}) {
+		ev.Peer = c.id.String()
+	}
+	if reason != nil {
+		ev.Reason = reason.Error()
+	}
+	return ev
+}
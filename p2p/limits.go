@@ -0,0 +1,81 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import "time"
+
+const (
+	// defaultMaxMessageSize is the default cap on the payload size of a single
+	// message, matching the limits of the eth and les sub-protocols.
+	defaultMaxMessageSize = 10 * 1024 * 1024
+
+	// defaultMaxMessageRate is the default number of messages per second a
+	// peer may send on average.
+	defaultMaxMessageRate = 1000
+
+	// messageBurstFactor is the multiple of the rate a peer may send in bursts.
+	messageBurstFactor = 2
+)
+
+// msgLimits is the inbound message budget enforced on a single peer.
+type msgLimits struct {
+	maxSize uint32  // Maximum payload size of a single message
+	rate    float64 // Messages replenished per second
+	burst   float64 // Maximum number of messages accumulated
+	tokens  float64 // Messages currently allowed
+	last    time.Time
+}
+
+// newMsgLimits creates a message budget, starting with a full burst allowance.
+func newMsgLimits(maxSize uint32, rate int) *msgLimits {
+	burst := float64(rate * messageBurstFactor)
+	return &msgLimits{maxSize: maxSize, rate: float64(rate), burst: burst, tokens: burst}
+}
+
+// check charges a received message against the budget, returning an error if
+// the message is too large or the peer exceeded its message rate.
+func (l *msgLimits) check(msg Msg) error {
+	if msg.Size > l.maxSize {
+		return newPeerError(errMsgLimit, "message size %d exceeds limit %d", msg.Size, l.maxSize)
+	}
+	now := msg.ReceivedAt
+	if !l.last.IsZero() {
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+	}
+	l.last = now
+
+	if l.tokens < 1 {
+		return newPeerError(errMsgLimit, "message rate exceeds %v/s", l.rate)
+	}
+	l.tokens--
+	return nil
+}
+
+// messageLimits returns the message budget to enforce on a new peer.
+func (srv *Server) messageLimits() *msgLimits {
+	size, rate := srv.MaxMessageSize, srv.MaxMessageRate
+	if size == 0 {
+		size = defaultMaxMessageSize
+	}
+	if rate == 0 {
+		rate = defaultMaxMessageRate
+	}
+	return newMsgLimits(size, rate)
+}
@@ -0,0 +1,68 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMsgLimits(t *testing.T) {
+	limits := newMsgLimits(100, 10)
+	now := time.Now()
+
+	if err := limits.check(Msg{Size: 101, ReceivedAt: now}); err == nil {
+		t.Errorf("oversized message accepted")
+	}
+	// The full burst allowance must be usable at once, but not more
+	for i := 0; i < 10*messageBurstFactor; i++ {
+		if err := limits.check(Msg{Size: 100, ReceivedAt: now}); err != nil {
+			t.Fatalf("message %d rejected within burst: %v", i, err)
+		}
+	}
+	if err := limits.check(Msg{ReceivedAt: now}); err == nil {
+		t.Errorf("message accepted beyond burst")
+	}
+	// The allowance must recover at the configured rate
+	now = now.Add(500 * time.Millisecond)
+	for i := 0; i < 5; i++ {
+		if err := limits.check(Msg{ReceivedAt: now}); err != nil {
+			t.Fatalf("message %d rejected after recovery: %v", i, err)
+		}
+	}
+	if err := limits.check(Msg{ReceivedAt: now}); err == nil {
+		t.Errorf("message accepted beyond recovered allowance")
+	}
+}
+
+func TestPeerMessageLimit(t *testing.T) {
+	closer, rw, peer, disc := testPeer([]Protocol{discard})
+	defer closer()
+	peer.limits = newMsgLimits(8, 100)
+
+	if err := Send(rw, baseProtocolLength, make([]byte, 16)); err != nil {
+		t.Fatalf("send failed: %v", err)
+	}
+	select {
+	case reason := <-disc:
+		if reason != DiscProtocolError {
+			t.Errorf("run returned wrong reason: got %v, want %v", reason, DiscProtocolError)
+		}
+	case <-time.After(500 * time.Millisecond):
+		t.Error("peer not disconnected")
+	}
+}
@@ -94,6 +94,7 @@ func Parse(spec string) (Interface, error) {
 const (
 	mapTimeout        = 20 * time.Minute
 	mapUpdateInterval = 15 * time.Minute
+	mapRetryInterval  = time.Minute
 )
 
 // Map adds a port mapping on m and keeps it alive until c is closed.
@@ -107,6 +108,7 @@ func Map(m Interface, c chan struct{}, protocol string, extport, intport int, na
 	}()
 	if err := m.AddMapping(protocol, extport, intport, name, mapTimeout); err != nil {
 		glog.V(logger.Debug).Infof("network port %s:%d could not be mapped: %v\n", protocol, intport, err)
+		refresh.Reset(mapRetryInterval)
 	} else {
 		glog.V(logger.Info).Infof("mapped network port %s:%d -> %d (%s) using %s\n", protocol, extport, intport, name, m)
 	}
@@ -119,9 +121,12 @@ func Map(m Interface, c chan struct{}, protocol string, extport, intport int, na
 		case <-refresh.C:
 			glog.V(logger.Detail).Infof("refresh port mapping %s:%d -> %d (%s) using %s\n", protocol, extport, intport, name, m)
 			if err := m.AddMapping(protocol, extport, intport, name, mapTimeout); err != nil {
+				// Retry sooner, the gateway might have been restarted or lost its lease
 				glog.V(logger.Debug).Infof("network port %s:%d could not be mapped: %v\n", protocol, intport, err)
+				refresh.Reset(mapRetryInterval)
+			} else {
+				refresh.Reset(mapUpdateInterval)
 			}
-			refresh.Reset(mapUpdateInterval)
 		}
 	}
 }
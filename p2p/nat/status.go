@@ -0,0 +1,141 @@
+// Copyright 2015 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package nat
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Status wraps a NAT interface, recording the outcome of all port mapping
+// operations and external address queries performed through it, so that the
+// current state of the NAT traversal can be reported.
+type Status struct {
+	Interface
+
+	mu       sync.Mutex
+	mappings map[string]*Mapping
+	extIP    net.IP
+	extErr   error
+}
+
+// Mapping describes the state of a single port mapping.
+type Mapping struct {
+	Protocol string    `json:"protocol"`
+	ExtPort  int       `json:"externalPort"`
+	IntPort  int       `json:"internalPort"`
+	Name     string    `json:"name"`
+	Renewed  time.Time `json:"renewed,omitempty"` // Time of the last successful (re)mapping
+	Expires  time.Time `json:"expires,omitempty"` // End of the lease granted by the last mapping
+	Error    string    `json:"error,omitempty"`   // Error of the last mapping attempt, if failed
+}
+
+// StatusInfo is a snapshot of the NAT traversal state.
+type StatusInfo struct {
+	Mechanism  string    `json:"mechanism"`
+	ExternalIP string    `json:"externalIP,omitempty"`
+	Error      string    `json:"error,omitempty"` // Error of the last external address query
+	Mappings   []Mapping `json:"mappings"`
+}
+
+// NewStatus creates a status tracker around the given NAT interface.
+func NewStatus(m Interface) *Status {
+	return &Status{Interface: m, mappings: make(map[string]*Mapping)}
+}
+
+func mappingKey(protocol string, extport, intport int) string {
+	return fmt.Sprintf("%s:%d:%d", protocol, extport, intport)
+}
+
+// AddMapping implements Interface, recording the result of the operation.
+func (s *Status) AddMapping(protocol string, extport, intport int, name string, lifetime time.Duration) error {
+	err := s.Interface.AddMapping(protocol, extport, intport, name, lifetime)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := mappingKey(protocol, extport, intport)
+	m := s.mappings[key]
+	if m == nil {
+		m = &Mapping{Protocol: protocol, ExtPort: extport, IntPort: intport, Name: name}
+		s.mappings[key] = m
+	}
+	if err != nil {
+		m.Error = err.Error()
+	} else {
+		now := time.Now()
+		m.Renewed, m.Expires, m.Error = now, now.Add(lifetime), ""
+	}
+	return err
+}
+
+// DeleteMapping implements Interface, forgetting the mapping.
+func (s *Status) DeleteMapping(protocol string, extport, intport int) error {
+	s.mu.Lock()
+	delete(s.mappings, mappingKey(protocol, extport, intport))
+	s.mu.Unlock()
+
+	return s.Interface.DeleteMapping(protocol, extport, intport)
+}
+
+// ExternalIP implements Interface, recording the address reported.
+func (s *Status) ExternalIP() (net.IP, error) {
+	ip, err := s.Interface.ExternalIP()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err != nil {
+		s.extErr = err
+	} else {
+		s.extIP, s.extErr = ip, nil
+	}
+	return ip, err
+}
+
+// Info returns a snapshot of the current mappings and external address.
+func (s *Status) Info() *StatusInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	info := &StatusInfo{Mechanism: s.Interface.String(), Mappings: []Mapping{}}
+	if s.extIP != nil {
+		info.ExternalIP = s.extIP.String()
+	}
+	if s.extErr != nil {
+		info.Error = s.extErr.Error()
+	}
+	for _, m := range s.mappings {
+		info.Mappings = append(info.Mappings, *m)
+	}
+	sort.Sort(mappingsByPort(info.Mappings))
+	return info
+}
+
+type mappingsByPort []Mapping
+
+func (ms mappingsByPort) Len() int      { return len(ms) }
+func (ms mappingsByPort) Swap(i, j int) { ms[i], ms[j] = ms[j], ms[i] }
+func (ms mappingsByPort) Less(i, j int) bool {
+	if ms[i].Protocol != ms[j].Protocol {
+		return ms[i].Protocol < ms[j].Protocol
+	}
+	return ms[i].ExtPort < ms[j].ExtPort
+}
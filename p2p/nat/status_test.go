@@ -0,0 +1,68 @@
+// Copyright 2015 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package nat
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// flakyNAT is a NAT interface failing all mappings on a given port.
+type flakyNAT struct {
+	extIP
+	failPort int
+}
+
+func (n flakyNAT) AddMapping(protocol string, extport, intport int, name string, lifetime time.Duration) error {
+	if extport == n.failPort {
+		return errors.New("mapping refused")
+	}
+	return nil
+}
+
+func TestStatusInfo(t *testing.T) {
+	status := NewStatus(flakyNAT{extIP{33, 44, 55, 66}, 30304})
+
+	if err := status.AddMapping("tcp", 30303, 30303, "p2p", time.Minute); err != nil {
+		t.Fatalf("unexpected mapping error: %v", err)
+	}
+	if err := status.AddMapping("udp", 30304, 30304, "discovery", time.Minute); err == nil {
+		t.Fatalf("expected mapping error")
+	}
+	if _, err := status.ExternalIP(); err != nil {
+		t.Fatalf("unexpected external IP error: %v", err)
+	}
+	info := status.Info()
+	if info.ExternalIP != "33.44.55.66" {
+		t.Errorf("external IP mismatch: have %q, want %q", info.ExternalIP, "33.44.55.66")
+	}
+	if len(info.Mappings) != 2 {
+		t.Fatalf("mapping count mismatch: have %d, want 2", len(info.Mappings))
+	}
+	if m := info.Mappings[0]; m.Protocol != "tcp" || m.Error != "" || m.Renewed.IsZero() || !m.Expires.After(m.Renewed) {
+		t.Errorf("successful mapping mismatch: %+v", m)
+	}
+	if m := info.Mappings[1]; m.Protocol != "udp" || m.Error == "" || !m.Renewed.IsZero() {
+		t.Errorf("failed mapping mismatch: %+v", m)
+	}
+	// Deleted mappings must not be reported any more
+	status.DeleteMapping("tcp", 30303, 30303)
+	if info := status.Info(); len(info.Mappings) != 1 {
+		t.Errorf("mapping count mismatch after delete: have %d, want 1", len(info.Mappings))
+	}
+}
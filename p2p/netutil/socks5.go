@@ -0,0 +1,200 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package netutil
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	socks5Version = 5
+
+	socks5AuthNone     = 0x00
+	socks5AuthPassword = 0x02
+	socks5AuthNoAccept = 0xff
+
+	socks5Connect = 0x01
+
+	socks5AddrIPv4   = 0x01
+	socks5AddrDomain = 0x03
+	socks5AddrIPv6   = 0x04
+)
+
+var socks5Errors = []string{
+	1: "general failure",
+	2: "connection not allowed by ruleset",
+	3: "network unreachable",
+	4: "host unreachable",
+	5: "connection refused",
+	6: "TTL expired",
+	7: "command not supported",
+	8: "address type not supported",
+}
+
+// SOCKS5Dialer establishes TCP connections through a SOCKS5 proxy (RFC 1928),
+// optionally authenticating with a username and password (RFC 1929). It can
+// be used to route outbound connections through e.g. Tor.
+type SOCKS5Dialer struct {
+	Proxy    string      // Address (host:port) of the proxy
+	Username string      // Optional username for authentication
+	Password string      // Optional password for authentication
+	Forward  *net.Dialer // Dialer used to connect to the proxy (nil = default)
+}
+
+// ParseSOCKS5 parses a proxy address of the form [user:password@]host:port.
+func ParseSOCKS5(s string) (*SOCKS5Dialer, error) {
+	d := new(SOCKS5Dialer)
+	if at := strings.LastIndex(s, "@"); at >= 0 {
+		creds := strings.SplitN(s[:at], ":", 2)
+		d.Username = creds[0]
+		if len(creds) == 2 {
+			d.Password = creds[1]
+		}
+		s = s[at+1:]
+	}
+	if _, _, err := net.SplitHostPort(s); err != nil {
+		return nil, fmt.Errorf("invalid proxy address %q: %v", s, err)
+	}
+	if len(d.Username) > 255 || len(d.Password) > 255 {
+		return nil, errors.New("proxy credentials too long")
+	}
+	d.Proxy = s
+	return d, nil
+}
+
+// Dial connects to addr via the proxy. Only the "tcp" network is supported.
+func (d *SOCKS5Dialer) Dial(network, addr string) (net.Conn, error) {
+	if network != "tcp" && network != "tcp4" && network != "tcp6" {
+		return nil, fmt.Errorf("socks5: network %q not supported", network)
+	}
+	forward := d.Forward
+	if forward == nil {
+		forward = new(net.Dialer)
+	}
+	conn, err := forward.Dial("tcp", d.Proxy)
+	if err != nil {
+		return nil, err
+	}
+	if forward.Timeout > 0 {
+		conn.SetDeadline(time.Now().Add(forward.Timeout))
+	}
+	if err := d.connect(conn, addr); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("socks5 proxy %s: %v", d.Proxy, err)
+	}
+	conn.SetDeadline(time.Time{})
+	return conn, nil
+}
+
+// connect runs the SOCKS5 handshake on conn, requesting a connection to addr.
+func (d *SOCKS5Dialer) connect(conn net.Conn, addr string) error {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		return fmt.Errorf("invalid port %q", portStr)
+	}
+	// Negotiate the authentication method
+	method := byte(socks5AuthNone)
+	if d.Username != "" {
+		method = socks5AuthPassword
+	}
+	if _, err := conn.Write([]byte{socks5Version, 1, method}); err != nil {
+		return err
+	}
+	buf := make([]byte, 2)
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		return err
+	}
+	if buf[0] != socks5Version {
+		return fmt.Errorf("unexpected protocol version %d", buf[0])
+	}
+	if buf[1] == socks5AuthNoAccept || buf[1] != method {
+		return errors.New("no acceptable authentication method")
+	}
+	if method == socks5AuthPassword {
+		req := []byte{1, byte(len(d.Username))}
+		req = append(req, d.Username...)
+		req = append(req, byte(len(d.Password)))
+		req = append(req, d.Password...)
+		if _, err := conn.Write(req); err != nil {
+			return err
+		}
+		if _, err := io.ReadFull(conn, buf); err != nil {
+			return err
+		}
+		if buf[1] != 0 {
+			return errors.New("authentication failed")
+		}
+	}
+	// Request the connection
+	req := []byte{socks5Version, socks5Connect, 0}
+	if ip := net.ParseIP(host); ip != nil {
+		if ip4 := ip.To4(); ip4 != nil {
+			req = append(req, socks5AddrIPv4)
+			req = append(req, ip4...)
+		} else {
+			req = append(req, socks5AddrIPv6)
+			req = append(req, ip.To16()...)
+		}
+	} else {
+		if len(host) > 255 {
+			return errors.New("destination host name too long")
+		}
+		req = append(req, socks5AddrDomain, byte(len(host)))
+		req = append(req, host...)
+	}
+	req = append(req, byte(port>>8), byte(port))
+	if _, err := conn.Write(req); err != nil {
+		return err
+	}
+	// Read the reply, discarding the bound address
+	reply := make([]byte, 4)
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		return err
+	}
+	if reply[1] != 0 {
+		if int(reply[1]) < len(socks5Errors) {
+			return errors.New(socks5Errors[reply[1]])
+		}
+		return fmt.Errorf("unknown error %d", reply[1])
+	}
+	var skip int
+	switch reply[3] {
+	case socks5AddrIPv4:
+		skip = net.IPv4len
+	case socks5AddrIPv6:
+		skip = net.IPv6len
+	case socks5AddrDomain:
+		if _, err := io.ReadFull(conn, reply[:1]); err != nil {
+			return err
+		}
+		skip = int(reply[0])
+	default:
+		return fmt.Errorf("unknown address type %d", reply[3])
+	}
+	_, err = io.ReadFull(conn, make([]byte, skip+2))
+	return err
+}
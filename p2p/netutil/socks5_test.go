@@ -0,0 +1,115 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package netutil
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"testing"
+)
+
+func TestParseSOCKS5(t *testing.T) {
+	tests := []struct {
+		input      string
+		proxy      string
+		user, pass string
+		wantErr    bool
+	}{
+		{input: "127.0.0.1:9050", proxy: "127.0.0.1:9050"},
+		{input: "alice:secret@localhost:1080", proxy: "localhost:1080", user: "alice", pass: "secret"},
+		{input: "alice@[::1]:1080", proxy: "[::1]:1080", user: "alice"},
+		{input: "localhost", wantErr: true},
+	}
+	for _, tt := range tests {
+		d, err := ParseSOCKS5(tt.input)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%q: expected error", tt.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", tt.input, err)
+			continue
+		}
+		if d.Proxy != tt.proxy || d.Username != tt.user || d.Password != tt.pass {
+			t.Errorf("%q: mismatch: have %+v", tt.input, d)
+		}
+	}
+}
+
+func TestSOCKS5Dial(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	// Run a minimal SOCKS5 server expecting password authentication and a
+	// connection request to 10.0.0.1:30303, then echoing a greeting.
+	errc := make(chan error, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			errc <- err
+			return
+		}
+		defer conn.Close()
+
+		expect := func(want []byte) bool {
+			have := make([]byte, len(want))
+			if _, err := io.ReadFull(conn, have); err != nil || !bytes.Equal(have, want) {
+				t.Errorf("proxy received %x, want %x (err %v)", have, want, err)
+				return false
+			}
+			return true
+		}
+		if !expect([]byte{5, 1, socks5AuthPassword}) {
+			return
+		}
+		conn.Write([]byte{5, socks5AuthPassword})
+		if !expect([]byte{1, 3, 'b', 'o', 'b', 2, 'p', 'w'}) {
+			return
+		}
+		conn.Write([]byte{1, 0})
+		if !expect([]byte{5, socks5Connect, 0, socks5AddrIPv4, 10, 0, 0, 1, 0x76, 0x5f}) {
+			return
+		}
+		conn.Write([]byte{5, 0, 0, socks5AddrIPv4, 127, 0, 0, 1, 0x12, 0x34})
+		conn.Write([]byte("hello"))
+		errc <- nil
+	}()
+
+	d := &SOCKS5Dialer{Proxy: listener.Addr().String(), Username: "bob", Password: "pw"}
+	conn, err := d.Dial("tcp", "10.0.0.1:30303")
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer conn.Close()
+
+	greeting := make([]byte, 5)
+	if _, err := io.ReadFull(conn, greeting); err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	if string(greeting) != "hello" {
+		t.Errorf("greeting mismatch: have %q, want %q", greeting, "hello")
+	}
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+}
@@ -32,10 +32,14 @@ import (
 )
 
 const (
-	baseProtocolVersion    = 4
+	baseProtocolVersion    = 5
 	baseProtocolLength     = uint64(16)
 	baseProtocolMaxMsgSize = 2 * 1024
 
+	// snappyProtocolVersion is the first base protocol version supporting
+	// snappy compression of message payloads.
+	snappyProtocolVersion = 5
+
 	pingInterval = 15 * time.Second
 )
 
@@ -66,6 +70,8 @@ type Peer struct {
 	rw      *conn
 	running map[string]*protoRW
 
+	limits *msgLimits // Inbound message budget, nil if unlimited
+
 	wg       sync.WaitGroup
 	protoErr chan error
 	closed   chan struct{}
@@ -107,6 +113,21 @@ func (p *Peer) LocalAddr() net.Addr {
 	return p.rw.fd.LocalAddr()
 }
 
+// Inbound returns whether the connection was initiated by the remote node.
+func (p *Peer) Inbound() bool {
+	return p.rw.is(inboundConn)
+}
+
+// Trusted returns whether the peer is trusted, i.e. exempt from peer limits.
+func (p *Peer) Trusted() bool {
+	return p.rw.is(trustedConn)
+}
+
+// Static returns whether the peer was dialed as a static node.
+func (p *Peer) Static() bool {
+	return p.rw.is(staticDialedConn)
+}
+
 // Disconnect terminates the peer connection with the given reason.
 // It returns immediately and does not wait until the connection is closed.
 func (p *Peer) Disconnect(reason DiscReason) {
@@ -167,6 +188,9 @@ loop:
 				glog.V(logger.Debug).Infof("%v: remote requested disconnect: %v\n", p, r)
 				requested = true
 				reason = r
+			} else if perr, ok := err.(*peerError); ok && perr.code == errMsgLimit {
+				reason = discReasonForError(err)
+				glog.V(logger.Debug).Infof("%v: %v (%v)\n", p, err, reason)
 			} else {
 				glog.V(logger.Detail).Infof("%v: read error: %v\n", p, err)
 				reason = DiscNetworkError
@@ -217,6 +241,13 @@ func (p *Peer) readLoop(errc chan<- error) {
 			return
 		}
 		msg.ReceivedAt = time.Now()
+		if p.limits != nil {
+			if err = p.limits.check(msg); err != nil {
+				msg.Discard()
+				errc <- err
+				return
+			}
+		}
 		if err = p.handle(msg); err != nil {
 			errc <- err
 			return
@@ -367,12 +398,16 @@ func (rw *protoRW) ReadMsg() (Msg, error) {
 // peer. Sub-protocol independent fields are contained and initialized here, with
 // protocol specifics delegated to all connected sub-protocols.
 type PeerInfo struct {
-	ID      string   `json:"id"`   // Unique node identifier (also the encryption key)
-	Name    string   `json:"name"` // Name of the node, including client type, version, OS, custom data
-	Caps    []string `json:"caps"` // Sum-protocols advertised by this particular peer
-	Network struct {
+	ID         string   `json:"id"`         // Unique node identifier (also the encryption key)
+	Name       string   `json:"name"`       // Name of the node, including client type, version, OS, custom data
+	Caps       []string `json:"caps"`       // Sum-protocols advertised by this particular peer
+	Negotiated []string `json:"negotiated"` // Sub-protocols running with this peer (shared by both sides)
+	Network    struct {
 		LocalAddress  string `json:"localAddress"`  // Local endpoint of the TCP data connection
 		RemoteAddress string `json:"remoteAddress"` // Remote endpoint of the TCP data connection
+		Inbound       bool   `json:"inbound"`       // Whether the connection was initiated by the remote side
+		Trusted       bool   `json:"trusted"`       // Whether the peer is exempt from the peer limits
+		Static        bool   `json:"static"`        // Whether the peer is a static node kept connected
 	} `json:"network"`
 	Protocols map[string]interface{} `json:"protocols"` // Sub-protocol specific metadata fields
 }
@@ -393,9 +428,13 @@ func (p *Peer) Info() *PeerInfo {
 	}
 	info.Network.LocalAddress = p.LocalAddr().String()
 	info.Network.RemoteAddress = p.RemoteAddr().String()
+	info.Network.Inbound = p.Inbound()
+	info.Network.Trusted = p.Trusted()
+	info.Network.Static = p.Static()
 
 	// Gather all the running protocol infos
 	for _, proto := range p.running {
+		info.Negotiated = append(info.Negotiated, proto.cap().String())
 		protoInfo := interface{}("unknown")
 		if query := proto.Protocol.PeerInfo; query != nil {
 			if metadata := query(p.ID()); metadata != nil {
@@ -406,5 +445,6 @@ func (p *Peer) Info() *PeerInfo {
 		}
 		info.Protocols[proto.Name] = protoInfo
 	}
+	sort.Strings(info.Negotiated)
 	return info
 }
@@ -23,11 +23,13 @@ import (
 const (
 	errInvalidMsgCode = iota
 	errInvalidMsg
+	errMsgLimit
 )
 
 var errorToString = map[int]string{
 	errInvalidMsgCode: "invalid message code",
 	errInvalidMsg:     "invalid message",
+	errMsgLimit:       "message limit exceeded",
 }
 
 type peerError struct {
@@ -103,7 +105,7 @@ func discReasonForError(err error) DiscReason {
 	peerError, ok := err.(*peerError)
 	if ok {
 		switch peerError.code {
-		case errInvalidMsgCode, errInvalidMsg:
+		case errInvalidMsgCode, errInvalidMsg, errMsgLimit:
 			return DiscProtocolError
 		default:
 			return DiscSubprotocolError
@@ -129,6 +129,25 @@ func TestPeerPing(t *testing.T) {
 	}
 }
 
+func TestPeerInfo(t *testing.T) {
+	closer, _, peer, _ := testPeer([]Protocol{discard})
+	defer closer()
+
+	peer.rw.set(inboundConn|trustedConn, true)
+	info := peer.Info()
+	if !info.Network.Inbound || !info.Network.Trusted || info.Network.Static {
+		t.Errorf("connection flags mismatch: %+v", info.Network)
+	}
+	if want := []string{"discard/0"}; !reflect.DeepEqual(info.Negotiated, want) {
+		t.Errorf("negotiated protocols mismatch: have %v, want %v", info.Negotiated, want)
+	}
+	// Demoting the peer must be reflected too
+	peer.rw.set(trustedConn, false)
+	if peer.Info().Network.Trusted {
+		t.Errorf("peer still trusted after demotion")
+	}
+}
+
 func TestPeerDisconnect(t *testing.T) {
 	closer, rw, _, disc := testPeer(nil)
 	defer closer()
@@ -0,0 +1,232 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/EarthDollar/go-earthdollar/logger"
+	"github.com/EarthDollar/go-earthdollar/logger/glog"
+	"github.com/EarthDollar/go-earthdollar/p2p/discover"
+)
+
+const (
+	// MaxReputation is the highest score a well behaving peer can accumulate.
+	MaxReputation = 100
+
+	// MinReputation is the lowest score a misbehaving peer can sink to.
+	MinReputation = -200
+
+	// BanReputation is the score at or below which a peer is disconnected and
+	// any further connection attempts (other than trusted or static) refused.
+	BanReputation = -100
+
+	// reputationHalfLife is the time after which a score decays to half of its
+	// value, allowing banned peers to eventually be given another chance.
+	reputationHalfLife = 6 * time.Hour
+)
+
+// reputationStore is implemented by node databases capable of persisting peer
+// scores across restarts (e.g. the discovery table).
+type reputationStore interface {
+	Reputation(id discover.NodeID) (int, time.Time)
+	SetReputation(id discover.NodeID, score int, updated time.Time) error
+}
+
+// reputation is the score of a single node at a given point in time.
+type reputation struct {
+	score   int
+	updated time.Time
+}
+
+// decayed returns the score of the node at the given time.
+func (r reputation) decayed(now time.Time) int {
+	elapsed := now.Sub(r.updated)
+	if elapsed <= 0 || r.score == 0 {
+		return r.score
+	}
+	score := r.score
+	for ; elapsed >= reputationHalfLife && score != 0; elapsed -= reputationHalfLife {
+		score /= 2
+	}
+	return score
+}
+
+// reputationTracker maintains the behavioural scores of remote nodes, caching
+// them in memory and writing them through to the persistent store, if any.
+type reputationTracker struct {
+	lock   sync.Mutex
+	scores map[discover.NodeID]reputation
+	store  reputationStore
+}
+
+func newReputationTracker(store reputationStore) *reputationTracker {
+	return &reputationTracker{
+		scores: make(map[discover.NodeID]reputation),
+		store:  store,
+	}
+}
+
+// load retrieves the current reputation of a node. The lock must be held.
+func (t *reputationTracker) load(id discover.NodeID) reputation {
+	if rep, ok := t.scores[id]; ok {
+		return rep
+	}
+	var rep reputation
+	if t.store != nil {
+		rep.score, rep.updated = t.store.Reputation(id)
+	}
+	t.scores[id] = rep
+	return rep
+}
+
+// set overwrites the score of a node, clamping it into the permitted range.
+// The lock must be held.
+func (t *reputationTracker) set(id discover.NodeID, score int, now time.Time) int {
+	if score > MaxReputation {
+		score = MaxReputation
+	}
+	if score < MinReputation {
+		score = MinReputation
+	}
+	t.scores[id] = reputation{score: score, updated: now}
+	if t.store != nil {
+		if err := t.store.SetReputation(id, score, now); err != nil {
+			glog.V(logger.Debug).Infof("failed to persist reputation of %x: %v", id[:8], err)
+		}
+	}
+	return score
+}
+
+// score returns the current reputation of a node.
+func (t *reputationTracker) score(id discover.NodeID) int {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return t.load(id).decayed(time.Now())
+}
+
+// adjust changes the reputation of a node by delta, returning the new score.
+func (t *reputationTracker) adjust(id discover.NodeID, delta int) int {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	now := time.Now()
+	return t.set(id, t.load(id).decayed(now)+delta, now)
+}
+
+// reset overwrites the reputation of a node, returning the new score.
+func (t *reputationTracker) reset(id discover.NodeID, score int) int {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return t.set(id, score, time.Now())
+}
+
+// all returns the current scores of all nodes seen since startup.
+func (t *reputationTracker) all() map[discover.NodeID]int {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	now := time.Now()
+	scores := make(map[discover.NodeID]int, len(t.scores))
+	for id, rep := range t.scores {
+		scores[id] = rep.decayed(now)
+	}
+	return scores
+}
+
+// sortByReputation reorders the nodes so that the best reputed ones come first,
+// keeping the original (random) order among equally scored nodes.
+func (t *reputationTracker) sortByReputation(nodes []*discover.Node) {
+	scores := make(map[discover.NodeID]int, len(nodes))
+	for _, n := range nodes {
+		scores[n.ID] = t.score(n.ID)
+	}
+	sort.Stable(nodesByReputation{nodes, scores})
+}
+
+type nodesByReputation struct {
+	nodes  []*discover.Node
+	scores map[discover.NodeID]int
+}
+
+func (s nodesByReputation) Len() int      { return len(s.nodes) }
+func (s nodesByReputation) Swap(i, j int) { s.nodes[i], s.nodes[j] = s.nodes[j], s.nodes[i] }
+func (s nodesByReputation) Less(i, j int) bool {
+	return s.scores[s.nodes[i].ID] > s.scores[s.nodes[j].ID]
+}
+
+// PeerReputation returns the current behavioural score of a node.
+func (srv *Server) PeerReputation(id discover.NodeID) int {
+	if srv.reputation == nil {
+		return 0
+	}
+	return srv.reputation.score(id)
+}
+
+// PeerReputations returns the current scores of all nodes the server rated
+// since it was started.
+func (srv *Server) PeerReputations() map[discover.NodeID]int {
+	if srv.reputation == nil {
+		return nil
+	}
+	return srv.reputation.all()
+}
+
+// AdjustPeerReputation changes the behavioural score of a node by delta,
+// giving the reason for logging purposes. If the score drops to BanReputation
+// or below, the peer is disconnected and subsequent connections refused until
+// its score recovers. The new score is returned.
+func (srv *Server) AdjustPeerReputation(id discover.NodeID, delta int, reason string) int {
+	if srv.reputation == nil {
+		return 0
+	}
+	score := srv.reputation.adjust(id, delta)
+	glog.V(logger.Detail).Infof("Peer %x reputation %+d (%s): %d", id[:8], delta, reason, score)
+
+	if score <= BanReputation {
+		for _, p := range srv.Peers() {
+			if p.ID() == id && !p.rw.is(trustedConn|staticDialedConn) {
+				glog.V(logger.Debug).Infof("Banning peer %x with reputation %d (%s)", id[:8], score, reason)
+				p.Disconnect(DiscUselessPeer)
+			}
+		}
+	}
+	return score
+}
+
+// SetPeerReputation overwrites the behavioural score of a node, e.g. to lift a
+// ban. The new (clamped) score is returned.
+func (srv *Server) SetPeerReputation(id discover.NodeID, score int) int {
+	if srv.reputation == nil {
+		return 0
+	}
+	return srv.reputation.reset(id, score)
+}
+
+// isBanned reports whether the node or its IP address is banned, or the node's
+// reputation is too low to connect.
+func (srv *Server) isBanned(id discover.NodeID, ip net.IP) bool {
+	if srv.bans != nil && srv.bans.banned(id, ip) {
+		return true
+	}
+	return srv.PeerReputation(id) <= BanReputation
+}
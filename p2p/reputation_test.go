@@ -0,0 +1,103 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"testing"
+	"time"
+
+	"github.com/EarthDollar/go-earthdollar/p2p/discover"
+)
+
+// memReputationStore is an in-memory reputationStore for testing.
+type memReputationStore map[discover.NodeID]reputation
+
+func (s memReputationStore) Reputation(id discover.NodeID) (int, time.Time) {
+	return s[id].score, s[id].updated
+}
+
+func (s memReputationStore) SetReputation(id discover.NodeID, score int, updated time.Time) error {
+	s[id] = reputation{score, updated}
+	return nil
+}
+
+func TestReputationDecay(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		score   int
+		elapsed time.Duration
+		want    int
+	}{
+		{100, 0, 100},
+		{100, reputationHalfLife - time.Second, 100},
+		{100, reputationHalfLife, 50},
+		{-200, 2 * reputationHalfLife, -50},
+		{1, reputationHalfLife, 0},
+		{-100, -time.Hour, -100},
+	}
+	for i, tt := range tests {
+		rep := reputation{score: tt.score, updated: now.Add(-tt.elapsed)}
+		if have := rep.decayed(now); have != tt.want {
+			t.Errorf("test %d: decayed score mismatch: have %d, want %d", i, have, tt.want)
+		}
+	}
+}
+
+func TestReputationTracker(t *testing.T) {
+	store := make(memReputationStore)
+	id1, id2 := randomID(), randomID()
+	store[id2] = reputation{score: 30, updated: time.Now()}
+
+	tracker := newReputationTracker(store)
+	if score := tracker.adjust(id1, -500); score != MinReputation {
+		t.Errorf("score not clamped to minimum: have %d, want %d", score, MinReputation)
+	}
+	if score := tracker.adjust(id2, 500); score != MaxReputation {
+		t.Errorf("score not clamped to maximum: have %d, want %d", score, MaxReputation)
+	}
+	if store[id1].score != MinReputation || store[id2].score != MaxReputation {
+		t.Errorf("scores not persisted: %v", store)
+	}
+	// A fresh tracker must resume from the persisted scores
+	tracker = newReputationTracker(store)
+	if score := tracker.score(id1); score != MinReputation {
+		t.Errorf("persisted score mismatch: have %d, want %d", score, MinReputation)
+	}
+	// Nodes must be ordered by descending reputation
+	nodes := []*discover.Node{{ID: id1}, {ID: randomID()}, {ID: id2}}
+	tracker.sortByReputation(nodes)
+	if nodes[0].ID != id2 || nodes[2].ID != id1 {
+		t.Errorf("nodes not sorted by reputation: %v", nodes)
+	}
+}
+
+func TestServerRefusesBannedPeers(t *testing.T) {
+	srv := &Server{Config: Config{MaxPeers: 10, PrivateKey: newkey()}, reputation: newReputationTracker(nil)}
+	id := randomID()
+	srv.reputation.reset(id, BanReputation)
+
+	if err := srv.encHandshakeChecks(nil, &conn{id: id, flags: inboundConn}); err != DiscUselessPeer {
+		t.Errorf("banned inbound peer: have %v, want %v", err, DiscUselessPeer)
+	}
+	if err := srv.encHandshakeChecks(nil, &conn{id: id, flags: trustedConn}); err != nil {
+		t.Errorf("banned trusted peer: have %v, want nil", err)
+	}
+	srv.SetPeerReputation(id, 0)
+	if err := srv.encHandshakeChecks(nil, &conn{id: id, flags: inboundConn}); err != nil {
+		t.Errorf("unbanned inbound peer: have %v, want nil", err)
+	}
+}
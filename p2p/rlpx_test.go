@@ -317,7 +317,10 @@ func (fakeHash) BlockSize() int              { return 0 }
 func (h fakeHash) Size() int           { return len(h) }
 func (h fakeHash) Sum(b []byte) []byte { return append(b, h...) }
 
-func TestRLPXFrameRW(t *testing.T) {
+func TestRLPXFrameRW(t *testing.T)       { testRLPXFrameRW(t, false) }
+func TestRLPXFrameRWSnappy(t *testing.T) { testRLPXFrameRW(t, true) }
+
+func testRLPXFrameRW(t *testing.T, snappy bool) {
 	var (
 		aesSecret      = make([]byte, 16)
 		macSecret      = make([]byte, 16)
@@ -348,6 +351,7 @@ func TestRLPXFrameRW(t *testing.T) {
 	s2.EgressMAC.Write(ingressMACinit)
 	s2.IngressMAC.Write(egressMACinit)
 	rw2 := newRLPXFrameRW(conn, s2)
+	rw1.snappy, rw2.snappy = snappy, snappy
 
 	// send some messages
 	for i := 0; i < 10; i++ {
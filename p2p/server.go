@@ -22,13 +22,17 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/EarthDollar/go-earthdollar/event"
 	"github.com/EarthDollar/go-earthdollar/logger"
 	"github.com/EarthDollar/go-earthdollar/logger/glog"
 	"github.com/EarthDollar/go-earthdollar/p2p/discover"
 	"github.com/EarthDollar/go-earthdollar/p2p/discv5"
+	"github.com/EarthDollar/go-earthdollar/p2p/dnsdisc"
 	"github.com/EarthDollar/go-earthdollar/p2p/nat"
 	"github.com/EarthDollar/go-earthdollar/p2p/netutil"
 )
@@ -37,6 +41,8 @@ const (
 	defaultDialTimeout      = 15 * time.Second
 	refreshPeersInterval    = 30 * time.Second
 	staticPeerCheckInterval = 15 * time.Second
+	dnsRefreshInterval      = 30 * time.Minute
+	natCheckInterval        = 5 * time.Minute
 
 	// Maximum number of concurrently handshaking inbound connections.
 	maxAcceptConns = 50
@@ -109,6 +115,11 @@ type Config struct {
 	// live nodes in the network.
 	NodeDatabase string
 
+	// DNSDiscovery is a list of DNS node tree URLs (enrtree://<key>@<domain>)
+	// which are periodically fetched and used as additional bootstrap nodes
+	// for the discovery table.
+	DNSDiscovery []string
+
 	// Protocols should contain the protocols supported
 	// by the server. Matching protocols are launched for
 	// each peer.
@@ -131,8 +142,26 @@ type Config struct {
 	// is used to dial outbound peer connections.
 	Dialer *net.Dialer
 
+	// If Proxy is set to a non-nil value, all outbound peer connections
+	// are routed through the given SOCKS5 proxy (e.g. Tor). Note that UDP
+	// discovery traffic is not proxied.
+	Proxy *netutil.SOCKS5Dialer
+
 	// If NoDial is true, the server will not dial any peers.
 	NoDial bool
+
+	// MaxMessageSize is the maximum payload size of a single message a peer
+	// may send before being disconnected. Zero defaults to preset values.
+	MaxMessageSize uint32
+
+	// MaxMessageRate is the number of messages per second a peer may send on
+	// average before being disconnected, short bursts of up to twice as many
+	// being tolerated. Zero defaults to preset values.
+	MaxMessageRate int
+
+	// If EventMux is set to a non-nil value, peer lifecycle events
+	// (PeerEvent) are posted to it.
+	EventMux *event.TypeMux
 }
 
 // Server manages all peer connections.
@@ -149,11 +178,17 @@ type Server struct {
 	running bool
 
 	ntab         discoverTable
+	reputation   *reputationTracker
+	bans         *banList
+	natStatus    *nat.Status // NAT traversal state, nil if NAT is not configured
 	listener     net.Listener
 	ourHandshake *protoHandshake
 	lastLookup   time.Time
 	DiscV5       *discv5.Network
 
+	recordLock sync.Mutex       // protects record
+	record     *discover.Record // signed record of the local node
+
 	// These are for Peers, PeerCount (and nothing else).
 	peerOp     chan peerOpFunc
 	peerOpDone chan struct{}
@@ -161,6 +196,8 @@ type Server struct {
 	quit          chan struct{}
 	addstatic     chan *discover.Node
 	removestatic  chan *discover.Node
+	addtrusted    chan *discover.Node
+	removetrusted chan *discover.Node
 	posthandshake chan *conn
 	addpeer       chan *conn
 	delpeer       chan *Peer
@@ -169,7 +206,7 @@ type Server struct {
 
 type peerOpFunc func(map[discover.NodeID]*Peer)
 
-type connFlag int
+type connFlag int32
 
 const (
 	dynDialedConn connFlag = 1 << iota
@@ -205,7 +242,7 @@ type transport interface {
 }
 
 func (c *conn) String() string {
-	s := c.flags.String() + " conn"
+	s := connFlag(atomic.LoadInt32((*int32)(&c.flags))).String() + " conn"
 	if (c.id != discover.NodeID{}) {
 		s += fmt.Sprintf(" %x", c.id[:8])
 	}
@@ -234,7 +271,24 @@ func (f connFlag) String() string {
 }
 
 func (c *conn) is(f connFlag) bool {
-	return c.flags&f != 0
+	flags := connFlag(atomic.LoadInt32((*int32)(&c.flags)))
+	return flags&f != 0
+}
+
+// set sets or clears the given flags. It is safe for concurrent use with is.
+func (c *conn) set(f connFlag, val bool) {
+	for {
+		oldFlags := connFlag(atomic.LoadInt32((*int32)(&c.flags)))
+		flags := oldFlags
+		if val {
+			flags |= f
+		} else {
+			flags &= ^f
+		}
+		if atomic.CompareAndSwapInt32((*int32)(&c.flags), int32(oldFlags), int32(flags)) {
+			return
+		}
+	}
 }
 
 // Peers returns all connected peers.
@@ -284,6 +338,25 @@ func (srv *Server) RemovePeer(node *discover.Node) {
 	}
 }
 
+// AddTrustedPeer adds the given node to a reserved whitelist which allows the
+// node to always connect, even if the slots are full. A connected peer is
+// promoted immediately.
+func (srv *Server) AddTrustedPeer(node *discover.Node) {
+	select {
+	case srv.addtrusted <- node:
+	case <-srv.quit:
+	}
+}
+
+// RemoveTrustedPeer removes the given node from the trusted peer set. The
+// connection itself, if any, is kept.
+func (srv *Server) RemoveTrustedPeer(node *discover.Node) {
+	select {
+	case srv.removetrusted <- node:
+	case <-srv.quit:
+	}
+}
+
 // Self returns the local node's endpoint information.
 func (srv *Server) Self() *discover.Node {
 	srv.lock.Lock()
@@ -349,18 +422,33 @@ func (srv *Server) Start() (err error) {
 	if srv.Dialer == nil {
 		srv.Dialer = &net.Dialer{Timeout: defaultDialTimeout}
 	}
+	if srv.Proxy != nil {
+		if srv.Proxy.Forward == nil {
+			srv.Proxy.Forward = srv.Dialer
+		}
+		glog.V(logger.Info).Infof("Routing outbound connections through SOCKS5 proxy %s", srv.Proxy.Proxy)
+	}
 	srv.quit = make(chan struct{})
 	srv.addpeer = make(chan *conn)
 	srv.delpeer = make(chan *Peer)
 	srv.posthandshake = make(chan *conn)
 	srv.addstatic = make(chan *discover.Node)
 	srv.removestatic = make(chan *discover.Node)
+	srv.addtrusted = make(chan *discover.Node)
+	srv.removetrusted = make(chan *discover.Node)
 	srv.peerOp = make(chan peerOpFunc)
 	srv.peerOpDone = make(chan struct{})
 
+	// NAT traversal, tracking mappings and the external address for reporting
+	var natm nat.Interface
+	if srv.NAT != nil {
+		srv.natStatus = nat.NewStatus(srv.NAT)
+		natm = srv.natStatus
+	}
+
 	// node table
 	if srv.Discovery {
-		ntab, err := discover.ListenUDP(srv.PrivateKey, srv.ListenAddr, srv.NAT, srv.NodeDatabase, srv.NetRestrict)
+		ntab, err := discover.ListenUDP(srv.PrivateKey, srv.ListenAddr, natm, srv.NodeDatabase, srv.NetRestrict)
 		if err != nil {
 			return err
 		}
@@ -368,10 +456,19 @@ func (srv *Server) Start() (err error) {
 			return err
 		}
 		srv.ntab = ntab
+
+		if len(srv.DNSDiscovery) > 0 {
+			srv.loopWG.Add(1)
+			go srv.dnsDiscoveryLoop(ntab, dnsdisc.NewClient(nil))
+		}
+		if natm != nil {
+			srv.loopWG.Add(1)
+			go srv.natLoop(ntab)
+		}
 	}
 
 	if srv.DiscoveryV5 {
-		ntab, err := discv5.ListenUDP(srv.PrivateKey, srv.DiscoveryV5Addr, srv.NAT, "", srv.NetRestrict) //srv.NodeDatabase)
+		ntab, err := discv5.ListenUDP(srv.PrivateKey, srv.DiscoveryV5Addr, natm, "", srv.NetRestrict) //srv.NodeDatabase)
 		if err != nil {
 			return err
 		}
@@ -385,7 +482,16 @@ func (srv *Server) Start() (err error) {
 	if !srv.Discovery {
 		dynPeers = 0
 	}
+	// peer reputations, persisted in the node database if discovery is running
+	store, _ := srv.ntab.(reputationStore)
+	srv.reputation = newReputationTracker(store)
+	// explicit node and IP bans, likewise persisted
+	bans, _ := srv.ntab.(banStore)
+	srv.bans = newBanList(bans)
+
 	dialer := newDialState(srv.StaticNodes, srv.ntab, dynPeers, srv.NetRestrict)
+	dialer.reputation = srv.reputation
+	dialer.bans = srv.bans
 
 	// handshake
 	srv.ourHandshake = &protoHandshake{Version: baseProtocolVersion, Name: srv.Name, ID: discover.PubkeyID(&srv.PrivateKey.PublicKey)}
@@ -420,16 +526,69 @@ func (srv *Server) startListening() error {
 	srv.loopWG.Add(1)
 	go srv.listenLoop()
 	// Map the TCP listening port if NAT is configured.
-	if !laddr.IP.IsLoopback() && srv.NAT != nil {
+	if !laddr.IP.IsLoopback() && srv.natStatus != nil {
 		srv.loopWG.Add(1)
 		go func() {
-			nat.Map(srv.NAT, srv.quit, "tcp", laddr.Port, laddr.Port, "ethereum p2p")
+			nat.Map(srv.natStatus, srv.quit, "tcp", laddr.Port, laddr.Port, "ethereum p2p")
 			srv.loopWG.Done()
 		}()
 	}
 	return nil
 }
 
+// dnsDiscoveryLoop periodically fetches the configured DNS node trees and
+// installs the nodes found, together with the bootstrap nodes, as fallback
+// nodes of the discovery table.
+func (srv *Server) dnsDiscoveryLoop(ntab *discover.Table, client *dnsdisc.Client) {
+	defer srv.loopWG.Done()
+
+	for {
+		nodes, err := client.SyncTrees(srv.DNSDiscovery)
+		if err != nil && len(nodes) == 0 {
+			glog.V(logger.Warn).Infof("DNS discovery failed: %v", err)
+		}
+		if len(nodes) > 0 {
+			glog.V(logger.Info).Infof("DNS discovery found %d nodes", len(nodes))
+			fallback := append(append([]*discover.Node{}, srv.BootstrapNodes...), nodes...)
+			if err := ntab.SetFallbackNodes(fallback); err != nil {
+				glog.V(logger.Warn).Infof("DNS discovery: %v", err)
+			}
+		}
+		select {
+		case <-time.After(dnsRefreshInterval):
+		case <-srv.quit:
+			return
+		}
+	}
+}
+
+// natLoop periodically queries the external address of the NAT gateway and
+// updates the advertised endpoint of the local node whenever it changes, e.g.
+// after the router was assigned a new address by the ISP.
+func (srv *Server) natLoop(ntab *discover.Table) {
+	defer srv.loopWG.Done()
+
+	ticker := time.NewTicker(natCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			ip, err := srv.natStatus.ExternalIP()
+			if err != nil {
+				glog.V(logger.Debug).Infof("NAT external IP query failed: %v", err)
+				continue
+			}
+			if old := ntab.Self().IP; !ip.Equal(old) {
+				glog.V(logger.Info).Infof("External IP changed from %v to %v", old, ip)
+				ntab.SetExternalIP(ip)
+			}
+		case <-srv.quit:
+			return
+		}
+	}
+}
+
 type dialer interface {
 	newTasks(running int, peers map[discover.NodeID]*Peer, now time.Time) []task
 	taskDone(task, time.Time)
@@ -447,8 +606,8 @@ func (srv *Server) run(dialstate dialer) {
 		queuedTasks  []task // tasks that can't run yet
 	)
 	// Put trusted nodes into a map to speed up checks.
-	// Trusted peers are loaded on startup and cannot be
-	// modified while the server is running.
+	// Trusted peers are loaded on startup and can be
+	// modified via AddTrustedPeer and RemoveTrustedPeer.
 	for _, n := range srv.TrustedNodes {
 		trusted[n.ID] = true
 	}
@@ -507,6 +666,22 @@ running:
 			if p, ok := peers[n.ID]; ok {
 				p.Disconnect(DiscRequested)
 			}
+		case n := <-srv.addtrusted:
+			// This channel is used by AddTrustedPeer to add a node
+			// to the trusted set, promoting it if connected.
+			glog.V(logger.Detail).Infoln("<-addtrusted:", n)
+			trusted[n.ID] = true
+			if p, ok := peers[n.ID]; ok {
+				p.rw.set(trustedConn, true)
+			}
+		case n := <-srv.removetrusted:
+			// This channel is used by RemoveTrustedPeer to remove
+			// a node from the trusted set, demoting it if connected.
+			glog.V(logger.Detail).Infoln("<-removetrusted:", n)
+			delete(trusted, n.ID)
+			if p, ok := peers[n.ID]; ok {
+				p.rw.set(trustedConn, false)
+			}
 		case op := <-srv.peerOp:
 			// This channel is used by Peers and PeerCount.
 			op(peers)
@@ -523,7 +698,7 @@ running:
 			// the remote identity is known (but hasn't been verified yet).
 			if trusted[c.id] {
 				// Ensure that the trusted flag is set before checking against MaxPeers.
-				c.flags |= trustedConn
+				c.set(trustedConn, true)
 			}
 			glog.V(logger.Detail).Infoln("<-posthandshake:", c)
 			// TODO: track in-progress inbound node IDs (pre-Peer) to avoid dialing them.
@@ -538,6 +713,7 @@ running:
 			} else {
 				// The handshakes are done and it passed all checks.
 				p := newPeer(c, srv.Protocols)
+				p.limits = srv.messageLimits()
 				peers[c.id] = p
 				go srv.runPeer(p)
 			}
@@ -592,6 +768,8 @@ func (srv *Server) encHandshakeChecks(peers map[discover.NodeID]*Peer, c *conn)
 		return DiscAlreadyConnected
 	case c.id == srv.Self().ID:
 		return DiscSelf
+	case !c.is(trustedConn|staticDialedConn) && srv.isBanned(c.id, remoteIP(c)):
+		return DiscUselessPeer
 	default:
 		return nil
 	}
@@ -648,6 +826,13 @@ func (srv *Server) listenLoop() {
 				continue
 			}
 		}
+		// Reject connections from banned IP addresses.
+		if tcp, ok := fd.RemoteAddr().(*net.TCPAddr); ok && srv.bans.bannedIP(tcp.IP) {
+			glog.V(logger.Debug).Infof("Rejected conn %v because the IP is banned", fd.RemoteAddr())
+			fd.Close()
+			slots <- struct{}{}
+			continue
+		}
 
 		fd = newMeteredConn(fd, true)
 		glog.V(logger.Debug).Infof("Accepted conn %v", fd.RemoteAddr())
@@ -678,42 +863,49 @@ func (srv *Server) setupConn(fd net.Conn, flags connFlag, dialDest *discover.Nod
 	var err error
 	if c.id, err = c.doEncHandshake(srv.PrivateKey, dialDest); err != nil {
 		glog.V(logger.Debug).Infof("%v faild enc handshake: %v", c, err)
-		c.close(err)
+		srv.closeFailed(c, err)
 		return
 	}
 	// For dialed connections, check that the remote public key matches.
 	if dialDest != nil && c.id != dialDest.ID {
-		c.close(DiscUnexpectedIdentity)
+		srv.closeFailed(c, DiscUnexpectedIdentity)
 		glog.V(logger.Debug).Infof("%v dialed identity mismatch, want %x", c, dialDest.ID[:8])
 		return
 	}
 	if err := srv.checkpoint(c, srv.posthandshake); err != nil {
 		glog.V(logger.Debug).Infof("%v failed checkpoint posthandshake: %v", c, err)
-		c.close(err)
+		srv.closeFailed(c, err)
 		return
 	}
 	// Run the protocol handshake
 	phs, err := c.doProtoHandshake(srv.ourHandshake)
 	if err != nil {
 		glog.V(logger.Debug).Infof("%v failed proto handshake: %v", c, err)
-		c.close(err)
+		srv.closeFailed(c, err)
 		return
 	}
 	if phs.ID != c.id {
 		glog.V(logger.Debug).Infof("%v wrong proto handshake identity: %x", c, phs.ID[:8])
-		c.close(DiscUnexpectedIdentity)
+		srv.closeFailed(c, DiscUnexpectedIdentity)
 		return
 	}
 	c.caps, c.name = phs.Caps, phs.Name
 	if err := srv.checkpoint(c, srv.addpeer); err != nil {
 		glog.V(logger.Debug).Infof("%v failed checkpoint addpeer: %v", c, err)
-		c.close(err)
+		srv.closeFailed(c, err)
 		return
 	}
 	// If the checks completed successfully, runPeer has now been
 	// launched by run.
 }
 
+// closeFailed closes a connection which failed to become a peer, publishing
+// the reason.
+func (srv *Server) closeFailed(c *conn, err error) {
+	c.close(err)
+	srv.postEvent(connEvent(PeerEventTypeHandshakeFailed, c, err))
+}
+
 // checkpoint sends the conn to run, which performs the
 // post-handshake checks for the stage (posthandshake, addpeer).
 func (srv *Server) checkpoint(c *conn, stage chan<- *conn) error {
@@ -739,11 +931,15 @@ func (srv *Server) runPeer(p *Peer) {
 	if srv.newPeerHook != nil {
 		srv.newPeerHook(p)
 	}
+	srv.postEvent(connEvent(PeerEventTypeAdd, p.rw, nil))
+
 	discreason := p.run()
 	// Note: run waits for existing peers to be sent on srv.delpeer
 	// before returning, so this send should not select on srv.quit.
 	srv.delpeer <- p
 
+	srv.postEvent(connEvent(PeerEventTypeDrop, p.rw, discreason))
+
 	glog.V(logger.Debug).Infof("Removed %v (%v)\n", p, discreason)
 }
 
@@ -757,8 +953,49 @@ type NodeInfo struct {
 		Discovery int `json:"discovery"` // UDP listening port for discovery protocol
 		Listener  int `json:"listener"`  // TCP listening port for RLPx
 	} `json:"ports"`
-	ListenAddr string                 `json:"listenAddr"`
-	Protocols  map[string]interface{} `json:"protocols"`
+	ENR        string                  `json:"enr,omitempty"`        // Signed node record of the node
+	NAT        *nat.StatusInfo         `json:"nat,omitempty"`        // Port mappings and external address of the NAT
+	ExternalIP string                  `json:"externalIP,omitempty"` // Externally reachable IP address, if known
+	ListenAddr string                  `json:"listenAddr"`
+	Versions   map[string][]uint       `json:"versions"`   // Supported versions of each sub-protocol
+	Negotiated map[string]map[uint]int `json:"negotiated"` // Number of peers running each sub-protocol version
+	Protocols  map[string]interface{}  `json:"protocols"`
+}
+
+// LocalRecord returns the signed node record of the local node. Whenever the
+// advertised endpoint changes, the record is re-signed with an increased
+// sequence number. Nil is returned if the server is not running.
+func (srv *Server) LocalRecord() *discover.Record {
+	srv.lock.Lock()
+	running := srv.running
+	srv.lock.Unlock()
+	if !running {
+		return nil
+	}
+	node := srv.Self()
+
+	srv.recordLock.Lock()
+	defer srv.recordLock.Unlock()
+
+	if srv.record != nil {
+		if current, err := discover.NewNodeFromRecord(srv.record); err == nil &&
+			current.IP.Equal(node.IP) && current.UDP == node.UDP && current.TCP == node.TCP {
+			return srv.record
+		}
+	}
+	// Sequence numbers start at the current time in milliseconds so that they
+	// keep increasing across restarts without needing to be persisted
+	seq := uint64(time.Now().UnixNano() / int64(time.Millisecond))
+	if srv.record != nil && srv.record.Seq() >= seq {
+		seq = srv.record.Seq() + 1
+	}
+	rec, err := discover.NewRecord(srv.PrivateKey, seq, node.IP, node.UDP, node.TCP)
+	if err != nil {
+		glog.V(logger.Error).Infof("failed to sign local node record: %v", err)
+		return srv.record
+	}
+	srv.record = rec
+	return rec
 }
 
 // NodeInfo gathers and returns a collection of metadata known about the host.
@@ -776,6 +1013,17 @@ func (srv *Server) NodeInfo() *NodeInfo {
 	}
 	info.Ports.Discovery = int(node.UDP)
 	info.Ports.Listener = int(node.TCP)
+	if rec := srv.LocalRecord(); rec != nil {
+		info.ENR = rec.String()
+	}
+	if srv.natStatus != nil {
+		info.NAT = srv.natStatus.Info()
+	}
+	if ip := srv.ExternalIP(); ip != nil {
+		info.ExternalIP = ip.String()
+	}
+	info.Versions = srv.ProtocolVersions()
+	info.Negotiated = srv.NegotiatedVersions()
 
 	// Gather all the running protocol infos (only once per protocol type)
 	for _, proto := range srv.Protocols {
@@ -790,6 +1038,55 @@ func (srv *Server) NodeInfo() *NodeInfo {
 	return info
 }
 
+// ExternalIP returns the IP address the node is reachable at from the outside,
+// as reported by the NAT device or discovered by the other nodes. Nil is returned
+// if the address isn't known.
+func (srv *Server) ExternalIP() net.IP {
+	if srv.natStatus != nil {
+		if ip := net.ParseIP(srv.natStatus.Info().ExternalIP); ip != nil {
+			return ip
+		}
+	}
+	if ip := srv.Self().IP; ip != nil && !ip.IsUnspecified() && !ip.IsLoopback() {
+		return ip
+	}
+	return nil
+}
+
+// ProtocolVersions returns the versions of each sub-protocol the server runs,
+// highest version first.
+func (srv *Server) ProtocolVersions() map[string][]uint {
+	versions := make(map[string][]uint)
+	for _, proto := range srv.Protocols {
+		versions[proto.Name] = append(versions[proto.Name], proto.Version)
+	}
+	for _, vs := range versions {
+		sort.Sort(sort.Reverse(uintSlice(vs)))
+	}
+	return versions
+}
+
+// NegotiatedVersions returns the number of connected peers running each version
+// of the sub-protocols.
+func (srv *Server) NegotiatedVersions() map[string]map[uint]int {
+	negotiated := make(map[string]map[uint]int)
+	for _, peer := range srv.Peers() {
+		for name, proto := range peer.running {
+			if negotiated[name] == nil {
+				negotiated[name] = make(map[uint]int)
+			}
+			negotiated[name][proto.Version]++
+		}
+	}
+	return negotiated
+}
+
+type uintSlice []uint
+
+func (s uintSlice) Len() int           { return len(s) }
+func (s uintSlice) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s uintSlice) Less(i, j int) bool { return s[i] < s[j] }
+
 // PeersInfo returns an array of metadata objects describing connected peers.
 func (srv *Server) PeersInfo() []*PeerInfo {
 	// Gather all the generic and sub-protocol specific infos
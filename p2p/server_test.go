@@ -27,6 +27,7 @@ import (
 
 	"github.com/EarthDollar/go-earthdollar/crypto"
 	"github.com/EarthDollar/go-earthdollar/crypto/sha3"
+	"github.com/EarthDollar/go-earthdollar/event"
 	"github.com/EarthDollar/go-earthdollar/p2p/discover"
 )
 
@@ -122,6 +123,47 @@ func TestServerListen(t *testing.T) {
 	}
 }
 
+func TestServerPeerEvents(t *testing.T) {
+	mux := new(event.TypeMux)
+	sub := mux.Subscribe(PeerEvent{})
+	defer sub.Unsubscribe()
+
+	remid := randomID()
+	srv := &Server{
+		Config: Config{
+			Name:       "test",
+			MaxPeers:   10,
+			ListenAddr: "127.0.0.1:0",
+			PrivateKey: newkey(),
+			EventMux:   mux,
+		},
+		newTransport: func(fd net.Conn) transport { return newTestTransport(remid, fd) },
+	}
+	if err := srv.Start(); err != nil {
+		t.Fatalf("could not start server: %v", err)
+	}
+	defer srv.Stop()
+
+	conn, err := net.DialTimeout("tcp", srv.ListenAddr, 5*time.Second)
+	if err != nil {
+		t.Fatalf("could not dial: %v", err)
+	}
+	expect := func(typ PeerEventType) {
+		select {
+		case ev := <-sub.Chan():
+			pev := ev.Data.(PeerEvent)
+			if pev.Type != typ || pev.Peer != remid.String() || !pev.Inbound {
+				t.Errorf("event mismatch: have %+v, want type %q for peer %x", pev, typ, remid[:8])
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("no %q event within one second", typ)
+		}
+	}
+	expect(PeerEventTypeAdd)
+	conn.Close()
+	expect(PeerEventTypeDrop)
+}
+
 func TestServerDial(t *testing.T) {
 	// run a one-shot TCP server to handle the connection.
 	listener, err := net.Listen("tcp", "127.0.0.1:0")
@@ -495,3 +537,35 @@ func randomID() (id discover.NodeID) {
 	}
 	return id
 }
+
+func TestServerNodeInfoVersions(t *testing.T) {
+	srv := &Server{
+		Config: Config{
+			Name:       "test",
+			MaxPeers:   10,
+			ListenAddr: "127.0.0.1:0",
+			PrivateKey: newkey(),
+			Protocols: []Protocol{
+				{Name: "eth", Version: 62, Length: 8},
+				{Name: "eth", Version: 63, Length: 17},
+				{Name: "shh", Version: 2, Length: 8},
+			},
+		},
+	}
+	if err := srv.Start(); err != nil {
+		t.Fatalf("could not start server: %v", err)
+	}
+	defer srv.Stop()
+
+	info := srv.NodeInfo()
+	want := map[string][]uint{"eth": {63, 62}, "shh": {2}}
+	if !reflect.DeepEqual(info.Versions, want) {
+		t.Errorf("protocol versions mismatch: have %v, want %v", info.Versions, want)
+	}
+	if len(info.Negotiated) != 0 {
+		t.Errorf("negotiated versions without peers: %v", info.Negotiated)
+	}
+	if info.ExternalIP != "" {
+		t.Errorf("external IP reported for loopback listener: %s", info.ExternalIP)
+	}
+}
@@ -0,0 +1,37 @@
+// Copyright 2015 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package params
+
+import "github.com/EarthDollar/go-earthdollar/common"
+
+// TrustedCheckpoint is a block known to be part of the canonical chain of a
+// network. Fast and light syncing nodes refuse to sync onto remote chains not
+// containing it, protecting them against long-range fake chains.
+type TrustedCheckpoint struct {
+	Number    uint64      // Block number of the checkpoint
+	Hash      common.Hash // Block hash of the checkpoint
+	BloomRoot common.Hash // Root of the bloom bits section ending at the checkpoint (zero if not known)
+}
+
+// TrustedCheckpoints associates the genesis hashes of known networks with the
+// checkpoints embedded into the client.
+var TrustedCheckpoints = map[common.Hash]*TrustedCheckpoint{
+	MainNetGenesisHash: {
+		Number: MainNetHomesteadGasRepriceBlock.Uint64(),
+		Hash:   MainNetHomesteadGasRepriceHash,
+	},
+}
@@ -47,6 +47,23 @@ var TestnetChainConfig = &ChainConfig{
 	EIP158Block:    big.NewInt(10),
 }
 
+// DevChainConfig is the chain parameters to run a single-node developer chain,
+// with all protocol upgrades active from the genesis block.
+var DevChainConfig = &ChainConfig{
+	ChainId:        big.NewInt(1337),
+	HomesteadBlock: big.NewInt(0),
+	DAOForkBlock:   nil,
+	DAOForkSupport: true,
+	EIP150Block:    big.NewInt(0),
+	EIP155Block:    big.NewInt(0),
+	EIP158Block:    big.NewInt(0),
+	EIP140Block:    big.NewInt(0),
+	EIP1014Block:   big.NewInt(0),
+	EIP198Block:    big.NewInt(0),
+	EIP152Block:    big.NewInt(0),
+	EIP3529Block:   big.NewInt(0),
+}
+
 // ChainConfig is the core config which determines the blockchain settings.
 //
 // ChainConfig is stored in the database on a per block basis. This means
@@ -65,11 +82,44 @@ type ChainConfig struct {
 
 	EIP155Block *big.Int `json:"eip155Block"` // EIP155 HF block
 	EIP158Block *big.Int `json:"eip158Block"` // EIP158 HF block
+
+	EIP140Block  *big.Int `json:"eip140Block,omitempty"`  // EIP140 (REVERT), EIP211 (return data) and EIP214 (STATICCALL) HF block (nil = no fork)
+	EIP1014Block *big.Int `json:"eip1014Block,omitempty"` // EIP1014 (CREATE2) HF block (nil = no fork)
+
+	EIP198Block *big.Int `json:"eip198Block,omitempty"` // EIP198 (modexp), EIP196 and EIP197 (alt_bn128) precompiles HF block (nil = no fork)
+	EIP152Block *big.Int `json:"eip152Block,omitempty"` // EIP152 (blake2f) precompile HF block (nil = no fork)
+
+	EIP3529Block *big.Int `json:"eip3529Block,omitempty"` // EIP3529 (refund reduction) HF block (nil = no fork)
+
+	// Monetary policy, the block reward being changed at the start of each era
+	BlockReward *big.Int    `json:"blockReward,omitempty"` // Block reward from genesis onward (nil = DefaultBlockReward)
+	RewardEras  []RewardEra `json:"rewardEras,omitempty"`  // Scheduled block reward changes
+
+	// Treasury funding, diverting a share of each block reward to the project
+	TreasuryBlock *big.Int   `json:"treasuryBlock,omitempty"` // Treasury fund split switch block (nil = no fork)
+	Treasuries    []Treasury `json:"treasuries,omitempty"`    // Treasury addresses and their share of the block reward
+
+	// Reward distribution, handing all rewards of a block to a system contract
+	RewardContractBlock *big.Int        `json:"rewardContractBlock,omitempty"` // Reward contract switch block (nil = no fork)
+	RewardContract      *common.Address `json:"rewardContract,omitempty"`      // Contract distributing the block rewards
+
+	// Uncle rewards, an uncle at depth d earning (8-d)/UncleRewardDivisor of the block
+	// reward and the including block 1/UncleInclusionDivisor of it per uncle
+	UncleRewardDivisor    *big.Int `json:"uncleRewardDivisor,omitempty"`    // Divisor of the uncle reward (nil = DefaultUncleRewardDivisor)
+	UncleInclusionDivisor *big.Int `json:"uncleInclusionDivisor,omitempty"` // Divisor of the uncle inclusion reward (nil = DefaultUncleInclusionDivisor)
+	NoUncleRewardBlock    *big.Int `json:"noUncleRewardBlock,omitempty"`    // Block from which uncles are not rewarded at all (nil = no fork)
+}
+
+// RewardEra is a scheduled change of the static block reward, e.g. a halving,
+// taking effect from the given block onward.
+type RewardEra struct {
+	Block  *big.Int `json:"block"`  // First block of the era
+	Reward *big.Int `json:"reward"` // Block reward paid during the era
 }
 
 // String implements the Stringer interface.
 func (c *ChainConfig) String() string {
-	return fmt.Sprintf("{ChainID: %v Homestead: %v DAO: %v DAOSupport: %v EIP150: %v EIP155: %v EIP158: %v}",
+	return fmt.Sprintf("{ChainID: %v Homestead: %v DAO: %v DAOSupport: %v EIP150: %v EIP155: %v EIP158: %v EIP140: %v EIP1014: %v EIP198: %v EIP152: %v EIP3529: %v}",
 		c.ChainId,
 		c.HomesteadBlock,
 		c.DAOForkBlock,
@@ -77,11 +127,16 @@ func (c *ChainConfig) String() string {
 		c.EIP150Block,
 		c.EIP155Block,
 		c.EIP158Block,
+		c.EIP140Block,
+		c.EIP1014Block,
+		c.EIP198Block,
+		c.EIP152Block,
+		c.EIP3529Block,
 	)
 }
 
 var (
-	TestChainConfig = &ChainConfig{big.NewInt(1), new(big.Int), new(big.Int), true, new(big.Int), common.Hash{}, new(big.Int), new(big.Int)}
+	TestChainConfig = &ChainConfig{big.NewInt(1), new(big.Int), new(big.Int), true, new(big.Int), common.Hash{}, new(big.Int), new(big.Int), nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil}
 	TestRules       = TestChainConfig.Rules(new(big.Int))
 )
 
@@ -100,14 +155,27 @@ func (c *ChainConfig) GasTable(num *big.Int) GasTable {
 	if num == nil {
 		return GasTableHomestead
 	}
+	for _, fork := range c.gasTableForks() {
+		if fork.block != nil && num.Cmp(fork.block) >= 0 {
+			return fork.table
+		}
+	}
+	return GasTableHomestead
+}
 
-	switch {
-	case c.EIP158Block != nil && num.Cmp(c.EIP158Block) >= 0:
-		return GasTableEIP158
-	case c.EIP150Block != nil && num.Cmp(c.EIP150Block) >= 0:
-		return GasTableHomesteadGasRepriceFork
-	default:
-		return GasTableHomestead
+// gasTableFork is a repricing hard fork, switching to a new gas table.
+type gasTableFork struct {
+	block *big.Int
+	table GasTable
+}
+
+// gasTableForks returns the repricing forks of the chain, latest first. A new
+// repricing fork is introduced by adding its gas table here.
+func (c *ChainConfig) gasTableForks() []gasTableFork {
+	return []gasTableFork{
+		{c.EIP3529Block, GasTableEIP3529},
+		{c.EIP158Block, GasTableEIP158},
+		{c.EIP150Block, GasTableEIP150},
 	}
 }
 
@@ -135,6 +203,133 @@ func (c *ChainConfig) IsEIP158(num *big.Int) bool {
 
 }
 
+// IsEIP140 returns whether num is either equal to the REVERT and return data
+// fork block or greater.
+func (c *ChainConfig) IsEIP140(num *big.Int) bool {
+	if c.EIP140Block == nil || num == nil {
+		return false
+	}
+	return num.Cmp(c.EIP140Block) >= 0
+}
+
+// IsEIP1014 returns whether num is either equal to the CREATE2 fork block or greater.
+func (c *ChainConfig) IsEIP1014(num *big.Int) bool {
+	if c.EIP1014Block == nil || num == nil {
+		return false
+	}
+	return num.Cmp(c.EIP1014Block) >= 0
+}
+
+// IsEIP198 returns whether num is either equal to the modexp and alt_bn128
+// precompiles fork block or greater.
+func (c *ChainConfig) IsEIP198(num *big.Int) bool {
+	if c.EIP198Block == nil || num == nil {
+		return false
+	}
+	return num.Cmp(c.EIP198Block) >= 0
+}
+
+// IsEIP152 returns whether num is either equal to the blake2f precompile fork
+// block or greater.
+func (c *ChainConfig) IsEIP152(num *big.Int) bool {
+	if c.EIP152Block == nil || num == nil {
+		return false
+	}
+	return num.Cmp(c.EIP152Block) >= 0
+}
+
+// IsEIP3529 returns whether num is either equal to the refund reduction fork
+// block or greater.
+func (c *ChainConfig) IsEIP3529(num *big.Int) bool {
+	if c.EIP3529Block == nil || num == nil {
+		return false
+	}
+	return num.Cmp(c.EIP3529Block) >= 0
+}
+
+// Treasury is an address receiving a fixed percentage of the static reward of
+// every block after the treasury fork.
+type Treasury struct {
+	Address common.Address `json:"address"` // Address credited with the share
+	Percent uint64         `json:"percent"` // Share of the block reward, in percent
+}
+
+// BlockRewardAt returns the static block reward of the miner of block num, being
+// that of the most recent era started at or before it.
+func (c *ChainConfig) BlockRewardAt(num *big.Int) *big.Int {
+	var (
+		reward = DefaultBlockReward
+		start  *big.Int
+	)
+	if c.BlockReward != nil {
+		reward = c.BlockReward
+	}
+	for _, era := range c.RewardEras {
+		if era.Block == nil || era.Reward == nil || num.Cmp(era.Block) < 0 {
+			continue
+		}
+		if start == nil || era.Block.Cmp(start) >= 0 {
+			reward, start = era.Reward, era.Block
+		}
+	}
+	return new(big.Int).Set(reward)
+}
+
+// UncleReward returns the reward of the miner of an uncle with the given number
+// included in block num, given the block reward in effect.
+func (c *ChainConfig) UncleReward(num, uncle, blockReward *big.Int) *big.Int {
+	if c.IsNoUncleReward(num) {
+		return new(big.Int)
+	}
+	divisor := DefaultUncleRewardDivisor
+	if c.UncleRewardDivisor != nil && c.UncleRewardDivisor.Sign() > 0 {
+		divisor = c.UncleRewardDivisor
+	}
+	r := new(big.Int).Add(uncle, UncleRewardDepth)
+	r.Sub(r, num)
+	r.Mul(r, blockReward)
+	return r.Div(r, divisor)
+}
+
+// UncleInclusionReward returns the reward of the miner of block num for each
+// uncle included, given the block reward in effect.
+func (c *ChainConfig) UncleInclusionReward(num, blockReward *big.Int) *big.Int {
+	if c.IsNoUncleReward(num) {
+		return new(big.Int)
+	}
+	divisor := DefaultUncleInclusionDivisor
+	if c.UncleInclusionDivisor != nil && c.UncleInclusionDivisor.Sign() > 0 {
+		divisor = c.UncleInclusionDivisor
+	}
+	return new(big.Int).Div(blockReward, divisor)
+}
+
+// IsNoUncleReward returns whether num is either equal to the block from which
+// uncles are no longer rewarded or greater.
+func (c *ChainConfig) IsNoUncleReward(num *big.Int) bool {
+	if c.NoUncleRewardBlock == nil || num == nil {
+		return false
+	}
+	return num.Cmp(c.NoUncleRewardBlock) >= 0
+}
+
+// IsTreasury returns whether num is either equal to the treasury fork block or greater.
+func (c *ChainConfig) IsTreasury(num *big.Int) bool {
+	if c.TreasuryBlock == nil || num == nil {
+		return false
+	}
+	return num.Cmp(c.TreasuryBlock) >= 0
+}
+
+// IsRewardContract returns whether num is either equal to the reward contract
+// fork block or greater, and a reward contract is configured.
+func (c *ChainConfig) IsRewardContract(num *big.Int) bool {
+	if c.RewardContractBlock == nil || c.RewardContract == nil || num == nil {
+		return false
+	}
+	return num.Cmp(c.RewardContractBlock) >= 0
+}
+
 // Rules wraps ChainConfig and is merely syntatic sugar or can be used for functions
 // that do not have or require information about the block.
 //
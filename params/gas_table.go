@@ -18,6 +18,10 @@ package params
 
 import "math/big"
 
+// GasTable organizes the gas prices of the operations whose cost has changed,
+// or may change, in a hard fork. The EVM picks the table matching the current
+// block from the chain configuration, so a repricing fork only needs a new
+// table and a fork switch, not changes to the interpreter.
 type GasTable struct {
 	ExtcodeSize *big.Int
 	ExtcodeCopy *big.Int
@@ -28,6 +32,29 @@ type GasTable struct {
 
 	ExpByte *big.Int
 
+	Sha3     *big.Int // Once per SHA3 operation
+	Sha3Word *big.Int // Once per word of SHA3 (and CREATE2) data
+	Copy     *big.Int // Once per word copied by the *COPY operations
+	Create   *big.Int // Once per CREATE and CREATE2 operation
+
+	SStoreSet    *big.Int // SSTORE from a zero to a non-zero value
+	SStoreReset  *big.Int // SSTORE from a non-zero to a non-zero (or zero to zero) value
+	SStoreClear  *big.Int // SSTORE from a non-zero to a zero value
+	SStoreRefund *big.Int // Refunded when clearing a storage slot
+
+	Log      *big.Int // Once per LOG* operation
+	LogTopic *big.Int // Once per LOG* topic
+	LogData  *big.Int // Once per byte of LOG* data
+
+	CallValueTransfer *big.Int // Paid for CALL and CALLCODE when transferring value
+	CallNewAccount    *big.Int // Paid for CALL when the destination account is created
+
+	SuicideRefund *big.Int // Refunded following a suicide operation
+
+	// RefundQuotient caps the gas refunded at the end of a transaction to
+	// the gas used divided by this quotient.
+	RefundQuotient *big.Int
+
 	// CreateBySuicide occurs when the
 	// refunded account is one that does
 	// not exist. This logic is similar
@@ -48,16 +75,34 @@ var (
 		Suicide:     big.NewInt(0),
 		ExpByte:     big.NewInt(10),
 
+		Sha3:     Sha3Gas,
+		Sha3Word: Sha3WordGas,
+		Copy:     CopyGas,
+		Create:   CreateGas,
+
+		SStoreSet:    SstoreSetGas,
+		SStoreReset:  SstoreResetGas,
+		SStoreClear:  SstoreClearGas,
+		SStoreRefund: SstoreRefundGas,
+
+		Log:      LogGas,
+		LogTopic: LogTopicGas,
+		LogData:  LogDataGas,
+
+		CallValueTransfer: CallValueTransferGas,
+		CallNewAccount:    CallNewAccountGas,
+
+		SuicideRefund:  SuicideRefundGas,
+		RefundQuotient: RefundQuotient,
+
 		// explicitly set to nil to indicate
 		// this rule does not apply to homestead.
 		CreateBySuicide: nil,
 	}
 
-	// GasTableHomestead contain the gas re-prices for
+	// GasTableEIP150 contain the gas re-prices for
 	// the homestead phase.
-	//
-	// TODO rename to GasTableEIP150
-	GasTableHomesteadGasRepriceFork = GasTable{
+	GasTableEIP150 = GasTable{
 		ExtcodeSize: big.NewInt(700),
 		ExtcodeCopy: big.NewInt(700),
 		Balance:     big.NewInt(400),
@@ -66,9 +111,31 @@ var (
 		Suicide:     big.NewInt(5000),
 		ExpByte:     big.NewInt(10),
 
+		Sha3:     Sha3Gas,
+		Sha3Word: Sha3WordGas,
+		Copy:     CopyGas,
+		Create:   CreateGas,
+
+		SStoreSet:    SstoreSetGas,
+		SStoreReset:  SstoreResetGas,
+		SStoreClear:  SstoreClearGas,
+		SStoreRefund: SstoreRefundGas,
+
+		Log:      LogGas,
+		LogTopic: LogTopicGas,
+		LogData:  LogDataGas,
+
+		CallValueTransfer: CallValueTransferGas,
+		CallNewAccount:    CallNewAccountGas,
+
+		SuicideRefund:  SuicideRefundGas,
+		RefundQuotient: RefundQuotient,
+
 		CreateBySuicide: big.NewInt(25000),
 	}
 
+	// GasTableEIP158 contain the gas re-prices for
+	// the EIP158 (spurious dragon) phase.
 	GasTableEIP158 = GasTable{
 		ExtcodeSize: big.NewInt(700),
 		ExtcodeCopy: big.NewInt(700),
@@ -78,6 +145,60 @@ var (
 		Suicide:     big.NewInt(5000),
 		ExpByte:     big.NewInt(50),
 
+		Sha3:     Sha3Gas,
+		Sha3Word: Sha3WordGas,
+		Copy:     CopyGas,
+		Create:   CreateGas,
+
+		SStoreSet:    SstoreSetGas,
+		SStoreReset:  SstoreResetGas,
+		SStoreClear:  SstoreClearGas,
+		SStoreRefund: SstoreRefundGas,
+
+		Log:      LogGas,
+		LogTopic: LogTopicGas,
+		LogData:  LogDataGas,
+
+		CallValueTransfer: CallValueTransferGas,
+		CallNewAccount:    CallNewAccountGas,
+
+		SuicideRefund:  SuicideRefundGas,
+		RefundQuotient: RefundQuotient,
+
+		CreateBySuicide: big.NewInt(25000),
+	}
+
+	// GasTableEIP3529 contain the reduced refunds of the EIP3529 phase,
+	// removing the suicide refund and lowering the refund cap.
+	GasTableEIP3529 = GasTable{
+		ExtcodeSize: big.NewInt(700),
+		ExtcodeCopy: big.NewInt(700),
+		Balance:     big.NewInt(400),
+		SLoad:       big.NewInt(200),
+		Calls:       big.NewInt(700),
+		Suicide:     big.NewInt(5000),
+		ExpByte:     big.NewInt(50),
+
+		Sha3:     Sha3Gas,
+		Sha3Word: Sha3WordGas,
+		Copy:     CopyGas,
+		Create:   CreateGas,
+
+		SStoreSet:    SstoreSetGas,
+		SStoreReset:  SstoreResetGas,
+		SStoreClear:  SstoreClearGas,
+		SStoreRefund: SstoreRefundGasEIP3529,
+
+		Log:      LogGas,
+		LogTopic: LogTopicGas,
+		LogData:  LogDataGas,
+
+		CallValueTransfer: CallValueTransferGas,
+		CallNewAccount:    CallNewAccountGas,
+
+		SuicideRefund:  new(big.Int),
+		RefundQuotient: RefundQuotientEIP3529,
+
 		CreateBySuicide: big.NewInt(25000),
 	}
 )
@@ -16,7 +16,11 @@
 
 package params
 
-import "math/big"
+import (
+	"math/big"
+
+	"github.com/EarthDollar/go-earthdollar/common"
+)
 
 var (
 	MaximumExtraDataSize   = big.NewInt(32)     // Maximum size extra data may be after Genesis.
@@ -70,4 +74,30 @@ var (
 	TxDataNonZeroGas     = big.NewInt(68)     // Per byte of data attached to a transaction that is not equal to zero. NOTE: Not payable on data of calls between transactions.
 
 	MaxCodeSize = 24576
+
+	ModExpQuadCoeffDiv      = big.NewInt(20)     // Divisor for the quadratic particle of the big int modular exponentiation.
+	Bn256AddGas             = big.NewInt(500)    // Gas needed for an elliptic curve addition.
+	Bn256ScalarMulGas       = big.NewInt(40000)  // Gas needed for an elliptic curve scalar multiplication.
+	Bn256PairingBaseGas     = big.NewInt(100000) // Base price for an elliptic curve pairing check.
+	Bn256PairingPerPointGas = big.NewInt(80000)  // Per-point price for an elliptic curve pairing check.
+	Blake2FRoundGas         = big.NewInt(1)      // Per-round price of the BLAKE2b compression function.
+
+	RefundQuotient         = big.NewInt(2)    // Maximum refund quotient; at most half of the gas used is refunded.
+	RefundQuotientEIP3529  = big.NewInt(5)    // Maximum refund quotient after EIP3529; at most a fifth of the gas used is refunded.
+	SstoreRefundGasEIP3529 = big.NewInt(4800) // Refunded gas after EIP3529, once per SSTORE operation if the zeroness changes to zero.
+
+	DefaultBlockReward = big.NewInt(5e+18)   // Block reward in wei for successfully mining a block, unless overridden by the chain config.
+	RewardContractGas  = big.NewInt(1000000) // Gas allowance of the reward distribution contract at block finalization.
+
+	UncleRewardDepth             = big.NewInt(8)  // Depth at which an uncle would no longer earn any reward.
+	DefaultUncleRewardDivisor    = big.NewInt(8)  // Divisor of the depth scaled block reward paid to the miner of an uncle.
+	DefaultUncleInclusionDivisor = big.NewInt(32) // Divisor of the block reward paid for including an uncle.
 )
+
+// SystemAddress is the sender of calls made by the protocol itself, such as the
+// invocation of the reward distribution contract.
+var SystemAddress = common.HexToAddress("0xfffffffffffffffffffffffffffffffffffffffe")
+
+// BloomBitsBlocks is the number of blocks a single bloom bit section vector
+// contains in the log filtering index.
+const BloomBitsBlocks uint64 = 4096
@@ -0,0 +1,143 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"reflect"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// redacted replaces secret arguments (passwords, private keys) in audit records.
+const redacted = "<redacted>"
+
+// auditedNamespaces lists the API namespaces of which every method is audited.
+var auditedNamespaces = map[string]bool{
+	"admin":    true,
+	"personal": true,
+}
+
+// auditedMethods lists individual methods outside of the audited namespaces
+// which sign or submit transactions on behalf of the node.
+var auditedMethods = map[string]bool{
+	"eth_sign":               true,
+	"eth_signTransaction":    true,
+	"eth_sendTransaction":    true,
+	"eth_sendRawTransaction": true,
+}
+
+// secretNamespaces lists the namespaces in which every plain string argument is
+// a password or a private key and must never reach the audit log.
+var secretNamespaces = map[string]bool{
+	"personal": true,
+}
+
+// AuditRecord describes a single invocation of a sensitive RPC method.
+type AuditRecord struct {
+	Time     time.Time     `json:"time"`
+	Origin   string        `json:"origin"`
+	Method   string        `json:"method"`
+	Params   []interface{} `json:"params"`
+	Duration time.Duration `json:"duration"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// Auditor is notified about every call to a sensitive RPC method once the call
+// has completed.
+type Auditor interface {
+	Audit(rec *AuditRecord)
+}
+
+// AuditLog is an Auditor writing records as line delimited JSON into a writer.
+type AuditLog struct {
+	lock sync.Mutex
+	enc  *json.Encoder
+}
+
+// NewAuditLog creates an audit log writing into w.
+func NewAuditLog(w io.Writer) *AuditLog {
+	return &AuditLog{enc: json.NewEncoder(w)}
+}
+
+// Audit implements Auditor, appending the record to the log.
+func (l *AuditLog) Audit(rec *AuditRecord) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.enc.Encode(rec)
+}
+
+// SetAuditor installs an auditor which is notified about every call to a
+// sensitive method. It must be called before the server starts serving.
+func (s *Server) SetAuditor(auditor Auditor) {
+	s.auditor = auditor
+}
+
+// isAudited reports whether calls to the given method must be audited.
+func isAudited(namespace, method string) bool {
+	return auditedNamespaces[namespace] || auditedMethods[namespace+serviceMethodSeparator+method]
+}
+
+// auditParams converts call arguments into their loggable form, removing any
+// secrets the method might receive.
+func auditParams(namespace string, args []reflect.Value) []interface{} {
+	params := make([]interface{}, len(args))
+	for i, arg := range args {
+		if secretNamespaces[namespace] && arg.Kind() == reflect.String {
+			params[i] = redacted
+			continue
+		}
+		params[i] = arg.Interface()
+	}
+	return params
+}
+
+// originKey is the context key under which the remote end of a connection is stored.
+type originKey struct{}
+
+// originFromContext returns the remote end of the connection a request was
+// received on, if it is known.
+func originFromContext(ctx context.Context) string {
+	if origin, ok := ctx.Value(originKey{}).(string); ok {
+		return origin
+	}
+	return ""
+}
+
+// codecOrigin tries to determine the remote end of the connection the codec
+// is operating on.
+func codecOrigin(codec ServerCodec) string {
+	jc, ok := codec.(*jsonCodec)
+	if !ok {
+		return ""
+	}
+	switch rw := jc.rw.(type) {
+	case *httpReadWriteNopCloser:
+		return rw.remote
+	case net.Conn:
+		if addr := rw.RemoteAddr(); addr != nil && addr.String() != "" && addr.String() != addr.Network() {
+			return addr.Network() + "://" + addr.String()
+		}
+		return rw.LocalAddr().Network()
+	}
+	return ""
+}
@@ -130,6 +130,8 @@ func (hc *httpConn) doRequest(ctx context.Context, msg interface{}) (io.ReadClos
 type httpReadWriteNopCloser struct {
 	io.Reader
 	io.Writer
+	remote string     // remote address of the requester, used for auditing
+	client rateClient // identity of the requester, used for rate limiting
 }
 
 // Close does nothing and returns always nil
@@ -157,7 +159,7 @@ func (srv *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// create a codec that reads direct from the request body until
 	// EOF and writes the response to w and order the server to process
 	// a single request.
-	codec := NewJSONCodec(&httpReadWriteNopCloser{r.Body, w})
+	codec := NewJSONCodec(&httpReadWriteNopCloser{r.Body, w, "http://" + r.RemoteAddr, requestRateClient(r)})
 	defer codec.Close()
 	srv.ServeSingleRequest(codec, OptionMethodInvocation)
 }
@@ -0,0 +1,75 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// SetBatchLimits restricts the number of requests a single batch may contain and
+// the total size of the responses an execution may produce. Responses exceeding
+// the size limit are replaced by errors. Zero values disable the respective
+// limit. It must be called before the server starts serving.
+func (s *Server) SetBatchLimits(maxItems, maxResponseSize int) {
+	s.batchItemLimit = maxItems
+	s.responseSizeLimit = maxResponseSize
+}
+
+// SetConcurrencyLimit bounds the number of requests (or batches) the server
+// executes concurrently, across all connections. Requests exceeding the limit
+// wait for a running one to finish. Zero disables the limit. It must be called
+// before the server starts serving.
+func (s *Server) SetConcurrencyLimit(workers int) {
+	if workers > 0 {
+		s.workers = make(chan struct{}, workers)
+	} else {
+		s.workers = nil
+	}
+}
+
+// acquireWorker blocks until a slot of the worker pool is available.
+func (s *Server) acquireWorker() {
+	if s.workers != nil {
+		s.workers <- struct{}{}
+	}
+}
+
+// releaseWorker returns a slot to the worker pool.
+func (s *Server) releaseWorker() {
+	if s.workers != nil {
+		<-s.workers
+	}
+}
+
+// batchTooLarge checks whether a batch exceeds the configured item limit,
+// returning the error to reply with if so.
+func (s *Server) batchTooLarge(reqs []*serverRequest) Error {
+	if s.batchItemLimit > 0 && len(reqs) > s.batchItemLimit {
+		return &invalidRequestError{fmt.Sprintf("batch too large (%d>%d)", len(reqs), s.batchItemLimit)}
+	}
+	return nil
+}
+
+// responseSize returns the encoded size of a response.
+func (s *Server) responseSize(response interface{}) int {
+	blob, err := json.Marshal(response)
+	if err != nil {
+		return 0
+	}
+	return len(blob)
+}
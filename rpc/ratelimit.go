@@ -0,0 +1,231 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+	"golang.org/x/net/websocket"
+)
+
+// maxRateBuckets is the number of clients tracked by a rate limiter, after which
+// the buckets of idle clients are dropped.
+const maxRateBuckets = 65536
+
+// APIKeyHeader is the HTTP header clients may present an API key in. Keys are
+// only honoured if a quota was configured for them, otherwise the client is
+// accounted by its IP address.
+const APIKeyHeader = "X-API-Key"
+
+// defaultMethodCosts are the token costs of the calls which are considerably
+// more expensive to serve than the average one. Entries may either be methods
+// or entire namespaces, the former taking precedence.
+var defaultMethodCosts = map[string]int{
+	"debug":                    10,
+	"debug_traceTransaction":   50,
+	"debug_traceBlock":         50,
+	"debug_traceBlockByNumber": 50,
+	"debug_traceBlockByHash":   50,
+	"debug_traceBlockFromFile": 50,
+	"eth_getLogs":              5,
+	"eth_call":                 2,
+	"eth_estimateGas":          2,
+}
+
+// RateQuota is the allowance of a client: the number of tokens (the cost of a
+// plain call) regained per second and the maximum number of tokens it may save
+// up for bursts of requests. A non-positive rate exempts the client from limits.
+type RateQuota struct {
+	Rate  float64
+	Burst int
+}
+
+// RateLimiter is a token bucket based limiter of the calls a single client may
+// make, identified by its API key or remote IP address. A limiter may be shared
+// by multiple servers, in which case the clients' allowance is shared too.
+type RateLimiter struct {
+	lock    sync.Mutex
+	quota   RateQuota               // Allowance of clients without a dedicated quota
+	quotas  map[string]RateQuota    // Dedicated allowances by API key or IP address
+	costs   map[string]int          // Token costs of methods or namespaces
+	buckets map[string]*tokenBucket // Current token buckets of the active clients
+}
+
+// tokenBucket is the state of a single client's allowance.
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// rateClient is the identity of the remote end of a connection.
+type rateClient struct {
+	ip  string // Remote IP address of the client
+	key string // API key presented by the client, if any
+}
+
+// rateClientKey is the context key under which the client identity is stored.
+type rateClientKey struct{}
+
+// NewRateLimiter creates a limiter granting every client rate calls per second,
+// with bursts of up to burst calls. If the burst is not positive, it defaults to a
+// second worth of calls.
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	costs := make(map[string]int, len(defaultMethodCosts))
+	for method, cost := range defaultMethodCosts {
+		costs[method] = cost
+	}
+	return &RateLimiter{
+		quota:   RateQuota{Rate: rate, Burst: burst},
+		quotas:  make(map[string]RateQuota),
+		costs:   costs,
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// SetQuota overrides the allowance of the client with the given API key or IP
+// address.
+func (l *RateLimiter) SetQuota(client string, quota RateQuota) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.quotas[client] = quota
+	delete(l.buckets, client)
+}
+
+// SetCost sets the number of tokens a call to the method (e.g. debug_traceBlock)
+// or to any method of the namespace (e.g. debug) consumes.
+func (l *RateLimiter) SetCost(method string, cost int) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.costs[method] = cost
+}
+
+// allow reports whether the client may make the call, consuming its cost.
+func (l *RateLimiter) allow(client rateClient, namespace, method string, now time.Time) bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	// Resolve the account the call is charged to
+	id, quota := client.ip, l.quota
+	if q, ok := l.quotas[client.key]; ok && client.key != "" {
+		id, quota = client.key, q
+	} else if q, ok := l.quotas[client.ip]; ok {
+		quota = q
+	}
+	if quota.Rate <= 0 {
+		return true
+	}
+	burst := float64(quota.Burst)
+	if burst <= 0 {
+		burst = quota.Rate
+	}
+	// Determine the cost of the call, capped so any call succeeds eventually
+	cost, ok := l.costs[namespace+serviceMethodSeparator+method]
+	if !ok {
+		if cost, ok = l.costs[namespace]; !ok {
+			cost = 1
+		}
+	}
+	if float64(cost) > burst {
+		cost = int(burst)
+	}
+	// Refill the client's bucket and try to take the cost out of it
+	bucket := l.buckets[id]
+	if bucket == nil {
+		if len(l.buckets) >= maxRateBuckets {
+			l.prune(now)
+		}
+		bucket = &tokenBucket{tokens: burst, last: now}
+		l.buckets[id] = bucket
+	}
+	if elapsed := now.Sub(bucket.last).Seconds(); elapsed > 0 {
+		bucket.tokens += elapsed * quota.Rate
+		if bucket.tokens > burst {
+			bucket.tokens = burst
+		}
+	}
+	bucket.last = now
+	if bucket.tokens < float64(cost) {
+		return false
+	}
+	bucket.tokens -= float64(cost)
+	return true
+}
+
+// prune drops the buckets of the clients which have regained their full default
+// allowance, as they would be recreated identically. The lock must be held.
+func (l *RateLimiter) prune(now time.Time) {
+	burst := float64(l.quota.Burst)
+	if burst <= 0 {
+		burst = l.quota.Rate
+	}
+	for id, bucket := range l.buckets {
+		if bucket.tokens+now.Sub(bucket.last).Seconds()*l.quota.Rate >= burst {
+			delete(l.buckets, id)
+		}
+	}
+}
+
+// SetRateLimiter installs a limiter restricting the rate at which clients may
+// call methods. It must be called before the server starts serving.
+func (s *Server) SetRateLimiter(limiter *RateLimiter) {
+	s.limiter = limiter
+}
+
+// rateClientFromContext returns the identity of the client a request was
+// received from.
+func rateClientFromContext(ctx context.Context) rateClient {
+	client, _ := ctx.Value(rateClientKey{}).(rateClient)
+	return client
+}
+
+// codecRateClient tries to determine the identity of the client at the remote
+// end of the connection the codec is operating on.
+func codecRateClient(codec ServerCodec) rateClient {
+	jc, ok := codec.(*jsonCodec)
+	if !ok {
+		return rateClient{}
+	}
+	switch rw := jc.rw.(type) {
+	case *httpReadWriteNopCloser:
+		return rw.client
+	case *websocket.Conn:
+		if req := rw.Request(); req != nil {
+			return requestRateClient(req)
+		}
+	case net.Conn:
+		if addr, ok := rw.RemoteAddr().(*net.TCPAddr); ok {
+			return rateClient{ip: addr.IP.String()}
+		}
+	}
+	return rateClient{}
+}
+
+// requestRateClient determines the identity of the client issuing an HTTP request.
+func requestRateClient(r *http.Request) rateClient {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	return rateClient{ip: ip, key: strings.TrimSpace(r.Header.Get(APIKeyHeader))}
+}
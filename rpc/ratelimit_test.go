@@ -0,0 +1,78 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	limiter := NewRateLimiter(1, 10)
+	limiter.SetQuota("trusted", RateQuota{})
+	limiter.SetQuota("10.0.0.2", RateQuota{Rate: 1, Burst: 100})
+
+	var (
+		now     = time.Unix(1500000000, 0)
+		alice   = rateClient{ip: "10.0.0.1"}
+		bob     = rateClient{ip: "10.0.0.1", key: "unknown"}
+		carol   = rateClient{ip: "10.0.0.2"}
+		trusted = rateClient{ip: "10.0.0.1", key: "trusted"}
+	)
+	// Plain calls should drain the bucket at unit cost
+	for i := 0; i < 10; i++ {
+		if !limiter.allow(alice, "eth", "blockNumber", now) {
+			t.Fatalf("call %d within burst denied", i)
+		}
+	}
+	if limiter.allow(alice, "eth", "blockNumber", now) {
+		t.Fatalf("call exceeding burst allowed")
+	}
+	// Unknown API keys must not allow evading the IP limit, configured ones should
+	if limiter.allow(bob, "eth", "blockNumber", now) {
+		t.Fatalf("unknown API key evaded IP limit")
+	}
+	for i := 0; i < 100; i++ {
+		if !limiter.allow(trusted, "debug", "traceTransaction", now) {
+			t.Fatalf("call %d of exempt client denied", i)
+		}
+	}
+	// Tokens should be regained over time, heavy calls costing more
+	now = now.Add(5 * time.Second)
+	if limiter.allow(alice, "debug", "dumpBlock", now) {
+		t.Fatalf("namespace weighted call allowed without enough tokens")
+	}
+	for i := 0; i < 5; i++ {
+		if !limiter.allow(alice, "eth", "blockNumber", now) {
+			t.Fatalf("call %d with regained tokens denied", i)
+		}
+	}
+	// Dedicated IP quotas should override the default allowance, with calls
+	// costing more than the burst being capped
+	for i := 0; i < 2; i++ {
+		if !limiter.allow(carol, "debug", "traceTransaction", now) {
+			t.Fatalf("trace %d within dedicated quota denied", i)
+		}
+	}
+	if limiter.allow(carol, "debug", "traceTransaction", now) {
+		t.Fatalf("trace exceeding dedicated quota allowed")
+	}
+	now = now.Add(time.Hour)
+	if !limiter.allow(alice, "debug", "traceTransaction", now) {
+		t.Fatalf("call costing more than the burst denied")
+	}
+}
@@ -20,7 +20,9 @@ import (
 	"fmt"
 	"reflect"
 	"runtime"
+	"strings"
 	"sync/atomic"
+	"time"
 
 	"github.com/EarthDollar/go-earthdollar/logger"
 	"github.com/EarthDollar/go-earthdollar/logger/glog"
@@ -32,7 +34,7 @@ const (
 	notificationBufferSize = 10000 // max buffered notifications before codec is closed
 
 	MetadataApi     = "rpc"
-	DefaultIPCApis  = "admin,debug,eth,miner,net,personal,shh,txpool,web3"
+	DefaultIPCApis  = "admin,debug,ed,eth,miner,net,personal,release,shh,txpool,web3"
 	DefaultHTTPApis = "eth,net,web3"
 )
 
@@ -162,6 +164,15 @@ func (s *Server) serveRequest(codec ServerCodec, singleShot bool, options CodecO
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// remember where requests originate from so sensitive calls can be audited
+	if s.auditor != nil {
+		ctx = context.WithValue(ctx, originKey{}, codecOrigin(codec))
+	}
+	// remember who requests originate from so their call rate can be limited
+	if s.limiter != nil {
+		ctx = context.WithValue(ctx, rateClientKey{}, codecRateClient(codec))
+	}
+
 	// if the codec supports notification include a notifier that callbacks can use
 	// to send notification to clients. It is thight to the codec/connection. If the
 	// connection is closed the notifier will stop and cancels all active subscriptions.
@@ -201,16 +212,42 @@ func (s *Server) serveRequest(codec ServerCodec, singleShot bool, options CodecO
 			return nil
 		}
 
+		// reject oversized batches without executing any of their requests
+		if batch {
+			if err := s.batchTooLarge(reqs); err != nil {
+				codec.Write(codec.CreateErrorResponse(nil, err))
+				if singleShot {
+					return nil
+				}
+				continue
+			}
+		}
+
+		// wait for a worker to become available if their number is limited
+		s.beginRequest()
+		s.acquireWorker()
 		if singleShot && batch {
 			s.execBatch(ctx, codec, reqs)
+			s.releaseWorker()
+			s.endRequest()
 			return nil
 		} else if singleShot && !batch {
 			s.exec(ctx, codec, reqs[0])
+			s.releaseWorker()
+			s.endRequest()
 			return nil
 		} else if !singleShot && batch {
-			go s.execBatch(ctx, codec, reqs)
+			go func() {
+				defer s.endRequest()
+				defer s.releaseWorker()
+				s.execBatch(ctx, codec, reqs)
+			}()
 		} else {
-			go s.exec(ctx, codec, reqs[0])
+			go func() {
+				defer s.endRequest()
+				defer s.releaseWorker()
+				s.exec(ctx, codec, reqs[0])
+			}()
 		}
 	}
 
@@ -246,6 +283,49 @@ func (s *Server) Stop() {
 	}
 }
 
+// WaitPending blocks until all requests that were being executed are finished
+// or the context is cancelled, in which case its error is returned. It is meant
+// to be called after Stop to let in-flight requests complete before tearing
+// down the services they use.
+func (s *Server) WaitPending(ctx context.Context) error {
+	s.pendingMu.Lock()
+	if s.pending == 0 {
+		s.pendingMu.Unlock()
+		return nil
+	}
+	idle := make(chan struct{})
+	s.idle = append(s.idle, idle)
+	s.pendingMu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// beginRequest marks the start of a request execution.
+func (s *Server) beginRequest() {
+	s.pendingMu.Lock()
+	s.pending++
+	s.pendingMu.Unlock()
+}
+
+// endRequest marks the end of a request execution, waking up any WaitPending
+// callers if it was the last one running.
+func (s *Server) endRequest() {
+	s.pendingMu.Lock()
+	defer s.pendingMu.Unlock()
+
+	if s.pending--; s.pending == 0 {
+		for _, idle := range s.idle {
+			close(idle)
+		}
+		s.idle = nil
+	}
+}
+
 // createSubscription will call the subscription callback and returns the subscription id or error.
 func (s *Server) createSubscription(ctx context.Context, c ServerCodec, req *serverRequest) (ID, error) {
 	// subscription have as first argument the context following optional arguments
@@ -283,6 +363,10 @@ func (s *Server) handle(ctx context.Context, codec ServerCodec, req *serverReque
 		return codec.CreateErrorResponse(&req.id, &invalidParamsError{"Expected subscription id as first argument"}), nil
 	}
 
+	if s.limiter != nil && !s.limiter.allow(rateClientFromContext(ctx), req.svcname, formatName(req.callb.method.Name), time.Now()) {
+		return codec.CreateErrorResponse(&req.id, &rateLimitError{}), nil
+	}
+
 	if req.callb.isSubscribe {
 		subid, err := s.createSubscription(ctx, codec, req)
 		if err != nil {
@@ -292,7 +376,7 @@ func (s *Server) handle(ctx context.Context, codec ServerCodec, req *serverReque
 		// active the subscription after the sub id was successfully sent to the client
 		activateSub := func() {
 			notifier, _ := NotifierFromContext(ctx)
-			notifier.activate(subid)
+			notifier.activate(subid, req.svcname)
 		}
 
 		return codec.CreateResponse(req.id, subid), activateSub
@@ -315,6 +399,19 @@ func (s *Server) handle(ctx context.Context, codec ServerCodec, req *serverReque
 	}
 
 	// execute RPC method and return result
+	var audit *AuditRecord
+	if method := formatName(req.callb.method.Name); s.auditor != nil && isAudited(req.svcname, method) {
+		audit = &AuditRecord{
+			Time:   time.Now(),
+			Origin: originFromContext(ctx),
+			Method: req.svcname + serviceMethodSeparator + method,
+			Params: auditParams(req.svcname, req.args),
+		}
+		defer func() {
+			audit.Duration = time.Since(audit.Time)
+			s.auditor.Audit(audit)
+		}()
+	}
 	reply := req.callb.method.Func.Call(arguments)
 	if len(reply) == 0 {
 		return codec.CreateResponse(req.id, nil), nil
@@ -323,6 +420,9 @@ func (s *Server) handle(ctx context.Context, codec ServerCodec, req *serverReque
 	if req.callb.errPos >= 0 { // test if method returned an error
 		if !reply[req.callb.errPos].IsNil() {
 			e := reply[req.callb.errPos].Interface().(error)
+			if audit != nil {
+				audit.Error = e.Error()
+			}
 			res := codec.CreateErrorResponse(&req.id, &callbackError{e.Error()})
 			return res, nil
 		}
@@ -339,6 +439,9 @@ func (s *Server) exec(ctx context.Context, codec ServerCodec, req *serverRequest
 	} else {
 		response, callback = s.handle(ctx, codec, req)
 	}
+	if s.responseSizeLimit > 0 && s.responseSize(response) > s.responseSizeLimit {
+		response, callback = codec.CreateErrorResponse(&req.id, &responseTooLargeError{}), nil
+	}
 
 	if err := codec.Write(response); err != nil {
 		glog.V(logger.Error).Infof("%v\n", err)
@@ -355,16 +458,30 @@ func (s *Server) exec(ctx context.Context, codec ServerCodec, req *serverRequest
 // It will only write the response back when the last request is processed.
 func (s *Server) execBatch(ctx context.Context, codec ServerCodec, requests []*serverRequest) {
 	responses := make([]interface{}, len(requests))
-	var callbacks []func()
+	var (
+		callbacks []func()
+		size      int
+	)
 	for i, req := range requests {
+		// once the response limit is reached, don't waste effort on the rest
+		if s.responseSizeLimit > 0 && size > s.responseSizeLimit {
+			responses[i] = codec.CreateErrorResponse(&req.id, &responseTooLargeError{})
+			continue
+		}
+		var callback func()
 		if req.err != nil {
 			responses[i] = codec.CreateErrorResponse(&req.id, req.err)
 		} else {
-			var callback func()
-			if responses[i], callback = s.handle(ctx, codec, req); callback != nil {
-				callbacks = append(callbacks, callback)
+			responses[i], callback = s.handle(ctx, codec, req)
+		}
+		if s.responseSizeLimit > 0 {
+			if size += s.responseSize(responses[i]); size > s.responseSizeLimit {
+				responses[i], callback = codec.CreateErrorResponse(&req.id, &responseTooLargeError{}), nil
 			}
 		}
+		if callback != nil {
+			callbacks = append(callbacks, callback)
+		}
 	}
 
 	if err := codec.Write(responses); err != nil {
@@ -399,7 +516,7 @@ func (s *Server) readRequest(codec ServerCodec) ([]*serverRequest, bool, Error)
 			continue
 		}
 
-		if r.isPubSub && r.method == unsubscribeMethod {
+		if r.isPubSub && strings.HasSuffix(r.method, unsubscribeMethodSuffix) {
 			requests[i] = &serverRequest{id: r.id, isUnsubscribe: true}
 			argTypes := []reflect.Type{reflect.TypeOf("")} // expect subscription id as first arg
 			if args, err := codec.ParseRequestArguments(argTypes, r.params); err == nil {
@@ -428,7 +545,7 @@ func (s *Server) readRequest(codec ServerCodec) ([]*serverRequest, bool, Error)
 					}
 				}
 			} else {
-				requests[i] = &serverRequest{id: r.id, err: &methodNotFoundError{subscribeMethod, r.method}}
+				requests[i] = &serverRequest{id: r.id, err: &methodNotFoundError{r.service + subscribeMethodSuffix, r.method}}
 			}
 			continue
 		}
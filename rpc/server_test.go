@@ -161,3 +161,187 @@ func TestServerMethodExecution(t *testing.T) {
 func TestServerMethodWithCtx(t *testing.T) {
 	testServerMethodExecution(t, "echoWithCtx")
 }
+
+type chanAuditor chan *AuditRecord
+
+func (c chanAuditor) Audit(rec *AuditRecord) { c <- rec }
+
+func TestServerAudit(t *testing.T) {
+	server := NewServer()
+	if err := server.RegisterName("personal", new(Service)); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.RegisterName("test", new(Service)); err != nil {
+		t.Fatal(err)
+	}
+	records := make(chanAuditor, 2)
+	server.SetAuditor(records)
+
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	go server.ServeCodec(NewJSONCodec(serverConn), OptionMethodInvocation)
+
+	out := json.NewEncoder(clientConn)
+	in := json.NewDecoder(clientConn)
+	for _, method := range []string{"test_echo", "personal_echo"} {
+		request := map[string]interface{}{
+			"id":      1,
+			"method":  method,
+			"version": "2.0",
+			"params":  []interface{}{"secret", 1, &Args{"abcde"}},
+		}
+		if err := out.Encode(request); err != nil {
+			t.Fatal(err)
+		}
+		response := jsonSuccessResponse{Result: &Result{}}
+		if err := in.Decode(&response); err != nil {
+			t.Fatal(err)
+		}
+	}
+	select {
+	case rec := <-records:
+		if rec.Method != "personal_echo" {
+			t.Fatalf("unexpected audited method: %s", rec.Method)
+		}
+		if rec.Origin != "pipe" {
+			t.Errorf("origin mismatch: have %q, want %q", rec.Origin, "pipe")
+		}
+		if len(rec.Params) != 3 || rec.Params[0] != redacted || rec.Params[1] != 1 {
+			t.Errorf("unexpected audited params: %v", rec.Params)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no audit record produced")
+	}
+	select {
+	case rec := <-records:
+		t.Fatalf("unexpected audit record: %+v", rec)
+	default:
+	}
+}
+
+func TestServerRateLimit(t *testing.T) {
+	server := NewServer()
+	if err := server.RegisterName("test", new(Service)); err != nil {
+		t.Fatal(err)
+	}
+	server.SetRateLimiter(NewRateLimiter(0.001, 2))
+
+	client := DialInProc(server)
+	defer client.Close()
+
+	for i := 0; i < 3; i++ {
+		err := client.Call(nil, "test_noArgsRets")
+		if i < 2 && err != nil {
+			t.Fatalf("call %d: unexpected error: %v", i, err)
+		}
+		if i == 2 && (err == nil || err.Error() != (&rateLimitError{}).Error()) {
+			t.Fatalf("call %d: error mismatch: have %v, want %v", i, err, &rateLimitError{})
+		}
+	}
+}
+
+func TestServerBatchLimits(t *testing.T) {
+	server := NewServer()
+	if err := server.RegisterName("test", new(Service)); err != nil {
+		t.Fatal(err)
+	}
+	server.SetBatchLimits(3, 250)
+
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	go server.ServeCodec(NewJSONCodec(serverConn), OptionMethodInvocation)
+
+	out := json.NewEncoder(clientConn)
+	in := json.NewDecoder(clientConn)
+
+	batch := func(n int, str string) []map[string]interface{} {
+		reqs := make([]map[string]interface{}, n)
+		for i := range reqs {
+			reqs[i] = map[string]interface{}{"id": i, "method": "test_echo", "jsonrpc": "2.0", "params": []interface{}{str, i, &Args{"x"}}}
+		}
+		return reqs
+	}
+	// Oversized batches should be rejected as a whole
+	if err := out.Encode(batch(4, "a")); err != nil {
+		t.Fatal(err)
+	}
+	var rejection jsonErrResponse
+	if err := in.Decode(&rejection); err != nil {
+		t.Fatalf("failed to decode batch rejection: %v", err)
+	}
+	if rejection.Error.Code != (&invalidRequestError{}).ErrorCode() {
+		t.Errorf("batch rejection code mismatch: have %d, want %d", rejection.Error.Code, (&invalidRequestError{}).ErrorCode())
+	}
+	// Batches producing too large responses should have their tail errored out
+	if err := out.Encode(batch(3, "0123456789012345678901234567890123456789")); err != nil {
+		t.Fatal(err)
+	}
+	var responses []jsonErrResponse
+	if err := in.Decode(&responses); err != nil {
+		t.Fatalf("failed to decode batch responses: %v", err)
+	}
+	if len(responses) != 3 {
+		t.Fatalf("response count mismatch: have %d, want 3", len(responses))
+	}
+	for i, want := range []int{0, 0, (&responseTooLargeError{}).ErrorCode()} {
+		if responses[i].Error.Code != want {
+			t.Errorf("response %d: error code mismatch: have %d, want %d", i, responses[i].Error.Code, want)
+		}
+	}
+}
+
+func TestServerConcurrencyLimit(t *testing.T) {
+	server := NewServer()
+	if err := server.RegisterName("test", new(Service)); err != nil {
+		t.Fatal(err)
+	}
+	server.SetConcurrencyLimit(1)
+
+	client := DialInProc(server)
+	defer client.Close()
+
+	start := time.Now()
+	errc := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() { errc <- client.Call(nil, "test_sleep", 100*time.Millisecond) }()
+	}
+	for i := 0; i < 2; i++ {
+		if err := <-errc; err != nil {
+			t.Fatalf("call failed: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("calls executed concurrently: finished in %v", elapsed)
+	}
+}
+
+func TestServerWaitPending(t *testing.T) {
+	server := NewServer()
+	if err := server.RegisterName("test", new(Service)); err != nil {
+		t.Fatal(err)
+	}
+	client := DialInProc(server)
+	defer client.Close()
+
+	// An idle server has nothing to wait for
+	if err := server.WaitPending(context.Background()); err != nil {
+		t.Fatalf("idle server: %v", err)
+	}
+	errc := make(chan error, 1)
+	go func() { errc <- client.Call(nil, "test_sleep", 200*time.Millisecond) }()
+	time.Sleep(50 * time.Millisecond)
+
+	// A running request must block the wait until the deadline
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := server.WaitPending(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("wait with running request: have %v, want %v", err, context.DeadlineExceeded)
+	}
+	// Once the request finished, the wait must return
+	if err := server.WaitPending(context.Background()); err != nil {
+		t.Fatalf("wait for running request: %v", err)
+	}
+	if err := <-errc; err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+}
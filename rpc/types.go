@@ -79,6 +79,17 @@ type Server struct {
 	run      int32
 	codecsMu sync.Mutex
 	codecs   *set.Set
+
+	auditor Auditor      // optional recipient of sensitive call records
+	limiter *RateLimiter // optional limiter of the clients' call rates
+
+	batchItemLimit    int           // maximum number of requests in a batch (0 = unlimited)
+	responseSizeLimit int           // maximum encoded size of a (batch) response (0 = unlimited)
+	workers           chan struct{} // optional pool bounding the concurrently executed requests
+
+	pendingMu sync.Mutex      // protects pending and idle
+	pending   int             // number of requests currently executing
+	idle      []chan struct{} // closed once no more requests are executing
 }
 
 // rpcRequest represents a raw incoming RPC request
@@ -112,7 +123,7 @@ type ServerCodec interface {
 	// Assemble error response with extra information about the error through info
 	CreateErrorResponseWithInfo(id interface{}, err Error, info interface{}) interface{}
 	// Create notification response
-	CreateNotification(string, interface{}) interface{}
+	CreateNotification(id, namespace string, event interface{}) interface{}
 	// Write msg to client.
 	Write(interface{}) error
 	// Close underlying data stream
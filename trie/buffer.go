@@ -0,0 +1,264 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package trie
+
+import (
+	"sync"
+
+	"github.com/EarthDollar/go-earthdollar/common"
+	"github.com/EarthDollar/go-earthdollar/ethdb"
+)
+
+// BufferLeafCallback is a callback type invoked when a node buffer flush reaches
+// a leaf node. It returns the roots of the tries and the hashes of the raw data
+// entries (e.g. contract code) referenced by the leaf, which must be retained
+// alongside the trie itself.
+type BufferLeafCallback func(leaf []byte) (tries []common.Hash, raws []common.Hash)
+
+// NodeBuffer is a write cache in front of a persistent database, accumulating
+// the nodes of recently committed tries in memory instead of writing each one
+// to disk. Nodes are only persisted on an explicit Flush, which at the same time
+// garbage collects all the buffered nodes not referenced any more by the tries
+// that should be kept around.
+//
+// Only entries keyed by a hash are buffered, anything else (e.g. secure trie key
+// preimages) is written through to the database directly.
+type NodeBuffer struct {
+	diskdb ethdb.Database // Persistent database to flush the nodes into
+
+	nodes map[common.Hash][]byte // Nodes and raw entries not yet written to disk
+	size  int                    // Approximate memory used by the buffered entries
+	lock  sync.RWMutex
+}
+
+// NewNodeBuffer creates a node buffer in front of a persistent database.
+func NewNodeBuffer(diskdb ethdb.Database) *NodeBuffer {
+	return &NodeBuffer{
+		diskdb: diskdb,
+		nodes:  make(map[common.Hash][]byte),
+	}
+}
+
+// Get retrieves an entry from the buffer, falling back to the database if it
+// was not found in memory.
+func (b *NodeBuffer) Get(key []byte) ([]byte, error) {
+	if len(key) == common.HashLength {
+		b.lock.RLock()
+		blob, ok := b.nodes[common.BytesToHash(key)]
+		b.lock.RUnlock()
+
+		if ok {
+			return blob, nil
+		}
+	}
+	return b.diskdb.Get(key)
+}
+
+// Put inserts an entry into the buffer if it's keyed by a hash, or writes it to
+// the database otherwise.
+func (b *NodeBuffer) Put(key []byte, value []byte) error {
+	if len(key) != common.HashLength {
+		return b.diskdb.Put(key, value)
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.insert(common.BytesToHash(key), value)
+	return nil
+}
+
+// insert adds a copy of the value into the buffer. The lock must be held.
+func (b *NodeBuffer) insert(hash common.Hash, value []byte) {
+	if _, ok := b.nodes[hash]; ok {
+		return
+	}
+	b.nodes[hash] = common.CopyBytes(value)
+	b.size += common.HashLength + len(value)
+}
+
+// Delete removes an entry both from the buffer and the database.
+func (b *NodeBuffer) Delete(key []byte) error {
+	if len(key) == common.HashLength {
+		b.lock.Lock()
+		if blob, ok := b.nodes[common.BytesToHash(key)]; ok {
+			delete(b.nodes, common.BytesToHash(key))
+			b.size -= common.HashLength + len(blob)
+		}
+		b.lock.Unlock()
+	}
+	return b.diskdb.Delete(key)
+}
+
+// Close is a noop, the lifecycle of the underlying database is managed by its
+// creator.
+func (b *NodeBuffer) Close() {}
+
+// NewBatch creates a write batch inserting into the buffer.
+func (b *NodeBuffer) NewBatch() ethdb.Batch {
+	return &bufferBatch{buffer: b}
+}
+
+// Size returns the approximate memory used by the buffered entries and their
+// number.
+func (b *NodeBuffer) Size() (int, int) {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+
+	return b.size, len(b.nodes)
+}
+
+// Flush writes the trie rooted at root into the database, along with all the
+// subtries and raw entries referenced from its leaves as reported by callback.
+// Afterwards every buffered entry not reachable from any of the retained roots
+// is dropped from memory. The number of entries written and dropped is returned.
+func (b *NodeBuffer) Flush(root common.Hash, retain []common.Hash, callback BufferLeafCallback) (int, int, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	// Persist all the buffered nodes of the requested trie
+	batch := b.diskdb.NewBatch()
+	flushed := b.reachable([]common.Hash{root}, callback)
+	for hash := range flushed {
+		if err := batch.Put(hash[:], b.nodes[hash]); err != nil {
+			return 0, 0, err
+		}
+	}
+	if err := batch.Write(); err != nil {
+		return 0, 0, err
+	}
+	for hash := range flushed {
+		b.size -= common.HashLength + len(b.nodes[hash])
+		delete(b.nodes, hash)
+	}
+	// Garbage collect everything not referenced by the retained tries
+	live := b.reachable(retain, callback)
+	dropped := len(b.nodes) - len(live)
+
+	nodes := make(map[common.Hash][]byte, len(live))
+	b.size = 0
+	for hash := range live {
+		nodes[hash] = b.nodes[hash]
+		b.size += common.HashLength + len(nodes[hash])
+	}
+	b.nodes = nodes
+
+	return len(flushed), dropped, nil
+}
+
+// reachable collects the hashes of all the buffered entries reachable from the
+// given trie roots. The walk stops at entries already persisted in the database,
+// since everything below them must be on disk too. The lock must be held.
+func (b *NodeBuffer) reachable(roots []common.Hash, callback BufferLeafCallback) map[common.Hash]struct{} {
+	type task struct {
+		hash     common.Hash
+		raw      bool
+		callback BufferLeafCallback
+	}
+	var (
+		seen  = make(map[common.Hash]struct{})
+		tasks []task
+	)
+	for _, root := range roots {
+		tasks = append(tasks, task{hash: root, callback: callback})
+	}
+	for len(tasks) > 0 {
+		t := tasks[len(tasks)-1]
+		tasks = tasks[:len(tasks)-1]
+
+		if _, ok := seen[t.hash]; ok {
+			continue
+		}
+		blob, ok := b.nodes[t.hash]
+		if !ok {
+			continue
+		}
+		seen[t.hash] = struct{}{}
+		if t.raw {
+			continue
+		}
+		n, err := decodeNode(t.hash[:], blob, 0)
+		if err != nil {
+			continue
+		}
+		forEachRef(n, func(child hashNode) {
+			tasks = append(tasks, task{hash: common.BytesToHash(child), callback: t.callback})
+		}, func(leaf valueNode) {
+			if t.callback == nil {
+				return
+			}
+			tries, raws := t.callback(leaf)
+			for _, root := range tries {
+				tasks = append(tasks, task{hash: root})
+			}
+			for _, hash := range raws {
+				tasks = append(tasks, task{hash: hash, raw: true})
+			}
+		})
+	}
+	return seen
+}
+
+// forEachRef invokes the callbacks for the child hashes and the leaf values of a
+// decoded node, descending into any nodes embedded into their parents.
+func forEachRef(n node, onChild func(hashNode), onLeaf func(valueNode)) {
+	switch n := n.(type) {
+	case *shortNode:
+		forEachRef(n.Val, onChild, onLeaf)
+	case *fullNode:
+		for _, child := range n.Children {
+			if child != nil {
+				forEachRef(child, onChild, onLeaf)
+			}
+		}
+	case hashNode:
+		onChild(n)
+	case valueNode:
+		onLeaf(n)
+	}
+}
+
+// bufferBatch is a write batch accumulating entries to insert into a node buffer
+// in one go.
+type bufferBatch struct {
+	buffer *NodeBuffer
+	keys   [][]byte
+	values [][]byte
+}
+
+// Put schedules an entry for insertion into the buffer.
+func (b *bufferBatch) Put(key, value []byte) error {
+	b.keys = append(b.keys, common.CopyBytes(key))
+	b.values = append(b.values, common.CopyBytes(value))
+	return nil
+}
+
+// Write inserts all the accumulated entries into the buffer.
+func (b *bufferBatch) Write() error {
+	b.buffer.lock.Lock()
+	defer b.buffer.lock.Unlock()
+
+	for i, key := range b.keys {
+		if len(key) != common.HashLength {
+			if err := b.buffer.diskdb.Put(key, b.values[i]); err != nil {
+				return err
+			}
+			continue
+		}
+		b.buffer.insert(common.BytesToHash(key), b.values[i])
+	}
+	return nil
+}
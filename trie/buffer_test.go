@@ -0,0 +1,157 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package trie
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/EarthDollar/go-earthdollar/common"
+	"github.com/EarthDollar/go-earthdollar/crypto"
+	"github.com/EarthDollar/go-earthdollar/ethdb"
+)
+
+// makeBufferedTries creates a two level trie structure in a node buffer, where
+// the leaves of the top trie are the roots of subtries, each subtrie also being
+// accompanied by a raw data blob. The top trie is committed revision times, each
+// time updating a different subtrie. The root hashes of all the revisions are
+// returned.
+func makeBufferedTries(t *testing.T, buffer *NodeBuffer, revisions int) []common.Hash {
+	top, _ := New(common.Hash{}, buffer)
+
+	var roots []common.Hash
+	for i := 0; i < revisions; i++ {
+		for j := 0; j < 4; j++ {
+			sub, _ := New(common.Hash{}, buffer)
+			for k := 0; k < 32; k++ {
+				sub.Update([]byte(fmt.Sprintf("key-%d-%d-%d", i, j, k)), bytes.Repeat([]byte{byte(k)}, 40))
+			}
+			subroot, err := sub.CommitTo(buffer)
+			if err != nil {
+				t.Fatalf("failed to commit subtrie: %v", err)
+			}
+			blob := []byte(fmt.Sprintf("blob-%x", subroot))
+			buffer.Put(crypto.Keccak256(blob), blob)
+
+			top.Update([]byte(fmt.Sprintf("sub-%d", j+4*(i%2))), subroot[:])
+		}
+		root, err := top.CommitTo(buffer)
+		if err != nil {
+			t.Fatalf("failed to commit top trie: %v", err)
+		}
+		roots = append(roots, root)
+	}
+	return roots
+}
+
+// bufferCallback resolves the subtries and raw blobs referenced by the leaves
+// of the tries created by makeBufferedTries.
+func bufferCallback(leaf []byte) ([]common.Hash, []common.Hash) {
+	return []common.Hash{common.BytesToHash(leaf)}, []common.Hash{crypto.Keccak256Hash([]byte(fmt.Sprintf("blob-%x", leaf)))}
+}
+
+// checkFlushedTrie verifies that the complete two level trie structure rooted
+// at root is available in the database.
+func checkFlushedTrie(t *testing.T, db Database, root common.Hash) {
+	top, err := New(root, db)
+	if err != nil {
+		t.Fatalf("failed to open top trie %x: %v", root, err)
+	}
+	it := NewIterator(top)
+	leaves := 0
+	for it.Next() {
+		leaves++
+		sub, err := New(common.BytesToHash(it.Value), db)
+		if err != nil {
+			t.Fatalf("failed to open subtrie %x: %v", it.Value, err)
+		}
+		subit := NewIterator(sub)
+		for subit.Next() {
+		}
+		if subit.nodeIt.Error != nil {
+			t.Fatalf("failed to iterate subtrie %x: %v", it.Value, subit.nodeIt.Error)
+		}
+		if _, err := db.Get(crypto.Keccak256([]byte(fmt.Sprintf("blob-%x", it.Value)))); err != nil {
+			t.Fatalf("raw blob of subtrie %x missing", it.Value)
+		}
+	}
+	if it.nodeIt.Error != nil {
+		t.Fatalf("failed to iterate top trie: %v", it.nodeIt.Error)
+	}
+	if leaves == 0 {
+		t.Fatalf("no leaves in trie %x", root)
+	}
+}
+
+// Tests that committing into a node buffer keeps the trie nodes in memory, and
+// that flushing writes out the complete requested trie structure.
+func TestNodeBufferFlush(t *testing.T) {
+	diskdb, _ := ethdb.NewMemDatabase()
+	buffer := NewNodeBuffer(diskdb)
+
+	roots := makeBufferedTries(t, buffer, 1)
+	if keys := len(diskdb.Keys()); keys != 0 {
+		t.Fatalf("buffered nodes leaked to disk: %d entries", keys)
+	}
+	checkFlushedTrie(t, buffer, roots[0])
+
+	if _, _, err := buffer.Flush(roots[0], nil, bufferCallback); err != nil {
+		t.Fatalf("failed to flush buffer: %v", err)
+	}
+	if size, nodes := buffer.Size(); size != 0 || nodes != 0 {
+		t.Fatalf("buffer not empty after flush: %d bytes, %d entries", size, nodes)
+	}
+	checkFlushedTrie(t, diskdb, roots[0])
+}
+
+// Tests that flushing garbage collects the buffered nodes of unflushed tries,
+// unless they are explicitly retained.
+func TestNodeBufferGarbageCollection(t *testing.T) {
+	diskdb, _ := ethdb.NewMemDatabase()
+	buffer := NewNodeBuffer(diskdb)
+	roots := makeBufferedTries(t, buffer, 4)
+
+	// Flush the last revision, retaining the one before it
+	written, dropped, err := buffer.Flush(roots[3], roots[2:3], bufferCallback)
+	if err != nil {
+		t.Fatalf("failed to flush buffer: %v", err)
+	}
+	if written == 0 || dropped == 0 {
+		t.Fatalf("flush stats mismatch: %d written, %d dropped", written, dropped)
+	}
+	checkFlushedTrie(t, diskdb, roots[3])
+	checkFlushedTrie(t, buffer, roots[2])
+
+	// The retained revision must not have been written to disk, the older ones
+	// must have been dropped altogether
+	if _, err := New(roots[2], diskdb); err == nil {
+		t.Fatalf("retained trie %x leaked to disk", roots[2])
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := New(roots[i], buffer); err == nil {
+			t.Fatalf("unreferenced trie %x not garbage collected", roots[i])
+		}
+	}
+	// Flushing again without retention should drop the remaining buffered state
+	if _, _, err := buffer.Flush(roots[3], nil, bufferCallback); err != nil {
+		t.Fatalf("failed to flush buffer: %v", err)
+	}
+	if size, nodes := buffer.Size(); size != 0 || nodes != 0 {
+		t.Fatalf("buffer not empty after flush: %d bytes, %d entries", size, nodes)
+	}
+}
@@ -52,6 +52,13 @@ func (it *Iterator) Next() bool {
 	return false
 }
 
+// Error returns the failure encountered while traversing the trie, if any (e.g.
+// a missing node). A failed iteration is indistinguishable from a finished one
+// via Next alone.
+func (it *Iterator) Error() error {
+	return it.nodeIt.Error
+}
+
 func (it *Iterator) makeKey() []byte {
 	key := it.keyBuf[:0]
 	for _, se := range it.nodeIt.stack {
@@ -0,0 +1,172 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package mailserver implements a whisper mail server, archiving the envelopes
+// relayed by the node and delivering them on request to the clients that were
+// offline when they were sent.
+package mailserver
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/EarthDollar/go-earthdollar/common"
+	"github.com/EarthDollar/go-earthdollar/ethdb"
+	"github.com/EarthDollar/go-earthdollar/logger"
+	"github.com/EarthDollar/go-earthdollar/logger/glog"
+	"github.com/EarthDollar/go-earthdollar/p2p"
+	"github.com/EarthDollar/go-earthdollar/rlp"
+	"github.com/EarthDollar/go-earthdollar/rpc"
+	whisper "github.com/EarthDollar/go-earthdollar/whisper/whisperv5"
+)
+
+// keyName is the name the symmetric key shared with the clients is stored under
+// in the whisper key store.
+const keyName = "mailserver"
+
+// archiveKeyLength is the size of the database key of an archived envelope:
+// the time it was sent followed by its hash, so that envelopes sent within a
+// time frame are stored contiguously.
+const archiveKeyLength = 4 + common.HashLength
+
+// WMailServer archives the envelopes seen by a whisper node into a database and
+// delivers them to the peers requesting them with the shared symmetric key.
+type WMailServer struct {
+	db  *ethdb.LDBDatabase
+	w   *whisper.Whisper
+	pow float64
+	key []byte
+}
+
+// New creates a mail server on top of the whisper node, archiving envelopes into
+// the given database. Requests need to be encrypted with the symmetric key
+// derived from the password and carry at least the given PoW. The server has to
+// be registered with the whisper node before it is started.
+func New(shh *whisper.Whisper, db *ethdb.LDBDatabase, password string, pow float64) (*WMailServer, error) {
+	if len(password) == 0 {
+		return nil, errors.New("mail server password not specified")
+	}
+	if err := shh.AddSymKey(keyName, []byte(password)); err != nil {
+		return nil, fmt.Errorf("failed to derive mail server key: %v", err)
+	}
+	return &WMailServer{
+		db:  db,
+		w:   shh,
+		pow: pow,
+		key: shh.GetSymKey(keyName),
+	}, nil
+}
+
+// Protocols implements node.Service, the mail server runs on top of the whisper
+// protocol.
+func (s *WMailServer) Protocols() []p2p.Protocol { return nil }
+
+// APIs implements node.Service, the mail server must be queried through the
+// whisper API of the clients.
+func (s *WMailServer) APIs() []rpc.API { return nil }
+
+// Start implements node.Service.
+func (s *WMailServer) Start(*p2p.Server) error { return nil }
+
+// Stop implements node.Service, closing the archive.
+func (s *WMailServer) Stop() error {
+	s.db.Close()
+	return nil
+}
+
+// archiveKey returns the database key of an envelope sent at the given time.
+func archiveKey(sent uint32, hash common.Hash) []byte {
+	key := make([]byte, archiveKeyLength)
+	binary.BigEndian.PutUint32(key, sent)
+	copy(key[4:], hash[:])
+	return key
+}
+
+// Archive implements whisper.MailServer, storing an envelope in the database.
+func (s *WMailServer) Archive(env *whisper.Envelope) {
+	blob, err := rlp.EncodeToBytes(env)
+	if err != nil {
+		glog.V(logger.Error).Infof("failed to encode envelope [%x]: %v", env.Hash(), err)
+		return
+	}
+	if err := s.db.Put(archiveKey(env.Expiry-env.TTL, env.Hash()), blob); err != nil {
+		glog.V(logger.Error).Infof("failed to archive envelope [%x]: %v", env.Hash(), err)
+	}
+}
+
+// DeliverMail implements whisper.MailServer, validating a request and sending
+// the matching archived envelopes to the peer.
+func (s *WMailServer) DeliverMail(peer *whisper.Peer, data []byte) {
+	req, err := s.validateRequest(data)
+	if err != nil {
+		glog.V(logger.Debug).Infof("invalid mail request: %v", err)
+		return
+	}
+	envelopes, err := s.query(req)
+	if err != nil {
+		glog.V(logger.Error).Infof("failed to query mail archive: %v", err)
+		return
+	}
+	for _, env := range envelopes {
+		if err := s.w.SendP2PDirect(peer, env); err != nil {
+			glog.V(logger.Debug).Infof("failed to deliver mail: %v", err)
+			return
+		}
+	}
+	glog.V(logger.Detail).Infof("delivered %d archived envelopes", len(envelopes))
+}
+
+// validateRequest decodes and decrypts a mail request envelope, checking its PoW.
+func (s *WMailServer) validateRequest(data []byte) (*whisper.MailRequest, error) {
+	env := new(whisper.Envelope)
+	if err := rlp.DecodeBytes(data, env); err != nil {
+		return nil, err
+	}
+	if env.PoW() < s.pow {
+		return nil, fmt.Errorf("insufficient PoW %f, want %f", env.PoW(), s.pow)
+	}
+	msg := env.Open(&whisper.Filter{KeySym: s.key})
+	if msg == nil {
+		return nil, errors.New("failed to decrypt request")
+	}
+	return whisper.DecodeMailRequest(msg.Payload)
+}
+
+// query retrieves the archived envelopes matching a request, oldest first.
+func (s *WMailServer) query(req *whisper.MailRequest) ([]*whisper.Envelope, error) {
+	it := s.db.NewIterator()
+	defer it.Release()
+
+	var envelopes []*whisper.Envelope
+	for ok := it.Seek(archiveKey(req.Lower, common.Hash{})); ok; ok = it.Next() {
+		if len(it.Key()) != archiveKeyLength {
+			continue
+		}
+		if binary.BigEndian.Uint32(it.Key()) > req.Upper {
+			break
+		}
+		env := new(whisper.Envelope)
+		if err := rlp.DecodeBytes(it.Value(), env); err != nil {
+			glog.V(logger.Error).Infof("failed to decode archived envelope %x: %v", it.Key(), err)
+			continue
+		}
+		if req.Topic == (whisper.TopicType{}) || env.Topic == req.Topic {
+			envelopes = append(envelopes, env)
+		}
+	}
+	return envelopes, it.Error()
+}
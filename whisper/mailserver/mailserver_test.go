@@ -0,0 +1,139 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package mailserver
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/EarthDollar/go-earthdollar/ethdb"
+	"github.com/EarthDollar/go-earthdollar/rlp"
+	whisper "github.com/EarthDollar/go-earthdollar/whisper/whisperv5"
+)
+
+func newTestServer(t *testing.T) (*WMailServer, func()) {
+	dir, err := ioutil.TempDir("", "mailserver-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	db, err := ethdb.NewLDBDatabase(dir, 0, 0)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatalf("failed to open database: %v", err)
+	}
+	server, err := New(whisper.New(nil), db, "mail server password", 0)
+	if err != nil {
+		db.Close()
+		os.RemoveAll(dir)
+		t.Fatalf("failed to create mail server: %v", err)
+	}
+	return server, func() {
+		server.Stop()
+		os.RemoveAll(dir)
+	}
+}
+
+func sealEnvelope(t *testing.T, key []byte, topic whisper.TopicType, payload []byte) *whisper.Envelope {
+	params := &whisper.MessageParams{
+		KeySym:   key,
+		Topic:    topic,
+		Payload:  payload,
+		PoW:      0.01,
+		WorkTime: 1,
+	}
+	env, err := whisper.NewSentMessage(params).Wrap(params)
+	if err != nil {
+		t.Fatalf("failed to wrap message: %v", err)
+	}
+	return env
+}
+
+// Tests that archived envelopes are retrieved by time frame and topic.
+func TestArchiveQuery(t *testing.T) {
+	server, cleanup := newTestServer(t)
+	defer cleanup()
+
+	var (
+		key    = []byte("0123456789abcdef0123456789abcdef")
+		topicA = whisper.TopicType{0x01, 0x02, 0x03, 0x04}
+		topicB = whisper.TopicType{0x05, 0x06, 0x07, 0x08}
+		sent   = []uint32{100, 200, 300}
+		topics = []whisper.TopicType{topicA, topicB, topicA}
+	)
+	for i := range sent {
+		env := sealEnvelope(t, key, topics[i], []byte{byte(i)})
+		env.Expiry = sent[i] + env.TTL
+		server.Archive(env)
+	}
+	tests := []struct {
+		req  whisper.MailRequest
+		want []uint32
+	}{
+		{whisper.MailRequest{Lower: 0, Upper: 1000}, []uint32{100, 200, 300}},
+		{whisper.MailRequest{Lower: 150, Upper: 300}, []uint32{200, 300}},
+		{whisper.MailRequest{Lower: 100, Upper: 100}, []uint32{100}},
+		{whisper.MailRequest{Lower: 0, Upper: 1000, Topic: topicA}, []uint32{100, 300}},
+		{whisper.MailRequest{Lower: 150, Upper: 1000, Topic: topicB}, []uint32{200}},
+		{whisper.MailRequest{Lower: 301, Upper: 1000}, nil},
+	}
+	for i, tt := range tests {
+		envelopes, err := server.query(&tt.req)
+		if err != nil {
+			t.Fatalf("test %d: query failed: %v", i, err)
+		}
+		if len(envelopes) != len(tt.want) {
+			t.Fatalf("test %d: envelope count mismatch: have %d, want %d", i, len(envelopes), len(tt.want))
+		}
+		for j, env := range envelopes {
+			if env.Expiry-env.TTL != tt.want[j] {
+				t.Errorf("test %d, envelope %d: sent time mismatch: have %d, want %d", i, j, env.Expiry-env.TTL, tt.want[j])
+			}
+		}
+	}
+}
+
+// Tests that only requests encrypted with the shared key are accepted.
+func TestRequestValidation(t *testing.T) {
+	server, cleanup := newTestServer(t)
+	defer cleanup()
+
+	req := &whisper.MailRequest{Lower: 10, Upper: 20, Topic: whisper.TopicType{0x01, 0x02, 0x03, 0x04}}
+	encode := func(key []byte, payload []byte) []byte {
+		blob, err := rlp.EncodeToBytes(sealEnvelope(t, key, req.Topic, payload))
+		if err != nil {
+			t.Fatalf("failed to encode request: %v", err)
+		}
+		return blob
+	}
+	have, err := server.validateRequest(encode(server.key, req.Bytes()))
+	if err != nil {
+		t.Fatalf("valid request rejected: %v", err)
+	}
+	if *have != *req {
+		t.Errorf("request mismatch: have %+v, want %+v", have, req)
+	}
+	if _, err := server.validateRequest(encode([]byte("0123456789abcdef0123456789abcdef"), req.Bytes())); err == nil {
+		t.Errorf("request with foreign key accepted")
+	}
+	if _, err := server.validateRequest(encode(server.key, req.Bytes()[:4])); err == nil {
+		t.Errorf("undersized request accepted")
+	}
+	if _, err := server.validateRequest([]byte{0x01, 0x02}); err == nil {
+		t.Errorf("garbage request accepted")
+	}
+}
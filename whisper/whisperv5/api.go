@@ -14,13 +14,14 @@
 // You should have received a copy of the GNU Lesser General Public License
 // along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
 
-package shhapi
+package whisperv5
 
 import (
 	"encoding/json"
 	"errors"
 	"fmt"
 	mathrand "math/rand"
+	"time"
 
 	"github.com/EarthDollar/go-earthdollar/common"
 	"github.com/EarthDollar/go-earthdollar/common/hexutil"
@@ -28,56 +29,44 @@ import (
 	"github.com/EarthDollar/go-earthdollar/logger"
 	"github.com/EarthDollar/go-earthdollar/logger/glog"
 	"github.com/EarthDollar/go-earthdollar/rpc"
-	"github.com/EarthDollar/go-earthdollar/whisper/whisperv5"
+	"golang.org/x/net/context"
 )
 
 var whisperOffLineErr = errors.New("whisper is offline")
 
 // PublicWhisperAPI provides the whisper RPC service.
 type PublicWhisperAPI struct {
-	whisper *whisperv5.Whisper
+	whisper *Whisper
 }
 
 // NewPublicWhisperAPI create a new RPC whisper service.
-func NewPublicWhisperAPI() *PublicWhisperAPI {
-	w := whisperv5.NewWhisper(nil)
+func NewPublicWhisperAPI(w *Whisper) *PublicWhisperAPI {
 	return &PublicWhisperAPI{whisper: w}
 }
 
-// APIs returns the RPC descriptors the Whisper implementation offers
-func APIs() []rpc.API {
-	return []rpc.API{
-		{
-			Namespace: whisperv5.ProtocolName,
-			Version:   whisperv5.ProtocolVersionStr,
-			Service:   NewPublicWhisperAPI(),
-			Public:    true,
-		},
-	}
-}
-
-// Start starts the Whisper worker threads.
-func (api *PublicWhisperAPI) Start() error {
+// Version returns the Whisper version this node offers.
+func (api *PublicWhisperAPI) Version() (hexutil.Uint, error) {
 	if api.whisper == nil {
-		return whisperOffLineErr
+		return 0, whisperOffLineErr
 	}
-	return api.whisper.Start(nil)
+	return hexutil.Uint(api.whisper.Version()), nil
 }
 
-// Stop stops the Whisper worker threads.
-func (api *PublicWhisperAPI) Stop() error {
+// SetMaxMessageSize sets the maximum size of messages accepted by the node.
+func (api *PublicWhisperAPI) SetMaxMessageSize(size uint32) error {
 	if api.whisper == nil {
 		return whisperOffLineErr
 	}
-	return api.whisper.Stop()
+	return api.whisper.SetMaxMessageSize(size)
 }
 
-// Version returns the Whisper version this node offers.
-func (api *PublicWhisperAPI) Version() (hexutil.Uint, error) {
+// SetMinimumPoW sets the minimum proof of work required from incoming
+// messages, announcing the new requirement to all connected peers.
+func (api *PublicWhisperAPI) SetMinimumPoW(pow float64) error {
 	if api.whisper == nil {
-		return 0, whisperOffLineErr
+		return whisperOffLineErr
 	}
-	return hexutil.Uint(api.whisper.Version()), nil
+	return api.whisper.SetMinimumPoW(pow)
 }
 
 // MarkPeerTrusted marks specific peer trusted, which will allow it
@@ -100,6 +89,41 @@ func (api *PublicWhisperAPI) RequestHistoricMessages(peerID hexutil.Bytes, data
 	return api.whisper.RequestHistoricMessages(peerID, data)
 }
 
+// RequestMail asks a mail server peer to deliver the envelopes it archived
+// within the time frame of the request, encrypting the request with the named
+// symmetric key shared with the server. The envelopes are delivered to the
+// filters accepting peer-to-peer messages.
+func (api *PublicWhisperAPI) RequestMail(args MailRequestArgs) error {
+	if api.whisper == nil {
+		return whisperOffLineErr
+	}
+	key := api.whisper.GetSymKey(args.KeyName)
+	if len(key) == 0 {
+		return errors.New("RequestMail: key was not found by name: " + args.KeyName)
+	}
+	upper := args.Upper
+	if upper == 0 {
+		upper = uint32(time.Now().Unix())
+	}
+	if args.Lower > upper {
+		return fmt.Errorf("RequestMail: invalid time frame [%d, %d]", args.Lower, upper)
+	}
+	req := &MailRequest{Lower: args.Lower, Upper: upper, Topic: args.Topic}
+	return api.whisper.RequestMail(args.PeerID, key, req, args.PoW, args.WorkTime)
+}
+
+// MailRequestArgs are the parameters of a request for archived envelopes. An
+// unset upper bound stands for the current time.
+type MailRequestArgs struct {
+	PeerID   hexutil.Bytes `json:"peerID"`
+	KeyName  string        `json:"keyname"`
+	Lower    uint32        `json:"lower"`
+	Upper    uint32        `json:"upper"`
+	Topic    TopicType     `json:"topic"`
+	PoW      float64       `json:"pow"`
+	WorkTime uint32        `json:"worktime"`
+}
+
 // HasIdentity checks if the whisper node is configured with the private key
 // of the specified public pair.
 func (api *PublicWhisperAPI) HasIdentity(identity string) (bool, error) {
@@ -128,6 +152,34 @@ func (api *PublicWhisperAPI) NewIdentity() (string, error) {
 	return common.ToHex(crypto.FromECDSAPub(&identity.PublicKey)), nil
 }
 
+// AddPrivateKey imports the private key of an identity, returning its public
+// key to be used as the identity id.
+func (api *PublicWhisperAPI) AddPrivateKey(key hexutil.Bytes) (string, error) {
+	if api.whisper == nil {
+		return "", whisperOffLineErr
+	}
+	if len(key) != 32 {
+		return "", fmt.Errorf("AddPrivateKey: invalid private key length %d", len(key))
+	}
+	priv := crypto.ToECDSA(key)
+	if !validatePrivateKey(priv) {
+		return "", errors.New("AddPrivateKey: invalid private key")
+	}
+	return api.whisper.AddIdentity(priv), nil
+}
+
+// GetPrivateKey exports the private key of an identity.
+func (api *PublicWhisperAPI) GetPrivateKey(identity string) (hexutil.Bytes, error) {
+	if api.whisper == nil {
+		return nil, whisperOffLineErr
+	}
+	key := api.whisper.GetIdentity(identity)
+	if key == nil {
+		return nil, errors.New("GetPrivateKey: identity not found: " + identity)
+	}
+	return crypto.FromECDSA(key), nil
+}
+
 // GenerateSymKey generates a random symmetric key and stores it under
 // the 'name' id. Will be used in the future for session key exchange.
 func (api *PublicWhisperAPI) GenerateSymKey(name string) error {
@@ -137,8 +189,9 @@ func (api *PublicWhisperAPI) GenerateSymKey(name string) error {
 	return api.whisper.GenerateSymKey(name)
 }
 
-// AddSymKey stores the key under the 'name' id.
-func (api *PublicWhisperAPI) AddSymKey(name string, key []byte) error {
+// AddSymKey derives a symmetric key from the given key material (e.g. a
+// password) and stores it under the 'name' id.
+func (api *PublicWhisperAPI) AddSymKey(name string, key hexutil.Bytes) error {
 	if api.whisper == nil {
 		return whisperOffLineErr
 	}
@@ -155,6 +208,18 @@ func (api *PublicWhisperAPI) HasSymKey(name string) (bool, error) {
 	return res, nil
 }
 
+// GetSymKey returns the symmetric key associated with the name string.
+func (api *PublicWhisperAPI) GetSymKey(name string) (hexutil.Bytes, error) {
+	if api.whisper == nil {
+		return nil, whisperOffLineErr
+	}
+	key := api.whisper.GetSymKey(name)
+	if len(key) == 0 {
+		return nil, errors.New("GetSymKey: key was not found by name: " + name)
+	}
+	return key, nil
+}
+
 // DeleteSymKey deletes the key associated with the name string if it exists.
 func (api *PublicWhisperAPI) DeleteSymKey(name string) error {
 	if api.whisper == nil {
@@ -170,12 +235,61 @@ func (api *PublicWhisperAPI) NewFilter(args WhisperFilterArgs) (uint32, error) {
 	if api.whisper == nil {
 		return 0, whisperOffLineErr
 	}
+	filter, err := api.newFilter(args)
+	if err != nil {
+		return 0, err
+	}
+	return api.whisper.Watch(filter), nil
+}
+
+// Messages creates a subscription that fires for every inbound whisper message
+// matching the given filter criteria.
+func (api *PublicWhisperAPI) Messages(ctx context.Context, args WhisperFilterArgs) (*rpc.Subscription, error) {
+	if api.whisper == nil {
+		return &rpc.Subscription{}, whisperOffLineErr
+	}
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	filter, err := api.newFilter(args)
+	if err != nil {
+		return &rpc.Subscription{}, err
+	}
+	id := api.whisper.Watch(filter)
+
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		// The filters collect the matching messages, forward them periodically
+		ticker := time.NewTicker(subscriptionCycle)
+		defer ticker.Stop()
+		defer api.whisper.Unwatch(id)
+
+		for {
+			select {
+			case <-ticker.C:
+				for _, msg := range filter.Retrieve() {
+					notifier.Notify(rpcSub.ID, NewWhisperMessage(msg))
+				}
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
 
-	filter := whisperv5.Filter{
+// newFilter assembles a message filter from the RPC criteria.
+func (api *PublicWhisperAPI) newFilter(args WhisperFilterArgs) (*Filter, error) {
+	filter := Filter{
 		Src:       crypto.ToECDSAPub(common.FromHex(args.From)),
 		KeySym:    api.whisper.GetSymKey(args.KeyName),
 		PoW:       args.PoW,
-		Messages:  make(map[common.Hash]*whisperv5.ReceivedMessage),
+		Messages:  make(map[common.Hash]*ReceivedMessage),
 		AcceptP2P: args.AcceptP2P,
 	}
 	if len(filter.KeySym) > 0 {
@@ -186,52 +300,51 @@ func (api *PublicWhisperAPI) NewFilter(args WhisperFilterArgs) (uint32, error) {
 	if len(args.Topics) == 0 {
 		info := "NewFilter: at least one topic must be specified"
 		glog.V(logger.Error).Infof(info)
-		return 0, errors.New(info)
+		return nil, errors.New(info)
 	}
 
 	if len(args.KeyName) != 0 && len(filter.KeySym) == 0 {
 		info := "NewFilter: key was not found by name: " + args.KeyName
 		glog.V(logger.Error).Infof(info)
-		return 0, errors.New(info)
+		return nil, errors.New(info)
 	}
 
 	if len(args.To) == 0 && len(filter.KeySym) == 0 {
 		info := "NewFilter: filter must contain either symmetric or asymmetric key"
 		glog.V(logger.Error).Infof(info)
-		return 0, errors.New(info)
+		return nil, errors.New(info)
 	}
 
 	if len(args.To) != 0 && len(filter.KeySym) != 0 {
 		info := "NewFilter: filter must not contain both symmetric and asymmetric key"
 		glog.V(logger.Error).Infof(info)
-		return 0, errors.New(info)
+		return nil, errors.New(info)
 	}
 
 	if len(args.To) > 0 {
 		dst := crypto.ToECDSAPub(common.FromHex(args.To))
-		if !whisperv5.ValidatePublicKey(dst) {
+		if !ValidatePublicKey(dst) {
 			info := "NewFilter: Invalid 'To' address"
 			glog.V(logger.Error).Infof(info)
-			return 0, errors.New(info)
+			return nil, errors.New(info)
 		}
 		filter.KeyAsym = api.whisper.GetIdentity(string(args.To))
 		if filter.KeyAsym == nil {
 			info := "NewFilter: non-existent identity provided"
 			glog.V(logger.Error).Infof(info)
-			return 0, errors.New(info)
+			return nil, errors.New(info)
 		}
 	}
 
 	if len(args.From) > 0 {
-		if !whisperv5.ValidatePublicKey(filter.Src) {
+		if !ValidatePublicKey(filter.Src) {
 			info := "NewFilter: Invalid 'From' address"
 			glog.V(logger.Error).Infof(info)
-			return 0, errors.New(info)
+			return nil, errors.New(info)
 		}
 	}
 
-	id := api.whisper.Watch(&filter)
-	return id, nil
+	return &filter, nil
 }
 
 // UninstallFilter disables and removes an existing filter.
@@ -256,7 +369,7 @@ func (api *PublicWhisperAPI) GetMessages(filterId uint32) []WhisperMessage {
 }
 
 // toWhisperMessages converts a Whisper message to a RPC whisper message.
-func toWhisperMessages(messages []*whisperv5.ReceivedMessage) []WhisperMessage {
+func toWhisperMessages(messages []*ReceivedMessage) []WhisperMessage {
 	msgs := make([]WhisperMessage, len(messages))
 	for i, msg := range messages {
 		msgs[i] = NewWhisperMessage(msg)
@@ -270,7 +383,7 @@ func (api *PublicWhisperAPI) Post(args PostArgs) error {
 		return whisperOffLineErr
 	}
 
-	params := whisperv5.MessageParams{
+	params := MessageParams{
 		TTL:      args.TTL,
 		Dst:      crypto.ToECDSAPub(common.FromHex(args.To)),
 		KeySym:   api.whisper.GetSymKey(args.KeyName),
@@ -280,10 +393,11 @@ func (api *PublicWhisperAPI) Post(args PostArgs) error {
 		WorkTime: args.WorkTime,
 		PoW:      args.PoW,
 	}
+	api.whisper.lightParams(&params)
 
 	if len(args.From) > 0 {
 		pub := crypto.ToECDSAPub(common.FromHex(args.From))
-		if !whisperv5.ValidatePublicKey(pub) {
+		if !ValidatePublicKey(pub) {
 			info := "Post: Invalid 'From' address"
 			glog.V(logger.Error).Infof(info)
 			return errors.New(info)
@@ -311,7 +425,7 @@ func (api *PublicWhisperAPI) Post(args PostArgs) error {
 		if params.Src == nil && filter.Src != nil {
 			params.Src = filter.KeyAsym
 		}
-		if (params.Topic == whisperv5.TopicType{}) {
+		if (params.Topic == TopicType{}) {
 			sz := len(filter.Topics)
 			if sz < 1 {
 				info := fmt.Sprintf("Post: no topics in filter # %d", args.FilterID)
@@ -347,7 +461,7 @@ func (api *PublicWhisperAPI) Post(args PostArgs) error {
 	}
 
 	if len(args.To) > 0 {
-		if !whisperv5.ValidatePublicKey(params.Dst) {
+		if !ValidatePublicKey(params.Dst) {
 			info := "Post: Invalid 'To' address"
 			glog.V(logger.Error).Infof(info)
 			return errors.New(info)
@@ -355,18 +469,18 @@ func (api *PublicWhisperAPI) Post(args PostArgs) error {
 	}
 
 	// encrypt and send
-	message := whisperv5.NewSentMessage(&params)
+	message := NewSentMessage(&params)
 	envelope, err := message.Wrap(&params)
 	if err != nil {
 		glog.V(logger.Error).Infof(err.Error())
 		return err
 	}
-	if len(envelope.Data) > whisperv5.MaxMessageLength {
+	if uint32(len(envelope.Data)) > api.whisper.MaxMessageSize() {
 		info := "Post: message is too big"
 		glog.V(logger.Error).Infof(info)
 		return errors.New(info)
 	}
-	if (envelope.Topic == whisperv5.TopicType{} && envelope.IsSymmetric()) {
+	if (envelope.Topic == TopicType{} && envelope.IsSymmetric()) {
 		info := "Post: topic is missing for symmetric encryption"
 		glog.V(logger.Error).Infof(info)
 		return errors.New(info)
@@ -380,17 +494,17 @@ func (api *PublicWhisperAPI) Post(args PostArgs) error {
 }
 
 type PostArgs struct {
-	TTL      uint32              `json:"ttl"`
-	From     string              `json:"from"`
-	To       string              `json:"to"`
-	KeyName  string              `json:"keyname"`
-	Topic    whisperv5.TopicType `json:"topic"`
-	Padding  hexutil.Bytes       `json:"padding"`
-	Payload  hexutil.Bytes       `json:"payload"`
-	WorkTime uint32              `json:"worktime"`
-	PoW      float64             `json:"pow"`
-	FilterID uint32              `json:"filterID"`
-	PeerID   hexutil.Bytes       `json:"peerID"`
+	TTL      uint32        `json:"ttl"`
+	From     string        `json:"from"`
+	To       string        `json:"to"`
+	KeyName  string        `json:"keyname"`
+	Topic    TopicType     `json:"topic"`
+	Padding  hexutil.Bytes `json:"padding"`
+	Payload  hexutil.Bytes `json:"payload"`
+	WorkTime uint32        `json:"worktime"`
+	PoW      float64       `json:"pow"`
+	FilterID uint32        `json:"filterID"`
+	PeerID   hexutil.Bytes `json:"peerID"`
 }
 
 type WhisperFilterArgs struct {
@@ -398,7 +512,7 @@ type WhisperFilterArgs struct {
 	From      string
 	KeyName   string
 	PoW       float64
-	Topics    []whisperv5.TopicType
+	Topics    []TopicType
 	AcceptP2P bool
 }
 
@@ -437,13 +551,13 @@ func (args *WhisperFilterArgs) UnmarshalJSON(b []byte) (err error) {
 				return fmt.Errorf("topic[%d] is not a string", i)
 			}
 		}
-		topicsDecoded := make([]whisperv5.TopicType, len(topics))
+		topicsDecoded := make([]TopicType, len(topics))
 		for j, s := range topics {
 			x := common.FromHex(s)
-			if x == nil || len(x) != whisperv5.TopicLength {
+			if x == nil || len(x) != TopicLength {
 				return fmt.Errorf("topic[%d] is invalid", j)
 			}
-			topicsDecoded[j] = whisperv5.BytesToTopic(x)
+			topicsDecoded[j] = BytesToTopic(x)
 		}
 		args.Topics = topicsDecoded
 	}
@@ -464,7 +578,7 @@ type WhisperMessage struct {
 }
 
 // NewWhisperMessage converts an internal message into an API version.
-func NewWhisperMessage(message *whisperv5.ReceivedMessage) WhisperMessage {
+func NewWhisperMessage(message *ReceivedMessage) WhisperMessage {
 	return WhisperMessage{
 		Payload: common.ToHex(message.Payload),
 		Padding: common.ToHex(message.Padding),
@@ -14,7 +14,7 @@
 // You should have received a copy of the GNU Lesser General Public License
 // along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
 
-package shhapi
+package whisperv5
 
 import (
 	"bytes"
@@ -24,12 +24,14 @@ import (
 	"encoding/json"
 
 	"github.com/EarthDollar/go-earthdollar/common"
-	"github.com/EarthDollar/go-earthdollar/whisper/whisperv5"
+	"github.com/EarthDollar/go-earthdollar/crypto"
+	"github.com/EarthDollar/go-earthdollar/rpc"
+	"golang.org/x/net/context"
 )
 
 func TestBasic(t *testing.T) {
 	var id string = "test"
-	api := NewPublicWhisperAPI()
+	api := NewPublicWhisperAPI(New(nil))
 	if api == nil {
 		t.Fatalf("failed to create API.")
 	}
@@ -39,7 +41,7 @@ func TestBasic(t *testing.T) {
 		t.Fatalf("failed generateFilter: %s.", err)
 	}
 
-	if uint64(ver) != whisperv5.ProtocolVersion {
+	if uint64(ver) != ProtocolVersion {
 		t.Fatalf("wrong version: %d.", ver)
 	}
 
@@ -182,22 +184,22 @@ func TestUnmarshalFilterArgs(t *testing.T) {
 	}
 
 	i := 0
-	if f.Topics[i] != (whisperv5.TopicType{0x00, 0x00, 0x00, 0x00}) {
+	if f.Topics[i] != (TopicType{0x00, 0x00, 0x00, 0x00}) {
 		t.Fatalf("wrong topic[%d]: %x.", i, f.Topics[i])
 	}
 
 	i++
-	if f.Topics[i] != (whisperv5.TopicType{0x00, 0x7f, 0x80, 0xff}) {
+	if f.Topics[i] != (TopicType{0x00, 0x7f, 0x80, 0xff}) {
 		t.Fatalf("wrong topic[%d]: %x.", i, f.Topics[i])
 	}
 
 	i++
-	if f.Topics[i] != (whisperv5.TopicType{0xff, 0x80, 0x7f, 0x00}) {
+	if f.Topics[i] != (TopicType{0xff, 0x80, 0x7f, 0x00}) {
 		t.Fatalf("wrong topic[%d]: %x.", i, f.Topics[i])
 	}
 
 	i++
-	if f.Topics[i] != (whisperv5.TopicType{0xf2, 0x6e, 0x77, 0x79}) {
+	if f.Topics[i] != (TopicType{0xf2, 0x6e, 0x77, 0x79}) {
 		t.Fatalf("wrong topic[%d]: %x.", i, f.Topics[i])
 	}
 }
@@ -235,7 +237,7 @@ func TestUnmarshalPostArgs(t *testing.T) {
 	if a.KeyName != "shh_test" {
 		t.Fatalf("wrong KeyName: %s.", a.KeyName)
 	}
-	if a.Topic != (whisperv5.TopicType{0xf2, 0x6e, 0x77, 0x79}) {
+	if a.Topic != (TopicType{0xf2, 0x6e, 0x77, 0x79}) {
 		t.Fatalf("wrong topic: %x.", a.Topic)
 	}
 	if string(a.Padding) != "this is my test string" {
@@ -272,13 +274,13 @@ func waitForMessage(api *PublicWhisperAPI, id uint32, target int) bool {
 }
 
 func TestIntegrationAsym(t *testing.T) {
-	api := NewPublicWhisperAPI()
+	api := NewPublicWhisperAPI(New(nil))
 	if api == nil {
 		t.Fatalf("failed to create API.")
 	}
 
-	api.Start()
-	defer api.Stop()
+	api.whisper.Start(nil)
+	defer api.whisper.Stop()
 
 	sig, err := api.NewIdentity()
 	if err != nil {
@@ -304,14 +306,14 @@ func TestIntegrationAsym(t *testing.T) {
 		t.Fatalf("wrong key")
 	}
 
-	var topics [2]whisperv5.TopicType
-	topics[0] = whisperv5.TopicType{0x00, 0x64, 0x00, 0xff}
-	topics[1] = whisperv5.TopicType{0xf2, 0x6e, 0x77, 0x79}
+	var topics [2]TopicType
+	topics[0] = TopicType{0x00, 0x64, 0x00, 0xff}
+	topics[1] = TopicType{0xf2, 0x6e, 0x77, 0x79}
 	var f WhisperFilterArgs
 	f.To = key
 	f.From = sig
 	f.Topics = topics[:]
-	f.PoW = whisperv5.MinimumPoW / 2
+	f.PoW = DefaultMinimumPoW / 2
 	f.AcceptP2P = true
 
 	id, err := api.NewFilter(f)
@@ -325,8 +327,8 @@ func TestIntegrationAsym(t *testing.T) {
 	p.To = f.To
 	p.Padding = []byte("test string")
 	p.Payload = []byte("extended test string")
-	p.PoW = whisperv5.MinimumPoW
-	p.Topic = whisperv5.TopicType{0xf2, 0x6e, 0x77, 0x79}
+	p.PoW = DefaultMinimumPoW
+	p.Topic = TopicType{0xf2, 0x6e, 0x77, 0x79}
 	p.WorkTime = 2
 
 	err = api.Post(p)
@@ -373,13 +375,13 @@ func TestIntegrationAsym(t *testing.T) {
 }
 
 func TestIntegrationSym(t *testing.T) {
-	api := NewPublicWhisperAPI()
+	api := NewPublicWhisperAPI(New(nil))
 	if api == nil {
 		t.Fatalf("failed to create API.")
 	}
 
-	api.Start()
-	defer api.Stop()
+	api.whisper.Start(nil)
+	defer api.whisper.Stop()
 
 	keyname := "schluessel"
 	err := api.GenerateSymKey(keyname)
@@ -403,9 +405,9 @@ func TestIntegrationSym(t *testing.T) {
 		t.Fatalf("failed HasIdentity: false negative.")
 	}
 
-	var topics [2]whisperv5.TopicType
-	topics[0] = whisperv5.TopicType{0x00, 0x7f, 0x80, 0xff}
-	topics[1] = whisperv5.TopicType{0xf2, 0x6e, 0x77, 0x79}
+	var topics [2]TopicType
+	topics[0] = TopicType{0x00, 0x7f, 0x80, 0xff}
+	topics[1] = TopicType{0xf2, 0x6e, 0x77, 0x79}
 	var f WhisperFilterArgs
 	f.KeyName = keyname
 	f.Topics = topics[:]
@@ -424,8 +426,8 @@ func TestIntegrationSym(t *testing.T) {
 	p.From = f.From
 	p.Padding = []byte("test string")
 	p.Payload = []byte("extended test string")
-	p.PoW = whisperv5.MinimumPoW
-	p.Topic = whisperv5.TopicType{0xf2, 0x6e, 0x77, 0x79}
+	p.PoW = DefaultMinimumPoW
+	p.Topic = TopicType{0xf2, 0x6e, 0x77, 0x79}
 	p.WorkTime = 2
 
 	err = api.Post(p)
@@ -472,13 +474,13 @@ func TestIntegrationSym(t *testing.T) {
 }
 
 func TestIntegrationSymWithFilter(t *testing.T) {
-	api := NewPublicWhisperAPI()
+	api := NewPublicWhisperAPI(New(nil))
 	if api == nil {
 		t.Fatalf("failed to create API.")
 	}
 
-	api.Start()
-	defer api.Stop()
+	api.whisper.Start(nil)
+	defer api.whisper.Stop()
 
 	keyname := "schluessel"
 	err := api.GenerateSymKey(keyname)
@@ -502,9 +504,9 @@ func TestIntegrationSymWithFilter(t *testing.T) {
 		t.Fatalf("failed HasIdentity: does not exist.")
 	}
 
-	var topics [2]whisperv5.TopicType
-	topics[0] = whisperv5.TopicType{0x00, 0x7f, 0x80, 0xff}
-	topics[1] = whisperv5.TopicType{0xf2, 0x6e, 0x77, 0x79}
+	var topics [2]TopicType
+	topics[0] = TopicType{0x00, 0x7f, 0x80, 0xff}
+	topics[1] = TopicType{0xf2, 0x6e, 0x77, 0x79}
 	var f WhisperFilterArgs
 	f.KeyName = keyname
 	f.Topics = topics[:]
@@ -523,8 +525,8 @@ func TestIntegrationSymWithFilter(t *testing.T) {
 	p.From = sig
 	p.Padding = []byte("test string")
 	p.Payload = []byte("extended test string")
-	p.PoW = whisperv5.MinimumPoW
-	p.Topic = whisperv5.TopicType{0xf2, 0x6e, 0x77, 0x79}
+	p.PoW = DefaultMinimumPoW
+	p.Topic = TopicType{0xf2, 0x6e, 0x77, 0x79}
 	p.WorkTime = 2
 
 	err = api.Post(p)
@@ -569,3 +571,98 @@ func TestIntegrationSymWithFilter(t *testing.T) {
 		t.Fatalf("failed to decrypt second message: %s.", text)
 	}
 }
+
+func TestKeyImportExport(t *testing.T) {
+	api := NewPublicWhisperAPI(New(nil))
+
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("failed to generate key: %s.", err)
+	}
+	id, err := api.AddPrivateKey(crypto.FromECDSA(key))
+	if err != nil {
+		t.Fatalf("failed AddPrivateKey: %s.", err)
+	}
+	if want := common.ToHex(crypto.FromECDSAPub(&key.PublicKey)); id != want {
+		t.Fatalf("identity mismatch: have %s, want %s.", id, want)
+	}
+	exported, err := api.GetPrivateKey(id)
+	if err != nil {
+		t.Fatalf("failed GetPrivateKey: %s.", err)
+	}
+	if !bytes.Equal(exported, crypto.FromECDSA(key)) {
+		t.Fatalf("exported private key mismatch.")
+	}
+	if _, err := api.AddPrivateKey([]byte{0x01, 0x02}); err == nil {
+		t.Fatalf("AddPrivateKey accepted an invalid key.")
+	}
+	if _, err := api.GetPrivateKey("0x0102"); err == nil {
+		t.Fatalf("GetPrivateKey exported an unknown identity.")
+	}
+
+	if err := api.AddSymKey("sym", []byte("some stuff here")); err != nil {
+		t.Fatalf("failed AddSymKey: %s.", err)
+	}
+	sym, err := api.GetSymKey("sym")
+	if err != nil {
+		t.Fatalf("failed GetSymKey: %s.", err)
+	}
+	if !bytes.Equal(sym, api.whisper.GetSymKey("sym")) {
+		t.Fatalf("exported symmetric key mismatch.")
+	}
+	if _, err := api.GetSymKey("unknown"); err == nil {
+		t.Fatalf("GetSymKey exported an unknown key.")
+	}
+}
+
+func TestSubscribeMessages(t *testing.T) {
+	api := NewPublicWhisperAPI(New(nil))
+	api.whisper.Start(nil)
+	defer api.whisper.Stop()
+
+	server := rpc.NewServer()
+	if err := server.RegisterName(ProtocolName, api); err != nil {
+		t.Fatalf("failed to register API: %s.", err)
+	}
+	defer server.Stop()
+	client := rpc.DialInProc(server)
+	defer client.Close()
+
+	keyname := "schluessel"
+	if err := api.GenerateSymKey(keyname); err != nil {
+		t.Fatalf("failed GenerateSymKey: %s.", err)
+	}
+	topic := TopicType{0xf2, 0x6e, 0x77, 0x79}
+	criteria := map[string]interface{}{
+		"keyname": keyname,
+		"topics":  []string{common.ToHex(topic[:])},
+	}
+	messages := make(chan WhisperMessage)
+	sub, err := client.Subscribe(context.Background(), ProtocolName, messages, "messages", criteria)
+	if err != nil {
+		t.Fatalf("failed to subscribe: %s.", err)
+	}
+	defer sub.Unsubscribe()
+
+	p := PostArgs{
+		TTL:      1,
+		KeyName:  keyname,
+		Payload:  []byte("subscribed test string"),
+		PoW:      DefaultMinimumPoW,
+		Topic:    topic,
+		WorkTime: 2,
+	}
+	if err := api.Post(p); err != nil {
+		t.Fatalf("failed to post message: %s.", err)
+	}
+	select {
+	case msg := <-messages:
+		if text := string(common.FromHex(msg.Payload)); text != "subscribed test string" {
+			t.Fatalf("failed to decrypt message: %s.", text)
+		}
+	case err := <-sub.Err():
+		t.Fatalf("subscription failed: %v.", err)
+	case <-time.After(5 * time.Second):
+		t.Fatalf("failed to receive message: timeout.")
+	}
+}
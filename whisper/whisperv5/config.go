@@ -0,0 +1,30 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package whisperv5
+
+// Config represents the configuration state of a whisper node.
+type Config struct {
+	MaxMessageSize     uint32  `json:"maxMessageSize"`     // Maximum size of the messages accepted and relayed
+	MinimumAcceptedPOW float64 `json:"minimumAcceptedPOW"` // Minimum PoW of the messages accepted and relayed
+	LightMode          bool    `json:"lightMode"`          // Only receive messages matching the filters, don't relay and use low PoW
+}
+
+// DefaultConfig contains the default settings of a whisper node.
+var DefaultConfig = Config{
+	MaxMessageSize:     DefaultMaxMessageSize,
+	MinimumAcceptedPOW: DefaultMinimumPoW,
+}
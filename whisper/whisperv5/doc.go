@@ -15,7 +15,10 @@
 // along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
 
 /*
-Package whisper implements the Whisper protocol (version 5).
+Package whisper implements the Whisper protocol (version 5), extended with the
+exchange of PoW requirements and topic bloom filters. As the extended status
+handshake is incompatible with plain version 5 peers, the protocol is advertised
+as shh/6.
 
 Whisper combines aspects of both DHTs and datagram messaging systems (e.g. UDP).
 As such it may be likened and compared to both, not dissimilar to the
@@ -36,14 +39,16 @@ import (
 
 const (
 	EnvelopeVersion    = uint64(0)
-	ProtocolVersion    = uint64(5)
-	ProtocolVersionStr = "5.0"
+	ProtocolVersion    = uint64(6)
+	ProtocolVersionStr = "6.0"
 	ProtocolName       = "shh"
 
 	statusCode           = 0 // used by whisper protocol
 	messagesCode         = 1 // normal whisper message
 	p2pCode              = 2 // peer-to-peer message (to be consumed by the peer, but not forwarded any further)
 	p2pRequestCode       = 3 // peer-to-peer message, used by Dapp protocol
+	powRequirementCode   = 4 // update of the minimum PoW the peer accepts
+	bloomFilterExCode    = 5 // update of the topics the peer is interested in
 	NumberOfMessageCodes = 64
 
 	paddingMask   = byte(3)
@@ -55,8 +60,12 @@ const (
 	saltLength        = 12
 	AESNonceMaxLength = 12
 
-	MaxMessageLength = 0xFFFF // todo: remove this restriction after testing. this should be regulated by PoW.
-	MinimumPoW       = 1.0    // todo: review after testing.
+	TopicBloomSize = 64 // size of the bloom filter advertising the topics of interest, in bytes
+
+	MaxMessageSize        = uint32(10 * 1024 * 1024) // maximum accepted size of a message, regardless of the configuration
+	DefaultMaxMessageSize = uint32(1024 * 1024)      // default maximum size of the messages accepted and relayed
+	DefaultMinimumPoW     = 1.0                      // default minimum PoW of the messages accepted and relayed
+	lightModeWorkTime     = 1                        // maximum time a light node spends sealing a message, in seconds
 
 	padSizeLimitLower = 128 // it can not be less - we don't want to reveal the absence of signature
 	padSizeLimitUpper = 256 // just an arbitrary number, could be changed without losing compatibility
@@ -64,6 +73,7 @@ const (
 
 	expirationCycle   = time.Second
 	transmissionCycle = 300 * time.Millisecond
+	subscriptionCycle = 250 * time.Millisecond
 
 	DefaultTTL     = 50 // seconds
 	SynchAllowance = 10 // seconds
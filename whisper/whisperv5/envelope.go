@@ -44,9 +44,10 @@ type Envelope struct {
 	Data     []byte
 	EnvNonce uint64
 
-	pow  float64     // Message-specific PoW as described in the Whisper specification.
-	hash common.Hash // Cached hash of the envelope to avoid rehashing every time.
-	// Don't access hash directly, use Hash() function instead.
+	pow   float64     // Message-specific PoW as described in the Whisper specification.
+	hash  common.Hash // Cached hash of the envelope to avoid rehashing every time.
+	bloom []byte      // Cached bloom filter of the envelope topic.
+	// Don't access hash and bloom directly, use Hash() and Bloom() instead.
 }
 
 // NewEnvelope wraps a Whisper message with expiration and destination data
@@ -166,6 +167,15 @@ func (e *Envelope) Hash() common.Hash {
 	return e.hash
 }
 
+// Bloom returns the bloom filter of the envelope topic, matched against the
+// topics of interest advertised by peers.
+func (e *Envelope) Bloom() []byte {
+	if e.bloom == nil {
+		e.bloom = TopicToBloom(e.Topic)
+	}
+	return e.bloom
+}
+
 // DecodeRLP decodes an Envelope from an RLP data stream.
 func (e *Envelope) DecodeRLP(s *rlp.Stream) error {
 	raw, err := s.Raw()
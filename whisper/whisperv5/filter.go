@@ -71,6 +71,23 @@ func (fs *Filters) Uninstall(id uint32) {
 	delete(fs.watchers, id)
 }
 
+// bloom returns the bloom filter of the topics the installed filters watch.
+func (fs *Filters) bloom() []byte {
+	fs.mutex.RLock()
+	defer fs.mutex.RUnlock()
+
+	bloom := make([]byte, TopicBloomSize)
+	for _, watcher := range fs.watchers {
+		if len(watcher.Topics) == 0 {
+			return MakeFullNodeBloom()
+		}
+		for _, topic := range watcher.Topics {
+			bloom = addBloom(bloom, TopicToBloom(topic))
+		}
+	}
+	return bloom
+}
+
 func (fs *Filters) Get(i uint32) *Filter {
 	fs.mutex.RLock()
 	defer fs.mutex.RUnlock()
@@ -0,0 +1,64 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package whisperv5
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// mailRequestLength is the size of an encoded mail request without the topic.
+const mailRequestLength = 8
+
+// MailRequest describes the archived envelopes a client asks a mail server to
+// deliver: all the ones sent within a time frame, optionally limited to a
+// single topic. It is carried as the payload of a request envelope encrypted
+// with the symmetric key shared with the server.
+type MailRequest struct {
+	Lower uint32    // Unix time of the oldest envelopes requested
+	Upper uint32    // Unix time of the newest envelopes requested
+	Topic TopicType // Topic of the envelopes requested, all topics if empty
+}
+
+// Bytes encodes the request into a message payload.
+func (r *MailRequest) Bytes() []byte {
+	payload := make([]byte, mailRequestLength, mailRequestLength+TopicLength)
+	binary.BigEndian.PutUint32(payload, r.Lower)
+	binary.BigEndian.PutUint32(payload[4:], r.Upper)
+	if r.Topic != (TopicType{}) {
+		payload = append(payload, r.Topic[:]...)
+	}
+	return payload
+}
+
+// DecodeMailRequest parses a request from a message payload.
+func DecodeMailRequest(payload []byte) (*MailRequest, error) {
+	if len(payload) < mailRequestLength {
+		return nil, fmt.Errorf("undersized mail request (%d bytes)", len(payload))
+	}
+	r := &MailRequest{
+		Lower: binary.BigEndian.Uint32(payload),
+		Upper: binary.BigEndian.Uint32(payload[4:]),
+	}
+	if len(payload) >= mailRequestLength+TopicLength {
+		r.Topic = BytesToTopic(payload[mailRequestLength:])
+	}
+	if r.Lower > r.Upper {
+		return nil, fmt.Errorf("invalid mail request time frame [%d, %d]", r.Lower, r.Upper)
+	}
+	return r, nil
+}
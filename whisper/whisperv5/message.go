@@ -236,8 +236,8 @@ func (msg *SentMessage) Wrap(options *MessageParams) (envelope *Envelope, err er
 			return nil, err
 		}
 	}
-	if len(msg.Raw) > MaxMessageLength {
-		glog.V(logger.Error).Infof("Message size must not exceed %d bytes", MaxMessageLength)
+	if uint32(len(msg.Raw)) > MaxMessageSize {
+		glog.V(logger.Error).Infof("Message size must not exceed %d bytes", MaxMessageSize)
 		return nil, errors.New("Oversized message")
 	}
 	var salt, nonce []byte
@@ -18,6 +18,8 @@ package whisperv5
 
 import (
 	"fmt"
+	"math"
+	"sync"
 	"time"
 
 	"github.com/EarthDollar/go-earthdollar/common"
@@ -35,6 +37,10 @@ type Peer struct {
 	ws      p2p.MsgReadWriter
 	trusted bool
 
+	powRequirement float64 // Minimum PoW of the envelopes the peer accepts
+	bloomFilter    []byte  // Topics the peer is interested in, nil if all
+	settingsMu     sync.RWMutex
+
 	known *set.Set // Messages already known by the peer to avoid wasting bandwidth
 
 	quit chan struct{}
@@ -66,12 +72,14 @@ func (p *Peer) stop() {
 }
 
 // handshake sends the protocol initiation status message to the remote peer and
-// verifies the remote status too.
+// verifies the remote status too. Besides the protocol version, the status
+// carries the minimum PoW and the bloom filter of the topics each side accepts.
 func (p *Peer) handshake() error {
 	// Send the handshake status message asynchronously
 	errc := make(chan error, 1)
 	go func() {
-		errc <- p2p.Send(p.ws, statusCode, ProtocolVersion)
+		pow := math.Float64bits(p.host.MinPow())
+		errc <- p2p.Send(p.ws, statusCode, []interface{}{ProtocolVersion, pow, p.host.BloomFilter()})
 	}()
 	// Fetch the remote status packet and verify protocol match
 	packet, err := p.ws.ReadMsg()
@@ -82,6 +90,9 @@ func (p *Peer) handshake() error {
 		return fmt.Errorf("peer sent %x before status packet", packet.Code)
 	}
 	s := rlp.NewStream(packet.Payload, uint64(packet.Size))
+	if _, err := s.List(); err != nil {
+		return fmt.Errorf("bad status message: %v", err)
+	}
 	peerVersion, err := s.Uint()
 	if err != nil {
 		return fmt.Errorf("bad status message: %v", err)
@@ -89,6 +100,20 @@ func (p *Peer) handshake() error {
 	if peerVersion != ProtocolVersion {
 		return fmt.Errorf("protocol version mismatch %d != %d", peerVersion, ProtocolVersion)
 	}
+	bits, err := s.Uint()
+	if err != nil {
+		return fmt.Errorf("bad status message: %v", err)
+	}
+	if err := p.setPoWRequirement(math.Float64frombits(bits)); err != nil {
+		return err
+	}
+	bloom, err := s.Bytes()
+	if err != nil {
+		return fmt.Errorf("bad status message: %v", err)
+	}
+	if err := p.setBloomFilter(bloom); err != nil {
+		return err
+	}
 	// Wait until out own status is consumed too
 	if err := <-errc; err != nil {
 		return fmt.Errorf("failed to send status packet: %v", err)
@@ -121,6 +146,36 @@ func (p *Peer) update() {
 	}
 }
 
+// setPoWRequirement updates the minimum PoW of the envelopes the peer accepts.
+func (p *Peer) setPoWRequirement(pow float64) error {
+	if math.IsInf(pow, 0) || math.IsNaN(pow) || pow < 0 {
+		return fmt.Errorf("invalid PoW requirement %v", pow)
+	}
+	p.settingsMu.Lock()
+	p.powRequirement = pow
+	p.settingsMu.Unlock()
+	return nil
+}
+
+// setBloomFilter updates the topics the peer is interested in.
+func (p *Peer) setBloomFilter(bloom []byte) error {
+	if len(bloom) != TopicBloomSize {
+		return fmt.Errorf("invalid bloom filter size %d", len(bloom))
+	}
+	p.settingsMu.Lock()
+	p.bloomFilter = bloom
+	p.settingsMu.Unlock()
+	return nil
+}
+
+// wants reports whether the envelope satisfies the requirements of the peer.
+func (p *Peer) wants(envelope *Envelope) bool {
+	p.settingsMu.RLock()
+	defer p.settingsMu.RUnlock()
+
+	return envelope.PoW() >= p.powRequirement && bloomFilterMatch(p.bloomFilter, envelope.Bloom())
+}
+
 // mark marks an envelope known to the peer so that it won't be sent back.
 func (peer *Peer) mark(envelope *Envelope) {
 	peer.known.Add(envelope.Hash())
@@ -157,10 +212,10 @@ func (peer *Peer) expire() {
 // ones over the network.
 func (p *Peer) broadcast() error {
 	// Fetch the envelopes and collect the unknown ones
-	envelopes := p.host.Envelopes()
+	envelopes := p.host.outbound()
 	transmit := make([]*Envelope, 0, len(envelopes))
 	for _, envelope := range envelopes {
-		if !p.marked(envelope) {
+		if !p.marked(envelope) && p.wants(envelope) {
 			transmit = append(transmit, envelope)
 			p.mark(envelope)
 		}
@@ -116,8 +116,7 @@ func initialize(t *testing.T) {
 
 	for i := 0; i < NumNodes; i++ {
 		var node TestNode
-		node.shh = NewWhisper(nil)
-		node.shh.test = true
+		node.shh = New(&Config{MaxMessageSize: DefaultMaxMessageSize, MinimumAcceptedPOW: 0})
 		node.shh.Start(nil)
 		topics := make([]TopicType, 0)
 		topics = append(topics, sharedTopic)
@@ -297,3 +296,58 @@ func TestPeerBasic(t *testing.T) {
 		t.Fatalf("failed mark with seed %d.", seed)
 	}
 }
+
+func TestPeerRequirements(t *testing.T) {
+	InitSingleTest()
+
+	params, err := generateMessageParams()
+	if err != nil {
+		t.Fatalf("failed generateMessageParams with seed %d.", seed)
+	}
+
+	params.PoW = 0.001
+	msg := NewSentMessage(params)
+	env, err := msg.Wrap(params)
+	if err != nil {
+		t.Fatalf("failed Wrap with seed %d.", seed)
+	}
+
+	p := newPeer(nil, nil, nil)
+	if !p.wants(env) {
+		t.Fatalf("peer without requirements refused envelope with seed %d.", seed)
+	}
+	if err := p.setPoWRequirement(env.PoW() * 2); err != nil {
+		t.Fatalf("failed to set PoW requirement: %v.", err)
+	}
+	if p.wants(env) {
+		t.Fatalf("peer accepted envelope with insufficient PoW with seed %d.", seed)
+	}
+	if err := p.setPoWRequirement(0); err != nil {
+		t.Fatalf("failed to reset PoW requirement: %v.", err)
+	}
+	if err := p.setPoWRequirement(-1); err == nil {
+		t.Fatalf("negative PoW requirement accepted.")
+	}
+
+	other := env.Topic
+	other[0]++
+	if err := p.setBloomFilter(TopicToBloom(other)); err != nil {
+		t.Fatalf("failed to set bloom filter: %v.", err)
+	}
+	if bloomFilterMatch(p.bloomFilter, env.Bloom()) {
+		// the topics collide in the bloom filter, nothing to check
+		return
+	}
+	if p.wants(env) {
+		t.Fatalf("peer accepted envelope with unwanted topic with seed %d.", seed)
+	}
+	if err := p.setBloomFilter(addBloom(TopicToBloom(other), env.Bloom())); err != nil {
+		t.Fatalf("failed to set bloom filter: %v.", err)
+	}
+	if !p.wants(env) {
+		t.Fatalf("peer refused envelope with wanted topic with seed %d.", seed)
+	}
+	if err := p.setBloomFilter(make([]byte, TopicBloomSize-1)); err == nil {
+		t.Fatalf("bloom filter of invalid size accepted.")
+	}
+}
@@ -67,3 +67,51 @@ func (t *TopicType) UnmarshalJSON(input []byte) error {
 	*t = BytesToTopic(b)
 	return nil
 }
+
+// TopicToBloom converts the topic (4 bytes) to a bloom filter, setting three of
+// its 512 bits: each of the first three topic bytes selects a bit within one
+// half of the filter, the respective bit of the fourth byte choosing the half.
+func TopicToBloom(topic TopicType) []byte {
+	bloom := make([]byte, TopicBloomSize)
+	for j := 0; j < 3; j++ {
+		index := int(topic[j])
+		if topic[3]&(1<<uint(j)) != 0 {
+			index += 256
+		}
+		bloom[index/8] |= 1 << uint(index%8)
+	}
+	return bloom
+}
+
+// MakeFullNodeBloom returns a bloom filter matching all topics, advertised by
+// nodes interested in (i.e. relaying) all the messages.
+func MakeFullNodeBloom() []byte {
+	bloom := make([]byte, TopicBloomSize)
+	for i := range bloom {
+		bloom[i] = 0xff
+	}
+	return bloom
+}
+
+// bloomFilterMatch reports whether all the bits set in sample are also set in
+// filter. A missing filter matches everything.
+func bloomFilterMatch(filter, sample []byte) bool {
+	if filter == nil {
+		return true
+	}
+	for i := 0; i < TopicBloomSize; i++ {
+		if filter[i]&sample[i] != sample[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// addBloom returns the union of two bloom filters.
+func addBloom(a, b []byte) []byte {
+	union := make([]byte, TopicBloomSize)
+	for i := 0; i < TopicBloomSize; i++ {
+		union[i] = a[i] | b[i]
+	}
+	return union
+}
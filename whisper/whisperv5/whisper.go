@@ -21,7 +21,9 @@ import (
 	"crypto/ecdsa"
 	crand "crypto/rand"
 	"crypto/sha256"
+	"errors"
 	"fmt"
+	"math"
 	"runtime"
 	"sync"
 	"time"
@@ -32,6 +34,7 @@ import (
 	"github.com/EarthDollar/go-earthdollar/logger/glog"
 	"github.com/EarthDollar/go-earthdollar/p2p"
 	"github.com/EarthDollar/go-earthdollar/rlp"
+	"github.com/EarthDollar/go-earthdollar/rpc"
 	"golang.org/x/crypto/pbkdf2"
 	set "gopkg.in/fatih/set.v0"
 )
@@ -47,6 +50,7 @@ type Whisper struct {
 	keyMu       sync.RWMutex
 
 	envelopes   map[common.Hash]*Envelope        // Pool of envelopes currently tracked by this node
+	local       map[common.Hash]struct{}         // Envelopes of the pool sent by this node
 	messages    map[common.Hash]*ReceivedMessage // Pool of successfully decrypted messages, which are not expired yet
 	expirations map[uint32]*set.SetNonTS         // Message expiration pool
 	poolMu      sync.RWMutex                     // Mutex to sync the message and expiration pools
@@ -56,6 +60,12 @@ type Whisper struct {
 
 	mailServer MailServer
 
+	minPoW      float64 // Minimum PoW of the envelopes accepted and relayed
+	maxMsgSize  uint32  // Maximum size of the envelopes accepted and relayed
+	bloomFilter []byte  // Bloom filter of the topics this node is interested in
+	lightMode   bool    // Whether the node only receives and sends its own envelopes
+	settingsMu  sync.RWMutex
+
 	messageQueue chan *Envelope
 	p2pMsgQueue  chan *Envelope
 	quit         chan struct{}
@@ -64,22 +74,35 @@ type Whisper struct {
 	test     bool
 }
 
-// New creates a Whisper client ready to communicate through the Ethereum P2P network.
-// Param s should be passed if you want to implement mail server, otherwise nil.
-func NewWhisper(server MailServer) *Whisper {
+// New creates a Whisper client ready to communicate through the Ethereum P2P
+// network, using the default configuration if none is given.
+func New(config *Config) *Whisper {
+	if config == nil {
+		config = &DefaultConfig
+	}
 	whisper := &Whisper{
 		privateKeys:  make(map[string]*ecdsa.PrivateKey),
 		symKeys:      make(map[string][]byte),
 		envelopes:    make(map[common.Hash]*Envelope),
+		local:        make(map[common.Hash]struct{}),
 		messages:     make(map[common.Hash]*ReceivedMessage),
 		expirations:  make(map[uint32]*set.SetNonTS),
 		peers:        make(map[*Peer]struct{}),
-		mailServer:   server,
+		minPoW:       config.MinimumAcceptedPOW,
+		maxMsgSize:   config.MaxMessageSize,
+		bloomFilter:  MakeFullNodeBloom(),
+		lightMode:    config.LightMode,
 		messageQueue: make(chan *Envelope, messageQueueLimit),
 		p2pMsgQueue:  make(chan *Envelope, messageQueueLimit),
 		quit:         make(chan struct{}),
 	}
+	if whisper.maxMsgSize == 0 || whisper.maxMsgSize > MaxMessageSize {
+		whisper.maxMsgSize = MaxMessageSize
+	}
 	whisper.filters = NewFilters(whisper)
+	if whisper.lightMode {
+		whisper.bloomFilter = whisper.filters.bloom()
+	}
 
 	// p2p whisper sub protocol handler
 	whisper.protocol = p2p.Protocol{
@@ -92,16 +115,117 @@ func NewWhisper(server MailServer) *Whisper {
 	return whisper
 }
 
+// RegisterServer registers a mail server, archiving the envelopes seen by this
+// node and delivering them to peers on request. It must be called before the
+// node is started.
+func (w *Whisper) RegisterServer(server MailServer) {
+	w.mailServer = server
+}
+
 // Protocols returns the whisper sub-protocols ran by this particular client.
 func (w *Whisper) Protocols() []p2p.Protocol {
 	return []p2p.Protocol{w.protocol}
 }
 
+// APIs returns the RPC descriptors the Whisper implementation offers.
+func (w *Whisper) APIs() []rpc.API {
+	return []rpc.API{
+		{
+			Namespace: ProtocolName,
+			Version:   ProtocolVersionStr,
+			Service:   NewPublicWhisperAPI(w),
+			Public:    true,
+		},
+	}
+}
+
 // Version returns the whisper sub-protocols version number.
 func (w *Whisper) Version() uint {
 	return w.protocol.Version
 }
 
+// MinPow returns the minimum PoW of the envelopes accepted by this node.
+func (w *Whisper) MinPow() float64 {
+	w.settingsMu.RLock()
+	defer w.settingsMu.RUnlock()
+	return w.minPoW
+}
+
+// MaxMessageSize returns the maximum size of the envelopes accepted by this node.
+func (w *Whisper) MaxMessageSize() uint32 {
+	w.settingsMu.RLock()
+	defer w.settingsMu.RUnlock()
+	return w.maxMsgSize
+}
+
+// LightMode reports whether the node runs in light mode: it only advertises the
+// topics of its filters, doesn't relay the envelopes of other nodes and seals
+// its own with the lowest PoW its peers accept.
+func (w *Whisper) LightMode() bool {
+	return w.lightMode
+}
+
+// BloomFilter returns the bloom filter of the topics this node advertises its
+// interest in.
+func (w *Whisper) BloomFilter() []byte {
+	w.settingsMu.RLock()
+	defer w.settingsMu.RUnlock()
+	return w.bloomFilter
+}
+
+// SetMaxMessageSize sets the maximum size of the envelopes accepted by this node.
+func (w *Whisper) SetMaxMessageSize(size uint32) error {
+	if size == 0 || size > MaxMessageSize {
+		return fmt.Errorf("message size must be between 1 and %d bytes", MaxMessageSize)
+	}
+	w.settingsMu.Lock()
+	w.maxMsgSize = size
+	w.settingsMu.Unlock()
+	return nil
+}
+
+// SetMinimumPoW sets the minimum PoW of the envelopes accepted by this node,
+// notifying the peers not to send any below it.
+func (w *Whisper) SetMinimumPoW(pow float64) error {
+	if pow < 0 {
+		return errors.New("invalid PoW requirement")
+	}
+	w.settingsMu.Lock()
+	w.minPoW = pow
+	w.settingsMu.Unlock()
+
+	w.notifyPeers(powRequirementCode, math.Float64bits(pow))
+	return nil
+}
+
+// SetBloomFilter sets the bloom filter of the topics this node advertises its
+// interest in, notifying the peers to only send matching envelopes.
+func (w *Whisper) SetBloomFilter(bloom []byte) error {
+	if len(bloom) != TopicBloomSize {
+		return fmt.Errorf("invalid bloom filter size %d, want %d", len(bloom), TopicBloomSize)
+	}
+	bloom = common.CopyBytes(bloom)
+
+	w.settingsMu.Lock()
+	w.bloomFilter = bloom
+	w.settingsMu.Unlock()
+
+	w.notifyPeers(bloomFilterExCode, bloom)
+	return nil
+}
+
+// notifyPeers sends a settings update to all the connected peers.
+func (w *Whisper) notifyPeers(code uint64, data interface{}) {
+	w.peerMu.RLock()
+	defer w.peerMu.RUnlock()
+
+	for p := range w.peers {
+		if err := p2p.Send(p.ws, code, data); err != nil {
+			glog.V(logger.Debug).Infof("%v: failed to send settings update: %v", p.peer, err)
+		}
+	}
+}
+
 func (w *Whisper) getPeer(peerID []byte) (*Peer, error) {
 	w.peerMu.Lock()
 	defer w.peerMu.Unlock()
@@ -134,12 +258,41 @@ func (w *Whisper) RequestHistoricMessages(peerID []byte, data []byte) error {
 	return p2p.Send(p.ws, p2pRequestCode, data)
 }
 
+// RequestMail asks a mail server peer to deliver the envelopes it archived that
+// match the request. The request is encrypted with the symmetric key shared with
+// the server, sealed with the given PoW. Delivered envelopes are passed to the
+// filters accepting peer-to-peer messages.
+func (w *Whisper) RequestMail(peerID []byte, key []byte, req *MailRequest, pow float64, workTime uint32) error {
+	params := &MessageParams{
+		KeySym:   key,
+		Topic:    req.Topic,
+		Payload:  req.Bytes(),
+		PoW:      pow,
+		WorkTime: workTime,
+	}
+	envelope, err := NewSentMessage(params).Wrap(params)
+	if err != nil {
+		return err
+	}
+	data, err := rlp.EncodeToBytes(envelope)
+	if err != nil {
+		return err
+	}
+	return w.RequestHistoricMessages(peerID, data)
+}
+
 func (w *Whisper) SendP2PMessage(peerID []byte, envelope *Envelope) error {
 	p, err := w.getPeer(peerID)
 	if err != nil {
 		return err
 	}
-	return p2p.Send(p.ws, p2pCode, envelope)
+	return w.SendP2PDirect(p, envelope)
+}
+
+// SendP2PDirect sends envelopes directly to a peer, bypassing the expiry and PoW
+// checks. The peer only accepts them if it marked this node trusted.
+func (w *Whisper) SendP2PDirect(peer *Peer, envelopes ...*Envelope) error {
+	return p2p.Send(peer.ws, p2pCode, envelopes)
 }
 
 // NewIdentity generates a new cryptographic identity for the client, and injects
@@ -161,6 +314,17 @@ func (w *Whisper) NewIdentity() *ecdsa.PrivateKey {
 	return key
 }
 
+// AddIdentity injects an existing private key into the known identities for
+// message decryption, returning its public key.
+func (w *Whisper) AddIdentity(key *ecdsa.PrivateKey) string {
+	id := common.ToHex(crypto.FromECDSAPub(&key.PublicKey))
+
+	w.keyMu.Lock()
+	defer w.keyMu.Unlock()
+	w.privateKeys[id] = key
+	return id
+}
+
 // DeleteIdentity deletes the specified key if it exists.
 func (w *Whisper) DeleteIdentity(key string) {
 	w.keyMu.Lock()
@@ -254,7 +418,9 @@ func (w *Whisper) GetSymKey(name string) []byte {
 // Watch installs a new message handler to run in case a matching packet arrives
 // from the whisper network.
 func (w *Whisper) Watch(f *Filter) uint32 {
-	return w.filters.Install(f)
+	id := w.filters.Install(f)
+	w.updateBloomFilter()
+	return id
 }
 
 func (w *Whisper) GetFilter(id uint32) *Filter {
@@ -264,12 +430,52 @@ func (w *Whisper) GetFilter(id uint32) *Filter {
 // Unwatch removes an installed message handler.
 func (w *Whisper) Unwatch(id uint32) {
 	w.filters.Uninstall(id)
+	w.updateBloomFilter()
+}
+
+// updateBloomFilter recomputes the topics a light node is interested in from
+// its filters, announcing them to the peers.
+func (w *Whisper) updateBloomFilter() {
+	if w.lightMode {
+		w.SetBloomFilter(w.filters.bloom())
+	}
 }
 
 // Send injects a message into the whisper send queue, to be distributed in the
 // network in the coming cycles.
 func (w *Whisper) Send(envelope *Envelope) error {
-	return w.add(envelope)
+	return w.add(envelope, true)
+}
+
+// peersMinPow returns the lowest PoW requirement among the connected peers.
+func (w *Whisper) peersMinPow() float64 {
+	w.peerMu.RLock()
+	defer w.peerMu.RUnlock()
+
+	pow := math.MaxFloat64
+	for p := range w.peers {
+		p.settingsMu.RLock()
+		pow = math.Min(pow, p.powRequirement)
+		p.settingsMu.RUnlock()
+	}
+	if pow == math.MaxFloat64 {
+		return 0
+	}
+	return pow
+}
+
+// lightParams lowers the PoW of an outgoing message of a light node to what the
+// least demanding peer requires, capping the time spent sealing it.
+func (w *Whisper) lightParams(params *MessageParams) {
+	if !w.lightMode {
+		return
+	}
+	params.PoW = math.Min(params.PoW, w.peersMinPow())
+	if params.PoW == 0 {
+		params.WorkTime = 0
+	} else if params.WorkTime > lightModeWorkTime {
+		params.WorkTime = lightModeWorkTime
+	}
 }
 
 // Start implements node.Service, starting the background data propagation thread
@@ -328,6 +534,10 @@ func (wh *Whisper) runMessageLoop(p *Peer, rw p2p.MsgReadWriter) error {
 		if err != nil {
 			return err
 		}
+		if packet.Size > wh.MaxMessageSize() {
+			glog.V(logger.Warn).Infof("%v: oversized message received (%d bytes), peer will be disconnected", p.peer, packet.Size)
+			return fmt.Errorf("oversized message received")
+		}
 
 		switch packet.Code {
 		case statusCode:
@@ -342,7 +552,13 @@ func (wh *Whisper) runMessageLoop(p *Peer, rw p2p.MsgReadWriter) error {
 			}
 			// inject all envelopes into the internal pool
 			for _, envelope := range envelopes {
-				if err := wh.add(envelope); err != nil {
+				// The filter might have changed recently, don't punish the peer
+				if !bloomFilterMatch(wh.BloomFilter(), envelope.Bloom()) {
+					glog.V(logger.Detail).Infof("%v: envelope not matching the bloom filter dropped [%x]", p.peer, envelope.Hash())
+					p.mark(envelope)
+					continue
+				}
+				if err := wh.add(envelope, false); err != nil {
 					glog.V(logger.Warn).Infof("%v: bad envelope received: [%v], peer will be disconnected", p.peer, err)
 					return fmt.Errorf("invalid envelope")
 				}
@@ -366,6 +582,24 @@ func (wh *Whisper) runMessageLoop(p *Peer, rw p2p.MsgReadWriter) error {
 					wh.postEvent(envelope, true)
 				}
 			}
+		case powRequirementCode:
+			var bits uint64
+			if err := packet.Decode(&bits); err != nil {
+				glog.V(logger.Warn).Infof("%v: failed to decode PoW requirement: [%v], peer will be disconnected", p.peer, err)
+				return fmt.Errorf("garbage received (powRequirement)")
+			}
+			if err := p.setPoWRequirement(math.Float64frombits(bits)); err != nil {
+				return err
+			}
+		case bloomFilterExCode:
+			var bloom []byte
+			if err := packet.Decode(&bloom); err != nil {
+				glog.V(logger.Warn).Infof("%v: failed to decode bloom filter: [%v], peer will be disconnected", p.peer, err)
+				return fmt.Errorf("garbage received (bloomFilter)")
+			}
+			if err := p.setBloomFilter(bloom); err != nil {
+				return err
+			}
 		case p2pRequestCode:
 			// Must be processed if mail server is implemented. Otherwise ignore.
 			if wh.mailServer != nil {
@@ -388,8 +622,9 @@ func (wh *Whisper) runMessageLoop(p *Peer, rw p2p.MsgReadWriter) error {
 
 // add inserts a new envelope into the message pool to be distributed within the
 // whisper network. It also inserts the envelope into the expiration pool at the
-// appropriate time-stamp. In case of error, connection should be dropped.
-func (wh *Whisper) add(envelope *Envelope) error {
+// appropriate time-stamp. Local envelopes are the ones sent by this node. In
+// case of error, connection should be dropped.
+func (wh *Whisper) add(envelope *Envelope, local bool) error {
 	now := uint32(time.Now().Unix())
 	sent := envelope.Expiry - envelope.TTL
 
@@ -411,7 +646,7 @@ func (wh *Whisper) add(envelope *Envelope) error {
 		}
 	}
 
-	if len(envelope.Data) > MaxMessageLength {
+	if uint32(len(envelope.Data)) > wh.MaxMessageSize() {
 		return fmt.Errorf("huge messages are not allowed [%x]", envelope.Hash())
 	}
 
@@ -429,7 +664,9 @@ func (wh *Whisper) add(envelope *Envelope) error {
 		return fmt.Errorf("oversized salt [%x]", envelope.Hash())
 	}
 
-	if envelope.PoW() < MinimumPoW && !wh.test {
+	// The requirements might have changed recently, so peers are not punished
+	// for violating them, the envelopes are just not accepted
+	if envelope.PoW() < wh.MinPow() && !wh.test && !(local && wh.lightMode) {
 		glog.V(logger.Debug).Infof("envelope with low PoW dropped: %f [%x]", envelope.PoW(), envelope.Hash())
 		return nil // drop envelope without error
 	}
@@ -447,6 +684,9 @@ func (wh *Whisper) add(envelope *Envelope) error {
 			wh.expirations[envelope.Expiry].Add(hash)
 		}
 	}
+	if local {
+		wh.local[hash] = struct{}{}
+	}
 	wh.poolMu.Unlock()
 
 	if alreadyCached {
@@ -539,6 +779,7 @@ func (w *Whisper) expire() {
 		// Dump all expired messages and remove timestamp
 		hashSet.Each(func(v interface{}) bool {
 			delete(w.envelopes, v.(common.Hash))
+			delete(w.local, v.(common.Hash))
 			delete(w.messages, v.(common.Hash))
 			return true
 		})
@@ -558,6 +799,22 @@ func (w *Whisper) Envelopes() []*Envelope {
 	return all
 }
 
+// outbound retrieves the pooled envelopes to be propagated to the peers: all of
+// them for a full node, only the ones sent by a light node itself.
+func (w *Whisper) outbound() []*Envelope {
+	if !w.lightMode {
+		return w.Envelopes()
+	}
+	w.poolMu.RLock()
+	defer w.poolMu.RUnlock()
+
+	all := make([]*Envelope, 0, len(w.local))
+	for hash := range w.local {
+		all = append(all, w.envelopes[hash])
+	}
+	return all
+}
+
 // Messages retrieves all the decrypted messages matching a filter id.
 func (w *Whisper) Messages(id uint32) []*ReceivedMessage {
 	result := make([]*ReceivedMessage, 0)
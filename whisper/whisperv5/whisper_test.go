@@ -26,7 +26,7 @@ import (
 )
 
 func TestWhisperBasic(t *testing.T) {
-	w := NewWhisper(nil)
+	w := New(nil)
 	p := w.Protocols()
 	shh := p[0]
 	if shh.Name != ProtocolName {
@@ -116,7 +116,7 @@ func TestWhisperBasic(t *testing.T) {
 }
 
 func TestWhisperIdentityManagement(t *testing.T) {
-	w := NewWhisper(nil)
+	w := New(nil)
 	id1 := w.NewIdentity()
 	id2 := w.NewIdentity()
 	pub1 := common.ToHex(crypto.FromECDSAPub(&id1.PublicKey))
@@ -192,7 +192,7 @@ func TestWhisperSymKeyManagement(t *testing.T) {
 	InitSingleTest()
 
 	var k1, k2 []byte
-	w := NewWhisper(nil)
+	w := New(nil)
 	id1 := string("arbitrary-string-1")
 	id2 := string("arbitrary-string-2")
 
@@ -310,7 +310,7 @@ func TestWhisperSymKeyManagement(t *testing.T) {
 func TestExpiry(t *testing.T) {
 	InitSingleTest()
 
-	w := NewWhisper(nil)
+	w := New(nil)
 	w.test = true
 	w.Start(nil)
 	defer w.Stop()
@@ -359,3 +359,60 @@ func TestExpiry(t *testing.T) {
 		t.Fatalf("expire failed, seed: %d.", seed)
 	}
 }
+
+func TestLightMode(t *testing.T) {
+	InitSingleTest()
+
+	w := New(&Config{MaxMessageSize: DefaultMaxMessageSize, MinimumAcceptedPOW: DefaultMinimumPoW, LightMode: true})
+	if !w.LightMode() {
+		t.Fatalf("light mode not enabled.")
+	}
+	if !bytes.Equal(w.BloomFilter(), make([]byte, TopicBloomSize)) {
+		t.Fatalf("light node without filters advertises topics: %x.", w.BloomFilter())
+	}
+
+	params, err := generateMessageParams()
+	if err != nil {
+		t.Fatalf("failed generateMessageParams with seed %d: %s.", seed, err)
+	}
+	id := w.Watch(&Filter{KeySym: params.KeySym, Topics: []TopicType{params.Topic}})
+	if !bloomFilterMatch(w.BloomFilter(), TopicToBloom(params.Topic)) {
+		t.Fatalf("light node does not advertise the topic of its filter, seed %d.", seed)
+	}
+	w.Unwatch(id)
+	if !bytes.Equal(w.BloomFilter(), make([]byte, TopicBloomSize)) {
+		t.Fatalf("light node advertises topics of removed filter, seed %d.", seed)
+	}
+
+	// Without peers to satisfy, no work is done on outgoing messages
+	params.PoW, params.WorkTime = 10, 5
+	w.lightParams(params)
+	if params.PoW != 0 || params.WorkTime != 0 {
+		t.Fatalf("light params not lowered: pow %f, work time %d.", params.PoW, params.WorkTime)
+	}
+
+	// Own envelopes are relayed regardless of their PoW, foreign ones never
+	own, err := NewSentMessage(params).Wrap(params)
+	if err != nil {
+		t.Fatalf("failed Wrap with seed %d: %s.", seed, err)
+	}
+	if err := w.Send(own); err != nil {
+		t.Fatalf("failed to send envelope with seed %d: %s.", seed, err)
+	}
+	params.PoW, params.WorkTime = 0.001, 1
+	foreign, err := NewSentMessage(params).Wrap(params)
+	if err != nil {
+		t.Fatalf("failed Wrap with seed %d: %s.", seed, err)
+	}
+	w.test = true
+	if err := w.add(foreign, false); err != nil {
+		t.Fatalf("failed to add envelope with seed %d: %s.", seed, err)
+	}
+	if len(w.Envelopes()) != 2 {
+		t.Fatalf("pool size mismatch: have %d, want 2.", len(w.Envelopes()))
+	}
+	outbound := w.outbound()
+	if len(outbound) != 1 || outbound[0].Hash() != own.Hash() {
+		t.Fatalf("light node relays foreign envelopes.")
+	}
+}